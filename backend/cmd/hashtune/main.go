@@ -0,0 +1,47 @@
+// Command hashtune benchmarks password hashing on this machine and prints the
+// PASSWORD_* settings that keep one hash within a target time. Run it on the
+// hardware the server runs on:
+//
+//	go run ./cmd/hashtune -target 250ms -memory 65536 -parallelism 2
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"base-app-service/pkg/auth"
+)
+
+func main() {
+	target := flag.Duration("target", 250*time.Millisecond, "longest acceptable time for one hash")
+	algorithm := flag.String("algorithm", auth.HashArgon2id, "argon2id or bcrypt")
+	memory := flag.Uint("memory", uint(auth.DefaultHashParams.Argon2Memory), "argon2id memory in KiB")
+	parallelism := flag.Uint("parallelism", uint(auth.DefaultHashParams.Argon2Parallelism), "argon2id lanes")
+	flag.Parse()
+
+	switch *algorithm {
+	case auth.HashArgon2id:
+		params, took := auth.TuneArgon2id(*target, uint32(*memory), uint8(*parallelism))
+		if took > *target {
+			fmt.Fprintf(os.Stderr, "warning: even one iteration takes %s at %d KiB; lower -memory\n", took, params.Argon2Memory)
+		}
+		fmt.Printf("# one hash takes %s\n", took.Round(time.Millisecond))
+		fmt.Printf("PASSWORD_HASH_ALGORITHM=%s\n", auth.HashArgon2id)
+		fmt.Printf("PASSWORD_ARGON2_MEMORY=%d\n", params.Argon2Memory)
+		fmt.Printf("PASSWORD_ARGON2_ITERATIONS=%d\n", params.Argon2Iterations)
+		fmt.Printf("PASSWORD_ARGON2_PARALLELISM=%d\n", params.Argon2Parallelism)
+	case auth.HashBcrypt:
+		params, took := auth.TuneBcrypt(*target)
+		if took > *target {
+			fmt.Fprintf(os.Stderr, "warning: the minimum bcrypt cost takes %s\n", took)
+		}
+		fmt.Printf("# one hash takes %s\n", took.Round(time.Millisecond))
+		fmt.Printf("PASSWORD_HASH_ALGORITHM=%s\n", auth.HashBcrypt)
+		fmt.Printf("PASSWORD_BCRYPT_COST=%d\n", params.BcryptCost)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown algorithm %q\n", *algorithm)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/auth"
+)
+
+const commandUsage = `usage:
+  server                                   start the API server
+  server admin create -email EMAIL -name NAME [-password PASSWORD]
+                                           create an admin account; the password
+                                           is read from stdin when not given`
+
+// runCommand runs a subcommand against the configured database and returns
+// the process exit code.
+func runCommand(args []string) int {
+	if len(args) >= 2 && args[0] == "admin" && args[1] == "create" {
+		return runAdminCreate(args[2:], os.Stdin)
+	}
+	fmt.Fprintln(os.Stderr, commandUsage)
+	return 2
+}
+
+// runAdminCreate adds an admin without going through setup, for headless
+// deploys and recovering access when no admin can sign in.
+func runAdminCreate(args []string, stdin io.Reader) int {
+	flags := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	email := flags.String("email", "", "email address of the admin")
+	name := flags.String("name", "", "display name of the admin")
+	password := flags.String("password", "", "password; read from stdin when empty")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *email == "" || *name == "" {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+	if *password == "" {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			fmt.Fprintf(os.Stderr, "failed to read password: %v\n", err)
+			return 1
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+	logger := zap.NewNop()
+	if err := auth.SetHashParams(passwordHashParams(cfg)); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid password hash settings: %v\n", err)
+		return 1
+	}
+	db, err := openDatabase(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	breachedPasswords, err := auth.OpenBreachedPasswords(cfg.Passwords.BreachedCorpusPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load breached password corpus: %v\n", err)
+		return 1
+	}
+	defer breachedPasswords.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	activityLogService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	systemSettingsService := services.NewSystemSettingsService(repositories.NewSystemSettingsRepository(db), activityLogService, logger)
+	passwordPolicyService := services.NewPasswordPolicyService(
+		systemSettingsService, userRepo, repositories.NewPasswordHistoryRepository(db), breachedPasswords, activityLogService, logger,
+	)
+	setupService := services.NewSetupService(cfg.Setup, userRepo, nil, passwordPolicyService, activityLogService, logger)
+
+	admin, err := setupService.CreateAdmin(context.Background(), services.CreateAdminRequest{
+		Email:    *email,
+		Name:     *name,
+		Password: *password,
+	}, "cli")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create admin: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Created admin %s (%s)\n", admin.Email, admin.ID)
+	return 0
+}
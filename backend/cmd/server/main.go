@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -26,9 +27,16 @@ import (
 	"base-app-service/internal/repositories"
 	"base-app-service/internal/services"
 	"base-app-service/pkg/auth"
+	"base-app-service/pkg/geoip"
+	"base-app-service/pkg/sms"
 )
 
 func main() {
+	// Subcommands such as `server admin create` run and exit
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	ctx, stop := context.WithCancel(context.Background())
 	defer stop()
 
@@ -48,29 +56,12 @@ func main() {
 	defer logger.Sync()
 
 	// Connect to database
-	dbConfig := database.DatabaseConfig{
-		Driver:                cfg.Database.Driver,
-		Host:                  cfg.Database.Host,
-		Port:                  cfg.Database.Port,
-		User:                  cfg.Database.User,
-		Password:              cfg.Database.Password,
-		Name:                  cfg.Database.Name,
-		SSLMode:               cfg.Database.SSLMode,
-		SQLitePath:            cfg.Database.SQLitePath,
-		MaxConnections:        cfg.Database.MaxConnections,
-		MaxIdleConnections:    cfg.Database.MaxIdleConnections,
-		ConnectionMaxLifetime: cfg.Database.ConnectionMaxLifetime,
-	}
-	db, err := database.NewConnection(dbConfig, logger)
+	db, err := openDatabase(cfg, logger)
 	if err != nil {
-		logger.Fatal("Failed to connect to database", zap.Error(err))
+		logger.Fatal("Failed to open database", zap.Error(err))
 	}
 	defer db.Close()
 
-	if err := db.RunMigrations("migrations"); err != nil {
-		logger.Fatal("Failed to run migrations", zap.Error(err))
-	}
-
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
 	sessionRepo := repositories.NewSessionRepository(db)
@@ -89,38 +80,96 @@ func main() {
 	searchRepo := repositories.NewSearchRepository(db)
 	adminSettingsRepo := repositories.NewAdminSettingsRepository(db)
 	customCRUDRepo := repositories.NewCustomCRUDRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	permissionRepo := repositories.NewPermissionRepository(db)
 	crudTemplateRepo := repositories.NewCRUDTemplateRepository(db)
+	identityRepo := repositories.NewIdentityRepository(db)
+	oidcStateRepo := repositories.NewOIDCStateRepository(db)
+	identityLinkRepo := repositories.NewIdentityLinkRepository(db)
+	passkeyRepo := repositories.NewPasskeyRepository(db)
+	webAuthnCeremonyRepo := repositories.NewWebAuthnCeremonyRepository(db)
+	magicLinkRepo := repositories.NewMagicLinkRepository(db)
+	systemSettingsRepo := repositories.NewSystemSettingsRepository(db)
+	oauthClientRepo := repositories.NewOAuthClientRepository(db)
+	oauthCodeRepo := repositories.NewOAuthCodeRepository(db)
+	oauthConsentRepo := repositories.NewOAuthConsentRepository(db)
+	oauthRefreshTokenRepo := repositories.NewOAuthRefreshTokenRepository(db)
+	oauthSigningKeyRepo := repositories.NewOAuthSigningKeyRepository(db)
+	loginEventRepo := repositories.NewLoginEventRepository(db)
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepository(db)
+	emailChangeRepo := repositories.NewEmailChangeRepository(db)
+	smsCodeRepo := repositories.NewSMSCodeRepository(db)
+	twoFactorRepo := repositories.NewTwoFactorRepository(db)
+	organizationRepo := repositories.NewOrganizationRepository(db)
+	organizationInvitationRepo := repositories.NewOrganizationInvitationRepository(db)
+	userInvitationRepo := repositories.NewUserInvitationRepository(db)
+	scimTokenRepo := repositories.NewSCIMTokenRepository(db)
+	connectionRepo := repositories.NewConnectionRepository(db)
+	dataExportRepo := repositories.NewDataExportRepository(db)
+	retentionRepo := repositories.NewRetentionRepository(db)
+	encryptionRepo := repositories.NewEncryptionRepository(db)
+	consentRepo := repositories.NewConsentRepository(db)
+	fileRepo := repositories.NewFileRepository(db)
+	accountDeletionRepo := repositories.NewAccountDeletionRepository(db)
 	// adminActivityLogRepo := repositories.NewAdminActivityLogRepository(db) // Reserved for future use
 	// userManagementActionRepo := repositories.NewUserManagementActionRepository(db) // Reserved for future use
 
-	// Seed default admin account
-	if err := seedDefaultAdmin(context.Background(), userRepo, logger); err != nil {
-		logger.Warn("Failed to seed default admin", zap.Error(err))
-	}
-
 	// Seed default CRUD templates
 	if err := seedDefaultCRUDTemplates(context.Background(), crudTemplateRepo, userRepo, logger); err != nil {
 		logger.Warn("Failed to seed default CRUD templates", zap.Error(err))
 	}
 
+	if err := auth.SetHashParams(passwordHashParams(cfg)); err != nil {
+		logger.Fatal("Invalid password hash settings", zap.Error(err))
+	}
+
 	// Initialize services
+	activityLogService := services.NewActivityLogService(logRepo, logger)
+	systemSettingsService := services.NewSystemSettingsService(systemSettingsRepo, activityLogService, logger)
+	breachedPasswords, err := auth.OpenBreachedPasswords(cfg.Passwords.BreachedCorpusPath)
+	if err != nil {
+		logger.Fatal("Failed to load breached password corpus", zap.Error(err))
+	}
+	defer breachedPasswords.Close()
+	passwordPolicyService := services.NewPasswordPolicyService(
+		systemSettingsService, userRepo, passwordHistoryRepo, breachedPasswords, activityLogService, logger,
+	)
 	authService := services.NewAuthService(
 		userRepo, sessionRepo, deviceRepo,
 		cfg.JWT.Secret, cfg.JWT.AccessTokenExpiry, cfg.JWT.RefreshTokenExpiry,
 		logger,
 	)
-	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, logger)
-	activityLogService := services.NewActivityLogService(logRepo, logger)
+	authService.SetPasswordPolicy(passwordPolicyService)
+	accountDeletionService, err := services.NewAccountDeletionService(
+		cfg.Deletion, accountDeletionRepo, userRepo, sessionRepo, organizationRepo, fileRepo, dataExportRepo,
+		activityLogService, logger,
+	)
+	if err != nil {
+		logger.Fatal("Invalid account deletion settings", zap.Error(err))
+	}
+	// Signing in cancels a deletion the user asked for
+	authService.SetAccountDeletion(accountDeletionService)
+	consentService := services.NewConsentService(consentRepo, activityLogService, logger)
+	authService.SetConsents(consentService)
+	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, passwordPolicyService, logger)
 	themeService := services.NewThemeService(themeRepo, logger)
 	requestService := services.NewRequestService(requestRepo, logger)
-	adminService := services.NewAdminService(userRepo, authService, activityLogService, requestRepo, logger)
+	adminService := services.NewAdminService(userRepo, authService, activityLogService, requestRepo, passwordPolicyService, logger)
 	
 	// New services
 	settingsService := services.NewSettingsService(settingsRepo, userRepo, logger)
 	dashboardService := services.NewDashboardService(dashboardRepo, logger)
 	notificationService := services.NewNotificationService(notificationRepo, logger)
-	messagingService := services.NewMessagingService(messageRepo, userRepo, logger)
-	accountSwitchService := services.NewAccountSwitchService(accountSwitchRepo, userRepo, logger)
+	notificationService.SetConsents(consentService)
+	settingsService.SetConsents(consentService)
+	privacyService := services.NewPrivacyService(settingsRepo, userRepo, connectionRepo, logger)
+	connectionService := services.NewConnectionService(connectionRepo, userRepo, privacyService, notificationService, logger)
+	messagingService := services.NewMessagingService(messageRepo, userRepo, privacyService, logger)
+	accountSwitchService := services.NewAccountSwitchService(
+		accountSwitchRepo, userRepo, activityLogService, notificationService,
+		cfg.JWT.Secret, cfg.JWT.ImpersonationMaxDuration, logger,
+	)
 	searchService := services.NewSearchService(
 		searchRepo,
 		dashboardRepo,
@@ -128,22 +177,113 @@ func main() {
 		notificationRepo,
 		customCRUDRepo,
 		userRepo,
+		privacyService,
 		logger,
 	)
 	adminSettingsService := services.NewAdminSettingsService(adminSettingsRepo, logger)
 	customCRUDService := services.NewCustomCRUDService(customCRUDRepo, logger)
 	crudTemplateService := services.NewCRUDTemplateService(crudTemplateRepo, logger)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, activityLogService, logger)
+	permissionService := services.NewPermissionService(roleRepo, permissionRepo, userRepo, activityLogService, logger)
+	accountSwitchService.SetPermissions(permissionService)
+	passwordPolicyService.SetPermissions(permissionService)
+	accountDeletionService.SetPermissions(permissionService)
+	oidcService := services.NewOIDCService(
+		cfg.OIDC, identityRepo, oidcStateRepo, identityLinkRepo, passkeyRepo, userRepo,
+		authService, activityLogService, logger,
+	)
+	passkeyService, err := services.NewPasskeyService(
+		cfg.WebAuthn, passkeyRepo, webAuthnCeremonyRepo, identityRepo, userRepo,
+		authService, activityLogService, logger,
+	)
+	if err != nil {
+		logger.Fatal("Failed to configure passkeys", zap.Error(err))
+	}
+	oauthServerService := services.NewOAuthServerService(
+		cfg.OAuth, oauthClientRepo, oauthCodeRepo, oauthConsentRepo, oauthRefreshTokenRepo,
+		oauthSigningKeyRepo, userRepo, sessionRepo, authService, activityLogService, logger,
+	)
+	// Logging out of base-app logs the user out of products signed in through it
+	authService.OnSessionsEnded(oauthServerService.EndSessions)
 	
 	// Email service
 	emailConfig := services.GetEmailConfigFromEnv()
 	emailService := services.NewEmailService(emailConfig, logger)
+	magicLinkService := services.NewMagicLinkService(
+		cfg.MagicLink, magicLinkRepo, userRepo, authService, systemSettingsService,
+		emailService, activityLogService, logger,
+	)
+	emailChangeService := services.NewEmailChangeService(
+		cfg.EmailChange, emailChangeRepo, userRepo, authService, emailService, activityLogService, logger,
+	)
+	invitationService := services.NewInvitationService(
+		cfg.Invitations, userInvitationRepo, userRepo, roleRepo, authService, passwordPolicyService,
+		emailService, activityLogService, logger,
+	)
+	invitationService.SetPermissions(permissionService)
+	setupService := services.NewSetupService(
+		cfg.Setup, userRepo, authService, passwordPolicyService, activityLogService, logger,
+	)
+	setupToken, err := setupService.Init(ctx)
+	if err != nil {
+		logger.Fatal("Failed to check for an admin account", zap.Error(err))
+	}
+	if setupToken != "" {
+		logger.Warn("No admin account exists. Create one with First-time Setup on the sign-in page using this one-time token, or run `server admin create`",
+			zap.String("setup_token", setupToken))
+	} else if required, _ := setupService.Required(ctx); required {
+		logger.Warn("No admin account exists. Create one with First-time Setup on the sign-in page using SETUP_TOKEN, or run `server admin create`")
+	}
+	scimService := services.NewSCIMService(
+		cfg.SCIM, scimTokenRepo, userRepo, roleRepo, permissionRepo, sessionRepo, passwordPolicyService, accountDeletionService,
+		activityLogService, logger,
+	)
+	organizationService := services.NewOrganizationService(
+		cfg.Organization, organizationRepo, organizationInvitationRepo, userRepo,
+		emailService, activityLogService, logger,
+	)
+	deviceService := services.NewDeviceService(
+		cfg.Devices, deviceRepo, authService, notificationService, emailService, activityLogService, logger,
+	)
+	authService.OnNewDevice(deviceService.NotifyNewDevice)
+	loginHistoryService := services.NewLoginHistoryService(cfg.LoginHistory, loginEventRepo, logger)
+	authService.OnLoginEvent(loginHistoryService.Record)
+
+	geoIP, err := geoip.Open(cfg.Devices.GeoIPDatabase)
+	if err != nil {
+		logger.Fatal("Failed to load GeoIP database", zap.Error(err))
+	}
+	defer geoIP.Close()
+	authService.SetGeoIP(geoIP)
+
+	smsProvider, err := openSMSProvider(cfg.SMS)
+	if err != nil {
+		logger.Fatal("Failed to set up SMS provider", zap.Error(err))
+	}
+	if closer, ok := smsProvider.(io.Closer); ok {
+		defer closer.Close()
+	}
+	smsService := services.NewSMSService(
+		cfg.SMS, smsProvider, smsCodeRepo, twoFactorRepo, userRepo, settingsService, activityLogService, logger,
+	)
+	notificationService.OnCreated(smsService.NotifySecurity)
+	twoFactorService := services.NewTwoFactorService(
+		twoFactorRepo, smsCodeRepo, userRepo, smsService, authService, settingsService,
+		notificationService, activityLogService, logger,
+	)
+	// Trusted devices skip the second factor for DEVICE_TRUST_DURATION
+	twoFactorService.SetDevices(deviceService)
+	authService.SetTwoFactor(twoFactorService)
 	
 	// File service
 	uploadDir := getEnv("UPLOAD_DIR", "uploads")
 	fileService := services.NewFileService(services.FileUploadConfig{
 		UploadDir: uploadDir,
 		MaxSize:   10 * 1024 * 1024, // 10MB default
-	}, logger)
+	}, fileRepo, logger)
+	dataExportService := services.NewDataExportService(
+		cfg.DataExport, dataExportRepo, userRepo, fileRepo, notificationService, activityLogService, logger,
+	)
 	
 	// Cache
 	_ = cache.NewInMemoryCache(logger) // Reserved for future use
@@ -151,25 +291,70 @@ func main() {
 	// Monitoring
 	metrics := monitoring.NewMetrics(logger)
 	healthChecker := monitoring.NewHealthChecker(db, logger)
+	retentionService := services.NewRetentionService(cfg.Retention, retentionRepo, metrics, activityLogService, logger)
+	encryptionService := services.NewEncryptionService(cfg.Encryption, db.Keyring(), encryptionRepo, activityLogService, logger)
 
-	// Background purge for soft-deleted users (5-day retention)
-	startDeletedUserSweeper(ctx, userRepo, logger)
+	startAccountDeletionSweeper(ctx, accountDeletionService, logger)
+	startLoginHistorySweeper(ctx, loginHistoryService, logger)
+	startDataExportSweeper(ctx, dataExportService, logger)
+	startRetentionSweeper(ctx, retentionService, cfg.Retention.Interval, logger)
+
+	// Finish any re-encryption a restart interrupted, and seal values stored
+	// before encryption was turned on
+	go func() {
+		if err := encryptionService.Reencrypt(ctx); err != nil {
+			logger.Error("Failed to re-encrypt stored values", zap.Error(err))
+		}
+	}()
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, passwordResetService, emailService, logger)
-	userHandler := handlers.NewUserHandler(userRepo, requestRepo, themeRepo, sessionRepo, logger)
+	userHandler := handlers.NewUserHandler(userRepo, passwordPolicyService, accountDeletionService, consentService, logger)
 	themeHandler := handlers.NewThemeHandler(themeService, logger)
-	adminHandler := handlers.NewAdminHandler(adminService, adminSettingsService, customCRUDService, crudTemplateService, logger)
+	adminHandler := handlers.NewAdminHandler(adminService, adminSettingsService, customCRUDService, crudTemplateService, accountDeletionService, logger)
 	requestHandler := handlers.NewRequestHandler(requestService, logger)
 	
 	// New handlers
-	settingsHandler := handlers.NewSettingsHandler(settingsService, sessionRepo, logger)
+	settingsHandler := handlers.NewSettingsHandler(settingsService, sessionRepo, authService, loginHistoryService, logger)
 	dashboardHandler := handlers.NewDashboardHandler(dashboardService, logger)
 	notificationHandler := handlers.NewNotificationHandler(notificationService, logger)
 	messagingHandler := handlers.NewMessagingHandler(messagingService, logger)
 	accountSwitchHandler := handlers.NewAccountSwitchHandler(accountSwitchService, logger)
 	searchHandler := handlers.NewSearchHandler(searchService, logger)
 	fileUploadHandler := handlers.NewFileUploadHandler(fileService, logger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, logger)
+	permissionHandler := handlers.NewPermissionHandler(permissionService, logger)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, logger)
+	passkeyHandler := handlers.NewPasskeyHandler(passkeyService, logger)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService, logger)
+	emailChangeHandler := handlers.NewEmailChangeHandler(emailChangeService, logger)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, smsService, logger)
+	deviceHandler := handlers.NewDeviceHandler(deviceService, logger)
+	systemSettingsHandler := handlers.NewSystemSettingsHandler(systemSettingsService, logger)
+	passwordPolicyHandler := handlers.NewPasswordPolicyHandler(passwordPolicyService, logger)
+	oauthServerHandler := handlers.NewOAuthServerHandler(oauthServerService, cfg.OAuth.ConsentURL, logger)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService, logger)
+	invitationHandler := handlers.NewInvitationHandler(invitationService, logger)
+	setupHandler := handlers.NewSetupHandler(setupService, logger)
+	scimHandler := handlers.NewSCIMHandler(scimService, logger)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService, logger)
+	connectionHandler := handlers.NewConnectionHandler(connectionService, logger)
+	dataExportHandler := handlers.NewDataExportHandler(dataExportService, logger)
+	retentionHandler := handlers.NewRetentionHandler(retentionService, logger)
+	encryptionHandler := handlers.NewEncryptionHandler(encryptionService, logger)
+	consentHandler := handlers.NewConsentHandler(consentService, logger)
+
+	// Routes reachable with personal access tokens, and the scopes they need.
+	// Anything not listed here is JWT-only.
+	apiKeyScopeRules := []middleware.ScopeRule{
+		// Only the profile itself is readable; settings, security and the
+		// rest of the account under /v1/users/me stay closed to API keys
+		{PathPrefix: "/v1/users/me", Exact: true, ReadScope: services.ScopeProfileRead},
+		{PathPrefix: "/v1/users/me/permissions", Exact: true, ReadScope: services.ScopeProfileRead},
+		{PathPrefix: "/v1/cruds/", ReadScope: services.ScopeCRUDsRead, WriteScope: services.ScopeCRUDsWrite},
+		{PathPrefix: "/v1/admin/users", ReadScope: services.ScopeAdminUsers, WriteScope: services.ScopeAdminUsers},
+		{PathPrefix: "/v1/admin/cruds/", ReadScope: services.ScopeCRUDsRead, WriteScope: services.ScopeCRUDsWrite},
+	}
 
 	// Setup router
 	router := mux.NewRouter()
@@ -177,7 +362,14 @@ func main() {
 	// Initialize rate limiter
 	rateLimiter := middleware.NewInMemoryRateLimiter()
 
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
+
 	// Middleware (order matters!)
+	// Client IP first, so logging and rate limiting see the real address
+	router.Use(middleware.ClientIPMiddleware(trustedProxies))
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.LoggingMiddleware(logger))
 	router.Use(middleware.SecurityHeadersMiddleware())
@@ -188,7 +380,7 @@ func main() {
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip CSRF for API endpoints
-			if strings.HasPrefix(r.URL.Path, "/v1/") {
+			if strings.HasPrefix(r.URL.Path, "/v1/") || strings.HasPrefix(r.URL.Path, "/scim/") {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -243,12 +435,56 @@ func main() {
 	
 	router.Use(middleware.ErrorRecovery(logger))
 
+	// Tighter per-client limits, on top of the global one, for endpoints that
+	// send email or accept single-use tokens
+	magicLinkRateLimit := middleware.RateLimitByEndpoint(rateLimiter, map[string]middleware.RateLimitConfig{
+		"/v1/auth/magic-link":        {Limit: 5, Window: 15 * time.Minute},
+		"/v1/auth/magic-link/verify": {Limit: 10, Window: 15 * time.Minute},
+	}, logger)
+
+	smsRateLimit := middleware.RateLimitByEndpoint(rateLimiter, map[string]middleware.RateLimitConfig{
+		"/v1/auth/two-factor/verify":              {Limit: 10, Window: 15 * time.Minute},
+		"/v1/auth/two-factor/resend":              {Limit: 5, Window: 15 * time.Minute},
+		"/v1/users/me/phone/verification":         {Limit: 5, Window: 15 * time.Minute},
+		"/v1/users/me/phone/verification/confirm": {Limit: 10, Window: 15 * time.Minute},
+	}, logger)
+
+	organizationRateLimit := middleware.RateLimitByEndpoint(rateLimiter, map[string]middleware.RateLimitConfig{
+		"/v1/orgs/invitations/accept": {Limit: 10, Window: 15 * time.Minute},
+	}, logger)
+
+	invitationRateLimit := middleware.RateLimitByEndpoint(rateLimiter, map[string]middleware.RateLimitConfig{
+		"/v1/auth/invitations/lookup": {Limit: 20, Window: 15 * time.Minute},
+		"/v1/auth/invitations/accept": {Limit: 10, Window: 15 * time.Minute},
+	}, logger)
+
+	setupRateLimit := middleware.RateLimitByEndpoint(rateLimiter, map[string]middleware.RateLimitConfig{
+		"/v1/setup": {Limit: 10, Window: 15 * time.Minute},
+	}, logger)
+
 	// Health check endpoints
 	router.HandleFunc("/health", healthChecker.HealthCheck).Methods("GET")
 	router.HandleFunc("/health/ready", healthChecker.ReadinessCheck).Methods("GET")
 	router.HandleFunc("/health/live", healthChecker.LivenessCheck).Methods("GET")
 	router.HandleFunc("/metrics", metrics.MetricsHandler).Methods("GET")
 
+	// SCIM 2.0 provisioning, authenticated with admin-issued SCIM tokens
+	scim := router.PathPrefix("/scim/v2").Subrouter()
+	scim.Use(middleware.SCIMAuth(scimService, logger))
+	scim.HandleFunc("/ServiceProviderConfig", scimHandler.ServiceProviderConfig).Methods("GET")
+	scim.HandleFunc("/Users", scimHandler.ListUsers).Methods("GET")
+	scim.HandleFunc("/Users", scimHandler.CreateUser).Methods("POST")
+	scim.HandleFunc("/Users/{id}", scimHandler.GetUser).Methods("GET")
+	scim.HandleFunc("/Users/{id}", scimHandler.ReplaceUser).Methods("PUT")
+	scim.HandleFunc("/Users/{id}", scimHandler.PatchUser).Methods("PATCH")
+	scim.HandleFunc("/Users/{id}", scimHandler.DeleteUser).Methods("DELETE")
+	scim.HandleFunc("/Groups", scimHandler.ListGroups).Methods("GET")
+	scim.HandleFunc("/Groups", scimHandler.CreateGroup).Methods("POST")
+	scim.HandleFunc("/Groups/{id}", scimHandler.GetGroup).Methods("GET")
+	scim.HandleFunc("/Groups/{id}", scimHandler.ReplaceGroup).Methods("PUT")
+	scim.HandleFunc("/Groups/{id}", scimHandler.PatchGroup).Methods("PATCH")
+	scim.HandleFunc("/Groups/{id}", scimHandler.DeleteGroup).Methods("DELETE")
+
 	// API v1 routes
 	v1 := router.PathPrefix("/v1").Subrouter()
 
@@ -259,19 +495,76 @@ func main() {
 	public.HandleFunc("/auth/refresh", authHandler.RefreshToken).Methods("POST")
 	public.HandleFunc("/auth/forgot-password", authHandler.ForgotPassword).Methods("POST")
 	public.HandleFunc("/auth/reset-password", authHandler.ResetPassword).Methods("POST")
+	public.HandleFunc("/auth/password-strength", passwordPolicyHandler.CheckStrength).Methods("POST")
+	public.HandleFunc("/auth/oidc/providers", oidcHandler.ListProviders).Methods("GET")
+	public.HandleFunc("/auth/oidc/link/confirm", oidcHandler.ConfirmLink).Methods("POST")
+	public.HandleFunc("/auth/oidc/{provider}/authorize", oidcHandler.Authorize).Methods("POST")
+	public.HandleFunc("/auth/oidc/{provider}/callback", oidcHandler.Callback).Methods("POST")
+	public.HandleFunc("/auth/passkeys/login/begin", passkeyHandler.BeginLogin).Methods("POST")
+	public.HandleFunc("/auth/passkeys/login/finish", passkeyHandler.FinishLogin).Methods("POST")
+	public.HandleFunc("/auth/magic-link", magicLinkHandler.Status).Methods("GET")
+	public.Handle("/auth/magic-link", magicLinkRateLimit(http.HandlerFunc(magicLinkHandler.Request))).Methods("POST")
+	public.Handle("/auth/magic-link/verify", magicLinkRateLimit(http.HandlerFunc(magicLinkHandler.Verify))).Methods("POST")
+	public.Handle("/auth/two-factor/verify", smsRateLimit(http.HandlerFunc(twoFactorHandler.Verify))).Methods("POST")
+	public.Handle("/auth/two-factor/resend", smsRateLimit(http.HandlerFunc(twoFactorHandler.Resend))).Methods("POST")
+	public.Handle("/auth/invitations/lookup", invitationRateLimit(http.HandlerFunc(invitationHandler.Lookup))).Methods("POST")
+	public.Handle("/auth/invitations/accept", invitationRateLimit(http.HandlerFunc(invitationHandler.Accept))).Methods("POST")
+	public.HandleFunc("/auth/email-change/confirm", emailChangeHandler.Confirm).Methods("POST")
+	public.HandleFunc("/auth/email-change/revert", emailChangeHandler.Revert).Methods("POST")
+	// Data export archives, by the token in the link the user was sent
+	public.HandleFunc("/exports/download", dataExportHandler.Download).Methods("GET")
+	// OpenID Connect provider endpoints for other products
+	public.HandleFunc("/oauth2/.well-known/openid-configuration", oauthServerHandler.Discovery).Methods("GET")
+	public.HandleFunc("/oauth2/jwks", oauthServerHandler.JWKS).Methods("GET")
+	public.HandleFunc("/oauth2/authorize", oauthServerHandler.Authorize).Methods("GET")
+	public.HandleFunc("/oauth2/token", oauthServerHandler.Token).Methods("POST")
+	public.HandleFunc("/oauth2/userinfo", oauthServerHandler.UserInfo).Methods("GET", "POST")
+	public.HandleFunc("/oauth2/logout", oauthServerHandler.Logout).Methods("GET")
 	public.HandleFunc("/admin/login", adminHandler.Login).Methods("POST")
-	public.HandleFunc("/admin/verify-code", adminHandler.VerifyAdminCode).Methods("POST") // Verify admin code
-	public.HandleFunc("/admin/create", adminHandler.CreateAdminPublic).Methods("POST") // Public admin creation with verification
+	// First-run setup creates the first admin with the setup token
+	public.HandleFunc("/setup", setupHandler.Status).Methods("GET")
+	public.HandleFunc("/legal-documents", consentHandler.CurrentDocuments).Methods("GET")
+	public.Handle("/setup", setupRateLimit(http.HandlerFunc(setupHandler.Complete))).Methods("POST")
+
+	// Routes a user whose password expired or was reset by an admin can still
+	// reach: enough to see who they are, change the password, or sign out.
+	passwordChangeAllowedPaths := []string{
+		"/v1/users/me",
+		"/v1/users/me/password",
+		"/v1/auth/logout",
+	}
 
 	// Protected routes
 	protected := v1.PathPrefix("").Subrouter()
-	protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret, logger))
+	protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret, apiKeyService, authService, logger))
+	protected.Use(middleware.RequireAPIKeyScopes(apiKeyScopeRules, logger))
+	protected.Use(middleware.TrackImpersonation(accountSwitchService, logger))
+	protected.Use(middleware.LoadPermissions(permissionService, logger))
+	protected.Use(middleware.OrganizationContext(organizationService, logger))
+	protected.Use(middleware.RequirePasswordChange(passwordPolicyService, passwordChangeAllowedPaths, logger))
+
+	// sensitive wraps routes that must not run under an impersonation token.
+	sensitive := func(h http.HandlerFunc) http.Handler {
+		return middleware.DenyImpersonation(logger)(h)
+	}
 	protected.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST")
 	protected.HandleFunc("/users/me", userHandler.GetCurrentUser).Methods("GET")
 	protected.HandleFunc("/users/me", userHandler.UpdateProfile).Methods("PUT")
-	protected.HandleFunc("/users/me/password", userHandler.ChangePassword).Methods("PUT")
-	protected.HandleFunc("/users/me/export", userHandler.ExportData).Methods("GET")
-	protected.HandleFunc("/users/me/delete", userHandler.RequestDeletion).Methods("POST")
+	protected.HandleFunc("/users/me/permissions", permissionHandler.MyPermissions).Methods("GET")
+	protected.HandleFunc("/users/me/consents", consentHandler.GetMyConsents).Methods("GET")
+	protected.HandleFunc("/users/me/consents", consentHandler.Accept).Methods("POST")
+	protected.HandleFunc("/users/me/consents/marketing", consentHandler.UpdateMarketing).Methods("PUT")
+	protected.Handle("/users/me/password", sensitive(userHandler.ChangePassword)).Methods("PUT")
+	protected.HandleFunc("/users/me/email", emailChangeHandler.GetPending).Methods("GET")
+	protected.Handle("/users/me/phone/verification", smsRateLimit(sensitive(twoFactorHandler.StartPhoneVerification))).Methods("POST")
+	protected.Handle("/users/me/phone/verification/confirm", smsRateLimit(sensitive(twoFactorHandler.ConfirmPhoneVerification))).Methods("POST")
+	protected.HandleFunc("/users/me/two-factor", twoFactorHandler.GetStatus).Methods("GET")
+	protected.Handle("/users/me/two-factor", sensitive(twoFactorHandler.Update)).Methods("PUT")
+	protected.Handle("/users/me/email", sensitive(emailChangeHandler.Request)).Methods("POST")
+	protected.Handle("/users/me/email", sensitive(emailChangeHandler.Cancel)).Methods("DELETE")
+	protected.HandleFunc("/users/me/exports", dataExportHandler.List).Methods("GET")
+	protected.Handle("/users/me/exports", sensitive(dataExportHandler.Request)).Methods("POST")
+	protected.Handle("/users/me/delete", sensitive(userHandler.RequestDeletion)).Methods("POST")
 	protected.HandleFunc("/users/me/settings/theme", themeHandler.GetTheme).Methods("GET")
 	protected.HandleFunc("/users/me/settings/theme", themeHandler.UpdateTheme).Methods("PUT")
 	protected.HandleFunc("/users/me/settings/theme/sync", themeHandler.SyncTheme).Methods("POST")
@@ -281,17 +574,50 @@ func main() {
 	// Settings routes
 	protected.HandleFunc("/users/me/settings", settingsHandler.GetSettings).Methods("GET")
 	protected.HandleFunc("/users/me/settings/sessions", settingsHandler.GetActiveSessions).Methods("GET")
-	protected.HandleFunc("/users/me/settings/sessions/logout-all", settingsHandler.LogoutAllDevices).Methods("POST")
+	protected.Handle("/users/me/settings/sessions/logout-all", sensitive(settingsHandler.LogoutAllDevices)).Methods("POST")
+	protected.Handle("/users/me/settings/sessions/{id}", sensitive(settingsHandler.RevokeSession)).Methods("DELETE")
+	protected.HandleFunc("/users/me/settings/login-history", settingsHandler.GetLoginHistory).Methods("GET")
 	protected.HandleFunc("/users/me/settings/profile", settingsHandler.UpdateProfileSettings).Methods("PUT")
-	protected.HandleFunc("/users/me/settings/security", settingsHandler.UpdateSecuritySettings).Methods("PUT")
+	protected.Handle("/users/me/settings/security", sensitive(settingsHandler.UpdateSecuritySettings)).Methods("PUT")
 	protected.HandleFunc("/users/me/settings/privacy", settingsHandler.UpdatePrivacySettings).Methods("PUT")
 	protected.HandleFunc("/users/me/settings/notifications", settingsHandler.UpdateNotificationSettings).Methods("PUT")
 	protected.HandleFunc("/users/me/settings/preferences", settingsHandler.UpdateAccountPreferences).Methods("PUT")
-	protected.HandleFunc("/users/me/settings/connected-accounts", settingsHandler.AddConnectedAccount).Methods("POST")
-	protected.HandleFunc("/users/me/settings/connected-accounts", settingsHandler.RemoveConnectedAccount).Methods("DELETE")
-	protected.HandleFunc("/users/me/settings/account/deactivate", settingsHandler.DeactivateAccount).Methods("POST")
+	protected.HandleFunc("/users/me/identities", oidcHandler.ListIdentities).Methods("GET")
+	protected.Handle("/users/me/identities/{provider}", sensitive(oidcHandler.LinkIdentity)).Methods("POST")
+	protected.Handle("/users/me/identities/{id}", sensitive(oidcHandler.UnlinkIdentity)).Methods("DELETE")
+	protected.HandleFunc("/users/me/passkeys", passkeyHandler.ListPasskeys).Methods("GET")
+	protected.Handle("/users/me/passkeys/registration/begin", sensitive(passkeyHandler.BeginRegistration)).Methods("POST")
+	protected.Handle("/users/me/passkeys/registration/finish", sensitive(passkeyHandler.FinishRegistration)).Methods("POST")
+	protected.Handle("/users/me/passkeys/{id}", sensitive(passkeyHandler.DeletePasskey)).Methods("DELETE")
+	protected.HandleFunc("/users/me/devices", deviceHandler.ListDevices).Methods("GET")
+	protected.HandleFunc("/users/me/devices/{id}", deviceHandler.RenameDevice).Methods("PUT")
+	protected.Handle("/users/me/devices/{id}", sensitive(deviceHandler.RemoveDevice)).Methods("DELETE")
+	protected.Handle("/users/me/devices/{id}/trust", sensitive(deviceHandler.UntrustDevice)).Methods("DELETE")
+	protected.HandleFunc("/users/me/oauth/grants", oauthServerHandler.ListGrants).Methods("GET")
+	protected.Handle("/users/me/oauth/grants/{client_id}", sensitive(oauthServerHandler.RevokeGrant)).Methods("DELETE")
+	protected.HandleFunc("/oauth2/consent", oauthServerHandler.ConsentPrompt).Methods("GET")
+	protected.Handle("/oauth2/consent", sensitive(oauthServerHandler.ConsentDecision)).Methods("POST")
+	protected.Handle("/users/me/settings/account/deactivate", sensitive(settingsHandler.DeactivateAccount)).Methods("POST")
 	protected.HandleFunc("/users/me/settings/account/reactivate", settingsHandler.ReactivateAccount).Methods("POST")
-	protected.HandleFunc("/users/me/settings/account/delete", settingsHandler.RequestAccountDeletion).Methods("POST")
+	protected.Handle("/users/me/settings/account/delete", sensitive(userHandler.RequestDeletion)).Methods("POST") // same as /users/me/delete
+
+	// Profiles, friends and blocking
+	protected.HandleFunc("/users/{id}/profile", privacyHandler.GetProfile).Methods("GET")
+	protected.HandleFunc("/friends", connectionHandler.ListFriends).Methods("GET")
+	protected.HandleFunc("/friends/requests", connectionHandler.ListRequests).Methods("GET")
+	protected.HandleFunc("/friends/requests", connectionHandler.SendRequest).Methods("POST")
+	protected.HandleFunc("/friends/requests/{id}/accept", connectionHandler.AcceptRequest).Methods("POST")
+	protected.HandleFunc("/friends/requests/{id}/decline", connectionHandler.DeclineRequest).Methods("POST")
+	protected.HandleFunc("/friends/requests/{id}", connectionHandler.CancelRequest).Methods("DELETE")
+	protected.HandleFunc("/friends/{user_id}", connectionHandler.RemoveFriend).Methods("DELETE")
+	protected.HandleFunc("/users/me/blocks", connectionHandler.ListBlocked).Methods("GET")
+	protected.HandleFunc("/users/me/blocks", connectionHandler.Block).Methods("POST")
+	protected.HandleFunc("/users/me/blocks/{user_id}", connectionHandler.Unblock).Methods("DELETE")
+
+	// API key routes
+	protected.HandleFunc("/users/me/api-keys", apiKeyHandler.List).Methods("GET")
+	protected.Handle("/users/me/api-keys", sensitive(apiKeyHandler.Create)).Methods("POST")
+	protected.Handle("/users/me/api-keys/{id}", sensitive(apiKeyHandler.Revoke)).Methods("DELETE")
 	
 	// Dashboard routes
 	protected.HandleFunc("/dashboard/items", dashboardHandler.CreateItem).Methods("POST")
@@ -316,8 +642,9 @@ func main() {
 	protected.HandleFunc("/messages/unread-count", messagingHandler.GetUnreadCount).Methods("GET")
 	
 	// Account switching routes
-	protected.HandleFunc("/account/switch", accountSwitchHandler.SwitchAccount).Methods("POST")
+	protected.Handle("/account/switch", sensitive(accountSwitchHandler.SwitchAccount)).Methods("POST")
 	protected.HandleFunc("/account/switch/history", accountSwitchHandler.GetSwitchHistory).Methods("GET")
+	protected.HandleFunc("/impersonation/stop", accountSwitchHandler.StopImpersonation).Methods("POST")
 	
 	// Search routes
 	protected.HandleFunc("/search", searchHandler.Search).Methods("GET", "POST")
@@ -347,52 +674,124 @@ func main() {
 	protected.HandleFunc("/cruds/templates/{name}", adminHandler.GetCRUDTemplate).Methods("GET")
 	protected.HandleFunc("/cruds/templates/{name}/create", adminHandler.CreateEntityFromTemplate).Methods("POST")
 
+	// Organization templates; admins and owners of the organization in
+	// X-Organization-ID manage them
+	protected.HandleFunc("/cruds/templates", adminHandler.CreateTemplate).Methods("POST")
+	protected.HandleFunc("/cruds/templates/id/{id}", adminHandler.UpdateTemplate).Methods("PUT")
+	protected.HandleFunc("/cruds/templates/id/{id}", adminHandler.DeleteTemplate).Methods("DELETE")
+
+	// Organization routes
+	protected.Handle("/orgs/invitations/accept", organizationRateLimit(http.HandlerFunc(organizationHandler.AcceptInvitation))).Methods("POST")
+	protected.HandleFunc("/orgs", organizationHandler.List).Methods("GET")
+	protected.HandleFunc("/orgs", organizationHandler.Create).Methods("POST")
+	protected.HandleFunc("/orgs/{id}", organizationHandler.Get).Methods("GET")
+	protected.HandleFunc("/orgs/{id}", organizationHandler.Update).Methods("PUT")
+	protected.Handle("/orgs/{id}", sensitive(organizationHandler.Delete)).Methods("DELETE")
+	protected.HandleFunc("/orgs/{id}/members", organizationHandler.ListMembers).Methods("GET")
+	protected.HandleFunc("/orgs/{id}/members/{user_id}", organizationHandler.UpdateMember).Methods("PUT")
+	protected.HandleFunc("/orgs/{id}/members/{user_id}", organizationHandler.RemoveMember).Methods("DELETE")
+	protected.HandleFunc("/orgs/{id}/invitations", organizationHandler.ListInvitations).Methods("GET")
+	protected.HandleFunc("/orgs/{id}/invitations", organizationHandler.Invite).Methods("POST")
+	protected.HandleFunc("/orgs/{id}/invitations/{invitation_id}", organizationHandler.RevokeInvitation).Methods("DELETE")
+
 	// Serve uploaded files
 	router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir(uploadDir))))
 
-	// Admin protected routes
+	// Admin protected routes. Each route names the permission it needs; roles
+	// are bundles of permissions, so custom roles can reach a subset of these.
+	requirePermission := func(permission string, h http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(permission, logger)(h)
+	}
 	adminProtected := v1.PathPrefix("/admin").Subrouter()
-	adminProtected.Use(middleware.AuthMiddleware(cfg.JWT.Secret, logger))
-	adminProtected.Use(middleware.RequireRole("admin", logger))
-	adminProtected.HandleFunc("/users", adminHandler.ListUsers).Methods("GET")
-	adminProtected.HandleFunc("/users/{id}", adminHandler.GetUser).Methods("GET")
-	adminProtected.HandleFunc("/users/{id}/status", adminHandler.UpdateUserStatus).Methods("POST")
-	adminProtected.HandleFunc("/logs", adminHandler.ListLogs).Methods("GET")
-	adminProtected.HandleFunc("/admins", adminHandler.AddAdmin).Methods("POST")
-	adminProtected.HandleFunc("/admins", adminHandler.ListAdmins).Methods("GET")
-	adminProtected.HandleFunc("/requests", adminHandler.ListRequests).Methods("GET")
-	adminProtected.HandleFunc("/requests/{id}/status", adminHandler.UpdateRequestStatus).Methods("POST")
+	adminProtected.Use(middleware.AuthMiddleware(cfg.JWT.Secret, apiKeyService, authService, logger))
+	adminProtected.Use(middleware.RequireAPIKeyScopes(apiKeyScopeRules, logger))
+	adminProtected.Use(middleware.DenyImpersonation(logger))
+	adminProtected.Use(middleware.LoadPermissions(permissionService, logger))
+	adminProtected.Use(middleware.RequirePasswordChange(passwordPolicyService, passwordChangeAllowedPaths, logger))
+	adminProtected.Handle("/users", requirePermission(models.PermUsersRead, adminHandler.ListUsers)).Methods("GET")
+	adminProtected.Handle("/users/{id}", requirePermission(models.PermUsersRead, adminHandler.GetUser)).Methods("GET")
+	adminProtected.Handle("/users/{id}/status", requirePermission(models.PermUsersWrite, adminHandler.UpdateUserStatus)).Methods("POST")
+	adminProtected.Handle("/logs", requirePermission(models.PermLogsRead, adminHandler.ListLogs)).Methods("GET")
+	adminProtected.Handle("/admins", requirePermission(models.PermAdminsManage, adminHandler.AddAdmin)).Methods("POST")
+	adminProtected.Handle("/admins", requirePermission(models.PermAdminsManage, adminHandler.ListAdmins)).Methods("GET")
+	adminProtected.Handle("/invitations", requirePermission(models.PermUsersRead, invitationHandler.List)).Methods("GET")
+	adminProtected.Handle("/invitations", requirePermission(models.PermUsersWrite, invitationHandler.Create)).Methods("POST")
+	adminProtected.Handle("/invitations/{id}/resend", requirePermission(models.PermUsersWrite, invitationHandler.Resend)).Methods("POST")
+	adminProtected.Handle("/invitations/{id}", requirePermission(models.PermUsersWrite, invitationHandler.Revoke)).Methods("DELETE")
+	adminProtected.Handle("/requests", requirePermission(models.PermRequestsManage, adminHandler.ListRequests)).Methods("GET")
+	adminProtected.Handle("/requests/{id}/status", requirePermission(models.PermRequestsManage, adminHandler.UpdateRequestStatus)).Methods("POST")
 	
 	// Admin settings routes
-	adminProtected.HandleFunc("/settings", adminHandler.GetSettings).Methods("GET")
-	adminProtected.HandleFunc("/settings", adminHandler.UpdateSettings).Methods("PUT")
+	adminProtected.Handle("/settings", requirePermission(models.PermSettingsRead, adminHandler.GetSettings)).Methods("GET")
+	adminProtected.Handle("/settings", requirePermission(models.PermSettingsWrite, adminHandler.UpdateSettings)).Methods("PUT")
+	adminProtected.Handle("/settings/system", requirePermission(models.PermSettingsRead, systemSettingsHandler.GetSettings)).Methods("GET")
+	adminProtected.Handle("/settings/system", requirePermission(models.PermSettingsWrite, systemSettingsHandler.UpdateSettings)).Methods("PUT")
+	adminProtected.Handle("/retention", requirePermission(models.PermSettingsRead, retentionHandler.ListRules)).Methods("GET")
+	adminProtected.Handle("/retention/preview", requirePermission(models.PermSettingsRead, retentionHandler.Preview)).Methods("GET")
+	adminProtected.Handle("/retention/runs", requirePermission(models.PermSettingsRead, retentionHandler.ListRuns)).Methods("GET")
+	adminProtected.Handle("/retention/run", requirePermission(models.PermSettingsWrite, retentionHandler.Run)).Methods("POST")
+	adminProtected.Handle("/retention/{class}", requirePermission(models.PermSettingsWrite, retentionHandler.UpdateRule)).Methods("PUT")
+	adminProtected.Handle("/encryption", requirePermission(models.PermSettingsRead, encryptionHandler.Status)).Methods("GET")
+	adminProtected.Handle("/encryption/rotate", requirePermission(models.PermSettingsWrite, encryptionHandler.Rotate)).Methods("POST")
+	adminProtected.Handle("/legal-documents", requirePermission(models.PermSettingsRead, consentHandler.ListDocuments)).Methods("GET")
+	adminProtected.Handle("/legal-documents", requirePermission(models.PermSettingsWrite, consentHandler.Publish)).Methods("POST")
 	
 	// Admin custom CRUD routes
-	adminProtected.HandleFunc("/cruds/entities", adminHandler.CreateCRUDEntity).Methods("POST")
-	adminProtected.HandleFunc("/cruds/entities", adminHandler.ListCRUDEntities).Methods("GET")
-	adminProtected.HandleFunc("/cruds/entities/{id}", adminHandler.GetCRUDEntity).Methods("GET")
-	adminProtected.HandleFunc("/cruds/entities/{id}", adminHandler.UpdateCRUDEntity).Methods("PUT")
-	adminProtected.HandleFunc("/cruds/entities/{id}", adminHandler.DeleteCRUDEntity).Methods("DELETE")
-	adminProtected.HandleFunc("/cruds/entities/{id}/data", adminHandler.CreateCRUDData).Methods("POST")
-	adminProtected.HandleFunc("/cruds/entities/{id}/data", adminHandler.ListCRUDData).Methods("GET")
-	adminProtected.HandleFunc("/cruds/data/{id}", adminHandler.GetCRUDData).Methods("GET")
-	adminProtected.HandleFunc("/cruds/data/{id}", adminHandler.UpdateCRUDData).Methods("PUT")
-	adminProtected.HandleFunc("/cruds/data/{id}", adminHandler.DeleteCRUDData).Methods("DELETE")
+	adminProtected.Handle("/cruds/entities", requirePermission(models.PermCRUDsManage, adminHandler.CreateCRUDEntity)).Methods("POST")
+	adminProtected.Handle("/cruds/entities", requirePermission(models.PermCRUDsManage, adminHandler.ListCRUDEntities)).Methods("GET")
+	adminProtected.Handle("/cruds/entities/{id}", requirePermission(models.PermCRUDsManage, adminHandler.GetCRUDEntity)).Methods("GET")
+	adminProtected.Handle("/cruds/entities/{id}", requirePermission(models.PermCRUDsManage, adminHandler.UpdateCRUDEntity)).Methods("PUT")
+	adminProtected.Handle("/cruds/entities/{id}", requirePermission(models.PermCRUDsManage, adminHandler.DeleteCRUDEntity)).Methods("DELETE")
+	adminProtected.Handle("/cruds/entities/{id}/data", requirePermission(models.PermCRUDsManage, adminHandler.CreateCRUDData)).Methods("POST")
+	adminProtected.Handle("/cruds/entities/{id}/data", requirePermission(models.PermCRUDsManage, adminHandler.ListCRUDData)).Methods("GET")
+	adminProtected.Handle("/cruds/data/{id}", requirePermission(models.PermCRUDsManage, adminHandler.GetCRUDData)).Methods("GET")
+	adminProtected.Handle("/cruds/data/{id}", requirePermission(models.PermCRUDsManage, adminHandler.UpdateCRUDData)).Methods("PUT")
+	adminProtected.Handle("/cruds/data/{id}", requirePermission(models.PermCRUDsManage, adminHandler.DeleteCRUDData)).Methods("DELETE")
 	
 	// CRUD Templates routes (for easy entity creation)
-	adminProtected.HandleFunc("/cruds/templates", adminHandler.GetCRUDTemplates).Methods("GET")
-	adminProtected.HandleFunc("/cruds/templates", adminHandler.CreateTemplate).Methods("POST")
-	adminProtected.HandleFunc("/cruds/templates/{name}", adminHandler.GetCRUDTemplate).Methods("GET")
-	adminProtected.HandleFunc("/cruds/templates/{name}/create", adminHandler.CreateEntityFromTemplate).Methods("POST")
-	adminProtected.HandleFunc("/cruds/templates/id/{id}", adminHandler.UpdateTemplate).Methods("PUT")
-	adminProtected.HandleFunc("/cruds/templates/id/{id}", adminHandler.DeleteTemplate).Methods("DELETE")
+	adminProtected.Handle("/cruds/templates", requirePermission(models.PermTemplatesManage, adminHandler.GetCRUDTemplates)).Methods("GET")
+	adminProtected.Handle("/cruds/templates", requirePermission(models.PermTemplatesManage, adminHandler.CreateTemplate)).Methods("POST")
+	adminProtected.Handle("/cruds/templates/{name}", requirePermission(models.PermTemplatesManage, adminHandler.GetCRUDTemplate)).Methods("GET")
+	adminProtected.Handle("/cruds/templates/{name}/create", requirePermission(models.PermCRUDsManage, adminHandler.CreateEntityFromTemplate)).Methods("POST")
+	adminProtected.Handle("/cruds/templates/id/{id}", requirePermission(models.PermTemplatesManage, adminHandler.UpdateTemplate)).Methods("PUT")
+	adminProtected.Handle("/cruds/templates/id/{id}", requirePermission(models.PermTemplatesManage, adminHandler.DeleteTemplate)).Methods("DELETE")
 	
 	// Enhanced admin user CRUD routes
-	adminProtected.HandleFunc("/users", adminHandler.CreateUser).Methods("POST")
-	adminProtected.HandleFunc("/users/{id}", adminHandler.UpdateUser).Methods("PUT")
-	adminProtected.HandleFunc("/users/{id}", adminHandler.DeleteUser).Methods("DELETE")
-	adminProtected.HandleFunc("/users/{id}/sessions", adminHandler.GetUserSessions).Methods("GET")
-	adminProtected.HandleFunc("/users/{id}/sessions", adminHandler.RevokeUserSessions).Methods("DELETE")
+	adminProtected.Handle("/users", requirePermission(models.PermUsersWrite, adminHandler.CreateUser)).Methods("POST")
+	adminProtected.Handle("/users/{id}", requirePermission(models.PermUsersWrite, adminHandler.UpdateUser)).Methods("PUT")
+	adminProtected.Handle("/users/{id}", requirePermission(models.PermUsersWrite, adminHandler.DeleteUser)).Methods("DELETE")
+	adminProtected.Handle("/users/{id}/sessions", requirePermission(models.PermUsersRead, adminHandler.GetUserSessions)).Methods("GET")
+	adminProtected.Handle("/users/{id}/consents", requirePermission(models.PermUsersRead, consentHandler.GetUserConsents)).Methods("GET")
+	adminProtected.Handle("/users/{id}/sessions", requirePermission(models.PermUsersWrite, adminHandler.RevokeUserSessions)).Methods("DELETE")
+	adminProtected.Handle("/users/{id}/password", requirePermission(models.PermUsersWrite, passwordPolicyHandler.ResetUserPassword)).Methods("POST")
+
+	// Impersonation
+	adminProtected.Handle("/impersonation", requirePermission(models.PermUsersImpersonate, accountSwitchHandler.StartImpersonation)).Methods("POST")
+	adminProtected.Handle("/impersonation/{id}/stop", requirePermission(models.PermUsersImpersonate, accountSwitchHandler.StopImpersonation)).Methods("POST")
+
+	// Roles and permissions
+	adminProtected.Handle("/permissions", requirePermission(models.PermRolesManage, permissionHandler.ListPermissions)).Methods("GET")
+	adminProtected.Handle("/roles", requirePermission(models.PermRolesManage, permissionHandler.ListRoles)).Methods("GET")
+	adminProtected.Handle("/roles", requirePermission(models.PermRolesManage, permissionHandler.CreateRole)).Methods("POST")
+	adminProtected.Handle("/roles/{name}", requirePermission(models.PermRolesManage, permissionHandler.UpdateRole)).Methods("PUT")
+	adminProtected.Handle("/roles/{name}", requirePermission(models.PermRolesManage, permissionHandler.DeleteRole)).Methods("DELETE")
+	adminProtected.Handle("/permissions/users/{id}/role", requirePermission(models.PermRolesManage, permissionHandler.AssignRole)).Methods("PUT")
+	adminProtected.Handle("/permissions/users/{id}", requirePermission(models.PermRolesManage, permissionHandler.GetUserPermissions)).Methods("GET")
+	adminProtected.Handle("/permissions/users/{id}", requirePermission(models.PermRolesManage, permissionHandler.GrantPermission)).Methods("POST")
+	adminProtected.Handle("/permissions/users/{id}/{permission}", requirePermission(models.PermRolesManage, permissionHandler.RevokePermission)).Methods("DELETE")
+
+	// OAuth clients (products signing in through base-app)
+	adminProtected.Handle("/oauth/clients", requirePermission(models.PermOAuthClientsManage, oauthServerHandler.ListClients)).Methods("GET")
+	adminProtected.Handle("/oauth/clients", requirePermission(models.PermOAuthClientsManage, oauthServerHandler.CreateClient)).Methods("POST")
+	adminProtected.Handle("/oauth/clients/{id}", requirePermission(models.PermOAuthClientsManage, oauthServerHandler.GetClient)).Methods("GET")
+	adminProtected.Handle("/oauth/clients/{id}", requirePermission(models.PermOAuthClientsManage, oauthServerHandler.UpdateClient)).Methods("PUT")
+	adminProtected.Handle("/oauth/clients/{id}", requirePermission(models.PermOAuthClientsManage, oauthServerHandler.DeleteClient)).Methods("DELETE")
+	adminProtected.Handle("/oauth/clients/{id}/secret", requirePermission(models.PermOAuthClientsManage, oauthServerHandler.RotateClientSecret)).Methods("POST")
+
+	// SCIM tokens for identity providers
+	adminProtected.Handle("/scim/tokens", requirePermission(models.PermAdminsManage, scimHandler.ListTokens)).Methods("GET")
+	adminProtected.Handle("/scim/tokens", requirePermission(models.PermAdminsManage, scimHandler.CreateToken)).Methods("POST")
+	adminProtected.Handle("/scim/tokens/{id}", requirePermission(models.PermAdminsManage, scimHandler.RevokeToken)).Methods("DELETE")
 
 	// Static frontend serving - try to serve frontend if it exists
 	// Check for frontend in common locations
@@ -570,53 +969,63 @@ func main() {
 	logger.Info("Server exited")
 }
 
-// Helper function for environment variables
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// openDatabase connects to the configured database, brings its schema up to
+// date and loads the keys its encrypted columns are sealed with.
+func openDatabase(cfg *config.Config, logger *zap.Logger) (*database.DB, error) {
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:                cfg.Database.Driver,
+		Host:                  cfg.Database.Host,
+		Port:                  cfg.Database.Port,
+		User:                  cfg.Database.User,
+		Password:              cfg.Database.Password,
+		Name:                  cfg.Database.Name,
+		SSLMode:               cfg.Database.SSLMode,
+		SQLitePath:            cfg.Database.SQLitePath,
+		MaxConnections:        cfg.Database.MaxConnections,
+		MaxIdleConnections:    cfg.Database.MaxIdleConnections,
+		ConnectionMaxLifetime: cfg.Database.ConnectionMaxLifetime,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
 	}
-	return defaultValue
-}
-
-func seedDefaultAdmin(ctx context.Context, userRepo repositories.UserRepository, logger *zap.Logger) error {
-	const adminEmail = "admin@gmail.com"
-	const adminPassword = "admin123"
-	const adminName = "Admin"
-
-	existing, _ := userRepo.GetByEmail(ctx, adminEmail)
-	if existing != nil {
-		return nil
+	if err := db.RunMigrations("migrations"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
 	}
 
-	hash, err := auth.HashPassword(adminPassword)
+	keyring, err := services.LoadKeyring(context.Background(), cfg.Encryption, cfg.Server.Env == "development", repositories.NewEncryptionRepository(db), logger)
 	if err != nil {
-		return err
+		db.Close()
+		return nil, fmt.Errorf("load encryption keys: %w", err)
 	}
+	db.SetKeyring(keyring)
+	return db, nil
+}
 
-	now := time.Now()
-	admin := &models.User{
-		ID:                uuid.New(),
-		Email:             adminEmail,
-		PasswordHash:      hash,
-		Name:              adminName,
-		Status:            "active",
-		Role:              "admin",
-		PasswordChangedAt: now,
-		CreatedAt:         now,
-		UpdatedAt:         now,
+func passwordHashParams(cfg *config.Config) auth.HashParams {
+	return auth.HashParams{
+		Algorithm:         cfg.Passwords.HashAlgorithm,
+		BcryptCost:        cfg.Passwords.BcryptCost,
+		Argon2Memory:      uint32(cfg.Passwords.Argon2Memory),
+		Argon2Iterations:  uint32(cfg.Passwords.Argon2Iterations),
+		Argon2Parallelism: uint8(cfg.Passwords.Argon2Parallelism),
 	}
+}
 
-	if err := userRepo.Create(ctx, admin); err != nil {
-		return err
+// Helper function for environment variables
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
-
-	logger.Info("Seeded default admin account", zap.String("email", adminEmail))
-	return nil
+	return defaultValue
 }
 
 func seedDefaultCRUDTemplates(ctx context.Context, templateRepo repositories.CRUDTemplateRepository, userRepo repositories.UserRepository, logger *zap.Logger) error {
-	// Get or create admin user for templates
-	admin, _ := userRepo.GetByEmail(ctx, "admin@gmail.com")
+	// Templates belong to the first admin
+	admin, err := userRepo.FirstByRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
 	if admin == nil {
 		// Admin doesn't exist yet, templates will be seeded on next startup
 		return nil
@@ -846,7 +1255,7 @@ func seedDefaultCRUDTemplates(ctx context.Context, templateRepo repositories.CRU
 
 	// Seed each template if it doesn't exist
 	for _, tmpl := range templates {
-		existing, _ := templateRepo.GetByName(ctx, tmpl.name)
+		existing, _ := templateRepo.GetByName(ctx, tmpl.name, nil)
 		if existing != nil {
 			continue // Template already exists
 		}
@@ -881,12 +1290,36 @@ func seedDefaultCRUDTemplates(ctx context.Context, templateRepo repositories.CRU
 	return nil
 }
 
-// startDeletedUserSweeper removes users that have been soft-deleted for more than 5 days.
-func startDeletedUserSweeper(ctx context.Context, userRepo repositories.UserRepository, logger *zap.Logger) {
+// startAccountDeletionSweeper purges accounts whose deletion grace period
+// is over.
+func startAccountDeletionSweeper(ctx context.Context, accountDeletionService *services.AccountDeletionService, logger *zap.Logger) {
 	run := func() {
-		cutoff := time.Now().Add(-5 * 24 * time.Hour)
-		if err := userRepo.PurgeDeletedBefore(context.Background(), cutoff); err != nil {
-			logger.Warn("Failed to purge soft-deleted users", zap.Error(err))
+		if _, err := accountDeletionService.PurgeDue(context.Background()); err != nil {
+			logger.Warn("Failed to purge deleted accounts", zap.Error(err))
+		}
+	}
+
+	run()
+
+	ticker := time.NewTicker(time.Hour)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// startLoginHistorySweeper deletes login events older than LOGIN_HISTORY_RETENTION.
+func startLoginHistorySweeper(ctx context.Context, loginHistoryService *services.LoginHistoryService, logger *zap.Logger) {
+	run := func() {
+		if _, err := loginHistoryService.PurgeExpired(context.Background()); err != nil {
+			logger.Warn("Failed to purge login history", zap.Error(err))
 		}
 	}
 
@@ -905,3 +1338,71 @@ func startDeletedUserSweeper(ctx context.Context, userRepo repositories.UserRepo
 		}
 	}()
 }
+
+// startDataExportSweeper builds exports a restart left pending and deletes
+// archives older than DATA_EXPORT_RETENTION.
+func startDataExportSweeper(ctx context.Context, dataExportService *services.DataExportService, logger *zap.Logger) {
+	run := func() {
+		if _, err := dataExportService.PurgeExpired(context.Background()); err != nil {
+			logger.Warn("Failed to purge data exports", zap.Error(err))
+		}
+	}
+
+	go dataExportService.ResumePending(context.Background())
+	run()
+
+	ticker := time.NewTicker(time.Hour)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// startRetentionSweeper applies the retention rules every interval.
+func startRetentionSweeper(ctx context.Context, retentionService *services.RetentionService, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	run := func() {
+		if _, err := retentionService.Run(ctx); err != nil {
+			logger.Warn("Failed to apply retention rules", zap.Error(err))
+		}
+	}
+
+	go run()
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// openSMSProvider sets up the configured SMS provider.
+func openSMSProvider(cfg config.SMSConfig) (sms.Provider, error) {
+	switch cfg.Provider {
+	case "log":
+		return sms.NewLogProvider(cfg.LogPath)
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("SMS_HTTP_URL is required for the http SMS provider")
+		}
+		return sms.NewHTTPProvider(cfg.HTTPURL, cfg.HTTPToken, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider: %s", cfg.Provider)
+	}
+}
@@ -3,22 +3,43 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server    ServerConfig
-	Database  DatabaseConfig
-	Redis     RedisConfig
-	JWT       JWTConfig
-	Webhook   WebhookConfig
-	RateLimit RateLimitConfig
-	Logging   LoggingConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	JWT          JWTConfig
+	Webhook      WebhookConfig
+	RateLimit    RateLimitConfig
+	Logging      LoggingConfig
+	OIDC         OIDCConfig
+	OAuth        OAuthServerConfig
+	WebAuthn     WebAuthnConfig
+	MagicLink    MagicLinkConfig
+	EmailChange  EmailChangeConfig
+	SMS          SMSConfig
+	Devices      DeviceConfig
+	LoginHistory LoginHistoryConfig
+	Passwords    PasswordConfig
+	Organization OrganizationConfig
+	Invitations  InvitationConfig
+	Setup        SetupConfig
+	SCIM         SCIMConfig
+	DataExport   DataExportConfig
+	Deletion     AccountDeletionConfig
+	Retention    RetentionConfig
+	Encryption   EncryptionConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
+	// TrustedProxies lists the IPs and CIDR ranges of reverse proxies whose
+	// X-Forwarded-For and X-Real-IP headers are honoured. Empty trusts none.
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -46,6 +67,8 @@ type JWTConfig struct {
 	Secret             string
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
+	// ImpersonationMaxDuration caps how long an impersonation token lives.
+	ImpersonationMaxDuration time.Duration
 }
 
 type WebhookConfig struct {
@@ -54,6 +77,184 @@ type WebhookConfig struct {
 	RetryBackoffMultiplier float64
 }
 
+// OIDCConfig lists the external OpenID Connect providers users can sign in
+// with. Providers are named in OIDC_PROVIDERS (comma separated) and each is
+// configured with OIDC_<NAME>_* variables.
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+	StateTTL  time.Duration
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OAuthServerConfig configures base-app acting as an OpenID Connect provider
+// for other products.
+type OAuthServerConfig struct {
+	// Issuer is the public base URL of the provider endpoints; discovery is
+	// served at Issuer + "/.well-known/openid-configuration".
+	Issuer          string
+	ConsentURL      string
+	CodeTTL         time.Duration
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// WebAuthnConfig identifies base-app as the relying party for passkeys.
+// RPID must be the registrable domain the frontend is served from and
+// RPOrigins the exact origins the browser reports.
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+	ChallengeTTL  time.Duration
+}
+
+// MagicLinkConfig controls emailed sign-in links. LoginURL is the page the
+// link opens; the token is appended as the magic_token query parameter. It
+// comes from configuration rather than the request so a forged Origin cannot
+// redirect a token elsewhere.
+type MagicLinkConfig struct {
+	LoginURL      string
+	TokenTTL      time.Duration
+	MaxPerEmail   int
+	RequestWindow time.Duration
+}
+
+// EmailChangeConfig controls changes of a user's email address. LinkURL is
+// the page both emailed links open, with the token in the email_change_token
+// or email_revert_token query parameter. The new address must be confirmed
+// within TokenTTL, and the old address can undo the change for RevertWindow
+// after that.
+type EmailChangeConfig struct {
+	LinkURL      string
+	TokenTTL     time.Duration
+	RevertWindow time.Duration
+}
+
+// SMSConfig selects how text messages are sent. Provider "log", the default,
+// writes them to LogPath, or standard output when empty, for development;
+// "http" posts them as JSON to HTTPURL with HTTPToken as a bearer token.
+// Codes expire after CodeTTL and stop working after MaxAttempts wrong
+// guesses, and a user is sent at most MaxPerHour codes.
+type SMSConfig struct {
+	Provider    string
+	LogPath     string
+	HTTPURL     string
+	HTTPToken   string
+	From        string
+	CodeTTL     time.Duration
+	MaxAttempts int
+	MaxPerHour  int
+}
+
+// DeviceConfig controls device management. A device the user trusts stays
+// trusted for TrustDuration, after which it must be trusted again. Sessions
+// and devices are located with the MaxMind DB file at GeoIPDatabase, if set.
+type DeviceConfig struct {
+	TrustDuration time.Duration
+	GeoIPDatabase string
+}
+
+// LoginHistoryConfig controls the login history. Events older than Retention
+// are deleted.
+type LoginHistoryConfig struct {
+	Retention time.Duration
+}
+
+// PasswordConfig holds password settings that live outside the admin-editable
+// policy. BreachedCorpusPath points to a sorted SHA-1 hash list of leaked
+// passwords; when empty, passwords are not checked against one. The hash
+// settings apply to new hashes; existing ones are upgraded as users sign in.
+type PasswordConfig struct {
+	BreachedCorpusPath string
+	HashAlgorithm      string
+	BcryptCost         int
+	Argon2Memory       int // KiB
+	Argon2Iterations   int
+	Argon2Parallelism  int
+}
+
+// OrganizationConfig controls organization invitations. InvitationURL is the
+// page the emailed link opens, with the token in the org_invitation_token
+// query parameter; invitations expire after InvitationTTL.
+type OrganizationConfig struct {
+	InvitationURL string
+	InvitationTTL time.Duration
+}
+
+// InvitationConfig controls the invitations admins send to onboard users.
+// LinkURL is the page the emailed link opens, with the token in the
+// invitation_token query parameter; invitations expire after TTL.
+type InvitationConfig struct {
+	LinkURL string
+	TTL     time.Duration
+}
+
+// SetupConfig controls first-run setup. Token, when set, is the setup token
+// that creates the first admin; otherwise one is generated and logged at
+// start while no admin exists.
+type SetupConfig struct {
+	Token string
+}
+
+// SCIMConfig controls the SCIM provisioning API. BaseURL is its public
+// address, used for resource locations.
+type SCIMConfig struct {
+	BaseURL string
+}
+
+// DataExportConfig controls personal data exports. Archives are written to
+// Dir and deleted, along with their download links, after Retention.
+// DownloadURL is the public address of the download endpoint; the token is
+// added in the token query parameter.
+type DataExportConfig struct {
+	Dir         string
+	Retention   time.Duration
+	DownloadURL string
+}
+
+// AccountDeletionConfig controls account deletion. Accounts are purged
+// GracePeriod after deletion is requested; until then users who asked for it
+// themselves can cancel by signing in. CRUDPolicy is "anonymize" to keep the
+// user's records in organization CRUD entities without their name on them,
+// or "delete" to remove them with everything else.
+type AccountDeletionConfig struct {
+	GracePeriod time.Duration
+	CRUDPolicy  string
+}
+
+// RetentionConfig controls the purger that applies retention rules. It runs
+// every Interval and deletes at most BatchSize rows per statement, pausing
+// BatchPause between batches so other writers are not locked out for long.
+// Archives of purged rows are written to ArchiveDir.
+type RetentionConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	BatchPause time.Duration
+	ArchiveDir string
+}
+
+// EncryptionConfig holds the master key that wraps the keys sensitive columns
+// are encrypted with. MasterKey, as base64 or hex, takes precedence over
+// MasterKeyFile; outside production a key file is generated when neither
+// exists. PreviousMasterKeys are tried for data keys wrapped before the master
+// key was changed, which are then rewrapped. Re-encryption rewrites at most
+// ReencryptBatchSize values per column at a time.
+type EncryptionConfig struct {
+	MasterKey          string
+	MasterKeyFile      string
+	PreviousMasterKeys []string
+	ReencryptBatchSize int
+}
+
 type RateLimitConfig struct {
 	Enabled   bool
 	KeyPrefix string
@@ -67,8 +268,9 @@ type LoggingConfig struct {
 func Load() (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:           getEnv("PORT", "8080"),
+			Env:            getEnv("ENV", "development"),
+			TrustedProxies: getEnvAsList("TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Driver:                getEnv("DB_DRIVER", "sqlite"),
@@ -90,9 +292,10 @@ func Load() (*Config, error) {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			Secret:             getEnv("JWT_SECRET", "change-me-in-production"),
-			AccessTokenExpiry:  getEnvAsDuration("JWT_ACCESS_TOKEN_EXPIRY", 15*time.Minute),
-			RefreshTokenExpiry: getEnvAsDuration("JWT_REFRESH_TOKEN_EXPIRY", 30*24*time.Hour),
+			Secret:                   getEnv("JWT_SECRET", "change-me-in-production"),
+			AccessTokenExpiry:        getEnvAsDuration("JWT_ACCESS_TOKEN_EXPIRY", 15*time.Minute),
+			RefreshTokenExpiry:       getEnvAsDuration("JWT_REFRESH_TOKEN_EXPIRY", 30*24*time.Hour),
+			ImpersonationMaxDuration: getEnvAsDuration("JWT_IMPERSONATION_MAX_DURATION", 30*time.Minute),
 		},
 		Webhook: WebhookConfig{
 			Secret:                 getEnv("WEBHOOK_SECRET", ""),
@@ -107,11 +310,120 @@ func Load() (*Config, error) {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		OIDC: OIDCConfig{
+			Providers: loadOIDCProviders(),
+			StateTTL:  getEnvAsDuration("OIDC_STATE_TTL", 10*time.Minute),
+		},
+		OAuth: OAuthServerConfig{
+			Issuer:          strings.TrimRight(getEnv("OAUTH_ISSUER", "http://localhost:"+getEnv("PORT", "8080")+"/v1/oauth2"), "/"),
+			ConsentURL:      getEnv("OAUTH_CONSENT_URL", "/consent"),
+			CodeTTL:         getEnvAsDuration("OAUTH_CODE_TTL", time.Minute),
+			AccessTokenTTL:  getEnvAsDuration("OAUTH_ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL: getEnvAsDuration("OAUTH_REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:          getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName: getEnv("WEBAUTHN_RP_NAME", "Base App"),
+			RPOrigins:     getEnvAsList("WEBAUTHN_RP_ORIGINS", []string{"http://localhost:" + getEnv("PORT", "8080")}),
+			ChallengeTTL:  getEnvAsDuration("WEBAUTHN_CHALLENGE_TTL", 5*time.Minute),
+		},
+		MagicLink: MagicLinkConfig{
+			LoginURL:      getEnv("MAGIC_LINK_URL", "http://localhost:"+getEnv("PORT", "8080")+"/"),
+			TokenTTL:      getEnvAsDuration("MAGIC_LINK_TTL", 15*time.Minute),
+			MaxPerEmail:   getEnvAsInt("MAGIC_LINK_MAX_PER_EMAIL", 3),
+			RequestWindow: getEnvAsDuration("MAGIC_LINK_REQUEST_WINDOW", 15*time.Minute),
+		},
+		EmailChange: EmailChangeConfig{
+			LinkURL:      getEnv("EMAIL_CHANGE_URL", "http://localhost:"+getEnv("PORT", "8080")+"/"),
+			TokenTTL:     getEnvAsDuration("EMAIL_CHANGE_TTL", 24*time.Hour),
+			RevertWindow: getEnvAsDuration("EMAIL_CHANGE_REVERT_WINDOW", 7*24*time.Hour),
+		},
+		SMS: SMSConfig{
+			Provider:    getEnv("SMS_PROVIDER", "log"),
+			LogPath:     getEnv("SMS_LOG_PATH", ""),
+			HTTPURL:     getEnv("SMS_HTTP_URL", ""),
+			HTTPToken:   getEnv("SMS_HTTP_TOKEN", ""),
+			From:        getEnv("SMS_FROM", "BaseApp"),
+			CodeTTL:     getEnvAsDuration("SMS_CODE_TTL", 10*time.Minute),
+			MaxAttempts: getEnvAsInt("SMS_MAX_ATTEMPTS", 5),
+			MaxPerHour:  getEnvAsInt("SMS_MAX_PER_HOUR", 5),
+		},
+		Devices: DeviceConfig{
+			TrustDuration: getEnvAsDuration("DEVICE_TRUST_DURATION", 30*24*time.Hour),
+			GeoIPDatabase: getEnv("GEOIP_DB_PATH", ""),
+		},
+		LoginHistory: LoginHistoryConfig{
+			Retention: getEnvAsDuration("LOGIN_HISTORY_RETENTION", 90*24*time.Hour),
+		},
+		Passwords: PasswordConfig{
+			BreachedCorpusPath: getEnv("BREACHED_PASSWORDS_PATH", ""),
+			HashAlgorithm:      getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			BcryptCost:         getEnvAsInt("PASSWORD_BCRYPT_COST", 12),
+			Argon2Memory:       getEnvAsInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+			Argon2Iterations:   getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3),
+			Argon2Parallelism:  getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 2),
+		},
+		Organization: OrganizationConfig{
+			InvitationURL: getEnv("ORG_INVITATION_URL", "http://localhost:"+getEnv("PORT", "8080")+"/"),
+			InvitationTTL: getEnvAsDuration("ORG_INVITATION_TTL", 7*24*time.Hour),
+		},
+		Invitations: InvitationConfig{
+			LinkURL: getEnv("INVITATION_URL", "http://localhost:"+getEnv("PORT", "8080")+"/"),
+			TTL:     getEnvAsDuration("INVITATION_TTL", 72*time.Hour),
+		},
+		Setup: SetupConfig{
+			Token: os.Getenv("SETUP_TOKEN"),
+		},
+		SCIM: SCIMConfig{
+			BaseURL: strings.TrimRight(getEnv("SCIM_BASE_URL", "http://localhost:"+getEnv("PORT", "8080")+"/scim/v2"), "/"),
+		},
+		DataExport: DataExportConfig{
+			Dir:         getEnv("DATA_EXPORT_DIR", "exports"),
+			Retention:   getEnvAsDuration("DATA_EXPORT_RETENTION", 7*24*time.Hour),
+			DownloadURL: getEnv("DATA_EXPORT_URL", "http://localhost:"+getEnv("PORT", "8080")+"/v1/exports/download"),
+		},
+		Deletion: AccountDeletionConfig{
+			GracePeriod: getEnvAsDuration("ACCOUNT_DELETION_GRACE_PERIOD", 5*24*time.Hour),
+			CRUDPolicy:  getEnv("ACCOUNT_DELETION_CRUD_POLICY", "anonymize"),
+		},
+		Retention: RetentionConfig{
+			Interval:   getEnvAsDuration("RETENTION_INTERVAL", time.Hour),
+			BatchSize:  getEnvAsInt("RETENTION_BATCH_SIZE", 500),
+			BatchPause: getEnvAsDuration("RETENTION_BATCH_PAUSE", 100*time.Millisecond),
+			ArchiveDir: getEnv("RETENTION_ARCHIVE_DIR", "archives"),
+		},
+		Encryption: EncryptionConfig{
+			MasterKey:          getEnv("ENCRYPTION_MASTER_KEY", ""),
+			MasterKeyFile:      getEnv("ENCRYPTION_MASTER_KEY_FILE", "master.key"),
+			PreviousMasterKeys: getEnvAsList("ENCRYPTION_PREVIOUS_MASTER_KEYS", nil),
+			ReencryptBatchSize: getEnvAsInt("ENCRYPTION_REENCRYPT_BATCH_SIZE", 200),
+		},
 	}
 
 	return cfg, nil
 }
 
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			IssuerURL:    getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		})
+	}
+	return providers
+}
+
 // Helper functions
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -155,3 +467,16 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvAsList(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
@@ -13,11 +13,14 @@ import (
 	"go.uber.org/zap"
 
 	_ "modernc.org/sqlite"
+
+	"base-app-service/pkg/encryption"
 )
 
 type DB struct {
 	*sql.DB
-	logger *zap.Logger
+	logger  *zap.Logger
+	keyring *encryption.Keyring
 }
 
 type DatabaseConfig struct {
@@ -91,8 +94,20 @@ func (db *DB) Close() error {
 	return db.DB.Close()
 }
 
+// SetKeyring sets the keys repositories encrypt sensitive columns with. It is
+// meant to be called once, before the database is used. Without a keyring
+// those columns are stored as they are.
+func (db *DB) SetKeyring(keyring *encryption.Keyring) {
+	db.keyring = keyring
+}
+
+// Keyring returns the keys set with SetKeyring, or nil.
+func (db *DB) Keyring() *encryption.Keyring {
+	return db.keyring
+}
+
 // Transaction helper
-func (db *DB) WithTransaction(ctx context.Context, fn func(*sql.Tx) error) error {
+func (db *DB) WithTransaction(ctx context.Context, fn func(*sql.Tx) error) (err error) {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -121,11 +136,6 @@ func (db *DB) RunMigrations(migrationsDir string) error {
 		return fmt.Errorf("create migrations_applied: %w", err)
 	}
 
-	// Ensure critical columns/tables exist even if older DB file is present
-	if err := db.ensureAdminSupport(); err != nil {
-		return err
-	}
-
 	files, err := os.ReadDir(migrationsDir)
 	if err != nil {
 		return fmt.Errorf("read migrations dir: %w", err)
@@ -170,7 +180,9 @@ func (db *DB) RunMigrations(migrationsDir string) error {
 		}
 	}
 
-	return nil
+	// Ensure critical columns/tables exist even if older DB file is present.
+	// This runs after the migrations so a fresh database already has users.
+	return db.ensureAdminSupport()
 }
 
 // ensureAdminSupport patches older SQLite files by adding the role column and admin tables if missing.
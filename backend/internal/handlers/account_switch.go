@@ -3,8 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
 	"base-app-service/internal/middleware"
@@ -76,3 +79,80 @@ func (h *AccountSwitchHandler) GetSwitchHistory(w http.ResponseWriter, r *http.R
 	})
 }
 
+
+type startImpersonationRequest struct {
+	UserID          uuid.UUID `json:"user_id" validate:"required"`
+	Reason          string    `json:"reason" validate:"required,max=500"`
+	DurationMinutes int       `json:"duration_minutes" validate:"omitempty,min=1"`
+}
+
+// StartImpersonation issues an impersonation token for the target user.
+func (h *AccountSwitchHandler) StartImpersonation(w http.ResponseWriter, r *http.Request) {
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if actorID == uuid.Nil {
+		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
+		return
+	}
+
+	var req startImpersonationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	ipAddress := getIPAddress(r)
+	userAgent := r.UserAgent()
+
+	session, err := h.accountSwitchService.StartImpersonation(r.Context(), actorID, req.UserID, req.Reason,
+		time.Duration(req.DurationMinutes)*time.Minute, &ipAddress, &userAgent)
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case strings.HasPrefix(err.Error(), "cannot"):
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		default:
+			errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    session,
+	})
+}
+
+// StopImpersonation ends the impersonation session the caller is using, or,
+// for an admin's own token, the session given in the URL.
+func (h *AccountSwitchHandler) StopImpersonation(w http.ResponseWriter, r *http.Request) {
+	actorID := middleware.GetActorIDFromContext(r.Context())
+	impersonationID := middleware.GetImpersonationIDFromContext(r.Context())
+	if actorID == uuid.Nil {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "not impersonating")
+			return
+		}
+		actorID = middleware.GetUserIDFromContext(r.Context())
+		impersonationID = id
+	}
+
+	if err := h.accountSwitchService.StopImpersonation(r.Context(), actorID, impersonationID); err != nil {
+		if err.Error() == "impersonation session not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Impersonation stopped",
+	})
+}
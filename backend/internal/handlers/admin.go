@@ -12,6 +12,7 @@ import (
 	"go.uber.org/zap"
 
 	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
 	"base-app-service/internal/services"
 	"base-app-service/pkg/errors"
 )
@@ -21,15 +22,17 @@ type AdminHandler struct {
 	adminSettingsService *services.AdminSettingsService
 	customCRUDService    *services.CustomCRUDService
 	crudTemplateService  *services.CRUDTemplateService
+	deletions            *services.AccountDeletionService
 	logger               *zap.Logger
 }
 
-func NewAdminHandler(adminService *services.AdminService, adminSettingsService *services.AdminSettingsService, customCRUDService *services.CustomCRUDService, crudTemplateService *services.CRUDTemplateService, logger *zap.Logger) *AdminHandler {
+func NewAdminHandler(adminService *services.AdminService, adminSettingsService *services.AdminSettingsService, customCRUDService *services.CustomCRUDService, crudTemplateService *services.CRUDTemplateService, deletions *services.AccountDeletionService, logger *zap.Logger) *AdminHandler {
 	return &AdminHandler{
 		adminService:         adminService,
 		adminSettingsService: adminSettingsService,
 		customCRUDService:    customCRUDService,
 		crudTemplateService:  crudTemplateService,
+		deletions:            deletions,
 		logger:               logger,
 	}
 }
@@ -67,14 +70,19 @@ func (h *AdminHandler) Login(w http.ResponseWriter, r *http.Request) {
 	agent := r.UserAgent()
 
 	user, session, err := h.adminService.Login(r.Context(), services.AdminLoginRequest{
-		Email:      req.Email,
-		Password:   req.Password,
-		DeviceID:   req.DeviceID,
-		DeviceName: req.DeviceName,
-		IPAddress:  &ip,
-		UserAgent:  &agent,
+		Email:            req.Email,
+		Password:         req.Password,
+		DeviceID:         req.DeviceID,
+		DeviceName:       req.DeviceName,
+		DeviceTrustToken: deviceTrustToken(r),
+		IPAddress:        &ip,
+		UserAgent:        &agent,
 	})
 	if err != nil {
+		if challenge, ok := err.(*services.TwoFactorRequiredError); ok {
+			respondTwoFactorChallenge(w, challenge)
+			return
+		}
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
 		return
 	}
@@ -291,9 +299,30 @@ func (h *AdminHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// crudTenant is whose custom CRUD entities a request works with. Outside an
+// organization, users with cruds.manage reach every user's entities.
+func crudTenant(r *http.Request) *models.Tenant {
+	tenant := middleware.GetTenantFromContext(r.Context())
+	if tenant.OrganizationID == nil && middleware.HasPermission(r.Context(), models.PermCRUDsManage) {
+		tenant.Unrestricted = true
+	}
+	return tenant
+}
+
+// respondCRUDError maps custom CRUD service errors to responses.
+func respondCRUDError(w http.ResponseWriter, err error) {
+	switch msg := err.Error(); msg {
+	case "entity not found", "data not found":
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", msg)
+	case "organization admin role required":
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	default:
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", msg)
+	}
+}
+
 // Custom CRUD Entity handlers
 func (h *AdminHandler) CreateCRUDEntity(w http.ResponseWriter, r *http.Request) {
-	adminID := middleware.GetUserIDFromContext(r.Context())
 	var req struct {
 		EntityName  string                 `json:"entity_name" validate:"required"`
 		DisplayName string                 `json:"display_name" validate:"required"`
@@ -304,9 +333,9 @@ func (h *AdminHandler) CreateCRUDEntity(w http.ResponseWriter, r *http.Request)
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
-	entity, err := h.customCRUDService.CreateEntity(r.Context(), adminID, req.EntityName, req.DisplayName, req.Description, req.Schema)
+	entity, err := h.customCRUDService.CreateEntity(r.Context(), crudTenant(r), req.EntityName, req.DisplayName, req.Description, req.Schema)
 	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
@@ -317,15 +346,7 @@ func (h *AdminHandler) CreateCRUDEntity(w http.ResponseWriter, r *http.Request)
 
 func (h *AdminHandler) ListCRUDEntities(w http.ResponseWriter, r *http.Request) {
 	activeOnly := r.URL.Query().Get("active_only") == "true"
-	// Get user ID from context - if present, filter by user; if admin, show all
-	userID := middleware.GetUserIDFromContext(r.Context())
-	var createdBy *uuid.UUID
-	// If not admin, only show entities created by this user
-	userRole := middleware.GetUserRoleFromContext(r.Context())
-	if userRole != "admin" {
-		createdBy = &userID
-	}
-	entities, err := h.customCRUDService.ListEntities(r.Context(), createdBy, activeOnly)
+	entities, err := h.customCRUDService.ListEntities(r.Context(), crudTenant(r), activeOnly)
 	if err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
@@ -343,9 +364,9 @@ func (h *AdminHandler) GetCRUDEntity(w http.ResponseWriter, r *http.Request) {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid id")
 		return
 	}
-	entity, err := h.customCRUDService.GetEntity(r.Context(), id)
+	entity, err := h.customCRUDService.GetEntity(r.Context(), id, crudTenant(r))
 	if err != nil {
-		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -366,9 +387,9 @@ func (h *AdminHandler) UpdateCRUDEntity(w http.ResponseWriter, r *http.Request)
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
-	entity, err := h.customCRUDService.UpdateEntity(r.Context(), id, updates)
+	entity, err := h.customCRUDService.UpdateEntity(r.Context(), id, crudTenant(r), updates)
 	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -384,8 +405,8 @@ func (h *AdminHandler) DeleteCRUDEntity(w http.ResponseWriter, r *http.Request)
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid id")
 		return
 	}
-	if err := h.customCRUDService.DeleteEntity(r.Context(), id); err != nil {
-		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	if err := h.customCRUDService.DeleteEntity(r.Context(), id, crudTenant(r)); err != nil {
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -396,7 +417,6 @@ func (h *AdminHandler) DeleteCRUDEntity(w http.ResponseWriter, r *http.Request)
 
 // Custom CRUD Data handlers
 func (h *AdminHandler) CreateCRUDData(w http.ResponseWriter, r *http.Request) {
-	adminID := middleware.GetUserIDFromContext(r.Context())
 	vars := mux.Vars(r)
 	entityID, err := uuid.Parse(vars["id"])
 	if err != nil {
@@ -408,9 +428,9 @@ func (h *AdminHandler) CreateCRUDData(w http.ResponseWriter, r *http.Request) {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
-	crudData, err := h.customCRUDService.CreateData(r.Context(), entityID, adminID, data)
+	crudData, err := h.customCRUDService.CreateData(r.Context(), entityID, crudTenant(r), data)
 	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
@@ -438,9 +458,9 @@ func (h *AdminHandler) ListCRUDData(w http.ResponseWriter, r *http.Request) {
 			offset = parsed
 		}
 	}
-	data, err := h.customCRUDService.ListData(r.Context(), entityID, limit, offset)
+	data, err := h.customCRUDService.ListData(r.Context(), entityID, crudTenant(r), limit, offset)
 	if err != nil {
-		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -456,9 +476,9 @@ func (h *AdminHandler) GetCRUDData(w http.ResponseWriter, r *http.Request) {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid id")
 		return
 	}
-	data, err := h.customCRUDService.GetData(r.Context(), id)
+	data, err := h.customCRUDService.GetData(r.Context(), id, crudTenant(r))
 	if err != nil {
-		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -468,7 +488,6 @@ func (h *AdminHandler) GetCRUDData(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AdminHandler) UpdateCRUDData(w http.ResponseWriter, r *http.Request) {
-	adminID := middleware.GetUserIDFromContext(r.Context())
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
@@ -480,9 +499,9 @@ func (h *AdminHandler) UpdateCRUDData(w http.ResponseWriter, r *http.Request) {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
-	crudData, err := h.customCRUDService.UpdateData(r.Context(), id, adminID, data)
+	crudData, err := h.customCRUDService.UpdateData(r.Context(), id, crudTenant(r), data)
 	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -498,8 +517,8 @@ func (h *AdminHandler) DeleteCRUDData(w http.ResponseWriter, r *http.Request) {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid id")
 		return
 	}
-	if err := h.customCRUDService.DeleteData(r.Context(), id); err != nil {
-		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	if err := h.customCRUDService.DeleteData(r.Context(), id, crudTenant(r)); err != nil {
+		respondCRUDError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -508,141 +527,24 @@ func (h *AdminHandler) DeleteCRUDData(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// VerifyAdminCode - Public endpoint to verify admin creation code (no auth required)
-func (h *AdminHandler) VerifyAdminCode(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		VerificationCode string `json:"verification_code" validate:"required"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
-		return
-	}
-
-	// Get expected code
-	defaultCode := "Kompasstech2025@"
-	expectedCode := defaultCode
-
-	systemCode, err := h.adminSettingsService.GetSystemVerificationCode(r.Context())
-	if err == nil {
-		expectedCode = systemCode
-	}
-
-	// Trim and compare codes (case-sensitive for security)
-	providedCode := strings.TrimSpace(req.VerificationCode)
-	expectedCode = strings.TrimSpace(expectedCode)
-
-	if providedCode != expectedCode {
-		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Invalid verification code")
-		return
-	}
-
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "Verification code is valid",
-	})
-}
-
-// CreateAdminPublic - Public endpoint for creating admin with verification code (no auth required)
-func (h *AdminHandler) CreateAdminPublic(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Email            string `json:"email" validate:"required,email"`
-		Password         string `json:"password" validate:"required,min=8"`
-		Name             string `json:"name" validate:"required"`
-		VerificationCode string `json:"verification_code" validate:"required"` // Required for public admin creation
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
-		return
-	}
-
-	// Verify code
-	defaultCode := "Kompasstech2025@"
-	expectedCode := defaultCode
-
-	systemCode, err := h.adminSettingsService.GetSystemVerificationCode(r.Context())
-	if err == nil {
-		expectedCode = systemCode
-	}
-
-	// Trim and compare codes
-	providedCode := strings.TrimSpace(req.VerificationCode)
-	expectedCode = strings.TrimSpace(expectedCode)
-
-	if providedCode != expectedCode {
-		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Invalid verification code")
-		return
-	}
-
-	// Create admin user
-	user, err := h.adminService.CreateUser(r.Context(), uuid.Nil, services.CreateUserRequest{
-		Email:    req.Email,
-		Name:     req.Name,
-		Password: req.Password,
-		Role:     "admin",
-		Status:   "active",
-	})
-	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
-			return
-		}
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
-		return
-	}
-
-	respondJSON(w, http.StatusCreated, map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
-			"id":    user.ID.String(),
-			"email": user.Email,
-			"name":  user.Name,
-			"role":  user.Role,
-		},
-	})
-}
-
 // Enhanced User CRUD handlers
 func (h *AdminHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Email            string `json:"email" validate:"required,email"`
-		Password         string `json:"password" validate:"required,min=8"`
-		Name             string `json:"name" validate:"required"`
-		Role             string `json:"role"`
-		Status           string `json:"status"`
-		VerificationCode string `json:"verification_code"` // For admin creation
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required,min=8"`
+		Name     string `json:"name" validate:"required"`
+		Role     string `json:"role"`
+		Status   string `json:"status"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
 
-	// If creating admin, verify code (only for public admin creation)
-	if req.Role == "admin" {
-		adminID := middleware.GetUserIDFromContext(r.Context())
-		if adminID == uuid.Nil {
-			// Public admin creation - check verification code
-			// Try to get verification code from first admin's settings, or use default
-			defaultCode := "Kompasstech2025@"
-			expectedCode := defaultCode
-
-			// Try to get from any admin's settings (use first admin found)
-			// For simplicity, we'll use the default code
-			// In production, you might want to query for the first admin and get their settings
-			systemCode, err := h.adminSettingsService.GetSystemVerificationCode(r.Context())
-			if err == nil {
-				expectedCode = systemCode
-			}
-
-			// Trim and compare codes
-			providedCode := strings.TrimSpace(req.VerificationCode)
-			expectedCode = strings.TrimSpace(expectedCode)
-
-			if providedCode != expectedCode {
-				errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Invalid verification code")
-				return
-			}
-		}
-		// If adminID != uuid.Nil, an admin is logged in creating another admin - no verification needed
+	// Creating an admin needs the admins permission on top of users:write
+	if req.Role == "admin" && !middleware.HasPermission(r.Context(), models.PermAdminsManage) {
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Missing permission "+models.PermAdminsManage)
+		return
 	}
 
 	if req.Role == "" {
@@ -708,13 +610,29 @@ func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	adminID := middleware.GetUserIDFromContext(r.Context())
-	if err := h.adminService.SetUserStatus(r.Context(), adminID, userID, "deleted"); err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	if userID == adminID {
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", "use account settings to delete your own account")
+		return
+	}
+	deletion, err := h.deletions.Request(r.Context(), userID, models.AccountDeletionByAdmin, &adminID)
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case "account deletion already requested":
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+		case "cannot manage an account with permissions you do not hold", "cannot delete the last admin":
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		default:
+			h.logger.Error("Failed to delete user", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete user")
+		}
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "User deleted successfully",
+		"data":    deletion,
 	})
 }
 
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+	logger        *zap.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name       string     `json:"name" validate:"required,max=100"`
+	Scopes     []string   `json:"scopes" validate:"required,min=1"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// Create issues a new API key. The plaintext token is only returned here.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	if userID == uuid.Nil {
+		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	key, token, err := h.apiKeyService.CreateKey(r.Context(), userID, services.CreateAPIKeyRequest{
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		AllowedIPs: req.AllowedIPs,
+		ExpiresAt:  req.ExpiresAt,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "requires admin role") {
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+			return
+		}
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"api_key": key,
+			"token":   token,
+		},
+		"message": "Store this token now; it will not be shown again",
+	})
+}
+
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	if userID == uuid.Nil {
+		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(r.Context(), userID)
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	if userID == uuid.Nil {
+		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
+		return
+	}
+
+	keyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid api key id")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(r.Context(), userID, keyID); err != nil {
+		if err.Error() == "api key not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "API key revoked",
+	})
+}
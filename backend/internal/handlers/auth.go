@@ -129,6 +129,10 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
 			return
 		}
+		if strings.HasPrefix(err.Error(), "password validation failed") || isTermsError(err) {
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
@@ -193,18 +197,31 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	serviceReq := services.LoginRequest{
-		Email:      req.Email,
-		Password:   req.Password,
-		RememberMe: req.RememberMe,
-		IPAddress:  &ipAddress,
-		UserAgent:  &userAgent,
-		DeviceID:   deviceIDPtr,
-		DeviceName: deviceNamePtr,
+		Email:            req.Email,
+		Password:         req.Password,
+		RememberMe:       req.RememberMe,
+		IPAddress:        &ipAddress,
+		UserAgent:        &userAgent,
+		DeviceID:         deviceIDPtr,
+		DeviceName:       deviceNamePtr,
+		DeviceTrustToken: deviceTrustToken(r),
 	}
 
 	user, session, isNewDevice, err := h.authService.Login(r.Context(), serviceReq)
 	if err != nil {
-		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid credentials")
+		if challenge, ok := err.(*services.TwoFactorRequiredError); ok {
+			respondTwoFactorChallenge(w, challenge)
+			return
+		}
+		switch msg := err.Error(); {
+		case msg == "too many codes requested, try again later":
+			errors.RespondError(w, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", msg)
+		case strings.HasPrefix(msg, "failed to send text message"):
+			h.logger.Error("Failed to send sign-in code", zap.Error(err))
+			errors.RespondError(w, http.StatusBadGateway, "PROVIDER_UNAVAILABLE", "Could not send the sign-in code")
+		default:
+			errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid credentials")
+		}
 		return
 	}
 
@@ -231,7 +248,9 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 				"refresh_token": *session.RefreshToken,
 				"expires_at":    session.ExpiresAt.Format(time.RFC3339),
 			},
-			"device": deviceData,
+			"device":                   deviceData,
+			"password_change_required": h.authService.PasswordChangeRequired(r.Context(), user),
+			"consent_required":         h.authService.ConsentRequired(r.Context(), user),
 		},
 	})
 }
@@ -248,7 +267,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := h.authService.RefreshToken(r.Context(), req.RefreshToken)
+	session, err := h.authService.RefreshToken(r.Context(), req.RefreshToken, clientInfo(r))
 	if err != nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
 		return
@@ -352,6 +371,10 @@ func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 			errors.RespondError(w, http.StatusBadRequest, "INVALID_TOKEN", err.Error())
 			return
 		}
+		if strings.HasPrefix(err.Error(), "password validation failed") {
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
@@ -364,24 +387,32 @@ func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 
 // Helper functions
 func getIPAddress(r *http.Request) string {
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		ip = r.Header.Get("X-Real-IP")
+	return middleware.GetIPAddress(r)
+}
+
+// clientInfo collects the request metadata recorded with a new session.
+func clientInfo(r *http.Request) services.ClientInfo {
+	ipAddress := getIPAddress(r)
+	userAgent := r.UserAgent()
+	info := services.ClientInfo{IPAddress: &ipAddress, UserAgent: &userAgent}
+	if deviceID := r.Header.Get("X-Device-ID"); deviceID != "" && len(deviceID) <= 255 {
+		info.DeviceID = &deviceID
 	}
-	if ip == "" {
-		ip = r.RemoteAddr
+	if deviceName := r.Header.Get("X-Device-Name"); deviceName != "" && len(deviceName) <= 255 {
+		info.DeviceName = &deviceName
 	}
+	info.DeviceTrustToken = deviceTrustToken(r)
+	return info
+}
 
-	// Remove port from IP address (PostgreSQL INET type doesn't accept ports)
-	// Handle IPv6 addresses like [::1]:57378
-	if idx := strings.LastIndex(ip, "]:"); idx != -1 {
-		ip = ip[1:idx] // Remove [ and :port
-	} else if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		// Handle IPv4 addresses like 127.0.0.1:57378
-		ip = ip[:idx]
+// deviceTrustToken returns the X-Device-Trust token a trusted device sends
+// to skip the second factor, or nil.
+func deviceTrustToken(r *http.Request) *string {
+	token := r.Header.Get("X-Device-Trust")
+	if token == "" || len(token) > 128 {
+		return nil
 	}
-
-	return ip
+	return &token
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
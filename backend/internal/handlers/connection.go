@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type ConnectionHandler struct {
+	connectionService *services.ConnectionService
+	logger            *zap.Logger
+}
+
+func NewConnectionHandler(connectionService *services.ConnectionService, logger *zap.Logger) *ConnectionHandler {
+	return &ConnectionHandler{
+		connectionService: connectionService,
+		logger:            logger,
+	}
+}
+
+// ListFriends returns a page of the signed-in user's friends.
+func (h *ConnectionHandler) ListFriends(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	friends, total, err := h.connectionService.ListFriends(r.Context(), middleware.GetUserIDFromContext(r.Context()), limit, offset)
+	if err != nil {
+		h.respondError(w, err, "Failed to list friends")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    friends,
+		"total":   total,
+	})
+}
+
+// RemoveFriend ends a friendship.
+func (h *ConnectionHandler) RemoveFriend(w http.ResponseWriter, r *http.Request) {
+	friendID, ok := pathUUID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.connectionService.RemoveFriend(r.Context(), middleware.GetUserIDFromContext(r.Context()), friendID); err != nil {
+		h.respondError(w, err, "Failed to remove friend")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Friend removed",
+	})
+}
+
+// ListRequests returns pending friend requests sent to the signed-in user, or
+// sent by them with direction=outgoing.
+func (h *ConnectionHandler) ListRequests(w http.ResponseWriter, r *http.Request) {
+	incoming := r.URL.Query().Get("direction") != "outgoing"
+
+	requests, err := h.connectionService.ListRequests(r.Context(), middleware.GetUserIDFromContext(r.Context()), incoming)
+	if err != nil {
+		h.respondError(w, err, "Failed to list friend requests")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    requests,
+	})
+}
+
+// SendRequest sends a friend request, or accepts theirs if the other user
+// already sent one.
+func (h *ConnectionHandler) SendRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID uuid.UUID `json:"user_id" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	connection, err := h.connectionService.SendRequest(r.Context(), middleware.GetUserIDFromContext(r.Context()), req.UserID)
+	if err != nil {
+		h.respondError(w, err, "Failed to send friend request")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    connection,
+	})
+}
+
+func (h *ConnectionHandler) AcceptRequest(w http.ResponseWriter, r *http.Request) {
+	requestID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	connection, err := h.connectionService.AcceptRequest(r.Context(), middleware.GetUserIDFromContext(r.Context()), requestID)
+	if err != nil {
+		h.respondError(w, err, "Failed to accept friend request")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    connection,
+	})
+}
+
+func (h *ConnectionHandler) DeclineRequest(w http.ResponseWriter, r *http.Request) {
+	requestID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.connectionService.DeclineRequest(r.Context(), middleware.GetUserIDFromContext(r.Context()), requestID); err != nil {
+		h.respondError(w, err, "Failed to decline friend request")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Friend request declined",
+	})
+}
+
+func (h *ConnectionHandler) CancelRequest(w http.ResponseWriter, r *http.Request) {
+	requestID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.connectionService.CancelRequest(r.Context(), middleware.GetUserIDFromContext(r.Context()), requestID); err != nil {
+		h.respondError(w, err, "Failed to cancel friend request")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Friend request cancelled",
+	})
+}
+
+func (h *ConnectionHandler) ListBlocked(w http.ResponseWriter, r *http.Request) {
+	blocked, err := h.connectionService.ListBlocked(r.Context(), middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		h.respondError(w, err, "Failed to list blocked users")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    blocked,
+	})
+}
+
+func (h *ConnectionHandler) Block(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID uuid.UUID `json:"user_id" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	if err := h.connectionService.Block(r.Context(), middleware.GetUserIDFromContext(r.Context()), req.UserID); err != nil {
+		h.respondError(w, err, "Failed to block user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "User blocked",
+	})
+}
+
+func (h *ConnectionHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.connectionService.Unblock(r.Context(), middleware.GetUserIDFromContext(r.Context()), userID); err != nil {
+		h.respondError(w, err, "Failed to unblock user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "User unblocked",
+	})
+}
+
+func (h *ConnectionHandler) respondError(w http.ResponseWriter, err error, fallback string) {
+	msg := err.Error()
+	switch msg {
+	case "user not found", "friend request not found", "not friends", "user is not blocked":
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", msg)
+	case "cannot send a friend request to this user":
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	case "already friends", "friend request already sent":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	case "cannot send a friend request to yourself", "cannot block yourself":
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+	default:
+		h.logger.Error(fallback, zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type ConsentHandler struct {
+	consentService *services.ConsentService
+	logger         *zap.Logger
+}
+
+func NewConsentHandler(consentService *services.ConsentService, logger *zap.Logger) *ConsentHandler {
+	return &ConsentHandler{
+		consentService: consentService,
+		logger:         logger,
+	}
+}
+
+// CurrentDocuments returns the current version of each legal document, for
+// sign-up forms to show and send back the version of.
+func (h *ConsentHandler) CurrentDocuments(w http.ResponseWriter, r *http.Request) {
+	docs, err := h.consentService.Current(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list legal documents", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list legal documents")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    docs,
+	})
+}
+
+// GetMyConsents returns the documents the user has to accept, their
+// marketing choice and their consent history.
+func (h *ConsentHandler) GetMyConsents(w http.ResponseWriter, r *http.Request) {
+	h.respondStatus(w, r, middleware.GetUserIDFromContext(r.Context()))
+}
+
+// Accept records the user accepting the current version of a document.
+func (h *ConsentHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind    string `json:"kind" validate:"required"`
+		Version string `json:"version" validate:"required,max=50"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	record, err := h.consentService.Accept(r.Context(), userID, req.Kind, req.Version, clientInfo(r))
+	if err != nil {
+		switch err.Error() {
+		case "unknown document kind", "only the current version can be accepted":
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		default:
+			h.logger.Error("Failed to record consent", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record consent")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    record,
+	})
+}
+
+// UpdateMarketing opts the user in to or out of marketing.
+func (h *ConsentHandler) UpdateMarketing(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Granted *bool `json:"granted" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	record, err := h.consentService.SetMarketing(r.Context(), userID, *req.Granted, clientInfo(r))
+	if err != nil {
+		h.logger.Error("Failed to record marketing consent", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record marketing consent")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    record,
+	})
+}
+
+// ListDocuments returns every published version with how many users
+// accepted it.
+func (h *ConsentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	docs, err := h.consentService.Documents(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list legal documents", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list legal documents")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    docs,
+	})
+}
+
+// Publish publishes a new version of a legal document.
+func (h *ConsentHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind     string  `json:"kind" validate:"required"`
+		Version  string  `json:"version" validate:"required,max=50"`
+		Title    string  `json:"title" validate:"required,max=255"`
+		URL      *string `json:"url" validate:"omitempty,url,max=2048"`
+		Required bool    `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	adminID := middleware.GetUserIDFromContext(r.Context())
+	doc, err := h.consentService.Publish(r.Context(), adminID, services.PublishDocumentRequest{
+		Kind:     req.Kind,
+		Version:  req.Version,
+		Title:    req.Title,
+		URL:      req.URL,
+		Required: req.Required,
+	})
+	if err != nil {
+		switch err.Error() {
+		case "unknown document kind", "version is required", "version is too long", "title is required":
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		case "version already published":
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+		default:
+			h.logger.Error("Failed to publish legal document", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to publish legal document")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    doc,
+	})
+}
+
+// GetUserConsents returns a user's pending documents, marketing choice and
+// consent ledger.
+func (h *ConsentHandler) GetUserConsents(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid user id")
+		return
+	}
+	h.respondStatus(w, r, userID)
+}
+
+func (h *ConsentHandler) respondStatus(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	status, err := h.consentService.Status(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get consents", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get consents")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// isTermsError reports whether err rejects the terms accepted for a new
+// account.
+func isTermsError(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "terms ") || msg == "unknown terms version"
+}
@@ -8,9 +8,32 @@ import (
 	"github.com/gorilla/mux"
 
 	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
 	"base-app-service/pkg/errors"
 )
 
+// templateTenant is whose templates a request manages: its organization's,
+// or the platform's for users with templates.manage outside an organization.
+func templateTenant(r *http.Request) *models.Tenant {
+	tenant := middleware.GetTenantFromContext(r.Context())
+	if tenant.OrganizationID == nil && middleware.HasPermission(r.Context(), models.PermTemplatesManage) {
+		tenant.Unrestricted = true
+	}
+	return tenant
+}
+
+// respondTemplateError maps CRUD template service errors to responses.
+func respondTemplateError(w http.ResponseWriter, err error) {
+	switch msg := err.Error(); msg {
+	case "template not found":
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "Template not found")
+	case "not allowed to manage these templates":
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	default:
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", msg)
+	}
+}
+
 // GetCRUDTemplates returns all available CRUD templates from database
 func (h *AdminHandler) GetCRUDTemplates(w http.ResponseWriter, r *http.Request) {
 	category := r.URL.Query().Get("category")
@@ -21,7 +44,7 @@ func (h *AdminHandler) GetCRUDTemplates(w http.ResponseWriter, r *http.Request)
 		categoryPtr = &category
 	}
 
-	templates, err := h.crudTemplateService.ListTemplates(r.Context(), categoryPtr, activeOnly)
+	templates, err := h.crudTemplateService.ListTemplates(r.Context(), middleware.GetTenantFromContext(r.Context()), categoryPtr, activeOnly)
 	if err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
@@ -37,6 +60,7 @@ func (h *AdminHandler) GetCRUDTemplates(w http.ResponseWriter, r *http.Request)
 
 		tmpl := map[string]interface{}{
 			"id":          template.ID.String(),
+			"organization_id": template.OrganizationID,
 			"name":        template.Name,
 			"display_name": template.DisplayName,
 			"description": template.Description,
@@ -63,7 +87,7 @@ func (h *AdminHandler) GetCRUDTemplate(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	templateName := vars["name"]
 
-	template, err := h.crudTemplateService.GetTemplateByName(r.Context(), templateName)
+	template, err := h.crudTemplateService.GetTemplateByName(r.Context(), templateName, middleware.GetTenantFromContext(r.Context()))
 	if err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
@@ -85,6 +109,7 @@ func (h *AdminHandler) GetCRUDTemplate(w http.ResponseWriter, r *http.Request) {
 		"success": true,
 		"data": map[string]interface{}{
 			"id":          template.ID.String(),
+			"organization_id": template.OrganizationID,
 			"name":        template.Name,
 			"display_name": template.DisplayName,
 			"description": template.Description,
@@ -102,7 +127,7 @@ func (h *AdminHandler) GetCRUDTemplate(w http.ResponseWriter, r *http.Request) {
 
 // CreateEntityFromTemplate creates a CRUD entity from a template
 func (h *AdminHandler) CreateEntityFromTemplate(w http.ResponseWriter, r *http.Request) {
-	adminID := middleware.GetUserIDFromContext(r.Context())
+	tenant := crudTenant(r)
 	vars := mux.Vars(r)
 	templateName := vars["name"]
 	
@@ -115,7 +140,7 @@ func (h *AdminHandler) CreateEntityFromTemplate(w http.ResponseWriter, r *http.R
 	json.NewDecoder(r.Body).Decode(&req)
 
 	// Get template from database
-	template, err := h.crudTemplateService.GetTemplateByName(r.Context(), templateName)
+	template, err := h.crudTemplateService.GetTemplateByName(r.Context(), templateName, tenant)
 	if err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
@@ -153,7 +178,7 @@ func (h *AdminHandler) CreateEntityFromTemplate(w http.ResponseWriter, r *http.R
 	// Create entity from template
 	entity, err := h.customCRUDService.CreateEntity(
 		r.Context(),
-		adminID,
+		tenant,
 		template.Name,
 		displayName,
 		descriptionPtr,
@@ -161,7 +186,7 @@ func (h *AdminHandler) CreateEntityFromTemplate(w http.ResponseWriter, r *http.R
 	)
 
 	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		respondCRUDError(w, err)
 		return
 	}
 
@@ -174,7 +199,6 @@ func (h *AdminHandler) CreateEntityFromTemplate(w http.ResponseWriter, r *http.R
 
 // CreateTemplate creates a new CRUD template
 func (h *AdminHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
-	adminID := middleware.GetUserIDFromContext(r.Context())
 	
 	var req struct {
 		Name        string                 `json:"name" validate:"required"`
@@ -192,7 +216,7 @@ func (h *AdminHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
 
 	template, err := h.crudTemplateService.CreateTemplate(
 		r.Context(),
-		adminID,
+		templateTenant(r),
 		req.Name,
 		req.DisplayName,
 		req.Description,
@@ -201,7 +225,7 @@ func (h *AdminHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
 		req.Category,
 	)
 	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		respondTemplateError(w, err)
 		return
 	}
 
@@ -213,6 +237,7 @@ func (h *AdminHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
 		"success": true,
 		"data": map[string]interface{}{
 			"id":          template.ID.String(),
+			"organization_id": template.OrganizationID,
 			"name":        template.Name,
 			"display_name": template.DisplayName,
 			"description": template.Description,
@@ -253,6 +278,7 @@ func (h *AdminHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 	template, err := h.crudTemplateService.UpdateTemplate(
 		r.Context(),
 		id,
+		templateTenant(r),
 		req.DisplayName,
 		req.Description,
 		req.Schema,
@@ -260,7 +286,7 @@ func (h *AdminHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		req.Category,
 	)
 	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		respondTemplateError(w, err)
 		return
 	}
 
@@ -272,6 +298,7 @@ func (h *AdminHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		"success": true,
 		"data": map[string]interface{}{
 			"id":          template.ID.String(),
+			"organization_id": template.OrganizationID,
 			"name":        template.Name,
 			"display_name": template.DisplayName,
 			"description": template.Description,
@@ -296,8 +323,8 @@ func (h *AdminHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.crudTemplateService.DeleteTemplate(r.Context(), id); err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	if err := h.crudTemplateService.DeleteTemplate(r.Context(), id, templateTenant(r)); err != nil {
+		respondTemplateError(w, err)
 		return
 	}
 
@@ -54,7 +54,7 @@ func (h *DashboardHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item, err := h.dashboardService.CreateItem(r.Context(), userID, req.Title, req.Description, req.Category, req.Metadata)
+	item, err := h.dashboardService.CreateItem(r.Context(), middleware.GetTenantFromContext(r.Context()), req.Title, req.Description, req.Category, req.Metadata)
 	if err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
@@ -86,18 +86,12 @@ func (h *DashboardHandler) GetItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item, err := h.dashboardService.GetItem(r.Context(), id)
+	item, err := h.dashboardService.GetItem(r.Context(), id, middleware.GetTenantFromContext(r.Context()))
 	if err != nil {
 		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "Item not found")
 		return
 	}
 
-	// Verify ownership
-	if item.UserID != userID {
-		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "You don't have access to this item")
-		return
-	}
-
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"data":    item,
@@ -114,7 +108,7 @@ func (h *DashboardHandler) ListItems(w http.ResponseWriter, r *http.Request) {
 
 	status := r.URL.Query().Get("status") // Optional: active, archived, deleted
 
-	items, err := h.dashboardService.ListItems(r.Context(), userID, status)
+	items, err := h.dashboardService.ListItems(r.Context(), middleware.GetTenantFromContext(r.Context()), status)
 	if err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
@@ -153,10 +147,10 @@ func (h *DashboardHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item, err := h.dashboardService.UpdateItem(r.Context(), id, userID, updates)
+	item, err := h.dashboardService.UpdateItem(r.Context(), id, middleware.GetTenantFromContext(r.Context()), updates)
 	if err != nil {
-		if err.Error() == "unauthorized: item does not belong to user" {
-			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		if err.Error() == "item not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "Item not found")
 			return
 		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
@@ -190,9 +184,9 @@ func (h *DashboardHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.dashboardService.DeleteItem(r.Context(), id, userID); err != nil {
-		if err.Error() == "unauthorized: item does not belong to user" {
-			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	if err := h.dashboardService.DeleteItem(r.Context(), id, middleware.GetTenantFromContext(r.Context())); err != nil {
+		if err.Error() == "item not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "Item not found")
 			return
 		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
@@ -225,9 +219,9 @@ func (h *DashboardHandler) SoftDeleteItem(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := h.dashboardService.SoftDeleteItem(r.Context(), id, userID); err != nil {
-		if err.Error() == "unauthorized: item does not belong to user" {
-			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	if err := h.dashboardService.SoftDeleteItem(r.Context(), id, middleware.GetTenantFromContext(r.Context())); err != nil {
+		if err.Error() == "item not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "Item not found")
 			return
 		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
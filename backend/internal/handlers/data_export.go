@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type DataExportHandler struct {
+	exportService *services.DataExportService
+	logger        *zap.Logger
+}
+
+func NewDataExportHandler(exportService *services.DataExportService, logger *zap.Logger) *DataExportHandler {
+	return &DataExportHandler{
+		exportService: exportService,
+		logger:        logger,
+	}
+}
+
+// Request starts an export of the signed-in user's data. The user is
+// notified with a download link when it is ready.
+func (h *DataExportHandler) Request(w http.ResponseWriter, r *http.Request) {
+	export, err := h.exportService.Request(r.Context(), middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		switch err.Error() {
+		case "an export is already in progress":
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+		case "user not found":
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		default:
+			h.logger.Error("Failed to request data export", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to request data export")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"data":    export,
+	})
+}
+
+// List returns the signed-in user's exports, newest first.
+func (h *DataExportHandler) List(w http.ResponseWriter, r *http.Request) {
+	exports, err := h.exportService.List(r.Context(), middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		h.logger.Error("Failed to list data exports", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list data exports")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    exports,
+	})
+}
+
+// Download serves an export archive to whoever holds its download token.
+func (h *DataExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "token is required")
+		return
+	}
+
+	export, path, err := h.exportService.Download(r.Context(), token)
+	if err != nil {
+		if err.Error() == "invalid or expired download link" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		h.logger.Error("Failed to load data export", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load data export")
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		h.logger.Error("Failed to open data export", zap.String("export_id", export.ID.String()), zap.Error(err))
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "invalid or expired download link")
+		return
+	}
+	defer file.Close()
+
+	name := "data-export-" + export.CreatedAt.UTC().Format("2006-01-02") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, name, *export.CompletedAt, file)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type DeviceHandler struct {
+	deviceService *services.DeviceService
+	logger        *zap.Logger
+}
+
+func NewDeviceHandler(deviceService *services.DeviceService, logger *zap.Logger) *DeviceHandler {
+	return &DeviceHandler{
+		deviceService: deviceService,
+		logger:        logger,
+	}
+}
+
+// ListDevices returns the devices the caller has signed in from.
+func (h *DeviceHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	devices, err := h.deviceService.ListDevices(r.Context(), userID)
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if devices == nil {
+		devices = []*models.Device{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    devices,
+	})
+}
+
+func (h *DeviceHandler) RenameDevice(w http.ResponseWriter, r *http.Request) {
+	deviceID, ok := parseDeviceID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	device, err := h.deviceService.RenameDevice(r.Context(), userID, deviceID, req.Name)
+	if err != nil {
+		h.respondDeviceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    device,
+	})
+}
+
+// UntrustDevice stops trusting the device. Devices are trusted by checking
+// "trust this device" when passing the second factor on them.
+func (h *DeviceHandler) UntrustDevice(w http.ResponseWriter, r *http.Request) {
+	deviceID, ok := parseDeviceID(w, r)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	device, err := h.deviceService.Untrust(r.Context(), userID, deviceID)
+	if err != nil {
+		h.respondDeviceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    device,
+	})
+}
+
+// RemoveDevice forgets the device and ends its sessions.
+func (h *DeviceHandler) RemoveDevice(w http.ResponseWriter, r *http.Request) {
+	deviceID, ok := parseDeviceID(w, r)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.deviceService.RemoveDevice(r.Context(), userID, deviceID); err != nil {
+		h.respondDeviceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Device removed and signed out",
+	})
+}
+
+func parseDeviceID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid device id")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *DeviceHandler) respondDeviceError(w http.ResponseWriter, err error) {
+	if err.Error() == "device not found" {
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		return
+	}
+	h.logger.Error("Device request failed", zap.Error(err))
+	errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update device")
+}
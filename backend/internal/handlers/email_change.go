@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type EmailChangeHandler struct {
+	emailChangeService *services.EmailChangeService
+	logger             *zap.Logger
+}
+
+func NewEmailChangeHandler(emailChangeService *services.EmailChangeService, logger *zap.Logger) *EmailChangeHandler {
+	return &EmailChangeHandler{
+		emailChangeService: emailChangeService,
+		logger:             logger,
+	}
+}
+
+// GetPending returns the signed-in user's unconfirmed email change, or null.
+func (h *EmailChangeHandler) GetPending(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	request, err := h.emailChangeService.Pending(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get pending email change", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get pending email change")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    request,
+	})
+}
+
+// Request starts a change of the signed-in user's email address.
+func (h *EmailChangeHandler) Request(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	var req struct {
+		NewEmail string `json:"new_email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	ipAddress := getIPAddress(r)
+	sessionID := middleware.GetSessionIDFromContext(r.Context())
+	request, err := h.emailChangeService.RequestChange(r.Context(), userID, sessionID, req.NewEmail, req.Password, &ipAddress)
+	if err != nil {
+		switch msg := err.Error(); msg {
+		case "invalid credentials":
+			errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Current password is incorrect")
+		case "email already in use":
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+		case "new email is the same as the current one", "set a password before changing your email address":
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+		default:
+			h.logger.Error("Email change request failed", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to request email change")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Check your new email address for a confirmation link",
+		"data":    request,
+	})
+}
+
+// Cancel withdraws the signed-in user's unconfirmed email change.
+func (h *EmailChangeHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	if err := h.emailChangeService.Cancel(r.Context(), userID); err != nil {
+		h.logger.Error("Failed to cancel email change", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel email change")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Email change cancelled",
+	})
+}
+
+// Confirm consumes the link sent to the new address.
+func (h *EmailChangeHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	token, ok := decodeEmailChangeToken(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.emailChangeService.Confirm(r.Context(), token)
+	if err != nil {
+		h.respondLinkError(w, err, "Failed to confirm email change")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Your email address has been changed",
+		"data": map[string]interface{}{
+			"email":          user.Email,
+			"email_verified": user.EmailVerified,
+		},
+	})
+}
+
+// Revert consumes the link sent to the old address.
+func (h *EmailChangeHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	token, ok := decodeEmailChangeToken(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.emailChangeService.Revert(r.Context(), token); err != nil {
+		h.respondLinkError(w, err, "Failed to revert email change")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "The email change has been undone and all sessions signed out",
+	})
+}
+
+func decodeEmailChangeToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Token string `json:"token" validate:"required,max=128"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return "", false
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return "", false
+	}
+	return req.Token, true
+}
+
+func (h *EmailChangeHandler) respondLinkError(w http.ResponseWriter, err error, fallback string) {
+	switch msg := err.Error(); msg {
+	case "invalid or expired link":
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_TOKEN", msg)
+	case "email already in use":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	default:
+		h.logger.Error(fallback, zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type EncryptionHandler struct {
+	encryptionService *services.EncryptionService
+	logger            *zap.Logger
+}
+
+func NewEncryptionHandler(encryptionService *services.EncryptionService, logger *zap.Logger) *EncryptionHandler {
+	return &EncryptionHandler{
+		encryptionService: encryptionService,
+		logger:            logger,
+	}
+}
+
+// Status returns the encryption keys and how the values of each encrypted
+// column are sealed.
+func (h *EncryptionHandler) Status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.encryptionService.Status(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get encryption status", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get encryption status")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// Rotate replaces the active data key and re-encrypts stored values with the
+// new one in the background.
+func (h *EncryptionHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	adminID := middleware.GetUserIDFromContext(r.Context())
+	status, err := h.encryptionService.Rotate(r.Context(), adminID)
+	if err != nil {
+		if err.Error() == "re-encryption is already running" {
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+			return
+		}
+		h.logger.Error("Failed to rotate encryption key", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to rotate encryption key")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    status,
+	})
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+	logger            *zap.Logger
+}
+
+func NewInvitationHandler(invitationService *services.InvitationService, logger *zap.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		logger:            logger,
+	}
+}
+
+// List returns invitations newest first. status=pending leaves out answered
+// and expired ones.
+func (h *InvitationHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	pendingOnly := r.URL.Query().Get("status") == models.InvitationStatusPending
+
+	invitations, err := h.invitationService.List(r.Context(), pendingOnly, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list invitations", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list invitations")
+		return
+	}
+
+	now := time.Now()
+	data := make([]map[string]interface{}, 0, len(invitations))
+	for _, invitation := range invitations {
+		data = append(data, invitationJSON(invitation, now))
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// Create invites an email address with a role. Inviting admins needs the
+// admins permission, and other roles the roles permission, as assigning them
+// would.
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email" validate:"required,email,max=255"`
+		Name  string `json:"name" validate:"max=255"`
+		Role  string `json:"role" validate:"max=32"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	if role == "" {
+		role = "user"
+	}
+	if !middleware.HasPermission(r.Context(), invitePermission(role)) {
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Not allowed to invite with this role")
+		return
+	}
+
+	invitation, err := h.invitationService.Invite(r.Context(), middleware.GetUserIDFromContext(r.Context()), req.Email, req.Name, role)
+	if err != nil {
+		h.respondError(w, err, "Failed to send invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Invitation sent",
+		"data":    invitationJSON(invitation, time.Now()),
+	})
+}
+
+// Resend emails an invitation again with a new link.
+func (h *InvitationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	invitation, err := h.invitationService.Resend(r.Context(), middleware.GetUserIDFromContext(r.Context()), id)
+	if err != nil {
+		h.respondError(w, err, "Failed to resend invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Invitation sent",
+		"data":    invitationJSON(invitation, time.Now()),
+	})
+}
+
+// Revoke withdraws an open invitation.
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.invitationService.Revoke(r.Context(), middleware.GetUserIDFromContext(r.Context()), id); err != nil {
+		h.respondError(w, err, "Failed to revoke invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Invitation revoked",
+	})
+}
+
+// Lookup tells the accept page which address and role a token is for.
+func (h *InvitationHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token" validate:"required,max=128"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	invitation, err := h.invitationService.Lookup(r.Context(), req.Token)
+	if err != nil {
+		h.respondError(w, err, "Failed to look up invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"email":      invitation.Email,
+			"name":       invitation.Name,
+			"role":       invitation.Role,
+			"expires_at": invitation.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+}
+
+// Accept creates the invited account and returns a session, like Signup.
+func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token         string `json:"token" validate:"required,max=128"`
+		Name          string `json:"name" validate:"max=255"`
+		Password      string `json:"password" validate:"required"`
+		TermsAccepted bool   `json:"terms_accepted" validate:"required"`
+		TermsVersion  string `json:"terms_version" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	user, session, err := h.invitationService.Accept(r.Context(), services.AcceptInvitationRequest{
+		Token:        req.Token,
+		Name:         req.Name,
+		Password:     req.Password,
+		TermsVersion: req.TermsVersion,
+		Client:       clientInfo(r),
+	})
+	if err != nil {
+		h.respondError(w, err, "Failed to accept invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{
+				"id":             user.ID.String(),
+				"email":          user.Email,
+				"name":           user.Name,
+				"email_verified": user.EmailVerified,
+				"status":         user.Status,
+				"role":           user.Role,
+			},
+			"session": map[string]interface{}{
+				"id":            session.ID.String(),
+				"token":         session.Token,
+				"refresh_token": *session.RefreshToken,
+				"expires_at":    session.ExpiresAt.Format(time.RFC3339),
+			},
+		},
+	})
+}
+
+func (h *InvitationHandler) respondError(w http.ResponseWriter, err error, fallback string) {
+	msg := err.Error()
+	switch {
+	case msg == "invitation not found":
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", msg)
+	case msg == "invalid or expired invitation":
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_TOKEN", msg)
+	case strings.HasPrefix(msg, "cannot grant permissions you do not hold"):
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	case msg == "user already exists", strings.HasPrefix(msg, "invitation was already "):
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	case msg == "role not found", msg == "name is required", strings.HasPrefix(msg, "password validation failed"), isTermsError(err):
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+	case strings.HasPrefix(msg, "failed to send invitation email"):
+		h.logger.Error(fallback, zap.Error(err))
+		errors.RespondError(w, http.StatusBadGateway, "PROVIDER_UNAVAILABLE", "Could not send the invitation email")
+	default:
+		h.logger.Error(fallback, zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+	}
+}
+
+// invitePermission is the permission needed to invite someone with role.
+func invitePermission(role string) string {
+	switch role {
+	case "user":
+		return models.PermUsersWrite
+	case "admin":
+		return models.PermAdminsManage
+	default:
+		return models.PermRolesManage
+	}
+}
+
+func invitationJSON(invitation *models.UserInvitation, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          invitation.ID.String(),
+		"email":       invitation.Email,
+		"name":        invitation.Name,
+		"role":        invitation.Role,
+		"status":      invitation.Status(now),
+		"invited_by":  invitation.InvitedBy,
+		"expires_at":  invitation.ExpiresAt.Format(time.RFC3339),
+		"sent_at":     invitation.SentAt.Format(time.RFC3339),
+		"send_count":  invitation.SendCount,
+		"accepted_at": invitation.AcceptedAt,
+		"accepted_by": invitation.AcceptedBy,
+		"revoked_at":  invitation.RevokedAt,
+		"created_at":  invitation.CreatedAt.Format(time.RFC3339),
+	}
+}
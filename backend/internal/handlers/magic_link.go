@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type MagicLinkHandler struct {
+	magicLinkService *services.MagicLinkService
+	logger           *zap.Logger
+}
+
+func NewMagicLinkHandler(magicLinkService *services.MagicLinkService, logger *zap.Logger) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkService: magicLinkService,
+		logger:           logger,
+	}
+}
+
+// Status tells the login page whether to offer magic-link login.
+func (h *MagicLinkHandler) Status(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"enabled": h.magicLinkService.Enabled(r.Context()),
+		},
+	})
+}
+
+func (h *MagicLinkHandler) Request(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	ipAddress := getIPAddress(r)
+	if err := h.magicLinkService.RequestLink(r.Context(), req.Email, &ipAddress); err != nil {
+		if err.Error() == "magic-link login is disabled" {
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+			return
+		}
+		h.logger.Error("Magic link request failed", zap.Error(err))
+	}
+
+	// Always return success to prevent email enumeration
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "If an account exists with this email, a sign-in link has been sent",
+	})
+}
+
+// Verify consumes a link and returns a session, like Login.
+func (h *MagicLinkHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token" validate:"required,max=128"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	info := clientInfo(r)
+	user, session, isNewDevice, err := h.magicLinkService.Verify(r.Context(), req.Token, info)
+	if err != nil {
+		if challenge, ok := err.(*services.TwoFactorRequiredError); ok {
+			respondTwoFactorChallenge(w, challenge)
+			return
+		}
+		switch msg := err.Error(); msg {
+		case "invalid or expired link":
+			errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", msg)
+		case "magic-link login is disabled", "account is not active":
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+		default:
+			h.logger.Error("Magic link login failed", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		}
+		return
+	}
+
+	deviceData := map[string]interface{}{}
+	if info.DeviceID != nil {
+		deviceData["id"] = *info.DeviceID
+		deviceData["is_new_device"] = isNewDevice
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{
+				"id":             user.ID.String(),
+				"email":          user.Email,
+				"name":           user.Name,
+				"email_verified": user.EmailVerified,
+				"status":         user.Status,
+				"role":           user.Role,
+			},
+			"session": map[string]interface{}{
+				"id":            session.ID.String(),
+				"token":         session.Token,
+				"refresh_token": *session.RefreshToken,
+				"expires_at":    session.ExpiresAt.Format(time.RFC3339),
+			},
+			"device": deviceData,
+		},
+	})
+}
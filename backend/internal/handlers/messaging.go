@@ -45,6 +45,10 @@ func (h *MessagingHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 
 	message, err := h.messagingService.SendMessage(r.Context(), userID, req.RecipientID, req.Subject, req.Content, req.Metadata)
 	if err != nil {
+		if err.Error() == "recipient does not accept messages" {
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+			return
+		}
 		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
 		return
 	}
@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+// OAuthServerHandler serves the OpenID Connect provider endpoints used by
+// other products. The protocol endpoints (discovery, jwks, token, userinfo)
+// answer in the formats the specs require rather than the API envelope.
+type OAuthServerHandler struct {
+	oauthService *services.OAuthServerService
+	consentURL   string
+	logger       *zap.Logger
+}
+
+func NewOAuthServerHandler(oauthService *services.OAuthServerService, consentURL string, logger *zap.Logger) *OAuthServerHandler {
+	return &OAuthServerHandler{
+		oauthService: oauthService,
+		consentURL:   consentURL,
+		logger:       logger,
+	}
+}
+
+type oauthClientRequest struct {
+	Name                   string   `json:"name" validate:"required,max=255"`
+	ProductName            *string  `json:"product_name"`
+	RedirectURIs           []string `json:"redirect_uris" validate:"required,min=1"`
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+	BackchannelLogoutURI   *string  `json:"backchannel_logout_uri"`
+	AllowedScopes          []string `json:"allowed_scopes"`
+	Confidential           bool     `json:"confidential"`
+	SkipConsent            bool     `json:"skip_consent"`
+	IsActive               *bool    `json:"is_active"`
+}
+
+func (req oauthClientRequest) toService() services.OAuthClientRequest {
+	return services.OAuthClientRequest{
+		Name:                   req.Name,
+		ProductName:            req.ProductName,
+		RedirectURIs:           req.RedirectURIs,
+		PostLogoutRedirectURIs: req.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:   req.BackchannelLogoutURI,
+		AllowedScopes:          req.AllowedScopes,
+		Confidential:           req.Confidential,
+		SkipConsent:            req.SkipConsent,
+		IsActive:               req.IsActive,
+	}
+}
+
+type consentDecisionRequest struct {
+	services.AuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+func (h *OAuthServerHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.oauthService.Discovery())
+}
+
+func (h *OAuthServerHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	jwks, err := h.oauthService.JWKS(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to load signing keys", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load signing keys")
+		return
+	}
+	respondJSON(w, http.StatusOK, jwks)
+}
+
+// Authorize validates the request and hands the browser to the consent
+// screen, which completes it through the consent API with the user's token.
+func (h *OAuthServerHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	req := authorizeRequestFromQuery(r.URL.Query())
+	_, _, err := h.oauthService.ValidateAuthorizeRequest(r.Context(), req)
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if stderrors.As(err, &oauthErr) {
+			http.Redirect(w, r, services.AuthorizationErrorRedirect(req.RedirectURI, oauthErr, req.State), http.StatusFound)
+			return
+		}
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	http.Redirect(w, r, h.consentURL+"?"+r.URL.RawQuery, http.StatusFound)
+}
+
+// ConsentPrompt returns what the consent screen should show for an
+// authorization request.
+func (h *OAuthServerHandler) ConsentPrompt(w http.ResponseWriter, r *http.Request) {
+	req := authorizeRequestFromQuery(r.URL.Query())
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	prompt, err := h.oauthService.ConsentPrompt(r.Context(), userID, req)
+	if err != nil {
+		h.respondAuthorizeError(w, req, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    prompt,
+	})
+}
+
+// ConsentDecision approves or denies an authorization request and returns
+// the URL to send the browser back to the product.
+func (h *OAuthServerHandler) ConsentDecision(w http.ResponseWriter, r *http.Request) {
+	var req consentDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	sessionID := middleware.GetSessionIDFromContext(r.Context())
+	redirectTo, err := h.oauthService.Decide(r.Context(), userID, sessionID, req.AuthorizeRequest, req.Approve)
+	if err != nil {
+		h.respondAuthorizeError(w, req.AuthorizeRequest, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"redirect_to": redirectTo,
+		},
+	})
+}
+
+func (h *OAuthServerHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed form body")
+		return
+	}
+
+	req := services.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+	if id, secret, ok := r.BasicAuth(); ok {
+		// client_secret_basic credentials are form-encoded (RFC 6749 2.3.1)
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	resp, err := h.oauthService.Token(r.Context(), req)
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if !stderrors.As(err, &oauthErr) {
+			h.logger.Error("Token request failed", zap.Error(err))
+			respondOAuthError(w, http.StatusInternalServerError, "server_error", "internal error")
+			return
+		}
+		status := http.StatusBadRequest
+		if oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		respondOAuthError(w, status, oauthErr.Code, oauthErr.Description)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *OAuthServerHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		respondOAuthError(w, http.StatusUnauthorized, "invalid_token", "missing bearer token")
+		return
+	}
+
+	claims, err := h.oauthService.UserInfo(r.Context(), token)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		respondOAuthError(w, http.StatusUnauthorized, "invalid_token", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, claims)
+}
+
+// Logout is the end_session_endpoint for RP-initiated logout.
+func (h *OAuthServerHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectTo, err := h.oauthService.Logout(r.Context(), q.Get("id_token_hint"), q.Get("post_logout_redirect_uri"), q.Get("state"))
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if redirectTo != "" {
+		http.Redirect(w, r, redirectTo, http.StatusFound)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Signed out",
+	})
+}
+
+// ListGrants returns the products the caller has authorized.
+func (h *OAuthServerHandler) ListGrants(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	grants, err := h.oauthService.ListGrants(r.Context(), userID)
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    grants,
+	})
+}
+
+func (h *OAuthServerHandler) RevokeGrant(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.oauthService.RevokeGrant(r.Context(), userID, mux.Vars(r)["client_id"]); err != nil {
+		if err.Error() == "grant not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Access revoked",
+	})
+}
+
+func (h *OAuthServerHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.oauthService.ListClients(r.Context())
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if clients == nil {
+		clients = []*models.OAuthClient{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    clients,
+	})
+}
+
+func (h *OAuthServerHandler) GetClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid client id")
+		return
+	}
+
+	client, err := h.oauthService.GetClient(r.Context(), id)
+	if err != nil {
+		h.respondClientError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    client,
+	})
+}
+
+func (h *OAuthServerHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var req oauthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	client, secret, err := h.oauthService.CreateClient(r.Context(), actorID, req.toService())
+	if err != nil {
+		h.respondClientError(w, err)
+		return
+	}
+
+	data := map[string]interface{}{"client": client}
+	if secret != "" {
+		data["client_secret"] = secret
+	}
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (h *OAuthServerHandler) UpdateClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid client id")
+		return
+	}
+
+	var req oauthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	client, err := h.oauthService.UpdateClient(r.Context(), actorID, id, req.toService())
+	if err != nil {
+		h.respondClientError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    client,
+	})
+}
+
+func (h *OAuthServerHandler) RotateClientSecret(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid client id")
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	secret, err := h.oauthService.RotateClientSecret(r.Context(), actorID, id)
+	if err != nil {
+		h.respondClientError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"client_secret": secret,
+		},
+	})
+}
+
+func (h *OAuthServerHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid client id")
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.oauthService.DeleteClient(r.Context(), actorID, id); err != nil {
+		h.respondClientError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Client deleted",
+	})
+}
+
+func (h *OAuthServerHandler) respondClientError(w http.ResponseWriter, err error) {
+	if err.Error() == "client not found" {
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		return
+	}
+	errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+}
+
+// respondAuthorizeError reports a failed authorization request to the
+// consent screen, including where to send the browser when the error can
+// be returned to the product.
+func (h *OAuthServerHandler) respondAuthorizeError(w http.ResponseWriter, req services.AuthorizeRequest, err error) {
+	var oauthErr *services.OAuthError
+	if stderrors.As(err, &oauthErr) {
+		respondJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error": map[string]interface{}{
+				"code":    strings.ToUpper(oauthErr.Code),
+				"message": oauthErr.Description,
+			},
+			"data": map[string]interface{}{
+				"redirect_to": services.AuthorizationErrorRedirect(req.RedirectURI, oauthErr, req.State),
+			},
+		})
+		return
+	}
+	errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+}
+
+func authorizeRequestFromQuery(q url.Values) services.AuthorizeRequest {
+	return services.AuthorizeRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		Nonce:               q.Get("nonce"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	}
+}
+
+func respondOAuthError(w http.ResponseWriter, status int, code, description string) {
+	respondJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+	logger      *zap.Logger
+}
+
+func NewOIDCHandler(oidcService *services.OIDCService, logger *zap.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		logger:      logger,
+	}
+}
+
+type oidcCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type confirmLinkRequest struct {
+	LinkToken string `json:"link_token" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+}
+
+// ListProviders returns the configured sign-in providers.
+func (h *OIDCHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    h.oidcService.Providers(),
+	})
+}
+
+// Authorize starts a sign-in and returns the provider URL to redirect to.
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.oidcService.BeginAuth(r.Context(), mux.Vars(r)["provider"], nil)
+	if err != nil {
+		h.respondOIDCError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"authorization_url": authURL,
+		},
+	})
+}
+
+// Callback completes a sign-in with the code and state the provider returned.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	var req oidcCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	result, err := h.oidcService.Complete(r.Context(), mux.Vars(r)["provider"], req.Code, req.State, clientInfo(r))
+	if err != nil {
+		h.respondOIDCError(w, err)
+		return
+	}
+
+	switch {
+	case result.LinkToken != "":
+		respondJSON(w, http.StatusConflict, map[string]interface{}{
+			"success": false,
+			"error": map[string]interface{}{
+				"code":    "LINK_CONFIRMATION_REQUIRED",
+				"message": "An account with this email already exists. Confirm with its password to link.",
+			},
+			"data": map[string]interface{}{
+				"link_token": result.LinkToken,
+				"email":      result.LinkEmail,
+			},
+		})
+	case result.Session == nil:
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result.Identity,
+		})
+	default:
+		h.respondSession(w, result)
+	}
+}
+
+// ConfirmLink links a pending provider identity to an existing account.
+func (h *OIDCHandler) ConfirmLink(w http.ResponseWriter, r *http.Request) {
+	var req confirmLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	result, err := h.oidcService.ConfirmLink(r.Context(), req.LinkToken, req.Password, clientInfo(r))
+	if err != nil {
+		h.respondOIDCError(w, err)
+		return
+	}
+	h.respondSession(w, result)
+}
+
+// ListIdentities returns the caller's linked provider identities.
+func (h *OIDCHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	identities, err := h.oidcService.ListIdentities(r.Context(), userID)
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if identities == nil {
+		identities = []*models.UserIdentity{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    identities,
+	})
+}
+
+// LinkIdentity starts an authorization whose callback attaches the provider
+// identity to the signed-in user.
+func (h *OIDCHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	authURL, err := h.oidcService.BeginAuth(r.Context(), mux.Vars(r)["provider"], &userID)
+	if err != nil {
+		h.respondOIDCError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"authorization_url": authURL,
+		},
+	})
+}
+
+func (h *OIDCHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	identityID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid identity id")
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.oidcService.Unlink(r.Context(), userID, identityID); err != nil {
+		h.respondOIDCError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Identity unlinked",
+	})
+}
+
+func (h *OIDCHandler) respondSession(w http.ResponseWriter, result *services.OIDCLoginResult) {
+	user, session := result.User, result.Session
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{
+				"id":             user.ID.String(),
+				"email":          user.Email,
+				"name":           user.Name,
+				"email_verified": user.EmailVerified,
+				"status":         user.Status,
+				"role":           user.Role,
+			},
+			"session": map[string]interface{}{
+				"id":            session.ID.String(),
+				"token":         session.Token,
+				"refresh_token": *session.RefreshToken,
+				"expires_at":    session.ExpiresAt.Format(time.RFC3339),
+			},
+			"is_new_user": result.IsNewUser,
+		},
+	})
+}
+
+func (h *OIDCHandler) respondOIDCError(w http.ResponseWriter, err error) {
+	if challenge, ok := err.(*services.TwoFactorRequiredError); ok {
+		respondTwoFactorChallenge(w, challenge)
+		return
+	}
+	switch msg := err.Error(); {
+	case strings.HasSuffix(msg, "is unavailable"):
+		errors.RespondError(w, http.StatusBadGateway, "PROVIDER_UNAVAILABLE", msg)
+	case msg == "provider not found", msg == "identity not found":
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", msg)
+	case msg == "invalid credentials", msg == "authorization failed", msg == "invalid or expired state", msg == "invalid or expired link token":
+		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", msg)
+	case msg == "account is not active", msg == "cannot remove your last login method":
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	case msg == "email already registered", msg == "identity is linked to another account":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	case msg == "provider did not return an email address":
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", msg)
+	default:
+		h.logger.Error("OIDC request failed", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to complete sign-in")
+	}
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type OrganizationHandler struct {
+	organizationService *services.OrganizationService
+	logger              *zap.Logger
+}
+
+func NewOrganizationHandler(organizationService *services.OrganizationService, logger *zap.Logger) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+		logger:              logger,
+	}
+}
+
+// List returns the signed-in user's organizations.
+func (h *OrganizationHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.organizationService.List(r.Context(), middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		h.respondError(w, err, "Failed to list organizations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    orgs,
+	})
+}
+
+// Create starts an organization owned by the signed-in user.
+func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	org, err := h.organizationService.Create(r.Context(), middleware.GetUserIDFromContext(r.Context()), req.Name)
+	if err != nil {
+		h.respondError(w, err, "Failed to create organization")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    org,
+	})
+}
+
+func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	org, err := h.organizationService.Get(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		h.respondError(w, err, "Failed to get organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    org,
+	})
+}
+
+func (h *OrganizationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	org, err := h.organizationService.Rename(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()), req.Name)
+	if err != nil {
+		h.respondError(w, err, "Failed to update organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    org,
+	})
+}
+
+func (h *OrganizationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.organizationService.Delete(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context())); err != nil {
+		h.respondError(w, err, "Failed to delete organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Organization deleted",
+	})
+}
+
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.organizationService.ListMembers(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		h.respondError(w, err, "Failed to list members")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    members,
+	})
+}
+
+// UpdateMember changes a member's role.
+func (h *OrganizationHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := pathUUID(w, r, "user_id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Role string `json:"role" validate:"required,oneof=owner admin member"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	if err := h.organizationService.ChangeRole(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()), userID, req.Role); err != nil {
+		h.respondError(w, err, "Failed to update member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Member role updated",
+	})
+}
+
+// RemoveMember removes a member, or lets the signed-in user leave when the
+// user_id is theirs.
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := pathUUID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.organizationService.RemoveMember(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()), userID); err != nil {
+		h.respondError(w, err, "Failed to remove member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Member removed",
+	})
+}
+
+func (h *OrganizationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.organizationService.ListInvitations(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		h.respondError(w, err, "Failed to list invitations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    invitations,
+	})
+}
+
+// Invite emails an invitation to join the organization.
+func (h *OrganizationHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Email string `json:"email" validate:"required,email"`
+		Role  string `json:"role" validate:"required,oneof=owner admin member"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	invitation, err := h.organizationService.Invite(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()), req.Email, req.Role)
+	if err != nil {
+		h.respondError(w, err, "Failed to send invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Invitation sent",
+		"data":    invitation,
+	})
+}
+
+func (h *OrganizationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := pathUUID(w, r, "invitation_id")
+	if !ok {
+		return
+	}
+
+	if err := h.organizationService.RevokeInvitation(r.Context(), orgID, middleware.GetUserIDFromContext(r.Context()), invitationID); err != nil {
+		h.respondError(w, err, "Failed to revoke invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Invitation revoked",
+	})
+}
+
+// AcceptInvitation joins the signed-in user to the organization of an
+// emailed invitation.
+func (h *OrganizationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token" validate:"required,max=128"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	org, err := h.organizationService.AcceptInvitation(r.Context(), req.Token, middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		h.respondError(w, err, "Failed to accept invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "You joined " + org.Name,
+		"data":    org,
+	})
+}
+
+func (h *OrganizationHandler) respondError(w http.ResponseWriter, err error, fallback string) {
+	msg := err.Error()
+	switch {
+	case msg == "organization not found", msg == "member not found", msg == "invitation not found":
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", msg)
+	case strings.HasSuffix(msg, " role required"), msg == "only owners can grant or remove the owner role",
+		msg == "invitation was sent to a different email address":
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	case msg == "an organization needs at least one owner", msg == "already a member of this organization":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	case msg == "invalid or expired invitation":
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_TOKEN", msg)
+	case msg == "invalid organization role", msg == "organization name is required":
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+	default:
+		h.logger.Error(fallback, zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+	}
+}
+
+// pathUUID parses the named path variable, answering 400 when it is not a
+// UUID.
+func pathUUID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)[name])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid "+strings.ReplaceAll(name, "_", " "))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type PasskeyHandler struct {
+	passkeyService *services.PasskeyService
+	logger         *zap.Logger
+}
+
+func NewPasskeyHandler(passkeyService *services.PasskeyService, logger *zap.Logger) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+		logger:         logger,
+	}
+}
+
+// finishPasskeyRequest carries the ceremony token from the begin step and the
+// PublicKeyCredential returned by navigator.credentials, as JSON.
+type finishPasskeyRequest struct {
+	CeremonyToken string          `json:"ceremony_token" validate:"required"`
+	Name          string          `json:"name" validate:"max=100"`
+	Credential    json.RawMessage `json:"credential" validate:"required"`
+}
+
+// BeginRegistration returns the creation options for a new passkey.
+func (h *PasskeyHandler) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	ceremony, err := h.passkeyService.BeginRegistration(r.Context(), userID)
+	if err != nil {
+		h.respondPasskeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    ceremony,
+	})
+}
+
+// FinishRegistration stores the passkey created by the authenticator.
+func (h *PasskeyHandler) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	var req finishPasskeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	passkey, err := h.passkeyService.FinishRegistration(r.Context(), userID, req.CeremonyToken, req.Name, req.Credential, clientInfo(r))
+	if err != nil {
+		h.respondPasskeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    passkey,
+	})
+}
+
+// BeginLogin returns the request options for a passkey sign-in.
+func (h *PasskeyHandler) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	ceremony, err := h.passkeyService.BeginLogin(r.Context())
+	if err != nil {
+		h.respondPasskeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    ceremony,
+	})
+}
+
+// FinishLogin verifies the assertion and returns a session, like Login.
+func (h *PasskeyHandler) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	var req finishPasskeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	info := clientInfo(r)
+	user, session, isNewDevice, err := h.passkeyService.FinishLogin(r.Context(), req.CeremonyToken, req.Credential, info)
+	if err != nil {
+		h.respondPasskeyError(w, err)
+		return
+	}
+
+	deviceData := map[string]interface{}{}
+	if info.DeviceID != nil {
+		deviceData["id"] = *info.DeviceID
+		deviceData["is_new_device"] = isNewDevice
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{
+				"id":             user.ID.String(),
+				"email":          user.Email,
+				"name":           user.Name,
+				"email_verified": user.EmailVerified,
+				"status":         user.Status,
+				"role":           user.Role,
+			},
+			"session": map[string]interface{}{
+				"id":            session.ID.String(),
+				"token":         session.Token,
+				"refresh_token": *session.RefreshToken,
+				"expires_at":    session.ExpiresAt.Format(time.RFC3339),
+			},
+			"device": deviceData,
+		},
+	})
+}
+
+// ListPasskeys returns the caller's registered passkeys.
+func (h *PasskeyHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+	passkeys, err := h.passkeyService.ListPasskeys(r.Context(), userID)
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if passkeys == nil {
+		passkeys = []*models.PasskeyCredential{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    passkeys,
+	})
+}
+
+func (h *PasskeyHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	passkeyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid passkey id")
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.passkeyService.DeletePasskey(r.Context(), userID, passkeyID); err != nil {
+		h.respondPasskeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Passkey removed",
+	})
+}
+
+func (h *PasskeyHandler) respondPasskeyError(w http.ResponseWriter, err error) {
+	switch msg := err.Error(); msg {
+	case "passkey not found":
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", msg)
+	case "passkey verification failed", "passkey has been disabled":
+		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", msg)
+	case "account is not active", "cannot remove your last login method":
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	case "passkey already registered":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	case "invalid passkey response", "passkey registration failed", "invalid or expired ceremony":
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", msg)
+	default:
+		h.logger.Error("Passkey request failed", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process passkey request")
+	}
+}
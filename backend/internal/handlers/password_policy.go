@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type PasswordPolicyHandler struct {
+	passwordPolicyService *services.PasswordPolicyService
+	logger                *zap.Logger
+}
+
+func NewPasswordPolicyHandler(passwordPolicyService *services.PasswordPolicyService, logger *zap.Logger) *PasswordPolicyHandler {
+	return &PasswordPolicyHandler{
+		passwordPolicyService: passwordPolicyService,
+		logger:                logger,
+	}
+}
+
+// CheckStrength rates a candidate password against the current policy, for
+// the strength meter on the signup and password forms.
+func (h *PasswordPolicyHandler) CheckStrength(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	evaluation := h.passwordPolicyService.Evaluate(r.Context(), req.Password)
+	policy := h.passwordPolicyService.Policy(r.Context())
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"valid":    evaluation.Valid,
+			"strength": evaluation.Strength.String(),
+			"score":    evaluation.Score,
+			"breached": evaluation.Breached,
+			"errors":   evaluation.Errors,
+			"policy": map[string]interface{}{
+				"min_length":        policy.MinLength,
+				"require_uppercase": policy.RequireUppercase,
+				"require_lowercase": policy.RequireLowercase,
+				"require_number":    policy.RequireNumber,
+				"require_special":   policy.RequireSpecial,
+			},
+		},
+	})
+}
+
+// ResetUserPassword lets an admin set a temporary password for a user, who
+// must change it at their next sign-in.
+func (h *PasswordPolicyHandler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid user ID")
+		return
+	}
+	var req struct {
+		Password string `json:"password" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	adminID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.passwordPolicyService.AdminResetPassword(r.Context(), adminID, userID, req.Password); err != nil {
+		switch {
+		case err.Error() == "user not found":
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case strings.HasPrefix(err.Error(), "cannot manage"):
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case strings.HasPrefix(err.Error(), "password validation failed"):
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		default:
+			h.logger.Error("Failed to reset user password", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to reset password")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Password reset. The user must change it at their next sign-in",
+	})
+}
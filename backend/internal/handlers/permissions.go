@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type PermissionHandler struct {
+	permissionService *services.PermissionService
+	logger            *zap.Logger
+}
+
+func NewPermissionHandler(permissionService *services.PermissionService, logger *zap.Logger) *PermissionHandler {
+	return &PermissionHandler{
+		permissionService: permissionService,
+		logger:            logger,
+	}
+}
+
+type roleRequest struct {
+	Name        string   `json:"name"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type grantPermissionRequest struct {
+	Permission string `json:"permission" validate:"required"`
+}
+
+type assignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// ListPermissions returns every known permission name.
+func (h *PermissionHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    models.AllPermissions,
+	})
+}
+
+// MyPermissions returns the caller's effective permissions.
+func (h *PermissionHandler) MyPermissions(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    middleware.GetPermissionsFromContext(r.Context()),
+	})
+}
+
+func (h *PermissionHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.permissionService.ListRoles(r.Context())
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    roles,
+	})
+}
+
+func (h *PermissionHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	role, err := h.permissionService.CreateRole(r.Context(), actorID, services.RoleRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		if err.Error() == "role already exists" {
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+			return
+		}
+		h.respondRoleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    role,
+	})
+}
+
+func (h *PermissionHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	req.Name = mux.Vars(r)["name"]
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	role, err := h.permissionService.UpdateRole(r.Context(), actorID, services.RoleRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		h.respondRoleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    role,
+	})
+}
+
+func (h *PermissionHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.permissionService.DeleteRole(r.Context(), actorID, mux.Vars(r)["name"]); err != nil {
+		h.respondRoleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Role deleted",
+	})
+}
+
+func (h *PermissionHandler) GetUserPermissions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid user id")
+		return
+	}
+
+	permissions, err := h.permissionService.GetUserPermissions(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    permissions,
+	})
+}
+
+func (h *PermissionHandler) GrantPermission(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid user id")
+		return
+	}
+
+	var req grantPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.permissionService.GrantPermission(r.Context(), actorID, userID, req.Permission); err != nil {
+		h.respondRoleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Permission granted",
+	})
+}
+
+func (h *PermissionHandler) RevokePermission(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid user id")
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.permissionService.RevokePermission(r.Context(), actorID, userID, vars["permission"]); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Permission revoked",
+	})
+}
+
+func (h *PermissionHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid user id")
+		return
+	}
+
+	var req assignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.permissionService.AssignRole(r.Context(), actorID, userID, req.Role); err != nil {
+		h.respondRoleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Role assigned",
+	})
+}
+
+func (h *PermissionHandler) respondRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case strings.Contains(err.Error(), "cannot"):
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	case err.Error() == "role is still assigned to users":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+	default:
+		errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+}
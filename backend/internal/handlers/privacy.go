@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type PrivacyHandler struct {
+	privacyService *services.PrivacyService
+	logger         *zap.Logger
+}
+
+func NewPrivacyHandler(privacyService *services.PrivacyService, logger *zap.Logger) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+		logger:         logger,
+	}
+}
+
+// GetProfile returns another user's profile as their privacy settings let
+// the caller see it.
+func (h *PrivacyHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	profile, err := h.privacyService.Profile(r.Context(), middleware.GetUserIDFromContext(r.Context()), userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		h.logger.Error("Failed to load profile", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load profile")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    profile,
+	})
+}
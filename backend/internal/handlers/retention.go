@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type RetentionHandler struct {
+	retentionService *services.RetentionService
+	logger           *zap.Logger
+}
+
+func NewRetentionHandler(retentionService *services.RetentionService, logger *zap.Logger) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+		logger:           logger,
+	}
+}
+
+// ListRules returns the retention rule in effect for every data class.
+func (h *RetentionHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.retentionService.Rules(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list retention rules", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list retention rules")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// UpdateRule sets the retention rule for the data class in the path.
+func (h *RetentionHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled    bool `json:"enabled"`
+		MaxAgeDays *int `json:"max_age_days"`
+		MaxRows    *int `json:"max_rows"`
+		Archive    bool `json:"archive"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	adminID := middleware.GetUserIDFromContext(r.Context())
+	rule, err := h.retentionService.UpdateRule(r.Context(), adminID, mux.Vars(r)["class"], req.Enabled, req.MaxAgeDays, req.MaxRows, req.Archive)
+	if err != nil {
+		switch err.Error() {
+		case "unknown data class":
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case "max_age_days must be between 1 and 36500", "max_rows must be at least 1", "an enabled rule needs max_age_days or max_rows":
+			errors.RespondError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		default:
+			h.logger.Error("Failed to update retention rule", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update retention rule")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// Preview reports how many rows each rule would purge right now, without
+// deleting anything.
+func (h *RetentionHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.retentionService.Preview(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to preview retention rules", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to preview retention rules")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    reports,
+	})
+}
+
+// Run applies the enabled rules now instead of waiting for the scheduler.
+func (h *RetentionHandler) Run(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.retentionService.Run(r.Context())
+	if err != nil {
+		if err.Error() == "a purge is already running" {
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+			return
+		}
+		h.logger.Error("Failed to run retention purge", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to run retention purge")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    runs,
+	})
+}
+
+// ListRuns returns the latest purger runs, newest first.
+func (h *RetentionHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	runs, err := h.retentionService.Runs(r.Context(), limit)
+	if err != nil {
+		h.logger.Error("Failed to list retention runs", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list retention runs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    runs,
+	})
+}
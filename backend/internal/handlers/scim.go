@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+// SCIMHandler serves the SCIM 2.0 endpoints identity providers provision
+// through, and the admin endpoints that issue their tokens.
+type SCIMHandler struct {
+	scimService *services.SCIMService
+	logger      *zap.Logger
+}
+
+func NewSCIMHandler(scimService *services.SCIMService, logger *zap.Logger) *SCIMHandler {
+	return &SCIMHandler{
+		scimService: scimService,
+		logger:      logger,
+	}
+}
+
+type scimPatchRequest struct {
+	Schemas    []string                      `json:"schemas"`
+	Operations []services.SCIMPatchOperation `json:"Operations"`
+}
+
+// CreateToken issues a SCIM token. The plaintext is only returned here.
+func (h *SCIMHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	token, plaintext, err := h.scimService.IssueToken(r.Context(), middleware.GetUserIDFromContext(r.Context()), req.Name)
+	if err != nil {
+		if err.Error() == "name is required" {
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.logger.Error("Failed to issue SCIM token", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to issue SCIM token")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"scim_token": token,
+			"token":      plaintext,
+		},
+		"message": "Store this token now; it will not be shown again",
+	})
+}
+
+func (h *SCIMHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.scimService.ListTokens(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list SCIM tokens", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list SCIM tokens")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    tokens,
+	})
+}
+
+func (h *SCIMHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.RevokeToken(r.Context(), middleware.GetUserIDFromContext(r.Context()), id); err != nil {
+		if err.Error() == "scim token not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		h.logger.Error("Failed to revoke SCIM token", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke SCIM token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "SCIM token revoked",
+	})
+}
+
+func (h *SCIMHandler) ServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	respondSCIM(w, http.StatusOK, h.scimService.ServiceProviderConfig())
+}
+
+func (h *SCIMHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	startIndex, count := scimPaging(r)
+	list, err := h.scimService.ListUsers(r.Context(), r.URL.Query().Get("filter"), startIndex, count)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, list)
+}
+
+func (h *SCIMHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.scimService.GetUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req services.SCIMUser
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	user, err := h.scimService.CreateUser(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), req)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusCreated, user)
+}
+
+func (h *SCIMHandler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	var req services.SCIMUser
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	user, err := h.scimService.ReplaceUser(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), mux.Vars(r)["id"], req)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	var req scimPatchRequest
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	user, err := h.scimService.PatchUser(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), mux.Vars(r)["id"], req.Operations)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.scimService.DeleteUser(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), mux.Vars(r)["id"]); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	startIndex, count := scimPaging(r)
+	list, err := h.scimService.ListGroups(r.Context(), r.URL.Query().Get("filter"), startIndex, count, scimWithMembers(r))
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, list)
+}
+
+func (h *SCIMHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := h.scimService.GetGroup(r.Context(), mux.Vars(r)["id"], scimWithMembers(r))
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, group)
+}
+
+func (h *SCIMHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req services.SCIMGroup
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	group, err := h.scimService.CreateGroup(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), req)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusCreated, group)
+}
+
+func (h *SCIMHandler) ReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	var req services.SCIMGroup
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	group, err := h.scimService.ReplaceGroup(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), mux.Vars(r)["id"], req)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, group)
+}
+
+func (h *SCIMHandler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	var req scimPatchRequest
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	group, err := h.scimService.PatchGroup(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), mux.Vars(r)["id"], req.Operations)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, group)
+}
+
+func (h *SCIMHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.scimService.DeleteGroup(r.Context(), middleware.GetSCIMTokenIDFromContext(r.Context()), mux.Vars(r)["id"]); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) respondError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "user not found", msg == "group not found":
+		errors.RespondSCIMError(w, http.StatusNotFound, "", msg)
+	case strings.HasSuffix(msg, "already exists"):
+		errors.RespondSCIMError(w, http.StatusConflict, "uniqueness", msg)
+	case strings.HasPrefix(msg, "invalid filter"):
+		errors.RespondSCIMError(w, http.StatusBadRequest, "invalidFilter", msg)
+	case strings.HasPrefix(msg, "invalid path"):
+		errors.RespondSCIMError(w, http.StatusBadRequest, "invalidPath", msg)
+	case strings.HasPrefix(msg, "invalid value"), strings.HasPrefix(msg, "password validation failed"):
+		errors.RespondSCIMError(w, http.StatusBadRequest, "invalidValue", msg)
+	case strings.HasPrefix(msg, "mutability"):
+		errors.RespondSCIMError(w, http.StatusBadRequest, "mutability", msg)
+	case strings.HasPrefix(msg, "invalid patch operation"):
+		errors.RespondSCIMError(w, http.StatusBadRequest, "invalidSyntax", msg)
+	default:
+		h.logger.Error("SCIM request failed", zap.Error(err))
+		errors.RespondSCIMError(w, http.StatusInternalServerError, "", "Internal error")
+	}
+}
+
+func respondSCIM(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func decodeSCIM(w http.ResponseWriter, r *http.Request, dest interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dest); err != nil {
+		errors.RespondSCIMError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return false
+	}
+	return true
+}
+
+// scimPaging reads startIndex and count; a missing count is passed on as -1
+// so the service applies its default.
+func scimPaging(r *http.Request) (int, int) {
+	query := r.URL.Query()
+	startIndex, _ := strconv.Atoi(query.Get("startIndex"))
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil {
+		count = -1
+	}
+	return startIndex, count
+}
+
+func scimWithMembers(r *http.Request) bool {
+	for _, attr := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return false
+		}
+	}
+	return true
+}
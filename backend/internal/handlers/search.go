@@ -61,7 +61,7 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 		req.Type = "all"
 	}
 
-	result, err := h.searchService.Search(r.Context(), userID, req)
+	result, err := h.searchService.Search(r.Context(), middleware.GetTenantFromContext(r.Context()), req)
 	if err != nil {
 		h.logger.Error("Search error", zap.Error(err))
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
@@ -3,9 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
 	"base-app-service/internal/middleware"
@@ -18,16 +21,26 @@ import (
 var validateSettings = validator.New()
 
 type SettingsHandler struct {
-	settingsService *services.SettingsService
-	sessionRepo     repositories.SessionRepository
-	logger          *zap.Logger
+	settingsService     *services.SettingsService
+	sessionRepo         repositories.SessionRepository
+	authService         *services.AuthService
+	loginHistoryService *services.LoginHistoryService
+	logger              *zap.Logger
 }
 
-func NewSettingsHandler(settingsService *services.SettingsService, sessionRepo repositories.SessionRepository, logger *zap.Logger) *SettingsHandler {
+func NewSettingsHandler(
+	settingsService *services.SettingsService,
+	sessionRepo repositories.SessionRepository,
+	authService *services.AuthService,
+	loginHistoryService *services.LoginHistoryService,
+	logger *zap.Logger,
+) *SettingsHandler {
 	return &SettingsHandler{
-		settingsService: settingsService,
-		sessionRepo:     sessionRepo,
-		logger:          logger,
+		settingsService:     settingsService,
+		sessionRepo:         sessionRepo,
+		authService:         authService,
+		loginHistoryService: loginHistoryService,
+		logger:              logger,
 	}
 }
 
@@ -66,6 +79,7 @@ func (h *SettingsHandler) GetActiveSessions(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Remove sensitive data
+	currentSessionID := middleware.GetSessionIDFromContext(r.Context())
 	safeSessions := make([]map[string]interface{}, len(sessions))
 	for i, s := range sessions {
 		safeSessions[i] = map[string]interface{}{
@@ -79,7 +93,7 @@ func (h *SettingsHandler) GetActiveSessions(w http.ResponseWriter, r *http.Reque
 			"location_city":   s.LocationCity,
 			"created_at":      s.CreatedAt,
 			"last_used_at":    s.LastUsedAt,
-			"is_current":      false, // Will be set by frontend based on current token
+			"is_current":      s.ID == currentSessionID,
 		}
 	}
 
@@ -89,42 +103,23 @@ func (h *SettingsHandler) GetActiveSessions(w http.ResponseWriter, r *http.Reque
 	})
 }
 
-// LogoutAllDevices logs out from all devices
-func (h *SettingsHandler) LogoutAllDevices(w http.ResponseWriter, r *http.Request) {
+// RevokeSession signs out one of the user's sessions
+func (h *SettingsHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
 		return
 	}
 
-	if err := h.sessionRepo.RevokeAllForUser(r.Context(), userID); err != nil {
-		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		return
-	}
-
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "Logged out from all devices successfully",
-	})
-}
-
-// UpdateProfileSettings updates profile settings
-func (h *SettingsHandler) UpdateProfileSettings(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.GetUserIDFromContext(r.Context())
-	if userID == uuid.Nil {
-		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
-		return
-	}
-
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	sessionID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid session id")
 		return
 	}
 
-	if err := h.settingsService.UpdateProfileSettings(r.Context(), userID, updates); err != nil {
-		if err.Error() == "username already taken" {
-			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+	if err := h.authService.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		if err.Error() == "session not found" {
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
 			return
 		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
@@ -133,62 +128,62 @@ func (h *SettingsHandler) UpdateProfileSettings(w http.ResponseWriter, r *http.R
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Profile settings updated successfully",
+		"message": "Session signed out",
 	})
 }
 
-// UpdateSecuritySettings updates security settings
-func (h *SettingsHandler) UpdateSecuritySettings(w http.ResponseWriter, r *http.Request) {
+// GetLoginHistory lists the user's sign-ins, failed attempts and token refreshes
+func (h *SettingsHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
-		return
+	limit, offset := 50, 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		limit = l
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil {
+		offset = o
 	}
 
-	if err := h.settingsService.UpdateSecuritySettings(r.Context(), userID, updates); err != nil {
+	events, err := h.loginHistoryService.ListForUser(r.Context(), userID, limit, offset)
+	if err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
+	if events == nil {
+		events = []*models.LoginEvent{}
+	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Security settings updated successfully",
+		"data":    events,
 	})
 }
 
-// UpdatePrivacySettings updates privacy settings
-func (h *SettingsHandler) UpdatePrivacySettings(w http.ResponseWriter, r *http.Request) {
+// LogoutAllDevices logs out from all devices
+func (h *SettingsHandler) LogoutAllDevices(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
-		return
-	}
-
-	if err := h.settingsService.UpdatePrivacySettings(r.Context(), userID, updates); err != nil {
+	if err := h.sessionRepo.RevokeAllForUser(r.Context(), userID); err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Privacy settings updated successfully",
+		"message": "Logged out from all devices successfully",
 	})
 }
 
-// UpdateNotificationSettings updates notification settings
-func (h *SettingsHandler) UpdateNotificationSettings(w http.ResponseWriter, r *http.Request) {
+// UpdateProfileSettings updates profile settings
+func (h *SettingsHandler) UpdateProfileSettings(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
@@ -201,19 +196,23 @@ func (h *SettingsHandler) UpdateNotificationSettings(w http.ResponseWriter, r *h
 		return
 	}
 
-	if err := h.settingsService.UpdateNotificationSettings(r.Context(), userID, updates); err != nil {
+	if err := h.settingsService.UpdateProfileSettings(r.Context(), userID, updates); err != nil {
+		if err.Error() == "username already taken" {
+			errors.RespondError(w, http.StatusConflict, "CONFLICT", err.Error())
+			return
+		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Notification settings updated successfully",
+		"message": "Profile settings updated successfully",
 	})
 }
 
-// UpdateAccountPreferences updates account preferences
-func (h *SettingsHandler) UpdateAccountPreferences(w http.ResponseWriter, r *http.Request) {
+// UpdateSecuritySettings updates security settings
+func (h *SettingsHandler) UpdateSecuritySettings(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
@@ -226,103 +225,93 @@ func (h *SettingsHandler) UpdateAccountPreferences(w http.ResponseWriter, r *htt
 		return
 	}
 
-	if err := h.settingsService.UpdateAccountPreferences(r.Context(), userID, updates); err != nil {
+	if err := h.settingsService.UpdateSecuritySettings(r.Context(), userID, updates); err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Account preferences updated successfully",
+		"message": "Security settings updated successfully",
 	})
 }
 
-// AddConnectedAccount adds a connected third-party account
-func (h *SettingsHandler) AddConnectedAccount(w http.ResponseWriter, r *http.Request) {
+// UpdatePrivacySettings updates privacy settings
+func (h *SettingsHandler) UpdatePrivacySettings(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
 		return
 	}
 
-	var account models.ConnectedAccount
-	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+	var updates map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
 
-	if err := h.settingsService.AddConnectedAccount(r.Context(), userID, account); err != nil {
+	if err := h.settingsService.UpdatePrivacySettings(r.Context(), userID, updates); err != nil {
+		if strings.HasPrefix(err.Error(), "invalid ") {
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Connected account added successfully",
+		"message": "Privacy settings updated successfully",
 	})
 }
 
-// RemoveConnectedAccount removes a connected account
-func (h *SettingsHandler) RemoveConnectedAccount(w http.ResponseWriter, r *http.Request) {
+// UpdateNotificationSettings updates notification settings
+func (h *SettingsHandler) UpdateNotificationSettings(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
 		return
 	}
 
-	var req struct {
-		Provider string `json:"provider" validate:"required"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var updates map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
 
-	if err := validateSettings.Struct(req); err != nil {
-		errors.RespondValidationError(w, err)
-		return
-	}
-
-	if err := h.settingsService.RemoveConnectedAccount(r.Context(), userID, req.Provider); err != nil {
+	if err := h.settingsService.UpdateNotificationSettings(r.Context(), userID, updates, clientInfo(r)); err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Connected account removed successfully",
+		"message": "Notification settings updated successfully",
 	})
 }
 
-// RequestAccountDeletion schedules account deletion
-func (h *SettingsHandler) RequestAccountDeletion(w http.ResponseWriter, r *http.Request) {
+// UpdateAccountPreferences updates account preferences
+func (h *SettingsHandler) UpdateAccountPreferences(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
 	if userID == uuid.Nil {
 		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session")
 		return
 	}
 
-	var req struct {
-		DaysUntilDeletion int `json:"days_until_deletion" validate:"required,min=1,max=30"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var updates map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
 		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
 
-	if err := validateSettings.Struct(req); err != nil {
-		errors.RespondValidationError(w, err)
-		return
-	}
-
-	if err := h.settingsService.RequestAccountDeletion(r.Context(), userID, req.DaysUntilDeletion); err != nil {
+	if err := h.settingsService.UpdateAccountPreferences(r.Context(), userID, updates); err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Account deletion scheduled successfully",
+		"message": "Account preferences updated successfully",
 	})
 }
 
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type SetupHandler struct {
+	setupService *services.SetupService
+	logger       *zap.Logger
+}
+
+func NewSetupHandler(setupService *services.SetupService, logger *zap.Logger) *SetupHandler {
+	return &SetupHandler{
+		setupService: setupService,
+		logger:       logger,
+	}
+}
+
+// Status tells the sign-in page whether to offer first-run setup.
+func (h *SetupHandler) Status(w http.ResponseWriter, r *http.Request) {
+	required, err := h.setupService.Required(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to check setup status", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to check setup status")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"required": required,
+		},
+	})
+}
+
+// Complete creates the first admin with the setup token and returns a
+// session, like Signup.
+func (h *SetupHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token         string `json:"token" validate:"required,max=128"`
+		Email         string `json:"email" validate:"required,email,max=255"`
+		Name          string `json:"name" validate:"required,max=255"`
+		Password      string `json:"password" validate:"required"`
+		TermsAccepted bool   `json:"terms_accepted" validate:"required"`
+		TermsVersion  string `json:"terms_version" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	user, session, err := h.setupService.Complete(r.Context(), services.CompleteSetupRequest{
+		Token:        req.Token,
+		Email:        req.Email,
+		Name:         req.Name,
+		Password:     req.Password,
+		TermsVersion: req.TermsVersion,
+		Client:       clientInfo(r),
+	})
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{
+				"id":             user.ID.String(),
+				"email":          user.Email,
+				"name":           user.Name,
+				"email_verified": user.EmailVerified,
+				"status":         user.Status,
+				"role":           user.Role,
+			},
+			"session": map[string]interface{}{
+				"id":            session.ID.String(),
+				"token":         session.Token,
+				"refresh_token": *session.RefreshToken,
+				"expires_at":    session.ExpiresAt.Format(time.RFC3339),
+			},
+		},
+	})
+}
+
+func (h *SetupHandler) respondError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "invalid setup token":
+		errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+	case msg == "setup is already complete", msg == "user already exists":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	case msg == "email is required", msg == "name is required", strings.HasPrefix(msg, "password validation failed"), isTermsError(err):
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+	default:
+		h.logger.Error("Failed to complete setup", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to complete setup")
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type SystemSettingsHandler struct {
+	systemSettingsService *services.SystemSettingsService
+	logger                *zap.Logger
+}
+
+func NewSystemSettingsHandler(systemSettingsService *services.SystemSettingsService, logger *zap.Logger) *SystemSettingsHandler {
+	return &SystemSettingsHandler{
+		systemSettingsService: systemSettingsService,
+		logger:                logger,
+	}
+}
+
+func (h *SystemSettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.systemSettingsService.GetSettings(r.Context())
+	if err != nil {
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+func (h *SystemSettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var updates map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	adminID := middleware.GetUserIDFromContext(r.Context())
+	settings, err := h.systemSettingsService.UpdateSettings(r.Context(), adminID, updates)
+	if err != nil {
+		msg := err.Error()
+		if msg == "no settings to update" || strings.HasPrefix(msg, "unknown setting") || strings.HasPrefix(msg, "invalid value") {
+			errors.RespondError(w, http.StatusBadRequest, "BAD_REQUEST", msg)
+			return
+		}
+		h.logger.Error("Failed to update system settings", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    settings,
+	})
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/errors"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+	smsService       *services.SMSService
+	logger           *zap.Logger
+}
+
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService, smsService *services.SMSService, logger *zap.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		smsService:       smsService,
+		logger:           logger,
+	}
+}
+
+// GetStatus returns the signed-in user's second factor and phone.
+func (h *TwoFactorHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	status, err := h.twoFactorService.Status(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get two-factor status", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get two-factor status")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// Update turns SMS two-factor authentication on or off.
+func (h *TwoFactorHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	var req struct {
+		Enabled  bool   `json:"enabled"`
+		Password string `json:"password" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	var err error
+	if req.Enabled {
+		err = h.twoFactorService.EnableSMS(r.Context(), userID, req.Password)
+	} else {
+		err = h.twoFactorService.Disable(r.Context(), userID, req.Password)
+	}
+	if err != nil {
+		switch msg := err.Error(); msg {
+		case "invalid credentials":
+			errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Current password is incorrect")
+		case "verify a phone number first", "set a password before changing two-factor authentication":
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+		default:
+			h.logger.Error("Failed to update two-factor authentication", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update two-factor authentication")
+		}
+		return
+	}
+
+	h.GetStatus(w, r)
+}
+
+// StartPhoneVerification texts a code to a new or unverified phone number.
+func (h *TwoFactorHandler) StartPhoneVerification(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	var req struct {
+		Phone string `json:"phone" validate:"required,max=32"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	ipAddress := getIPAddress(r)
+	code, err := h.smsService.StartPhoneVerification(r.Context(), userID, req.Phone, &ipAddress)
+	if err != nil {
+		h.respondSMSError(w, err, "Failed to send verification code")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Verification code sent",
+		"data": map[string]interface{}{
+			"expires_at": code.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+}
+
+// ConfirmPhoneVerification checks the texted code and stores the number.
+func (h *TwoFactorHandler) ConfirmPhoneVerification(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r.Context())
+
+	var req struct {
+		Code string `json:"code" validate:"required,max=16"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	user, err := h.smsService.ConfirmPhone(r.Context(), userID, strings.TrimSpace(req.Code))
+	if err != nil {
+		h.respondSMSError(w, err, "Failed to verify phone number")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Phone number verified",
+		"data": map[string]interface{}{
+			"phone":          user.Phone,
+			"phone_verified": user.PhoneVerified,
+		},
+	})
+}
+
+// Verify completes a sign-in that needed a second factor and returns a
+// session, like Login.
+func (h *TwoFactorHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" validate:"required,max=128"`
+		Code           string `json:"code" validate:"required,max=16"`
+		TrustDevice    bool   `json:"trust_device"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	info := clientInfo(r)
+	user, session, isNewDevice, trustToken, err := h.twoFactorService.Verify(r.Context(), req.ChallengeToken,
+		strings.TrimSpace(req.Code), req.TrustDevice, info)
+	if err != nil {
+		switch msg := err.Error(); msg {
+		case "invalid or expired code":
+			errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", msg)
+		case "account is not active":
+			errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", msg)
+		default:
+			h.logger.Error("Two-factor sign-in failed", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		}
+		return
+	}
+
+	deviceData := map[string]interface{}{}
+	if info.DeviceID != nil {
+		deviceData["id"] = *info.DeviceID
+		deviceData["is_new_device"] = isNewDevice
+		if trustToken != "" {
+			// Sent as X-Device-Trust to skip the second factor on this device
+			deviceData["trust_token"] = trustToken
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{
+				"id":             user.ID.String(),
+				"email":          user.Email,
+				"name":           user.Name,
+				"email_verified": user.EmailVerified,
+				"status":         user.Status,
+				"role":           user.Role,
+			},
+			"session": map[string]interface{}{
+				"id":            session.ID.String(),
+				"token":         session.Token,
+				"refresh_token": *session.RefreshToken,
+				"expires_at":    session.ExpiresAt.Format(time.RFC3339),
+			},
+			"device": deviceData,
+		},
+	})
+}
+
+// Resend texts a new code for a pending sign-in.
+func (h *TwoFactorHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" validate:"required,max=128"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		errors.RespondValidationError(w, err)
+		return
+	}
+
+	challenge, err := h.twoFactorService.Resend(r.Context(), req.ChallengeToken, clientInfo(r))
+	if err != nil {
+		h.respondSMSError(w, err, "Failed to send sign-in code")
+		return
+	}
+	respondTwoFactorChallenge(w, challenge)
+}
+
+func (h *TwoFactorHandler) respondSMSError(w http.ResponseWriter, err error, fallback string) {
+	msg := err.Error()
+	switch {
+	case msg == "invalid or expired code":
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_TOKEN", msg)
+	case msg == "too many codes requested, try again later":
+		errors.RespondError(w, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", msg)
+	case msg == "turn off two-factor authentication before changing your phone number":
+		errors.RespondError(w, http.StatusConflict, "CONFLICT", msg)
+	case strings.HasPrefix(msg, "phone number "):
+		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+	case strings.HasPrefix(msg, "failed to send text message"):
+		h.logger.Error(fallback, zap.Error(err))
+		errors.RespondError(w, http.StatusBadGateway, "PROVIDER_UNAVAILABLE", "Could not send the text message")
+	default:
+		h.logger.Error(fallback, zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+	}
+}
+
+// respondTwoFactorChallenge answers a sign-in whose first step succeeded but
+// that needs a second factor before a session is issued.
+func respondTwoFactorChallenge(w http.ResponseWriter, challenge *services.TwoFactorRequiredError) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"two_factor_required": true,
+			"challenge_token":     challenge.Token,
+			"method":              challenge.Method,
+			"destination":         challenge.Destination,
+			"expires_at":          challenge.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+}
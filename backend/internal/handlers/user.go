@@ -3,37 +3,40 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
 	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
 	"base-app-service/pkg/auth"
 	"base-app-service/pkg/errors"
 )
 
 type UserHandler struct {
-	userRepo    repositories.UserRepository
-	requestRepo repositories.AccessRequestRepository
-	themeRepo   repositories.ThemeRepository
-	sessionRepo repositories.SessionRepository
-	logger      *zap.Logger
+	userRepo  repositories.UserRepository
+	passwords *services.PasswordPolicyService
+	deletions *services.AccountDeletionService
+	consents  *services.ConsentService
+	logger    *zap.Logger
 }
 
 func NewUserHandler(
 	userRepo repositories.UserRepository,
-	requestRepo repositories.AccessRequestRepository,
-	themeRepo repositories.ThemeRepository,
-	sessionRepo repositories.SessionRepository,
+	passwords *services.PasswordPolicyService,
+	deletions *services.AccountDeletionService,
+	consents *services.ConsentService,
 	logger *zap.Logger,
 ) *UserHandler {
 	return &UserHandler{
-		userRepo:    userRepo,
-		requestRepo: requestRepo,
-		themeRepo:   themeRepo,
-		sessionRepo: sessionRepo,
-		logger:      logger,
+		userRepo:  userRepo,
+		passwords: passwords,
+		deletions: deletions,
+		consents:  consents,
+		logger:    logger,
 	}
 }
 
@@ -50,6 +53,14 @@ func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Newly published required documents the user is asked to accept
+	pending, err := h.consents.Pending(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to check pending consents", zap.Error(err))
+		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get user")
+		return
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"data": map[string]interface{}{
@@ -66,6 +77,10 @@ func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 			"role":           user.Role,
 			"created_at":     user.CreatedAt,
 			"updated_at":     user.UpdatedAt,
+			// consent_required asks the client to have the user accept the
+			// pending documents before carrying on
+			"consent_required": len(pending) > 0,
+			"pending_consents": pending,
 		},
 	})
 }
@@ -87,7 +102,6 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		Name      *string `json:"name"`
 		FirstName *string `json:"first_name"`
 		LastName  *string `json:"last_name"`
-		Phone     *string `json:"phone"`
 		PhotoURL  *string `json:"photo_url"`
 	}
 
@@ -106,14 +120,12 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	if req.LastName != nil {
 		user.LastName = req.LastName
 	}
-	if req.Phone != nil {
-		user.Phone = req.Phone
-	}
 	if req.PhotoURL != nil {
 		user.PhotoURL = req.PhotoURL
 	}
 
-	// Note: Password updates are not allowed here - use change password endpoint
+	// Note: Password, email and phone updates are not allowed here - they each
+	// have an endpoint that confirms the change
 
 	if err := h.userRepo.Update(r.Context(), user); err != nil {
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
@@ -163,14 +175,11 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newHash, err := auth.HashPassword(req.NewPassword)
-	if err != nil {
-		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
-		return
-	}
-
-	changedAt := time.Now()
-	if err := h.userRepo.UpdatePassword(r.Context(), userID, newHash, changedAt); err != nil {
+	if err := h.passwords.SetPassword(r.Context(), user, req.NewPassword, false); err != nil {
+		if strings.HasPrefix(err.Error(), "password validation failed") {
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
 		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
@@ -181,72 +190,30 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ExportData lets a user download their data snapshot.
-func (h *UserHandler) ExportData(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.GetUserIDFromContext(r.Context())
-	if userID.String() == "00000000-0000-0000-0000-000000000000" {
-		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid user ID")
-		return
-	}
-
-	user, err := h.userRepo.GetByID(r.Context(), userID)
-	if err != nil {
-		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
-		return
-	}
-
-	requests, err := h.requestRepo.ListByUser(r.Context(), userID.String())
-	if err != nil {
-		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		return
-	}
-
-	theme, _ := h.themeRepo.GetGlobalTheme(r.Context(), userID)
-
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
-			"profile":         user,
-			"theme":           theme,
-			"access_requests": requests,
-		},
-	})
-}
-
-// RequestDeletion performs a soft delete, revokes sessions, and schedules purge after retention.
+// RequestDeletion marks the account deleted, signs it out everywhere and
+// schedules the purge. Signing in again before then cancels it.
 func (h *UserHandler) RequestDeletion(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserIDFromContext(r.Context())
-	if userID.String() == "00000000-0000-0000-0000-000000000000" {
-		errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid user ID")
-		return
-	}
-
-	user, err := h.userRepo.GetByID(r.Context(), userID)
+	deletion, err := h.deletions.Request(r.Context(), userID, models.AccountDeletionBySelf, nil)
 	if err != nil {
-		errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
-		return
-	}
-	if user.Status == "deleted" {
-		errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account deletion already requested")
+		switch err.Error() {
+		case "user not found":
+			errors.RespondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+		case "account deletion already requested":
+			errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account deletion already requested")
+		default:
+			h.logger.Error("Failed to request account deletion", zap.Error(err))
+			errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to request account deletion")
+		}
 		return
 	}
 
-	if err := h.userRepo.MarkDeleted(r.Context(), userID); err != nil {
-		errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		return
-	}
-
-	// Revoke all active sessions for safety.
-	if err := h.sessionRepo.RevokeAllForUser(r.Context(), userID); err != nil {
-		h.logger.Warn("failed to revoke sessions after deletion request", zap.Error(err))
-	}
-
-	purgeAt := time.Now().Add(5 * 24 * time.Hour)
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Account scheduled for deletion in 5 days. You will be signed out.",
+		"message": "Account scheduled for deletion. You will be signed out; sign in again before " +
+			deletion.PurgeAt.UTC().Format("January 2, 2006 15:04 MST") + " to cancel.",
 		"data": map[string]interface{}{
-			"purge_at": purgeAt.Format(time.RFC3339),
+			"purge_at": deletion.PurgeAt.Format(time.RFC3339),
 			"status":   "deleted",
 		},
 	})
@@ -8,11 +8,28 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"base-app-service/internal/models"
 	"base-app-service/pkg/auth"
 	"base-app-service/pkg/errors"
 )
 
-func AuthMiddleware(jwtSecret string, logger *zap.Logger) func(http.Handler) http.Handler {
+// APIKeyAuthenticator resolves a personal access token to its key and owner.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, token, ipAddress string) (*models.APIKey, *models.User, error)
+}
+
+// SessionChecker reports whether the session an access token was issued for
+// is still active, so revoking a session stops its access tokens straight
+// away rather than when they expire.
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, userID, sessionID uuid.UUID) bool
+}
+
+// AuthMiddleware accepts either a JWT access token or, when apiKeys is set,
+// a personal access token ("Bearer pat_..."). When sessions is set, access
+// tokens of revoked or ended sessions are rejected; impersonation tokens have
+// no session and are checked by TrackImpersonation instead.
+func AuthMiddleware(jwtSecret string, apiKeys APIKeyAuthenticator, sessions SessionChecker, logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -28,6 +45,24 @@ func AuthMiddleware(jwtSecret string, logger *zap.Logger) func(http.Handler) htt
 			}
 
 			token := parts[1]
+			if apiKeys != nil && strings.HasPrefix(token, "pat_") {
+				key, user, err := apiKeys.AuthenticateAPIKey(r.Context(), token, GetIPAddress(r))
+				if err != nil {
+					logger.Debug("API key authentication failed", zap.Error(err))
+					errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid API key")
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
+				ctx = context.WithValue(ctx, SessionIDKey, uuid.Nil)
+				ctx = context.WithValue(ctx, UserRoleKey, user.Role)
+				ctx = context.WithValue(ctx, APIKeyIDKey, key.ID)
+				ctx = context.WithValue(ctx, ScopesKey, key.Scopes)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			claims, err := auth.ValidateToken(token, jwtSecret)
 			if err != nil {
 				errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
@@ -45,11 +80,30 @@ func AuthMiddleware(jwtSecret string, logger *zap.Logger) func(http.Handler) htt
 				errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session ID")
 				return
 			}
+			if sessions != nil && claims.Actor == nil && !sessions.IsSessionActive(r.Context(), userID, sessionID) {
+				errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Session has ended")
+				return
+			}
 
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
 			ctx = context.WithValue(ctx, SessionIDKey, sessionID)
 			ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
 
+			if claims.Actor != nil {
+				actorID, err := uuid.Parse(claims.Actor.Subject)
+				if err != nil {
+					errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid actor")
+					return
+				}
+				impersonationID, err := uuid.Parse(claims.Actor.ImpersonationID)
+				if err != nil {
+					errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid impersonation ID")
+					return
+				}
+				ctx = context.WithValue(ctx, ActorIDKey, actorID)
+				ctx = context.WithValue(ctx, ImpersonationIDKey, impersonationID)
+			}
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -79,6 +133,93 @@ func GetUserRoleFromContext(ctx context.Context) string {
 	return role
 }
 
+// GetAPIKeyIDFromContext returns the API key used for the request, or uuid.Nil
+// when the request was authenticated with a JWT.
+func GetAPIKeyIDFromContext(ctx context.Context) uuid.UUID {
+	keyID, ok := ctx.Value(APIKeyIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return keyID
+}
+
+func GetScopesFromContext(ctx context.Context) []string {
+	scopes, ok := ctx.Value(ScopesKey).([]string)
+	if !ok {
+		return nil
+	}
+	return scopes
+}
+
+// ScopeRule maps a path prefix to the scopes an API key needs to read
+// (GET/HEAD) or write (any other method) under it. With Exact, the rule
+// covers PathPrefix itself and nothing below it.
+type ScopeRule struct {
+	PathPrefix string
+	Exact      bool
+	ReadScope  string
+	WriteScope string
+}
+
+func (rule *ScopeRule) matches(path string) bool {
+	if rule.Exact {
+		return path == rule.PathPrefix
+	}
+	return strings.HasPrefix(path, rule.PathPrefix)
+}
+
+// RequireAPIKeyScopes restricts API-key requests to routes covered by a rule
+// and the scopes granted to the key. JWT-authenticated requests pass through.
+// Routes without a matching rule, or whose rule leaves the scope for the
+// request method empty, are closed to API keys.
+func RequireAPIKeyScopes(rules []ScopeRule, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetAPIKeyIDFromContext(r.Context()) == uuid.Nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var rule *ScopeRule
+			for i := range rules {
+				if rules[i].matches(r.URL.Path) {
+					if rule == nil || len(rules[i].PathPrefix) > len(rule.PathPrefix) {
+						rule = &rules[i]
+					}
+				}
+			}
+			required := ""
+			if rule != nil {
+				required = rule.WriteScope
+				if r.Method == http.MethodGet || r.Method == http.MethodHead {
+					required = rule.ReadScope
+				}
+			}
+			if required == "" {
+				errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Endpoint not available to API keys")
+				return
+			}
+			if !hasScope(GetScopesFromContext(r.Context()), required, rule.WriteScope) {
+				errors.RespondError(w, http.StatusForbidden, "INSUFFICIENT_SCOPE", "API key is missing scope "+required)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasScope reports whether scopes contains required. A write scope also
+// grants read access under the same rule.
+func hasScope(scopes []string, required, writeScope string) bool {
+	for _, s := range scopes {
+		if s == required || (writeScope != "" && s == writeScope) {
+			return true
+		}
+	}
+	return false
+}
+
 // RequireRole ensures the authenticated user has a matching role.
 func RequireRole(role string, logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
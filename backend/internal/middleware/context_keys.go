@@ -5,9 +5,17 @@ type contextKey string
 
 // Context keys used across middleware
 const (
-	UserIDKey    contextKey = "user_id"
-	SessionIDKey contextKey = "session_id"
-	UserRoleKey  contextKey = "user_role"
-	RequestIDKey contextKey = "request_id"
+	UserIDKey           contextKey = "user_id"
+	SessionIDKey        contextKey = "session_id"
+	UserRoleKey         contextKey = "user_role"
+	RequestIDKey        contextKey = "request_id"
+	APIKeyIDKey         contextKey = "api_key_id"
+	ScopesKey           contextKey = "scopes"
+	PermissionsKey      contextKey = "permissions"
+	ActorIDKey          contextKey = "actor_id"
+	ImpersonationIDKey  contextKey = "impersonation_id"
+	ClientIPKey         contextKey = "client_ip"
+	OrganizationIDKey   contextKey = "organization_id"
+	OrganizationRoleKey contextKey = "organization_role"
+	SCIMTokenIDKey      contextKey = "scim_token_id"
 )
-
@@ -7,7 +7,7 @@ func CORSMiddleware() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Product-Name, X-Device-ID")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Product-Name, X-Device-ID, X-Device-Trust, X-Organization-ID")
 			w.Header().Set("Access-Control-Max-Age", "3600")
 
 			if r.Method == "OPTIONS" {
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/pkg/errors"
+)
+
+// ImpersonationTracker validates impersonation sessions and audits requests
+// made under them.
+type ImpersonationTracker interface {
+	IsImpersonationActive(ctx context.Context, impersonationID uuid.UUID) bool
+	RecordImpersonatedRequest(ctx context.Context, impersonationID, actorID, userID uuid.UUID, method, path string)
+}
+
+// GetActorIDFromContext returns the admin behind an impersonation token, or
+// uuid.Nil for ordinary requests.
+func GetActorIDFromContext(ctx context.Context) uuid.UUID {
+	actorID, ok := ctx.Value(ActorIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return actorID
+}
+
+func GetImpersonationIDFromContext(ctx context.Context) uuid.UUID {
+	impersonationID, ok := ctx.Value(ImpersonationIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return impersonationID
+}
+
+// IsImpersonating reports whether the request uses an impersonation token.
+func IsImpersonating(ctx context.Context) bool {
+	return GetActorIDFromContext(ctx) != uuid.Nil
+}
+
+// TrackImpersonation rejects impersonation tokens whose session was stopped or
+// expired, and records every remaining impersonated request with both
+// identities. It must run after AuthMiddleware.
+func TrackImpersonation(tracker ImpersonationTracker, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !IsImpersonating(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			impersonationID := GetImpersonationIDFromContext(r.Context())
+			if !tracker.IsImpersonationActive(r.Context(), impersonationID) {
+				errors.RespondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Impersonation session has ended")
+				return
+			}
+
+			tracker.RecordImpersonatedRequest(r.Context(), impersonationID,
+				GetActorIDFromContext(r.Context()), GetUserIDFromContext(r.Context()),
+				r.Method, r.URL.Path)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DenyImpersonation blocks sensitive actions while impersonating.
+func DenyImpersonation(logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsImpersonating(r.Context()) {
+				logger.Warn("Blocked sensitive action during impersonation",
+					zap.String("actor_id", GetActorIDFromContext(r.Context()).String()),
+					zap.String("user_id", GetUserIDFromContext(r.Context()).String()),
+					zap.String("path", r.URL.Path),
+				)
+				errors.RespondError(w, http.StatusForbidden, "IMPERSONATION_FORBIDDEN", "This action is not allowed while impersonating")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
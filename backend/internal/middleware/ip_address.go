@@ -1,26 +1,118 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 )
 
+// ParseTrustedProxies parses a list of proxy addresses, each either a single
+// IP or a CIDR range, as accepted by ClientIPMiddleware.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", proxy)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", proxy)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ClientIPMiddleware resolves the client address once per request. Forwarding
+// headers are only honoured when the connection comes from a trusted proxy;
+// X-Forwarded-For is then read right to left, skipping trusted hops, so a
+// client cannot spoof its address by sending the header itself.
+func ClientIPMiddleware(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trustedProxies)
+			ctx := context.WithValue(r.Context(), ClientIPKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetIPAddress returns the client address resolved by ClientIPMiddleware, or
+// the connection's peer address when the middleware did not run.
 func GetIPAddress(r *http.Request) string {
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		ip = r.Header.Get("X-Real-IP")
+	if ip, ok := r.Context().Value(ClientIPKey).(string); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+func resolveClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	peer := remoteIP(r)
+	if !isTrustedProxy(peer, trustedProxies) {
+		return peer
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
 	}
-	if ip == "" {
-		ip = r.RemoteAddr
+	if len(hops) == 0 {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+			return realIP
+		}
+		return peer
 	}
 
-	// Remove port from IP address
-	if idx := strings.LastIndex(ip, "]:"); idx != -1 {
-		ip = ip[1:idx] // Remove [ and :port
-	} else if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
+	// The nearest untrusted hop is the client; anything left of it was
+	// supplied by the client and cannot be relied on
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		client = hops[i]
+		if !isTrustedProxy(client, trustedProxies) {
+			break
+		}
 	}
+	return client
+}
 
-	return ip
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
 }
 
+// remoteIP strips the port from the connection's peer address.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
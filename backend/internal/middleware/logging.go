@@ -34,7 +34,7 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 				zap.String("path", r.URL.Path),
 				zap.Int("status", wrapped.statusCode),
 				zap.Duration("duration", duration),
-				zap.String("ip", r.RemoteAddr),
+				zap.String("ip", GetIPAddress(r)),
 				zap.String("user_agent", r.UserAgent()),
 			)
 		})
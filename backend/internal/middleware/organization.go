@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/pkg/errors"
+)
+
+// OrganizationHeader selects the organization a request works in. Without
+// it, requests work with the user's own records.
+const OrganizationHeader = "X-Organization-ID"
+
+// OrganizationResolver returns a user's role in an organization, or "" when
+// they are not a member.
+type OrganizationResolver interface {
+	MemberRole(ctx context.Context, organizationID, userID uuid.UUID) (string, error)
+}
+
+// OrganizationContext checks that the user belongs to the organization named
+// by OrganizationHeader and stores it, with their role, in the context. It
+// must run after AuthMiddleware.
+func OrganizationContext(resolver OrganizationResolver, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(OrganizationHeader)
+			userID := GetUserIDFromContext(r.Context())
+			if header == "" || userID == uuid.Nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			organizationID, err := uuid.Parse(header)
+			if err != nil {
+				errors.RespondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid "+OrganizationHeader+" header")
+				return
+			}
+
+			role, err := resolver.MemberRole(r.Context(), organizationID, userID)
+			if err != nil {
+				logger.Error("Failed to load organization membership",
+					zap.String("user_id", userID.String()),
+					zap.String("organization_id", organizationID.String()),
+					zap.Error(err),
+				)
+				errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load organization")
+				return
+			}
+			if role == "" {
+				errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Not a member of this organization")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), OrganizationIDKey, organizationID)
+			ctx = context.WithValue(ctx, OrganizationRoleKey, role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetOrganizationIDFromContext returns the organization the request works in,
+// or uuid.Nil for the user's own records.
+func GetOrganizationIDFromContext(ctx context.Context) uuid.UUID {
+	organizationID, ok := ctx.Value(OrganizationIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return organizationID
+}
+
+func GetOrganizationRoleFromContext(ctx context.Context) string {
+	role, ok := ctx.Value(OrganizationRoleKey).(string)
+	if !ok {
+		return ""
+	}
+	return role
+}
+
+// GetTenantFromContext returns whose records the request works with.
+func GetTenantFromContext(ctx context.Context) *models.Tenant {
+	tenant := &models.Tenant{UserID: GetUserIDFromContext(ctx)}
+	if organizationID := GetOrganizationIDFromContext(ctx); organizationID != uuid.Nil {
+		tenant.OrganizationID = &organizationID
+		tenant.OrganizationRole = GetOrganizationRoleFromContext(ctx)
+	}
+	return tenant
+}
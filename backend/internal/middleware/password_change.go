@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/pkg/errors"
+)
+
+// PasswordChangeChecker reports whether a user has to replace their password
+// before doing anything else.
+type PasswordChangeChecker interface {
+	PasswordChangeRequired(ctx context.Context, userID uuid.UUID) bool
+}
+
+// RequirePasswordChange rejects requests from users whose password has expired
+// or was reset by an admin, except to the paths in allowed, which must let
+// them change it. API keys and impersonation tokens are not held back: neither
+// can change the password. It must run after AuthMiddleware.
+func RequirePasswordChange(checker PasswordChangeChecker, allowed []string, logger *zap.Logger) func(http.Handler) http.Handler {
+	allowedPaths := make(map[string]bool, len(allowed))
+	for _, path := range allowed {
+		allowedPaths[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := GetUserIDFromContext(r.Context())
+			if userID == uuid.Nil || allowedPaths[r.URL.Path] ||
+				GetAPIKeyIDFromContext(r.Context()) != uuid.Nil || IsImpersonating(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if checker.PasswordChangeRequired(r.Context(), userID) {
+				logger.Debug("Blocked request pending password change",
+					zap.String("user_id", userID.String()),
+					zap.String("path", r.URL.Path),
+				)
+				errors.RespondError(w, http.StatusForbidden, "PASSWORD_CHANGE_REQUIRED", "You must change your password before continuing")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
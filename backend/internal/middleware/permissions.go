@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/pkg/errors"
+)
+
+// PermissionResolver returns the effective permissions for a user.
+type PermissionResolver interface {
+	EffectivePermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+}
+
+// LoadPermissions resolves the authenticated user's permissions once per
+// request and stores them in the context. It must run after AuthMiddleware.
+func LoadPermissions(resolver PermissionResolver, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := GetUserIDFromContext(r.Context())
+			if userID == uuid.Nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			permissions, err := resolver.EffectivePermissions(r.Context(), userID)
+			if err != nil {
+				logger.Error("Failed to load permissions", zap.String("user_id", userID.String()), zap.Error(err))
+				errors.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load permissions")
+				return
+			}
+
+			set := make(map[string]bool, len(permissions))
+			for _, p := range permissions {
+				set[p] = true
+			}
+			ctx := context.WithValue(r.Context(), PermissionsKey, set)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// HasPermission reports whether the request context carries permission.
+func HasPermission(ctx context.Context, permission string) bool {
+	set, ok := ctx.Value(PermissionsKey).(map[string]bool)
+	return ok && set[permission]
+}
+
+// GetPermissionsFromContext returns the permissions loaded by LoadPermissions.
+func GetPermissionsFromContext(ctx context.Context) []string {
+	set, ok := ctx.Value(PermissionsKey).(map[string]bool)
+	if !ok {
+		return nil
+	}
+	permissions := make([]string, 0, len(set))
+	for p := range set {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// RequirePermission rejects requests whose user lacks permission.
+func RequirePermission(permission string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasPermission(r.Context(), permission) {
+				logger.Debug("Permission denied",
+					zap.String("user_id", GetUserIDFromContext(r.Context()).String()),
+					zap.String("permission", permission),
+				)
+				errors.RespondError(w, http.StatusForbidden, "FORBIDDEN", "Missing permission "+permission)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
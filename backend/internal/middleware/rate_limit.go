@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -16,6 +18,7 @@ type RateLimiter interface {
 }
 
 type inMemoryRateLimiter struct {
+	mu    sync.Mutex
 	store map[string]*rateLimitEntry
 }
 
@@ -38,15 +41,19 @@ func (rl *inMemoryRateLimiter) cleanup() {
 	defer ticker.Stop()
 	for range ticker.C {
 		now := time.Now()
+		rl.mu.Lock()
 		for key, entry := range rl.store {
 			if now.After(entry.resetTime) {
 				delete(rl.store, key)
 			}
 		}
+		rl.mu.Unlock()
 	}
 }
 
 func (rl *inMemoryRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 	now := time.Now()
 	entry, exists := rl.store[key]
 
@@ -67,6 +74,8 @@ func (rl *inMemoryRateLimiter) Allow(ctx context.Context, key string, limit int,
 }
 
 func (rl *inMemoryRateLimiter) GetRemaining(ctx context.Context, key string, limit int) (int, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 	entry, exists := rl.store[key]
 	if !exists {
 		return limit, nil
@@ -84,9 +93,10 @@ func RateLimitMiddleware(limiter RateLimiter, limit int, window time.Duration, l
 			// Get identifier (IP address or user ID)
 			identifier := GetIPAddress(r)
 			
-			// If user is authenticated, use user ID instead
+			// If user is authenticated, use user ID instead. Anonymous
+			// requests carry uuid.Nil and are limited per IP.
 			userID := GetUserIDFromContext(r.Context())
-			if userID.String() != "" {
+			if userID != uuid.Nil {
 				identifier = fmt.Sprintf("user:%s", userID.String())
 			}
 
@@ -144,7 +154,7 @@ func RateLimitByEndpoint(limiter RateLimiter, limits map[string]RateLimitConfig,
 
 			identifier := GetIPAddress(r)
 			userID := GetUserIDFromContext(r.Context())
-			if userID.String() != "" {
+			if userID != uuid.Nil {
 				identifier = fmt.Sprintf("user:%s", userID.String())
 			}
 
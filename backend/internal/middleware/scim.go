@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/pkg/errors"
+)
+
+// SCIMTokenAuthenticator resolves a SCIM bearer token to its record.
+type SCIMTokenAuthenticator interface {
+	AuthenticateSCIMToken(ctx context.Context, token string) (*models.SCIMToken, error)
+}
+
+// SCIMAuth accepts only admin-issued SCIM tokens and stores the token's ID in
+// the context. Failures use the SCIM error format.
+func SCIMAuth(tokens SCIMTokenAuthenticator, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+				errors.RespondSCIMError(w, http.StatusUnauthorized, "", "Missing bearer token")
+				return
+			}
+
+			scimToken, err := tokens.AuthenticateSCIMToken(r.Context(), token)
+			if err != nil {
+				logger.Debug("SCIM authentication failed", zap.Error(err))
+				w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+				errors.RespondSCIMError(w, http.StatusUnauthorized, "", "Invalid SCIM token")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), SCIMTokenIDKey, scimToken.ID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetSCIMTokenIDFromContext returns the SCIM token the request was made with.
+func GetSCIMTokenIDFromContext(ctx context.Context) uuid.UUID {
+	if id, ok := ctx.Value(SCIMTokenIDKey).(uuid.UUID); ok {
+		return id
+	}
+	return uuid.Nil
+}
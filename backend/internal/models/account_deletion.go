@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Who asked for an account to be deleted.
+const (
+	AccountDeletionBySelf  = "self"
+	AccountDeletionByAdmin = "admin"
+	AccountDeletionBySCIM  = "scim"
+)
+
+// What a purge does with the CRUD records a user created.
+const (
+	CRUDPolicyAnonymize = "anonymize"
+	CRUDPolicyDelete    = "delete"
+)
+
+// AccountDeletion is a pending deletion. The account is marked deleted until
+// PurgeAt, when it is purged with everything stored about it.
+type AccountDeletion struct {
+	UserID         uuid.UUID  `db:"user_id" json:"user_id"`
+	RequestedBy    string     `db:"requested_by" json:"requested_by"`
+	ActorID        *uuid.UUID `db:"actor_id" json:"actor_id,omitempty"`
+	PreviousStatus string     `db:"previous_status" json:"-"`
+	RequestedAt    time.Time  `db:"requested_at" json:"requested_at"`
+	PurgeAt        time.Time  `db:"purge_at" json:"purge_at"`
+}
+
+// CancelledBySignIn reports whether signing in cancels the deletion, which is
+// only so for deletions users asked for themselves.
+func (d *AccountDeletion) CancelledBySignIn() bool {
+	return d.RequestedBy == AccountDeletionBySelf
+}
+
+// AccountPurgeCounts counts the rows a purge deleted and anonymized, by
+// table. Tables with nothing to purge are left out.
+type AccountPurgeCounts struct {
+	Deleted    map[string]int64 `json:"deleted"`
+	Anonymized map[string]int64 `json:"anonymized,omitempty"`
+}
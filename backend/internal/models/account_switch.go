@@ -15,6 +15,14 @@ type AccountSwitch struct {
 	Reason          *string   `db:"reason" json:"reason"`
 	IPAddress       *string   `db:"ip_address" json:"ip_address"`
 	UserAgent       *string   `db:"user_agent" json:"user_agent"`
+	ExpiresAt       *time.Time `db:"expires_at" json:"expires_at"` // Set for impersonation sessions
+	EndedAt         *time.Time `db:"ended_at" json:"ended_at"`
 	CreatedAt       time.Time `db:"created_at" json:"created_at"`
 }
 
+// IsActiveImpersonation reports whether the record is an impersonation session
+// that has neither been stopped nor expired.
+func (s *AccountSwitch) IsActiveImpersonation(now time.Time) bool {
+	return s.SwitchedToUserID != nil && s.ExpiresAt != nil && s.EndedAt == nil && now.Before(*s.ExpiresAt)
+}
+
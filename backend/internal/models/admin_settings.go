@@ -9,17 +9,16 @@ import (
 type AdminSettings struct {
 	AdminID              uuid.UUID `db:"admin_id" json:"admin_id"`
 	DashboardLayout      *string   `db:"dashboard_layout" json:"dashboard_layout"` // JSON
-	DefaultPermissions   *string   `db:"default_permissions" json:"default_permissions"` // JSON array
 	NotificationPreferences *string `db:"notification_preferences" json:"notification_preferences"` // JSON
 	ThemePreferences      *string   `db:"theme_preferences" json:"theme_preferences"` // JSON
-	AdminVerificationCode *string   `db:"admin_verification_code" json:"admin_verification_code"` // Verification code for admin creation
 	CreatedAt            time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt            time.Time `db:"updated_at" json:"updated_at"`
 }
 
 type CustomCRUDEntity struct {
 	ID          uuid.UUID `db:"id" json:"id"`
-	CreatedBy   uuid.UUID `db:"created_by" json:"created_by"`
+	OrganizationID *uuid.UUID `db:"organization_id" json:"organization_id"` // nil for an entity private to CreatedBy
+	CreatedBy   *uuid.UUID `db:"created_by" json:"created_by"` // nil once an anonymizing purge removed the creator
 	EntityName  string    `db:"entity_name" json:"entity_name"` // e.g., "products"
 	DisplayName string    `db:"display_name" json:"display_name"`
 	Description *string   `db:"description" json:"description"`
@@ -33,7 +32,7 @@ type CustomCRUDData struct {
 	ID        uuid.UUID  `db:"id" json:"id"`
 	EntityID  uuid.UUID  `db:"entity_id" json:"entity_id"`
 	Data      string     `db:"data" json:"data"` // JSON
-	CreatedBy uuid.UUID  `db:"created_by" json:"created_by"`
+	CreatedBy *uuid.UUID `db:"created_by" json:"created_by"` // nil once an anonymizing purge removed the creator
 	UpdatedBy *uuid.UUID `db:"updated_by" json:"updated_by"`
 	CreatedAt time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
@@ -73,6 +72,7 @@ type AdminPermission struct {
 // CRUDTemplate represents a CRUD template stored in the database
 type CRUDTemplate struct {
 	ID          uuid.UUID `db:"id" json:"id"`
+	OrganizationID *uuid.UUID `db:"organization_id" json:"organization_id"` // nil for platform templates
 	Name        string    `db:"name" json:"name"` // e.g., "portfolio", "visa"
 	DisplayName string    `db:"display_name" json:"display_name"`
 	Description *string   `db:"description" json:"description"`
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a long-lived personal access token used by machine clients.
+// Only the SHA-256 hash of the token is stored; the plaintext is shown once.
+type APIKey struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	UserID     uuid.UUID  `db:"user_id" json:"user_id"`
+	Name       string     `db:"name" json:"name"`
+	KeyPrefix  string     `db:"key_prefix" json:"key_prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	AllowedIPs []string   `db:"allowed_ips" json:"allowed_ips"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	LastUsedIP *string    `db:"last_used_ip" json:"last_used_ip"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
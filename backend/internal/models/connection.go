@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ConnectionPending  = "pending"
+	ConnectionAccepted = "accepted"
+)
+
+// Connection is a friendship between two users, or a request for one while
+// Status is pending.
+type Connection struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	RequesterID uuid.UUID  `db:"requester_id" json:"requester_id"`
+	AddresseeID uuid.UUID  `db:"addressee_id" json:"addressee_id"`
+	Status      string     `db:"status" json:"status"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	AcceptedAt  *time.Time `db:"accepted_at" json:"accepted_at"`
+}
+
+// Other returns the user on the other side of the connection from userID.
+func (c *Connection) Other(userID uuid.UUID) uuid.UUID {
+	if c.RequesterID == userID {
+		return c.AddresseeID
+	}
+	return c.RequesterID
+}
+
+// UserBlock records that BlockerID blocked BlockedID.
+type UserBlock struct {
+	BlockerID uuid.UUID `db:"blocker_id" json:"blocker_id"`
+	BlockedID uuid.UUID `db:"blocked_id" json:"blocked_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Friend is a friend's profile as the user may see it.
+type Friend struct {
+	*PublicProfile
+	FriendsSince *time.Time `json:"friends_since"`
+}
+
+// FriendRequest is a pending request to or from the user. User is the other
+// side of it.
+type FriendRequest struct {
+	ID        uuid.UUID      `json:"id"`
+	Direction string         `json:"direction"` // incoming, outgoing
+	User      *PublicProfile `json:"user"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// BlockedUser is someone the user blocked.
+type BlockedUser struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
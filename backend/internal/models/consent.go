@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Legal document kinds, which are also the consent kinds for accepting them.
+const (
+	LegalDocumentTerms   = "terms"
+	LegalDocumentPrivacy = "privacy"
+)
+
+// LegalDocumentKinds lists every kind of legal document.
+var LegalDocumentKinds = []string{LegalDocumentTerms, LegalDocumentPrivacy}
+
+// ConsentMarketing is the consent kind for opting in or out of marketing.
+const ConsentMarketing = "marketing"
+
+// Where a consent was given.
+const (
+	ConsentSourceSignup     = "signup"
+	ConsentSourceSetup      = "setup"
+	ConsentSourceInvitation = "invitation"
+	ConsentSourceUser       = "user"
+)
+
+// LegalDocument is a published version of the terms of service or privacy
+// policy. Publishing a Required version asks every user to accept it.
+type LegalDocument struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	Kind        string     `db:"kind" json:"kind"`
+	Version     string     `db:"version" json:"version"`
+	Title       string     `db:"title" json:"title"`
+	URL         *string    `db:"url" json:"url,omitempty"`
+	Required    bool       `db:"required" json:"required"`
+	PublishedBy *uuid.UUID `db:"published_by" json:"published_by,omitempty"`
+	PublishedAt time.Time  `db:"published_at" json:"published_at"`
+	// Acceptances counts the users who accepted this version; it is only
+	// filled in for admins.
+	Acceptances *int64 `db:"-" json:"acceptances,omitempty"`
+}
+
+// ConsentRecord is an entry in the consent ledger: a user accepting a legal
+// document version, or opting in or out of marketing.
+type ConsentRecord struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	Kind      string    `db:"kind" json:"kind"`
+	Version   *string   `db:"version" json:"version,omitempty"`
+	Granted   bool      `db:"granted" json:"granted"`
+	Source    string    `db:"source" json:"source"`
+	IPAddress *string   `db:"ip_address" json:"ip_address,omitempty"`
+	UserAgent *string   `db:"user_agent" json:"user_agent,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ConsentStatus is where a user stands: the documents they still have to
+// accept, their marketing choice and the ledger entries behind it.
+type ConsentStatus struct {
+	Pending   []*LegalDocument `json:"pending"`
+	Marketing bool             `json:"marketing"`
+	History   []*ConsentRecord `json:"history"`
+}
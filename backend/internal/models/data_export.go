@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired"
+)
+
+// DataExport is a ZIP archive of everything stored about a user, built in the
+// background. Once ready it can be downloaded with a token until ExpiresAt,
+// when the archive is deleted. Only the SHA-256 hash of the token is stored.
+type DataExport struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	Status       string     `db:"status" json:"status"`
+	FilePath     *string    `db:"file_path" json:"-"`
+	Size         *int64     `db:"size" json:"size,omitempty"`
+	TokenHash    *string    `db:"token_hash" json:"-"`
+	Error        *string    `db:"error" json:"error,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	CompletedAt  *time.Time `db:"completed_at" json:"completed_at"`
+	ExpiresAt    *time.Time `db:"expires_at" json:"expires_at"`
+	DownloadedAt *time.Time `db:"downloaded_at" json:"downloaded_at"`
+}
+
+// UploadedFile records a file a user uploaded.
+type UploadedFile struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	UserID       uuid.UUID `db:"user_id" json:"user_id"`
+	OriginalName string    `db:"original_name" json:"original_name"`
+	StoredName   string    `db:"stored_name" json:"stored_name"`
+	Path         string    `db:"path" json:"path"`
+	Size         int64     `db:"size" json:"size"`
+	ContentType  *string   `db:"content_type" json:"content_type"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
@@ -19,6 +19,8 @@ type Device struct {
 	LocationCity    *string    `db:"location_city" json:"location_city"`
 	IsTrusted       bool       `db:"is_trusted" json:"is_trusted"`
 	TrustedAt       *time.Time `db:"trusted_at" json:"trusted_at"`
+	TrustTokenHash  *string    `db:"trust_token_hash" json:"-"`
+	TrustExpiresAt  *time.Time `db:"-" json:"trust_expires_at,omitempty"`
 	LastUsedAt      time.Time  `db:"last_used_at" json:"last_used_at"`
 	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
 }
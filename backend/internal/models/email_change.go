@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailChangeRequest is a request to move an account to a new email address.
+// The address only changes once the link sent to NewEmail is followed, and
+// the link sent to OldEmail can undo it until RevertExpiresAt.
+type EmailChangeRequest struct {
+	ID               uuid.UUID  `db:"id" json:"id"`
+	UserID           uuid.UUID  `db:"user_id" json:"user_id"`
+	OldEmail         string     `db:"old_email" json:"old_email"`
+	NewEmail         string     `db:"new_email" json:"new_email"`
+	ConfirmTokenHash string     `db:"confirm_token_hash" json:"-"`
+	RevertTokenHash  string     `db:"revert_token_hash" json:"-"`
+	SessionID        *uuid.UUID `db:"session_id" json:"-"`
+	IPAddress        *string    `db:"ip_address" json:"-"`
+	ExpiresAt        time.Time  `db:"expires_at" json:"expires_at"`
+	RevertExpiresAt  time.Time  `db:"revert_expires_at" json:"revert_expires_at"`
+	ConfirmedAt      *time.Time `db:"confirmed_at" json:"confirmed_at"`
+	RevertedAt       *time.Time `db:"reverted_at" json:"reverted_at"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+}
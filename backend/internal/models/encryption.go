@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Encryption key purposes
+const (
+	EncryptionKeyData  = "data"
+	EncryptionKeyIndex = "index"
+)
+
+// Encryption key statuses
+const (
+	EncryptionKeyActive  = "active"
+	EncryptionKeyRetired = "retired"
+)
+
+// EncryptionKey is a data or index key, stored wrapped by the master key.
+type EncryptionKey struct {
+	ID          string     `db:"id" json:"id"`
+	Purpose     string     `db:"purpose" json:"purpose"`
+	Status      string     `db:"status" json:"status"`
+	WrappedKey  string     `db:"wrapped_key" json:"-"`
+	MasterKeyID string     `db:"master_key_id" json:"master_key_id"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	RetiredAt   *time.Time `db:"retired_at" json:"retired_at,omitempty"`
+}
+
+// EncryptedFieldStats counts the values stored in an encrypted column:
+// Plaintext were stored before encryption and not yet re-encrypted, and
+// ByKey counts sealed values by the data key they were sealed with.
+type EncryptedFieldStats struct {
+	Field     string           `json:"field"`
+	Total     int64            `json:"total"`
+	Plaintext int64            `json:"plaintext"`
+	ByKey     map[string]int64 `json:"by_key"`
+}
+
+// EncryptionStatus is what admins see of field encryption.
+type EncryptionStatus struct {
+	MasterKeyID  string                 `json:"master_key_id"`
+	ActiveKeyID  string                 `json:"active_key_id"`
+	Keys         []*EncryptionKey       `json:"keys"`
+	Fields       []*EncryptedFieldStats `json:"fields"`
+	Reencrypting bool                   `json:"reencrypting"`
+}
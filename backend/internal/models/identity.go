@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a local user to a subject at an external OIDC provider.
+type UserIdentity struct {
+	ID            uuid.UUID  `db:"id" json:"id"`
+	UserID        uuid.UUID  `db:"user_id" json:"user_id"`
+	Provider      string     `db:"provider" json:"provider"`
+	Subject       string     `db:"subject" json:"subject"`
+	Email         *string    `db:"email" json:"email"`
+	EmailVerified bool       `db:"email_verified" json:"email_verified"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	LastLoginAt   *time.Time `db:"last_login_at" json:"last_login_at"`
+}
+
+// OIDCAuthState is an in-flight authorization request. StateHash is the
+// SHA-256 of the state parameter sent to the provider.
+type OIDCAuthState struct {
+	StateHash    string     `db:"state_hash" json:"-"`
+	Provider     string     `db:"provider" json:"provider"`
+	CodeVerifier string     `db:"code_verifier" json:"-"`
+	Nonce        string     `db:"nonce" json:"-"`
+	LinkUserID   *uuid.UUID `db:"link_user_id" json:"link_user_id"`
+	ExpiresAt    time.Time  `db:"expires_at" json:"expires_at"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// IdentityLinkRequest holds a provider identity whose verified email matches
+// an existing account until the account owner confirms the link.
+type IdentityLinkRequest struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	Provider  string     `db:"provider" json:"provider"`
+	Subject   string     `db:"subject" json:"subject"`
+	Email     string     `db:"email" json:"email"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
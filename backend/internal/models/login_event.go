@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Login event types.
+const (
+	LoginEventSuccess            = "login_success"
+	LoginEventFailure            = "login_failure"
+	LoginEventTwoFactorChallenge = "two_factor_challenge"
+	LoginEventTokenRefresh       = "token_refresh"
+)
+
+// LoginEvent records a sign-in attempt or token refresh with the client it
+// came from. UserID is nil for failed attempts against an unknown email.
+type LoginEvent struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	UserID          *uuid.UUID `db:"user_id" json:"user_id,omitempty"`
+	Email           *string    `db:"email" json:"-"`
+	EventType       string     `db:"event_type" json:"event_type"`
+	Method          *string    `db:"method" json:"method"`
+	FailureReason   *string    `db:"failure_reason" json:"failure_reason,omitempty"`
+	SessionID       *uuid.UUID `db:"session_id" json:"session_id,omitempty"`
+	IPAddress       *string    `db:"ip_address" json:"ip_address"`
+	UserAgent       *string    `db:"user_agent" json:"user_agent"`
+	DeviceID        *string    `db:"device_id" json:"device_id"`
+	DeviceType      *string    `db:"device_type" json:"device_type"`
+	OS              *string    `db:"os" json:"os"`
+	Browser         *string    `db:"browser" json:"browser"`
+	LocationCountry *string    `db:"location_country" json:"location_country"`
+	LocationCity    *string    `db:"location_city" json:"location_city"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MagicLinkToken is a single-use sign-in link sent by email. Only the SHA-256
+// of the token is stored; the token itself exists only in the email.
+type MagicLinkToken struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	IPAddress *string    `db:"ip_address" json:"ip_address"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
@@ -6,6 +6,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// NotificationTypePromotion marks marketing notifications, which are only
+// created for users who opted in to marketing.
+const NotificationTypePromotion = "promotion"
+
 type Notification struct {
 	ID        uuid.UUID  `db:"id" json:"id"`
 	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClient is a product registered to sign users in through base-app.
+// Public clients have no secret and must use PKCE.
+type OAuthClient struct {
+	ID                     uuid.UUID  `db:"id" json:"id"`
+	ClientID               string     `db:"client_id" json:"client_id"`
+	ClientSecretHash       *string    `db:"client_secret_hash" json:"-"`
+	Name                   string     `db:"name" json:"name"`
+	ProductName            *string    `db:"product_name" json:"product_name"`
+	RedirectURIs           []string   `db:"redirect_uris" json:"redirect_uris"`
+	PostLogoutRedirectURIs []string   `db:"post_logout_redirect_uris" json:"post_logout_redirect_uris"`
+	BackchannelLogoutURI   *string    `db:"backchannel_logout_uri" json:"backchannel_logout_uri"`
+	AllowedScopes          []string   `db:"allowed_scopes" json:"allowed_scopes"`
+	SkipConsent            bool       `db:"skip_consent" json:"skip_consent"`
+	IsActive               bool       `db:"is_active" json:"is_active"`
+	CreatedBy              *uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt              time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt              time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// IsConfidential reports whether the client authenticates with a secret.
+func (c *OAuthClient) IsConfidential() bool {
+	return c.ClientSecretHash != nil
+}
+
+// OAuthAuthorizationCode is a single-use code issued after consent.
+type OAuthAuthorizationCode struct {
+	CodeHash            string    `db:"code_hash"`
+	ClientID            string    `db:"client_id"`
+	UserID              uuid.UUID `db:"user_id"`
+	SessionID           uuid.UUID `db:"session_id"`
+	RedirectURI         string    `db:"redirect_uri"`
+	Scopes              []string  `db:"scopes"`
+	Nonce               *string   `db:"nonce"`
+	CodeChallenge       *string   `db:"code_challenge"`
+	CodeChallengeMethod *string   `db:"code_challenge_method"`
+	ExpiresAt           time.Time `db:"expires_at"`
+	CreatedAt           time.Time `db:"created_at"`
+}
+
+// OAuthConsent records the scopes a user has granted a client.
+type OAuthConsent struct {
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	ClientID  string    `db:"client_id" json:"client_id"`
+	Scopes    []string  `db:"scopes" json:"scopes"`
+	GrantedAt time.Time `db:"granted_at" json:"granted_at"`
+}
+
+// OAuthRefreshToken is bound to the base-app session it was issued under
+// and stops working when that session ends.
+type OAuthRefreshToken struct {
+	ID        uuid.UUID  `db:"id"`
+	TokenHash string     `db:"token_hash"`
+	ClientID  string     `db:"client_id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	SessionID uuid.UUID  `db:"session_id"`
+	Scopes    []string   `db:"scopes"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// OAuthSigningKey is the RSA key used to sign ID and access tokens.
+type OAuthSigningKey struct {
+	KID        string    `db:"kid"`
+	PrivateKey string    `db:"private_key"`
+	CreatedAt  time.Time `db:"created_at"`
+}
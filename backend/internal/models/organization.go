@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles a user can hold in an organization. Owners can do everything,
+// including deleting the organization and managing other owners; admins
+// manage members, invitations and the organization's shared setup (CRUD
+// entities, templates, webhooks); members work with its records.
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+// IsOrganizationRole reports whether role is one of the organization roles.
+func IsOrganizationRole(role string) bool {
+	return role == OrganizationRoleOwner || role == OrganizationRoleAdmin || role == OrganizationRoleMember
+}
+
+type Organization struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	CreatedBy *uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+
+	// Role is the requesting user's role, when listed for them
+	Role string `db:"-" json:"role,omitempty"`
+}
+
+type OrganizationMember struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	Role           string    `db:"role" json:"role"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+
+	// Filled in when listing members
+	Email string `db:"-" json:"email,omitempty"`
+	Name  string `db:"-" json:"name,omitempty"`
+}
+
+// OrganizationInvitation is an emailed offer to join an organization. Only a
+// hash of its token is stored.
+type OrganizationInvitation struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	Email          string     `db:"email" json:"email"`
+	Role           string     `db:"role" json:"role"`
+	TokenHash      string     `db:"token_hash" json:"-"`
+	InvitedBy      *uuid.UUID `db:"invited_by" json:"invited_by"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	AcceptedAt     *time.Time `db:"accepted_at" json:"accepted_at"`
+	AcceptedBy     *uuid.UUID `db:"accepted_by" json:"accepted_by"`
+	RevokedAt      *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Tenant is whose records a request works with: an organization's when
+// OrganizationID is set, otherwise the user's own. New records are created
+// for the tenant. Unrestricted lets lookups reach every tenant's records; it
+// is only set for platform admins acting outside an organization.
+type Tenant struct {
+	UserID           uuid.UUID
+	OrganizationID   *uuid.UUID
+	OrganizationRole string
+	Unrestricted     bool
+}
+
+// CanManage reports whether the user may change the tenant's shared setup:
+// always for their own records, and as an owner or admin of an organization.
+func (t *Tenant) CanManage() bool {
+	if t.Unrestricted || t.OrganizationID == nil {
+		return true
+	}
+	return t.OrganizationRole == OrganizationRoleOwner || t.OrganizationRole == OrganizationRoleAdmin
+}
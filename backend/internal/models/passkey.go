@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasskeyCredential is a WebAuthn public key credential registered by a user.
+// CredentialID is the base64url encoded credential ID the authenticator
+// reports; the private key never leaves the authenticator.
+type PasskeyCredential struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	UserID          uuid.UUID  `db:"user_id" json:"user_id"`
+	DeviceID        *uuid.UUID `db:"device_id" json:"device_id"`
+	CredentialID    string     `db:"credential_id" json:"-"`
+	PublicKey       []byte     `db:"public_key" json:"-"`
+	AttestationType string     `db:"attestation_type" json:"-"`
+	AAGUID          *string    `db:"aaguid" json:"aaguid"`
+	SignCount       uint32     `db:"sign_count" json:"-"`
+	Transports      []string   `db:"transports" json:"transports"`
+	BackupEligible  bool       `db:"backup_eligible" json:"backup_eligible"`
+	BackupState     bool       `db:"backup_state" json:"backup_state"`
+	Name            string     `db:"name" json:"name"`
+	CloneDetectedAt *time.Time `db:"clone_detected_at" json:"clone_detected_at"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt      *time.Time `db:"last_used_at" json:"last_used_at"`
+}
+
+// WebAuthnCeremony is an in-flight registration or login. TokenHash is the
+// SHA-256 of the ceremony token handed to the client; SessionData is the
+// library's challenge state as JSON.
+type WebAuthnCeremony struct {
+	TokenHash   string     `db:"token_hash" json:"-"`
+	Ceremony    string     `db:"ceremony" json:"ceremony"`
+	UserID      *uuid.UUID `db:"user_id" json:"user_id"`
+	SessionData string     `db:"session_data" json:"-"`
+	ExpiresAt   time.Time  `db:"expires_at" json:"expires_at"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
+
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permission names checked by RequirePermission.
+const (
+	PermUsersRead          = "users.read"
+	PermUsersWrite         = "users.write"
+	PermUsersImpersonate   = "users.impersonate"
+	PermAdminsManage       = "admins.manage"
+	PermRolesManage        = "roles.manage"
+	PermCRUDsManage        = "cruds.manage"
+	PermTemplatesManage    = "templates.manage"
+	PermLogsRead           = "logs.read"
+	PermRequestsManage     = "requests.manage"
+	PermSettingsRead       = "settings.read"
+	PermSettingsWrite      = "settings.write"
+	PermOAuthClientsManage = "oauth_clients.manage"
+)
+
+// PermissionWildcard in a role's permission list grants every permission.
+const PermissionWildcard = "*"
+
+// AllPermissions lists every known permission.
+var AllPermissions = []string{
+	PermUsersRead,
+	PermUsersWrite,
+	PermUsersImpersonate,
+	PermAdminsManage,
+	PermRolesManage,
+	PermCRUDsManage,
+	PermTemplatesManage,
+	PermLogsRead,
+	PermRequestsManage,
+	PermSettingsRead,
+	PermSettingsWrite,
+	PermOAuthClientsManage,
+}
+
+// IsKnownPermission reports whether name is one of AllPermissions.
+func IsKnownPermission(name string) bool {
+	for _, p := range AllPermissions {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is a named bundle of permissions. System roles (admin, user) cannot be
+// deleted, and the admin role cannot be edited.
+type Role struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description *string   `db:"description" json:"description"`
+	Permissions []string  `db:"permissions" json:"permissions"`
+	IsSystem    bool      `db:"is_system" json:"is_system"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
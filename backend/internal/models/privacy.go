@@ -0,0 +1,40 @@
+package models
+
+import "github.com/google/uuid"
+
+// Who may see a profile, email address or phone number.
+const (
+	VisibilityPublic  = "public"
+	VisibilityFriends = "friends"
+	VisibilityPrivate = "private"
+)
+
+// Who may start a conversation with a user.
+const (
+	MessagingEveryone = "everyone"
+	MessagingFriends  = "friends"
+	MessagingNone     = "none"
+)
+
+func ValidVisibility(visibility string) bool {
+	return visibility == VisibilityPublic || visibility == VisibilityFriends || visibility == VisibilityPrivate
+}
+
+// PublicProfile is what one user may see of another. Fields the owner's
+// privacy settings hide from the viewer are left out; Restricted means the
+// profile itself is hidden and only the name is shown.
+type PublicProfile struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Username    *string   `json:"username,omitempty"`
+	DisplayName *string   `json:"display_name,omitempty"`
+	Bio         *string   `json:"bio,omitempty"`
+	FirstName   *string   `json:"first_name,omitempty"`
+	LastName    *string   `json:"last_name,omitempty"`
+	PhotoURL    *string   `json:"photo_url,omitempty"`
+	Email       *string   `json:"email,omitempty"`
+	Phone       *string   `json:"phone,omitempty"`
+	Restricted  bool      `json:"restricted"`
+	IsFriend    bool      `json:"is_friend"`
+	CanMessage  bool      `json:"can_message"`
+}
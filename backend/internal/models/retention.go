@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Data classes retention rules apply to.
+const (
+	RetentionActivityLogs        = "activity_logs"
+	RetentionNotifications       = "notifications"
+	RetentionSearchHistory       = "search_history"
+	RetentionWebhookEvents       = "webhook_events"
+	RetentionSessions            = "sessions"
+	RetentionPasswordResetTokens = "password_reset_tokens"
+)
+
+// RetentionDataClasses lists every data class in the order they are purged.
+var RetentionDataClasses = []string{
+	RetentionSessions,
+	RetentionPasswordResetTokens,
+	RetentionWebhookEvents,
+	RetentionSearchHistory,
+	RetentionNotifications,
+	RetentionActivityLogs,
+}
+
+// RetentionRule says how long rows of a data class are kept. Rows older than
+// MaxAgeDays, and rows beyond the newest MaxRows, are purged; a nil limit does
+// not apply. With Archive, rows are written to an archive file first.
+type RetentionRule struct {
+	DataClass  string     `db:"data_class" json:"data_class"`
+	Enabled    bool       `db:"enabled" json:"enabled"`
+	MaxAgeDays *int       `db:"max_age_days" json:"max_age_days"`
+	MaxRows    *int       `db:"max_rows" json:"max_rows"`
+	Archive    bool       `db:"archive" json:"archive"`
+	UpdatedBy  *uuid.UUID `db:"updated_by" json:"updated_by,omitempty"`
+	UpdatedAt  *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// RetentionRun records one purge of a data class.
+type RetentionRun struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	DataClass   string     `db:"data_class" json:"data_class"`
+	RowsPurged  int64      `db:"rows_purged" json:"rows_purged"`
+	Batches     int        `db:"batches" json:"batches"`
+	ArchivePath *string    `db:"archive_path" json:"archive_path,omitempty"`
+	Error       *string    `db:"error" json:"error,omitempty"`
+	StartedAt   time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt  *time.Time `db:"finished_at" json:"finished_at"`
+}
+
+// RetentionReport is what a rule would purge if it ran now.
+type RetentionReport struct {
+	DataClass   string `json:"data_class"`
+	Enabled     bool   `json:"enabled"`
+	RowsTotal   int64  `json:"rows_total"`
+	RowsMatched int64  `json:"rows_matched"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignupSourceSCIM marks accounts provisioned by an identity provider.
+const SignupSourceSCIM = "scim"
+
+// SCIMToken is a bearer token an admin issues to an identity provider for
+// provisioning users and groups. Only the SHA-256 hash of the token is
+// stored; the plaintext is shown once.
+type SCIMToken struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	TokenPrefix string     `db:"token_prefix" json:"token_prefix"`
+	TokenHash   string     `db:"token_hash" json:"-"`
+	CreatedBy   *uuid.UUID `db:"created_by" json:"created_by"`
+	LastUsedAt  *time.Time `db:"last_used_at" json:"last_used_at"`
+	RevokedAt   *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
@@ -49,8 +49,8 @@ type ComprehensiveSettings struct {
 	ReducedMotion bool  `db:"reduced_motion" json:"reduced_motion"`
 	ScreenReader  bool  `db:"screen_reader" json:"screen_reader"`
 	
-	// Connected Accounts (JSON string)
-	ConnectedAccounts *string `db:"connected_accounts" json:"connected_accounts"` // JSON array
+	// Connected Accounts (legacy, see user_identities)
+	ConnectedAccounts *string `db:"connected_accounts" json:"-"` // Legacy; superseded by user_identities
 	
 	// Data & Account Control
 	AccountDeletionRequested bool       `db:"account_deletion_requested" json:"account_deletion_requested"`
@@ -60,11 +60,3 @@ type ComprehensiveSettings struct {
 	
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
-
-// ConnectedAccount represents a connected third-party account
-type ConnectedAccount struct {
-	Provider    string    `json:"provider"` // google, facebook, apple, etc.
-	Email       string    `json:"email"`
-	ConnectedAt time.Time `json:"connected_at"`
-}
-
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMS code purposes
+const (
+	SMSPurposePhoneVerification = "phone_verification"
+	SMSPurposeLogin             = "login"
+)
+
+// SMSCode is a one-time code sent by text message. Login codes also carry
+// the hash of a challenge token, which the client holds between the two
+// sign-in steps.
+type SMSCode struct {
+	ID            uuid.UUID  `db:"id" json:"id"`
+	UserID        uuid.UUID  `db:"user_id" json:"user_id"`
+	Purpose       string     `db:"purpose" json:"purpose"`
+	Phone         string     `db:"phone" json:"-"`
+	CodeHash      string     `db:"code_hash" json:"-"`
+	ChallengeHash *string    `db:"challenge_hash" json:"-"`
+	LoginMethod   *string    `db:"login_method" json:"-"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	IPAddress     *string    `db:"ip_address" json:"-"`
+	ExpiresAt     time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt        *time.Time `db:"used_at" json:"used_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SystemSetting is an instance-wide setting managed by admins. Values are
+// stored as text; SystemSettingsService knows each key's type and default.
+type SystemSetting struct {
+	Key       string     `db:"key" json:"key"`
+	Value     string     `db:"value" json:"value"`
+	UpdatedBy *uuid.UUID `db:"updated_by" json:"updated_by"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// System setting keys
+const (
+	SettingMagicLinkEnabled = "magic_link_enabled"
+
+	SettingPasswordMinLength        = "password_min_length"
+	SettingPasswordRequireUppercase = "password_require_uppercase"
+	SettingPasswordRequireLowercase = "password_require_lowercase"
+	SettingPasswordRequireNumber    = "password_require_number"
+	SettingPasswordRequireSpecial   = "password_require_special"
+	SettingPasswordMaxAgeDays       = "password_max_age_days"
+	SettingPasswordHistoryCount     = "password_history_count"
+	SettingPasswordCheckBreached    = "password_check_breached"
+)
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Second factor methods
+const (
+	TwoFactorMethodSMS = "sms"
+)
+
+// UserTwoFactor records the second factor a user has turned on.
+type UserTwoFactor struct {
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	Method    string    `db:"method" json:"method"`
+	EnabledAt time.Time `db:"enabled_at" json:"enabled_at"`
+}
@@ -13,6 +13,7 @@ type User struct {
 	EmailVerificationToken *string    `db:"email_verification_token" json:"-"`
 	PasswordHash           string     `db:"password_hash" json:"-"`
 	PasswordChangedAt      time.Time  `db:"password_changed_at" json:"password_changed_at"`
+	MustChangePassword     bool       `db:"must_change_password" json:"must_change_password"`
 	Name                   string     `db:"name" json:"name"`
 	FirstName              *string    `db:"first_name" json:"first_name"`
 	LastName               *string    `db:"last_name" json:"last_name"`
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation states, derived from the timestamps.
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// SignupSourceInvitation marks accounts created by accepting an invitation.
+const SignupSourceInvitation = "invitation"
+
+// UserInvitation is an admin's emailed offer of an account with Role. The
+// invitee picks their own password when accepting. Only a hash of the token
+// is stored.
+type UserInvitation struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	Email      string     `db:"email" json:"email"`
+	Name       *string    `db:"name" json:"name"`
+	Role       string     `db:"role" json:"role"`
+	TokenHash  string     `db:"token_hash" json:"-"`
+	InvitedBy  *uuid.UUID `db:"invited_by" json:"invited_by"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	SentAt     time.Time  `db:"sent_at" json:"sent_at"`
+	SendCount  int        `db:"send_count" json:"send_count"`
+	AcceptedAt *time.Time `db:"accepted_at" json:"accepted_at"`
+	AcceptedBy *uuid.UUID `db:"accepted_by" json:"accepted_by"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Status returns where the invitation stands at now.
+func (i *UserInvitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case now.After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
@@ -12,6 +12,7 @@ type WebhookEvent struct {
 	EventVersion       string     `db:"event_version" json:"event_version"`
 	EventSource        string     `db:"event_source" json:"event_source"`
 	UserID             uuid.UUID  `db:"user_id" json:"user_id"`
+	OrganizationID     *uuid.UUID `db:"organization_id" json:"organization_id"`
 	Payload            []byte     `db:"payload" json:"payload"`
 	PayloadHash        string     `db:"payload_hash" json:"payload_hash"`
 	WebhookURL         string     `db:"webhook_url" json:"webhook_url"`
@@ -33,6 +34,7 @@ type WebhookEvent struct {
 type WebhookSubscription struct {
 	ID                     uuid.UUID  `db:"id" json:"id"`
 	UserID                 *uuid.UUID `db:"user_id" json:"user_id"`
+	OrganizationID         *uuid.UUID `db:"organization_id" json:"organization_id"`
 	SubscriptionName       string     `db:"subscription_name" json:"subscription_name"`
 	WebhookURL             string     `db:"webhook_url" json:"webhook_url"`
 	WebhookSecret          string     `db:"webhook_secret" json:"-"`
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,15 +15,29 @@ type Metrics struct {
 	requestsDuration map[string]time.Duration
 	errorsTotal      int64
 	logger           *zap.Logger
+
+	// Rows deleted by retention rules, by data class
+	purgeMu     sync.Mutex
+	rowsPurged  map[string]int64
+	lastPurgeAt time.Time
 }
 
 func NewMetrics(logger *zap.Logger) *Metrics {
 	return &Metrics{
 		requestsDuration: make(map[string]time.Duration),
+		rowsPurged:       make(map[string]int64),
 		logger:           logger,
 	}
 }
 
+// RecordPurge counts rows a retention rule deleted.
+func (m *Metrics) RecordPurge(dataClass string, rows int64) {
+	m.purgeMu.Lock()
+	defer m.purgeMu.Unlock()
+	m.rowsPurged[dataClass] += rows
+	m.lastPurgeAt = time.Now()
+}
+
 func (m *Metrics) RecordRequest(method, path string, duration time.Duration, statusCode int) {
 	m.requestsTotal++
 	
@@ -35,10 +50,23 @@ func (m *Metrics) RecordRequest(method, path string, duration time.Duration, sta
 }
 
 func (m *Metrics) GetMetrics() map[string]interface{} {
+	m.purgeMu.Lock()
+	rowsPurged := make(map[string]int64, len(m.rowsPurged))
+	for dataClass, rows := range m.rowsPurged {
+		rowsPurged[dataClass] = rows
+	}
+	var lastPurgeAt interface{}
+	if !m.lastPurgeAt.IsZero() {
+		lastPurgeAt = m.lastPurgeAt.UTC().Format(time.RFC3339)
+	}
+	m.purgeMu.Unlock()
+
 	return map[string]interface{}{
-		"requests_total": m.requestsTotal,
-		"errors_total":   m.errorsTotal,
-		"uptime_seconds": time.Since(startTime).Seconds(),
+		"requests_total":              m.requestsTotal,
+		"errors_total":                m.errorsTotal,
+		"uptime_seconds":              time.Since(startTime).Seconds(),
+		"retention_rows_purged_total": rowsPurged,
+		"retention_last_purge_at":     lastPurgeAt,
 	}
 }
 
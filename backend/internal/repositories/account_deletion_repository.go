@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type AccountDeletionRepository interface {
+	Create(ctx context.Context, deletion *models.AccountDeletion) error
+	// GetByUserID returns nil, nil when no deletion is scheduled.
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.AccountDeletion, error)
+	// Delete cancels the deletion. It reports false when none was scheduled.
+	Delete(ctx context.Context, userID uuid.UUID) (bool, error)
+	// ListDue returns deletions whose purge time is not after the time.
+	ListDue(ctx context.Context, at time.Time) ([]*models.AccountDeletion, error)
+	// Purge removes the user and every record about them in one
+	// transaction. crudPolicy is models.CRUDPolicyAnonymize or
+	// models.CRUDPolicyDelete.
+	Purge(ctx context.Context, userID uuid.UUID, crudPolicy string) (*models.AccountPurgeCounts, error)
+}
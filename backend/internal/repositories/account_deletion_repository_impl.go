@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type accountDeletionRepository struct {
+	db *database.DB
+}
+
+func NewAccountDeletionRepository(db *database.DB) AccountDeletionRepository {
+	return &accountDeletionRepository{db: db}
+}
+
+const accountDeletionColumns = `user_id, requested_by, actor_id, previous_status, requested_at, purge_at`
+
+func (r *accountDeletionRepository) Create(ctx context.Context, deletion *models.AccountDeletion) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO account_deletions (`+accountDeletionColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		deletion.UserID.String(), deletion.RequestedBy, nullableUUID(deletion.ActorID), deletion.PreviousStatus,
+		deletion.RequestedAt, deletion.PurgeAt,
+	)
+	return err
+}
+
+func (r *accountDeletionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.AccountDeletion, error) {
+	deletion, err := scanAccountDeletion(r.db.QueryRowContext(ctx,
+		`SELECT `+accountDeletionColumns+` FROM account_deletions WHERE user_id = ?`, userID.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return deletion, err
+}
+
+func (r *accountDeletionRepository) Delete(ctx context.Context, userID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM account_deletions WHERE user_id = ?`, userID.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *accountDeletionRepository) ListDue(ctx context.Context, at time.Time) ([]*models.AccountDeletion, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+accountDeletionColumns+` FROM account_deletions WHERE purge_at <= ? ORDER BY purge_at`, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []*models.AccountDeletion
+	for rows.Next() {
+		deletion, err := scanAccountDeletion(rows)
+		if err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, rows.Err()
+}
+
+func scanAccountDeletion(scanner interface{ Scan(...interface{}) error }) (*models.AccountDeletion, error) {
+	deletion := &models.AccountDeletion{}
+	var actorID sql.NullString
+	err := scanner.Scan(
+		&deletion.UserID, &deletion.RequestedBy, &actorID, &deletion.PreviousStatus,
+		&deletion.RequestedAt, &deletion.PurgeAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if actorID.Valid {
+		id, _ := uuid.Parse(actorID.String)
+		deletion.ActorID = &id
+	}
+	return deletion, nil
+}
+
+// purgeStep deletes or anonymizes one table's rows about a user. The query
+// takes the user's ID once for each placeholder.
+type purgeStep struct {
+	table     string
+	query     string
+	anonymize bool
+}
+
+// crudPurgeSteps handle CRUD entities and records under each policy. Private
+// entities go in both cases since nobody else can see them; organization
+// entities stay with the organization.
+var crudPurgeSteps = map[string][]purgeStep{
+	models.CRUDPolicyAnonymize: {
+		{table: "custom_crud_data", query: `DELETE FROM custom_crud_data WHERE entity_id IN
+			(SELECT id FROM custom_crud_entities WHERE created_by = ? AND organization_id IS NULL)`},
+		{table: "custom_crud_entities", query: `DELETE FROM custom_crud_entities WHERE created_by = ? AND organization_id IS NULL`},
+		{table: "custom_crud_data", query: `UPDATE custom_crud_data SET created_by = NULL WHERE created_by = ?`, anonymize: true},
+	},
+	models.CRUDPolicyDelete: {
+		{table: "custom_crud_data", query: `DELETE FROM custom_crud_data WHERE created_by = ? OR entity_id IN
+			(SELECT id FROM custom_crud_entities WHERE created_by = ? AND organization_id IS NULL)`},
+		{table: "custom_crud_entities", query: `DELETE FROM custom_crud_entities WHERE created_by = ? AND organization_id IS NULL`},
+	},
+}
+
+// accountPurgeSteps remove everything else stored about a user, children
+// before parents. They do not rely on foreign key cascades, which are off
+// unless the connection enables them, and references kept for other users'
+// sake are cleared instead. Audit logs are left to their own retention.
+var accountPurgeSteps = []purgeStep{
+	{table: "custom_crud_data", query: `UPDATE custom_crud_data SET updated_by = NULL WHERE updated_by = ?`, anonymize: true},
+	{table: "custom_crud_entities", query: `UPDATE custom_crud_entities SET created_by = NULL WHERE created_by = ?`, anonymize: true},
+	{table: "oauth_authorization_codes", query: `DELETE FROM oauth_authorization_codes WHERE user_id = ?`},
+	{table: "oauth_refresh_tokens", query: `DELETE FROM oauth_refresh_tokens WHERE user_id = ?`},
+	{table: "oauth_consents", query: `DELETE FROM oauth_consents WHERE user_id = ?`},
+	{table: "passkey_credentials", query: `DELETE FROM passkey_credentials WHERE user_id = ?`},
+	{table: "sessions", query: `DELETE FROM sessions WHERE user_id = ?`},
+	{table: "user_devices", query: `DELETE FROM user_devices WHERE user_id = ?`},
+	{table: "login_events", query: `DELETE FROM login_events WHERE user_id = ?`},
+	{table: "identity_link_requests", query: `DELETE FROM identity_link_requests WHERE user_id = ?`},
+	{table: "user_identities", query: `DELETE FROM user_identities WHERE user_id = ?`},
+	{table: "api_keys", query: `DELETE FROM api_keys WHERE user_id = ?`},
+	{table: "magic_link_tokens", query: `DELETE FROM magic_link_tokens WHERE user_id = ?`},
+	{table: "password_reset_tokens", query: `DELETE FROM password_reset_tokens WHERE user_id = ?`},
+	{table: "password_history", query: `DELETE FROM password_history WHERE user_id = ?`},
+	{table: "email_change_requests", query: `DELETE FROM email_change_requests WHERE user_id = ?`},
+	{table: "sms_codes", query: `DELETE FROM sms_codes WHERE user_id = ?`},
+	{table: "user_two_factor", query: `DELETE FROM user_two_factor WHERE user_id = ?`},
+	{table: "notifications", query: `DELETE FROM notifications WHERE user_id = ?`},
+	{table: "conversations", query: `DELETE FROM conversations WHERE participant1_id = ? OR participant2_id = ?`},
+	{table: "messages", query: `DELETE FROM messages WHERE sender_id = ? OR recipient_id = ?`},
+	{table: "search_history", query: `DELETE FROM search_history WHERE user_id = ?`},
+	// Organization items pass to another owner; where none is left, the
+	// organization is gone and the items go with the user's own
+	{table: "dashboard_items", query: `UPDATE dashboard_items SET user_id = (
+		SELECT m.user_id FROM organization_members m
+		WHERE m.organization_id = dashboard_items.organization_id AND m.role = 'owner' AND m.user_id <> ?
+		ORDER BY m.created_at LIMIT 1)
+		WHERE user_id = ? AND organization_id IN (
+			SELECT organization_id FROM organization_members WHERE role = 'owner' AND user_id <> ?)`, anonymize: true},
+	{table: "dashboard_items", query: `DELETE FROM dashboard_items WHERE user_id = ?`},
+	{table: "access_requests", query: `DELETE FROM access_requests WHERE user_id = ?`},
+	{table: "account_switches", query: `DELETE FROM account_switches WHERE user_id = ? OR switched_to_user_id = ?`},
+	{table: "user_connections", query: `DELETE FROM user_connections WHERE requester_id = ? OR addressee_id = ?`},
+	{table: "user_blocks", query: `DELETE FROM user_blocks WHERE blocker_id = ? OR blocked_id = ?`},
+	{table: "organization_members", query: `DELETE FROM organization_members WHERE user_id = ?`},
+	{table: "organizations", query: `UPDATE organizations SET created_by = NULL WHERE created_by = ?`, anonymize: true},
+	{table: "organization_invitations", query: `UPDATE organization_invitations SET invited_by = NULL WHERE invited_by = ?`, anonymize: true},
+	{table: "organization_invitations", query: `UPDATE organization_invitations SET accepted_by = NULL WHERE accepted_by = ?`, anonymize: true},
+	{table: "user_invitations", query: `UPDATE user_invitations SET invited_by = NULL WHERE invited_by = ?`, anonymize: true},
+	{table: "user_invitations", query: `UPDATE user_invitations SET accepted_by = NULL WHERE accepted_by = ?`, anonymize: true},
+	{table: "scim_tokens", query: `UPDATE scim_tokens SET created_by = NULL WHERE created_by = ?`, anonymize: true},
+	{table: "retention_rules", query: `UPDATE retention_rules SET updated_by = NULL WHERE updated_by = ?`, anonymize: true},
+	{table: "legal_documents", query: `UPDATE legal_documents SET published_by = NULL WHERE published_by = ?`, anonymize: true},
+	{table: "consent_records", query: `DELETE FROM consent_records WHERE user_id = ?`},
+	{table: "uploaded_files", query: `DELETE FROM uploaded_files WHERE user_id = ?`},
+	{table: "data_exports", query: `DELETE FROM data_exports WHERE user_id = ?`},
+	{table: "webhook_events", query: `DELETE FROM webhook_events WHERE user_id = ?`},
+	{table: "webhook_subscriptions", query: `UPDATE webhook_subscriptions SET user_id = NULL
+		WHERE user_id = ? AND organization_id IN (SELECT id FROM organizations)`, anonymize: true},
+	{table: "webhook_subscriptions", query: `DELETE FROM webhook_subscriptions WHERE user_id = ?`},
+	{table: "user_settings_comprehensive", query: `DELETE FROM user_settings_comprehensive WHERE user_id = ?`},
+	{table: "user_settings", query: `DELETE FROM user_settings WHERE user_id = ?`},
+	{table: "product_theme_preferences", query: `DELETE FROM product_theme_preferences WHERE user_id = ?`},
+	{table: "admin_settings", query: `DELETE FROM admin_settings WHERE admin_id = ?`},
+	{table: "admin_permissions", query: `UPDATE admin_permissions SET granted_by = NULL WHERE granted_by = ?`, anonymize: true},
+	{table: "admin_permissions", query: `DELETE FROM admin_permissions WHERE admin_id = ?`},
+	{table: "user_management_actions", query: `DELETE FROM user_management_actions WHERE admin_id = ? OR user_id = ?`},
+	{table: "admin_activity_logs", query: `DELETE FROM admin_activity_logs WHERE admin_id = ?`},
+	{table: "account_deletions", query: `DELETE FROM account_deletions WHERE user_id = ?`},
+	{table: "users", query: `DELETE FROM users WHERE id = ?`},
+}
+
+func (r *accountDeletionRepository) Purge(ctx context.Context, userID uuid.UUID, crudPolicy string) (*models.AccountPurgeCounts, error) {
+	crudSteps, ok := crudPurgeSteps[crudPolicy]
+	if !ok {
+		return nil, fmt.Errorf("unknown CRUD policy %q", crudPolicy)
+	}
+
+	counts := &models.AccountPurgeCounts{Deleted: map[string]int64{}, Anonymized: map[string]int64{}}
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, step := range append(crudSteps, accountPurgeSteps...) {
+			args := make([]interface{}, strings.Count(step.query, "?"))
+			for i := range args {
+				args[i] = userID.String()
+			}
+			result, err := tx.ExecContext(ctx, step.query, args...)
+			if err != nil {
+				return fmt.Errorf("purge %s: %w", step.table, err)
+			}
+			n, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				continue
+			}
+			if step.anonymize {
+				counts.Anonymized[step.table] += n
+			} else {
+				counts.Deleted[step.table] += n
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -10,6 +11,8 @@ import (
 
 type AccountSwitchRepository interface {
 	Create(ctx context.Context, switchRecord *models.AccountSwitch) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AccountSwitch, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*models.AccountSwitch, error)
+	End(ctx context.Context, id uuid.UUID, endedAt time.Time) error
 }
 
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -20,8 +21,8 @@ func NewAccountSwitchRepository(db *database.DB) AccountSwitchRepository {
 
 func (r *accountSwitchRepository) Create(ctx context.Context, switchRecord *models.AccountSwitch) error {
 	query := `
-		INSERT INTO account_switches (id, user_id, switched_to_user_id, switched_to_role, switched_from_role, reason, ip_address, user_agent, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO account_switches (id, user_id, switched_to_user_id, switched_to_role, switched_from_role, reason, ip_address, user_agent, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	var switchedToUserID *string
 	if switchRecord.SwitchedToUserID != nil {
@@ -38,13 +39,68 @@ func (r *accountSwitchRepository) Create(ctx context.Context, switchRecord *mode
 		switchRecord.Reason,
 		switchRecord.IPAddress,
 		switchRecord.UserAgent,
+		switchRecord.ExpiresAt,
 		switchRecord.CreatedAt,
 	)
 	return err
 }
 
+const accountSwitchColumns = `id, user_id, switched_to_user_id, switched_to_role, switched_from_role, reason, ip_address, user_agent, expires_at, ended_at, created_at`
+
+func scanAccountSwitch(scanner interface{ Scan(...interface{}) error }) (*models.AccountSwitch, error) {
+	var s models.AccountSwitch
+	var userIDStr, idStr string
+	var switchedToUserID, switchedToRole, switchedFromRole, reason, ipAddress, userAgent sql.NullString
+	var expiresAt, endedAt sql.NullTime
+
+	err := scanner.Scan(&idStr, &userIDStr, &switchedToUserID, &switchedToRole, &switchedFromRole,
+		&reason, &ipAddress, &userAgent, &expiresAt, &endedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ID, _ = uuid.Parse(idStr)
+	s.UserID, _ = uuid.Parse(userIDStr)
+	if switchedToUserID.Valid {
+		id, _ := uuid.Parse(switchedToUserID.String)
+		s.SwitchedToUserID = &id
+	}
+	if switchedToRole.Valid {
+		s.SwitchedToRole = &switchedToRole.String
+	}
+	if switchedFromRole.Valid {
+		s.SwitchedFromRole = &switchedFromRole.String
+	}
+	if reason.Valid {
+		s.Reason = &reason.String
+	}
+	if ipAddress.Valid {
+		s.IPAddress = &ipAddress.String
+	}
+	if userAgent.Valid {
+		s.UserAgent = &userAgent.String
+	}
+	if expiresAt.Valid {
+		s.ExpiresAt = &expiresAt.Time
+	}
+	if endedAt.Valid {
+		s.EndedAt = &endedAt.Time
+	}
+
+	return &s, nil
+}
+
+func (r *accountSwitchRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AccountSwitch, error) {
+	query := `SELECT ` + accountSwitchColumns + ` FROM account_switches WHERE id = ?`
+	s, err := scanAccountSwitch(r.db.QueryRowContext(ctx, query, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
 func (r *accountSwitchRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*models.AccountSwitch, error) {
-	query := `SELECT id, user_id, switched_to_user_id, switched_to_role, switched_from_role, reason, ip_address, user_agent, created_at
+	query := `SELECT ` + accountSwitchColumns + `
 		FROM account_switches WHERE user_id = ? ORDER BY created_at DESC LIMIT ?`
 	rows, err := r.db.QueryContext(ctx, query, userID.String(), limit)
 	if err != nil {
@@ -54,41 +110,18 @@ func (r *accountSwitchRepository) GetByUserID(ctx context.Context, userID uuid.U
 
 	var switches []*models.AccountSwitch
 	for rows.Next() {
-		var s models.AccountSwitch
-		var userIDStr, idStr string
-		var switchedToUserID, switchedToRole, switchedFromRole, reason, ipAddress, userAgent sql.NullString
-
-		err := rows.Scan(&idStr, &userIDStr, &switchedToUserID, &switchedToRole, &switchedFromRole,
-			&reason, &ipAddress, &userAgent, &s.CreatedAt)
+		s, err := scanAccountSwitch(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		s.ID, _ = uuid.Parse(idStr)
-		s.UserID, _ = uuid.Parse(userIDStr)
-		if switchedToUserID.Valid {
-			id, _ := uuid.Parse(switchedToUserID.String)
-			s.SwitchedToUserID = &id
-		}
-		if switchedToRole.Valid {
-			s.SwitchedToRole = &switchedToRole.String
-		}
-		if switchedFromRole.Valid {
-			s.SwitchedFromRole = &switchedFromRole.String
-		}
-		if reason.Valid {
-			s.Reason = &reason.String
-		}
-		if ipAddress.Valid {
-			s.IPAddress = &ipAddress.String
-		}
-		if userAgent.Valid {
-			s.UserAgent = &userAgent.String
-		}
-
-		switches = append(switches, &s)
+		switches = append(switches, s)
 	}
 
 	return switches, rows.Err()
 }
 
+func (r *accountSwitchRepository) End(ctx context.Context, id uuid.UUID, endedAt time.Time) error {
+	query := `UPDATE account_switches SET ended_at = ? WHERE id = ? AND ended_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, endedAt, id.String())
+	return err
+}
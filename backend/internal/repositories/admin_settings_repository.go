@@ -12,20 +12,22 @@ type AdminSettingsRepository interface {
 	GetByAdminID(ctx context.Context, adminID uuid.UUID) (*models.AdminSettings, error)
 	Create(ctx context.Context, settings *models.AdminSettings) error
 	Update(ctx context.Context, settings *models.AdminSettings) error
-	GetFirstAdminVerificationCode(ctx context.Context) (string, error) // Get verification code from first admin
 }
 
+// CustomCRUDRepository lookups only return entities, and data of entities,
+// belonging to the given tenant.
 type CustomCRUDRepository interface {
 	CreateEntity(ctx context.Context, entity *models.CustomCRUDEntity) error
-	GetEntityByID(ctx context.Context, id uuid.UUID) (*models.CustomCRUDEntity, error)
-	GetEntityByName(ctx context.Context, name string) (*models.CustomCRUDEntity, error)
-	ListEntities(ctx context.Context, createdBy *uuid.UUID, activeOnly bool) ([]*models.CustomCRUDEntity, error)
+	GetEntityByID(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CustomCRUDEntity, error)
+	// GetEntityByName returns nil, nil when the tenant has no such entity.
+	GetEntityByName(ctx context.Context, name string, tenant *models.Tenant) (*models.CustomCRUDEntity, error)
+	ListEntities(ctx context.Context, tenant *models.Tenant, activeOnly bool) ([]*models.CustomCRUDEntity, error)
 	UpdateEntity(ctx context.Context, entity *models.CustomCRUDEntity) error
 	DeleteEntity(ctx context.Context, id uuid.UUID) error
-	
+
 	CreateData(ctx context.Context, data *models.CustomCRUDData) error
-	GetDataByID(ctx context.Context, id uuid.UUID) (*models.CustomCRUDData, error)
-	ListDataByEntity(ctx context.Context, entityID uuid.UUID, limit int, offset int) ([]*models.CustomCRUDData, error)
+	GetDataByID(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CustomCRUDData, error)
+	ListDataByEntity(ctx context.Context, entityID uuid.UUID, tenant *models.Tenant, limit int, offset int) ([]*models.CustomCRUDData, error)
 	UpdateData(ctx context.Context, data *models.CustomCRUDData) error
 	DeleteData(ctx context.Context, id uuid.UUID) error
 }
@@ -3,7 +3,6 @@ package repositories
 import (
 	"context"
 	"database/sql"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,12 +21,12 @@ func NewAdminSettingsRepository(db *database.DB) AdminSettingsRepository {
 
 func (r *adminSettingsRepository) GetByAdminID(ctx context.Context, adminID uuid.UUID) (*models.AdminSettings, error) {
 	var s models.AdminSettings
-	var dashboardLayout, defaultPermissions, notificationPreferences, themePreferences, adminVerificationCode sql.NullString
+	var dashboardLayout, notificationPreferences, themePreferences sql.NullString
 
-	query := `SELECT admin_id, dashboard_layout, default_permissions, notification_preferences, theme_preferences, admin_verification_code, created_at, updated_at
+	query := `SELECT admin_id, dashboard_layout, notification_preferences, theme_preferences, created_at, updated_at
 		FROM admin_settings WHERE admin_id = ?`
 	err := r.db.QueryRowContext(ctx, query, adminID.String()).Scan(
-		&s.AdminID, &dashboardLayout, &defaultPermissions, &notificationPreferences, &themePreferences, &adminVerificationCode,
+		&s.AdminID, &dashboardLayout, &notificationPreferences, &themePreferences,
 		&s.CreatedAt, &s.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -40,36 +39,24 @@ func (r *adminSettingsRepository) GetByAdminID(ctx context.Context, adminID uuid
 	if dashboardLayout.Valid {
 		s.DashboardLayout = &dashboardLayout.String
 	}
-	if defaultPermissions.Valid {
-		s.DefaultPermissions = &defaultPermissions.String
-	}
 	if notificationPreferences.Valid {
 		s.NotificationPreferences = &notificationPreferences.String
 	}
 	if themePreferences.Valid {
 		s.ThemePreferences = &themePreferences.String
 	}
-	if adminVerificationCode.Valid {
-		s.AdminVerificationCode = &adminVerificationCode.String
-	}
 
 	return &s, nil
 }
 
 func (r *adminSettingsRepository) Create(ctx context.Context, settings *models.AdminSettings) error {
-	defaultCode := "Kompasstech2025@"
-	if settings.AdminVerificationCode == nil {
-		settings.AdminVerificationCode = &defaultCode
-	}
-	query := `INSERT INTO admin_settings (admin_id, dashboard_layout, default_permissions, notification_preferences, theme_preferences, admin_verification_code, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO admin_settings (admin_id, dashboard_layout, notification_preferences, theme_preferences, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
 	_, err := r.db.ExecContext(ctx, query,
 		settings.AdminID.String(),
 		settings.DashboardLayout,
-		settings.DefaultPermissions,
 		settings.NotificationPreferences,
 		settings.ThemePreferences,
-		settings.AdminVerificationCode,
 		time.Now(),
 		time.Now(),
 	)
@@ -77,46 +64,18 @@ func (r *adminSettingsRepository) Create(ctx context.Context, settings *models.A
 }
 
 func (r *adminSettingsRepository) Update(ctx context.Context, settings *models.AdminSettings) error {
-	query := `UPDATE admin_settings SET dashboard_layout = ?, default_permissions = ?, notification_preferences = ?, theme_preferences = ?, admin_verification_code = ?, updated_at = ?
+	query := `UPDATE admin_settings SET dashboard_layout = ?, notification_preferences = ?, theme_preferences = ?, updated_at = ?
 		WHERE admin_id = ?`
 	_, err := r.db.ExecContext(ctx, query,
 		settings.DashboardLayout,
-		settings.DefaultPermissions,
 		settings.NotificationPreferences,
 		settings.ThemePreferences,
-		settings.AdminVerificationCode,
 		time.Now(),
 		settings.AdminID.String(),
 	)
 	return err
 }
 
-func (r *adminSettingsRepository) GetFirstAdminVerificationCode(ctx context.Context) (string, error) {
-	var adminVerificationCode sql.NullString
-	
-	// Get the verification code from the first admin's settings
-	query := `SELECT admin_verification_code FROM admin_settings 
-		WHERE admin_verification_code IS NOT NULL AND admin_verification_code != ''
-		ORDER BY created_at ASC LIMIT 1`
-	
-	err := r.db.QueryRowContext(ctx, query).Scan(&adminVerificationCode)
-	if err == sql.ErrNoRows {
-		// No admin settings found, return default
-		return "Kompasstech2025@", nil
-	}
-	if err != nil {
-		return "Kompasstech2025@", err
-	}
-	
-	if adminVerificationCode.Valid && adminVerificationCode.String != "" {
-		// Trim the code before returning
-		return strings.TrimSpace(adminVerificationCode.String), nil
-	}
-	
-	// Fallback to default
-	return "Kompasstech2025@", nil
-}
-
 type customCRUDRepository struct {
 	db *database.DB
 }
@@ -125,12 +84,15 @@ func NewCustomCRUDRepository(db *database.DB) CustomCRUDRepository {
 	return &customCRUDRepository{db: db}
 }
 
+const customCRUDEntityColumns = `id, organization_id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at`
+
 func (r *customCRUDRepository) CreateEntity(ctx context.Context, entity *models.CustomCRUDEntity) error {
-	query := `INSERT INTO custom_crud_entities (id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO custom_crud_entities (id, organization_id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	_, err := r.db.ExecContext(ctx, query,
 		entity.ID.String(),
-		entity.CreatedBy.String(),
+		nullableUUID(entity.OrganizationID),
+		nullableUUID(entity.CreatedBy),
 		entity.EntityName,
 		entity.DisplayName,
 		entity.Description,
@@ -142,78 +104,32 @@ func (r *customCRUDRepository) CreateEntity(ctx context.Context, entity *models.
 	return err
 }
 
-func (r *customCRUDRepository) GetEntityByID(ctx context.Context, id uuid.UUID) (*models.CustomCRUDEntity, error) {
-	var e models.CustomCRUDEntity
-	var createdByStr, idStr string
-	var description sql.NullString
-
-	query := `SELECT id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at
-		FROM custom_crud_entities WHERE id = ?`
-	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
-		&idStr, &createdByStr, &e.EntityName, &e.DisplayName, &description,
-		&e.Schema, &e.IsActive, &e.CreatedAt, &e.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	e.ID, _ = uuid.Parse(idStr)
-	e.CreatedBy, _ = uuid.Parse(createdByStr)
-	if description.Valid {
-		e.Description = &description.String
-	}
-
-	return &e, nil
+func (r *customCRUDRepository) GetEntityByID(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CustomCRUDEntity, error) {
+	condition, args := tenantCondition(tenant, "organization_id", "created_by")
+	query := `SELECT ` + customCRUDEntityColumns + `
+		FROM custom_crud_entities WHERE id = ? AND ` + condition
+	return scanCustomCRUDEntity(r.db.QueryRowContext(ctx, query, append([]interface{}{id.String()}, args...)...))
 }
 
-func (r *customCRUDRepository) GetEntityByName(ctx context.Context, name string) (*models.CustomCRUDEntity, error) {
-	var e models.CustomCRUDEntity
-	var createdByStr, idStr string
-	var description sql.NullString
-
-	query := `SELECT id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at
-		FROM custom_crud_entities WHERE entity_name = ?`
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
-		&idStr, &createdByStr, &e.EntityName, &e.DisplayName, &description,
-		&e.Schema, &e.IsActive, &e.CreatedAt, &e.UpdatedAt,
-	)
+func (r *customCRUDRepository) GetEntityByName(ctx context.Context, name string, tenant *models.Tenant) (*models.CustomCRUDEntity, error) {
+	condition, args := tenantCondition(tenant, "organization_id", "created_by")
+	query := `SELECT ` + customCRUDEntityColumns + `
+		FROM custom_crud_entities WHERE entity_name = ? AND ` + condition
+	entity, err := scanCustomCRUDEntity(r.db.QueryRowContext(ctx, query, append([]interface{}{name}, args...)...))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-
-	e.ID, _ = uuid.Parse(idStr)
-	e.CreatedBy, _ = uuid.Parse(createdByStr)
-	if description.Valid {
-		e.Description = &description.String
-	}
-
-	return &e, nil
+	return entity, err
 }
 
-func (r *customCRUDRepository) ListEntities(ctx context.Context, createdBy *uuid.UUID, activeOnly bool) ([]*models.CustomCRUDEntity, error) {
-	var query string
-	var args []interface{}
-
-	if createdBy != nil && activeOnly {
-		query = `SELECT id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at
-			FROM custom_crud_entities WHERE created_by = ? AND is_active = 1 ORDER BY created_at DESC`
-		args = []interface{}{createdBy.String()}
-	} else if createdBy != nil {
-		query = `SELECT id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at
-			FROM custom_crud_entities WHERE created_by = ? ORDER BY created_at DESC`
-		args = []interface{}{createdBy.String()}
-	} else if activeOnly {
-		query = `SELECT id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at
-			FROM custom_crud_entities WHERE is_active = 1 ORDER BY created_at DESC`
-		args = []interface{}{}
-	} else {
-		query = `SELECT id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at
-			FROM custom_crud_entities ORDER BY created_at DESC`
-		args = []interface{}{}
+func (r *customCRUDRepository) ListEntities(ctx context.Context, tenant *models.Tenant, activeOnly bool) ([]*models.CustomCRUDEntity, error) {
+	condition, args := tenantCondition(tenant, "organization_id", "created_by")
+	query := `SELECT ` + customCRUDEntityColumns + `
+		FROM custom_crud_entities WHERE ` + condition
+	if activeOnly {
+		query += ` AND is_active = 1`
 	}
+	query += ` ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -223,26 +139,43 @@ func (r *customCRUDRepository) ListEntities(ctx context.Context, createdBy *uuid
 
 	var entities []*models.CustomCRUDEntity
 	for rows.Next() {
-		var e models.CustomCRUDEntity
-		var createdByStr, idStr string
-		var description sql.NullString
-
-		err := rows.Scan(&idStr, &createdByStr, &e.EntityName, &e.DisplayName, &description,
-			&e.Schema, &e.IsActive, &e.CreatedAt, &e.UpdatedAt)
+		e, err := scanCustomCRUDEntity(rows)
 		if err != nil {
 			return nil, err
 		}
+		entities = append(entities, e)
+	}
 
-		e.ID, _ = uuid.Parse(idStr)
-		e.CreatedBy, _ = uuid.Parse(createdByStr)
-		if description.Valid {
-			e.Description = &description.String
-		}
+	return entities, rows.Err()
+}
+
+func scanCustomCRUDEntity(scanner interface{ Scan(...interface{}) error }) (*models.CustomCRUDEntity, error) {
+	var e models.CustomCRUDEntity
+	var idStr string
+	var organizationID, createdBy, description sql.NullString
+
+	err := scanner.Scan(
+		&idStr, &organizationID, &createdBy, &e.EntityName, &e.DisplayName, &description,
+		&e.Schema, &e.IsActive, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		entities = append(entities, &e)
+	e.ID, _ = uuid.Parse(idStr)
+	if createdBy.Valid {
+		id, _ := uuid.Parse(createdBy.String)
+		e.CreatedBy = &id
+	}
+	if organizationID.Valid {
+		orgID, _ := uuid.Parse(organizationID.String)
+		e.OrganizationID = &orgID
+	}
+	if description.Valid {
+		e.Description = &description.String
 	}
 
-	return entities, rows.Err()
+	return &e, nil
 }
 
 func (r *customCRUDRepository) UpdateEntity(ctx context.Context, entity *models.CustomCRUDEntity) error {
@@ -272,23 +205,57 @@ func (r *customCRUDRepository) CreateData(ctx context.Context, data *models.Cust
 		data.ID.String(),
 		data.EntityID.String(),
 		data.Data,
-		data.CreatedBy.String(),
+		nullableUUID(data.CreatedBy),
 		data.CreatedAt,
 		data.UpdatedAt,
 	)
 	return err
 }
 
-func (r *customCRUDRepository) GetDataByID(ctx context.Context, id uuid.UUID) (*models.CustomCRUDData, error) {
+// Data belongs to the tenant of its entity
+const customCRUDDataColumns = `d.id, d.entity_id, d.data, d.created_by, d.updated_by, d.created_at, d.updated_at, d.deleted_at`
+
+func (r *customCRUDRepository) GetDataByID(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CustomCRUDData, error) {
+	condition, args := tenantCondition(tenant, "e.organization_id", "e.created_by")
+	query := `SELECT ` + customCRUDDataColumns + `
+		FROM custom_crud_data d JOIN custom_crud_entities e ON e.id = d.entity_id
+		WHERE d.id = ? AND d.deleted_at IS NULL AND ` + condition
+	return scanCustomCRUDData(r.db.QueryRowContext(ctx, query, append([]interface{}{id.String()}, args...)...))
+}
+
+func (r *customCRUDRepository) ListDataByEntity(ctx context.Context, entityID uuid.UUID, tenant *models.Tenant, limit int, offset int) ([]*models.CustomCRUDData, error) {
+	condition, args := tenantCondition(tenant, "e.organization_id", "e.created_by")
+	query := `SELECT ` + customCRUDDataColumns + `
+		FROM custom_crud_data d JOIN custom_crud_entities e ON e.id = d.entity_id
+		WHERE d.entity_id = ? AND d.deleted_at IS NULL AND ` + condition + `
+		ORDER BY d.created_at DESC LIMIT ? OFFSET ?`
+	args = append([]interface{}{entityID.String()}, args...)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []*models.CustomCRUDData
+	for rows.Next() {
+		d, err := scanCustomCRUDData(rows)
+		if err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, d)
+	}
+
+	return dataList, rows.Err()
+}
+
+func scanCustomCRUDData(scanner interface{ Scan(...interface{}) error }) (*models.CustomCRUDData, error) {
 	var d models.CustomCRUDData
-	var entityIDStr, createdByStr, idStr string
-	var updatedBy sql.NullString
+	var entityIDStr, idStr string
+	var createdBy, updatedBy sql.NullString
 	var deletedAt sql.NullTime
 
-	query := `SELECT id, entity_id, data, created_by, updated_by, created_at, updated_at, deleted_at
-		FROM custom_crud_data WHERE id = ? AND deleted_at IS NULL`
-	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
-		&idStr, &entityIDStr, &d.Data, &createdByStr, &updatedBy,
+	err := scanner.Scan(
+		&idStr, &entityIDStr, &d.Data, &createdBy, &updatedBy,
 		&d.CreatedAt, &d.UpdatedAt, &deletedAt,
 	)
 	if err != nil {
@@ -297,7 +264,10 @@ func (r *customCRUDRepository) GetDataByID(ctx context.Context, id uuid.UUID) (*
 
 	d.ID, _ = uuid.Parse(idStr)
 	d.EntityID, _ = uuid.Parse(entityIDStr)
-	d.CreatedBy, _ = uuid.Parse(createdByStr)
+	if createdBy.Valid {
+		id, _ := uuid.Parse(createdBy.String)
+		d.CreatedBy = &id
+	}
 	if updatedBy.Valid {
 		id, _ := uuid.Parse(updatedBy.String)
 		d.UpdatedBy = &id
@@ -309,46 +279,6 @@ func (r *customCRUDRepository) GetDataByID(ctx context.Context, id uuid.UUID) (*
 	return &d, nil
 }
 
-func (r *customCRUDRepository) ListDataByEntity(ctx context.Context, entityID uuid.UUID, limit int, offset int) ([]*models.CustomCRUDData, error) {
-	query := `SELECT id, entity_id, data, created_by, updated_by, created_at, updated_at, deleted_at
-		FROM custom_crud_data WHERE entity_id = ? AND deleted_at IS NULL
-		ORDER BY created_at DESC LIMIT ? OFFSET ?`
-	rows, err := r.db.QueryContext(ctx, query, entityID.String(), limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var dataList []*models.CustomCRUDData
-	for rows.Next() {
-		var d models.CustomCRUDData
-		var entityIDStr, createdByStr, idStr string
-		var updatedBy sql.NullString
-		var deletedAt sql.NullTime
-
-		err := rows.Scan(&idStr, &entityIDStr, &d.Data, &createdByStr, &updatedBy,
-			&d.CreatedAt, &d.UpdatedAt, &deletedAt)
-		if err != nil {
-			return nil, err
-		}
-
-		d.ID, _ = uuid.Parse(idStr)
-		d.EntityID, _ = uuid.Parse(entityIDStr)
-		d.CreatedBy, _ = uuid.Parse(createdByStr)
-		if updatedBy.Valid {
-			id, _ := uuid.Parse(updatedBy.String)
-			d.UpdatedBy = &id
-		}
-		if deletedAt.Valid {
-			d.DeletedAt = &deletedAt.Time
-		}
-
-		dataList = append(dataList, &d)
-	}
-
-	return dataList, rows.Err()
-}
-
 func (r *customCRUDRepository) UpdateData(ctx context.Context, data *models.CustomCRUDData) error {
 	var updatedByStr *string
 	if data.UpdatedBy != nil {
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	UpdateLastUsed(ctx context.Context, id uuid.UUID, ipAddress string, usedAt time.Time) error
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type apiKeyRepository struct {
+	db *database.DB
+}
+
+func NewAPIKeyRepository(db *database.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	scopesJSON, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+	var allowedIPs *string
+	if len(key.AllowedIPs) > 0 {
+		b, err := json.Marshal(key.AllowedIPs)
+		if err != nil {
+			return err
+		}
+		value := string(b)
+		allowedIPs = &value
+	}
+
+	query := `
+		INSERT INTO api_keys (
+			id, user_id, name, key_prefix, key_hash, scopes, allowed_ips,
+			expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.DB.ExecContext(ctx, query,
+		key.ID, key.UserID, key.Name, key.KeyPrefix, key.KeyHash,
+		string(scopesJSON), allowedIPs, key.ExpiresAt, key.CreatedAt,
+	)
+	return err
+}
+
+const apiKeyColumns = `id, user_id, name, key_prefix, key_hash, scopes, allowed_ips,
+	expires_at, last_used_at, last_used_ip, revoked_at, created_at`
+
+func scanAPIKey(scanner interface{ Scan(...interface{}) error }) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var scopes string
+	var allowedIPs, lastUsedIP sql.NullString
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	if err := scanner.Scan(
+		&key.ID, &key.UserID, &key.Name, &key.KeyPrefix, &key.KeyHash, &scopes, &allowedIPs,
+		&expiresAt, &lastUsedAt, &lastUsedIP, &revokedAt, &key.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("decode api key scopes: %w", err)
+	}
+	if allowedIPs.Valid && allowedIPs.String != "" {
+		if err := json.Unmarshal([]byte(allowedIPs.String), &key.AllowedIPs); err != nil {
+			return nil, fmt.Errorf("decode api key allowed ips: %w", err)
+		}
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if lastUsedIP.Valid {
+		key.LastUsedIP = &lastUsedIP.String
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+	key, err := scanAPIKey(r.db.DB.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("api key not found")
+	}
+	return key, err
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+	key, err := scanAPIKey(r.db.DB.QueryRowContext(ctx, query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("api key not found")
+	}
+	return key, err
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = ? ORDER BY created_at DESC`
+	rows, err := r.db.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+	_, err := r.db.DB.ExecContext(ctx, query, time.Now(), id)
+	return err
+}
+
+func (r *apiKeyRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+	_, err := r.db.DB.ExecContext(ctx, query, time.Now(), userID)
+	return err
+}
+
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID, ipAddress string, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = ?, last_used_ip = ? WHERE id = ?`
+	_, err := r.db.DB.ExecContext(ctx, query, usedAt, ipAddress, id)
+	return err
+}
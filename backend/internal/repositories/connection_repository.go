@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type ConnectionRepository interface {
+	Create(ctx context.Context, connection *models.Connection) error
+	// GetByID and GetBetween return nil, nil when there is no connection.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Connection, error)
+	// GetBetween finds the connection between two users, whoever requested it.
+	GetBetween(ctx context.Context, userID, otherID uuid.UUID) (*models.Connection, error)
+	// Accept reports false when the connection is not a pending request.
+	Accept(ctx context.Context, id uuid.UUID, acceptedAt time.Time) (bool, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListFriends returns a page of the user's accepted connections, most
+	// recent first, and how many there are in all.
+	ListFriends(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*models.Connection, int, error)
+	// ListPending returns requests sent to the user, or sent by them when
+	// incoming is false, newest first.
+	ListPending(ctx context.Context, userID uuid.UUID, incoming bool) ([]*models.Connection, error)
+	AreFriends(ctx context.Context, userID, otherID uuid.UUID) (bool, error)
+
+	Block(ctx context.Context, block *models.UserBlock) error
+	// Unblock reports false when blockerID had not blocked blockedID.
+	Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+	// IsBlocked reports whether either user blocked the other.
+	IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error)
+	ListBlocked(ctx context.Context, blockerID uuid.UUID) ([]*models.UserBlock, error)
+}
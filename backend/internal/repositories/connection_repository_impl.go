@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type connectionRepository struct {
+	db *database.DB
+}
+
+func NewConnectionRepository(db *database.DB) ConnectionRepository {
+	return &connectionRepository{db: db}
+}
+
+const connectionColumns = `id, requester_id, addressee_id, status, created_at, accepted_at`
+
+// betweenCondition matches the connection between two users either way round.
+const betweenCondition = `((requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?))`
+
+func (r *connectionRepository) Create(ctx context.Context, connection *models.Connection) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO user_connections (id, requester_id, addressee_id, status, created_at, accepted_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		connection.ID.String(), connection.RequesterID.String(), connection.AddresseeID.String(),
+		connection.Status, connection.CreatedAt, connection.AcceptedAt,
+	)
+	return err
+}
+
+func (r *connectionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Connection, error) {
+	connection, err := scanConnection(r.db.QueryRowContext(ctx,
+		`SELECT `+connectionColumns+` FROM user_connections WHERE id = ?`, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return connection, err
+}
+
+func (r *connectionRepository) GetBetween(ctx context.Context, userID, otherID uuid.UUID) (*models.Connection, error) {
+	connection, err := scanConnection(r.db.QueryRowContext(ctx,
+		`SELECT `+connectionColumns+` FROM user_connections WHERE `+betweenCondition,
+		userID.String(), otherID.String(), otherID.String(), userID.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return connection, err
+}
+
+func (r *connectionRepository) Accept(ctx context.Context, id uuid.UUID, acceptedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE user_connections SET status = ?, accepted_at = ? WHERE id = ? AND status = ?`,
+		models.ConnectionAccepted, acceptedAt, id.String(), models.ConnectionPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *connectionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_connections WHERE id = ?`, id.String())
+	return err
+}
+
+func (r *connectionRepository) ListFriends(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*models.Connection, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_connections
+		WHERE (requester_id = ? OR addressee_id = ?) AND status = ?`,
+		userID.String(), userID.String(), models.ConnectionAccepted).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	connections, err := r.list(ctx, `SELECT `+connectionColumns+` FROM user_connections
+		WHERE (requester_id = ? OR addressee_id = ?) AND status = ?
+		ORDER BY accepted_at DESC, id LIMIT ? OFFSET ?`,
+		userID.String(), userID.String(), models.ConnectionAccepted, limit, offset)
+	return connections, total, err
+}
+
+func (r *connectionRepository) ListPending(ctx context.Context, userID uuid.UUID, incoming bool) ([]*models.Connection, error) {
+	column := "requester_id"
+	if incoming {
+		column = "addressee_id"
+	}
+	return r.list(ctx, `SELECT `+connectionColumns+` FROM user_connections
+		WHERE `+column+` = ? AND status = ? ORDER BY created_at DESC`,
+		userID.String(), models.ConnectionPending)
+}
+
+func (r *connectionRepository) AreFriends(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_connections WHERE `+betweenCondition+` AND status = ?`,
+		userID.String(), otherID.String(), otherID.String(), userID.String(), models.ConnectionAccepted).Scan(&count)
+	return count > 0, err
+}
+
+func (r *connectionRepository) Block(ctx context.Context, block *models.UserBlock) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO user_blocks (blocker_id, blocked_id, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(blocker_id, blocked_id) DO NOTHING`,
+		block.BlockerID.String(), block.BlockedID.String(), block.CreatedAt)
+	return err
+}
+
+func (r *connectionRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?`,
+		blockerID.String(), blockedID.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *connectionRepository) IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_blocks
+		WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)`,
+		userID.String(), otherID.String(), otherID.String(), userID.String()).Scan(&count)
+	return count > 0, err
+}
+
+func (r *connectionRepository) ListBlocked(ctx context.Context, blockerID uuid.UUID) ([]*models.UserBlock, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT blocker_id, blocked_id, created_at FROM user_blocks
+		WHERE blocker_id = ? ORDER BY created_at DESC`, blockerID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []*models.UserBlock
+	for rows.Next() {
+		block := &models.UserBlock{}
+		if err := rows.Scan(&block.BlockerID, &block.BlockedID, &block.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, rows.Err()
+}
+
+func (r *connectionRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.Connection, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var connections []*models.Connection
+	for rows.Next() {
+		connection, err := scanConnection(rows)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+	return connections, rows.Err()
+}
+
+func scanConnection(scanner interface{ Scan(...interface{}) error }) (*models.Connection, error) {
+	connection := &models.Connection{}
+	err := scanner.Scan(
+		&connection.ID, &connection.RequesterID, &connection.AddresseeID, &connection.Status,
+		&connection.CreatedAt, &connection.AcceptedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type ConsentRepository interface {
+	CreateDocument(ctx context.Context, doc *models.LegalDocument) error
+	GetDocument(ctx context.Context, kind, version string) (*models.LegalDocument, error)
+	// ListDocuments returns the published versions of a kind of document, or
+	// of every kind when kind is empty, newest first.
+	ListDocuments(ctx context.Context, kind string) ([]*models.LegalDocument, error)
+	// CountAcceptances counts the users who accepted each version of each
+	// document, keyed by kind and then version.
+	CountAcceptances(ctx context.Context) (map[string]map[string]int64, error)
+
+	CreateRecord(ctx context.Context, record *models.ConsentRecord) error
+	// ListRecords returns a user's consent ledger, newest first.
+	ListRecords(ctx context.Context, userID uuid.UUID) ([]*models.ConsentRecord, error)
+	// LatestRecord returns the user's newest record of a kind.
+	LatestRecord(ctx context.Context, userID uuid.UUID, kind string) (*models.ConsentRecord, error)
+}
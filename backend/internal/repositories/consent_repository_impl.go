@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type consentRepository struct {
+	db *database.DB
+}
+
+func NewConsentRepository(db *database.DB) ConsentRepository {
+	return &consentRepository{db: db}
+}
+
+const legalDocumentColumns = `id, kind, version, title, url, required, published_by, published_at`
+
+func scanLegalDocument(scanner interface{ Scan(...interface{}) error }) (*models.LegalDocument, error) {
+	var doc models.LegalDocument
+	var idStr string
+	var url, publishedBy sql.NullString
+
+	err := scanner.Scan(&idStr, &doc.Kind, &doc.Version, &doc.Title, &url, &doc.Required, &publishedBy, &doc.PublishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.ID, _ = uuid.Parse(idStr)
+	if url.Valid {
+		doc.URL = &url.String
+	}
+	if publishedBy.Valid {
+		id, _ := uuid.Parse(publishedBy.String)
+		doc.PublishedBy = &id
+	}
+	return &doc, nil
+}
+
+func (r *consentRepository) CreateDocument(ctx context.Context, doc *models.LegalDocument) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO legal_documents (`+legalDocumentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		doc.ID.String(), doc.Kind, doc.Version, doc.Title, doc.URL, doc.Required, nullableUUID(doc.PublishedBy), doc.PublishedAt)
+	return err
+}
+
+func (r *consentRepository) GetDocument(ctx context.Context, kind, version string) (*models.LegalDocument, error) {
+	doc, err := scanLegalDocument(r.db.QueryRowContext(ctx,
+		`SELECT `+legalDocumentColumns+` FROM legal_documents WHERE kind = ? AND version = ?`, kind, version))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return doc, err
+}
+
+func (r *consentRepository) ListDocuments(ctx context.Context, kind string) ([]*models.LegalDocument, error) {
+	query := `SELECT ` + legalDocumentColumns + ` FROM legal_documents`
+	var args []interface{}
+	if kind != "" {
+		query += ` WHERE kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY published_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*models.LegalDocument
+	for rows.Next() {
+		doc, err := scanLegalDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+func (r *consentRepository) CountAcceptances(ctx context.Context) (map[string]map[string]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT kind, version, COUNT(DISTINCT user_id) FROM consent_records
+		WHERE kind != ? AND granted = 1 AND version IS NOT NULL GROUP BY kind, version`, models.ConsentMarketing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]map[string]int64{}
+	for rows.Next() {
+		var kind, version string
+		var count int64
+		if err := rows.Scan(&kind, &version, &count); err != nil {
+			return nil, err
+		}
+		if counts[kind] == nil {
+			counts[kind] = map[string]int64{}
+		}
+		counts[kind][version] = count
+	}
+	return counts, rows.Err()
+}
+
+const consentRecordColumns = `id, user_id, kind, version, granted, source, ip_address, user_agent, created_at`
+
+func scanConsentRecord(scanner interface{ Scan(...interface{}) error }) (*models.ConsentRecord, error) {
+	var record models.ConsentRecord
+	var idStr, userIDStr string
+	var version, ipAddress, userAgent sql.NullString
+
+	err := scanner.Scan(&idStr, &userIDStr, &record.Kind, &version, &record.Granted, &record.Source,
+		&ipAddress, &userAgent, &record.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	record.ID, _ = uuid.Parse(idStr)
+	record.UserID, _ = uuid.Parse(userIDStr)
+	if version.Valid {
+		record.Version = &version.String
+	}
+	if ipAddress.Valid {
+		record.IPAddress = &ipAddress.String
+	}
+	if userAgent.Valid {
+		record.UserAgent = &userAgent.String
+	}
+	return &record, nil
+}
+
+func (r *consentRepository) CreateRecord(ctx context.Context, record *models.ConsentRecord) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO consent_records (`+consentRecordColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.ID.String(), record.UserID.String(), record.Kind, record.Version, record.Granted, record.Source,
+		record.IPAddress, record.UserAgent, record.CreatedAt)
+	return err
+}
+
+func (r *consentRepository) ListRecords(ctx context.Context, userID uuid.UUID) ([]*models.ConsentRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+consentRecordColumns+` FROM consent_records
+		WHERE user_id = ? ORDER BY created_at DESC`, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*models.ConsentRecord
+	for rows.Next() {
+		record, err := scanConsentRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func (r *consentRepository) LatestRecord(ctx context.Context, userID uuid.UUID, kind string) (*models.ConsentRecord, error) {
+	record, err := scanConsentRecord(r.db.QueryRowContext(ctx, `SELECT `+consentRecordColumns+` FROM consent_records
+		WHERE user_id = ? AND kind = ? ORDER BY created_at DESC LIMIT 1`, userID.String(), kind))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return record, err
+}
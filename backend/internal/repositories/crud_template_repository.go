@@ -13,14 +13,17 @@ type CRUDTemplateRepository interface {
 	// Create creates a new CRUD template
 	Create(ctx context.Context, template *models.CRUDTemplate) error
 
-	// GetByID retrieves a template by ID
-	GetByID(ctx context.Context, id uuid.UUID) (*models.CRUDTemplate, error)
+	// GetByID retrieves a template of exactly the given organization, or a
+	// platform template when organizationID is nil
+	GetByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (*models.CRUDTemplate, error)
 
-	// GetByName retrieves a template by name
-	GetByName(ctx context.Context, name string) (*models.CRUDTemplate, error)
+	// GetByName retrieves a template by name from the organization's own
+	// templates or, failing that, the platform's
+	GetByName(ctx context.Context, name string, organizationID *uuid.UUID) (*models.CRUDTemplate, error)
 
-	// List retrieves all active templates, optionally filtered by category
-	List(ctx context.Context, category *string, activeOnly bool) ([]*models.CRUDTemplate, error)
+	// List retrieves the platform templates and the organization's own,
+	// optionally filtered by category
+	List(ctx context.Context, organizationID *uuid.UUID, category *string, activeOnly bool) ([]*models.CRUDTemplate, error)
 
 	// ListByCreator retrieves templates created by a specific admin
 	ListByCreator(ctx context.Context, createdBy uuid.UUID) ([]*models.CRUDTemplate, error)
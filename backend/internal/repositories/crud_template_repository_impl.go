@@ -20,8 +20,8 @@ func NewCRUDTemplateRepository(db *database.DB) CRUDTemplateRepository {
 }
 
 func (r *crudTemplateRepository) Create(ctx context.Context, template *models.CRUDTemplate) error {
-	query := `INSERT INTO crud_templates (id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO crud_templates (id, organization_id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
 	isActive := 0
 	if template.IsActive {
@@ -34,6 +34,7 @@ func (r *crudTemplateRepository) Create(ctx context.Context, template *models.CR
 
 	_, err := r.db.ExecContext(ctx, query,
 		template.ID.String(),
+		nullableUUID(template.OrganizationID),
 		template.Name,
 		template.DisplayName,
 		template.Description,
@@ -49,16 +50,17 @@ func (r *crudTemplateRepository) Create(ctx context.Context, template *models.CR
 	return err
 }
 
-func (r *crudTemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.CRUDTemplate, error) {
+func (r *crudTemplateRepository) GetByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (*models.CRUDTemplate, error) {
+	condition, args := organizationCondition("organization_id", organizationID)
 	var t models.CRUDTemplate
-	var description, icon, category sql.NullString
+	var organization, description, icon, category sql.NullString
 	var isActive, isSystem int
 
-	query := `SELECT id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
-		FROM crud_templates WHERE id = ?`
+	query := `SELECT id, organization_id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
+		FROM crud_templates WHERE id = ? AND ` + condition
 	
-	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
-		&t.ID, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{id.String()}, args...)...).Scan(
+		&t.ID, &organization, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
 		&t.CreatedBy, &isActive, &isSystem, &t.CreatedAt, &t.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -68,6 +70,10 @@ func (r *crudTemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 		return nil, err
 	}
 
+	if organization.Valid {
+		orgID, _ := uuid.Parse(organization.String)
+		t.OrganizationID = &orgID
+	}
 	if description.Valid {
 		t.Description = &description.String
 	}
@@ -83,16 +89,17 @@ func (r *crudTemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	return &t, nil
 }
 
-func (r *crudTemplateRepository) GetByName(ctx context.Context, name string) (*models.CRUDTemplate, error) {
+func (r *crudTemplateRepository) GetByName(ctx context.Context, name string, organizationID *uuid.UUID) (*models.CRUDTemplate, error) {
 	var t models.CRUDTemplate
-	var description, icon, category sql.NullString
+	var organization, description, icon, category sql.NullString
 	var isActive, isSystem int
 
-	query := `SELECT id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
-		FROM crud_templates WHERE name = ?`
+	query := `SELECT id, organization_id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
+		FROM crud_templates WHERE name = ? AND (organization_id IS NULL OR organization_id = ?)
+		ORDER BY organization_id IS NULL LIMIT 1`
 	
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
-		&t.ID, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
+	err := r.db.QueryRowContext(ctx, query, name, nullableUUID(organizationID)).Scan(
+		&t.ID, &organization, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
 		&t.CreatedBy, &isActive, &isSystem, &t.CreatedAt, &t.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -102,6 +109,10 @@ func (r *crudTemplateRepository) GetByName(ctx context.Context, name string) (*m
 		return nil, err
 	}
 
+	if organization.Valid {
+		orgID, _ := uuid.Parse(organization.String)
+		t.OrganizationID = &orgID
+	}
 	if description.Valid {
 		t.Description = &description.String
 	}
@@ -117,10 +128,10 @@ func (r *crudTemplateRepository) GetByName(ctx context.Context, name string) (*m
 	return &t, nil
 }
 
-func (r *crudTemplateRepository) List(ctx context.Context, category *string, activeOnly bool) ([]*models.CRUDTemplate, error) {
-	query := `SELECT id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
-		FROM crud_templates WHERE 1=1`
-	args := []interface{}{}
+func (r *crudTemplateRepository) List(ctx context.Context, organizationID *uuid.UUID, category *string, activeOnly bool) ([]*models.CRUDTemplate, error) {
+	query := `SELECT id, organization_id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
+		FROM crud_templates WHERE (organization_id IS NULL OR organization_id = ?)`
+	args := []interface{}{nullableUUID(organizationID)}
 
 	if category != nil {
 		query += ` AND category = ?`
@@ -142,17 +153,21 @@ func (r *crudTemplateRepository) List(ctx context.Context, category *string, act
 	var templates []*models.CRUDTemplate
 	for rows.Next() {
 		var t models.CRUDTemplate
-		var description, icon, category sql.NullString
+		var organization, description, icon, category sql.NullString
 		var isActive, isSystem int
 
 		err := rows.Scan(
-			&t.ID, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
+			&t.ID, &organization, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
 			&t.CreatedBy, &isActive, &isSystem, &t.CreatedAt, &t.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		if organization.Valid {
+			orgID, _ := uuid.Parse(organization.String)
+			t.OrganizationID = &orgID
+		}
 		if description.Valid {
 			t.Description = &description.String
 		}
@@ -172,7 +187,7 @@ func (r *crudTemplateRepository) List(ctx context.Context, category *string, act
 }
 
 func (r *crudTemplateRepository) ListByCreator(ctx context.Context, createdBy uuid.UUID) ([]*models.CRUDTemplate, error) {
-	query := `SELECT id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
+	query := `SELECT id, organization_id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
 		FROM crud_templates WHERE created_by = ? ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, createdBy.String())
@@ -184,17 +199,21 @@ func (r *crudTemplateRepository) ListByCreator(ctx context.Context, createdBy uu
 	var templates []*models.CRUDTemplate
 	for rows.Next() {
 		var t models.CRUDTemplate
-		var description, icon, category sql.NullString
+		var organization, description, icon, category sql.NullString
 		var isActive, isSystem int
 
 		err := rows.Scan(
-			&t.ID, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
+			&t.ID, &organization, &t.Name, &t.DisplayName, &description, &t.Schema, &icon, &category,
 			&t.CreatedBy, &isActive, &isSystem, &t.CreatedAt, &t.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		if organization.Valid {
+			orgID, _ := uuid.Parse(organization.String)
+			t.OrganizationID = &orgID
+		}
 		if description.Valid {
 			t.Description = &description.String
 		}
@@ -8,13 +8,14 @@ import (
 	"base-app-service/internal/models"
 )
 
+// DashboardRepository lookups only return items belonging to the given
+// tenant.
 type DashboardRepository interface {
 	Create(ctx context.Context, item *models.DashboardItem) error
-	GetByID(ctx context.Context, id uuid.UUID) (*models.DashboardItem, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID, status string) ([]*models.DashboardItem, error)
-	ListItems(ctx context.Context, userID uuid.UUID, limit int, offset int) ([]*models.DashboardItem, error)
+	GetByID(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.DashboardItem, error)
+	GetByTenant(ctx context.Context, tenant *models.Tenant, status string) ([]*models.DashboardItem, error)
+	ListItems(ctx context.Context, tenant *models.Tenant, limit int, offset int) ([]*models.DashboardItem, error)
 	Update(ctx context.Context, item *models.DashboardItem) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	SoftDelete(ctx context.Context, id uuid.UUID) error
 }
-
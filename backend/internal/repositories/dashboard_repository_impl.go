@@ -19,15 +19,18 @@ func NewDashboardRepository(db *database.DB) DashboardRepository {
 	return &dashboardRepository{db: db}
 }
 
+const dashboardItemColumns = `id, user_id, organization_id, title, description, category, status, priority, metadata, created_at, updated_at, deleted_at`
+
 func (r *dashboardRepository) Create(ctx context.Context, item *models.DashboardItem) error {
 	query := `
-		INSERT INTO dashboard_items (id, user_id, title, description, category, status, priority, metadata, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO dashboard_items (id, user_id, organization_id, title, description, category, status, priority, metadata, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	now := time.Now()
 	_, err := r.db.ExecContext(ctx, query,
 		item.ID.String(),
 		item.UserID.String(),
+		nullableUUID(item.OrganizationID),
 		item.Title,
 		item.Description,
 		item.Category,
@@ -40,53 +43,16 @@ func (r *dashboardRepository) Create(ctx context.Context, item *models.Dashboard
 	return err
 }
 
-func (r *dashboardRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DashboardItem, error) {
-	var item models.DashboardItem
-	var userIDStr, idStr string
-	var description, category, metadata sql.NullString
-	var deletedAt sql.NullTime
-
+func (r *dashboardRepository) GetByID(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.DashboardItem, error) {
+	condition, args := tenantCondition(tenant, "organization_id", "user_id")
 	query := `
-		SELECT id, user_id, title, description, category, status, priority, metadata, created_at, updated_at, deleted_at
+		SELECT ` + dashboardItemColumns + `
 		FROM dashboard_items
-		WHERE id = ? AND deleted_at IS NULL
-	`
-	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
-		&idStr,
-		&userIDStr,
-		&item.Title,
-		&description,
-		&category,
-		&item.Status,
-		&item.Priority,
-		&metadata,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-		&deletedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	item.ID, _ = uuid.Parse(idStr)
-	item.UserID, _ = uuid.Parse(userIDStr)
-	if description.Valid {
-		item.Description = &description.String
-	}
-	if category.Valid {
-		item.Category = &category.String
-	}
-	if metadata.Valid {
-		item.Metadata = &metadata.String
-	}
-	if deletedAt.Valid {
-		item.DeletedAt = &deletedAt.Time
-	}
-
-	return &item, nil
+		WHERE id = ? AND deleted_at IS NULL AND ` + condition
+	return scanDashboardItem(r.db.QueryRowContext(ctx, query, append([]interface{}{id.String()}, args...)...))
 }
 
-func (r *dashboardRepository) ListItems(ctx context.Context, userID uuid.UUID, limit int, offset int) ([]*models.DashboardItem, error) {
+func (r *dashboardRepository) ListItems(ctx context.Context, tenant *models.Tenant, limit int, offset int) ([]*models.DashboardItem, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -94,15 +60,33 @@ func (r *dashboardRepository) ListItems(ctx context.Context, userID uuid.UUID, l
 		offset = 0
 	}
 
+	condition, args := tenantCondition(tenant, "organization_id", "user_id")
 	query := `
-		SELECT id, user_id, title, description, category, status, priority, metadata, created_at, updated_at, deleted_at
+		SELECT ` + dashboardItemColumns + `
 		FROM dashboard_items
-		WHERE user_id = ? AND deleted_at IS NULL
+		WHERE ` + condition + ` AND deleted_at IS NULL
 		ORDER BY priority DESC, created_at DESC
 		LIMIT ? OFFSET ?
 	`
+	return r.list(ctx, query, append(args, limit, offset)...)
+}
+
+func (r *dashboardRepository) GetByTenant(ctx context.Context, tenant *models.Tenant, status string) ([]*models.DashboardItem, error) {
+	condition, args := tenantCondition(tenant, "organization_id", "user_id")
+	query := `
+		SELECT ` + dashboardItemColumns + `
+		FROM dashboard_items
+		WHERE ` + condition + ` AND deleted_at IS NULL`
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY priority DESC, created_at DESC`
+	return r.list(ctx, query, args...)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, userID.String(), limit, offset)
+func (r *dashboardRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.DashboardItem, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -110,109 +94,60 @@ func (r *dashboardRepository) ListItems(ctx context.Context, userID uuid.UUID, l
 
 	var items []*models.DashboardItem
 	for rows.Next() {
-		var item models.DashboardItem
-		var userIDStr, idStr string
-		var description, category, metadata sql.NullString
-		var deletedAt sql.NullTime
-
-		err := rows.Scan(&idStr, &userIDStr, &item.Title, &description, &category,
-			&item.Status, &item.Priority, &metadata, &item.CreatedAt, &item.UpdatedAt, &deletedAt)
+		item, err := scanDashboardItem(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		item.ID, _ = uuid.Parse(idStr)
-		item.UserID, _ = uuid.Parse(userIDStr)
-		if description.Valid {
-			item.Description = &description.String
-		}
-		if category.Valid {
-			item.Category = &category.String
-		}
-		if metadata.Valid {
-			item.Metadata = &metadata.String
-		}
-		if deletedAt.Valid {
-			item.DeletedAt = &deletedAt.Time
-		}
-
-		items = append(items, &item)
+		items = append(items, item)
 	}
 
 	return items, rows.Err()
 }
 
-func (r *dashboardRepository) GetByUserID(ctx context.Context, userID uuid.UUID, status string) ([]*models.DashboardItem, error) {
-	var items []*models.DashboardItem
-	var query string
-	var args []interface{}
-
-	if status == "" {
-		query = `
-			SELECT id, user_id, title, description, category, status, priority, metadata, created_at, updated_at, deleted_at
-			FROM dashboard_items
-			WHERE user_id = ? AND deleted_at IS NULL
-			ORDER BY priority DESC, created_at DESC
-		`
-		args = []interface{}{userID.String()}
-	} else {
-		query = `
-			SELECT id, user_id, title, description, category, status, priority, metadata, created_at, updated_at, deleted_at
-			FROM dashboard_items
-			WHERE user_id = ? AND status = ? AND deleted_at IS NULL
-			ORDER BY priority DESC, created_at DESC
-		`
-		args = []interface{}{userID.String(), status}
-	}
+func scanDashboardItem(scanner interface{ Scan(...interface{}) error }) (*models.DashboardItem, error) {
+	var item models.DashboardItem
+	var userIDStr, idStr string
+	var organizationID, description, category, metadata sql.NullString
+	var deletedAt sql.NullTime
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	err := scanner.Scan(
+		&idStr,
+		&userIDStr,
+		&organizationID,
+		&item.Title,
+		&description,
+		&category,
+		&item.Status,
+		&item.Priority,
+		&metadata,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&deletedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var item models.DashboardItem
-		var userIDStr, idStr string
-		var description, category, metadata sql.NullString
-		var deletedAt sql.NullTime
-
-		err := rows.Scan(
-			&idStr,
-			&userIDStr,
-			&item.Title,
-			&description,
-			&category,
-			&item.Status,
-			&item.Priority,
-			&metadata,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-			&deletedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		item.ID, _ = uuid.Parse(idStr)
-		item.UserID, _ = uuid.Parse(userIDStr)
-		if description.Valid {
-			item.Description = &description.String
-		}
-		if category.Valid {
-			item.Category = &category.String
-		}
-		if metadata.Valid {
-			item.Metadata = &metadata.String
-		}
-		if deletedAt.Valid {
-			item.DeletedAt = &deletedAt.Time
-		}
-
-		items = append(items, &item)
+	item.ID, _ = uuid.Parse(idStr)
+	item.UserID, _ = uuid.Parse(userIDStr)
+	if organizationID.Valid {
+		orgID, _ := uuid.Parse(organizationID.String)
+		item.OrganizationID = &orgID
+	}
+	if description.Valid {
+		item.Description = &description.String
+	}
+	if category.Valid {
+		item.Category = &category.String
+	}
+	if metadata.Valid {
+		item.Metadata = &metadata.String
+	}
+	if deletedAt.Valid {
+		item.DeletedAt = &deletedAt.Time
 	}
 
-	return items, rows.Err()
+	return &item, nil
 }
 
 func (r *dashboardRepository) Update(ctx context.Context, item *models.DashboardItem) error {
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	// GetByID and GetByTokenHash return nil, nil when there is no such export.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.DataExport, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.DataExport, error)
+	ListPending(ctx context.Context) ([]*models.DataExport, error)
+	// ListExpired returns ready exports whose archives expired before the time.
+	ListExpired(ctx context.Context, before time.Time) ([]*models.DataExport, error)
+	MarkReady(ctx context.Context, export *models.DataExport) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string, at time.Time) error
+	MarkExpired(ctx context.Context, id uuid.UUID) error
+	MarkDownloaded(ctx context.Context, id uuid.UUID, at time.Time) error
+	// UserRecords returns the user's rows from every table holding their
+	// data, keyed by section name, without credentials and other secrets.
+	UserRecords(ctx context.Context, userID uuid.UUID) (map[string][]map[string]interface{}, error)
+}
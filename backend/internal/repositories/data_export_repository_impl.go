@@ -0,0 +1,233 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type dataExportRepository struct {
+	db *database.DB
+}
+
+func NewDataExportRepository(db *database.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+const dataExportColumns = `id, user_id, status, file_path, size, token_hash, error, created_at, completed_at, expires_at, downloaded_at`
+
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO data_exports (id, user_id, status, created_at) VALUES (?, ?, ?, ?)`,
+		export.ID.String(), export.UserID.String(), export.Status, export.CreatedAt,
+	)
+	return err
+}
+
+func (r *dataExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, `SELECT `+dataExportColumns+` FROM data_exports WHERE id = ?`, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return export, err
+}
+
+func (r *dataExportRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.DataExport, error) {
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, `SELECT `+dataExportColumns+` FROM data_exports WHERE token_hash = ?`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return export, err
+}
+
+func (r *dataExportRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.DataExport, error) {
+	return r.list(ctx, `SELECT `+dataExportColumns+` FROM data_exports WHERE user_id = ? ORDER BY created_at DESC`, userID.String())
+}
+
+func (r *dataExportRepository) ListPending(ctx context.Context) ([]*models.DataExport, error) {
+	return r.list(ctx, `SELECT `+dataExportColumns+` FROM data_exports WHERE status = ? ORDER BY created_at`, models.DataExportPending)
+}
+
+func (r *dataExportRepository) ListExpired(ctx context.Context, before time.Time) ([]*models.DataExport, error) {
+	return r.list(ctx, `SELECT `+dataExportColumns+` FROM data_exports WHERE status = ? AND expires_at < ?`,
+		models.DataExportReady, before)
+}
+
+func (r *dataExportRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.DataExport, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []*models.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
+
+func (r *dataExportRepository) MarkReady(ctx context.Context, export *models.DataExport) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE data_exports
+		SET status = ?, file_path = ?, size = ?, token_hash = ?, completed_at = ?, expires_at = ?
+		WHERE id = ?`,
+		models.DataExportReady, export.FilePath, export.Size, export.TokenHash, export.CompletedAt, export.ExpiresAt, export.ID.String(),
+	)
+	return err
+}
+
+func (r *dataExportRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE data_exports SET status = ?, error = ?, completed_at = ? WHERE id = ?`,
+		models.DataExportFailed, reason, at, id.String())
+	return err
+}
+
+// MarkExpired forgets the archive and its token once the file is gone.
+func (r *dataExportRepository) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE data_exports SET status = ?, file_path = NULL, token_hash = NULL WHERE id = ?`,
+		models.DataExportExpired, id.String())
+	return err
+}
+
+func (r *dataExportRepository) MarkDownloaded(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE data_exports SET downloaded_at = ? WHERE id = ?`, at, id.String())
+	return err
+}
+
+func scanDataExport(scanner interface{ Scan(...interface{}) error }) (*models.DataExport, error) {
+	export := &models.DataExport{}
+	err := scanner.Scan(
+		&export.ID, &export.UserID, &export.Status, &export.FilePath, &export.Size, &export.TokenHash,
+		&export.Error, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt, &export.DownloadedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// userDataSection is one table's worth of a user's data. The query takes the
+// user's ID once for each placeholder; omit lists columns left out of the
+// export because they hold credentials or other secrets, and sealed the
+// encrypted fields exported decrypted.
+type userDataSection struct {
+	name   string
+	query  string
+	omit   []string
+	sealed []string
+}
+
+var userDataSections = []userDataSection{
+	{name: "profile", query: `SELECT * FROM users WHERE id = ?`,
+		omit:   []string{"password_hash", "email_verification_token", "phone_verification_code", "phone_index"},
+		sealed: []string{fieldUserPhone}},
+	{name: "settings", query: `SELECT * FROM user_settings_comprehensive WHERE user_id = ?`,
+		omit: []string{"two_factor_secret", "two_factor_backup_codes", "security_questions"}},
+	{name: "preferences", query: `SELECT * FROM user_settings WHERE user_id = ?`},
+	{name: "product_themes", query: `SELECT * FROM product_theme_preferences WHERE user_id = ?`},
+	{name: "two_factor", query: `SELECT * FROM user_two_factor WHERE user_id = ?`},
+	{name: "sessions", query: `SELECT * FROM sessions WHERE user_id = ? ORDER BY created_at`,
+		omit: []string{"token", "refresh_token"}},
+	{name: "devices", query: `SELECT * FROM user_devices WHERE user_id = ? ORDER BY created_at`},
+	{name: "login_history", query: `SELECT * FROM login_events WHERE user_id = ? ORDER BY created_at`},
+	{name: "identities", query: `SELECT * FROM user_identities WHERE user_id = ?`},
+	{name: "passkeys", query: `SELECT * FROM passkey_credentials WHERE user_id = ?`,
+		omit: []string{"public_key"}},
+	{name: "api_keys", query: `SELECT * FROM api_keys WHERE user_id = ?`,
+		omit: []string{"key_hash"}},
+	{name: "notifications", query: `SELECT * FROM notifications WHERE user_id = ? ORDER BY created_at`},
+	{name: "messages", query: `SELECT * FROM messages WHERE sender_id = ? OR recipient_id = ? ORDER BY created_at`},
+	{name: "conversations", query: `SELECT * FROM conversations WHERE participant1_id = ? OR participant2_id = ?`},
+	{name: "search_history", query: `SELECT * FROM search_history WHERE user_id = ? ORDER BY created_at`},
+	{name: "dashboard_items", query: `SELECT * FROM dashboard_items WHERE user_id = ? ORDER BY created_at`},
+	{name: "crud_records", query: `SELECT * FROM custom_crud_data WHERE created_by = ? ORDER BY created_at`},
+	{name: "access_requests", query: `SELECT * FROM access_requests WHERE user_id = ? ORDER BY created_at`},
+	{name: "account_switches", query: `SELECT * FROM account_switches WHERE user_id = ? OR switched_to_user_id = ? ORDER BY created_at`},
+	{name: "connections", query: `SELECT * FROM user_connections WHERE requester_id = ? OR addressee_id = ?`},
+	{name: "blocks", query: `SELECT * FROM user_blocks WHERE blocker_id = ?`},
+	{name: "organizations", query: `SELECT * FROM organization_members WHERE user_id = ?`},
+	{name: "files", query: `SELECT * FROM uploaded_files WHERE user_id = ? ORDER BY created_at`},
+	{name: "consents", query: `SELECT * FROM consent_records WHERE user_id = ? ORDER BY created_at`},
+	{name: "activity_logs", query: `SELECT * FROM activity_logs
+		WHERE actor_id = ? OR (target_type = 'user' AND target_id = ?) ORDER BY created_at`},
+	{name: "admin_activity_logs", query: `SELECT * FROM admin_activity_logs WHERE admin_id = ? ORDER BY created_at`},
+}
+
+func (r *dataExportRepository) UserRecords(ctx context.Context, userID uuid.UUID) (map[string][]map[string]interface{}, error) {
+	records := make(map[string][]map[string]interface{}, len(userDataSections))
+	for _, section := range userDataSections {
+		args := make([]interface{}, strings.Count(section.query, "?"))
+		for i := range args {
+			args[i] = userID.String()
+		}
+		rows, err := queryRecords(ctx, r.db, section.query, args, section.omit)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", section.name, err)
+		}
+		for _, field := range section.sealed {
+			column := field[strings.Index(field, ".")+1:]
+			for _, row := range rows {
+				if value, ok := row[column].(string); ok {
+					if row[column], err = openField(r.db, field, value); err != nil {
+						return nil, fmt.Errorf("export %s: %w", section.name, err)
+					}
+				}
+			}
+		}
+		records[section.name] = rows
+	}
+	return records, nil
+}
+
+// queryRecords returns the query's rows as column-to-value maps, leaving out
+// the omitted columns.
+func queryRecords(ctx context.Context, db *database.DB, query string, args []interface{}, omit []string) ([]map[string]interface{}, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	omitted := make(map[string]bool, len(omit))
+	for _, column := range omit {
+		omitted[column] = true
+	}
+
+	records := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if omitted[column] {
+				continue
+			}
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			record[column] = values[i]
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
@@ -42,7 +42,7 @@ func (r *deviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.D
 	query := `
 		SELECT id, user_id, device_id, device_name, device_type, os, browser,
 			ip_address, location_country, location_city, is_trusted,
-			trusted_at, last_used_at, created_at
+			trusted_at, trust_token_hash, last_used_at, created_at
 		FROM user_devices
 		WHERE id = ?
 	`
@@ -52,7 +52,7 @@ func (r *deviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.D
 		&device.ID, &device.UserID, &device.DeviceID, &device.DeviceName,
 		&device.DeviceType, &device.OS, &device.Browser, &device.IPAddress,
 		&device.LocationCountry, &device.LocationCity, &device.IsTrusted,
-		&device.TrustedAt, &device.LastUsedAt, &device.CreatedAt,
+		&device.TrustedAt, &device.TrustTokenHash, &device.LastUsedAt, &device.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -73,7 +73,7 @@ func (r *deviceRepository) GetByDeviceID(ctx context.Context, userID uuid.UUID,
 	query := `
 		SELECT id, user_id, device_id, device_name, device_type, os, browser,
 			ip_address, location_country, location_city, is_trusted,
-			trusted_at, last_used_at, created_at
+			trusted_at, trust_token_hash, last_used_at, created_at
 		FROM user_devices
 		WHERE user_id = ? AND device_id = ?
 	`
@@ -83,7 +83,7 @@ func (r *deviceRepository) GetByDeviceID(ctx context.Context, userID uuid.UUID,
 		&device.ID, &device.UserID, &device.DeviceID, &device.DeviceName,
 		&device.DeviceType, &device.OS, &device.Browser, &device.IPAddress,
 		&device.LocationCountry, &device.LocationCity, &device.IsTrusted,
-		&device.TrustedAt, &device.LastUsedAt, &device.CreatedAt,
+		&device.TrustedAt, &device.TrustTokenHash, &device.LastUsedAt, &device.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -100,7 +100,7 @@ func (r *deviceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([
 	query := `
 		SELECT id, user_id, device_id, device_name, device_type, os, browser,
 			ip_address, location_country, location_city, is_trusted,
-			trusted_at, last_used_at, created_at
+			trusted_at, trust_token_hash, last_used_at, created_at
 		FROM user_devices
 		WHERE user_id = ?
 		ORDER BY last_used_at DESC
@@ -119,7 +119,7 @@ func (r *deviceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([
 			&device.ID, &device.UserID, &device.DeviceID, &device.DeviceName,
 			&device.DeviceType, &device.OS, &device.Browser, &device.IPAddress,
 			&device.LocationCountry, &device.LocationCity, &device.IsTrusted,
-			&device.TrustedAt, &device.LastUsedAt, &device.CreatedAt,
+			&device.TrustedAt, &device.TrustTokenHash, &device.LastUsedAt, &device.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -133,13 +133,16 @@ func (r *deviceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([
 func (r *deviceRepository) Update(ctx context.Context, device *models.Device) error {
 	query := `
 		UPDATE user_devices
-		SET device_name = ?, last_used_at = ?, is_trusted = ?, trusted_at = ?
+		SET device_name = ?, device_type = ?, os = ?, browser = ?, ip_address = ?,
+			location_country = ?, location_city = ?, last_used_at = ?,
+			is_trusted = ?, trusted_at = ?, trust_token_hash = ?
 		WHERE id = ?
 	`
 
 	_, err := r.db.DB.ExecContext(ctx, query,
-		device.DeviceName, device.LastUsedAt,
-		device.IsTrusted, device.TrustedAt, device.ID,
+		device.DeviceName, device.DeviceType, device.OS, device.Browser,
+		device.IPAddress, device.LocationCountry, device.LocationCity,
+		device.LastUsedAt, device.IsTrusted, device.TrustedAt, device.TrustTokenHash, device.ID,
 	)
 
 	return err
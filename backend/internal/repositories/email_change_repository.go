@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type EmailChangeRepository interface {
+	Create(ctx context.Context, request *models.EmailChangeRequest) error
+	// GetByConfirmTokenHash and GetByRevertTokenHash return nil, nil when no
+	// request has the token.
+	GetByConfirmTokenHash(ctx context.Context, tokenHash string) (*models.EmailChangeRequest, error)
+	GetByRevertTokenHash(ctx context.Context, tokenHash string) (*models.EmailChangeRequest, error)
+	// GetPendingByUserID returns the user's outstanding request, or nil, nil.
+	GetPendingByUserID(ctx context.Context, userID uuid.UUID, now time.Time) (*models.EmailChangeRequest, error)
+	// MarkConfirmed and MarkReverted report false when the request was already
+	// confirmed or reverted, so each link works once.
+	MarkConfirmed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	MarkReverted(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// CancelPending reverts every unconfirmed request for the user.
+	CancelPending(ctx context.Context, userID uuid.UUID, at time.Time) error
+}
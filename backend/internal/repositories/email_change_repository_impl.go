@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type emailChangeRepository struct {
+	db *database.DB
+}
+
+func NewEmailChangeRepository(db *database.DB) EmailChangeRepository {
+	return &emailChangeRepository{db: db}
+}
+
+const emailChangeColumns = `id, user_id, old_email, new_email, confirm_token_hash, revert_token_hash,
+	session_id, ip_address, expires_at, revert_expires_at, confirmed_at, reverted_at, created_at`
+
+func (r *emailChangeRepository) Create(ctx context.Context, request *models.EmailChangeRequest) error {
+	query := `INSERT INTO email_change_requests (id, user_id, old_email, new_email, confirm_token_hash,
+		revert_token_hash, session_id, ip_address, expires_at, revert_expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query,
+		request.ID.String(), request.UserID.String(), request.OldEmail, request.NewEmail,
+		request.ConfirmTokenHash, request.RevertTokenHash, request.SessionID, request.IPAddress,
+		request.ExpiresAt, request.RevertExpiresAt, request.CreatedAt,
+	)
+	return err
+}
+
+func (r *emailChangeRepository) GetByConfirmTokenHash(ctx context.Context, tokenHash string) (*models.EmailChangeRequest, error) {
+	return r.getOne(ctx, `SELECT `+emailChangeColumns+` FROM email_change_requests WHERE confirm_token_hash = ?`, tokenHash)
+}
+
+func (r *emailChangeRepository) GetByRevertTokenHash(ctx context.Context, tokenHash string) (*models.EmailChangeRequest, error) {
+	return r.getOne(ctx, `SELECT `+emailChangeColumns+` FROM email_change_requests WHERE revert_token_hash = ?`, tokenHash)
+}
+
+func (r *emailChangeRepository) GetPendingByUserID(ctx context.Context, userID uuid.UUID, now time.Time) (*models.EmailChangeRequest, error) {
+	return r.getOne(ctx, `SELECT `+emailChangeColumns+` FROM email_change_requests
+		WHERE user_id = ? AND confirmed_at IS NULL AND reverted_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC LIMIT 1`, userID.String(), now)
+}
+
+func (r *emailChangeRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.EmailChangeRequest, error) {
+	request := &models.EmailChangeRequest{}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&request.ID, &request.UserID, &request.OldEmail, &request.NewEmail,
+		&request.ConfirmTokenHash, &request.RevertTokenHash, &request.SessionID, &request.IPAddress,
+		&request.ExpiresAt, &request.RevertExpiresAt, &request.ConfirmedAt, &request.RevertedAt, &request.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (r *emailChangeRepository) MarkConfirmed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE email_change_requests SET confirmed_at = ?
+		WHERE id = ? AND confirmed_at IS NULL AND reverted_at IS NULL`, at, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *emailChangeRepository) MarkReverted(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE email_change_requests SET reverted_at = ?
+		WHERE id = ? AND reverted_at IS NULL`, at, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *emailChangeRepository) CancelPending(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE email_change_requests SET reverted_at = ?
+		WHERE user_id = ? AND confirmed_at IS NULL AND reverted_at IS NULL`, at, userID.String())
+	return err
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"errors"
+
+	"base-app-service/internal/database"
+	"base-app-service/pkg/encryption"
+	"base-app-service/pkg/sms"
+)
+
+// Encrypted columns, named "table.column". The name is authenticated with
+// each sealed value.
+const (
+	fieldTwoFactorSecret      = "user_settings_comprehensive.two_factor_secret"
+	fieldTwoFactorBackupCodes = "user_settings_comprehensive.two_factor_backup_codes"
+	fieldSecurityQuestions    = "user_settings_comprehensive.security_questions"
+	fieldUserPhone            = "users.phone"
+	fieldSMSCodePhone         = "sms_codes.phone"
+	fieldSubscriptionSecret   = "webhook_subscriptions.webhook_secret"
+	fieldEventSecret          = "webhook_events.webhook_secret"
+)
+
+// encryptedField is a column whose values are sealed with the database's
+// keyring. key is the table's primary key column; index, if set, is the
+// column holding the value's blind index.
+type encryptedField struct {
+	name   string
+	table  string
+	column string
+	key    string
+	index  string
+}
+
+var encryptedFields = []encryptedField{
+	{name: fieldTwoFactorSecret, table: "user_settings_comprehensive", column: "two_factor_secret", key: "user_id"},
+	{name: fieldTwoFactorBackupCodes, table: "user_settings_comprehensive", column: "two_factor_backup_codes", key: "user_id"},
+	{name: fieldSecurityQuestions, table: "user_settings_comprehensive", column: "security_questions", key: "user_id"},
+	{name: fieldUserPhone, table: "users", column: "phone", key: "id", index: "phone_index"},
+	{name: fieldSMSCodePhone, table: "sms_codes", column: "phone", key: "id"},
+	{name: fieldSubscriptionSecret, table: "webhook_subscriptions", column: "webhook_secret", key: "id"},
+	{name: fieldEventSecret, table: "webhook_events", column: "webhook_secret", key: "id"},
+}
+
+var errNoKeyring = errors.New("encrypted value found but no encryption keys are loaded")
+
+// sealField encrypts value for field with the database's keyring. Empty
+// values, and every value when no keyring is set, are stored as they are.
+func sealField(db *database.DB, field, value string) (string, error) {
+	keyring := db.Keyring()
+	if keyring == nil || value == "" {
+		return value, nil
+	}
+	return keyring.Seal(field, value)
+}
+
+// openField decrypts a value read from field. Values stored before
+// encryption was turned on are returned as they are.
+func openField(db *database.DB, field, value string) (string, error) {
+	if !encryption.IsSealed(value) {
+		return value, nil
+	}
+	keyring := db.Keyring()
+	if keyring == nil {
+		return "", errNoKeyring
+	}
+	return keyring.Open(field, value)
+}
+
+// sealNullable and openNullable are sealField and openField for nullable
+// columns.
+func sealNullable(db *database.DB, field string, value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	sealed, err := sealField(db, field, *value)
+	return &sealed, err
+}
+
+func openNullable(db *database.DB, field string, value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	opened, err := openField(db, field, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &opened, nil
+}
+
+// blindIndex returns the blind index of value for field, or nil when value is
+// nil or no keyring is set.
+func blindIndex(db *database.DB, field string, value *string) (*string, error) {
+	keyring := db.Keyring()
+	if keyring == nil || value == nil || *value == "" {
+		return nil, nil
+	}
+	index, err := keyring.BlindIndex(field, normalizeIndexed(field, *value))
+	if err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// normalizeIndexed makes equal values index the same however they were
+// written.
+func normalizeIndexed(field, value string) string {
+	if field == fieldUserPhone {
+		if phone, err := sms.Normalize(value); err == nil {
+			return phone
+		}
+	}
+	return value
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"base-app-service/internal/models"
+)
+
+type EncryptionRepository interface {
+	ListKeys(ctx context.Context) ([]*models.EncryptionKey, error)
+	CreateKey(ctx context.Context, key *models.EncryptionKey) error
+	// Rewrap stores a key wrapped by another master key.
+	Rewrap(ctx context.Context, id, wrappedKey, masterKeyID string) error
+	// RetireDataKeys retires every active data key but keepID.
+	RetireDataKeys(ctx context.Context, keepID string, at time.Time) error
+	DeleteKey(ctx context.Context, id string) error
+
+	// FieldStats counts the values of every encrypted column by the key they
+	// are sealed with.
+	FieldStats(ctx context.Context) ([]*models.EncryptedFieldStats, error)
+	// CountSealedWith counts the values sealed with a data key across every
+	// encrypted column.
+	CountSealedWith(ctx context.Context, keyID string) (int64, error)
+	// Reencrypt seals up to limit values of each encrypted column that are
+	// not yet sealed with the active key, and returns how many it rewrote.
+	Reencrypt(ctx context.Context, limit int) (int, error)
+}
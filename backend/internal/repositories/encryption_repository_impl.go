@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/pkg/encryption"
+)
+
+type encryptionRepository struct {
+	db *database.DB
+}
+
+func NewEncryptionRepository(db *database.DB) EncryptionRepository {
+	return &encryptionRepository{db: db}
+}
+
+func (r *encryptionRepository) ListKeys(ctx context.Context) ([]*models.EncryptionKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, purpose, status, wrapped_key, master_key_id, created_at, retired_at
+		FROM encryption_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.EncryptionKey
+	for rows.Next() {
+		key := &models.EncryptionKey{}
+		var retiredAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Purpose, &key.Status, &key.WrappedKey, &key.MasterKeyID, &key.CreatedAt, &retiredAt); err != nil {
+			return nil, err
+		}
+		if retiredAt.Valid {
+			key.RetiredAt = &retiredAt.Time
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *encryptionRepository) CreateKey(ctx context.Context, key *models.EncryptionKey) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO encryption_keys (id, purpose, status, wrapped_key, master_key_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Purpose, key.Status, key.WrappedKey, key.MasterKeyID, key.CreatedAt)
+	return err
+}
+
+func (r *encryptionRepository) Rewrap(ctx context.Context, id, wrappedKey, masterKeyID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE encryption_keys SET wrapped_key = ?, master_key_id = ? WHERE id = ?`,
+		wrappedKey, masterKeyID, id)
+	return err
+}
+
+func (r *encryptionRepository) RetireDataKeys(ctx context.Context, keepID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE encryption_keys SET status = ?, retired_at = ?
+		WHERE purpose = ? AND status = ? AND id != ?`,
+		models.EncryptionKeyRetired, at, models.EncryptionKeyData, models.EncryptionKeyActive, keepID)
+	return err
+}
+
+func (r *encryptionRepository) DeleteKey(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM encryption_keys WHERE id = ? AND status = ?`, id, models.EncryptionKeyRetired)
+	return err
+}
+
+func (r *encryptionRepository) FieldStats(ctx context.Context) ([]*models.EncryptedFieldStats, error) {
+	stats := make([]*models.EncryptedFieldStats, 0, len(encryptedFields))
+	for _, field := range encryptedFields {
+		// The key ID sits between "enc:v1:" and the next colon
+		query := fmt.Sprintf(`SELECT CASE WHEN %[1]s LIKE 'enc:v1:%%' THEN substr(%[1]s, 8, instr(substr(%[1]s, 8), ':') - 1) ELSE '' END AS key_id,
+			COUNT(*) FROM %[2]s WHERE %[1]s IS NOT NULL AND %[1]s != '' GROUP BY key_id`, field.column, field.table)
+		rows, err := r.db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+
+		stat := &models.EncryptedFieldStats{Field: field.name, ByKey: map[string]int64{}}
+		for rows.Next() {
+			var keyID string
+			var count int64
+			if err := rows.Scan(&keyID, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			stat.Total += count
+			if keyID == "" {
+				stat.Plaintext += count
+			} else {
+				stat.ByKey[keyID] = count
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+func (r *encryptionRepository) CountSealedWith(ctx context.Context, keyID string) (int64, error) {
+	var total int64
+	for _, field := range encryptedFields {
+		var count int64
+		query := `SELECT COUNT(*) FROM ` + field.table + ` WHERE ` + field.column + ` LIKE ?`
+		if err := r.db.QueryRowContext(ctx, query, encryption.SealedPrefix(keyID)+"%").Scan(&count); err != nil {
+			return 0, fmt.Errorf("%s: %w", field.name, err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (r *encryptionRepository) Reencrypt(ctx context.Context, limit int) (int, error) {
+	keyring := r.db.Keyring()
+	if keyring == nil {
+		return 0, errNoKeyring
+	}
+	current := encryption.SealedPrefix(keyring.ActiveKeyID()) + "%"
+
+	rewritten := 0
+	for _, field := range encryptedFields {
+		n, err := r.reencryptField(ctx, field, current, limit)
+		if err != nil {
+			return rewritten, fmt.Errorf("%s: %w", field.name, err)
+		}
+		rewritten += n
+	}
+	return rewritten, nil
+}
+
+// reencryptField reseals up to limit values of field not matching current,
+// the prefix of values sealed with the active key. A value changed since it
+// was read is left for the next batch.
+func (r *encryptionRepository) reencryptField(ctx context.Context, field encryptedField, current string, limit int) (int, error) {
+	query := `SELECT ` + field.key + `, ` + field.column + ` FROM ` + field.table + `
+		WHERE ` + field.column + ` IS NOT NULL AND ` + field.column + ` != '' AND ` + field.column + ` NOT LIKE ? LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, current, limit)
+	if err != nil {
+		return 0, err
+	}
+	type stored struct{ key, value string }
+	var batch []stored
+	for rows.Next() {
+		var s stored
+		if err := rows.Scan(&s.key, &s.value); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	update := `UPDATE ` + field.table + ` SET ` + field.column + ` = ?`
+	if field.index != "" {
+		update += `, ` + field.index + ` = ?`
+	}
+	update += ` WHERE ` + field.key + ` = ? AND ` + field.column + ` = ?`
+
+	rewritten := 0
+	for _, s := range batch {
+		plaintext, err := openField(r.db, field.name, s.value)
+		if err != nil {
+			return rewritten, err
+		}
+		sealed, err := sealField(r.db, field.name, plaintext)
+		if err != nil {
+			return rewritten, err
+		}
+		args := []interface{}{sealed}
+		if field.index != "" {
+			index, err := blindIndex(r.db, field.name, &plaintext)
+			if err != nil {
+				return rewritten, err
+			}
+			args = append(args, index)
+		}
+		result, err := r.db.ExecContext(ctx, update, append(args, s.key, s.value)...)
+		if err != nil {
+			return rewritten, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			rewritten++
+		}
+	}
+	return rewritten, nil
+}
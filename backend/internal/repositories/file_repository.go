@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type FileRepository interface {
+	Create(ctx context.Context, file *models.UploadedFile) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UploadedFile, error)
+	DeleteByPath(ctx context.Context, path string) error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type fileRepository struct {
+	db *database.DB
+}
+
+func NewFileRepository(db *database.DB) FileRepository {
+	return &fileRepository{db: db}
+}
+
+func (r *fileRepository) Create(ctx context.Context, file *models.UploadedFile) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO uploaded_files (id, user_id, original_name, stored_name, path, size, content_type, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		file.ID.String(), file.UserID.String(), file.OriginalName, file.StoredName, file.Path, file.Size, file.ContentType, file.CreatedAt,
+	)
+	return err
+}
+
+func (r *fileRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UploadedFile, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, user_id, original_name, stored_name, path, size, content_type, created_at
+		FROM uploaded_files WHERE user_id = ? ORDER BY created_at`, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*models.UploadedFile
+	for rows.Next() {
+		file := &models.UploadedFile{}
+		if err := rows.Scan(&file.ID, &file.UserID, &file.OriginalName, &file.StoredName, &file.Path,
+			&file.Size, &file.ContentType, &file.CreatedAt); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+func (r *fileRepository) DeleteByPath(ctx context.Context, path string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM uploaded_files WHERE path = ?`, path)
+	return err
+}
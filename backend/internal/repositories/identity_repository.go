@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.UserIdentity, error)
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error)
+	UpdateLastLogin(ctx context.Context, id uuid.UUID, at time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state *models.OIDCAuthState) error
+	// Consume returns the state and deletes it so it cannot be replayed.
+	Consume(ctx context.Context, stateHash string) (*models.OIDCAuthState, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type IdentityLinkRepository interface {
+	Create(ctx context.Context, req *models.IdentityLinkRequest) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.IdentityLinkRequest, error)
+	MarkUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type identityRepository struct {
+	db *database.DB
+}
+
+func NewIdentityRepository(db *database.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	query := `INSERT INTO user_identities (id, user_id, provider, subject, email, email_verified, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query,
+		identity.ID.String(), identity.UserID.String(), identity.Provider, identity.Subject,
+		identity.Email, identity.EmailVerified, identity.CreatedAt, identity.LastLoginAt,
+	)
+	return err
+}
+
+const identityColumns = `id, user_id, provider, subject, email, email_verified, created_at, last_login_at`
+
+func scanIdentity(scanner interface{ Scan(...interface{}) error }) (*models.UserIdentity, error) {
+	identity := &models.UserIdentity{}
+	var email sql.NullString
+	var lastLoginAt sql.NullTime
+	if err := scanner.Scan(
+		&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject,
+		&email, &identity.EmailVerified, &identity.CreatedAt, &lastLoginAt,
+	); err != nil {
+		return nil, err
+	}
+	if email.Valid {
+		identity.Email = &email.String
+	}
+	if lastLoginAt.Valid {
+		identity.LastLoginAt = &lastLoginAt.Time
+	}
+	return identity, nil
+}
+
+// GetByID returns nil, nil when the identity does not exist.
+func (r *identityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE id = ?`
+	identity, err := scanIdentity(r.db.QueryRowContext(ctx, query, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return identity, err
+}
+
+// GetByProviderSubject returns nil, nil when the identity does not exist.
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE provider = ? AND subject = ?`
+	identity, err := scanIdentity(r.db.QueryRowContext(ctx, query, provider, subject))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return identity, err
+}
+
+func (r *identityRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE user_id = ? ORDER BY created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []*models.UserIdentity
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+func (r *identityRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE user_identities SET last_login_at = ? WHERE id = ?`, at, id.String())
+	return err
+}
+
+func (r *identityRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_identities WHERE id = ?`, id.String())
+	return err
+}
+
+type oidcStateRepository struct {
+	db *database.DB
+}
+
+func NewOIDCStateRepository(db *database.DB) OIDCStateRepository {
+	return &oidcStateRepository{db: db}
+}
+
+func (r *oidcStateRepository) Create(ctx context.Context, state *models.OIDCAuthState) error {
+	var linkUserID *string
+	if state.LinkUserID != nil {
+		value := state.LinkUserID.String()
+		linkUserID = &value
+	}
+	query := `INSERT INTO oidc_auth_states (state_hash, provider, code_verifier, nonce, link_user_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query,
+		state.StateHash, state.Provider, state.CodeVerifier, state.Nonce, linkUserID,
+		state.ExpiresAt, state.CreatedAt,
+	)
+	return err
+}
+
+// Consume returns nil, nil when the state is unknown or was already used.
+func (r *oidcStateRepository) Consume(ctx context.Context, stateHash string) (*models.OIDCAuthState, error) {
+	state := &models.OIDCAuthState{}
+	var linkUserID sql.NullString
+	query := `SELECT state_hash, provider, code_verifier, nonce, link_user_id, expires_at, created_at
+		FROM oidc_auth_states WHERE state_hash = ?`
+	err := r.db.QueryRowContext(ctx, query, stateHash).Scan(
+		&state.StateHash, &state.Provider, &state.CodeVerifier, &state.Nonce, &linkUserID,
+		&state.ExpiresAt, &state.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM oidc_auth_states WHERE state_hash = ?`, stateHash)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Consumed concurrently by another request
+		return nil, nil
+	}
+
+	if linkUserID.Valid {
+		if id, err := uuid.Parse(linkUserID.String); err == nil {
+			state.LinkUserID = &id
+		}
+	}
+	return state, nil
+}
+
+func (r *oidcStateRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oidc_auth_states WHERE expires_at < ?`, before)
+	return err
+}
+
+type identityLinkRepository struct {
+	db *database.DB
+}
+
+func NewIdentityLinkRepository(db *database.DB) IdentityLinkRepository {
+	return &identityLinkRepository{db: db}
+}
+
+func (r *identityLinkRepository) Create(ctx context.Context, req *models.IdentityLinkRequest) error {
+	query := `INSERT INTO identity_link_requests (id, token_hash, user_id, provider, subject, email, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query,
+		req.ID.String(), req.TokenHash, req.UserID.String(), req.Provider, req.Subject, req.Email,
+		req.ExpiresAt, req.CreatedAt,
+	)
+	return err
+}
+
+// GetByTokenHash returns nil, nil when no request matches.
+func (r *identityLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.IdentityLinkRequest, error) {
+	req := &models.IdentityLinkRequest{}
+	var usedAt sql.NullTime
+	query := `SELECT id, token_hash, user_id, provider, subject, email, expires_at, used_at, created_at
+		FROM identity_link_requests WHERE token_hash = ?`
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&req.ID, &req.TokenHash, &req.UserID, &req.Provider, &req.Subject, &req.Email,
+		&req.ExpiresAt, &usedAt, &req.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		req.UsedAt = &usedAt.Time
+	}
+	return req, nil
+}
+
+func (r *identityLinkRepository) MarkUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE identity_link_requests SET used_at = ? WHERE id = ? AND used_at IS NULL`, usedAt, id.String())
+	return err
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type LoginEventRepository interface {
+	Create(ctx context.Context, event *models.LoginEvent) error
+	ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.LoginEvent, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
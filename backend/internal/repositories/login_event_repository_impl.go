@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type loginEventRepository struct {
+	db *database.DB
+}
+
+func NewLoginEventRepository(db *database.DB) LoginEventRepository {
+	return &loginEventRepository{db: db}
+}
+
+func (r *loginEventRepository) Create(ctx context.Context, event *models.LoginEvent) error {
+	query := `
+		INSERT INTO login_events (
+			id, user_id, email, event_type, method, failure_reason, session_id,
+			ip_address, user_agent, device_id, device_type, os, browser,
+			location_country, location_city, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID.String(), event.UserID, event.Email, event.EventType, event.Method,
+		event.FailureReason, event.SessionID, event.IPAddress, event.UserAgent,
+		event.DeviceID, event.DeviceType, event.OS, event.Browser,
+		event.LocationCountry, event.LocationCity, event.CreatedAt,
+	)
+	return err
+}
+
+// ListByUserID returns the user's events, newest first.
+func (r *loginEventRepository) ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.LoginEvent, error) {
+	query := `
+		SELECT id, user_id, email, event_type, method, failure_reason, session_id,
+			ip_address, user_agent, device_id, device_type, os, browser,
+			location_country, location_city, created_at
+		FROM login_events
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID.String(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.LoginEvent
+	for rows.Next() {
+		event := &models.LoginEvent{}
+		if err := rows.Scan(
+			&event.ID, &event.UserID, &event.Email, &event.EventType, &event.Method,
+			&event.FailureReason, &event.SessionID, &event.IPAddress, &event.UserAgent,
+			&event.DeviceID, &event.DeviceType, &event.OS, &event.Browser,
+			&event.LocationCountry, &event.LocationCity, &event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *loginEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM login_events WHERE created_at < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type MagicLinkRepository interface {
+	Create(ctx context.Context, token *models.MagicLinkToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.MagicLinkToken, error)
+	// MarkUsed reports false when the link was already used, so a link signs in once.
+	MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// InvalidateByUserID marks every outstanding link for the user as used.
+	InvalidateByUserID(ctx context.Context, userID uuid.UUID, at time.Time) error
+	CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type SystemSettingsRepository interface {
+	Get(ctx context.Context, key string) (*models.SystemSetting, error)
+	List(ctx context.Context) ([]*models.SystemSetting, error)
+	Set(ctx context.Context, setting *models.SystemSetting) error
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type magicLinkRepository struct {
+	db *database.DB
+}
+
+func NewMagicLinkRepository(db *database.DB) MagicLinkRepository {
+	return &magicLinkRepository{db: db}
+}
+
+func (r *magicLinkRepository) Create(ctx context.Context, token *models.MagicLinkToken) error {
+	query := `INSERT INTO magic_link_tokens (id, user_id, token_hash, ip_address, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID.String(), token.UserID.String(), token.TokenHash, token.IPAddress, token.ExpiresAt, token.CreatedAt,
+	)
+	return err
+}
+
+// GetByTokenHash returns nil, nil when no link has the token.
+func (r *magicLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.MagicLinkToken, error) {
+	token := &models.MagicLinkToken{}
+	var ipAddress sql.NullString
+	var usedAt sql.NullTime
+	query := `SELECT id, user_id, token_hash, ip_address, expires_at, used_at, created_at
+		FROM magic_link_tokens WHERE token_hash = ?`
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.TokenHash, &ipAddress, &token.ExpiresAt, &usedAt, &token.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ipAddress.Valid {
+		token.IPAddress = &ipAddress.String
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	return token, nil
+}
+
+func (r *magicLinkRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE magic_link_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`,
+		at, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *magicLinkRepository) InvalidateByUserID(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE magic_link_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL`,
+		at, userID.String())
+	return err
+}
+
+func (r *magicLinkRepository) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM magic_link_tokens WHERE user_id = ? AND created_at >= ?`,
+		userID.String(), since).Scan(&count)
+	return count, err
+}
+
+func (r *magicLinkRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM magic_link_tokens WHERE expires_at < ?`, before)
+	return err
+}
+
+type systemSettingsRepository struct {
+	db *database.DB
+}
+
+func NewSystemSettingsRepository(db *database.DB) SystemSettingsRepository {
+	return &systemSettingsRepository{db: db}
+}
+
+func scanSystemSetting(scanner interface{ Scan(...interface{}) error }) (*models.SystemSetting, error) {
+	setting := &models.SystemSetting{}
+	var updatedBy sql.NullString
+	if err := scanner.Scan(&setting.Key, &setting.Value, &updatedBy, &setting.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if updatedBy.Valid {
+		if id, err := uuid.Parse(updatedBy.String); err == nil {
+			setting.UpdatedBy = &id
+		}
+	}
+	return setting, nil
+}
+
+// Get returns nil, nil when the setting has never been changed.
+func (r *systemSettingsRepository) Get(ctx context.Context, key string) (*models.SystemSetting, error) {
+	setting, err := scanSystemSetting(r.db.QueryRowContext(ctx,
+		`SELECT key, value, updated_by, updated_at FROM system_settings WHERE key = ?`, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return setting, err
+}
+
+func (r *systemSettingsRepository) List(ctx context.Context) ([]*models.SystemSetting, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT key, value, updated_by, updated_at FROM system_settings ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []*models.SystemSetting
+	for rows.Next() {
+		setting, err := scanSystemSetting(rows)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, setting)
+	}
+	return settings, rows.Err()
+}
+
+func (r *systemSettingsRepository) Set(ctx context.Context, setting *models.SystemSetting) error {
+	var updatedBy *string
+	if setting.UpdatedBy != nil {
+		value := setting.UpdatedBy.String()
+		updatedBy = &value
+	}
+	query := `INSERT INTO system_settings (key, value, updated_by, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_by = excluded.updated_by, updated_at = excluded.updated_at`
+	_, err := r.db.ExecContext(ctx, query, setting.Key, setting.Value, updatedBy, setting.UpdatedAt)
+	return err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *models.OAuthClient) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.OAuthClient, error)
+	GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	List(ctx context.Context) ([]*models.OAuthClient, error)
+	Update(ctx context.Context, client *models.OAuthClient) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type OAuthCodeRepository interface {
+	Create(ctx context.Context, code *models.OAuthAuthorizationCode) error
+	// Consume returns the code and deletes it so it can be redeemed once.
+	Consume(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type OAuthConsentRepository interface {
+	Get(ctx context.Context, userID uuid.UUID, clientID string) (*models.OAuthConsent, error)
+	Upsert(ctx context.Context, consent *models.OAuthConsent) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.OAuthConsent, error)
+	Delete(ctx context.Context, userID uuid.UUID, clientID string) error
+}
+
+type OAuthRefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.OAuthRefreshToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.OAuthRefreshToken, error)
+	// Revoke returns false when the token was already revoked.
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	RevokeBySession(ctx context.Context, sessionID uuid.UUID, at time.Time) error
+	RevokeByUserAndClient(ctx context.Context, userID uuid.UUID, clientID string, at time.Time) error
+	// ClientIDsBySession lists the clients that were issued tokens under a session.
+	ClientIDsBySession(ctx context.Context, sessionID uuid.UUID) ([]string, error)
+}
+
+type OAuthSigningKeyRepository interface {
+	Create(ctx context.Context, key *models.OAuthSigningKey) error
+	List(ctx context.Context) ([]*models.OAuthSigningKey, error)
+}
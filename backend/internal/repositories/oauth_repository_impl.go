@@ -0,0 +1,450 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+func marshalStrings(values []string) (string, error) {
+	if values == nil {
+		values = []string{}
+	}
+	b, err := json.Marshal(values)
+	return string(b), err
+}
+
+func unmarshalStrings(value sql.NullString, dest *[]string) error {
+	if !value.Valid || value.String == "" {
+		*dest = []string{}
+		return nil
+	}
+	return json.Unmarshal([]byte(value.String), dest)
+}
+
+type oauthClientRepository struct {
+	db *database.DB
+}
+
+func NewOAuthClientRepository(db *database.DB) OAuthClientRepository {
+	return &oauthClientRepository{db: db}
+}
+
+func (r *oauthClientRepository) Create(ctx context.Context, client *models.OAuthClient) error {
+	redirectURIs, err := marshalStrings(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+	logoutURIs, err := marshalStrings(client.PostLogoutRedirectURIs)
+	if err != nil {
+		return err
+	}
+	scopes, err := marshalStrings(client.AllowedScopes)
+	if err != nil {
+		return err
+	}
+	var createdBy *string
+	if client.CreatedBy != nil {
+		value := client.CreatedBy.String()
+		createdBy = &value
+	}
+
+	query := `
+		INSERT INTO oauth_clients (
+			id, client_id, client_secret_hash, name, product_name, redirect_uris,
+			post_logout_redirect_uris, backchannel_logout_uri, allowed_scopes,
+			skip_consent, is_active, created_by, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		client.ID.String(), client.ClientID, client.ClientSecretHash, client.Name, client.ProductName,
+		redirectURIs, logoutURIs, client.BackchannelLogoutURI, scopes,
+		client.SkipConsent, client.IsActive, createdBy, client.CreatedAt, client.UpdatedAt,
+	)
+	return err
+}
+
+const oauthClientColumns = `id, client_id, client_secret_hash, name, product_name, redirect_uris,
+	post_logout_redirect_uris, backchannel_logout_uri, allowed_scopes,
+	skip_consent, is_active, created_by, created_at, updated_at`
+
+func scanOAuthClient(scanner interface{ Scan(...interface{}) error }) (*models.OAuthClient, error) {
+	client := &models.OAuthClient{}
+	var secretHash, productName, backchannelURI, createdBy sql.NullString
+	var redirectURIs, logoutURIs, scopes sql.NullString
+
+	if err := scanner.Scan(
+		&client.ID, &client.ClientID, &secretHash, &client.Name, &productName, &redirectURIs,
+		&logoutURIs, &backchannelURI, &scopes,
+		&client.SkipConsent, &client.IsActive, &createdBy, &client.CreatedAt, &client.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalStrings(redirectURIs, &client.RedirectURIs); err != nil {
+		return nil, fmt.Errorf("decode oauth client redirect uris: %w", err)
+	}
+	if err := unmarshalStrings(logoutURIs, &client.PostLogoutRedirectURIs); err != nil {
+		return nil, fmt.Errorf("decode oauth client logout uris: %w", err)
+	}
+	if err := unmarshalStrings(scopes, &client.AllowedScopes); err != nil {
+		return nil, fmt.Errorf("decode oauth client scopes: %w", err)
+	}
+	if secretHash.Valid {
+		client.ClientSecretHash = &secretHash.String
+	}
+	if productName.Valid {
+		client.ProductName = &productName.String
+	}
+	if backchannelURI.Valid {
+		client.BackchannelLogoutURI = &backchannelURI.String
+	}
+	if createdBy.Valid {
+		if id, err := uuid.Parse(createdBy.String); err == nil {
+			client.CreatedBy = &id
+		}
+	}
+	return client, nil
+}
+
+// GetByID returns nil, nil when the client does not exist.
+func (r *oauthClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE id = ?`
+	client, err := scanOAuthClient(r.db.QueryRowContext(ctx, query, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return client, err
+}
+
+// GetByClientID returns nil, nil when the client does not exist.
+func (r *oauthClientRepository) GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE client_id = ?`
+	client, err := scanOAuthClient(r.db.QueryRowContext(ctx, query, clientID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return client, err
+}
+
+func (r *oauthClientRepository) List(ctx context.Context) ([]*models.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*models.OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+func (r *oauthClientRepository) Update(ctx context.Context, client *models.OAuthClient) error {
+	redirectURIs, err := marshalStrings(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+	logoutURIs, err := marshalStrings(client.PostLogoutRedirectURIs)
+	if err != nil {
+		return err
+	}
+	scopes, err := marshalStrings(client.AllowedScopes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE oauth_clients SET
+			client_secret_hash = ?, name = ?, product_name = ?, redirect_uris = ?,
+			post_logout_redirect_uris = ?, backchannel_logout_uri = ?, allowed_scopes = ?,
+			skip_consent = ?, is_active = ?, updated_at = ?
+		WHERE id = ?
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		client.ClientSecretHash, client.Name, client.ProductName, redirectURIs,
+		logoutURIs, client.BackchannelLogoutURI, scopes,
+		client.SkipConsent, client.IsActive, client.UpdatedAt, client.ID.String(),
+	)
+	return err
+}
+
+func (r *oauthClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oauth_clients WHERE id = ?`, id.String())
+	return err
+}
+
+type oauthCodeRepository struct {
+	db *database.DB
+}
+
+func NewOAuthCodeRepository(db *database.DB) OAuthCodeRepository {
+	return &oauthCodeRepository{db: db}
+}
+
+func (r *oauthCodeRepository) Create(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	scopes, err := marshalStrings(code.Scopes)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO oauth_authorization_codes (
+			code_hash, client_id, user_id, session_id, redirect_uri, scopes, nonce,
+			code_challenge, code_challenge_method, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		code.CodeHash, code.ClientID, code.UserID.String(), code.SessionID.String(), code.RedirectURI,
+		scopes, code.Nonce, code.CodeChallenge, code.CodeChallengeMethod, code.ExpiresAt, code.CreatedAt,
+	)
+	return err
+}
+
+// Consume returns nil, nil when the code is unknown or was already redeemed.
+func (r *oauthCodeRepository) Consume(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error) {
+	code := &models.OAuthAuthorizationCode{}
+	var scopes, nonce, challenge, challengeMethod sql.NullString
+	query := `SELECT code_hash, client_id, user_id, session_id, redirect_uri, scopes, nonce,
+			code_challenge, code_challenge_method, expires_at, created_at
+		FROM oauth_authorization_codes WHERE code_hash = ?`
+	err := r.db.QueryRowContext(ctx, query, codeHash).Scan(
+		&code.CodeHash, &code.ClientID, &code.UserID, &code.SessionID, &code.RedirectURI, &scopes, &nonce,
+		&challenge, &challengeMethod, &code.ExpiresAt, &code.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM oauth_authorization_codes WHERE code_hash = ?`, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Redeemed concurrently by another request
+		return nil, nil
+	}
+
+	if err := unmarshalStrings(scopes, &code.Scopes); err != nil {
+		return nil, fmt.Errorf("decode authorization code scopes: %w", err)
+	}
+	if nonce.Valid {
+		code.Nonce = &nonce.String
+	}
+	if challenge.Valid {
+		code.CodeChallenge = &challenge.String
+	}
+	if challengeMethod.Valid {
+		code.CodeChallengeMethod = &challengeMethod.String
+	}
+	return code, nil
+}
+
+func (r *oauthCodeRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oauth_authorization_codes WHERE expires_at < ?`, before)
+	return err
+}
+
+type oauthConsentRepository struct {
+	db *database.DB
+}
+
+func NewOAuthConsentRepository(db *database.DB) OAuthConsentRepository {
+	return &oauthConsentRepository{db: db}
+}
+
+func scanOAuthConsent(scanner interface{ Scan(...interface{}) error }) (*models.OAuthConsent, error) {
+	consent := &models.OAuthConsent{}
+	var scopes sql.NullString
+	if err := scanner.Scan(&consent.UserID, &consent.ClientID, &scopes, &consent.GrantedAt); err != nil {
+		return nil, err
+	}
+	if err := unmarshalStrings(scopes, &consent.Scopes); err != nil {
+		return nil, fmt.Errorf("decode consent scopes: %w", err)
+	}
+	return consent, nil
+}
+
+// Get returns nil, nil when the user has not consented to the client.
+func (r *oauthConsentRepository) Get(ctx context.Context, userID uuid.UUID, clientID string) (*models.OAuthConsent, error) {
+	query := `SELECT user_id, client_id, scopes, granted_at FROM oauth_consents WHERE user_id = ? AND client_id = ?`
+	consent, err := scanOAuthConsent(r.db.QueryRowContext(ctx, query, userID.String(), clientID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return consent, err
+}
+
+func (r *oauthConsentRepository) Upsert(ctx context.Context, consent *models.OAuthConsent) error {
+	scopes, err := marshalStrings(consent.Scopes)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO oauth_consents (user_id, client_id, scopes, granted_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, client_id) DO UPDATE SET scopes = excluded.scopes, granted_at = excluded.granted_at
+	`
+	_, err = r.db.ExecContext(ctx, query, consent.UserID.String(), consent.ClientID, scopes, consent.GrantedAt)
+	return err
+}
+
+func (r *oauthConsentRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.OAuthConsent, error) {
+	query := `SELECT user_id, client_id, scopes, granted_at FROM oauth_consents WHERE user_id = ? ORDER BY granted_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consents []*models.OAuthConsent
+	for rows.Next() {
+		consent, err := scanOAuthConsent(rows)
+		if err != nil {
+			return nil, err
+		}
+		consents = append(consents, consent)
+	}
+	return consents, rows.Err()
+}
+
+func (r *oauthConsentRepository) Delete(ctx context.Context, userID uuid.UUID, clientID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oauth_consents WHERE user_id = ? AND client_id = ?`, userID.String(), clientID)
+	return err
+}
+
+type oauthRefreshTokenRepository struct {
+	db *database.DB
+}
+
+func NewOAuthRefreshTokenRepository(db *database.DB) OAuthRefreshTokenRepository {
+	return &oauthRefreshTokenRepository{db: db}
+}
+
+func (r *oauthRefreshTokenRepository) Create(ctx context.Context, token *models.OAuthRefreshToken) error {
+	scopes, err := marshalStrings(token.Scopes)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO oauth_refresh_tokens (
+			id, token_hash, client_id, user_id, session_id, scopes, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		token.ID.String(), token.TokenHash, token.ClientID, token.UserID.String(), token.SessionID.String(),
+		scopes, token.ExpiresAt, token.CreatedAt,
+	)
+	return err
+}
+
+// GetByTokenHash returns nil, nil when no token matches.
+func (r *oauthRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.OAuthRefreshToken, error) {
+	token := &models.OAuthRefreshToken{}
+	var scopes sql.NullString
+	var revokedAt sql.NullTime
+	query := `SELECT id, token_hash, client_id, user_id, session_id, scopes, expires_at, revoked_at, created_at
+		FROM oauth_refresh_tokens WHERE token_hash = ?`
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.TokenHash, &token.ClientID, &token.UserID, &token.SessionID, &scopes,
+		&token.ExpiresAt, &revokedAt, &token.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := unmarshalStrings(scopes, &token.Scopes); err != nil {
+		return nil, fmt.Errorf("decode refresh token scopes: %w", err)
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (r *oauthRefreshTokenRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE oauth_refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, at, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *oauthRefreshTokenRepository) RevokeBySession(ctx context.Context, sessionID uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE oauth_refresh_tokens SET revoked_at = ? WHERE session_id = ? AND revoked_at IS NULL`, at, sessionID.String())
+	return err
+}
+
+func (r *oauthRefreshTokenRepository) RevokeByUserAndClient(ctx context.Context, userID uuid.UUID, clientID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE oauth_refresh_tokens SET revoked_at = ? WHERE user_id = ? AND client_id = ? AND revoked_at IS NULL`, at, userID.String(), clientID)
+	return err
+}
+
+func (r *oauthRefreshTokenRepository) ClientIDsBySession(ctx context.Context, sessionID uuid.UUID) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT client_id FROM oauth_refresh_tokens WHERE session_id = ?`, sessionID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clientIDs []string
+	for rows.Next() {
+		var clientID string
+		if err := rows.Scan(&clientID); err != nil {
+			return nil, err
+		}
+		clientIDs = append(clientIDs, clientID)
+	}
+	return clientIDs, rows.Err()
+}
+
+type oauthSigningKeyRepository struct {
+	db *database.DB
+}
+
+func NewOAuthSigningKeyRepository(db *database.DB) OAuthSigningKeyRepository {
+	return &oauthSigningKeyRepository{db: db}
+}
+
+func (r *oauthSigningKeyRepository) Create(ctx context.Context, key *models.OAuthSigningKey) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO oauth_signing_keys (kid, private_key, created_at) VALUES (?, ?, ?)`,
+		key.KID, key.PrivateKey, key.CreatedAt)
+	return err
+}
+
+// List returns keys newest first; the first key is the active signing key.
+func (r *oauthSigningKeyRepository) List(ctx context.Context) ([]*models.OAuthSigningKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT kid, private_key, created_at FROM oauth_signing_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.OAuthSigningKey
+	for rows.Next() {
+		key := &models.OAuthSigningKey{}
+		if err := rows.Scan(&key.KID, &key.PrivateKey, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
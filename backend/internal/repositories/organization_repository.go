@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type OrganizationRepository interface {
+	// Create stores the organization with owner as its first member.
+	Create(ctx context.Context, org *models.Organization, owner *models.OrganizationMember) error
+	// GetByID returns nil, nil when there is no such organization.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	// ListByUserID returns the user's organizations with Role set to theirs.
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error)
+	Update(ctx context.Context, org *models.Organization) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	AddMember(ctx context.Context, member *models.OrganizationMember) error
+	// GetMember returns nil, nil when the user is not a member.
+	GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error)
+	// ListMembers returns members with their email and name.
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error)
+	UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role string) error
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+	CountMembersWithRole(ctx context.Context, orgID uuid.UUID, role string) (int, error)
+}
+
+type OrganizationInvitationRepository interface {
+	Create(ctx context.Context, invitation *models.OrganizationInvitation) error
+	// GetByID and GetByTokenHash return nil, nil when nothing matches.
+	GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.OrganizationInvitation, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.OrganizationInvitation, error)
+	// ListPending returns the organization's unanswered, unexpired invitations.
+	ListPending(ctx context.Context, orgID uuid.UUID, now time.Time) ([]*models.OrganizationInvitation, error)
+	// MarkAccepted and Revoke report false when the invitation was already
+	// accepted or revoked, so each one is used once.
+	MarkAccepted(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// RevokePendingForEmail revokes earlier open invitations of email to the
+	// organization, when it is invited again.
+	RevokePendingForEmail(ctx context.Context, orgID uuid.UUID, email string, at time.Time) error
+}
@@ -0,0 +1,275 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type organizationRepository struct {
+	db *database.DB
+}
+
+func NewOrganizationRepository(db *database.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization, owner *models.OrganizationMember) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO organizations (id, name, created_by, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			org.ID.String(), org.Name, nullableUUID(org.CreatedBy), org.CreatedAt, org.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO organization_members (organization_id, user_id, role, created_at)
+			VALUES (?, ?, ?, ?)`,
+			owner.OrganizationID.String(), owner.UserID.String(), owner.Role, owner.CreatedAt,
+		)
+		return err
+	})
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	org := &models.Organization{}
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, created_by, created_at, updated_at
+		FROM organizations WHERE id = ?`, id.String()).Scan(
+		&org.ID, &org.Name, &org.CreatedBy, &org.CreatedAt, &org.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (r *organizationRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT o.id, o.name, o.created_by, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = ?
+		ORDER BY o.name`, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []*models.Organization
+	for rows.Next() {
+		org := &models.Organization{}
+		if err := rows.Scan(&org.ID, &org.Name, &org.CreatedBy, &org.CreatedAt, &org.UpdatedAt, &org.Role); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE organizations SET name = ?, updated_at = ? WHERE id = ?`,
+		org.Name, org.UpdatedAt, org.ID.String())
+	return err
+}
+
+func (r *organizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM organizations WHERE id = ?`, id.String())
+	return err
+}
+
+func (r *organizationRepository) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO organization_members (organization_id, user_id, role, created_at)
+		VALUES (?, ?, ?, ?)`,
+		member.OrganizationID.String(), member.UserID.String(), member.Role, member.CreatedAt)
+	return err
+}
+
+func (r *organizationRepository) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	member := &models.OrganizationMember{}
+	err := r.db.QueryRowContext(ctx, `SELECT organization_id, user_id, role, created_at
+		FROM organization_members WHERE organization_id = ? AND user_id = ?`,
+		orgID.String(), userID.String()).Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT m.organization_id, m.user_id, m.role, m.created_at, u.email, u.name
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = ?
+		ORDER BY m.created_at`, orgID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*models.OrganizationMember
+	for rows.Next() {
+		member := &models.OrganizationMember{}
+		if err := rows.Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt,
+			&member.Email, &member.Name); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r *organizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE organization_members SET role = ? WHERE organization_id = ? AND user_id = ?`,
+		role, orgID.String(), userID.String())
+	return err
+}
+
+func (r *organizationRepository) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM organization_members WHERE organization_id = ? AND user_id = ?`,
+		orgID.String(), userID.String())
+	return err
+}
+
+func (r *organizationRepository) CountMembersWithRole(ctx context.Context, orgID uuid.UUID, role string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM organization_members WHERE organization_id = ? AND role = ?`,
+		orgID.String(), role).Scan(&count)
+	return count, err
+}
+
+type organizationInvitationRepository struct {
+	db *database.DB
+}
+
+func NewOrganizationInvitationRepository(db *database.DB) OrganizationInvitationRepository {
+	return &organizationInvitationRepository{db: db}
+}
+
+const organizationInvitationColumns = `id, organization_id, email, role, token_hash, invited_by, expires_at,
+	accepted_at, accepted_by, revoked_at, created_at`
+
+func (r *organizationInvitationRepository) Create(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO organization_invitations (id, organization_id, email, role,
+		token_hash, invited_by, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		invitation.ID.String(), invitation.OrganizationID.String(), invitation.Email, invitation.Role,
+		invitation.TokenHash, nullableUUID(invitation.InvitedBy), invitation.ExpiresAt, invitation.CreatedAt,
+	)
+	return err
+}
+
+func (r *organizationInvitationRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.OrganizationInvitation, error) {
+	return r.getOne(ctx, `SELECT `+organizationInvitationColumns+` FROM organization_invitations
+		WHERE id = ? AND organization_id = ?`, id.String(), orgID.String())
+}
+
+func (r *organizationInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.OrganizationInvitation, error) {
+	return r.getOne(ctx, `SELECT `+organizationInvitationColumns+` FROM organization_invitations
+		WHERE token_hash = ?`, tokenHash)
+}
+
+func (r *organizationInvitationRepository) ListPending(ctx context.Context, orgID uuid.UUID, now time.Time) ([]*models.OrganizationInvitation, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+organizationInvitationColumns+` FROM organization_invitations
+		WHERE organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC`, orgID.String(), now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*models.OrganizationInvitation
+	for rows.Next() {
+		invitation, err := scanOrganizationInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *organizationInvitationRepository) MarkAccepted(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE organization_invitations SET accepted_at = ?, accepted_by = ?
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL`, at, userID.String(), id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *organizationInvitationRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE organization_invitations SET revoked_at = ?
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL`, at, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *organizationInvitationRepository) RevokePendingForEmail(ctx context.Context, orgID uuid.UUID, email string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE organization_invitations SET revoked_at = ?
+		WHERE organization_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL`,
+		at, orgID.String(), email)
+	return err
+}
+
+func (r *organizationInvitationRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.OrganizationInvitation, error) {
+	invitation, err := scanOrganizationInvitation(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return invitation, err
+}
+
+func scanOrganizationInvitation(scanner interface{ Scan(...interface{}) error }) (*models.OrganizationInvitation, error) {
+	invitation := &models.OrganizationInvitation{}
+	err := scanner.Scan(
+		&invitation.ID, &invitation.OrganizationID, &invitation.Email, &invitation.Role, &invitation.TokenHash,
+		&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.AcceptedBy,
+		&invitation.RevokedAt, &invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// tenantCondition limits a query to the records of tenant, given the table's
+// organization and owner columns: the organization's records, or the user's
+// own ones outside any organization.
+func tenantCondition(tenant *models.Tenant, orgColumn, ownerColumn string) (string, []interface{}) {
+	if tenant.Unrestricted {
+		return "1 = 1", nil
+	}
+	if tenant.OrganizationID != nil {
+		return orgColumn + " = ?", []interface{}{tenant.OrganizationID.String()}
+	}
+	return orgColumn + " IS NULL AND " + ownerColumn + " = ?", []interface{}{tenant.UserID.String()}
+}
+
+// organizationCondition matches rows of exactly the organization, or rows of
+// no organization when organizationID is nil.
+func organizationCondition(column string, organizationID *uuid.UUID) (string, []interface{}) {
+	if organizationID == nil {
+		return column + " IS NULL", nil
+	}
+	return column + " = ?", []interface{}{organizationID.String()}
+}
+
+// nullableUUID returns id as a string, or nil for a SQL NULL.
+func nullableUUID(id *uuid.UUID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type PasskeyRepository interface {
+	Create(ctx context.Context, credential *models.PasskeyCredential) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PasskeyCredential, error)
+	GetByCredentialID(ctx context.Context, credentialID string) (*models.PasskeyCredential, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.PasskeyCredential, error)
+	// RecordUse stores the sign count and backup state reported by a successful assertion.
+	RecordUse(ctx context.Context, id uuid.UUID, signCount uint32, backupState bool, at time.Time) error
+	MarkCloned(ctx context.Context, id uuid.UUID, at time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type WebAuthnCeremonyRepository interface {
+	Create(ctx context.Context, ceremony *models.WebAuthnCeremony) error
+	// Consume returns the ceremony and deletes it so its challenge is answered once.
+	Consume(ctx context.Context, tokenHash string) (*models.WebAuthnCeremony, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
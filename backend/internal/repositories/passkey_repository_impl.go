@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type passkeyRepository struct {
+	db *database.DB
+}
+
+func NewPasskeyRepository(db *database.DB) PasskeyRepository {
+	return &passkeyRepository{db: db}
+}
+
+func (r *passkeyRepository) Create(ctx context.Context, credential *models.PasskeyCredential) error {
+	transports, err := marshalStrings(credential.Transports)
+	if err != nil {
+		return err
+	}
+	var deviceID *string
+	if credential.DeviceID != nil {
+		value := credential.DeviceID.String()
+		deviceID = &value
+	}
+	query := `INSERT INTO passkey_credentials (id, user_id, device_id, credential_id, public_key, attestation_type, aaguid,
+		sign_count, transports, backup_eligible, backup_state, name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.db.ExecContext(ctx, query,
+		credential.ID.String(), credential.UserID.String(), deviceID, credential.CredentialID, credential.PublicKey,
+		credential.AttestationType, credential.AAGUID, credential.SignCount, transports,
+		credential.BackupEligible, credential.BackupState, credential.Name, credential.CreatedAt,
+	)
+	return err
+}
+
+const passkeyColumns = `id, user_id, device_id, credential_id, public_key, attestation_type, aaguid, sign_count,
+	transports, backup_eligible, backup_state, name, clone_detected_at, created_at, last_used_at`
+
+func scanPasskey(scanner interface{ Scan(...interface{}) error }) (*models.PasskeyCredential, error) {
+	credential := &models.PasskeyCredential{}
+	var deviceID, attestationType, aaguid, transports sql.NullString
+	var cloneDetectedAt, lastUsedAt sql.NullTime
+	if err := scanner.Scan(
+		&credential.ID, &credential.UserID, &deviceID, &credential.CredentialID, &credential.PublicKey,
+		&attestationType, &aaguid, &credential.SignCount, &transports,
+		&credential.BackupEligible, &credential.BackupState, &credential.Name,
+		&cloneDetectedAt, &credential.CreatedAt, &lastUsedAt,
+	); err != nil {
+		return nil, err
+	}
+	if deviceID.Valid {
+		if id, err := uuid.Parse(deviceID.String); err == nil {
+			credential.DeviceID = &id
+		}
+	}
+	credential.AttestationType = attestationType.String
+	if aaguid.Valid {
+		credential.AAGUID = &aaguid.String
+	}
+	if err := unmarshalStrings(transports, &credential.Transports); err != nil {
+		return nil, err
+	}
+	if cloneDetectedAt.Valid {
+		credential.CloneDetectedAt = &cloneDetectedAt.Time
+	}
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = &lastUsedAt.Time
+	}
+	return credential, nil
+}
+
+// GetByID returns nil, nil when the passkey does not exist.
+func (r *passkeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PasskeyCredential, error) {
+	query := `SELECT ` + passkeyColumns + ` FROM passkey_credentials WHERE id = ?`
+	credential, err := scanPasskey(r.db.QueryRowContext(ctx, query, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return credential, err
+}
+
+// GetByCredentialID returns nil, nil when no passkey has the credential ID.
+func (r *passkeyRepository) GetByCredentialID(ctx context.Context, credentialID string) (*models.PasskeyCredential, error) {
+	query := `SELECT ` + passkeyColumns + ` FROM passkey_credentials WHERE credential_id = ?`
+	credential, err := scanPasskey(r.db.QueryRowContext(ctx, query, credentialID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return credential, err
+}
+
+func (r *passkeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.PasskeyCredential, error) {
+	query := `SELECT ` + passkeyColumns + ` FROM passkey_credentials WHERE user_id = ? ORDER BY created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []*models.PasskeyCredential
+	for rows.Next() {
+		credential, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, rows.Err()
+}
+
+func (r *passkeyRepository) RecordUse(ctx context.Context, id uuid.UUID, signCount uint32, backupState bool, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE passkey_credentials SET sign_count = ?, backup_state = ?, last_used_at = ? WHERE id = ?`,
+		signCount, backupState, at, id.String())
+	return err
+}
+
+func (r *passkeyRepository) MarkCloned(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE passkey_credentials SET clone_detected_at = ? WHERE id = ? AND clone_detected_at IS NULL`,
+		at, id.String())
+	return err
+}
+
+func (r *passkeyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM passkey_credentials WHERE id = ?`, id.String())
+	return err
+}
+
+type webAuthnCeremonyRepository struct {
+	db *database.DB
+}
+
+func NewWebAuthnCeremonyRepository(db *database.DB) WebAuthnCeremonyRepository {
+	return &webAuthnCeremonyRepository{db: db}
+}
+
+func (r *webAuthnCeremonyRepository) Create(ctx context.Context, ceremony *models.WebAuthnCeremony) error {
+	var userID *string
+	if ceremony.UserID != nil {
+		value := ceremony.UserID.String()
+		userID = &value
+	}
+	query := `INSERT INTO webauthn_ceremonies (token_hash, ceremony, user_id, session_data, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query,
+		ceremony.TokenHash, ceremony.Ceremony, userID, ceremony.SessionData, ceremony.ExpiresAt, ceremony.CreatedAt,
+	)
+	return err
+}
+
+// Consume returns nil, nil when the ceremony is unknown or was already used.
+func (r *webAuthnCeremonyRepository) Consume(ctx context.Context, tokenHash string) (*models.WebAuthnCeremony, error) {
+	ceremony := &models.WebAuthnCeremony{}
+	var userID sql.NullString
+	query := `SELECT token_hash, ceremony, user_id, session_data, expires_at, created_at
+		FROM webauthn_ceremonies WHERE token_hash = ?`
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&ceremony.TokenHash, &ceremony.Ceremony, &userID, &ceremony.SessionData, &ceremony.ExpiresAt, &ceremony.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webauthn_ceremonies WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Consumed concurrently by another request
+		return nil, nil
+	}
+
+	if userID.Valid {
+		if id, err := uuid.Parse(userID.String); err == nil {
+			ceremony.UserID = &id
+		}
+	}
+	return ceremony, nil
+}
+
+func (r *webAuthnCeremonyRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webauthn_ceremonies WHERE expires_at < ?`, before)
+	return err
+}
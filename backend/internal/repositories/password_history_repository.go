@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, entry *models.PasswordHistoryEntry) error
+	ListRecent(ctx context.Context, userID uuid.UUID, limit int) ([]*models.PasswordHistoryEntry, error)
+	DeleteAllButRecent(ctx context.Context, userID uuid.UUID, keep int) error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type passwordHistoryRepository struct {
+	db *database.DB
+}
+
+func NewPasswordHistoryRepository(db *database.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, entry *models.PasswordHistoryEntry) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO password_history (id, user_id, password_hash, created_at) VALUES (?, ?, ?, ?)`,
+		entry.ID.String(), entry.UserID.String(), entry.PasswordHash, entry.CreatedAt)
+	return err
+}
+
+// ListRecent returns the user's most recent previous passwords, newest first.
+func (r *passwordHistoryRepository) ListRecent(ctx context.Context, userID uuid.UUID, limit int) ([]*models.PasswordHistoryEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, user_id, password_hash, created_at FROM password_history
+		WHERE user_id = ? ORDER BY created_at DESC LIMIT ?`, userID.String(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.PasswordHistoryEntry
+	for rows.Next() {
+		entry := &models.PasswordHistoryEntry{}
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.PasswordHash, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (r *passwordHistoryRepository) DeleteAllButRecent(ctx context.Context, userID uuid.UUID, keep int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM password_history WHERE user_id = ? AND id NOT IN (
+		SELECT id FROM password_history WHERE user_id = ? ORDER BY created_at DESC LIMIT ?)`,
+		userID.String(), userID.String(), keep)
+	return err
+}
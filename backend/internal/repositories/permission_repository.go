@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *models.Role) error
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+	List(ctx context.Context) ([]*models.Role, error)
+	Update(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+}
+
+// PermissionRepository stores permissions granted directly to a user, on top
+// of those bundled in the user's role.
+type PermissionRepository interface {
+	Grant(ctx context.Context, permission *models.AdminPermission) error
+	Revoke(ctx context.Context, userID uuid.UUID, permissionName string) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.AdminPermission, error)
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type roleRepository struct {
+	db *database.DB
+}
+
+func NewRoleRepository(db *database.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO roles (id, name, description, permissions, is_system, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.db.ExecContext(ctx, query,
+		role.ID.String(), role.Name, role.Description, string(permissions), role.IsSystem,
+		role.CreatedAt, role.UpdatedAt,
+	)
+	return err
+}
+
+const roleColumns = `id, name, description, permissions, is_system, created_at, updated_at`
+
+func scanRole(scanner interface{ Scan(...interface{}) error }) (*models.Role, error) {
+	role := &models.Role{}
+	var description sql.NullString
+	var permissions string
+	if err := scanner.Scan(
+		&role.ID, &role.Name, &description, &permissions, &role.IsSystem,
+		&role.CreatedAt, &role.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if description.Valid {
+		role.Description = &description.String
+	}
+	if err := json.Unmarshal([]byte(permissions), &role.Permissions); err != nil {
+		return nil, fmt.Errorf("decode role permissions: %w", err)
+	}
+	return role, nil
+}
+
+// GetByName returns nil, nil when the role does not exist.
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE name = ?`
+	role, err := scanRole(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return role, err
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]*models.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles ORDER BY is_system DESC, name ASC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*models.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *roleRepository) Update(ctx context.Context, role *models.Role) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE roles SET description = ?, permissions = ?, updated_at = ? WHERE name = ?`
+	_, err = r.db.ExecContext(ctx, query, role.Description, string(permissions), time.Now(), role.Name)
+	return err
+}
+
+func (r *roleRepository) Delete(ctx context.Context, name string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM roles WHERE name = ? AND is_system = 0`, name)
+	return err
+}
+
+type permissionRepository struct {
+	db *database.DB
+}
+
+func NewPermissionRepository(db *database.DB) PermissionRepository {
+	return &permissionRepository{db: db}
+}
+
+func (r *permissionRepository) Grant(ctx context.Context, permission *models.AdminPermission) error {
+	var grantedBy *string
+	if permission.GrantedBy != nil {
+		value := permission.GrantedBy.String()
+		grantedBy = &value
+	}
+	query := `INSERT INTO admin_permissions (id, admin_id, permission_name, granted_at, granted_by)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(admin_id, permission_name) DO UPDATE SET granted_at = excluded.granted_at, granted_by = excluded.granted_by`
+	_, err := r.db.ExecContext(ctx, query,
+		permission.ID.String(), permission.AdminID.String(), permission.PermissionName,
+		permission.GrantedAt, grantedBy,
+	)
+	return err
+}
+
+func (r *permissionRepository) Revoke(ctx context.Context, userID uuid.UUID, permissionName string) error {
+	query := `DELETE FROM admin_permissions WHERE admin_id = ? AND permission_name = ?`
+	_, err := r.db.ExecContext(ctx, query, userID.String(), permissionName)
+	return err
+}
+
+func (r *permissionRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.AdminPermission, error) {
+	query := `SELECT id, admin_id, permission_name, granted_at, granted_by
+		FROM admin_permissions WHERE admin_id = ? ORDER BY permission_name ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []*models.AdminPermission
+	for rows.Next() {
+		p := &models.AdminPermission{}
+		var grantedBy sql.NullString
+		if err := rows.Scan(&p.ID, &p.AdminID, &p.PermissionName, &p.GrantedAt, &grantedBy); err != nil {
+			return nil, err
+		}
+		if grantedBy.Valid {
+			if id, err := uuid.Parse(grantedBy.String); err == nil {
+				p.GrantedBy = &id
+			}
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
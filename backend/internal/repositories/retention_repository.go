@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"base-app-service/internal/models"
+)
+
+type RetentionRepository interface {
+	// ListRules returns the stored rules. Classes without one use defaults.
+	ListRules(ctx context.Context) ([]*models.RetentionRule, error)
+	SaveRule(ctx context.Context, rule *models.RetentionRule) error
+	// Count returns how many rows the rule's data class holds and how many of
+	// them the rule would purge at the time.
+	Count(ctx context.Context, rule *models.RetentionRule, now time.Time) (total int64, matched int64, err error)
+	// NextBatch returns up to limit of the rows the rule would purge, oldest
+	// first, without credentials. Each has its id.
+	NextBatch(ctx context.Context, rule *models.RetentionRule, now time.Time, limit int) ([]map[string]interface{}, error)
+	// DeleteRows deletes rows of the data class by id.
+	DeleteRows(ctx context.Context, dataClass string, ids []interface{}) (int64, error)
+
+	CreateRun(ctx context.Context, run *models.RetentionRun) error
+	// ListRuns returns the latest runs, newest first.
+	ListRuns(ctx context.Context, limit int) ([]*models.RetentionRun, error)
+}
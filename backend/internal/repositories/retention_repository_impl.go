@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type retentionRepository struct {
+	db *database.DB
+}
+
+func NewRetentionRepository(db *database.DB) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+// retentionClass is where a data class lives. age is the expression rows are
+// aged by; only rows matching condition are ever purged, and each ? in it
+// takes the current time. omit lists columns left out of archives.
+type retentionClass struct {
+	table     string
+	age       string
+	condition string
+	omit      []string
+}
+
+var retentionClasses = map[string]retentionClass{
+	models.RetentionActivityLogs:  {table: "activity_logs", age: "created_at", condition: "1 = 1"},
+	models.RetentionNotifications: {table: "notifications", age: "created_at", condition: "1 = 1"},
+	models.RetentionSearchHistory: {table: "search_history", age: "created_at", condition: "1 = 1"},
+	// Only events that are done being delivered
+	models.RetentionWebhookEvents: {table: "webhook_events", age: "created_at", condition: "status IN ('delivered', 'failed')",
+		omit: []string{"webhook_secret"}},
+	// Revoked sessions, and expired ones that can no longer be refreshed
+	models.RetentionSessions: {table: "sessions", age: "COALESCE(revoked_at, refresh_token_expires_at, expires_at)",
+		condition: "revoked_at IS NOT NULL OR (expires_at < ? AND (refresh_token_expires_at IS NULL OR refresh_token_expires_at < ?))",
+		omit:      []string{"token", "refresh_token"}},
+	// Used and expired tokens
+	models.RetentionPasswordResetTokens: {table: "password_reset_tokens", age: "COALESCE(used_at, expires_at)",
+		condition: "used_at IS NOT NULL OR expires_at < ?", omit: []string{"token"}},
+}
+
+func (r *retentionRepository) ListRules(ctx context.Context) ([]*models.RetentionRule, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT data_class, enabled, max_age_days, max_rows, archive, updated_by, updated_at
+		FROM retention_rules ORDER BY data_class`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.RetentionRule
+	for rows.Next() {
+		rule := &models.RetentionRule{}
+		var maxAgeDays, maxRows sql.NullInt64
+		var updatedBy sql.NullString
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&rule.DataClass, &rule.Enabled, &maxAgeDays, &maxRows, &rule.Archive, &updatedBy, &updatedAt); err != nil {
+			return nil, err
+		}
+		if maxAgeDays.Valid {
+			days := int(maxAgeDays.Int64)
+			rule.MaxAgeDays = &days
+		}
+		if maxRows.Valid {
+			n := int(maxRows.Int64)
+			rule.MaxRows = &n
+		}
+		if updatedBy.Valid {
+			id, _ := uuid.Parse(updatedBy.String)
+			rule.UpdatedBy = &id
+		}
+		if updatedAt.Valid {
+			rule.UpdatedAt = &updatedAt.Time
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *retentionRepository) SaveRule(ctx context.Context, rule *models.RetentionRule) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO retention_rules (data_class, enabled, max_age_days, max_rows, archive, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(data_class) DO UPDATE SET enabled = excluded.enabled, max_age_days = excluded.max_age_days,
+			max_rows = excluded.max_rows, archive = excluded.archive, updated_by = excluded.updated_by, updated_at = excluded.updated_at`,
+		rule.DataClass, rule.Enabled, rule.MaxAgeDays, rule.MaxRows, rule.Archive, nullableUUID(rule.UpdatedBy), rule.UpdatedAt,
+	)
+	return err
+}
+
+// match returns the WHERE clause selecting the rows rule would purge, or ""
+// when it has no limits.
+func (c retentionClass) match(rule *models.RetentionRule, now time.Time) (string, []interface{}) {
+	condition, args := c.eligible(now)
+	var limits []string
+	if rule.MaxAgeDays != nil {
+		limits = append(limits, c.age+" < ?")
+		args = append(args, now.AddDate(0, 0, -*rule.MaxAgeDays))
+	}
+	if rule.MaxRows != nil {
+		keep, keepArgs := c.eligible(now)
+		limits = append(limits, `id NOT IN (SELECT id FROM `+c.table+` WHERE `+keep+` ORDER BY `+c.age+` DESC LIMIT ?)`)
+		args = append(append(args, keepArgs...), *rule.MaxRows)
+	}
+	if len(limits) == 0 {
+		return "", nil
+	}
+	return condition + " AND (" + strings.Join(limits, " OR ") + ")", args
+}
+
+func (c retentionClass) eligible(now time.Time) (string, []interface{}) {
+	args := make([]interface{}, strings.Count(c.condition, "?"))
+	for i := range args {
+		args[i] = now
+	}
+	return "(" + c.condition + ")", args
+}
+
+func (r *retentionRepository) Count(ctx context.Context, rule *models.RetentionRule, now time.Time) (int64, int64, error) {
+	class, ok := retentionClasses[rule.DataClass]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown data class %q", rule.DataClass)
+	}
+	var total, matched int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+class.table).Scan(&total); err != nil {
+		return 0, 0, err
+	}
+	where, args := class.match(rule, now)
+	if where == "" {
+		return total, 0, nil
+	}
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+class.table+` WHERE `+where, args...).Scan(&matched); err != nil {
+		return 0, 0, err
+	}
+	return total, matched, nil
+}
+
+func (r *retentionRepository) NextBatch(ctx context.Context, rule *models.RetentionRule, now time.Time, limit int) ([]map[string]interface{}, error) {
+	class, ok := retentionClasses[rule.DataClass]
+	if !ok {
+		return nil, fmt.Errorf("unknown data class %q", rule.DataClass)
+	}
+	where, args := class.match(rule, now)
+	if where == "" {
+		return nil, nil
+	}
+	query := `SELECT * FROM ` + class.table + ` WHERE ` + where + ` ORDER BY ` + class.age + ` LIMIT ?`
+	return queryRecords(ctx, r.db, query, append(args, limit), class.omit)
+}
+
+func (r *retentionRepository) DeleteRows(ctx context.Context, dataClass string, ids []interface{}) (int64, error) {
+	class, ok := retentionClasses[dataClass]
+	if !ok {
+		return 0, fmt.Errorf("unknown data class %q", dataClass)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	result, err := r.db.ExecContext(ctx, `DELETE FROM `+class.table+` WHERE id IN (`+placeholders+`)`, ids...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *retentionRepository) CreateRun(ctx context.Context, run *models.RetentionRun) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO retention_runs
+		(id, data_class, rows_purged, batches, archive_path, error, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID.String(), run.DataClass, run.RowsPurged, run.Batches, run.ArchivePath, run.Error, run.StartedAt, run.FinishedAt,
+	)
+	return err
+}
+
+func (r *retentionRepository) ListRuns(ctx context.Context, limit int) ([]*models.RetentionRun, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, data_class, rows_purged, batches, archive_path, error, started_at, finished_at
+		FROM retention_runs ORDER BY started_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*models.RetentionRun
+	for rows.Next() {
+		run := &models.RetentionRun{}
+		if err := rows.Scan(&run.ID, &run.DataClass, &run.RowsPurged, &run.Batches, &run.ArchivePath, &run.Error,
+			&run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type SCIMTokenRepository interface {
+	Create(ctx context.Context, token *models.SCIMToken) error
+	// GetByHash returns nil, nil when no token has the hash.
+	GetByHash(ctx context.Context, tokenHash string) (*models.SCIMToken, error)
+	List(ctx context.Context) ([]*models.SCIMToken, error)
+	// Revoke reports false when the token does not exist or was already revoked.
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) (bool, error)
+	UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type scimTokenRepository struct {
+	db *database.DB
+}
+
+func NewSCIMTokenRepository(db *database.DB) SCIMTokenRepository {
+	return &scimTokenRepository{db: db}
+}
+
+const scimTokenColumns = `id, name, token_prefix, token_hash, created_by, last_used_at, revoked_at, created_at`
+
+func (r *scimTokenRepository) Create(ctx context.Context, token *models.SCIMToken) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO scim_tokens (id, name, token_prefix, token_hash, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		token.ID.String(), token.Name, token.TokenPrefix, token.TokenHash, nullableUUID(token.CreatedBy), token.CreatedAt,
+	)
+	return err
+}
+
+func (r *scimTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.SCIMToken, error) {
+	token, err := scanSCIMToken(r.db.QueryRowContext(ctx, `SELECT `+scimTokenColumns+` FROM scim_tokens WHERE token_hash = ?`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+func (r *scimTokenRepository) List(ctx context.Context) ([]*models.SCIMToken, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+scimTokenColumns+` FROM scim_tokens ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*models.SCIMToken
+	for rows.Next() {
+		token, err := scanSCIMToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *scimTokenRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE scim_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		revokedAt, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *scimTokenRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE scim_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id.String())
+	return err
+}
+
+func scanSCIMToken(scanner interface{ Scan(...interface{}) error }) (*models.SCIMToken, error) {
+	token := &models.SCIMToken{}
+	err := scanner.Scan(
+		&token.ID, &token.Name, &token.TokenPrefix, &token.TokenHash, &token.CreatedBy,
+		&token.LastUsedAt, &token.RevokedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
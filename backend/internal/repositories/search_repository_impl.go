@@ -79,26 +79,31 @@ func (r *searchRepository) ClearSearchHistory(ctx context.Context, userID uuid.U
 	return err
 }
 
-func (r *searchRepository) SearchDashboardItems(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.DashboardItem, error) {
+func (r *searchRepository) SearchDashboardItems(ctx context.Context, tenant *models.Tenant, query string, limit int) ([]*models.DashboardItem, error) {
+	condition, args := tenantCondition(tenant, "dashboard_items.organization_id", "dashboard_items.user_id")
+	columns := `dashboard_items.id, dashboard_items.user_id, dashboard_items.organization_id, dashboard_items.title,
+		dashboard_items.description, dashboard_items.category, dashboard_items.status, dashboard_items.priority,
+		dashboard_items.metadata, dashboard_items.created_at, dashboard_items.updated_at, dashboard_items.deleted_at`
+
 	// Use FTS5 for full-text search
-	ftsQuery := `SELECT id, user_id, title, description, category, status, priority, metadata, created_at, updated_at, deleted_at
+	ftsQuery := `SELECT ` + columns + `
 		FROM dashboard_items_fts
 		JOIN dashboard_items ON dashboard_items_fts.id = dashboard_items.id
-		WHERE dashboard_items.user_id = ? AND dashboard_items_fts MATCH ?
+		WHERE ` + condition + ` AND dashboard_items_fts MATCH ?
 		ORDER BY rank LIMIT ?`
 	
 	// Format query for FTS5 (space-separated terms)
 	searchTerms := strings.Join(strings.Fields(query), " OR ")
 	
-	rows, err := r.db.QueryContext(ctx, ftsQuery, userID.String(), searchTerms, limit)
+	rows, err := r.db.QueryContext(ctx, ftsQuery, append(args, searchTerms, limit)...)
 	if err != nil {
 		// Fallback to simple LIKE search if FTS5 fails
-		fallbackQuery := `SELECT id, user_id, title, description, category, status, priority, metadata, created_at, updated_at, deleted_at
+		fallbackQuery := `SELECT ` + columns + `
 			FROM dashboard_items
-			WHERE user_id = ? AND (title LIKE ? OR description LIKE ?)
+			WHERE ` + condition + ` AND (title LIKE ? OR description LIKE ?)
 			ORDER BY created_at DESC LIMIT ?`
 		searchPattern := "%" + query + "%"
-		rows, err = r.db.QueryContext(ctx, fallbackQuery, userID.String(), searchPattern, searchPattern, limit)
+		rows, err = r.db.QueryContext(ctx, fallbackQuery, append(args, searchPattern, searchPattern, limit)...)
 		if err != nil {
 			return nil, err
 		}
@@ -107,33 +112,11 @@ func (r *searchRepository) SearchDashboardItems(ctx context.Context, userID uuid
 
 	var items []*models.DashboardItem
 	for rows.Next() {
-		var item models.DashboardItem
-		var userIDStr, idStr string
-		var description, category, metadata sql.NullString
-		var deletedAt sql.NullTime
-
-		err := rows.Scan(&idStr, &userIDStr, &item.Title, &description, &category,
-			&item.Status, &item.Priority, &metadata, &item.CreatedAt, &item.UpdatedAt, &deletedAt)
+		item, err := scanDashboardItem(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		item.ID, _ = uuid.Parse(idStr)
-		item.UserID, _ = uuid.Parse(userIDStr)
-		if description.Valid {
-			item.Description = &description.String
-		}
-		if category.Valid {
-			item.Category = &category.String
-		}
-		if metadata.Valid {
-			item.Metadata = &metadata.String
-		}
-		if deletedAt.Valid {
-			item.DeletedAt = &deletedAt.Time
-		}
-
-		items = append(items, &item)
+		items = append(items, item)
 	}
 
 	return items, rows.Err()
@@ -200,17 +183,39 @@ func (r *searchRepository) SearchMessages(ctx context.Context, userID uuid.UUID,
 	return messages, rows.Err()
 }
 
+// searchableUserCondition leaves out users who opted out of search,
+// deactivated their account, or blocked or were blocked by the searcher; it
+// takes the searcher's ID twice. Queries join the users' settings as usc.
+// Users who never saved settings have the defaults and can be found.
+const searchableUserCondition = `COALESCE(usc.search_visibility, 1) = 1 AND COALESCE(usc.account_deactivated, 0) = 0
+	AND NOT EXISTS (SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = u.id AND b.blocked_id = ?) OR (b.blocker_id = ? AND b.blocked_id = u.id))`
+
+// friendOfSearcherCondition matches users who are friends with the searcher,
+// whose ID it takes twice.
+const friendOfSearcherCondition = `EXISTS (SELECT 1 FROM user_connections c WHERE c.status = 'accepted'
+	AND ((c.requester_id = u.id AND c.addressee_id = ?) OR (c.requester_id = ? AND c.addressee_id = u.id)))`
+
+// profileVisibleToSearcherCondition matches users whose profile the searcher
+// may see, like PrivacyService does; it takes the searcher's ID twice.
+const profileVisibleToSearcherCondition = `(COALESCE(usc.profile_visibility, 'public') = 'public'
+	OR (usc.profile_visibility = 'friends' AND ` + friendOfSearcherCondition + `))`
+
 func (r *searchRepository) SearchUsers(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.User, error) {
-	// Simple LIKE search for users (name, email)
+	// Simple LIKE search for users by name, and by email where the searcher may see it
 	searchPattern := "%" + query + "%"
-	searchQuery := `SELECT id, email, email_verified, email_verification_token, password_hash, password_changed_at,
-		name, first_name, last_name, photo_url, role, phone, phone_verified, status, signup_source,
-		created_at, updated_at, last_login_at
-		FROM users
-		WHERE (name LIKE ? OR email LIKE ?) AND id != ? AND status IN ('active', 'pending')
-		ORDER BY name LIMIT ?`
+	searchQuery := `SELECT u.id, u.email, u.email_verified, u.email_verification_token, u.password_hash, u.password_changed_at,
+		u.name, u.first_name, u.last_name, u.photo_url, u.role, u.phone, u.phone_verified, u.status, u.signup_source,
+		u.created_at, u.updated_at, u.last_login_at
+		FROM users u
+		LEFT JOIN user_settings_comprehensive usc ON usc.user_id = u.id
+		WHERE (u.name LIKE ? OR (u.email LIKE ? AND (COALESCE(usc.email_visibility, 'private') = 'public'
+				OR (usc.email_visibility = 'friends' AND ` + friendOfSearcherCondition + `))))
+			AND u.id != ? AND u.status IN ('active', 'pending') AND ` + searchableUserCondition + `
+		ORDER BY u.name LIMIT ?`
 	
-	rows, err := r.db.QueryContext(ctx, searchQuery, searchPattern, searchPattern, userID.String(), limit)
+	viewer := userID.String()
+	rows, err := r.db.QueryContext(ctx, searchQuery, searchPattern, searchPattern, viewer, viewer, viewer, viewer, viewer, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -242,7 +247,9 @@ func (r *searchRepository) SearchUsers(ctx context.Context, userID uuid.UUID, qu
 			u.PhotoURL = &photoURL.String
 		}
 		if phone.Valid {
-			u.Phone = &phone.String
+			if u.Phone, err = openNullable(r.db, fieldUserPhone, &phone.String); err != nil {
+				return nil, err
+			}
 		}
 		if signupSource.Valid {
 			u.SignupSource = &signupSource.String
@@ -265,9 +272,14 @@ func (r *searchRepository) SearchUsersByLocation(ctx context.Context, userID uui
 		u.created_at, u.updated_at, u.last_login_at
 		FROM users u
 		LEFT JOIN sessions s ON s.user_id = u.id
-		WHERE u.id != ? AND u.status IN ('active', 'pending')`
+		LEFT JOIN user_settings_comprehensive usc ON usc.user_id = u.id
+		WHERE u.id != ? AND u.status IN ('active', 'pending') AND ` + searchableUserCondition + `
+			AND ` + profileVisibleToSearcherCondition
 	
-	args := []interface{}{userID.String()}
+	// Where a user signs in from is part of their profile, so only those whose
+	// profile the searcher may see are matched by location
+	viewer := userID.String()
+	args := []interface{}{viewer, viewer, viewer, viewer, viewer}
 	
 	if country != nil {
 		query += ` AND s.location_country LIKE ?`
@@ -313,7 +325,9 @@ func (r *searchRepository) SearchUsersByLocation(ctx context.Context, userID uui
 			u.PhotoURL = &photoURL.String
 		}
 		if phone.Valid {
-			u.Phone = &phone.String
+			if u.Phone, err = openNullable(r.db, fieldUserPhone, &phone.String); err != nil {
+				return nil, err
+			}
 		}
 		if signupSource.Valid {
 			u.SignupSource = &signupSource.String
@@ -23,16 +23,18 @@ func (r *sessionRepository) Create(ctx context.Context, session *models.Session)
 	query := `
 		INSERT INTO sessions (
 			id, user_id, token, refresh_token, refresh_token_expires_at,
-			device_id, device_name, ip_address, is_active, expires_at,
+			device_id, device_type, device_name, os, browser, ip_address,
+			location_country, location_city, is_active, expires_at,
 			created_at, last_used_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.DB.ExecContext(ctx, query,
 		session.ID, session.UserID, session.Token, session.RefreshToken,
-		session.RefreshTokenExpiresAt, session.DeviceID, session.DeviceName,
-		session.IPAddress, session.IsActive, session.ExpiresAt,
-		session.CreatedAt, session.LastUsedAt,
+		session.RefreshTokenExpiresAt, session.DeviceID, session.DeviceType,
+		session.DeviceName, session.OS, session.Browser, session.IPAddress,
+		session.LocationCountry, session.LocationCity, session.IsActive,
+		session.ExpiresAt, session.CreatedAt, session.LastUsedAt,
 	)
 
 	return err
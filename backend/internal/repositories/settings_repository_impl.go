@@ -98,10 +98,18 @@ func (r *settingsRepository) GetByUserID(ctx context.Context, userID uuid.UUID)
 		s.ConnectedAccounts = &connectedAccounts.String
 	}
 
+	if err := r.openSecrets(&s); err != nil {
+		return nil, err
+	}
 	return &s, nil
 }
 
 func (r *settingsRepository) Create(ctx context.Context, settings *models.ComprehensiveSettings) error {
+	secrets, err := r.sealSecrets(settings)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO user_settings_comprehensive (
 			user_id, username, display_name, bio, date_of_birth,
@@ -118,11 +126,11 @@ func (r *settingsRepository) Create(ctx context.Context, settings *models.Compre
 			account_deactivated_at, updated_at
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		settings.UserID.String(),
 		settings.Username, settings.DisplayName, settings.Bio, settings.DateOfBirth,
-		settings.TwoFactorEnabled, settings.TwoFactorSecret, settings.TwoFactorBackupCodes,
-		settings.SecurityQuestions, settings.PasswordLastChanged,
+		settings.TwoFactorEnabled, secrets.TwoFactorSecret, secrets.TwoFactorBackupCodes,
+		secrets.SecurityQuestions, settings.PasswordLastChanged,
 		settings.ProfileVisibility, settings.EmailVisibility, settings.PhoneVisibility,
 		settings.AllowMessaging, settings.SearchVisibility, settings.DataSharingEnabled,
 		settings.EmailNotifications, settings.SMSNotifications, settings.PushNotifications,
@@ -137,6 +145,11 @@ func (r *settingsRepository) Create(ctx context.Context, settings *models.Compre
 }
 
 func (r *settingsRepository) Update(ctx context.Context, settings *models.ComprehensiveSettings) error {
+	secrets, err := r.sealSecrets(settings)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE user_settings_comprehensive SET
 			username = ?, display_name = ?, bio = ?, date_of_birth = ?,
@@ -153,10 +166,10 @@ func (r *settingsRepository) Update(ctx context.Context, settings *models.Compre
 			account_deactivated_at = ?, updated_at = ?
 		WHERE user_id = ?
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		settings.Username, settings.DisplayName, settings.Bio, settings.DateOfBirth,
-		settings.TwoFactorEnabled, settings.TwoFactorSecret, settings.TwoFactorBackupCodes,
-		settings.SecurityQuestions, settings.PasswordLastChanged,
+		settings.TwoFactorEnabled, secrets.TwoFactorSecret, secrets.TwoFactorBackupCodes,
+		secrets.SecurityQuestions, settings.PasswordLastChanged,
 		settings.ProfileVisibility, settings.EmailVisibility, settings.PhoneVisibility,
 		settings.AllowMessaging, settings.SearchVisibility, settings.DataSharingEnabled,
 		settings.EmailNotifications, settings.SMSNotifications, settings.PushNotifications,
@@ -253,6 +266,38 @@ func (r *settingsRepository) GetUsername(ctx context.Context, username string) (
 		s.ConnectedAccounts = &connectedAccounts.String
 	}
 
+	if err := r.openSecrets(&s); err != nil {
+		return nil, err
+	}
 	return &s, nil
 }
 
+// sealSecrets returns a copy of settings' two-factor secret, backup codes and
+// security questions, encrypted for storage.
+func (r *settingsRepository) sealSecrets(settings *models.ComprehensiveSettings) (*models.ComprehensiveSettings, error) {
+	var sealed models.ComprehensiveSettings
+	var err error
+	if sealed.TwoFactorSecret, err = sealNullable(r.db, fieldTwoFactorSecret, settings.TwoFactorSecret); err != nil {
+		return nil, err
+	}
+	if sealed.TwoFactorBackupCodes, err = sealNullable(r.db, fieldTwoFactorBackupCodes, settings.TwoFactorBackupCodes); err != nil {
+		return nil, err
+	}
+	if sealed.SecurityQuestions, err = sealNullable(r.db, fieldSecurityQuestions, settings.SecurityQuestions); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// openSecrets decrypts the secrets sealSecrets encrypted, in place.
+func (r *settingsRepository) openSecrets(s *models.ComprehensiveSettings) error {
+	var err error
+	if s.TwoFactorSecret, err = openNullable(r.db, fieldTwoFactorSecret, s.TwoFactorSecret); err != nil {
+		return err
+	}
+	if s.TwoFactorBackupCodes, err = openNullable(r.db, fieldTwoFactorBackupCodes, s.TwoFactorBackupCodes); err != nil {
+		return err
+	}
+	s.SecurityQuestions, err = openNullable(r.db, fieldSecurityQuestions, s.SecurityQuestions)
+	return err
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type SMSCodeRepository interface {
+	Create(ctx context.Context, code *models.SMSCode) error
+	// GetPending returns the user's newest unused, unexpired code for purpose,
+	// or nil, nil.
+	GetPending(ctx context.Context, userID uuid.UUID, purpose string, now time.Time) (*models.SMSCode, error)
+	// GetByChallengeHash returns nil, nil when no code has the challenge.
+	GetByChallengeHash(ctx context.Context, challengeHash string) (*models.SMSCode, error)
+	// AddAttempt counts a wrong guess and returns the new total.
+	AddAttempt(ctx context.Context, id uuid.UUID) (int, error)
+	// MarkUsed reports false when the code was already used.
+	MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// Consume marks the code used unless it already was or has had
+	// maxAttempts wrong guesses, and reports whether it did.
+	Consume(ctx context.Context, id uuid.UUID, at time.Time, maxAttempts int) (bool, error)
+	// InvalidateByUserID marks the user's unused codes for purpose as used.
+	InvalidateByUserID(ctx context.Context, userID uuid.UUID, purpose string, at time.Time) error
+	// CountSince counts codes sent to the user since the given time, for
+	// any purpose.
+	CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type smsCodeRepository struct {
+	db *database.DB
+}
+
+func NewSMSCodeRepository(db *database.DB) SMSCodeRepository {
+	return &smsCodeRepository{db: db}
+}
+
+const smsCodeColumns = `id, user_id, purpose, phone, code_hash, challenge_hash, login_method,
+	attempts, ip_address, expires_at, used_at, created_at`
+
+func (r *smsCodeRepository) Create(ctx context.Context, code *models.SMSCode) error {
+	phone, err := sealField(r.db, fieldSMSCodePhone, code.Phone)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO sms_codes (id, user_id, purpose, phone, code_hash, challenge_hash, login_method,
+		attempts, ip_address, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.db.ExecContext(ctx, query,
+		code.ID.String(), code.UserID.String(), code.Purpose, phone, code.CodeHash,
+		code.ChallengeHash, code.LoginMethod, code.Attempts, code.IPAddress, code.ExpiresAt, code.CreatedAt,
+	)
+	return err
+}
+
+func (r *smsCodeRepository) GetPending(ctx context.Context, userID uuid.UUID, purpose string, now time.Time) (*models.SMSCode, error) {
+	return r.getOne(ctx, `SELECT `+smsCodeColumns+` FROM sms_codes
+		WHERE user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC LIMIT 1`, userID.String(), purpose, now)
+}
+
+func (r *smsCodeRepository) GetByChallengeHash(ctx context.Context, challengeHash string) (*models.SMSCode, error) {
+	return r.getOne(ctx, `SELECT `+smsCodeColumns+` FROM sms_codes WHERE challenge_hash = ?`, challengeHash)
+}
+
+func (r *smsCodeRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.SMSCode, error) {
+	code := &models.SMSCode{}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&code.ID, &code.UserID, &code.Purpose, &code.Phone, &code.CodeHash, &code.ChallengeHash, &code.LoginMethod,
+		&code.Attempts, &code.IPAddress, &code.ExpiresAt, &code.UsedAt, &code.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if code.Phone, err = openField(r.db, fieldSMSCodePhone, code.Phone); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+func (r *smsCodeRepository) AddAttempt(ctx context.Context, id uuid.UUID) (int, error) {
+	if _, err := r.db.ExecContext(ctx, `UPDATE sms_codes SET attempts = attempts + 1 WHERE id = ?`, id.String()); err != nil {
+		return 0, err
+	}
+	var attempts int
+	err := r.db.QueryRowContext(ctx, `SELECT attempts FROM sms_codes WHERE id = ?`, id.String()).Scan(&attempts)
+	return attempts, err
+}
+
+func (r *smsCodeRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE sms_codes SET used_at = ? WHERE id = ? AND used_at IS NULL`, at, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *smsCodeRepository) Consume(ctx context.Context, id uuid.UUID, at time.Time, maxAttempts int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE sms_codes SET used_at = ? WHERE id = ? AND used_at IS NULL AND attempts < ?`,
+		at, id.String(), maxAttempts)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *smsCodeRepository) InvalidateByUserID(ctx context.Context, userID uuid.UUID, purpose string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE sms_codes SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL`,
+		at, userID.String(), purpose)
+	return err
+}
+
+func (r *smsCodeRepository) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sms_codes WHERE user_id = ? AND created_at >= ?`,
+		userID.String(), since).Scan(&count)
+	return count, err
+}
+
+func (r *smsCodeRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sms_codes WHERE expires_at < ?`, before)
+	return err
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type TwoFactorRepository interface {
+	// Get returns nil, nil when the user has no second factor.
+	Get(ctx context.Context, userID uuid.UUID) (*models.UserTwoFactor, error)
+	Set(ctx context.Context, twoFactor *models.UserTwoFactor) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
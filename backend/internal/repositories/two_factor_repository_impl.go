@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type twoFactorRepository struct {
+	db *database.DB
+}
+
+func NewTwoFactorRepository(db *database.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+func (r *twoFactorRepository) Get(ctx context.Context, userID uuid.UUID) (*models.UserTwoFactor, error) {
+	twoFactor := &models.UserTwoFactor{}
+	err := r.db.QueryRowContext(ctx, `SELECT user_id, method, enabled_at FROM user_two_factor WHERE user_id = ?`,
+		userID.String()).Scan(&twoFactor.UserID, &twoFactor.Method, &twoFactor.EnabledAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return twoFactor, nil
+}
+
+func (r *twoFactorRepository) Set(ctx context.Context, twoFactor *models.UserTwoFactor) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO user_two_factor (user_id, method, enabled_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET method = excluded.method, enabled_at = excluded.enabled_at`,
+		twoFactor.UserID.String(), twoFactor.Method, twoFactor.EnabledAt)
+	return err
+}
+
+func (r *twoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = ?`, userID.String())
+	return err
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/models"
+)
+
+type UserInvitationRepository interface {
+	Create(ctx context.Context, invitation *models.UserInvitation) error
+	// GetByID and GetByTokenHash return nil, nil when nothing matches.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.UserInvitation, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.UserInvitation, error)
+	// List returns invitations newest first. With pendingOnly, only the
+	// unanswered, unexpired ones as of now.
+	List(ctx context.Context, pendingOnly bool, now time.Time, limit, offset int) ([]*models.UserInvitation, error)
+	// Resend replaces the token of an open invitation and extends it. It
+	// reports false when the invitation was accepted or revoked meanwhile.
+	Resend(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt, sentAt time.Time) (bool, error)
+	// MarkAccepted and Revoke report false when the invitation was already
+	// accepted or revoked, so each one is used once.
+	MarkAccepted(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// RevokePendingForEmail revokes earlier open invitations of email when it
+	// is invited again.
+	RevokePendingForEmail(ctx context.Context, email string, at time.Time) error
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+)
+
+type userInvitationRepository struct {
+	db *database.DB
+}
+
+func NewUserInvitationRepository(db *database.DB) UserInvitationRepository {
+	return &userInvitationRepository{db: db}
+}
+
+const userInvitationColumns = `id, email, name, role, token_hash, invited_by, expires_at, sent_at, send_count,
+	accepted_at, accepted_by, revoked_at, created_at`
+
+func (r *userInvitationRepository) Create(ctx context.Context, invitation *models.UserInvitation) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO user_invitations (id, email, name, role, token_hash,
+		invited_by, expires_at, sent_at, send_count, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		invitation.ID.String(), invitation.Email, invitation.Name, invitation.Role, invitation.TokenHash,
+		nullableUUID(invitation.InvitedBy), invitation.ExpiresAt, invitation.SentAt, invitation.SendCount,
+		invitation.CreatedAt,
+	)
+	return err
+}
+
+func (r *userInvitationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.UserInvitation, error) {
+	return r.getOne(ctx, `SELECT `+userInvitationColumns+` FROM user_invitations WHERE id = ?`, id.String())
+}
+
+func (r *userInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.UserInvitation, error) {
+	return r.getOne(ctx, `SELECT `+userInvitationColumns+` FROM user_invitations WHERE token_hash = ?`, tokenHash)
+}
+
+func (r *userInvitationRepository) List(ctx context.Context, pendingOnly bool, now time.Time, limit, offset int) ([]*models.UserInvitation, error) {
+	query := `SELECT ` + userInvitationColumns + ` FROM user_invitations`
+	var args []interface{}
+	if pendingOnly {
+		query += ` WHERE accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?`
+		args = append(args, now)
+	}
+	query += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*models.UserInvitation
+	for rows.Next() {
+		invitation, err := scanUserInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *userInvitationRepository) Resend(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt, sentAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE user_invitations
+		SET token_hash = ?, expires_at = ?, sent_at = ?, send_count = send_count + 1
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL`,
+		tokenHash, expiresAt, sentAt, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *userInvitationRepository) MarkAccepted(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE user_invitations SET accepted_at = ?, accepted_by = ?
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL`, at, userID.String(), id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *userInvitationRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE user_invitations SET revoked_at = ?
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL`, at, id.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *userInvitationRepository) RevokePendingForEmail(ctx context.Context, email string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE user_invitations SET revoked_at = ?
+		WHERE email = ? AND accepted_at IS NULL AND revoked_at IS NULL`, at, email)
+	return err
+}
+
+func (r *userInvitationRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.UserInvitation, error) {
+	invitation, err := scanUserInvitation(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return invitation, err
+}
+
+func scanUserInvitation(scanner interface{ Scan(...interface{}) error }) (*models.UserInvitation, error) {
+	invitation := &models.UserInvitation{}
+	err := scanner.Scan(
+		&invitation.ID, &invitation.Email, &invitation.Name, &invitation.Role, &invitation.TokenHash,
+		&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.SentAt, &invitation.SendCount,
+		&invitation.AcceptedAt, &invitation.AcceptedBy, &invitation.RevokedAt, &invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
@@ -13,11 +13,21 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// FirstByRole returns the oldest account with role that is not deleted,
+	// or nil when there is none.
+	FirstByRole(ctx context.Context, role string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
-	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, changedAt time.Time) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, changedAt time.Time, mustChange bool) error
+	UpdateEmail(ctx context.Context, userID uuid.UUID, email string, verified bool) error
+	UpdatePhone(ctx context.Context, userID uuid.UUID, phone *string, verified bool) error
+	UpdatePasswordHash(ctx context.Context, userID uuid.UUID, passwordHash string) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, search string) ([]*models.User, error)
+	// ListPage returns accounts that are not deleted, oldest first, with
+	// their total count.
+	ListPage(ctx context.Context, offset, limit int) ([]*models.User, int, error)
+	// ListByRole returns the accounts with role that are not deleted.
+	ListByRole(ctx context.Context, role string) ([]*models.User, error)
 	SetStatus(ctx context.Context, id uuid.UUID, status string) error
 	MarkDeleted(ctx context.Context, id uuid.UUID) error
-	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) error
 }
@@ -21,16 +21,21 @@ func NewUserRepository(db *database.DB) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	phone, phoneIndex, err := r.sealPhone(user.Phone)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO users (
-			id, email, password_hash, name, first_name, last_name, phone,
+			id, email, password_hash, name, first_name, last_name, phone, phone_index,
 			role, signup_source, status, password_changed_at, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		user.ID, user.Email, user.PasswordHash, user.Name,
-		user.FirstName, user.LastName, user.Phone, user.Role,
+		user.FirstName, user.LastName, phone, phoneIndex, user.Role,
 		user.SignupSource, user.Status,
 		user.PasswordChangedAt, user.CreatedAt, user.UpdatedAt,
 	)
@@ -38,11 +43,24 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	return err
 }
 
+// sealPhone returns the phone number to store and its blind index.
+func (r *userRepository) sealPhone(phone *string) (*string, *string, error) {
+	sealed, err := sealNullable(r.db, fieldUserPhone, phone)
+	if err != nil {
+		return nil, nil, err
+	}
+	index, err := blindIndex(r.db, fieldUserPhone, phone)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealed, index, nil
+}
+
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
 		SELECT id, email, email_verified, password_hash, name, first_name, last_name,
 			photo_url, phone, role, phone_verified, status, signup_source,
-			password_changed_at, created_at, updated_at, last_login_at
+			password_changed_at, must_change_password, created_at, updated_at, last_login_at
 		FROM users
 		WHERE id = ?
 	`
@@ -52,7 +70,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
 		&user.Name, &user.FirstName, &user.LastName, &user.PhotoURL,
 		&user.Phone, &user.Role, &user.PhoneVerified, &user.Status, &user.SignupSource,
-		&user.PasswordChangedAt, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+		&user.PasswordChangedAt, &user.MustChangePassword, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -62,6 +80,9 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		return nil, err
 	}
 
+	if user.Phone, err = openNullable(r.db, fieldUserPhone, user.Phone); err != nil {
+		return nil, err
+	}
 	return user, nil
 }
 
@@ -69,7 +90,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 	query := `
 		SELECT id, email, email_verified, password_hash, name, first_name, last_name,
 			photo_url, phone, role, phone_verified, status, signup_source,
-			password_changed_at, created_at, updated_at, last_login_at
+			password_changed_at, must_change_password, created_at, updated_at, last_login_at
 		FROM users
 		WHERE email = ?
 	`
@@ -79,7 +100,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 		&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
 		&user.Name, &user.FirstName, &user.LastName, &user.PhotoURL,
 		&user.Phone, &user.Role, &user.PhoneVerified, &user.Status, &user.SignupSource,
-		&user.PasswordChangedAt, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+		&user.PasswordChangedAt, &user.MustChangePassword, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -89,34 +110,102 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 		return nil, err
 	}
 
+	if user.Phone, err = openNullable(r.db, fieldUserPhone, user.Phone); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) FirstByRole(ctx context.Context, role string) (*models.User, error) {
+	query := `
+		SELECT id, email, email_verified, password_hash, name, first_name, last_name,
+			photo_url, phone, role, phone_verified, status, signup_source,
+			password_changed_at, must_change_password, created_at, updated_at, last_login_at
+		FROM users
+		WHERE role = ? AND status != 'deleted'
+		ORDER BY created_at ASC
+		LIMIT 1
+	`
+
+	user := &models.User{}
+	err := r.db.DB.QueryRowContext(ctx, query, role).Scan(
+		&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
+		&user.Name, &user.FirstName, &user.LastName, &user.PhotoURL,
+		&user.Phone, &user.Role, &user.PhoneVerified, &user.Status, &user.SignupSource,
+		&user.PasswordChangedAt, &user.MustChangePassword, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Phone, err = openNullable(r.db, fieldUserPhone, user.Phone); err != nil {
+		return nil, err
+	}
 	return user, nil
 }
 
+// Update saves the user's profile fields. The email address and phone number
+// are changed only through UpdateEmail and UpdatePhone, once the new one has
+// been confirmed.
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET email = ?, name = ?, first_name = ?, last_name = ?,
-			photo_url = ?, phone = ?, status = ?, last_login_at = ?,
+		SET name = ?, first_name = ?, last_name = ?,
+			photo_url = ?, status = ?, last_login_at = ?,
 			updated_at = ?, role = ?
 		WHERE id = ?
 	`
 
 	_, err := r.db.DB.ExecContext(ctx, query,
-		user.Email, user.Name, user.FirstName, user.LastName,
-		user.PhotoURL, user.Phone, user.Status, user.LastLoginAt, user.UpdatedAt, user.Role, user.ID,
+		user.Name, user.FirstName, user.LastName,
+		user.PhotoURL, user.Status, user.LastLoginAt, user.UpdatedAt, user.Role, user.ID,
 	)
 
 	return err
 }
 
-func (r *userRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, changedAt time.Time) error {
+// UpdatePassword stores a new password hash. mustChange makes the user
+// choose another password at their next sign-in, e.g. after an admin reset.
+func (r *userRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, changedAt time.Time, mustChange bool) error {
 	query := `
 		UPDATE users
-		SET password_hash = ?, password_changed_at = ?, updated_at = ?
+		SET password_hash = ?, password_changed_at = ?, must_change_password = ?, updated_at = ?
 		WHERE id = ?
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, passwordHash, changedAt, time.Now(), userID)
+	_, err := r.db.DB.ExecContext(ctx, query, passwordHash, changedAt, mustChange, time.Now(), userID)
+	return err
+}
+
+// UpdateEmail moves the account to a new address and records whether that
+// address has been verified.
+func (r *userRepository) UpdateEmail(ctx context.Context, userID uuid.UUID, email string, verified bool) error {
+	_, err := r.db.DB.ExecContext(ctx, `UPDATE users SET email = ?, email_verified = ?, updated_at = ? WHERE id = ?`,
+		email, verified, time.Now(), userID)
+	return err
+}
+
+// UpdatePhone sets the user's phone number, or clears it when phone is nil,
+// and records whether the number has been verified.
+func (r *userRepository) UpdatePhone(ctx context.Context, userID uuid.UUID, phone *string, verified bool) error {
+	sealed, index, err := r.sealPhone(phone)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.DB.ExecContext(ctx, `UPDATE users SET phone = ?, phone_index = ?, phone_verified = ?, updated_at = ? WHERE id = ?`,
+		sealed, index, verified, time.Now(), userID)
+	return err
+}
+
+// UpdatePasswordHash replaces the hash of the same password, e.g. to move it
+// to a stronger algorithm. Unlike UpdatePassword it leaves the password's age
+// alone.
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	_, err := r.db.DB.ExecContext(ctx, `UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, userID)
 	return err
 }
 
@@ -138,38 +227,33 @@ func (r *userRepository) MarkDeleted(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-func (r *userRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) error {
-	query := `
-		DELETE FROM users
-		WHERE status = 'deleted'
-		  AND status_changed_at IS NOT NULL
-		  AND status_changed_at <= ?
-	`
-	_, err := r.db.DB.ExecContext(ctx, query, cutoff)
-	return err
-}
-
+// List returns up to 200 users, newest first. A search matches names and
+// email addresses containing it, and phone numbers equal to it.
 func (r *userRepository) List(ctx context.Context, search string) ([]*models.User, error) {
 	var rows *sql.Rows
 	var err error
 
 	if search != "" {
 		pattern := "%" + search + "%"
+		phoneIndex, indexErr := blindIndex(r.db, fieldUserPhone, &search)
+		if indexErr != nil {
+			return nil, indexErr
+		}
 		query := `
 			SELECT id, email, email_verified, password_hash, name, first_name, last_name,
 				photo_url, phone, role, phone_verified, status, signup_source,
-				password_changed_at, created_at, updated_at, last_login_at
+				password_changed_at, must_change_password, created_at, updated_at, last_login_at
 			FROM users
-			WHERE email LIKE ? OR name LIKE ?
+			WHERE email LIKE ? OR name LIKE ? OR phone_index = ?
 			ORDER BY created_at DESC
 			LIMIT 200
 		`
-		rows, err = r.db.DB.QueryContext(ctx, query, pattern, pattern)
+		rows, err = r.db.DB.QueryContext(ctx, query, pattern, pattern, phoneIndex)
 	} else {
 		query := `
 			SELECT id, email, email_verified, password_hash, name, first_name, last_name,
 				photo_url, phone, role, phone_verified, status, signup_source,
-				password_changed_at, created_at, updated_at, last_login_at
+				password_changed_at, must_change_password, created_at, updated_at, last_login_at
 			FROM users
 			ORDER BY created_at DESC
 			LIMIT 200
@@ -180,6 +264,49 @@ func (r *userRepository) List(ctx context.Context, search string) ([]*models.Use
 	if err != nil {
 		return nil, err
 	}
+	return r.scanUserRows(rows)
+}
+
+func (r *userRepository) ListPage(ctx context.Context, offset, limit int) ([]*models.User, int, error) {
+	var total int
+	if err := r.db.DB.QueryRowContext(ctx, `SELECT COUNT(1) FROM users WHERE status != 'deleted'`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, email, email_verified, password_hash, name, first_name, last_name,
+			photo_url, phone, role, phone_verified, status, signup_source,
+			password_changed_at, must_change_password, created_at, updated_at, last_login_at
+		FROM users
+		WHERE status != 'deleted'
+		ORDER BY created_at ASC, id ASC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.db.DB.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	users, err := r.scanUserRows(rows)
+	return users, total, err
+}
+
+func (r *userRepository) ListByRole(ctx context.Context, role string) ([]*models.User, error) {
+	query := `
+		SELECT id, email, email_verified, password_hash, name, first_name, last_name,
+			photo_url, phone, role, phone_verified, status, signup_source,
+			password_changed_at, must_change_password, created_at, updated_at, last_login_at
+		FROM users
+		WHERE role = ? AND status != 'deleted'
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.DB.QueryContext(ctx, query, role)
+	if err != nil {
+		return nil, err
+	}
+	return r.scanUserRows(rows)
+}
+
+func (r *userRepository) scanUserRows(rows *sql.Rows) ([]*models.User, error) {
 	defer rows.Close()
 
 	var users []*models.User
@@ -189,14 +316,17 @@ func (r *userRepository) List(ctx context.Context, search string) ([]*models.Use
 			&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
 			&user.Name, &user.FirstName, &user.LastName, &user.PhotoURL,
 			&user.Phone, &user.Role, &user.PhoneVerified, &user.Status, &user.SignupSource,
-			&user.PasswordChangedAt, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+			&user.PasswordChangedAt, &user.MustChangePassword, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 		); err != nil {
 			return nil, err
 		}
+		var err error
+		if user.Phone, err = openNullable(r.db, fieldUserPhone, user.Phone); err != nil {
+			return nil, err
+		}
 		users = append(users, user)
 	}
-
-	return users, nil
+	return users, rows.Err()
 }
 
 func (r *userRepository) SetStatus(ctx context.Context, id uuid.UUID, status string) error {
@@ -15,8 +15,11 @@ type WebhookRepository interface {
 	UpdateEvent(ctx context.Context, event *models.WebhookEvent) error
 	GetEventByID(ctx context.Context, id uuid.UUID) (*models.WebhookEvent, error)
 
-	// Subscription methods
-	GetActiveSubscriptions(ctx context.Context, eventType string) ([]*models.WebhookSubscription, error)
+	// Subscription methods. Subscriptions belong to an organization, or to
+	// the platform when organizationID is nil, and only see its events.
+	GetActiveSubscriptions(ctx context.Context, eventType string, organizationID *uuid.UUID) ([]*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, organizationID *uuid.UUID) ([]*models.WebhookSubscription, error)
+	GetSubscriptionByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (*models.WebhookSubscription, error)
 	GetSubscriptionByURL(ctx context.Context, url string) (*models.WebhookSubscription, error)
 	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
 	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
@@ -21,18 +21,23 @@ func NewWebhookRepository(db *database.DB) WebhookRepository {
 }
 
 func (r *webhookRepository) CreateEvent(ctx context.Context, event *models.WebhookEvent) error {
+	secret, err := sealField(r.db, fieldEventSecret, event.WebhookSecret)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO webhook_events (
-			id, event_type, event_version, event_source, user_id, payload,
-			payload_hash, webhook_url, webhook_secret, status, max_attempts,
+			id, event_type, event_version, event_source, user_id, organization_id,
+			payload, payload_hash, webhook_url, webhook_secret, status, max_attempts,
 			scheduled_at, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query,
+	_, err = r.db.DB.ExecContext(ctx, query,
 		event.ID, event.EventType, event.EventVersion, event.EventSource,
-		event.UserID, event.Payload, event.PayloadHash,
-		event.WebhookURL, event.WebhookSecret, event.Status, event.MaxAttempts,
+		event.UserID, event.OrganizationID, event.Payload, event.PayloadHash,
+		event.WebhookURL, secret, event.Status, event.MaxAttempts,
 		event.ScheduledAt, event.CreatedAt, event.UpdatedAt,
 	)
 
@@ -41,8 +46,8 @@ func (r *webhookRepository) CreateEvent(ctx context.Context, event *models.Webho
 
 func (r *webhookRepository) GetPendingEvents(ctx context.Context, limit int) ([]*models.WebhookEvent, error) {
 	query := `
-		SELECT id, event_type, event_version, event_source, user_id, payload,
-			payload_hash, webhook_url, webhook_secret, status, delivery_attempts,
+		SELECT id, event_type, event_version, event_source, user_id, organization_id,
+			payload, payload_hash, webhook_url, webhook_secret, status, delivery_attempts,
 			max_attempts, scheduled_at, processed_at, delivered_at, next_retry_at,
 			last_response_status, last_response_body, last_error_message,
 			created_at, updated_at
@@ -64,7 +69,7 @@ func (r *webhookRepository) GetPendingEvents(ctx context.Context, limit int) ([]
 		event := &models.WebhookEvent{}
 		err := rows.Scan(
 			&event.ID, &event.EventType, &event.EventVersion, &event.EventSource,
-			&event.UserID, &event.Payload, &event.PayloadHash,
+			&event.UserID, &event.OrganizationID, &event.Payload, &event.PayloadHash,
 			&event.WebhookURL, &event.WebhookSecret, &event.Status,
 			&event.DeliveryAttempts, &event.MaxAttempts,
 			&event.ScheduledAt, &event.ProcessedAt, &event.DeliveredAt,
@@ -74,6 +79,9 @@ func (r *webhookRepository) GetPendingEvents(ctx context.Context, limit int) ([]
 		if err != nil {
 			return nil, err
 		}
+		if event.WebhookSecret, err = openField(r.db, fieldEventSecret, event.WebhookSecret); err != nil {
+			return nil, err
+		}
 		events = append(events, event)
 	}
 
@@ -100,8 +108,8 @@ func (r *webhookRepository) UpdateEvent(ctx context.Context, event *models.Webho
 
 func (r *webhookRepository) GetEventByID(ctx context.Context, id uuid.UUID) (*models.WebhookEvent, error) {
 	query := `
-		SELECT id, event_type, event_version, event_source, user_id, payload,
-			payload_hash, webhook_url, webhook_secret, status, delivery_attempts,
+		SELECT id, event_type, event_version, event_source, user_id, organization_id,
+			payload, payload_hash, webhook_url, webhook_secret, status, delivery_attempts,
 			max_attempts, scheduled_at, processed_at, delivered_at, next_retry_at,
 			last_response_status, last_response_body, last_error_message,
 			created_at, updated_at
@@ -112,7 +120,7 @@ func (r *webhookRepository) GetEventByID(ctx context.Context, id uuid.UUID) (*mo
 	event := &models.WebhookEvent{}
 	err := r.db.DB.QueryRowContext(ctx, query, id).Scan(
 		&event.ID, &event.EventType, &event.EventVersion, &event.EventSource,
-		&event.UserID, &event.Payload, &event.PayloadHash,
+		&event.UserID, &event.OrganizationID, &event.Payload, &event.PayloadHash,
 		&event.WebhookURL, &event.WebhookSecret, &event.Status,
 		&event.DeliveryAttempts, &event.MaxAttempts,
 		&event.ScheduledAt, &event.ProcessedAt, &event.DeliveredAt,
@@ -126,92 +134,139 @@ func (r *webhookRepository) GetEventByID(ctx context.Context, id uuid.UUID) (*mo
 	if err != nil {
 		return nil, err
 	}
+	if event.WebhookSecret, err = openField(r.db, fieldEventSecret, event.WebhookSecret); err != nil {
+		return nil, err
+	}
 
 	return event, nil
 }
 
-func (r *webhookRepository) GetActiveSubscriptions(ctx context.Context, eventType string) ([]*models.WebhookSubscription, error) {
-	query := `
-		SELECT id, user_id, subscription_name, webhook_url, webhook_secret,
+const webhookSubscriptionColumns = `id, user_id, organization_id, subscription_name, webhook_url, webhook_secret,
 			event_types, is_active, is_verified, rate_limit_per_minute,
 			max_retries, retry_backoff_multiplier, description, metadata,
-			created_at, updated_at
+			created_at, updated_at`
+
+func (r *webhookRepository) GetActiveSubscriptions(ctx context.Context, eventType string, organizationID *uuid.UUID) ([]*models.WebhookSubscription, error) {
+	condition, args := organizationCondition("organization_id", organizationID)
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
 		FROM webhook_subscriptions
-		WHERE is_active = 1
-	`
+		WHERE is_active = 1 AND ` + condition
 
-	rows, err := r.db.DB.QueryContext(ctx, query)
+	subscriptions, err := r.listSubscriptions(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var subscriptions []*models.WebhookSubscription
-	for rows.Next() {
-		sub := &models.WebhookSubscription{}
-		var eventTypes string
-		err := rows.Scan(
-			&sub.ID, &sub.UserID, &sub.SubscriptionName, &sub.WebhookURL,
-			&sub.WebhookSecret, &eventTypes, &sub.IsActive, &sub.IsVerified,
-			&sub.RateLimitPerMinute, &sub.MaxRetries, &sub.RetryBackoffMultiplier,
-			&sub.Description, &sub.Metadata, &sub.CreatedAt, &sub.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		sub.EventTypes = splitEventTypes(eventTypes)
+	var matching []*models.WebhookSubscription
+	for _, sub := range subscriptions {
 		if eventType != "" && !containsEvent(sub.EventTypes, eventType) {
 			continue
 		}
-		subscriptions = append(subscriptions, sub)
+		matching = append(matching, sub)
 	}
 
-	return subscriptions, nil
+	return matching, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, organizationID *uuid.UUID) ([]*models.WebhookSubscription, error) {
+	condition, args := organizationCondition("organization_id", organizationID)
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE ` + condition + `
+		ORDER BY created_at DESC`
+	return r.listSubscriptions(ctx, query, args...)
+}
+
+func (r *webhookRepository) GetSubscriptionByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (*models.WebhookSubscription, error) {
+	condition, args := organizationCondition("organization_id", organizationID)
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE id = ? AND ` + condition
+
+	sub, err := r.scanSubscription(r.db.DB.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
 }
 
 func (r *webhookRepository) GetSubscriptionByURL(ctx context.Context, url string) (*models.WebhookSubscription, error) {
 	query := `
-		SELECT id, user_id, subscription_name, webhook_url, webhook_secret,
-			event_types, is_active, is_verified, rate_limit_per_minute,
-			max_retries, retry_backoff_multiplier, description, metadata,
-			created_at, updated_at
+		SELECT ` + webhookSubscriptionColumns + `
 		FROM webhook_subscriptions
 		WHERE webhook_url = ? AND is_active = 1
 		LIMIT 1
 	`
 
+	sub, err := r.scanSubscription(r.db.DB.QueryRowContext(ctx, query, url))
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found, return nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (r *webhookRepository) listSubscriptions(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.WebhookSubscription
+	for rows.Next() {
+		sub, err := r.scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (r *webhookRepository) scanSubscription(scanner interface{ Scan(...interface{}) error }) (*models.WebhookSubscription, error) {
 	sub := &models.WebhookSubscription{}
 	var eventTypes string
-	err := r.db.DB.QueryRowContext(ctx, query, url).Scan(
-		&sub.ID, &sub.UserID, &sub.SubscriptionName, &sub.WebhookURL,
+	err := scanner.Scan(
+		&sub.ID, &sub.UserID, &sub.OrganizationID, &sub.SubscriptionName, &sub.WebhookURL,
 		&sub.WebhookSecret, &eventTypes, &sub.IsActive, &sub.IsVerified,
 		&sub.RateLimitPerMinute, &sub.MaxRetries, &sub.RetryBackoffMultiplier,
 		&sub.Description, &sub.Metadata, &sub.CreatedAt, &sub.UpdatedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, nil // Not found, return nil
-	}
 	if err != nil {
 		return nil, err
 	}
-
+	if sub.WebhookSecret, err = openField(r.db, fieldSubscriptionSecret, sub.WebhookSecret); err != nil {
+		return nil, err
+	}
 	sub.EventTypes = splitEventTypes(eventTypes)
 	return sub, nil
 }
 
 func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	secret, err := sealField(r.db, fieldSubscriptionSecret, sub.WebhookSecret)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO webhook_subscriptions (
-			id, user_id, subscription_name, webhook_url, webhook_secret,
+			id, user_id, organization_id, subscription_name, webhook_url, webhook_secret,
 			event_types, is_active, is_verified, rate_limit_per_minute,
 			max_retries, retry_backoff_multiplier, description, metadata,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query,
-		sub.ID, sub.UserID, sub.SubscriptionName, sub.WebhookURL, sub.WebhookSecret,
+	_, err = r.db.DB.ExecContext(ctx, query,
+		sub.ID, sub.UserID, sub.OrganizationID, sub.SubscriptionName, sub.WebhookURL, secret,
 		joinEventTypes(sub.EventTypes), sub.IsActive, sub.IsVerified,
 		sub.RateLimitPerMinute, sub.MaxRetries, sub.RetryBackoffMultiplier,
 		sub.Description, sub.Metadata, sub.CreatedAt, sub.UpdatedAt,
@@ -221,6 +276,11 @@ func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.
 }
 
 func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	secret, err := sealField(r.db, fieldSubscriptionSecret, sub.WebhookSecret)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE webhook_subscriptions
 		SET subscription_name = ?, webhook_url = ?, webhook_secret = ?,
@@ -231,8 +291,8 @@ func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub *models.
 		WHERE id = ?
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query,
-		sub.SubscriptionName, sub.WebhookURL, sub.WebhookSecret,
+	_, err = r.db.DB.ExecContext(ctx, query,
+		sub.SubscriptionName, sub.WebhookURL, secret,
 		joinEventTypes(sub.EventTypes), sub.IsActive, sub.IsVerified,
 		sub.RateLimitPerMinute, sub.MaxRetries, sub.RetryBackoffMultiplier,
 		sub.Description, sub.Metadata, sub.ID,
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// AccountDeletionService is the one way accounts are deleted, whether users
+// ask themselves, an admin deletes them or SCIM deprovisions them. Requesting
+// deletion marks the account deleted and signs it out everywhere; after the
+// grace period the account is purged with every record about it and a
+// receipt is written to the audit log. Until then, users who asked for the
+// deletion themselves cancel it by signing in.
+type AccountDeletionService struct {
+	cfg          config.AccountDeletionConfig
+	deletionRepo repositories.AccountDeletionRepository
+	userRepo     repositories.UserRepository
+	sessionRepo  repositories.SessionRepository
+	orgRepo      repositories.OrganizationRepository
+	fileRepo     repositories.FileRepository
+	exportRepo   repositories.DataExportRepository
+	permissions  *PermissionService
+	logService   *ActivityLogService
+	logger       *zap.Logger
+}
+
+func NewAccountDeletionService(
+	cfg config.AccountDeletionConfig,
+	deletionRepo repositories.AccountDeletionRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	orgRepo repositories.OrganizationRepository,
+	fileRepo repositories.FileRepository,
+	exportRepo repositories.DataExportRepository,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) (*AccountDeletionService, error) {
+	if cfg.CRUDPolicy == "" {
+		cfg.CRUDPolicy = models.CRUDPolicyAnonymize
+	}
+	if cfg.CRUDPolicy != models.CRUDPolicyAnonymize && cfg.CRUDPolicy != models.CRUDPolicyDelete {
+		return nil, fmt.Errorf("unknown CRUD policy %q: use %s or %s", cfg.CRUDPolicy, models.CRUDPolicyAnonymize, models.CRUDPolicyDelete)
+	}
+	if cfg.GracePeriod < 0 {
+		return nil, errors.New("the deletion grace period cannot be negative")
+	}
+	return &AccountDeletionService{
+		cfg:          cfg,
+		deletionRepo: deletionRepo,
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		orgRepo:      orgRepo,
+		fileRepo:     fileRepo,
+		exportRepo:   exportRepo,
+		logService:   logService,
+		logger:       logger,
+	}, nil
+}
+
+// SetPermissions refuses admin deletions of accounts holding permissions the
+// acting admin does not. Without it, any admin with users.write may delete
+// any account but the last admin.
+func (s *AccountDeletionService) SetPermissions(permissions *PermissionService) {
+	s.permissions = permissions
+}
+
+// Request schedules userID's account for purging after the grace period.
+// requestedBy is one of the models.AccountDeletionBy constants; actorID is the
+// admin who deleted the account, if one did. Admins cannot delete the last
+// admin.
+func (s *AccountDeletionService) Request(ctx context.Context, userID uuid.UUID, requestedBy string, actorID *uuid.UUID) (*models.AccountDeletion, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status == "deleted" {
+		return nil, errors.New("account deletion already requested")
+	}
+	if requestedBy == models.AccountDeletionByAdmin {
+		if err := s.guardAdminDeletion(ctx, actorID, user); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	deletion := &models.AccountDeletion{
+		UserID:         userID,
+		RequestedBy:    requestedBy,
+		ActorID:        actorID,
+		PreviousStatus: user.Status,
+		RequestedAt:    now,
+		PurgeAt:        now.Add(s.cfg.GracePeriod),
+	}
+	if err := s.deletionRepo.Create(ctx, deletion); err != nil {
+		return nil, fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+	if err := s.userRepo.MarkDeleted(ctx, userID); err != nil {
+		s.deletionRepo.Delete(ctx, userID)
+		return nil, err
+	}
+	if err := s.sessionRepo.RevokeAllForUser(ctx, userID); err != nil {
+		s.logger.Warn("Failed to revoke sessions after deletion request", zap.String("user_id", userID.String()), zap.Error(err))
+	}
+
+	actor, role := &userID, user.Role
+	switch requestedBy {
+	case models.AccountDeletionByAdmin:
+		actor, role = actorID, "admin"
+	case models.AccountDeletionBySCIM:
+		actor, role = nil, "scim"
+	}
+	s.logService.Record(ctx, actor, role, "account_deletion_requested", strPtr("user"), strPtr(userID.String()), map[string]interface{}{
+		"requested_by": requestedBy,
+		"purge_at":     deletion.PurgeAt.UTC().Format(time.RFC3339),
+	})
+	return deletion, nil
+}
+
+// guardAdminDeletion keeps admins from deleting accounts more privileged than
+// their own and from deleting the last active admin.
+func (s *AccountDeletionService) guardAdminDeletion(ctx context.Context, actorID *uuid.UUID, user *models.User) error {
+	if s.permissions != nil && actorID != nil {
+		if err := s.permissions.CheckCanManage(ctx, *actorID, user.ID); err != nil {
+			return err
+		}
+	}
+	if user.Role != "admin" {
+		return nil
+	}
+	admins, err := s.userRepo.ListByRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	for _, admin := range admins {
+		if admin.ID != user.ID && admin.Status == "active" {
+			return nil
+		}
+	}
+	return errors.New("cannot delete the last admin")
+}
+
+// Get returns the deletion scheduled for userID, or nil.
+func (s *AccountDeletionService) Get(ctx context.Context, userID uuid.UUID) (*models.AccountDeletion, error) {
+	return s.deletionRepo.GetByUserID(ctx, userID)
+}
+
+// CancelledBySignIn reports whether signing in as user would cancel a
+// deletion they requested. Sign-in checks it before letting a deleted
+// account through.
+func (s *AccountDeletionService) CancelledBySignIn(ctx context.Context, user *models.User) bool {
+	if user.Status != "deleted" {
+		return false
+	}
+	deletion, err := s.deletionRepo.GetByUserID(ctx, user.ID)
+	if err != nil || deletion == nil {
+		return false
+	}
+	return deletion.CancelledBySignIn() && time.Now().Before(deletion.PurgeAt)
+}
+
+// CancelOnSignIn cancels the deletion user requested and restores the status
+// the account had before, updating user to match.
+func (s *AccountDeletionService) CancelOnSignIn(ctx context.Context, user *models.User) error {
+	deletion, err := s.deletionRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if deletion == nil || !deletion.CancelledBySignIn() {
+		return errors.New("account is not active")
+	}
+	if err := s.userRepo.SetStatus(ctx, user.ID, deletion.PreviousStatus); err != nil {
+		return err
+	}
+	if _, err := s.deletionRepo.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+	user.Status = deletion.PreviousStatus
+
+	s.logService.Record(ctx, &user.ID, user.Role, "account_deletion_cancelled", strPtr("user"), strPtr(user.ID.String()), nil)
+	return nil
+}
+
+// PurgeDue purges the accounts whose grace period is over and returns how
+// many were purged. An account that fails is retried on the next run.
+func (s *AccountDeletionService) PurgeDue(ctx context.Context) (int, error) {
+	deletions, err := s.deletionRepo.ListDue(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, deletion := range deletions {
+		if err := s.purge(ctx, deletion); err != nil {
+			s.logger.Error("Failed to purge account", zap.String("user_id", deletion.UserID.String()), zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge hands over organizations the user was the last owner of, removes
+// their records and files, and writes the receipt. The receipt holds only
+// the account ID and what was removed.
+func (s *AccountDeletionService) purge(ctx context.Context, deletion *models.AccountDeletion) error {
+	userID := deletion.UserID
+	if err := s.handOverOrganizations(ctx, userID); err != nil {
+		return err
+	}
+
+	// Read the paths before their records go with the purge
+	var paths []string
+	files, err := s.fileRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	exports, err := s.exportRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if export.FilePath != nil {
+			paths = append(paths, *export.FilePath)
+		}
+	}
+
+	counts, err := s.deletionRepo.Purge(ctx, userID, s.cfg.CRUDPolicy)
+	if err != nil {
+		return err
+	}
+	removed := 0
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("Failed to remove file of purged account", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+
+	s.logService.Record(ctx, nil, "system", "account_purged", strPtr("user"), strPtr(userID.String()), map[string]interface{}{
+		"requested_by":  deletion.RequestedBy,
+		"requested_at":  deletion.RequestedAt.UTC().Format(time.RFC3339),
+		"crud_policy":   s.cfg.CRUDPolicy,
+		"deleted":       counts.Deleted,
+		"anonymized":    counts.Anonymized,
+		"files_removed": removed,
+	})
+	return nil
+}
+
+// handOverOrganizations keeps every organization the user owns with an owner:
+// where they are the last one, the highest-ranking remaining member, longest
+// standing first, becomes owner. Organizations with no one else in them are
+// deleted.
+func (s *AccountDeletionService) handOverOrganizations(ctx context.Context, userID uuid.UUID) error {
+	orgs, err := s.orgRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if org.Role != models.OrganizationRoleOwner {
+			continue
+		}
+		owners, err := s.orgRepo.CountMembersWithRole(ctx, org.ID, models.OrganizationRoleOwner)
+		if err != nil {
+			return err
+		}
+		if owners > 1 {
+			continue
+		}
+
+		members, err := s.orgRepo.ListMembers(ctx, org.ID)
+		if err != nil {
+			return err
+		}
+		var successor *models.OrganizationMember
+		for _, member := range members {
+			if member.UserID == userID {
+				continue
+			}
+			if successor == nil || organizationRoleRank[member.Role] > organizationRoleRank[successor.Role] {
+				successor = member
+			}
+		}
+
+		if successor == nil {
+			if err := s.orgRepo.Delete(ctx, org.ID); err != nil {
+				return err
+			}
+			s.logService.Record(ctx, nil, "system", "organization_deleted", strPtr("organization"), strPtr(org.ID.String()), map[string]interface{}{
+				"reason": "last member's account was purged",
+			})
+			continue
+		}
+		if err := s.orgRepo.UpdateMemberRole(ctx, org.ID, successor.UserID, models.OrganizationRoleOwner); err != nil {
+			return err
+		}
+		s.logService.Record(ctx, nil, "system", "organization_owner_transferred", strPtr("organization"), strPtr(org.ID.String()), map[string]interface{}{
+			"user_id": successor.UserID.String(),
+			"reason":  "last owner's account was purged",
+		})
+	}
+	return nil
+}
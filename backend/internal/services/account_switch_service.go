@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,23 +11,37 @@ import (
 
 	"base-app-service/internal/models"
 	"base-app-service/internal/repositories"
+	"base-app-service/pkg/auth"
 )
 
 type AccountSwitchService struct {
-	accountSwitchRepo repositories.AccountSwitchRepository
-	userRepo          repositories.UserRepository
-	logger            *zap.Logger
+	accountSwitchRepo   repositories.AccountSwitchRepository
+	userRepo            repositories.UserRepository
+	logService          *ActivityLogService
+	notificationService *NotificationService
+	permissions         *PermissionService
+	jwtSecret           string
+	maxImpersonation    time.Duration
+	logger              *zap.Logger
 }
 
 func NewAccountSwitchService(
 	accountSwitchRepo repositories.AccountSwitchRepository,
 	userRepo repositories.UserRepository,
+	logService *ActivityLogService,
+	notificationService *NotificationService,
+	jwtSecret string,
+	maxImpersonation time.Duration,
 	logger *zap.Logger,
 ) *AccountSwitchService {
 	return &AccountSwitchService{
-		accountSwitchRepo: accountSwitchRepo,
-		userRepo:          userRepo,
-		logger:            logger,
+		accountSwitchRepo:   accountSwitchRepo,
+		userRepo:            userRepo,
+		logService:          logService,
+		notificationService: notificationService,
+		jwtSecret:           jwtSecret,
+		maxImpersonation:    maxImpersonation,
+		logger:              logger,
 	}
 }
 
@@ -78,3 +93,144 @@ func (s *AccountSwitchService) GetSwitchHistory(ctx context.Context, userID uuid
 	return s.accountSwitchRepo.GetByUserID(ctx, userID, limit)
 }
 
+
+type ImpersonationSession struct {
+	Switch      *models.AccountSwitch `json:"switch"`
+	AccessToken string                `json:"access_token"`
+	ExpiresAt   time.Time             `json:"expires_at"`
+}
+
+// SetPermissions refuses impersonating users who hold any admin permission,
+// whatever their role. Without it, only the admin role is refused.
+func (s *AccountSwitchService) SetPermissions(permissions *PermissionService) {
+	s.permissions = permissions
+}
+
+// StartImpersonation records an impersonation session and issues a
+// short-lived access token for the target user that names the actor. The
+// target is notified. Accounts with admin permissions cannot be impersonated.
+func (s *AccountSwitchService) StartImpersonation(ctx context.Context, actorID, targetUserID uuid.UUID, reason string, duration time.Duration, ipAddress *string, userAgent *string) (*ImpersonationSession, error) {
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	if actorID == targetUserID {
+		return nil, errors.New("cannot impersonate yourself")
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil || actor == nil {
+		return nil, errors.New("user not found")
+	}
+	target, err := s.userRepo.GetByID(ctx, targetUserID)
+	if err != nil || target == nil {
+		return nil, errors.New("target user not found")
+	}
+	if target.Role == "admin" {
+		return nil, errors.New("cannot impersonate an admin")
+	}
+	if s.permissions != nil {
+		// Every permission is an admin permission, so custom roles and
+		// direct grants count as admin access too
+		held, err := s.permissions.EffectivePermissions(ctx, target.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(held) > 0 {
+			return nil, errors.New("cannot impersonate an admin")
+		}
+	}
+	if target.Status != "active" && target.Status != "pending" {
+		return nil, errors.New("target account is not active")
+	}
+
+	if duration <= 0 || duration > s.maxImpersonation {
+		duration = s.maxImpersonation
+	}
+	now := time.Now()
+	expiresAt := now.Add(duration)
+
+	switchRecord := &models.AccountSwitch{
+		ID:               uuid.New(),
+		UserID:           actorID,
+		SwitchedToUserID: &targetUserID,
+		SwitchedToRole:   &target.Role,
+		SwitchedFromRole: &actor.Role,
+		Reason:           &reason,
+		IPAddress:        ipAddress,
+		UserAgent:        userAgent,
+		ExpiresAt:        &expiresAt,
+		CreatedAt:        now,
+	}
+	if err := s.accountSwitchRepo.Create(ctx, switchRecord); err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateImpersonationToken(
+		target.ID.String(), target.Role, actorID.String(), switchRecord.ID.String(),
+		s.jwtSecret, expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &actorID, actor.Role, "impersonation_started", strPtr("user"), strPtr(targetUserID.String()), map[string]interface{}{
+		"impersonation_id": switchRecord.ID.String(),
+		"reason":           reason,
+		"expires_at":       expiresAt,
+	})
+
+	message := fmt.Sprintf("A member of our support team (%s) is viewing your account until %s. Reason: %s",
+		actor.Name, expiresAt.UTC().Format(time.RFC1123), reason)
+	if _, err := s.notificationService.CreateNotification(ctx, targetUserID, "security", "Support is accessing your account", message, nil, nil); err != nil {
+		s.logger.Warn("Failed to notify impersonated user", zap.Error(err))
+	}
+
+	return &ImpersonationSession{
+		Switch:      switchRecord,
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// StopImpersonation ends an impersonation session. Only the admin who
+// started it can stop it.
+func (s *AccountSwitchService) StopImpersonation(ctx context.Context, actorID, impersonationID uuid.UUID) error {
+	switchRecord, err := s.accountSwitchRepo.GetByID(ctx, impersonationID)
+	if err != nil {
+		return err
+	}
+	if switchRecord == nil || switchRecord.UserID != actorID || switchRecord.ExpiresAt == nil {
+		return errors.New("impersonation session not found")
+	}
+	if switchRecord.EndedAt != nil {
+		return nil
+	}
+
+	if err := s.accountSwitchRepo.End(ctx, impersonationID, time.Now()); err != nil {
+		return err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "impersonation_stopped", strPtr("user"), strPtr(switchRecord.SwitchedToUserID.String()), map[string]interface{}{
+		"impersonation_id": impersonationID.String(),
+	})
+	return nil
+}
+
+// IsImpersonationActive implements middleware.ImpersonationTracker.
+func (s *AccountSwitchService) IsImpersonationActive(ctx context.Context, impersonationID uuid.UUID) bool {
+	switchRecord, err := s.accountSwitchRepo.GetByID(ctx, impersonationID)
+	if err != nil {
+		s.logger.Error("Failed to load impersonation session", zap.Error(err))
+		return false
+	}
+	return switchRecord != nil && switchRecord.IsActiveImpersonation(time.Now())
+}
+
+// RecordImpersonatedRequest implements middleware.ImpersonationTracker.
+func (s *AccountSwitchService) RecordImpersonatedRequest(ctx context.Context, impersonationID, actorID, userID uuid.UUID, method, path string) {
+	s.logService.Record(ctx, &actorID, "admin", "impersonated_request", strPtr("user"), strPtr(userID.String()), map[string]interface{}{
+		"impersonation_id": impersonationID.String(),
+		"method":           method,
+		"path":             path,
+	})
+}
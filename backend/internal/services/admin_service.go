@@ -19,16 +19,18 @@ type AdminService struct {
 	authService *AuthService
 	logService  *ActivityLogService
 	requestRepo repositories.AccessRequestRepository
+	passwords   *PasswordPolicyService
 	logger      *zap.Logger
 }
 
 type AdminLoginRequest struct {
-	Email      string
-	Password   string
-	IPAddress  *string
-	UserAgent  *string
-	DeviceID   *string
-	DeviceName *string
+	Email            string
+	Password         string
+	IPAddress        *string
+	UserAgent        *string
+	DeviceID         *string
+	DeviceName       *string
+	DeviceTrustToken *string
 }
 
 type CreateAdminRequest struct {
@@ -37,15 +39,12 @@ type CreateAdminRequest struct {
 	Password string
 }
 
-const defaultAdminEmail = "admin@gmail.com"
-const defaultAdminPassword = "admin123"
-const defaultAdminName = "Admin"
-
 func NewAdminService(
 	userRepo repositories.UserRepository,
 	authService *AuthService,
 	logService *ActivityLogService,
 	requestRepo repositories.AccessRequestRepository,
+	passwords *PasswordPolicyService,
 	logger *zap.Logger,
 ) *AdminService {
 	return &AdminService{
@@ -53,6 +52,7 @@ func NewAdminService(
 		authService: authService,
 		logService:  logService,
 		requestRepo: requestRepo,
+		passwords:   passwords,
 		logger:      logger,
 	}
 }
@@ -60,44 +60,31 @@ func NewAdminService(
 func (s *AdminService) Login(ctx context.Context, req AdminLoginRequest) (*models.User, *models.Session, error) {
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		if strings.EqualFold(req.Email, defaultAdminEmail) && req.Password == defaultAdminPassword {
-			created, createErr := s.ensureDefaultAdmin(ctx)
-			if createErr != nil {
-				s.logger.Warn("failed to auto-create default admin on login", zap.Error(createErr))
-				return nil, nil, errors.New("invalid credentials")
-			}
-			user = created
-		} else {
-			return nil, nil, errors.New("invalid credentials")
-		}
-	}
-
-	// Auto-repair default admin account if credentials match defaults but stored state is off.
-	if strings.EqualFold(user.Email, defaultAdminEmail) && req.Password == defaultAdminPassword {
-		if repaired, err := s.repairDefaultAdmin(ctx, user); err == nil {
-			user = repaired
-		} else {
-			s.logger.Warn("failed to repair default admin", zap.Error(err))
-		}
+		return nil, nil, errors.New("invalid credentials")
 	}
 
 	if strings.ToLower(user.Role) != "admin" {
 		return nil, nil, errors.New("invalid credentials")
 	}
 
-	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
-		return nil, nil, errors.New("invalid credentials")
+	client := ClientInfo{
+		IPAddress:        req.IPAddress,
+		UserAgent:        req.UserAgent,
+		DeviceID:         req.DeviceID,
+		DeviceName:       req.DeviceName,
+		DeviceTrustToken: req.DeviceTrustToken,
 	}
 
-	if user.Status != "active" && user.Status != "pending" {
-		return nil, nil, errors.New("account is not active")
+	if !s.authService.verifyPassword(ctx, user, req.Password) {
+		s.authService.recordLoginFailure(ctx, user, user.Email, LoginMethodPassword, "invalid_password", client)
+		return nil, nil, errors.New("invalid credentials")
 	}
 
-	now := time.Now()
-	user.LastLoginAt = &now
-	s.userRepo.Update(ctx, user)
+	if err := s.authService.requireSecondFactor(ctx, user, client, LoginMethodPassword); err != nil {
+		return nil, nil, err
+	}
 
-	session, err := s.authService.createSession(ctx, user, req.IPAddress, req.UserAgent, req.DeviceID, req.DeviceName)
+	session, _, err := s.authService.completeLogin(ctx, user, client, LoginMethodPassword)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -113,7 +100,10 @@ func (s *AdminService) AddAdmin(ctx context.Context, actorID uuid.UUID, req Crea
 		return nil, errors.New("admin already exists")
 	}
 
-	passwordHash, err := auth.HashPassword(req.Password)
+	if err := s.passwords.Validate(ctx, nil, req.Password); err != nil {
+		return nil, err
+	}
+	passwordHash, err := auth.GenerateHash(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +144,10 @@ func (s *AdminService) CreateUser(ctx context.Context, actorID uuid.UUID, req Cr
 		return nil, errors.New("user already exists")
 	}
 
-	passwordHash, err := auth.HashPassword(req.Password)
+	if err := s.passwords.Validate(ctx, nil, req.Password); err != nil {
+		return nil, err
+	}
+	passwordHash, err := auth.GenerateHash(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -260,61 +253,6 @@ func strPtr(value string) *string {
 	return &value
 }
 
-func (s *AdminService) ensureDefaultAdmin(ctx context.Context) (*models.User, error) {
-	now := time.Now()
-	passwordHash, err := auth.HashPassword(defaultAdminPassword)
-	if err != nil {
-		return nil, err
-	}
-	admin := &models.User{
-		ID:                uuid.New(),
-		Email:             defaultAdminEmail,
-		PasswordHash:      passwordHash,
-		Name:              defaultAdminName,
-		Status:            "active",
-		Role:              "admin",
-		PasswordChangedAt: now,
-		CreatedAt:         now,
-		UpdatedAt:         now,
-	}
-	if err := s.userRepo.Create(ctx, admin); err != nil {
-		return nil, err
-	}
-	s.logger.Info("Auto-created default admin during login")
-	return admin, nil
-}
-
-func (s *AdminService) repairDefaultAdmin(ctx context.Context, user *models.User) (*models.User, error) {
-	now := time.Now()
-	changed := false
-
-	if strings.ToLower(user.Role) != "admin" {
-		user.Role = "admin"
-		changed = true
-	}
-	if user.Status != "active" && user.Status != "pending" {
-		user.Status = "active"
-		changed = true
-	}
-	if user.Name == "" {
-		user.Name = defaultAdminName
-		changed = true
-	}
-	if !auth.CheckPasswordHash(defaultAdminPassword, user.PasswordHash) {
-		hash, err := auth.HashPassword(defaultAdminPassword)
-		if err != nil {
-			return user, err
-		}
-		user.PasswordHash = hash
-		user.PasswordChangedAt = now
-		changed = true
-	}
-	if changed {
-		user.UpdatedAt = now
-		if err := s.userRepo.Update(ctx, user); err != nil {
-			return user, err
-		}
-		s.logger.Info("Repaired default admin account on login")
-	}
-	return user, nil
+func intPtr(value int) *int {
+	return &value
 }
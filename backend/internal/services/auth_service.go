@@ -12,6 +12,8 @@ import (
 	"base-app-service/internal/models"
 	"base-app-service/internal/repositories"
 	"base-app-service/pkg/auth"
+	"base-app-service/pkg/geoip"
+	"base-app-service/pkg/useragent"
 )
 
 type AuthService struct {
@@ -22,8 +24,53 @@ type AuthService struct {
 	accessExpiry  time.Duration
 	refreshExpiry time.Duration
 	logger        *zap.Logger
+	geoIP         *geoip.Reader
+	passwords     *PasswordPolicyService
+	twoFactor     *TwoFactorService
+	deletions     *AccountDeletionService
+	consents      *ConsentService
+
+	sessionEndedHooks []SessionEndedHook
+	newDeviceHooks    []NewDeviceHook
+	loginEventHooks   []LoginEventHook
 }
 
+// SessionEndedHook is called after Logout revokes sessions, e.g. to end the
+// sessions products hold through base-app sign-in.
+type SessionEndedHook func(ctx context.Context, userID uuid.UUID, sessionIDs []uuid.UUID)
+
+// NewDeviceHook is called after a sign-in from a device the user has not
+// used before, e.g. to alert them.
+type NewDeviceHook func(ctx context.Context, user *models.User, device *models.Device, client ClientInfo)
+
+// LoginEventHook is called for every sign-in attempt and token refresh, e.g.
+// to keep a login history.
+type LoginEventHook func(ctx context.Context, event *models.LoginEvent)
+
+// TwoFactorRequiredError is returned instead of a session when the first
+// sign-in step succeeded but the user has a second factor turned on. Token
+// identifies the pending sign-in when the code is submitted; Destination is
+// where the code went, masked for display.
+type TwoFactorRequiredError struct {
+	Token       string
+	Method      string
+	Destination string
+	ExpiresAt   time.Time
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+// Login methods recorded with login events.
+const (
+	LoginMethodPassword     = "password"
+	LoginMethodPasskey      = "passkey"
+	LoginMethodMagicLink    = "magic_link"
+	LoginMethodOIDC         = "oidc"
+	LoginMethodRefreshToken = "refresh_token"
+)
+
 func NewAuthService(
 	userRepo repositories.UserRepository,
 	sessionRepo repositories.SessionRepository,
@@ -61,13 +108,24 @@ type SignupRequest struct {
 }
 
 type LoginRequest struct {
-	Email      string
-	Password   string
-	RememberMe bool
-	DeviceID   *string
-	DeviceName *string
-	IPAddress  *string
-	UserAgent  *string
+	Email            string
+	Password         string
+	RememberMe       bool
+	DeviceID         *string
+	DeviceName       *string
+	DeviceTrustToken *string
+	IPAddress        *string
+	UserAgent        *string
+}
+
+// ClientInfo carries the request metadata used when a session is created.
+// DeviceTrustToken is the token a trusted device was given, if any.
+type ClientInfo struct {
+	IPAddress        *string
+	UserAgent        *string
+	DeviceID         *string
+	DeviceName       *string
+	DeviceTrustToken *string
 }
 
 func (s *AuthService) Signup(ctx context.Context, req SignupRequest) (*models.User, *models.Session, error) {
@@ -77,13 +135,19 @@ func (s *AuthService) Signup(ctx context.Context, req SignupRequest) (*models.Us
 		return nil, nil, errors.New("email already exists")
 	}
 
+	if !req.TermsAccepted && s.consents != nil {
+		return nil, nil, errors.New("terms must be accepted")
+	}
+	if err := s.checkTerms(ctx, req.TermsVersion); err != nil {
+		return nil, nil, err
+	}
+
 	// Validate and hash password
-	validation := auth.ValidatePassword(req.Password)
-	if !validation.Valid {
-		return nil, nil, fmt.Errorf("password validation failed: %s", validation.Errors[0])
+	if err := s.validateNewPassword(ctx, req.Password); err != nil {
+		return nil, nil, err
 	}
-	
-	passwordHash, err := auth.HashPassword(req.Password)
+
+	passwordHash, err := auth.GenerateHash(req.Password)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -110,8 +174,16 @@ func (s *AuthService) Signup(ctx context.Context, req SignupRequest) (*models.Us
 		return nil, nil, err
 	}
 
+	client := ClientInfo{
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		DeviceID:   req.DeviceID,
+		DeviceName: req.DeviceName,
+	}
+	s.recordSignupConsents(ctx, user, models.ConsentSourceSignup, req.TermsVersion, &req.MarketingConsent, client)
+
 	// Create session
-	session, err := s.createSession(ctx, user, req.IPAddress, req.UserAgent, req.DeviceID, req.DeviceName)
+	session, err := s.createSession(ctx, user, client)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -122,20 +194,101 @@ func (s *AuthService) Signup(ctx context.Context, req SignupRequest) (*models.Us
 }
 
 func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*models.User, *models.Session, bool, error) {
+	client := ClientInfo{
+		IPAddress:        req.IPAddress,
+		UserAgent:        req.UserAgent,
+		DeviceID:         req.DeviceID,
+		DeviceName:       req.DeviceName,
+		DeviceTrustToken: req.DeviceTrustToken,
+	}
+
 	// Get user
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
+		s.recordLoginFailure(ctx, nil, req.Email, LoginMethodPassword, "unknown_email", client)
 		return nil, nil, false, errors.New("invalid credentials")
 	}
 
 	// Check password
-	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
+	if !s.verifyPassword(ctx, user, req.Password) {
+		s.recordLoginFailure(ctx, user, user.Email, LoginMethodPassword, "invalid_password", client)
 		return nil, nil, false, errors.New("invalid credentials")
 	}
 
+	if err := s.requireSecondFactor(ctx, user, client, LoginMethodPassword); err != nil {
+		return nil, nil, false, err
+	}
+
+	session, isNewDevice, err := s.completeLogin(ctx, user, client, LoginMethodPassword)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	s.logger.Info("User logged in", zap.String("user_id", user.ID.String()))
+
+	return user, session, isNewDevice, nil
+}
+
+// verifyPassword checks password against the user's stored hash. On a match,
+// a hash made with an outdated algorithm or cost is replaced with one using the
+// current parameters; failing to do so does not fail the sign-in.
+func (s *AuthService) verifyPassword(ctx context.Context, user *models.User, password string) bool {
+	if !auth.CheckPasswordHash(password, user.PasswordHash) {
+		return false
+	}
+	if auth.NeedsRehash(user.PasswordHash) {
+		hash, err := auth.GenerateHash(password)
+		if err == nil {
+			err = s.userRepo.UpdatePasswordHash(ctx, user.ID, hash)
+		}
+		if err != nil {
+			s.logger.Warn("Failed to upgrade password hash", zap.String("user_id", user.ID.String()), zap.Error(err))
+		} else {
+			user.PasswordHash = hash
+			s.logger.Info("Upgraded password hash", zap.String("user_id", user.ID.String()))
+		}
+	}
+	return true
+}
+
+// requireSecondFactor starts a second-factor challenge when the user has one
+// turned on and is not signing in from a device they trust, returning it as
+// a *TwoFactorRequiredError. It returns nil when the sign-in can complete
+// straight away.
+func (s *AuthService) requireSecondFactor(ctx context.Context, user *models.User, client ClientInfo, method string) error {
+	if s.twoFactor == nil {
+		return nil
+	}
+	required, err := s.twoFactor.Required(ctx, user, client)
+	if err != nil || !required {
+		return err
+	}
+	// Don't send codes for accounts that cannot sign in anyway
+	if !s.canSignIn(ctx, user) {
+		s.recordLoginFailure(ctx, user, user.Email, method, "account_not_active", client)
+		return errors.New("account is not active")
+	}
+	challenge, err := s.twoFactor.Begin(ctx, user, client, method)
+	if err != nil {
+		return err
+	}
+	return challenge
+}
+
+// completeLogin finishes a sign-in once the user has been authenticated by
+// any method: it checks the account status, records the login and device,
+// and starts a session. method names how the user authenticated.
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User, client ClientInfo, method string) (*models.Session, bool, error) {
 	// Check status
-	if user.Status != "active" && user.Status != "pending" {
-		return nil, nil, false, errors.New("account is not active")
+	if !s.canSignIn(ctx, user) {
+		s.recordLoginFailure(ctx, user, user.Email, method, "account_not_active", client)
+		return nil, false, errors.New("account is not active")
+	}
+	// Signing in cancels a deletion the user asked for
+	if user.Status == "deleted" {
+		if err := s.deletions.CancelOnSignIn(ctx, user); err != nil {
+			return nil, false, err
+		}
 	}
 
 	// Update last login
@@ -143,43 +296,149 @@ func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*models.User
 	user.LastLoginAt = &now
 	s.userRepo.Update(ctx, user)
 
-	// Check if device exists
-	var device *models.Device
-	var isNewDevice bool
-	if req.DeviceID != nil {
-		device, _ = s.deviceRepo.GetByDeviceID(ctx, user.ID, req.DeviceID)
-		isNewDevice = device == nil
-
-		// Create or update device
-		if device == nil {
-			device = &models.Device{
-				ID:         uuid.New(),
-				UserID:     user.ID,
-				DeviceID:   *req.DeviceID,
-				DeviceName: req.DeviceName,
-				IPAddress:  req.IPAddress,
-				CreatedAt:  now,
-				LastUsedAt: now,
-			}
-			s.deviceRepo.Create(ctx, device)
-		} else {
-			device.LastUsedAt = now
-			s.deviceRepo.Update(ctx, device)
+	device, isNewDevice := s.touchDevice(ctx, user.ID, client)
+
+	session, err := s.createSession(ctx, user, client)
+	if err != nil {
+		return nil, false, err
+	}
+	s.recordLoginEvent(ctx, &models.LoginEvent{
+		UserID:    &user.ID,
+		Email:     &user.Email,
+		EventType: models.LoginEventSuccess,
+		Method:    &method,
+		SessionID: &session.ID,
+	}, client)
+
+	if isNewDevice && device != nil {
+		for _, hook := range s.newDeviceHooks {
+			hook(ctx, user, device, client)
 		}
 	}
+	return session, isNewDevice, nil
+}
 
-	// Create session
-	session, err := s.createSession(ctx, user, req.IPAddress, req.UserAgent, req.DeviceID, req.DeviceName)
-	if err != nil {
-		return nil, nil, false, err
+// touchDevice creates or refreshes the user_devices row for the client's
+// device ID. It returns nil when the client sent no device ID.
+func (s *AuthService) touchDevice(ctx context.Context, userID uuid.UUID, client ClientInfo) (*models.Device, bool) {
+	if client.DeviceID == nil {
+		return nil, false
 	}
 
-	s.logger.Info("User logged in", zap.String("user_id", user.ID.String()))
+	now := time.Now()
+	details := s.describeClient(client)
+	device, _ := s.deviceRepo.GetByDeviceID(ctx, userID, client.DeviceID)
+	if device == nil {
+		device = &models.Device{
+			ID:              uuid.New(),
+			UserID:          userID,
+			DeviceID:        *client.DeviceID,
+			DeviceName:      client.DeviceName,
+			DeviceType:      details.DeviceType,
+			OS:              details.OS,
+			Browser:         details.Browser,
+			IPAddress:       client.IPAddress,
+			LocationCountry: details.LocationCountry,
+			LocationCity:    details.LocationCity,
+			CreatedAt:       now,
+			LastUsedAt:      now,
+		}
+		if err := s.deviceRepo.Create(ctx, device); err != nil {
+			s.logger.Warn("Failed to record device", zap.Error(err))
+			return nil, true
+		}
+		return device, true
+	}
 
-	return user, session, isNewDevice, nil
+	device.LastUsedAt = now
+	if client.IPAddress != nil {
+		device.IPAddress = client.IPAddress
+		device.LocationCountry = details.LocationCountry
+		device.LocationCity = details.LocationCity
+	}
+	if client.UserAgent != nil {
+		device.DeviceType = details.DeviceType
+		device.OS = details.OS
+		device.Browser = details.Browser
+	}
+	s.deviceRepo.Update(ctx, device)
+	return device, false
+}
+
+// clientDetails is what describeClient derives from a ClientInfo.
+type clientDetails struct {
+	DeviceType      *string
+	OS              *string
+	Browser         *string
+	LocationCountry *string
+	LocationCity    *string
+}
+
+// describeClient parses the client's user agent and looks up its IP address
+// in the GeoIP database, if one is configured.
+func (s *AuthService) describeClient(client ClientInfo) clientDetails {
+	var details clientDetails
+	if client.UserAgent != nil {
+		ua := useragent.Parse(*client.UserAgent)
+		details.DeviceType = optionalString(ua.DeviceType)
+		details.OS = optionalString(ua.OS)
+		details.Browser = optionalString(ua.Browser)
+	}
+	if client.IPAddress != nil {
+		location := s.geoIP.Lookup(*client.IPAddress)
+		details.LocationCountry = optionalString(location.Country)
+		details.LocationCity = optionalString(location.City)
+	}
+	return details
+}
+
+// recordLoginEvent fills in the client details and passes the event to the
+// login event hooks.
+func (s *AuthService) recordLoginEvent(ctx context.Context, event *models.LoginEvent, client ClientInfo) {
+	if len(s.loginEventHooks) == 0 {
+		return
+	}
+
+	details := s.describeClient(client)
+	event.ID = uuid.New()
+	event.IPAddress = client.IPAddress
+	event.UserAgent = client.UserAgent
+	event.DeviceID = client.DeviceID
+	event.DeviceType = details.DeviceType
+	event.OS = details.OS
+	event.Browser = details.Browser
+	event.LocationCountry = details.LocationCountry
+	event.LocationCity = details.LocationCity
+	event.CreatedAt = time.Now()
+
+	for _, hook := range s.loginEventHooks {
+		hook(ctx, event)
+	}
+}
+
+// recordLoginFailure records a failed sign-in. user is nil when the email
+// matched no account.
+func (s *AuthService) recordLoginFailure(ctx context.Context, user *models.User, email, method, reason string, client ClientInfo) {
+	event := &models.LoginEvent{
+		Email:         &email,
+		EventType:     models.LoginEventFailure,
+		Method:        &method,
+		FailureReason: &reason,
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+	s.recordLoginEvent(ctx, event, client)
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
 }
 
-func (s *AuthService) createSession(ctx context.Context, user *models.User, ipAddress, userAgent, deviceID, deviceName *string) (*models.Session, error) {
+func (s *AuthService) createSession(ctx context.Context, user *models.User, client ClientInfo) (*models.Session, error) {
 	sessionID := uuid.New()
 
 	// Generate tokens
@@ -199,15 +458,21 @@ func (s *AuthService) createSession(ctx context.Context, user *models.User, ipAd
 	now := time.Now()
 
 	// Create session
+	details := s.describeClient(client)
 	session := &models.Session{
 		ID:                    sessionID,
 		UserID:                user.ID,
 		Token:                 tokenPair.AccessToken,
 		RefreshToken:          &tokenPair.RefreshToken,
 		RefreshTokenExpiresAt: &refreshExpiresAt,
-		DeviceID:              deviceID,
-		DeviceName:            deviceName,
-		IPAddress:             ipAddress,
+		DeviceID:              client.DeviceID,
+		DeviceType:            details.DeviceType,
+		DeviceName:            client.DeviceName,
+		OS:                    details.OS,
+		Browser:               details.Browser,
+		IPAddress:             client.IPAddress,
+		LocationCountry:       details.LocationCountry,
+		LocationCity:          details.LocationCity,
 		IsActive:              true,
 		ExpiresAt:             tokenPair.ExpiresAt,
 		CreatedAt:             now,
@@ -221,7 +486,7 @@ func (s *AuthService) createSession(ctx context.Context, user *models.User, ipAd
 	return session, nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*models.Session, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*models.Session, error) {
 	// Validate refresh token
 	claims, err := auth.ValidateToken(refreshToken, s.jwtSecret)
 	if err != nil {
@@ -265,17 +530,235 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, err
 	}
 
+	method := LoginMethodRefreshToken
+	s.recordLoginEvent(ctx, &models.LoginEvent{
+		UserID:    &session.UserID,
+		EventType: models.LoginEventTokenRefresh,
+		Method:    &method,
+		SessionID: &session.ID,
+	}, client)
+
 	return session, nil
 }
 
+// OnSessionsEnded registers a hook that runs after Logout.
+func (s *AuthService) OnSessionsEnded(hook SessionEndedHook) {
+	s.sessionEndedHooks = append(s.sessionEndedHooks, hook)
+}
+
+// OnLoginEvent registers a hook that runs for every sign-in attempt and
+// token refresh.
+func (s *AuthService) OnLoginEvent(hook LoginEventHook) {
+	s.loginEventHooks = append(s.loginEventHooks, hook)
+}
+
+// SetPasswordPolicy sets the policy new passwords are checked against.
+// Without one, auth.DefaultPasswordPolicy applies.
+func (s *AuthService) SetPasswordPolicy(passwords *PasswordPolicyService) {
+	s.passwords = passwords
+}
+
+// SetTwoFactor enables second-factor challenges at sign-in for users who
+// have turned one on.
+func (s *AuthService) SetTwoFactor(twoFactor *TwoFactorService) {
+	s.twoFactor = twoFactor
+}
+
+// SetAccountDeletion lets users sign in to accounts they asked to delete,
+// which cancels the deletion. Without it, deleted accounts cannot sign in.
+func (s *AuthService) SetAccountDeletion(deletions *AccountDeletionService) {
+	s.deletions = deletions
+}
+
+// SetConsents records the terms accepted and the marketing choice made when
+// accounts are created, and checks the terms version against the published
+// ones. Without it, neither is kept.
+func (s *AuthService) SetConsents(consents *ConsentService) {
+	s.consents = consents
+}
+
+// ConsentRequired reports whether user has to accept a newly published
+// version of a legal document. It is always false without SetConsents.
+func (s *AuthService) ConsentRequired(ctx context.Context, user *models.User) bool {
+	if s.consents == nil {
+		return false
+	}
+	pending, err := s.consents.Pending(ctx, user.ID)
+	if err != nil {
+		s.logger.Warn("Failed to check pending consents", zap.String("user_id", user.ID.String()), zap.Error(err))
+		return false
+	}
+	return len(pending) > 0
+}
+
+// checkTerms checks the terms version accepted for a new account.
+func (s *AuthService) checkTerms(ctx context.Context, version string) error {
+	if s.consents == nil {
+		return nil
+	}
+	return s.consents.CheckTerms(ctx, version)
+}
+
+// recordSignupConsents adds the consents given with a new account to the
+// ledger. The account is kept if that fails; the user is then asked to
+// accept the terms again.
+func (s *AuthService) recordSignupConsents(ctx context.Context, user *models.User, source, termsVersion string, marketing *bool, client ClientInfo) {
+	if s.consents == nil {
+		return
+	}
+	if err := s.consents.RecordSignup(ctx, user.ID, source, termsVersion, marketing, client); err != nil {
+		s.logger.Error("Failed to record consents", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
+
+// canSignIn reports whether the account's status lets it sign in.
+func (s *AuthService) canSignIn(ctx context.Context, user *models.User) bool {
+	if user.Status == "active" || user.Status == "pending" {
+		return true
+	}
+	return s.deletions != nil && s.deletions.CancelledBySignIn(ctx, user)
+}
+
+// PasswordChangeRequired reports whether user must change their password
+// before using the account. It is always false without a password policy.
+func (s *AuthService) PasswordChangeRequired(ctx context.Context, user *models.User) bool {
+	if s.passwords == nil {
+		return false
+	}
+	return s.passwords.ChangeRequired(ctx, user)
+}
+
+func (s *AuthService) validateNewPassword(ctx context.Context, password string) error {
+	if s.passwords != nil {
+		return s.passwords.Validate(ctx, nil, password)
+	}
+	validation := auth.ValidatePassword(password)
+	if !validation.Valid {
+		return fmt.Errorf("password validation failed: %s", validation.Errors[0])
+	}
+	return nil
+}
+
+// SetGeoIP sets the database used to locate sessions and devices by IP
+// address. Without one, location fields are left empty.
+func (s *AuthService) SetGeoIP(reader *geoip.Reader) {
+	s.geoIP = reader
+}
+
+// OnNewDevice registers a hook that runs after a sign-in from a new device.
+func (s *AuthService) OnNewDevice(hook NewDeviceHook) {
+	s.newDeviceHooks = append(s.newDeviceHooks, hook)
+}
+
+// RevokeDeviceSessions ends every active session the user holds on a device.
+func (s *AuthService) RevokeDeviceSessions(ctx context.Context, userID uuid.UUID, deviceID string) error {
+	sessions, err := s.sessionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var ended []uuid.UUID
+	for _, session := range sessions {
+		if !session.IsActive || session.DeviceID == nil || *session.DeviceID != deviceID {
+			continue
+		}
+		if err := s.sessionRepo.Revoke(ctx, session.ID); err != nil {
+			return err
+		}
+		ended = append(ended, session.ID)
+	}
+
+	if len(ended) > 0 {
+		for _, hook := range s.sessionEndedHooks {
+			hook(ctx, userID, ended)
+		}
+	}
+	return nil
+}
+
+// IsSessionActive implements middleware.SessionChecker: an access token is
+// only accepted while the session it was issued for is active.
+func (s *AuthService) IsSessionActive(ctx context.Context, userID, sessionID uuid.UUID) bool {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	return err == nil && session != nil && session.IsActive && session.UserID == userID
+}
+
+// RevokeSession ends one of the user's sessions, e.g. from the active
+// sessions list. Other users' sessions are reported as not found.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+	if err := s.sessionRepo.Revoke(ctx, sessionID); err != nil {
+		return err
+	}
+
+	for _, hook := range s.sessionEndedHooks {
+		hook(ctx, userID, []uuid.UUID{sessionID})
+	}
+	return nil
+}
+
+// RevokeOtherSessions ends all of the user's sessions except keepSessionID,
+// e.g. after a credential change. Pass uuid.Nil to end every session.
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	sessions, err := s.sessionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var ended []uuid.UUID
+	for _, session := range sessions {
+		if !session.IsActive || session.ID == keepSessionID {
+			continue
+		}
+		if err := s.sessionRepo.Revoke(ctx, session.ID); err != nil {
+			return err
+		}
+		ended = append(ended, session.ID)
+	}
+
+	if len(ended) > 0 {
+		for _, hook := range s.sessionEndedHooks {
+			hook(ctx, userID, ended)
+		}
+	}
+	return nil
+}
+
 func (s *AuthService) Logout(ctx context.Context, sessionID uuid.UUID, revokeAll bool) error {
+	// Get user ID from session first
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if revokeAll {
+			return err
+		}
+		// Already ended; revoking again is harmless
+		return s.sessionRepo.Revoke(ctx, sessionID)
+	}
+
+	ended := []uuid.UUID{sessionID}
 	if revokeAll {
-		// Get user ID from session first
-		session, err := s.sessionRepo.GetByID(ctx, sessionID)
+		sessions, err := s.sessionRepo.GetByUserID(ctx, session.UserID)
 		if err != nil {
 			return err
 		}
-		return s.sessionRepo.RevokeAllForUser(ctx, session.UserID)
+		ended = ended[:0]
+		for _, sess := range sessions {
+			if sess.IsActive {
+				ended = append(ended, sess.ID)
+			}
+		}
+		if err := s.sessionRepo.RevokeAllForUser(ctx, session.UserID); err != nil {
+			return err
+		}
+	} else if err := s.sessionRepo.Revoke(ctx, sessionID); err != nil {
+		return err
+	}
+
+	for _, hook := range s.sessionEndedHooks {
+		hook(ctx, session.UserID, ended)
 	}
-	return s.sessionRepo.Revoke(ctx, sessionID)
+	return nil
 }
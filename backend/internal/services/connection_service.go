@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+const (
+	defaultFriendsPageSize = 20
+	maxFriendsPageSize     = 100
+)
+
+// ConnectionService manages friendships and blocks between users. A friend
+// request becomes a friendship when the other user accepts it, or straight
+// away when they had already asked. Blocking someone ends any friendship or
+// request between the two and keeps them from finding, viewing, befriending
+// or messaging each other.
+type ConnectionService struct {
+	connectionRepo      repositories.ConnectionRepository
+	userRepo            repositories.UserRepository
+	privacy             *PrivacyService
+	notificationService *NotificationService
+	logger              *zap.Logger
+}
+
+func NewConnectionService(
+	connectionRepo repositories.ConnectionRepository,
+	userRepo repositories.UserRepository,
+	privacy *PrivacyService,
+	notificationService *NotificationService,
+	logger *zap.Logger,
+) *ConnectionService {
+	return &ConnectionService{
+		connectionRepo:      connectionRepo,
+		userRepo:            userRepo,
+		privacy:             privacy,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// SendRequest asks targetID to be userID's friend.
+func (s *ConnectionService) SendRequest(ctx context.Context, userID, targetID uuid.UUID) (*models.Connection, error) {
+	if userID == targetID {
+		return nil, errors.New("cannot send a friend request to yourself")
+	}
+	target, err := s.userRepo.GetByID(ctx, targetID)
+	if err != nil || target == nil || !visibleStatus(target.Status) {
+		return nil, errors.New("user not found")
+	}
+	blocked, err := s.connectionRepo.IsBlocked(ctx, userID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, errors.New("cannot send a friend request to this user")
+	}
+
+	existing, err := s.connectionRepo.GetBetween(ctx, userID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		switch {
+		case existing.Status == models.ConnectionAccepted:
+			return nil, errors.New("already friends")
+		case existing.RequesterID == userID:
+			return nil, errors.New("friend request already sent")
+		default:
+			// They asked first, so asking back accepts
+			return s.AcceptRequest(ctx, userID, existing.ID)
+		}
+	}
+
+	connection := &models.Connection{
+		ID:          uuid.New(),
+		RequesterID: userID,
+		AddresseeID: targetID,
+		Status:      models.ConnectionPending,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.connectionRepo.Create(ctx, connection); err != nil {
+		return nil, fmt.Errorf("failed to create friend request: %w", err)
+	}
+
+	s.notify(ctx, targetID, userID, connection.ID, "New friend request", "%s wants to be your friend")
+	return connection, nil
+}
+
+// AcceptRequest accepts a request sent to userID.
+func (s *ConnectionService) AcceptRequest(ctx context.Context, userID, requestID uuid.UUID) (*models.Connection, error) {
+	connection, err := s.incomingRequest(ctx, userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	accepted, err := s.connectionRepo.Accept(ctx, connection.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, errors.New("friend request not found")
+	}
+	connection.Status = models.ConnectionAccepted
+	connection.AcceptedAt = &now
+
+	s.notify(ctx, connection.RequesterID, userID, connection.ID, "Friend request accepted", "%s accepted your friend request")
+	return connection, nil
+}
+
+// DeclineRequest turns down a request sent to userID. The requester is not
+// told and may ask again.
+func (s *ConnectionService) DeclineRequest(ctx context.Context, userID, requestID uuid.UUID) error {
+	connection, err := s.incomingRequest(ctx, userID, requestID)
+	if err != nil {
+		return err
+	}
+	return s.connectionRepo.Delete(ctx, connection.ID)
+}
+
+// CancelRequest withdraws a request userID sent.
+func (s *ConnectionService) CancelRequest(ctx context.Context, userID, requestID uuid.UUID) error {
+	connection, err := s.connectionRepo.GetByID(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if connection == nil || connection.Status != models.ConnectionPending || connection.RequesterID != userID {
+		return errors.New("friend request not found")
+	}
+	return s.connectionRepo.Delete(ctx, connection.ID)
+}
+
+// RemoveFriend ends the friendship between userID and friendID.
+func (s *ConnectionService) RemoveFriend(ctx context.Context, userID, friendID uuid.UUID) error {
+	connection, err := s.connectionRepo.GetBetween(ctx, userID, friendID)
+	if err != nil {
+		return err
+	}
+	if connection == nil || connection.Status != models.ConnectionAccepted {
+		return errors.New("not friends")
+	}
+	return s.connectionRepo.Delete(ctx, connection.ID)
+}
+
+// ListFriends returns a page of userID's friends and how many they have.
+func (s *ConnectionService) ListFriends(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Friend, int, error) {
+	if limit <= 0 {
+		limit = defaultFriendsPageSize
+	}
+	if limit > maxFriendsPageSize {
+		limit = maxFriendsPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	connections, total, err := s.connectionRepo.ListFriends(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	friends := make([]*models.Friend, 0, len(connections))
+	for _, connection := range connections {
+		profile, err := s.privacy.Profile(ctx, userID, connection.Other(userID))
+		if err != nil {
+			continue
+		}
+		friends = append(friends, &models.Friend{PublicProfile: profile, FriendsSince: connection.AcceptedAt})
+	}
+	return friends, total, nil
+}
+
+// ListRequests returns pending requests sent to userID, or sent by them when
+// incoming is false.
+func (s *ConnectionService) ListRequests(ctx context.Context, userID uuid.UUID, incoming bool) ([]*models.FriendRequest, error) {
+	connections, err := s.connectionRepo.ListPending(ctx, userID, incoming)
+	if err != nil {
+		return nil, err
+	}
+	direction := "outgoing"
+	if incoming {
+		direction = "incoming"
+	}
+	requests := make([]*models.FriendRequest, 0, len(connections))
+	for _, connection := range connections {
+		profile, err := s.privacy.Profile(ctx, userID, connection.Other(userID))
+		if err != nil {
+			continue
+		}
+		requests = append(requests, &models.FriendRequest{
+			ID:        connection.ID,
+			Direction: direction,
+			User:      profile,
+			CreatedAt: connection.CreatedAt,
+		})
+	}
+	return requests, nil
+}
+
+// Block blocks targetID for userID and ends any friendship or request
+// between them.
+func (s *ConnectionService) Block(ctx context.Context, userID, targetID uuid.UUID) error {
+	if userID == targetID {
+		return errors.New("cannot block yourself")
+	}
+	target, err := s.userRepo.GetByID(ctx, targetID)
+	if err != nil || target == nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.connectionRepo.Block(ctx, &models.UserBlock{BlockerID: userID, BlockedID: targetID, CreatedAt: time.Now()}); err != nil {
+		return err
+	}
+	connection, err := s.connectionRepo.GetBetween(ctx, userID, targetID)
+	if err != nil {
+		return err
+	}
+	if connection != nil {
+		return s.connectionRepo.Delete(ctx, connection.ID)
+	}
+	return nil
+}
+
+func (s *ConnectionService) Unblock(ctx context.Context, userID, targetID uuid.UUID) error {
+	unblocked, err := s.connectionRepo.Unblock(ctx, userID, targetID)
+	if err != nil {
+		return err
+	}
+	if !unblocked {
+		return errors.New("user is not blocked")
+	}
+	return nil
+}
+
+func (s *ConnectionService) ListBlocked(ctx context.Context, userID uuid.UUID) ([]*models.BlockedUser, error) {
+	blocks, err := s.connectionRepo.ListBlocked(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make([]*models.BlockedUser, 0, len(blocks))
+	for _, block := range blocks {
+		user, err := s.userRepo.GetByID(ctx, block.BlockedID)
+		if err != nil || user == nil {
+			continue
+		}
+		blocked = append(blocked, &models.BlockedUser{UserID: user.ID, Name: user.Name, BlockedAt: block.CreatedAt})
+	}
+	return blocked, nil
+}
+
+func (s *ConnectionService) incomingRequest(ctx context.Context, userID, requestID uuid.UUID) (*models.Connection, error) {
+	connection, err := s.connectionRepo.GetByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if connection == nil || connection.Status != models.ConnectionPending || connection.AddresseeID != userID {
+		return nil, errors.New("friend request not found")
+	}
+	return connection, nil
+}
+
+// notify tells recipientID about a request from or acceptance by actorID.
+// message has a %s for the actor's name.
+func (s *ConnectionService) notify(ctx context.Context, recipientID, actorID, connectionID uuid.UUID, title, message string) {
+	name := "Someone"
+	if actor, err := s.userRepo.GetByID(ctx, actorID); err == nil && actor != nil {
+		name = actor.Name
+	}
+	metadata, _ := json.Marshal(map[string]string{
+		"connection_id": connectionID.String(),
+		"user_id":       actorID.String(),
+	})
+	if _, err := s.notificationService.CreateNotification(ctx, recipientID, "social", title, fmt.Sprintf(message, name),
+		strPtr("/friends"), strPtr(string(metadata))); err != nil {
+		s.logger.Warn("Failed to create friend notification", zap.String("user_id", recipientID.String()), zap.Error(err))
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// ConsentService keeps the consent ledger. Admins publish versions of the
+// terms of service and privacy policy; users accept them at sign-up or when
+// prompted, and opt in or out of marketing. Every acceptance and marketing
+// choice is recorded with when and from where it was made.
+type ConsentService struct {
+	consentRepo repositories.ConsentRepository
+	logService  *ActivityLogService
+	logger      *zap.Logger
+}
+
+func NewConsentService(consentRepo repositories.ConsentRepository, logService *ActivityLogService, logger *zap.Logger) *ConsentService {
+	return &ConsentService{
+		consentRepo: consentRepo,
+		logService:  logService,
+		logger:      logger,
+	}
+}
+
+// PublishDocumentRequest is a new version of a legal document. With
+// Required, users who have not accepted it are asked to.
+type PublishDocumentRequest struct {
+	Kind     string
+	Version  string
+	Title    string
+	URL      *string
+	Required bool
+}
+
+// Publish publishes a new version of a legal document, which becomes the
+// current one.
+func (s *ConsentService) Publish(ctx context.Context, adminID uuid.UUID, req PublishDocumentRequest) (*models.LegalDocument, error) {
+	if !isLegalDocumentKind(req.Kind) {
+		return nil, errors.New("unknown document kind")
+	}
+	version := strings.TrimSpace(req.Version)
+	title := strings.TrimSpace(req.Title)
+	switch {
+	case version == "":
+		return nil, errors.New("version is required")
+	case len(version) > 50:
+		return nil, errors.New("version is too long")
+	case title == "":
+		return nil, errors.New("title is required")
+	}
+
+	existing, err := s.consentRepo.GetDocument(ctx, req.Kind, version)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("version already published")
+	}
+
+	doc := &models.LegalDocument{
+		ID:          uuid.New(),
+		Kind:        req.Kind,
+		Version:     version,
+		Title:       title,
+		URL:         req.URL,
+		Required:    req.Required,
+		PublishedBy: &adminID,
+		PublishedAt: time.Now(),
+	}
+	if err := s.consentRepo.CreateDocument(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &adminID, "admin", "legal_document_published", strPtr("legal_document"), strPtr(doc.ID.String()), map[string]interface{}{
+		"kind":     doc.Kind,
+		"version":  doc.Version,
+		"required": doc.Required,
+	})
+	return doc, nil
+}
+
+// Current returns the current version of each legal document that has been
+// published. It is public, so who published them is left out, as it is from
+// Pending.
+func (s *ConsentService) Current(ctx context.Context) ([]*models.LegalDocument, error) {
+	docs, err := s.documentsByKind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := make([]*models.LegalDocument, 0, len(docs))
+	for _, kind := range models.LegalDocumentKinds {
+		if len(docs[kind]) > 0 {
+			doc := docs[kind][0]
+			doc.PublishedBy = nil
+			current = append(current, doc)
+		}
+	}
+	return current, nil
+}
+
+// Documents returns every published version, newest first, with how many
+// users accepted each.
+func (s *ConsentService) Documents(ctx context.Context) ([]*models.LegalDocument, error) {
+	docs, err := s.consentRepo.ListDocuments(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.consentRepo.CountAcceptances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		count := counts[doc.Kind][doc.Version]
+		doc.Acceptances = &count
+	}
+	return docs, nil
+}
+
+// Pending returns the current version of each document the user has to
+// accept: those with a required version published since the version they
+// last accepted.
+func (s *ConsentService) Pending(ctx context.Context, userID uuid.UUID) ([]*models.LegalDocument, error) {
+	docs, err := s.documentsByKind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := []*models.LegalDocument{}
+	for _, kind := range models.LegalDocumentKinds {
+		acceptable, required := acceptableVersions(docs[kind])
+		if !required {
+			continue
+		}
+		latest, err := s.consentRepo.LatestRecord(ctx, userID, kind)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil || latest.Version == nil || !acceptable[*latest.Version] {
+			doc := docs[kind][0]
+			doc.PublishedBy = nil
+			pending = append(pending, doc)
+		}
+	}
+	return pending, nil
+}
+
+// Status returns the documents the user has to accept, their marketing
+// choice and their consent ledger.
+func (s *ConsentService) Status(ctx context.Context, userID uuid.UUID) (*models.ConsentStatus, error) {
+	pending, err := s.Pending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	marketing, err := s.MarketingAllowed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.consentRepo.ListRecords(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []*models.ConsentRecord{}
+	}
+	return &models.ConsentStatus{Pending: pending, Marketing: marketing, History: history}, nil
+}
+
+// Accept records the user accepting the current version of a document.
+func (s *ConsentService) Accept(ctx context.Context, userID uuid.UUID, kind, version string, client ClientInfo) (*models.ConsentRecord, error) {
+	if !isLegalDocumentKind(kind) {
+		return nil, errors.New("unknown document kind")
+	}
+	docs, err := s.consentRepo.ListDocuments(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 || docs[0].Version != version {
+		return nil, errors.New("only the current version can be accepted")
+	}
+	return s.record(ctx, userID, kind, &version, true, models.ConsentSourceUser, client)
+}
+
+// SetMarketing records the user opting in or out of marketing. Nothing is
+// recorded when the choice does not change.
+func (s *ConsentService) SetMarketing(ctx context.Context, userID uuid.UUID, granted bool, client ClientInfo) (*models.ConsentRecord, error) {
+	latest, err := s.consentRepo.LatestRecord(ctx, userID, models.ConsentMarketing)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Granted == granted {
+		return latest, nil
+	}
+	return s.record(ctx, userID, models.ConsentMarketing, nil, granted, models.ConsentSourceUser, client)
+}
+
+// MarketingAllowed reports whether the user has currently opted in to
+// marketing. Anything promotional sent to a user must check it first.
+func (s *ConsentService) MarketingAllowed(ctx context.Context, userID uuid.UUID) (bool, error) {
+	latest, err := s.consentRepo.LatestRecord(ctx, userID, models.ConsentMarketing)
+	if err != nil {
+		return false, err
+	}
+	return latest != nil && latest.Granted, nil
+}
+
+// CheckTerms checks the terms version accepted when an account is created.
+// Until terms are published any version is taken as given; afterwards it has
+// to be one that would not be asked to be accepted again.
+func (s *ConsentService) CheckTerms(ctx context.Context, version string) error {
+	if strings.TrimSpace(version) == "" {
+		return errors.New("terms version is required")
+	}
+	docs, err := s.consentRepo.ListDocuments(ctx, models.LegalDocumentTerms)
+	if err != nil || len(docs) == 0 {
+		return err
+	}
+	acceptable, _ := acceptableVersions(docs)
+	if !acceptable[version] {
+		for _, doc := range docs {
+			if doc.Version == version {
+				return errors.New("terms version is out of date")
+			}
+		}
+		return errors.New("unknown terms version")
+	}
+	return nil
+}
+
+// RecordSignup records the terms accepted when an account was created and,
+// if one was made, the marketing choice.
+func (s *ConsentService) RecordSignup(ctx context.Context, userID uuid.UUID, source, termsVersion string, marketing *bool, client ClientInfo) error {
+	if _, err := s.record(ctx, userID, models.LegalDocumentTerms, &termsVersion, true, source, client); err != nil {
+		return err
+	}
+	if marketing != nil {
+		if _, err := s.record(ctx, userID, models.ConsentMarketing, nil, *marketing, source, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ConsentService) record(ctx context.Context, userID uuid.UUID, kind string, version *string, granted bool, source string, client ClientInfo) (*models.ConsentRecord, error) {
+	record := &models.ConsentRecord{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Kind:      kind,
+		Version:   version,
+		Granted:   granted,
+		Source:    source,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		CreatedAt: time.Now(),
+	}
+	if err := s.consentRepo.CreateRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// documentsByKind returns the published versions of each document, newest
+// first.
+func (s *ConsentService) documentsByKind(ctx context.Context) (map[string][]*models.LegalDocument, error) {
+	docs, err := s.consentRepo.ListDocuments(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	byKind := make(map[string][]*models.LegalDocument)
+	for _, doc := range docs {
+		byKind[doc.Kind] = append(byKind[doc.Kind], doc)
+	}
+	return byKind, nil
+}
+
+// acceptableVersions returns the versions of a document, given newest first,
+// that satisfy its latest required version: that version and any published
+// after it. Without a required version every version does, and required is
+// false.
+func acceptableVersions(docs []*models.LegalDocument) (acceptable map[string]bool, required bool) {
+	acceptable = make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		acceptable[doc.Version] = true
+		if doc.Required {
+			return acceptable, true
+		}
+	}
+	return acceptable, false
+}
+
+func isLegalDocumentKind(kind string) bool {
+	for _, k := range models.LegalDocumentKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -25,33 +25,51 @@ func NewCRUDTemplateService(templateRepo repositories.CRUDTemplateRepository, lo
 	}
 }
 
-// CreateTemplate creates a new CRUD template
-func (s *CRUDTemplateService) CreateTemplate(ctx context.Context, createdBy uuid.UUID, name, displayName string, description *string, schema map[string]interface{}, icon, category *string) (*models.CRUDTemplate, error) {
+// canManageTemplates reports whether the tenant may change its templates: the
+// platform's need Unrestricted, an organization's the owner or admin role.
+func canManageTemplates(tenant *models.Tenant) bool {
+	if tenant.OrganizationID == nil {
+		return tenant.Unrestricted
+	}
+	return tenant.CanManage()
+}
+
+// CreateTemplate creates a new CRUD template for the tenant's organization,
+// or for the whole platform outside one
+func (s *CRUDTemplateService) CreateTemplate(ctx context.Context, tenant *models.Tenant, name, displayName string, description *string, schema map[string]interface{}, icon, category *string) (*models.CRUDTemplate, error) {
+	if !canManageTemplates(tenant) {
+		return nil, errors.New("not allowed to manage these templates")
+	}
+
 	// Validate schema is valid JSON
 	schemaJSON, err := json.Marshal(schema)
 	if err != nil {
 		return nil, errors.New("invalid schema format")
 	}
 
-	// Check if template name already exists
-	existing, _ := s.templateRepo.GetByName(ctx, name)
-	if existing != nil {
+	// Check if template name already exists (organizations may reuse platform names)
+	existing, err := s.templateRepo.GetByName(ctx, name, tenant.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && (existing.OrganizationID == nil) == (tenant.OrganizationID == nil) {
 		return nil, errors.New("template name already exists")
 	}
 
 	template := &models.CRUDTemplate{
-		ID:          uuid.New(),
-		Name:        name,
-		DisplayName: displayName,
-		Description: description,
-		Schema:      string(schemaJSON),
-		Icon:        icon,
-		Category:    category,
-		CreatedBy:   createdBy,
-		IsActive:    true,
-		IsSystem:    false, // User-created templates are not system templates
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:             uuid.New(),
+		OrganizationID: tenant.OrganizationID,
+		Name:           name,
+		DisplayName:    displayName,
+		Description:    description,
+		Schema:         string(schemaJSON),
+		Icon:           icon,
+		Category:       category,
+		CreatedBy:      tenant.UserID,
+		IsActive:       true,
+		IsSystem:       false, // User-created templates are not system templates
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
 	if err := s.templateRepo.Create(ctx, template); err != nil {
@@ -61,19 +79,19 @@ func (s *CRUDTemplateService) CreateTemplate(ctx context.Context, createdBy uuid
 	return template, nil
 }
 
-// GetTemplate retrieves a template by ID
-func (s *CRUDTemplateService) GetTemplate(ctx context.Context, id uuid.UUID) (*models.CRUDTemplate, error) {
-	return s.templateRepo.GetByID(ctx, id)
+// GetTemplate retrieves one of the tenant's own templates by ID
+func (s *CRUDTemplateService) GetTemplate(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CRUDTemplate, error) {
+	return s.templateRepo.GetByID(ctx, id, tenant.OrganizationID)
 }
 
-// GetTemplateByName retrieves a template by name
-func (s *CRUDTemplateService) GetTemplateByName(ctx context.Context, name string) (*models.CRUDTemplate, error) {
-	return s.templateRepo.GetByName(ctx, name)
+// GetTemplateByName retrieves a template the tenant can use by name
+func (s *CRUDTemplateService) GetTemplateByName(ctx context.Context, name string, tenant *models.Tenant) (*models.CRUDTemplate, error) {
+	return s.templateRepo.GetByName(ctx, name, tenant.OrganizationID)
 }
 
-// ListTemplates retrieves all templates, optionally filtered
-func (s *CRUDTemplateService) ListTemplates(ctx context.Context, category *string, activeOnly bool) ([]*models.CRUDTemplate, error) {
-	return s.templateRepo.List(ctx, category, activeOnly)
+// ListTemplates retrieves the templates the tenant can use, optionally filtered
+func (s *CRUDTemplateService) ListTemplates(ctx context.Context, tenant *models.Tenant, category *string, activeOnly bool) ([]*models.CRUDTemplate, error) {
+	return s.templateRepo.List(ctx, tenant.OrganizationID, category, activeOnly)
 }
 
 // ListTemplatesByCreator retrieves templates created by a specific admin
@@ -82,14 +100,17 @@ func (s *CRUDTemplateService) ListTemplatesByCreator(ctx context.Context, create
 }
 
 // UpdateTemplate updates an existing template
-func (s *CRUDTemplateService) UpdateTemplate(ctx context.Context, id uuid.UUID, displayName *string, description *string, schema map[string]interface{}, icon, category *string) (*models.CRUDTemplate, error) {
-	template, err := s.templateRepo.GetByID(ctx, id)
+func (s *CRUDTemplateService) UpdateTemplate(ctx context.Context, id uuid.UUID, tenant *models.Tenant, displayName *string, description *string, schema map[string]interface{}, icon, category *string) (*models.CRUDTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id, tenant.OrganizationID)
 	if err != nil {
 		return nil, err
 	}
 	if template == nil {
 		return nil, errors.New("template not found")
 	}
+	if !canManageTemplates(tenant) {
+		return nil, errors.New("not allowed to manage these templates")
+	}
 
 	if displayName != nil {
 		template.DisplayName = *displayName
@@ -120,14 +141,17 @@ func (s *CRUDTemplateService) UpdateTemplate(ctx context.Context, id uuid.UUID,
 }
 
 // DeleteTemplate deletes a template (only if not system template)
-func (s *CRUDTemplateService) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
-	template, err := s.templateRepo.GetByID(ctx, id)
+func (s *CRUDTemplateService) DeleteTemplate(ctx context.Context, id uuid.UUID, tenant *models.Tenant) error {
+	template, err := s.templateRepo.GetByID(ctx, id, tenant.OrganizationID)
 	if err != nil {
 		return err
 	}
 	if template == nil {
 		return errors.New("template not found")
 	}
+	if !canManageTemplates(tenant) {
+		return errors.New("not allowed to manage these templates")
+	}
 	if template.IsSystem {
 		return errors.New("cannot delete system template")
 	}
@@ -153,5 +177,3 @@ func (s *CRUDTemplateService) GetTemplateSchemaAsMap(template *models.CRUDTempla
 	}
 	return schema, nil
 }
-
-
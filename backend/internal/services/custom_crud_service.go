@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"time"
@@ -25,29 +26,41 @@ func NewCustomCRUDService(crudRepo repositories.CustomCRUDRepository, logger *za
 	}
 }
 
-func (s *CustomCRUDService) CreateEntity(ctx context.Context, createdBy uuid.UUID, entityName, displayName string, description *string, schema map[string]interface{}) (*models.CustomCRUDEntity, error) {
+// CreateEntity creates an entity for the tenant. In an organization only
+// owners and admins may define entities; members work with their data.
+func (s *CustomCRUDService) CreateEntity(ctx context.Context, tenant *models.Tenant, entityName, displayName string, description *string, schema map[string]interface{}) (*models.CustomCRUDEntity, error) {
+	if !tenant.CanManage() {
+		return nil, errors.New("organization admin role required")
+	}
+
 	// Validate schema is valid JSON
 	schemaJSON, err := json.Marshal(schema)
 	if err != nil {
 		return nil, errors.New("invalid schema format")
 	}
 
-	// Check if entity name already exists for this user (allow same name for different users)
-	existing, _ := s.crudRepo.GetEntityByName(ctx, entityName)
-	if existing != nil && existing.CreatedBy == createdBy {
+	// Names are unique per user, or per organization (the same name may exist elsewhere)
+	owner := *tenant
+	owner.Unrestricted = false
+	existing, err := s.crudRepo.GetEntityByName(ctx, entityName, &owner)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
 		return nil, errors.New("you already have an entity with this name")
 	}
 
 	entity := &models.CustomCRUDEntity{
-		ID:          uuid.New(),
-		CreatedBy:   createdBy,
-		EntityName:  entityName,
-		DisplayName: displayName,
-		Description: description,
-		Schema:      string(schemaJSON),
-		IsActive:    true,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:             uuid.New(),
+		OrganizationID: tenant.OrganizationID,
+		CreatedBy:      &tenant.UserID,
+		EntityName:     entityName,
+		DisplayName:    displayName,
+		Description:    description,
+		Schema:         string(schemaJSON),
+		IsActive:       true,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
 	if err := s.crudRepo.CreateEntity(ctx, entity); err != nil {
@@ -57,16 +70,20 @@ func (s *CustomCRUDService) CreateEntity(ctx context.Context, createdBy uuid.UUI
 	return entity, nil
 }
 
-func (s *CustomCRUDService) GetEntity(ctx context.Context, id uuid.UUID) (*models.CustomCRUDEntity, error) {
-	return s.crudRepo.GetEntityByID(ctx, id)
+func (s *CustomCRUDService) GetEntity(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CustomCRUDEntity, error) {
+	entity, err := s.crudRepo.GetEntityByID(ctx, id, tenant)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("entity not found")
+	}
+	return entity, err
 }
 
-func (s *CustomCRUDService) ListEntities(ctx context.Context, createdBy *uuid.UUID, activeOnly bool) ([]*models.CustomCRUDEntity, error) {
-	return s.crudRepo.ListEntities(ctx, createdBy, activeOnly)
+func (s *CustomCRUDService) ListEntities(ctx context.Context, tenant *models.Tenant, activeOnly bool) ([]*models.CustomCRUDEntity, error) {
+	return s.crudRepo.ListEntities(ctx, tenant, activeOnly)
 }
 
-func (s *CustomCRUDService) UpdateEntity(ctx context.Context, id uuid.UUID, updates map[string]interface{}) (*models.CustomCRUDEntity, error) {
-	entity, err := s.crudRepo.GetEntityByID(ctx, id)
+func (s *CustomCRUDService) UpdateEntity(ctx context.Context, id uuid.UUID, tenant *models.Tenant, updates map[string]interface{}) (*models.CustomCRUDEntity, error) {
+	entity, err := s.managedEntity(ctx, id, tenant)
 	if err != nil {
 		return nil, err
 	}
@@ -95,13 +112,29 @@ func (s *CustomCRUDService) UpdateEntity(ctx context.Context, id uuid.UUID, upda
 	return entity, nil
 }
 
-func (s *CustomCRUDService) DeleteEntity(ctx context.Context, id uuid.UUID) error {
+func (s *CustomCRUDService) DeleteEntity(ctx context.Context, id uuid.UUID, tenant *models.Tenant) error {
+	if _, err := s.managedEntity(ctx, id, tenant); err != nil {
+		return err
+	}
 	return s.crudRepo.DeleteEntity(ctx, id)
 }
 
-func (s *CustomCRUDService) CreateData(ctx context.Context, entityID uuid.UUID, createdBy uuid.UUID, data map[string]interface{}) (*models.CustomCRUDData, error) {
+// managedEntity loads one of the tenant's entities for a change to its
+// definition, which in an organization needs the owner or admin role.
+func (s *CustomCRUDService) managedEntity(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CustomCRUDEntity, error) {
+	entity, err := s.GetEntity(ctx, id, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if !tenant.CanManage() {
+		return nil, errors.New("organization admin role required")
+	}
+	return entity, nil
+}
+
+func (s *CustomCRUDService) CreateData(ctx context.Context, entityID uuid.UUID, tenant *models.Tenant, data map[string]interface{}) (*models.CustomCRUDData, error) {
 	// Validate entity exists
-	entity, err := s.crudRepo.GetEntityByID(ctx, entityID)
+	entity, err := s.crudRepo.GetEntityByID(ctx, entityID, tenant)
 	if err != nil || entity == nil {
 		return nil, errors.New("entity not found")
 	}
@@ -127,7 +160,7 @@ func (s *CustomCRUDService) CreateData(ctx context.Context, entityID uuid.UUID,
 		ID:        uuid.New(),
 		EntityID:  entityID,
 		Data:      string(dataJSON),
-		CreatedBy: createdBy,
+		CreatedBy: &tenant.UserID,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -139,19 +172,27 @@ func (s *CustomCRUDService) CreateData(ctx context.Context, entityID uuid.UUID,
 	return crudData, nil
 }
 
-func (s *CustomCRUDService) GetData(ctx context.Context, id uuid.UUID) (*models.CustomCRUDData, error) {
-	return s.crudRepo.GetDataByID(ctx, id)
+func (s *CustomCRUDService) GetData(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.CustomCRUDData, error) {
+	crudData, err := s.crudRepo.GetDataByID(ctx, id, tenant)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("data not found")
+	}
+	return crudData, err
 }
 
-func (s *CustomCRUDService) ListData(ctx context.Context, entityID uuid.UUID, limit, offset int) ([]*models.CustomCRUDData, error) {
+func (s *CustomCRUDService) ListData(ctx context.Context, entityID uuid.UUID, tenant *models.Tenant, limit, offset int) ([]*models.CustomCRUDData, error) {
+	entity, err := s.crudRepo.GetEntityByID(ctx, entityID, tenant)
+	if err != nil || entity == nil {
+		return nil, errors.New("entity not found")
+	}
 	if limit <= 0 {
 		limit = 50
 	}
-	return s.crudRepo.ListDataByEntity(ctx, entityID, limit, offset)
+	return s.crudRepo.ListDataByEntity(ctx, entityID, tenant, limit, offset)
 }
 
-func (s *CustomCRUDService) UpdateData(ctx context.Context, id uuid.UUID, updatedBy uuid.UUID, data map[string]interface{}) (*models.CustomCRUDData, error) {
-	crudData, err := s.crudRepo.GetDataByID(ctx, id)
+func (s *CustomCRUDService) UpdateData(ctx context.Context, id uuid.UUID, tenant *models.Tenant, data map[string]interface{}) (*models.CustomCRUDData, error) {
+	crudData, err := s.GetData(ctx, id, tenant)
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +203,7 @@ func (s *CustomCRUDService) UpdateData(ctx context.Context, id uuid.UUID, update
 	}
 
 	crudData.Data = string(dataJSON)
+	updatedBy := tenant.UserID
 	crudData.UpdatedBy = &updatedBy
 	crudData.UpdatedAt = time.Now()
 
@@ -172,7 +214,9 @@ func (s *CustomCRUDService) UpdateData(ctx context.Context, id uuid.UUID, update
 	return crudData, nil
 }
 
-func (s *CustomCRUDService) DeleteData(ctx context.Context, id uuid.UUID) error {
+func (s *CustomCRUDService) DeleteData(ctx context.Context, id uuid.UUID, tenant *models.Tenant) error {
+	if _, err := s.GetData(ctx, id, tenant); err != nil {
+		return err
+	}
 	return s.crudRepo.DeleteData(ctx, id)
 }
-
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -14,7 +15,7 @@ import (
 
 type DashboardService struct {
 	dashboardRepo repositories.DashboardRepository
-	logger         *zap.Logger
+	logger        *zap.Logger
 }
 
 func NewDashboardService(
@@ -27,23 +28,24 @@ func NewDashboardService(
 	}
 }
 
-// CreateItem creates a new dashboard item
-func (s *DashboardService) CreateItem(ctx context.Context, userID uuid.UUID, title string, description *string, category *string, metadata *string) (*models.DashboardItem, error) {
+// CreateItem creates a new dashboard item for the tenant
+func (s *DashboardService) CreateItem(ctx context.Context, tenant *models.Tenant, title string, description *string, category *string, metadata *string) (*models.DashboardItem, error) {
 	if title == "" {
 		return nil, errors.New("title is required")
 	}
 
 	item := &models.DashboardItem{
-		ID:          uuid.New(),
-		UserID:      userID,
-		Title:       title,
-		Description: description,
-		Category:    category,
-		Status:      "active",
-		Priority:    0,
-		Metadata:    metadata,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:             uuid.New(),
+		UserID:         tenant.UserID,
+		OrganizationID: tenant.OrganizationID,
+		Title:          title,
+		Description:    description,
+		Category:       category,
+		Status:         "active",
+		Priority:       0,
+		Metadata:       metadata,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
 	if err := s.dashboardRepo.Create(ctx, item); err != nil {
@@ -53,28 +55,23 @@ func (s *DashboardService) CreateItem(ctx context.Context, userID uuid.UUID, tit
 	return item, nil
 }
 
-// GetItem retrieves a dashboard item by ID
-func (s *DashboardService) GetItem(ctx context.Context, id uuid.UUID) (*models.DashboardItem, error) {
-	return s.dashboardRepo.GetByID(ctx, id)
+// GetItem retrieves one of the tenant's dashboard items by ID
+func (s *DashboardService) GetItem(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.DashboardItem, error) {
+	return s.dashboardRepo.GetByID(ctx, id, tenant)
 }
 
-// ListItems retrieves all dashboard items for a user
-func (s *DashboardService) ListItems(ctx context.Context, userID uuid.UUID, status string) ([]*models.DashboardItem, error) {
-	return s.dashboardRepo.GetByUserID(ctx, userID, status)
+// ListItems retrieves all dashboard items of the tenant
+func (s *DashboardService) ListItems(ctx context.Context, tenant *models.Tenant, status string) ([]*models.DashboardItem, error) {
+	return s.dashboardRepo.GetByTenant(ctx, tenant, status)
 }
 
 // UpdateItem updates a dashboard item
-func (s *DashboardService) UpdateItem(ctx context.Context, id uuid.UUID, userID uuid.UUID, updates map[string]interface{}) (*models.DashboardItem, error) {
-	item, err := s.dashboardRepo.GetByID(ctx, id)
+func (s *DashboardService) UpdateItem(ctx context.Context, id uuid.UUID, tenant *models.Tenant, updates map[string]interface{}) (*models.DashboardItem, error) {
+	item, err := s.ownedItem(ctx, id, tenant)
 	if err != nil {
 		return nil, err
 	}
 
-	// Verify ownership
-	if item.UserID != userID {
-		return nil, errors.New("unauthorized: item does not belong to user")
-	}
-
 	// Update allowed fields
 	if title, ok := updates["title"].(string); ok && title != "" {
 		item.Title = title
@@ -105,32 +102,32 @@ func (s *DashboardService) UpdateItem(ctx context.Context, id uuid.UUID, userID
 }
 
 // DeleteItem permanently deletes a dashboard item
-func (s *DashboardService) DeleteItem(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	item, err := s.dashboardRepo.GetByID(ctx, id)
-	if err != nil {
+func (s *DashboardService) DeleteItem(ctx context.Context, id uuid.UUID, tenant *models.Tenant) error {
+	if _, err := s.ownedItem(ctx, id, tenant); err != nil {
 		return err
 	}
 
-	// Verify ownership
-	if item.UserID != userID {
-		return errors.New("unauthorized: item does not belong to user")
-	}
-
 	return s.dashboardRepo.Delete(ctx, id)
 }
 
 // SoftDeleteItem soft deletes a dashboard item
-func (s *DashboardService) SoftDeleteItem(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	item, err := s.dashboardRepo.GetByID(ctx, id)
-	if err != nil {
+func (s *DashboardService) SoftDeleteItem(ctx context.Context, id uuid.UUID, tenant *models.Tenant) error {
+	if _, err := s.ownedItem(ctx, id, tenant); err != nil {
 		return err
 	}
 
-	// Verify ownership
-	if item.UserID != userID {
-		return errors.New("unauthorized: item does not belong to user")
-	}
-
 	return s.dashboardRepo.SoftDelete(ctx, id)
 }
 
+// ownedItem loads an item the tenant may change. Items of other tenants are
+// reported the same way as missing ones.
+func (s *DashboardService) ownedItem(ctx context.Context, id uuid.UUID, tenant *models.Tenant) (*models.DashboardItem, error) {
+	item, err := s.dashboardRepo.GetByID(ctx, id, tenant)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("item not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// DataExportService builds archives of everything stored about a user for
+// subject access requests. An export is requested, built in the background
+// into a ZIP holding one JSON file per kind of record and the user's uploaded
+// files, and the user is notified with a download link. The archive and link
+// expire after the configured retention.
+type DataExportService struct {
+	cfg                 config.DataExportConfig
+	exportRepo          repositories.DataExportRepository
+	userRepo            repositories.UserRepository
+	fileRepo            repositories.FileRepository
+	notificationService *NotificationService
+	logService          *ActivityLogService
+	logger              *zap.Logger
+}
+
+func NewDataExportService(
+	cfg config.DataExportConfig,
+	exportRepo repositories.DataExportRepository,
+	userRepo repositories.UserRepository,
+	fileRepo repositories.FileRepository,
+	notificationService *NotificationService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *DataExportService {
+	if cfg.Dir == "" {
+		cfg.Dir = "exports"
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		logger.Warn("Failed to create data export directory", zap.String("dir", cfg.Dir), zap.Error(err))
+	}
+	return &DataExportService{
+		cfg:                 cfg,
+		exportRepo:          exportRepo,
+		userRepo:            userRepo,
+		fileRepo:            fileRepo,
+		notificationService: notificationService,
+		logService:          logService,
+		logger:              logger,
+	}
+}
+
+// Request starts an export of userID's data. Only one export per user is
+// built at a time.
+func (s *DataExportService) Request(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	exports, err := s.exportRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.Status == models.DataExportPending {
+			return nil, errors.New("an export is already in progress")
+		}
+	}
+
+	export := &models.DataExport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    models.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to create data export: %w", err)
+	}
+	s.logService.Record(ctx, &userID, user.Role, "data_export_requested", strPtr("data_export"), strPtr(export.ID.String()), nil)
+
+	go s.Build(context.Background(), export.ID)
+	return export, nil
+}
+
+func (s *DataExportService) List(ctx context.Context, userID uuid.UUID) ([]*models.DataExport, error) {
+	return s.exportRepo.ListByUser(ctx, userID)
+}
+
+// Build writes the archive for a pending export and notifies its user. It
+// does nothing for exports that are no longer pending.
+func (s *DataExportService) Build(ctx context.Context, exportID uuid.UUID) {
+	export, err := s.exportRepo.GetByID(ctx, exportID)
+	if err != nil || export == nil || export.Status != models.DataExportPending {
+		return
+	}
+
+	path := filepath.Join(s.cfg.Dir, export.ID.String()+".zip")
+	size, err := s.writeArchive(ctx, export.UserID, path)
+	if err != nil {
+		os.Remove(path)
+		s.logger.Error("Failed to build data export", zap.String("export_id", export.ID.String()), zap.Error(err))
+		if err := s.exportRepo.MarkFailed(ctx, export.ID, "failed to build the archive", time.Now()); err != nil {
+			s.logger.Error("Failed to mark data export failed", zap.Error(err))
+		}
+		s.notify(ctx, export.UserID, "Your data export failed", "We could not build your data export. Please request a new one.", nil)
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		os.Remove(path)
+		s.logger.Error("Failed to generate data export token", zap.Error(err))
+		if err := s.exportRepo.MarkFailed(ctx, export.ID, "failed to generate the download link", time.Now()); err != nil {
+			s.logger.Error("Failed to mark data export failed", zap.Error(err))
+		}
+		return
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Retention)
+	export.FilePath = &path
+	export.Size = &size
+	export.TokenHash = strPtr(hashToken(token))
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	if err := s.exportRepo.MarkReady(ctx, export); err != nil {
+		os.Remove(path)
+		s.logger.Error("Failed to mark data export ready", zap.String("export_id", export.ID.String()), zap.Error(err))
+		return
+	}
+
+	link := withQuery(s.cfg.DownloadURL, url.Values{"token": {token}})
+	s.notify(ctx, export.UserID, "Your data export is ready",
+		fmt.Sprintf("Download it before %s, when it is deleted.", expiresAt.UTC().Format("January 2, 2006 15:04 MST")), &link)
+}
+
+// ResumePending builds exports left pending, such as by a restart.
+func (s *DataExportService) ResumePending(ctx context.Context) {
+	exports, err := s.exportRepo.ListPending(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to list pending data exports", zap.Error(err))
+		return
+	}
+	for _, export := range exports {
+		s.Build(ctx, export.ID)
+	}
+}
+
+// Download returns the ready export a download token belongs to and the path
+// of its archive.
+func (s *DataExportService) Download(ctx context.Context, token string) (*models.DataExport, string, error) {
+	export, err := s.exportRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, "", err
+	}
+	if export == nil || export.Status != models.DataExportReady || export.FilePath == nil ||
+		export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		return nil, "", errors.New("invalid or expired download link")
+	}
+	if err := s.exportRepo.MarkDownloaded(ctx, export.ID, time.Now()); err != nil {
+		s.logger.Warn("Failed to record data export download", zap.Error(err))
+	}
+	return export, *export.FilePath, nil
+}
+
+// PurgeExpired deletes archives past their retention.
+func (s *DataExportService) PurgeExpired(ctx context.Context) (int, error) {
+	exports, err := s.exportRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, export := range exports {
+		if export.FilePath != nil {
+			if err := os.Remove(*export.FilePath); err != nil && !os.IsNotExist(err) {
+				s.logger.Warn("Failed to delete data export", zap.String("export_id", export.ID.String()), zap.Error(err))
+				continue
+			}
+		}
+		if err := s.exportRepo.MarkExpired(ctx, export.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// writeArchive writes the user's records and files to a ZIP at path and
+// returns its size.
+func (s *DataExportService) writeArchive(ctx context.Context, userID uuid.UUID, path string) (int64, error) {
+	records, err := s.exportRepo.UserRecords(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	files, err := s.fileRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	now := time.Now()
+	archive := zip.NewWriter(out)
+	if err := writeJSON(archive, "export.json", now, map[string]interface{}{
+		"user_id":     userID,
+		"exported_at": now.UTC(),
+	}); err != nil {
+		return 0, err
+	}
+	names := make([]string, 0, len(records))
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeJSON(archive, name+".json", now, records[name]); err != nil {
+			return 0, err
+		}
+	}
+	for _, file := range files {
+		if err := copyIntoArchive(archive, file); err != nil {
+			// A missing upload should not cost the user the rest of the export
+			s.logger.Warn("Failed to add uploaded file to data export", zap.String("path", file.Path), zap.Error(err))
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func writeJSON(archive *zip.Writer, name string, modified time.Time, v interface{}) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func copyIntoArchive(archive *zip.Writer, file *models.UploadedFile) error {
+	src, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     "files/" + file.ID.String() + "_" + filepath.Base(file.OriginalName),
+		Method:   zip.Deflate,
+		Modified: file.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+func (s *DataExportService) notify(ctx context.Context, userID uuid.UUID, title, message string, link *string) {
+	if _, err := s.notificationService.CreateNotification(ctx, userID, "system", title, message, link, nil); err != nil {
+		s.logger.Warn("Failed to notify about data export", zap.String("user_id", userID.String()), zap.Error(err))
+	}
+}
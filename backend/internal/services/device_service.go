@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// DeviceService lets users manage the devices they have signed in from and
+// alerts them when a new device signs in. Devices are identified by the
+// X-Device-ID the client sends; trusted ones also send the X-Device-Trust
+// token they were given.
+type DeviceService struct {
+	trustDuration       time.Duration
+	deviceRepo          repositories.DeviceRepository
+	authService         *AuthService
+	notificationService *NotificationService
+	emailService        *EmailService
+	logService          *ActivityLogService
+	logger              *zap.Logger
+}
+
+func NewDeviceService(
+	cfg config.DeviceConfig,
+	deviceRepo repositories.DeviceRepository,
+	authService *AuthService,
+	notificationService *NotificationService,
+	emailService *EmailService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *DeviceService {
+	return &DeviceService{
+		trustDuration:       cfg.TrustDuration,
+		deviceRepo:          deviceRepo,
+		authService:         authService,
+		notificationService: notificationService,
+		emailService:        emailService,
+		logService:          logService,
+		logger:              logger,
+	}
+}
+
+// IsTrusted reports whether the device was trusted within the trust period.
+func (s *DeviceService) IsTrusted(device *models.Device) bool {
+	return device.IsTrusted && device.TrustTokenHash != nil && device.TrustedAt != nil &&
+		time.Now().Before(device.TrustedAt.Add(s.trustDuration))
+}
+
+// trustedClient reports whether client signs in from a device userID trusts.
+// The device ID alone is not secret, so the client must also present the
+// trust token the device was given when it was trusted.
+func (s *DeviceService) trustedClient(ctx context.Context, userID uuid.UUID, client ClientInfo) bool {
+	if client.DeviceID == nil || client.DeviceTrustToken == nil {
+		return false
+	}
+	device, err := s.deviceRepo.GetByDeviceID(ctx, userID, client.DeviceID)
+	if err != nil || device == nil || !s.IsTrusted(device) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashToken(*client.DeviceTrustToken)), []byte(*device.TrustTokenHash)) == 1
+}
+
+// trust starts the trust period of the device client signed in from and
+// returns the token it must present to skip the second factor. Only
+// TwoFactorService calls it, once the second factor has been passed. It
+// returns an empty token when the client sent no device ID.
+func (s *DeviceService) trust(ctx context.Context, user *models.User, client ClientInfo) (string, error) {
+	if client.DeviceID == nil {
+		return "", nil
+	}
+	device, err := s.deviceRepo.GetByDeviceID(ctx, user.ID, client.DeviceID)
+	if err != nil || device == nil {
+		return "", errors.New("device not found")
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	hash := hashToken(token)
+	now := time.Now()
+	device.IsTrusted = true
+	device.TrustedAt = &now
+	device.TrustTokenHash = &hash
+	if err := s.deviceRepo.Update(ctx, device); err != nil {
+		return "", err
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "device_trusted", strPtr("device"), strPtr(device.ID.String()), nil)
+	return token, nil
+}
+
+// withTrust clears a lapsed trust and fills in when the current one ends.
+func (s *DeviceService) withTrust(device *models.Device) *models.Device {
+	if !s.IsTrusted(device) {
+		device.IsTrusted = false
+		device.TrustedAt = nil
+		device.TrustExpiresAt = nil
+		return device
+	}
+	expiresAt := device.TrustedAt.Add(s.trustDuration)
+	device.TrustExpiresAt = &expiresAt
+	return device
+}
+
+func (s *DeviceService) ListDevices(ctx context.Context, userID uuid.UUID) ([]*models.Device, error) {
+	devices, err := s.deviceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		s.withTrust(device)
+	}
+	return devices, nil
+}
+
+// getOwnDevice returns the device only if it belongs to the user, so other
+// users' devices look the same as missing ones.
+func (s *DeviceService) getOwnDevice(ctx context.Context, userID, id uuid.UUID) (*models.Device, error) {
+	device, err := s.deviceRepo.GetByID(ctx, id)
+	if err != nil || device == nil || device.UserID != userID {
+		return nil, errors.New("device not found")
+	}
+	return device, nil
+}
+
+func (s *DeviceService) RenameDevice(ctx context.Context, userID, id uuid.UUID, name string) (*models.Device, error) {
+	device, err := s.getOwnDevice(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	device.DeviceName = &name
+	if err := s.deviceRepo.Update(ctx, device); err != nil {
+		return nil, err
+	}
+	return s.withTrust(device), nil
+}
+
+// Untrust stops trusting a device, so its next sign-in needs the second
+// factor again. Devices are trusted by passing the second factor on them.
+func (s *DeviceService) Untrust(ctx context.Context, userID, id uuid.UUID) (*models.Device, error) {
+	device, err := s.getOwnDevice(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	device.IsTrusted = false
+	device.TrustedAt = nil
+	device.TrustTokenHash = nil
+	if err := s.deviceRepo.Update(ctx, device); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &userID, "user", "device_untrusted", strPtr("device"), strPtr(device.ID.String()), nil)
+	return s.withTrust(device), nil
+}
+
+// RemoveDevice forgets a device and signs it out. Its next sign-in counts as
+// a new device again.
+func (s *DeviceService) RemoveDevice(ctx context.Context, userID, id uuid.UUID) error {
+	device, err := s.getOwnDevice(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authService.RevokeDeviceSessions(ctx, userID, device.DeviceID); err != nil {
+		return fmt.Errorf("failed to revoke device sessions: %w", err)
+	}
+	if err := s.deviceRepo.Delete(ctx, device.ID); err != nil {
+		return err
+	}
+
+	s.logService.Record(ctx, &userID, "user", "device_removed", strPtr("device"), strPtr(device.ID.String()), nil)
+	return nil
+}
+
+// NotifyNewDevice alerts the user in-app and by email that a device they have
+// not used before signed in. It is registered with AuthService.OnNewDevice.
+func (s *DeviceService) NotifyNewDevice(ctx context.Context, user *models.User, device *models.Device, client ClientInfo) {
+	details := describeDevice(device, client)
+	message := fmt.Sprintf("A new device signed in to your account: %s, on %s. "+
+		"If this wasn't you, remove the device in Settings and change your password.",
+		details, time.Now().UTC().Format("2 Jan 2006 15:04 MST"))
+
+	metadata := map[string]interface{}{"device_id": device.ID.String()}
+	if device.IPAddress != nil {
+		metadata["ip_address"] = *device.IPAddress
+	}
+	if client.UserAgent != nil {
+		metadata["user_agent"] = *client.UserAgent
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	if _, err := s.notificationService.CreateNotification(ctx, user.ID, "security", "New sign-in to your account",
+		message, strPtr("/settings"), strPtr(string(metadataJSON))); err != nil {
+		s.logger.Warn("Failed to create new device notification", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "new_device_login", strPtr("device"), strPtr(device.ID.String()), metadata)
+
+	// Mail delivery must not hold up the sign-in
+	go func(ctx context.Context) {
+		if err := s.emailService.SendNotificationEmail(ctx, user.Email, "New sign-in to your account", message, ""); err != nil {
+			s.logger.Warn("Failed to send new device email", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// describeDevice reads like "Chrome on macOS from 203.0.113.7 (Berlin, DE)",
+// using whatever is known about the device.
+func describeDevice(device *models.Device, client ClientInfo) string {
+	var name string
+	switch {
+	case device.Browser != nil && device.OS != nil:
+		name = *device.Browser + " on " + *device.OS
+	case device.Browser != nil:
+		name = *device.Browser
+	case device.DeviceName != nil && *device.DeviceName != "":
+		name = *device.DeviceName
+	case client.UserAgent != nil && *client.UserAgent != "":
+		name = *client.UserAgent
+	default:
+		name = "an unknown device"
+	}
+
+	if device.IPAddress != nil && *device.IPAddress != "" {
+		name += " from " + *device.IPAddress
+	}
+
+	var location []string
+	if device.LocationCity != nil && *device.LocationCity != "" {
+		location = append(location, *device.LocationCity)
+	}
+	if device.LocationCountry != nil && *device.LocationCountry != "" {
+		location = append(location, *device.LocationCountry)
+	}
+	if len(location) > 0 {
+		name += " (" + strings.Join(location, ", ") + ")"
+	}
+	return name
+}
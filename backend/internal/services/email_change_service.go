@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// EmailChangeService moves an account to a new email address. The change
+// needs the current password, only takes effect once a link sent to the new
+// address is followed, and can be undone from the old address for a while
+// afterwards.
+type EmailChangeService struct {
+	cfg          config.EmailChangeConfig
+	changeRepo   repositories.EmailChangeRepository
+	userRepo     repositories.UserRepository
+	authService  *AuthService
+	emailService *EmailService
+	logService   *ActivityLogService
+	logger       *zap.Logger
+}
+
+func NewEmailChangeService(
+	cfg config.EmailChangeConfig,
+	changeRepo repositories.EmailChangeRepository,
+	userRepo repositories.UserRepository,
+	authService *AuthService,
+	emailService *EmailService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *EmailChangeService {
+	return &EmailChangeService{
+		cfg:          cfg,
+		changeRepo:   changeRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+		emailService: emailService,
+		logService:   logService,
+		logger:       logger,
+	}
+}
+
+// RequestChange starts a change to newEmail after checking the user's
+// password. It replaces any earlier unconfirmed request, emails a
+// confirmation link to the new address and a notice with a revert link to the
+// current one. sessionID is the session making the request, which stays
+// signed in when the change is confirmed.
+func (s *EmailChangeService) RequestChange(ctx context.Context, userID, sessionID uuid.UUID, newEmail, password string, ipAddress *string) (*models.EmailChangeRequest, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.PasswordHash == "" {
+		return nil, errors.New("set a password before changing your email address")
+	}
+	if !s.authService.verifyPassword(ctx, user, password) {
+		return nil, errors.New("invalid credentials")
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, errors.New("new email is the same as the current one")
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, newEmail); existing != nil {
+		return nil, errors.New("email already in use")
+	}
+
+	now := time.Now()
+	if err := s.changeRepo.CancelPending(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+
+	confirmToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	revertToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	request := &models.EmailChangeRequest{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmTokenHash: hashToken(confirmToken),
+		RevertTokenHash:  hashToken(revertToken),
+		IPAddress:        ipAddress,
+		ExpiresAt:        now.Add(s.cfg.TokenTTL),
+		RevertExpiresAt:  now.Add(s.cfg.TokenTTL + s.cfg.RevertWindow),
+		CreatedAt:        now,
+	}
+	if sessionID != uuid.Nil {
+		request.SessionID = &sessionID
+	}
+	if err := s.changeRepo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to create email change request: %w", err)
+	}
+
+	confirmURL := withQuery(s.cfg.LinkURL, url.Values{"email_change_token": {confirmToken}})
+	if err := s.emailService.SendEmailChangeConfirmation(ctx, newEmail, confirmURL, s.cfg.TokenTTL); err != nil {
+		return nil, fmt.Errorf("failed to send confirmation email: %w", err)
+	}
+	revertURL := withQuery(s.cfg.LinkURL, url.Values{"email_revert_token": {revertToken}})
+	if err := s.emailService.SendEmailChangeNotice(ctx, user.Email, newEmail, revertURL, s.cfg.TokenTTL+s.cfg.RevertWindow); err != nil {
+		// The confirmation is out; the change must not go ahead unannounced
+		s.logger.Error("Failed to send email change notice", zap.String("user_id", user.ID.String()), zap.Error(err))
+		if cancelErr := s.changeRepo.CancelPending(ctx, user.ID, time.Now()); cancelErr != nil {
+			s.logger.Error("Failed to cancel email change", zap.String("user_id", user.ID.String()), zap.Error(cancelErr))
+		}
+		return nil, fmt.Errorf("failed to send email change notice: %w", err)
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "email_change_requested", strPtr("user"), strPtr(user.ID.String()), map[string]interface{}{
+		"new_email": newEmail,
+	})
+	return request, nil
+}
+
+// Pending returns the user's unconfirmed request, or nil.
+func (s *EmailChangeService) Pending(ctx context.Context, userID uuid.UUID) (*models.EmailChangeRequest, error) {
+	return s.changeRepo.GetPendingByUserID(ctx, userID, time.Now())
+}
+
+// Cancel withdraws the user's unconfirmed request, if any.
+func (s *EmailChangeService) Cancel(ctx context.Context, userID uuid.UUID) error {
+	return s.changeRepo.CancelPending(ctx, userID, time.Now())
+}
+
+// Confirm consumes a confirmation link: the account takes the new address,
+// which counts as verified, and every session except the one that asked for
+// the change is signed out.
+func (s *EmailChangeService) Confirm(ctx context.Context, token string) (*models.User, error) {
+	request, err := s.changeRepo.GetByConfirmTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if request == nil || request.ConfirmedAt != nil || request.RevertedAt != nil || now.After(request.ExpiresAt) {
+		return nil, errors.New("invalid or expired link")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, request.UserID)
+	if err != nil || user.Email != request.OldEmail {
+		// The address changed some other way since the request was made
+		return nil, errors.New("invalid or expired link")
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, request.NewEmail); existing != nil {
+		return nil, errors.New("email already in use")
+	}
+
+	confirmed, err := s.changeRepo.MarkConfirmed(ctx, request.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		// Confirmed or reverted concurrently by another request
+		return nil, errors.New("invalid or expired link")
+	}
+	if err := s.userRepo.UpdateEmail(ctx, user.ID, request.NewEmail, true); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+	user.Email = request.NewEmail
+	user.EmailVerified = true
+
+	keep := uuid.Nil
+	if request.SessionID != nil {
+		keep = *request.SessionID
+	}
+	if err := s.authService.RevokeOtherSessions(ctx, user.ID, keep); err != nil {
+		s.logger.Error("Failed to revoke sessions after email change", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "email_changed", strPtr("user"), strPtr(user.ID.String()), map[string]interface{}{
+		"old_email": request.OldEmail,
+		"new_email": request.NewEmail,
+	})
+	s.logger.Info("User email changed", zap.String("user_id", user.ID.String()))
+	return user, nil
+}
+
+// Revert consumes a revert link sent to the old address. A request that is
+// still pending is cancelled. A confirmed change is undone and every session
+// is signed out, on the assumption that whoever made it had taken over the
+// account.
+func (s *EmailChangeService) Revert(ctx context.Context, token string) error {
+	request, err := s.changeRepo.GetByRevertTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if request == nil || request.RevertedAt != nil || now.After(request.RevertExpiresAt) {
+		return errors.New("invalid or expired link")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, request.UserID)
+	if err != nil {
+		return errors.New("invalid or expired link")
+	}
+	if request.ConfirmedAt != nil && user.Email == request.NewEmail {
+		if existing, _ := s.userRepo.GetByEmail(ctx, request.OldEmail); existing != nil && existing.ID != user.ID {
+			return errors.New("email already in use")
+		}
+	}
+
+	reverted, err := s.changeRepo.MarkReverted(ctx, request.ID, now)
+	if err != nil {
+		return err
+	}
+	if !reverted {
+		return errors.New("invalid or expired link")
+	}
+
+	if request.ConfirmedAt == nil {
+		s.logService.Record(ctx, &user.ID, user.Role, "email_change_cancelled", strPtr("user"), strPtr(user.ID.String()), nil)
+		return nil
+	}
+
+	// Only undo the change this link belongs to, not a later one
+	if user.Email == request.NewEmail {
+		if err := s.userRepo.UpdateEmail(ctx, user.ID, request.OldEmail, true); err != nil {
+			return fmt.Errorf("failed to restore email: %w", err)
+		}
+	}
+	if err := s.authService.RevokeOtherSessions(ctx, user.ID, uuid.Nil); err != nil {
+		s.logger.Error("Failed to revoke sessions after email revert", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "email_change_reverted", strPtr("user"), strPtr(user.ID.String()), map[string]interface{}{
+		"old_email": request.OldEmail,
+		"new_email": request.NewEmail,
+	})
+	s.logger.Warn("User email change reverted", zap.String("user_id", user.ID.String()))
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"net/smtp"
 	"os"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -82,6 +83,126 @@ func (es *EmailService) loadTemplates() {
 		<p style="color: #7f8c8d; font-size: 12px;">This is an automated message, please do not reply.</p>
 	</div>
 </body>
+</html>`,
+		"magic_link": `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>Sign In</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Sign In to Base App</h2>
+		<p>Hello,</p>
+		<p>Click the button below to sign in. The link can be used once.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="{{.LoginURL}}" style="background-color: #3498db; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">Sign In</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #3498db;">{{.LoginURL}}</p>
+		<p>This link will expire in {{.Expiry}}.</p>
+		<p>If you didn't request this, please ignore this email. Nobody can sign in without the link.</p>
+		<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+		<p style="color: #7f8c8d; font-size: 12px;">This is an automated message, please do not reply.</p>
+	</div>
+</body>
+</html>`,
+		"email_change_confirm": `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>Confirm Your New Email Address</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Confirm Your New Email Address</h2>
+		<p>Hello,</p>
+		<p>A request was made to use this address for your Base App account. Click the button below to confirm it:</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="{{.ConfirmURL}}" style="background-color: #3498db; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">Confirm Email</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #3498db;">{{.ConfirmURL}}</p>
+		<p>This link will expire in {{.Expiry}}. Your account keeps its current address until you confirm.</p>
+		<p>If you didn't request this, please ignore this email.</p>
+		<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+		<p style="color: #7f8c8d; font-size: 12px;">This is an automated message, please do not reply.</p>
+	</div>
+</body>
+</html>`,
+		"email_change_notice": `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>Email Address Change Requested</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Email Address Change Requested</h2>
+		<p>Hello,</p>
+		<p>A request was made to change the email address of your Base App account to <strong>{{.NewEmail}}</strong>. The change takes effect once the new address is confirmed.</p>
+		<p>If this wasn't you, click the button below to cancel the change, or undo it if it has already happened. This signs out every session on your account:</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="{{.RevertURL}}" style="background-color: #e74c3c; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">This Wasn't Me</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #3498db;">{{.RevertURL}}</p>
+		<p>This link works for {{.Expiry}}. We also recommend changing your password.</p>
+		<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+		<p style="color: #7f8c8d; font-size: 12px;">This is an automated message, please do not reply.</p>
+	</div>
+</body>
+</html>`,
+		"organization_invitation": `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>Organization Invitation</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Join {{.Organization}} on Base App</h2>
+		<p>Hello,</p>
+		<p>{{.Inviter}} invited you to join <strong>{{.Organization}}</strong> as {{.Role}}. Sign in or create an account with this email address, then click the button below to accept:</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="{{.InviteURL}}" style="background-color: #27ae60; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">Accept Invitation</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #3498db;">{{.InviteURL}}</p>
+		<p>This invitation will expire in {{.Expiry}}.</p>
+		<p>If you weren't expecting it, you can ignore this email.</p>
+		<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+		<p style="color: #7f8c8d; font-size: 12px;">This is an automated message, please do not reply.</p>
+	</div>
+</body>
+</html>`,
+		"user_invitation": `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>Invitation</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">You're invited to Base App</h2>
+		<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+		<p>{{.Inviter}} invited you to join Base App as {{.Role}}. Click the button below to choose a password and finish setting up your account:</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="{{.InviteURL}}" style="background-color: #27ae60; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">Accept Invitation</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #3498db;">{{.InviteURL}}</p>
+		<p>This invitation will expire in {{.Expiry}} and can only be used once.</p>
+		<p>If you weren't expecting it, you can ignore this email.</p>
+		<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+		<p style="color: #7f8c8d; font-size: 12px;">This is an automated message, please do not reply.</p>
+	</div>
+</body>
 </html>`,
 		"welcome": `
 <!DOCTYPE html>
@@ -191,6 +312,152 @@ func (es *EmailService) SendPasswordResetEmail(ctx context.Context, to, resetTok
 	return es.SendEmail(ctx, email)
 }
 
+// SendMagicLinkEmail sends a single-use sign-in link that expires after ttl.
+func (es *EmailService) SendMagicLinkEmail(ctx context.Context, to, loginURL string, ttl time.Duration) error {
+	tmpl, ok := es.templates["magic_link"]
+	if !ok {
+		return fmt.Errorf("magic link template not found")
+	}
+
+	var buf bytes.Buffer
+	data := map[string]string{
+		"LoginURL": loginURL,
+		"Expiry":   fmt.Sprintf("%d minutes", int(ttl.Round(time.Minute).Minutes())),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	email := Email{
+		To:      []string{to},
+		Subject: "Your sign-in link",
+		HTML:    buf.String(),
+	}
+
+	return es.SendEmail(ctx, email)
+}
+
+// SendEmailChangeConfirmation asks the owner of a new address to confirm it
+// before it replaces the account's current one.
+func (es *EmailService) SendEmailChangeConfirmation(ctx context.Context, to, confirmURL string, ttl time.Duration) error {
+	tmpl, ok := es.templates["email_change_confirm"]
+	if !ok {
+		return fmt.Errorf("email change confirmation template not found")
+	}
+
+	var buf bytes.Buffer
+	data := map[string]string{
+		"ConfirmURL": confirmURL,
+		"Expiry":     formatExpiry(ttl),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	email := Email{
+		To:      []string{to},
+		Subject: "Confirm your new email address",
+		HTML:    buf.String(),
+	}
+
+	return es.SendEmail(ctx, email)
+}
+
+// SendEmailChangeNotice tells the current address that a change to newEmail
+// was requested, with a link that undoes it for window.
+func (es *EmailService) SendEmailChangeNotice(ctx context.Context, to, newEmail, revertURL string, window time.Duration) error {
+	tmpl, ok := es.templates["email_change_notice"]
+	if !ok {
+		return fmt.Errorf("email change notice template not found")
+	}
+
+	var buf bytes.Buffer
+	data := map[string]string{
+		"NewEmail":  newEmail,
+		"RevertURL": revertURL,
+		"Expiry":    formatExpiry(window),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	email := Email{
+		To:      []string{to},
+		Subject: "Your email address is being changed",
+		HTML:    buf.String(),
+	}
+
+	return es.SendEmail(ctx, email)
+}
+
+// SendOrganizationInvitation invites to to join organization with role.
+func (es *EmailService) SendOrganizationInvitation(ctx context.Context, to, organization, inviter, role, inviteURL string, ttl time.Duration) error {
+	tmpl, ok := es.templates["organization_invitation"]
+	if !ok {
+		return fmt.Errorf("organization invitation template not found")
+	}
+
+	var buf bytes.Buffer
+	data := map[string]string{
+		"Organization": organization,
+		"Inviter":      inviter,
+		"Role":         role,
+		"InviteURL":    inviteURL,
+		"Expiry":       formatExpiry(ttl),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	email := Email{
+		To:      []string{to},
+		Subject: "You're invited to join " + organization,
+		HTML:    buf.String(),
+	}
+
+	return es.SendEmail(ctx, email)
+}
+
+// SendUserInvitation emails an admin's invitation to create an account.
+func (es *EmailService) SendUserInvitation(ctx context.Context, to, name, inviter, role, inviteURL string, ttl time.Duration) error {
+	tmpl, ok := es.templates["user_invitation"]
+	if !ok {
+		return fmt.Errorf("user invitation template not found")
+	}
+
+	var buf bytes.Buffer
+	data := map[string]string{
+		"Name":      name,
+		"Inviter":   inviter,
+		"Role":      role,
+		"InviteURL": inviteURL,
+		"Expiry":    formatExpiry(ttl),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	email := Email{
+		To:      []string{to},
+		Subject: "You're invited to Base App",
+		HTML:    buf.String(),
+	}
+
+	return es.SendEmail(ctx, email)
+}
+
+// formatExpiry renders a link lifetime in the largest whole unit.
+func formatExpiry(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%d days", int(d.Round(24*time.Hour).Hours()/24))
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Round(time.Hour).Hours()))
+	default:
+		return fmt.Sprintf("%d minutes", int(d.Round(time.Minute).Minutes()))
+	}
+}
+
 func (es *EmailService) SendWelcomeEmail(ctx context.Context, to, name, loginURL string) error {
 	tmpl, ok := es.templates["welcome"]
 	if !ok {
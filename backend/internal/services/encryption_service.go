@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/encryption"
+)
+
+// LoadKeyring loads the master key and unwraps the stored data and index keys
+// with it, creating any that do not exist yet. Keys wrapped by one of the
+// previous master keys are rewrapped by the current one. With generate, a
+// missing master key file is created as long as no keys were stored yet,
+// which is only meant for development.
+func LoadKeyring(ctx context.Context, cfg config.EncryptionConfig, generate bool, encryptionRepo repositories.EncryptionRepository, logger *zap.Logger) (*encryption.Keyring, error) {
+	stored, err := encryptionRepo.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := loadMasterKey(cfg, generate && len(stored) == 0, logger)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := encryption.NewKeyring(master)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[string][]byte, len(cfg.PreviousMasterKeys))
+	for i, s := range cfg.PreviousMasterKeys {
+		key, err := encryption.ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("previous master key %d: %w", i+1, err)
+		}
+		previous[encryption.MasterKeyID(key)] = key
+	}
+
+	hasIndex, hasActive := false, false
+	for _, stored := range stored {
+		key, err := unwrapStoredKey(ctx, keyring, previous, encryptionRepo, stored)
+		if err != nil {
+			return nil, err
+		}
+		active := stored.Status == models.EncryptionKeyActive
+		switch stored.Purpose {
+		case models.EncryptionKeyIndex:
+			keyring.SetIndexKey(key)
+			hasIndex = true
+		case models.EncryptionKeyData:
+			if err := keyring.AddKey(stored.ID, key, active); err != nil {
+				return nil, err
+			}
+			hasActive = hasActive || active
+		}
+	}
+
+	if !hasIndex {
+		key, err := createEncryptionKey(ctx, keyring, encryptionRepo, models.EncryptionKeyIndex)
+		if err != nil {
+			return nil, err
+		}
+		keyring.SetIndexKey(key)
+	}
+	if !hasActive {
+		if _, err := addDataKey(ctx, keyring, encryptionRepo); err != nil {
+			return nil, err
+		}
+	}
+	return keyring, nil
+}
+
+// loadMasterKey reads the master key from the config or the key file,
+// generating the file if it does not exist and generate is set.
+func loadMasterKey(cfg config.EncryptionConfig, generate bool, logger *zap.Logger) ([]byte, error) {
+	if cfg.MasterKey != "" {
+		key, err := encryption.ParseKey(cfg.MasterKey)
+		if err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_MASTER_KEY: %w", err)
+		}
+		return key, nil
+	}
+
+	data, err := os.ReadFile(cfg.MasterKeyFile)
+	if err == nil {
+		key, err := encryption.ParseKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.MasterKeyFile, err)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !generate {
+		return nil, fmt.Errorf("no master key: set ENCRYPTION_MASTER_KEY or ENCRYPTION_MASTER_KEY_FILE (%w)", err)
+	}
+
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(cfg.MasterKeyFile, []byte(encryption.EncodeKey(key)+"\n"), 0o600); err != nil {
+		return nil, fmt.Errorf("write master key file: %w", err)
+	}
+	logger.Warn("Generated a new master key file; keep it safe and out of version control, or set ENCRYPTION_MASTER_KEY",
+		zap.String("path", cfg.MasterKeyFile))
+	return key, nil
+}
+
+// unwrapStoredKey unwraps a stored key, rewrapping it by the current master
+// key if it was wrapped by a previous one.
+func unwrapStoredKey(ctx context.Context, keyring *encryption.Keyring, previous map[string][]byte, encryptionRepo repositories.EncryptionRepository, stored *models.EncryptionKey) ([]byte, error) {
+	if stored.MasterKeyID == keyring.MasterKeyID() {
+		key, err := keyring.Unwrap(stored.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", stored.ID, err)
+		}
+		return key, nil
+	}
+
+	master, ok := previous[stored.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s is wrapped by master key %s, which is neither the current nor a previous master key", stored.ID, stored.MasterKeyID)
+	}
+	key, err := encryption.UnwrapWith(master, stored.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", stored.ID, err)
+	}
+	wrapped, err := keyring.Wrap(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := encryptionRepo.Rewrap(ctx, stored.ID, wrapped, keyring.MasterKeyID()); err != nil {
+		return nil, fmt.Errorf("rewrap key %s: %w", stored.ID, err)
+	}
+	return key, nil
+}
+
+// createEncryptionKey generates and stores a new active key.
+func createEncryptionKey(ctx context.Context, keyring *encryption.Keyring, encryptionRepo repositories.EncryptionRepository, purpose string) ([]byte, error) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	id, err := encryption.NewKeyID()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := keyring.Wrap(key)
+	if err != nil {
+		return nil, err
+	}
+	err = encryptionRepo.CreateKey(ctx, &models.EncryptionKey{
+		ID:          id,
+		Purpose:     purpose,
+		Status:      models.EncryptionKeyActive,
+		WrappedKey:  wrapped,
+		MasterKeyID: keyring.MasterKeyID(),
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if purpose == models.EncryptionKeyData {
+		if err := keyring.AddKey(id, key, true); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// addDataKey creates a data key and makes it the one new values are sealed
+// with, returning its ID.
+func addDataKey(ctx context.Context, keyring *encryption.Keyring, encryptionRepo repositories.EncryptionRepository) (string, error) {
+	if _, err := createEncryptionKey(ctx, keyring, encryptionRepo, models.EncryptionKeyData); err != nil {
+		return "", err
+	}
+	return keyring.ActiveKeyID(), nil
+}
+
+// EncryptionService manages the data keys sensitive columns are encrypted
+// with. Rotating creates a new data key for new values and re-encrypts the
+// stored ones with it in the background; a retired key is destroyed once
+// nothing is sealed with it any more.
+type EncryptionService struct {
+	cfg            config.EncryptionConfig
+	keyring        *encryption.Keyring
+	encryptionRepo repositories.EncryptionRepository
+	logService     *ActivityLogService
+	logger         *zap.Logger
+	reencrypting   sync.Mutex
+}
+
+func NewEncryptionService(
+	cfg config.EncryptionConfig,
+	keyring *encryption.Keyring,
+	encryptionRepo repositories.EncryptionRepository,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *EncryptionService {
+	if cfg.ReencryptBatchSize <= 0 {
+		cfg.ReencryptBatchSize = 200
+	}
+	return &EncryptionService{
+		cfg:            cfg,
+		keyring:        keyring,
+		encryptionRepo: encryptionRepo,
+		logService:     logService,
+		logger:         logger,
+	}
+}
+
+// Status reports the keys and how the values of each encrypted column are
+// sealed.
+func (s *EncryptionService) Status(ctx context.Context) (*models.EncryptionStatus, error) {
+	keys, err := s.encryptionRepo.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := s.encryptionRepo.FieldStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.EncryptionStatus{
+		MasterKeyID:  s.keyring.MasterKeyID(),
+		ActiveKeyID:  s.keyring.ActiveKeyID(),
+		Keys:         keys,
+		Fields:       fields,
+		Reencrypting: s.isReencrypting(),
+	}, nil
+}
+
+func (s *EncryptionService) isReencrypting() bool {
+	if !s.reencrypting.TryLock() {
+		return true
+	}
+	s.reencrypting.Unlock()
+	return false
+}
+
+// Rotate makes a new data key the active one, retires the others and starts
+// re-encrypting the stored values with the new key. Keys cannot be rotated
+// while a re-encryption is still running.
+func (s *EncryptionService) Rotate(ctx context.Context, adminID uuid.UUID) (*models.EncryptionStatus, error) {
+	if s.isReencrypting() {
+		return nil, errors.New("re-encryption is already running")
+	}
+	previous := s.keyring.ActiveKeyID()
+	id, err := addDataKey(ctx, s.keyring, s.encryptionRepo)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.encryptionRepo.RetireDataKeys(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &adminID, "admin", "encryption_key_rotated", strPtr("encryption_key"), strPtr(id), map[string]interface{}{
+		"previous_key_id": previous,
+	})
+
+	go func() {
+		if err := s.Reencrypt(context.Background()); err != nil {
+			s.logger.Error("Failed to re-encrypt after key rotation", zap.Error(err))
+		}
+	}()
+	return s.Status(ctx)
+}
+
+// Reencrypt seals every stored value not yet sealed with the active key with
+// it, including values stored before encryption was turned on, then destroys
+// the retired keys nothing is sealed with any more.
+func (s *EncryptionService) Reencrypt(ctx context.Context) error {
+	if !s.reencrypting.TryLock() {
+		return errors.New("re-encryption is already running")
+	}
+	defer s.reencrypting.Unlock()
+
+	total := 0
+	for {
+		n, err := s.encryptionRepo.Reencrypt(ctx, s.cfg.ReencryptBatchSize)
+		total += n
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if total > 0 {
+		s.logger.Info("Re-encrypted stored values", zap.Int("values", total), zap.String("key_id", s.keyring.ActiveKeyID()))
+	}
+
+	keys, err := s.encryptionRepo.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key.Purpose != models.EncryptionKeyData || key.Status != models.EncryptionKeyRetired {
+			continue
+		}
+		count, err := s.encryptionRepo.CountSealedWith(ctx, key.ID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := s.encryptionRepo.DeleteKey(ctx, key.ID); err != nil {
+			return err
+		}
+		s.keyring.RemoveKey(key.ID)
+		s.logService.Record(ctx, nil, "system", "encryption_key_destroyed", strPtr("encryption_key"), strPtr(key.ID), nil)
+	}
+	return nil
+}
@@ -15,11 +15,15 @@ import (
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
 )
 
 type FileService struct {
 	uploadDir string
 	maxSize   int64
+	fileRepo  repositories.FileRepository
 	logger    *zap.Logger
 }
 
@@ -39,7 +43,7 @@ type FileUploadConfig struct {
 	MaxSize   int64 // in bytes
 }
 
-func NewFileService(config FileUploadConfig, logger *zap.Logger) *FileService {
+func NewFileService(config FileUploadConfig, fileRepo repositories.FileRepository, logger *zap.Logger) *FileService {
 	// Create upload directory if it doesn't exist
 	if config.UploadDir == "" {
 		config.UploadDir = "uploads"
@@ -49,6 +53,7 @@ func NewFileService(config FileUploadConfig, logger *zap.Logger) *FileService {
 	return &FileService{
 		uploadDir: config.UploadDir,
 		maxSize:   config.MaxSize,
+		fileRepo:  fileRepo,
 		logger:    logger,
 	}
 }
@@ -116,6 +121,21 @@ func (fs *FileService) UploadFile(ctx context.Context, file multipart.File, head
 		CreatedAt:    time.Now(),
 	}
 
+	// Record the owner so the file goes into their data export
+	if err := fs.fileRepo.Create(ctx, &models.UploadedFile{
+		ID:           fileInfo.ID,
+		UserID:       userID,
+		OriginalName: fileInfo.OriginalName,
+		StoredName:   storedName,
+		Path:         filePath,
+		Size:         fileInfo.Size,
+		ContentType:  &contentType,
+		CreatedAt:    fileInfo.CreatedAt,
+	}); err != nil {
+		os.Remove(filePath)
+		return nil, fmt.Errorf("failed to record file: %w", err)
+	}
+
 	fs.logger.Info("File uploaded", zap.String("file_id", fileInfo.ID.String()))
 	return fileInfo, nil
 }
@@ -137,6 +157,9 @@ func (fs *FileService) DeleteFile(ctx context.Context, filePath string) error {
 	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	if err := fs.fileRepo.DeleteByPath(ctx, filePath); err != nil {
+		return err
+	}
 
 	fs.logger.Info("File deleted", zap.String("path", filePath))
 	return nil
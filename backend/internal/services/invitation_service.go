@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/auth"
+)
+
+// InvitationService onboards users and admins by email. An admin invites an
+// address with a role; the invitee follows the emailed link, picks their own
+// password and is signed in. Tokens expire and work once.
+type InvitationService struct {
+	cfg            config.InvitationConfig
+	invitationRepo repositories.UserInvitationRepository
+	userRepo       repositories.UserRepository
+	roleRepo       repositories.RoleRepository
+	authService    *AuthService
+	passwords      *PasswordPolicyService
+	permissions    *PermissionService
+	emailService   *EmailService
+	logService     *ActivityLogService
+	logger         *zap.Logger
+}
+
+// AcceptInvitationRequest is what the invitee fills in to create the account.
+type AcceptInvitationRequest struct {
+	Token        string
+	Name         string
+	Password     string
+	TermsVersion string
+	Client       ClientInfo
+}
+
+func NewInvitationService(
+	cfg config.InvitationConfig,
+	invitationRepo repositories.UserInvitationRepository,
+	userRepo repositories.UserRepository,
+	roleRepo repositories.RoleRepository,
+	authService *AuthService,
+	passwords *PasswordPolicyService,
+	emailService *EmailService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *InvitationService {
+	return &InvitationService{
+		cfg:            cfg,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		authService:    authService,
+		passwords:      passwords,
+		emailService:   emailService,
+		logService:     logService,
+		logger:         logger,
+	}
+}
+
+// SetPermissions refuses invitations with roles carrying permissions the
+// inviting admin does not hold. Without it, the permission the handler
+// checks is the only limit.
+func (s *InvitationService) SetPermissions(permissions *PermissionService) {
+	s.permissions = permissions
+}
+
+// Invite emails an invitation to create an account with role. Inviting an
+// address again replaces its open invitation.
+func (s *InvitationService) Invite(ctx context.Context, actorID uuid.UUID, email, name, role string) (*models.UserInvitation, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	role = strings.ToLower(strings.TrimSpace(role))
+	if role == "" {
+		role = "user"
+	}
+	found, err := s.invitableRole(ctx, actorID, role)
+	if err != nil {
+		return nil, err
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, email); existing != nil {
+		return nil, errors.New("user already exists")
+	}
+
+	now := time.Now()
+	if err := s.invitationRepo.RevokePendingForEmail(ctx, email, now); err != nil {
+		return nil, err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	invitation := &models.UserInvitation{
+		ID:        uuid.New(),
+		Email:     email,
+		Role:      found.Name,
+		TokenHash: hashToken(token),
+		InvitedBy: &actorID,
+		ExpiresAt: now.Add(s.cfg.TTL),
+		SentAt:    now,
+		SendCount: 1,
+		CreatedAt: now,
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		invitation.Name = &name
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	if err := s.send(ctx, actorID, invitation, token); err != nil {
+		if _, revokeErr := s.invitationRepo.Revoke(ctx, invitation.ID, time.Now()); revokeErr != nil {
+			s.logger.Error("Failed to revoke unsent invitation", zap.String("invitation_id", invitation.ID.String()), zap.Error(revokeErr))
+		}
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "user_invited", strPtr("invitation"), strPtr(invitation.ID.String()), map[string]interface{}{
+		"email": email,
+		"role":  invitation.Role,
+	})
+	return invitation, nil
+}
+
+// List returns invitations newest first; pendingOnly leaves out accepted,
+// revoked and expired ones.
+func (s *InvitationService) List(ctx context.Context, pendingOnly bool, limit, offset int) ([]*models.UserInvitation, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.invitationRepo.List(ctx, pendingOnly, time.Now(), limit, offset)
+}
+
+// Resend emails an open or expired invitation again under a new token, so
+// earlier links stop working, and restarts its expiry.
+func (s *InvitationService) Resend(ctx context.Context, actorID, id uuid.UUID) (*models.UserInvitation, error) {
+	invitation, err := s.invitationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, errors.New("invitation not found")
+	}
+	if status := invitation.Status(time.Now()); status == models.InvitationStatusAccepted || status == models.InvitationStatusRevoked {
+		return nil, errors.New("invitation was already " + status)
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, invitation.Email); existing != nil {
+		return nil, errors.New("user already exists")
+	}
+	if _, err := s.invitableRole(ctx, actorID, invitation.Role); err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	resent, err := s.invitationRepo.Resend(ctx, invitation.ID, hashToken(token), now.Add(s.cfg.TTL), now)
+	if err != nil {
+		return nil, err
+	}
+	if !resent {
+		return nil, errors.New("invitation not found")
+	}
+	if err := s.send(ctx, actorID, invitation, token); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "invitation_resent", strPtr("invitation"), strPtr(invitation.ID.String()), map[string]interface{}{
+		"email": invitation.Email,
+	})
+	return s.invitationRepo.GetByID(ctx, invitation.ID)
+}
+
+// invitableRole returns the role named name if actorID may invite someone
+// with it: they must hold every permission it carries.
+func (s *InvitationService) invitableRole(ctx context.Context, actorID uuid.UUID, name string) (*models.Role, error) {
+	role, err := s.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("role not found")
+	}
+	if s.permissions != nil {
+		if err := s.permissions.checkHeld(ctx, actorID, role.Permissions); err != nil {
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+// Revoke withdraws an open invitation.
+func (s *InvitationService) Revoke(ctx context.Context, actorID, id uuid.UUID) error {
+	invitation, err := s.invitationRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if invitation == nil {
+		return errors.New("invitation not found")
+	}
+	revoked, err := s.invitationRepo.Revoke(ctx, invitation.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("invitation was already " + invitation.Status(time.Now()))
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "invitation_revoked", strPtr("invitation"), strPtr(invitation.ID.String()), map[string]interface{}{
+		"email": invitation.Email,
+	})
+	return nil
+}
+
+// Lookup returns the open invitation behind token, so the accept page can
+// show who it is for.
+func (s *InvitationService) Lookup(ctx context.Context, token string) (*models.UserInvitation, error) {
+	invitation, err := s.invitationRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.Status(time.Now()) != models.InvitationStatusPending {
+		return nil, errors.New("invalid or expired invitation")
+	}
+	return invitation, nil
+}
+
+// Accept creates the invited account with the invitee's password and signs
+// them in. Following the emailed link verifies the address.
+func (s *InvitationService) Accept(ctx context.Context, req AcceptInvitationRequest) (*models.User, *models.Session, error) {
+	invitation, err := s.Lookup(ctx, req.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, invitation.Email); existing != nil {
+		return nil, nil, errors.New("user already exists")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" && invitation.Name != nil {
+		name = *invitation.Name
+	}
+	if name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+	if err := s.passwords.Validate(ctx, nil, req.Password); err != nil {
+		return nil, nil, err
+	}
+	if err := s.authService.checkTerms(ctx, req.TermsVersion); err != nil {
+		return nil, nil, err
+	}
+	passwordHash, err := auth.GenerateHash(req.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	source := models.SignupSourceInvitation
+	user := &models.User{
+		ID:                uuid.New(),
+		Email:             invitation.Email,
+		PasswordHash:      passwordHash,
+		Name:              name,
+		Status:            "active",
+		Role:              invitation.Role,
+		SignupSource:      &source,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	accepted, err := s.invitationRepo.MarkAccepted(ctx, invitation.ID, user.ID, now)
+	if err != nil || !accepted {
+		// Revoked or used concurrently; don't leave an account behind
+		if deleteErr := s.userRepo.Delete(ctx, user.ID); deleteErr != nil {
+			s.logger.Error("Failed to remove account of unused invitation", zap.String("user_id", user.ID.String()), zap.Error(deleteErr))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("invalid or expired invitation")
+	}
+	if err := s.userRepo.UpdateEmail(ctx, user.ID, user.Email, true); err != nil {
+		s.logger.Warn("Failed to mark invited email verified", zap.String("user_id", user.ID.String()), zap.Error(err))
+	} else {
+		user.EmailVerified = true
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "invitation_accepted", strPtr("invitation"), strPtr(invitation.ID.String()), map[string]interface{}{
+		"invited_by": invitation.InvitedBy,
+		"role":       user.Role,
+	})
+	s.authService.recordSignupConsents(ctx, user, models.ConsentSourceInvitation, req.TermsVersion, nil, req.Client)
+
+	session, _, err := s.authService.completeLogin(ctx, user, req.Client, LoginMethodPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.logger.Info("User joined by invitation", zap.String("user_id", user.ID.String()))
+	return user, session, nil
+}
+
+func (s *InvitationService) send(ctx context.Context, actorID uuid.UUID, invitation *models.UserInvitation, token string) error {
+	inviter := "An administrator"
+	if actor, err := s.userRepo.GetByID(ctx, actorID); err == nil && actor != nil {
+		inviter = actor.Name
+	}
+	name := ""
+	if invitation.Name != nil {
+		name = *invitation.Name
+	}
+	inviteURL := withQuery(s.cfg.LinkURL, url.Values{"invitation_token": {token}})
+	if err := s.emailService.SendUserInvitation(ctx, invitation.Email, name, inviter, invitation.Role, inviteURL, s.cfg.TTL); err != nil {
+		return fmt.Errorf("failed to send invitation email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// LoginHistoryService keeps the sign-in attempts and token refreshes reported
+// by AuthService, so users can review where their account was used.
+type LoginHistoryService struct {
+	cfg            config.LoginHistoryConfig
+	loginEventRepo repositories.LoginEventRepository
+	logger         *zap.Logger
+}
+
+func NewLoginHistoryService(cfg config.LoginHistoryConfig, loginEventRepo repositories.LoginEventRepository, logger *zap.Logger) *LoginHistoryService {
+	return &LoginHistoryService{
+		cfg:            cfg,
+		loginEventRepo: loginEventRepo,
+		logger:         logger,
+	}
+}
+
+// Record stores a login event. It is registered with AuthService.OnLoginEvent;
+// a failure to record never fails the sign-in itself.
+func (s *LoginHistoryService) Record(ctx context.Context, event *models.LoginEvent) {
+	if err := s.loginEventRepo.Create(ctx, event); err != nil {
+		s.logger.Warn("Failed to record login event", zap.String("event_type", event.EventType), zap.Error(err))
+	}
+}
+
+// ListForUser returns the user's login history, newest first.
+func (s *LoginHistoryService) ListForUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.LoginEvent, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.loginEventRepo.ListByUserID(ctx, userID, limit, offset)
+}
+
+// PurgeExpired deletes events older than the retention period.
+func (s *LoginHistoryService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.loginEventRepo.DeleteBefore(ctx, time.Now().Add(-s.cfg.Retention))
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// MagicLinkService signs users in with a single-use link sent to their email
+// address, as an alternative to a password. Links are stored hashed, expire
+// quickly and end in the same session path as a password login.
+type MagicLinkService struct {
+	cfg             config.MagicLinkConfig
+	magicLinkRepo   repositories.MagicLinkRepository
+	userRepo        repositories.UserRepository
+	authService     *AuthService
+	settingsService *SystemSettingsService
+	emailService    *EmailService
+	logService      *ActivityLogService
+	logger          *zap.Logger
+}
+
+func NewMagicLinkService(
+	cfg config.MagicLinkConfig,
+	magicLinkRepo repositories.MagicLinkRepository,
+	userRepo repositories.UserRepository,
+	authService *AuthService,
+	settingsService *SystemSettingsService,
+	emailService *EmailService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *MagicLinkService {
+	return &MagicLinkService{
+		cfg:             cfg,
+		magicLinkRepo:   magicLinkRepo,
+		userRepo:        userRepo,
+		authService:     authService,
+		settingsService: settingsService,
+		emailService:    emailService,
+		logService:      logService,
+		logger:          logger,
+	}
+}
+
+// Enabled reports whether admins have turned magic-link login on.
+func (s *MagicLinkService) Enabled(ctx context.Context) bool {
+	return s.settingsService.Bool(ctx, models.SettingMagicLinkEnabled)
+}
+
+// RequestLink emails a sign-in link to the address. It returns nil whether or
+// not an account exists, and quietly sends nothing once the address has had
+// MaxPerEmail links within RequestWindow, so the response reveals neither.
+func (s *MagicLinkService) RequestLink(ctx context.Context, email string, ipAddress *string) error {
+	if !s.Enabled(ctx) {
+		return errors.New("magic-link login is disabled")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		s.logger.Info("Magic link requested for unknown email")
+		return nil
+	}
+	if !s.authService.canSignIn(ctx, user) {
+		s.logger.Info("Magic link requested for inactive account", zap.String("user_id", user.ID.String()))
+		return nil
+	}
+
+	now := time.Now()
+	sent, err := s.magicLinkRepo.CountSince(ctx, user.ID, now.Add(-s.cfg.RequestWindow))
+	if err != nil {
+		return err
+	}
+	if sent >= s.cfg.MaxPerEmail {
+		s.logger.Warn("Magic link request limit reached", zap.String("user_id", user.ID.String()))
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	if err := s.magicLinkRepo.Create(ctx, &models.MagicLinkToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		IPAddress: ipAddress,
+		ExpiresAt: now.Add(s.cfg.TokenTTL),
+		CreatedAt: now,
+	}); err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	// Expired links still count towards the request limit until the window passes
+	if err := s.magicLinkRepo.DeleteExpired(ctx, now.Add(-s.cfg.RequestWindow)); err != nil {
+		s.logger.Warn("Failed to delete expired magic links", zap.Error(err))
+	}
+
+	if err := s.emailService.SendMagicLinkEmail(ctx, user.Email, withQuery(s.cfg.LoginURL, url.Values{"magic_token": {token}}), s.cfg.TokenTTL); err != nil {
+		return fmt.Errorf("failed to send magic link: %w", err)
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "magic_link_requested", strPtr("user"), strPtr(user.ID.String()), nil)
+	return nil
+}
+
+// Verify consumes a link and starts a session for its owner through the same
+// path as a password login. Every other outstanding link for the user is
+// invalidated.
+func (s *MagicLinkService) Verify(ctx context.Context, token string, client ClientInfo) (*models.User, *models.Session, bool, error) {
+	if !s.Enabled(ctx) {
+		return nil, nil, false, errors.New("magic-link login is disabled")
+	}
+
+	link, err := s.magicLinkRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	now := time.Now()
+	if link == nil || link.UsedAt != nil || now.After(link.ExpiresAt) {
+		return nil, nil, false, errors.New("invalid or expired link")
+	}
+	used, err := s.magicLinkRepo.MarkUsed(ctx, link.ID, now)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !used {
+		// Used concurrently by another request
+		return nil, nil, false, errors.New("invalid or expired link")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, link.UserID)
+	if err != nil {
+		return nil, nil, false, errors.New("invalid or expired link")
+	}
+
+	if err := s.authService.requireSecondFactor(ctx, user, client, LoginMethodMagicLink); err != nil {
+		return nil, nil, false, err
+	}
+
+	session, isNewDevice, err := s.authService.completeLogin(ctx, user, client, LoginMethodMagicLink)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if err := s.magicLinkRepo.InvalidateByUserID(ctx, user.ID, now); err != nil {
+		s.logger.Warn("Failed to invalidate magic links", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "magic_link_login", strPtr("session"), strPtr(session.ID.String()), map[string]interface{}{
+		"new_device": isNewDevice,
+	})
+	s.logger.Info("User logged in with magic link", zap.String("user_id", user.ID.String()))
+
+	return user, session, isNewDevice, nil
+}
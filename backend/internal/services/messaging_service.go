@@ -15,17 +15,20 @@ import (
 type MessagingService struct {
 	messageRepo repositories.MessageRepository
 	userRepo    repositories.UserRepository
+	privacy     *PrivacyService
 	logger      *zap.Logger
 }
 
 func NewMessagingService(
 	messageRepo repositories.MessageRepository,
 	userRepo repositories.UserRepository,
+	privacy *PrivacyService,
 	logger *zap.Logger,
 ) *MessagingService {
 	return &MessagingService{
 		messageRepo: messageRepo,
 		userRepo:    userRepo,
+		privacy:     privacy,
 		logger:      logger,
 	}
 }
@@ -36,14 +39,13 @@ func (s *MessagingService) SendMessage(ctx context.Context, senderID, recipientI
 		return nil, errors.New("message content is required")
 	}
 
-	// Verify recipient exists
-	recipient, err := s.userRepo.GetByID(ctx, recipientID)
-	if err != nil || recipient == nil {
-		return nil, errors.New("recipient not found")
+	// Verify the recipient exists and accepts messages from the sender
+	if err := s.privacy.CanMessage(ctx, senderID, recipientID); err != nil {
+		return nil, err
 	}
 
 	// Get or create conversation
-	_, err = s.messageRepo.GetOrCreateConversation(ctx, senderID, recipientID)
+	_, err := s.messageRepo.GetOrCreateConversation(ctx, senderID, recipientID)
 	if err != nil {
 		return nil, err
 	}
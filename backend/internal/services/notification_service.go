@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,8 +15,15 @@ import (
 type NotificationService struct {
 	notificationRepo repositories.NotificationRepository
 	logger            *zap.Logger
+	consents          *ConsentService
+
+	createdHooks []NotificationCreatedHook
 }
 
+// NotificationCreatedHook is called after a notification is stored, e.g. to
+// deliver it over another channel.
+type NotificationCreatedHook func(ctx context.Context, notification *models.Notification)
+
 func NewNotificationService(notificationRepo repositories.NotificationRepository, logger *zap.Logger) *NotificationService {
 	return &NotificationService{
 		notificationRepo: notificationRepo,
@@ -23,8 +31,19 @@ func NewNotificationService(notificationRepo repositories.NotificationRepository
 	}
 }
 
-// CreateNotification creates a new notification
+// CreateNotification creates a new notification. Promotions are refused for
+// users who have not opted in to marketing.
 func (s *NotificationService) CreateNotification(ctx context.Context, userID uuid.UUID, notificationType, title, message string, link *string, metadata *string) (*models.Notification, error) {
+	if notificationType == models.NotificationTypePromotion && s.consents != nil {
+		allowed, err := s.consents.MarketingAllowed(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, errors.New("user has not opted in to marketing")
+		}
+	}
+
 	notification := &models.Notification{
 		ID:        uuid.New(),
 		UserID:    userID,
@@ -41,9 +60,24 @@ func (s *NotificationService) CreateNotification(ctx context.Context, userID uui
 		return nil, err
 	}
 
+	for _, hook := range s.createdHooks {
+		hook(ctx, notification)
+	}
+
 	return notification, nil
 }
 
+// SetConsents makes promotions depend on the user's current marketing
+// consent. Without it, promotions are created for everyone.
+func (s *NotificationService) SetConsents(consents *ConsentService) {
+	s.consents = consents
+}
+
+// OnCreated registers a hook to run after each notification is created.
+func (s *NotificationService) OnCreated(hook NotificationCreatedHook) {
+	s.createdHooks = append(s.createdHooks, hook)
+}
+
 // GetNotifications retrieves notifications for a user
 func (s *NotificationService) GetNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]*models.Notification, error) {
 	if limit <= 0 {
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+const identityLinkExpiry = 15 * time.Minute
+
+// OIDCService signs users in through external OpenID Connect providers using
+// the authorization code flow with PKCE. Identities are keyed by the
+// provider's subject claim, never by email.
+type OIDCService struct {
+	providers    map[string]config.OIDCProviderConfig
+	stateTTL     time.Duration
+	identityRepo repositories.IdentityRepository
+	stateRepo    repositories.OIDCStateRepository
+	linkRepo     repositories.IdentityLinkRepository
+	passkeyRepo  repositories.PasskeyRepository
+	userRepo     repositories.UserRepository
+	authService  *AuthService
+	logService   *ActivityLogService
+	logger       *zap.Logger
+
+	mu        sync.Mutex
+	discovery map[string]*oidc.Provider
+}
+
+func NewOIDCService(
+	cfg config.OIDCConfig,
+	identityRepo repositories.IdentityRepository,
+	stateRepo repositories.OIDCStateRepository,
+	linkRepo repositories.IdentityLinkRepository,
+	passkeyRepo repositories.PasskeyRepository,
+	userRepo repositories.UserRepository,
+	authService *AuthService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *OIDCService {
+	providers := make(map[string]config.OIDCProviderConfig, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		providers[p.Name] = p
+	}
+	return &OIDCService{
+		providers:    providers,
+		stateTTL:     cfg.StateTTL,
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+		linkRepo:     linkRepo,
+		passkeyRepo:  passkeyRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+		logService:   logService,
+		logger:       logger,
+		discovery:    make(map[string]*oidc.Provider),
+	}
+}
+
+// OIDCProviderInfo is the public description of a configured provider.
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OIDCLoginResult is the outcome of a provider callback. Exactly one of
+// Session, Identity (link flow) or LinkToken is set.
+type OIDCLoginResult struct {
+	User      *models.User
+	Session   *models.Session
+	IsNewUser bool
+	Identity  *models.UserIdentity
+	LinkToken string
+	LinkEmail string
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Picture       string `json:"picture"`
+}
+
+func (s *OIDCService) Providers() []OIDCProviderInfo {
+	infos := make([]OIDCProviderInfo, 0, len(s.providers))
+	for _, p := range s.providers {
+		infos = append(infos, OIDCProviderInfo{Name: p.Name, DisplayName: p.DisplayName})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// BeginAuth creates the provider authorization URL. When linkUserID is set
+// the resulting identity is attached to that user instead of signing in.
+func (s *OIDCService) BeginAuth(ctx context.Context, providerName string, linkUserID *uuid.UUID) (string, error) {
+	oauthCfg, _, err := s.client(ctx, providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := time.Now()
+	if err := s.stateRepo.Create(ctx, &models.OIDCAuthState{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    now.Add(s.stateTTL),
+		CreatedAt:    now,
+	}); err != nil {
+		return "", err
+	}
+	if err := s.stateRepo.DeleteExpired(ctx, now); err != nil {
+		s.logger.Warn("Failed to purge expired oidc states", zap.Error(err))
+	}
+
+	return oauthCfg.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)), nil
+}
+
+// Complete exchanges the authorization code and resolves the provider
+// identity to a local user.
+func (s *OIDCService) Complete(ctx context.Context, providerName, code, state string, client ClientInfo) (*OIDCLoginResult, error) {
+	authState, err := s.stateRepo.Consume(ctx, hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if authState == nil || authState.Provider != providerName || time.Now().After(authState.ExpiresAt) {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	oauthCfg, provider, err := s.client(ctx, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthCfg.Exchange(ctx, code, oauth2.VerifierOption(authState.CodeVerifier))
+	if err != nil {
+		s.logger.Warn("OIDC code exchange failed", zap.String("provider", providerName), zap.Error(err))
+		return nil, errors.New("authorization failed")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("authorization failed")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: oauthCfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		s.logger.Warn("OIDC id token rejected", zap.String("provider", providerName), zap.Error(err))
+		return nil, errors.New("authorization failed")
+	}
+	if idToken.Nonce != authState.Nonce {
+		return nil, errors.New("authorization failed")
+	}
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, errors.New("authorization failed")
+	}
+
+	existing, err := s.identityRepo.GetByProviderSubject(ctx, providerName, idToken.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if authState.LinkUserID != nil {
+		if existing != nil {
+			if existing.UserID == *authState.LinkUserID {
+				return &OIDCLoginResult{Identity: existing}, nil
+			}
+			return nil, errors.New("identity is linked to another account")
+		}
+		identity, err := s.createIdentity(ctx, *authState.LinkUserID, providerName, idToken.Subject, claims)
+		if err != nil {
+			return nil, err
+		}
+		return &OIDCLoginResult{Identity: identity}, nil
+	}
+
+	if existing != nil {
+		user, err := s.userRepo.GetByID(ctx, existing.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.identityRepo.UpdateLastLogin(ctx, existing.ID, time.Now()); err != nil {
+			s.logger.Warn("Failed to update identity last login", zap.Error(err))
+		}
+		session, err := s.startSession(ctx, user, client)
+		if err != nil {
+			return nil, err
+		}
+		return &OIDCLoginResult{User: user, Session: session}, nil
+	}
+
+	if claims.Email == "" {
+		return nil, errors.New("provider did not return an email address")
+	}
+
+	if user, _ := s.userRepo.GetByEmail(ctx, claims.Email); user != nil {
+		// Only a verified email may be offered for linking, and even then the
+		// account owner has to prove control of the account first.
+		if !claims.EmailVerified {
+			return nil, errors.New("email already registered")
+		}
+		linkToken, err := randomToken()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		if err := s.linkRepo.Create(ctx, &models.IdentityLinkRequest{
+			ID:        uuid.New(),
+			TokenHash: hashToken(linkToken),
+			UserID:    user.ID,
+			Provider:  providerName,
+			Subject:   idToken.Subject,
+			Email:     claims.Email,
+			ExpiresAt: now.Add(identityLinkExpiry),
+			CreatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+		return &OIDCLoginResult{LinkToken: linkToken, LinkEmail: claims.Email}, nil
+	}
+
+	user, err := s.createUser(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.createIdentity(ctx, user.ID, providerName, idToken.Subject, claims); err != nil {
+		return nil, err
+	}
+	session, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCLoginResult{User: user, Session: session, IsNewUser: true}, nil
+}
+
+// ConfirmLink attaches a pending provider identity to the existing account
+// once the owner has re-entered the account password.
+func (s *OIDCService) ConfirmLink(ctx context.Context, linkToken, password string, client ClientInfo) (*OIDCLoginResult, error) {
+	req, err := s.linkRepo.GetByTokenHash(ctx, hashToken(linkToken))
+	if err != nil {
+		return nil, err
+	}
+	if req == nil || req.UsedAt != nil || time.Now().After(req.ExpiresAt) {
+		return nil, errors.New("invalid or expired link token")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.authService.verifyPassword(ctx, user, password) {
+		return nil, errors.New("invalid credentials")
+	}
+
+	if err := s.linkRepo.MarkUsed(ctx, req.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	identity, err := s.createIdentity(ctx, user.ID, req.Provider, req.Subject, oidcClaims{Email: req.Email, EmailVerified: true})
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCLoginResult{User: user, Session: session, Identity: identity}, nil
+}
+
+func (s *OIDCService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	return s.identityRepo.ListByUser(ctx, userID)
+}
+
+// Unlink removes an identity unless it is the user's last way to sign in.
+func (s *OIDCService) Unlink(ctx context.Context, userID, identityID uuid.UUID) error {
+	identity, err := s.identityRepo.GetByID(ctx, identityID)
+	if err != nil {
+		return err
+	}
+	if identity == nil || identity.UserID != userID {
+		return errors.New("identity not found")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	identities, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	passkeys, err := s.passkeyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" && len(identities) <= 1 && len(passkeys) == 0 {
+		return errors.New("cannot remove your last login method")
+	}
+
+	if err := s.identityRepo.Delete(ctx, identityID); err != nil {
+		return err
+	}
+	s.logService.Record(ctx, &userID, user.Role, "identity_unlinked", strPtr("user_identity"), strPtr(identityID.String()), map[string]interface{}{
+		"provider": identity.Provider,
+	})
+	return nil
+}
+
+func (s *OIDCService) client(ctx context.Context, providerName string) (*oauth2.Config, *oidc.Provider, error) {
+	cfg, ok := s.providers[providerName]
+	if !ok {
+		return nil, nil, errors.New("provider not found")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	provider, ok := s.discovery[providerName]
+	if !ok {
+		// Discovery runs on first use so an unreachable provider does not
+		// block startup; the result is cached for the process lifetime.
+		discovered, err := oidc.NewProvider(context.WithoutCancel(ctx), cfg.IssuerURL)
+		if err != nil {
+			s.logger.Error("OIDC discovery failed", zap.String("provider", providerName), zap.Error(err))
+			return nil, nil, fmt.Errorf("provider %s is unavailable", providerName)
+		}
+		provider = discovered
+		s.discovery[providerName] = provider
+	}
+
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       cfg.Scopes,
+	}, provider, nil
+}
+
+func (s *OIDCService) createIdentity(ctx context.Context, userID uuid.UUID, providerName, subject string, claims oidcClaims) (*models.UserIdentity, error) {
+	now := time.Now()
+	identity := &models.UserIdentity{
+		ID:            uuid.New(),
+		UserID:        userID,
+		Provider:      providerName,
+		Subject:       subject,
+		EmailVerified: claims.EmailVerified,
+		CreatedAt:     now,
+		LastLoginAt:   &now,
+	}
+	if claims.Email != "" {
+		identity.Email = &claims.Email
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &userID, "user", "identity_linked", strPtr("user_identity"), strPtr(identity.ID.String()), map[string]interface{}{
+		"provider": providerName,
+	})
+	return identity, nil
+}
+
+// createUser registers a password-less account. The email is only marked
+// verified when the provider asserts it.
+func (s *OIDCService) createUser(ctx context.Context, providerName string, claims oidcClaims) (*models.User, error) {
+	now := time.Now()
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+	status := "pending"
+	if claims.EmailVerified {
+		status = "active"
+	}
+	source := "oidc:" + providerName
+
+	user := &models.User{
+		ID:                uuid.New(),
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              name,
+		Status:            status,
+		Role:              "user",
+		SignupSource:      &source,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if claims.GivenName != "" {
+		user.FirstName = &claims.GivenName
+	}
+	if claims.FamilyName != "" {
+		user.LastName = &claims.FamilyName
+	}
+	if claims.Picture != "" {
+		user.PhotoURL = &claims.Picture
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	s.logger.Info("User signed up via OIDC", zap.String("user_id", user.ID.String()), zap.String("provider", providerName))
+	return user, nil
+}
+
+// startSession signs the user in. Users with a second factor are challenged
+// for it first, as with any other sign-in method.
+func (s *OIDCService) startSession(ctx context.Context, user *models.User, client ClientInfo) (*models.Session, error) {
+	if err := s.authService.requireSecondFactor(ctx, user, client, LoginMethodOIDC); err != nil {
+		return nil, err
+	}
+	session, _, err := s.authService.completeLogin(ctx, user, client, LoginMethodOIDC)
+	return session, err
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// OrganizationService manages organizations, the workspaces users share
+// records in: their members and roles and email invitations. Every
+// organization keeps at least one owner.
+type OrganizationService struct {
+	cfg            config.OrganizationConfig
+	orgRepo        repositories.OrganizationRepository
+	invitationRepo repositories.OrganizationInvitationRepository
+	userRepo       repositories.UserRepository
+	emailService   *EmailService
+	logService     *ActivityLogService
+	logger         *zap.Logger
+}
+
+func NewOrganizationService(
+	cfg config.OrganizationConfig,
+	orgRepo repositories.OrganizationRepository,
+	invitationRepo repositories.OrganizationInvitationRepository,
+	userRepo repositories.UserRepository,
+	emailService *EmailService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *OrganizationService {
+	return &OrganizationService{
+		cfg:            cfg,
+		orgRepo:        orgRepo,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		emailService:   emailService,
+		logService:     logService,
+		logger:         logger,
+	}
+}
+
+// Create starts an organization with userID as its owner.
+func (s *OrganizationService) Create(ctx context.Context, userID uuid.UUID, name string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("organization name is required")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	now := time.Now()
+	org := &models.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedBy: &user.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Role:      models.OrganizationRoleOwner,
+	}
+	owner := &models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         user.ID,
+		Role:           models.OrganizationRoleOwner,
+		CreatedAt:      now,
+	}
+	if err := s.orgRepo.Create(ctx, org, owner); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	s.record(ctx, user, "organization_created", org.ID, map[string]interface{}{"name": org.Name})
+	return org, nil
+}
+
+// List returns the user's organizations with their role in each.
+func (s *OrganizationService) List(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	return s.orgRepo.ListByUserID(ctx, userID)
+}
+
+// Get returns an organization the user belongs to.
+func (s *OrganizationService) Get(ctx context.Context, orgID, userID uuid.UUID) (*models.Organization, error) {
+	member, err := s.member(ctx, orgID, userID, models.OrganizationRoleMember)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("organization not found")
+	}
+	org.Role = member.Role
+	return org, nil
+}
+
+// Rename changes the organization's name. It needs the admin role.
+func (s *OrganizationService) Rename(ctx context.Context, orgID, userID uuid.UUID, name string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("organization name is required")
+	}
+	member, err := s.member(ctx, orgID, userID, models.OrganizationRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("organization not found")
+	}
+
+	org.Role = member.Role
+	org.Name = name
+	org.UpdatedAt = time.Now()
+	if err := s.orgRepo.Update(ctx, org); err != nil {
+		return nil, err
+	}
+	s.recordByID(ctx, userID, "organization_updated", org.ID, map[string]interface{}{"name": org.Name})
+	return org, nil
+}
+
+// Delete removes the organization with all of its records. Only owners can
+// delete it.
+func (s *OrganizationService) Delete(ctx context.Context, orgID, userID uuid.UUID) error {
+	if _, err := s.member(ctx, orgID, userID, models.OrganizationRoleOwner); err != nil {
+		return err
+	}
+	if err := s.orgRepo.Delete(ctx, orgID); err != nil {
+		return err
+	}
+	s.recordByID(ctx, userID, "organization_deleted", orgID, nil)
+	return nil
+}
+
+// ListMembers returns the organization's members to any of them.
+func (s *OrganizationService) ListMembers(ctx context.Context, orgID, userID uuid.UUID) ([]*models.OrganizationMember, error) {
+	if _, err := s.member(ctx, orgID, userID, models.OrganizationRoleMember); err != nil {
+		return nil, err
+	}
+	return s.orgRepo.ListMembers(ctx, orgID)
+}
+
+// ChangeRole gives a member another role. Admins can change members and
+// admins; only owners can make or unmake owners, and the last owner cannot
+// step down.
+func (s *OrganizationService) ChangeRole(ctx context.Context, orgID, actorID, targetID uuid.UUID, role string) error {
+	if !models.IsOrganizationRole(role) {
+		return errors.New("invalid organization role")
+	}
+	actor, err := s.member(ctx, orgID, actorID, models.OrganizationRoleAdmin)
+	if err != nil {
+		return err
+	}
+	target, err := s.orgRepo.GetMember(ctx, orgID, targetID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return errors.New("member not found")
+	}
+	if target.Role == role {
+		return nil
+	}
+	if (target.Role == models.OrganizationRoleOwner || role == models.OrganizationRoleOwner) && actor.Role != models.OrganizationRoleOwner {
+		return errors.New("only owners can grant or remove the owner role")
+	}
+	if err := s.keepOwner(ctx, target); err != nil {
+		return err
+	}
+
+	if err := s.orgRepo.UpdateMemberRole(ctx, orgID, targetID, role); err != nil {
+		return err
+	}
+	s.recordByID(ctx, actorID, "organization_member_role_changed", orgID, map[string]interface{}{
+		"user_id": targetID.String(),
+		"from":    target.Role,
+		"to":      role,
+	})
+	return nil
+}
+
+// RemoveMember takes a user out of the organization. Members may always
+// leave; removing someone else needs the admin role, or the owner role to
+// remove an owner. The last owner cannot leave.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID, actorID, targetID uuid.UUID) error {
+	minimum := models.OrganizationRoleAdmin
+	if actorID == targetID {
+		minimum = models.OrganizationRoleMember
+	}
+	actor, err := s.member(ctx, orgID, actorID, minimum)
+	if err != nil {
+		return err
+	}
+	target, err := s.orgRepo.GetMember(ctx, orgID, targetID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return errors.New("member not found")
+	}
+	if target.Role == models.OrganizationRoleOwner && actor.Role != models.OrganizationRoleOwner {
+		return errors.New("only owners can grant or remove the owner role")
+	}
+	if err := s.keepOwner(ctx, target); err != nil {
+		return err
+	}
+
+	if err := s.orgRepo.RemoveMember(ctx, orgID, targetID); err != nil {
+		return err
+	}
+	action := "organization_member_removed"
+	if actorID == targetID {
+		action = "organization_left"
+	}
+	s.recordByID(ctx, actorID, action, orgID, map[string]interface{}{"user_id": targetID.String()})
+	return nil
+}
+
+// Invite emails email a link to join the organization with role, replacing
+// earlier open invitations of the address. It needs the admin role, and the
+// owner role to invite owners.
+func (s *OrganizationService) Invite(ctx context.Context, orgID, actorID uuid.UUID, email, role string) (*models.OrganizationInvitation, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !models.IsOrganizationRole(role) {
+		return nil, errors.New("invalid organization role")
+	}
+	actor, err := s.member(ctx, orgID, actorID, models.OrganizationRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.OrganizationRoleOwner && actor.Role != models.OrganizationRoleOwner {
+		return nil, errors.New("only owners can grant or remove the owner role")
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, email); existing != nil {
+		member, err := s.orgRepo.GetMember(ctx, orgID, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			return nil, errors.New("already a member of this organization")
+		}
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("organization not found")
+	}
+	inviter, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	now := time.Now()
+	if err := s.invitationRepo.RevokePendingForEmail(ctx, orgID, email, now); err != nil {
+		return nil, err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	invitation := &models.OrganizationInvitation{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hashToken(token),
+		InvitedBy:      &inviter.ID,
+		ExpiresAt:      now.Add(s.cfg.InvitationTTL),
+		CreatedAt:      now,
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	inviteURL := withQuery(s.cfg.InvitationURL, url.Values{"org_invitation_token": {token}})
+	if err := s.emailService.SendOrganizationInvitation(ctx, email, org.Name, inviter.Name, role, inviteURL, s.cfg.InvitationTTL); err != nil {
+		if _, revokeErr := s.invitationRepo.Revoke(ctx, invitation.ID, time.Now()); revokeErr != nil {
+			s.logger.Error("Failed to revoke unsent invitation", zap.String("invitation_id", invitation.ID.String()), zap.Error(revokeErr))
+		}
+		return nil, fmt.Errorf("failed to send invitation email: %w", err)
+	}
+
+	s.record(ctx, inviter, "organization_member_invited", orgID, map[string]interface{}{
+		"email": email,
+		"role":  role,
+	})
+	return invitation, nil
+}
+
+// ListInvitations returns the organization's open invitations. It needs the
+// admin role.
+func (s *OrganizationService) ListInvitations(ctx context.Context, orgID, userID uuid.UUID) ([]*models.OrganizationInvitation, error) {
+	if _, err := s.member(ctx, orgID, userID, models.OrganizationRoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.invitationRepo.ListPending(ctx, orgID, time.Now())
+}
+
+// RevokeInvitation withdraws an open invitation. It needs the admin role.
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, orgID, userID, invitationID uuid.UUID) error {
+	if _, err := s.member(ctx, orgID, userID, models.OrganizationRoleAdmin); err != nil {
+		return err
+	}
+	invitation, err := s.invitationRepo.GetByID(ctx, orgID, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation == nil {
+		return errors.New("invitation not found")
+	}
+	revoked, err := s.invitationRepo.Revoke(ctx, invitation.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("invitation not found")
+	}
+	s.recordByID(ctx, userID, "organization_invitation_revoked", orgID, map[string]interface{}{"email": invitation.Email})
+	return nil
+}
+
+// AcceptInvitation adds the signed-in user to the organization they were
+// invited to. The invitation must be addressed to the user's email and
+// works once.
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, token string, userID uuid.UUID) (*models.Organization, error) {
+	invitation, err := s.invitationRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if invitation == nil || invitation.AcceptedAt != nil || invitation.RevokedAt != nil || now.After(invitation.ExpiresAt) {
+		return nil, errors.New("invalid or expired invitation")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, errors.New("invitation was sent to a different email address")
+	}
+
+	existing, err := s.orgRepo.GetMember(ctx, invitation.OrganizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("already a member of this organization")
+	}
+
+	accepted, err := s.invitationRepo.MarkAccepted(ctx, invitation.ID, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		// Used concurrently by another request
+		return nil, errors.New("invalid or expired invitation")
+	}
+	if err := s.orgRepo.AddMember(ctx, &models.OrganizationMember{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         user.ID,
+		Role:           invitation.Role,
+		CreatedAt:      now,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	s.record(ctx, user, "organization_invitation_accepted", invitation.OrganizationID, map[string]interface{}{"role": invitation.Role})
+	return s.Get(ctx, invitation.OrganizationID, user.ID)
+}
+
+// MemberRole returns the user's role in the organization, or "" when they
+// are not a member. It lets OrganizationContext resolve the request's
+// organization.
+func (s *OrganizationService) MemberRole(ctx context.Context, orgID, userID uuid.UUID) (string, error) {
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+	if err != nil || member == nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// organizationRoleRank orders roles so a minimum can be required.
+var organizationRoleRank = map[string]int{
+	models.OrganizationRoleMember: 1,
+	models.OrganizationRoleAdmin:  2,
+	models.OrganizationRoleOwner:  3,
+}
+
+// member returns the user's membership if their role is at least minimum.
+// Non-members are told the organization does not exist.
+func (s *OrganizationService) member(ctx context.Context, orgID, userID uuid.UUID, minimum string) (*models.OrganizationMember, error) {
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, errors.New("organization not found")
+	}
+	if organizationRoleRank[member.Role] < organizationRoleRank[minimum] {
+		return nil, fmt.Errorf("organization %s role required", minimum)
+	}
+	return member, nil
+}
+
+// keepOwner refuses to change or remove target when they are the
+// organization's last owner.
+func (s *OrganizationService) keepOwner(ctx context.Context, target *models.OrganizationMember) error {
+	if target.Role != models.OrganizationRoleOwner {
+		return nil
+	}
+	owners, err := s.orgRepo.CountMembersWithRole(ctx, target.OrganizationID, models.OrganizationRoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.New("an organization needs at least one owner")
+	}
+	return nil
+}
+
+func (s *OrganizationService) record(ctx context.Context, actor *models.User, action string, orgID uuid.UUID, metadata map[string]interface{}) {
+	s.logService.Record(ctx, &actor.ID, actor.Role, action, strPtr("organization"), strPtr(orgID.String()), metadata)
+}
+
+func (s *OrganizationService) recordByID(ctx context.Context, actorID uuid.UUID, action string, orgID uuid.UUID, metadata map[string]interface{}) {
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		s.logger.Warn("Failed to load user for activity log", zap.String("user_id", actorID.String()), zap.Error(err))
+		return
+	}
+	s.record(ctx, actor, action, orgID, metadata)
+}
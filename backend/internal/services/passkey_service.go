@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+const maxPasskeyNameLength = 100
+
+// PasskeyService registers WebAuthn passkeys and signs users in with them as
+// an alternative to a password. Passkeys are discoverable credentials with
+// user verification, so a login needs neither an email nor a password.
+type PasskeyService struct {
+	webAuthn     *webauthn.WebAuthn
+	challengeTTL time.Duration
+	passkeyRepo  repositories.PasskeyRepository
+	ceremonyRepo repositories.WebAuthnCeremonyRepository
+	identityRepo repositories.IdentityRepository
+	userRepo     repositories.UserRepository
+	authService  *AuthService
+	logService   *ActivityLogService
+	logger       *zap.Logger
+}
+
+func NewPasskeyService(
+	cfg config.WebAuthnConfig,
+	passkeyRepo repositories.PasskeyRepository,
+	ceremonyRepo repositories.WebAuthnCeremonyRepository,
+	identityRepo repositories.IdentityRepository,
+	userRepo repositories.UserRepository,
+	authService *AuthService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) (*PasskeyService, error) {
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebAuthn configuration: %w", err)
+	}
+
+	return &PasskeyService{
+		webAuthn:     webAuthn,
+		challengeTTL: cfg.ChallengeTTL,
+		passkeyRepo:  passkeyRepo,
+		ceremonyRepo: ceremonyRepo,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+		logService:   logService,
+		logger:       logger,
+	}, nil
+}
+
+// PasskeyCeremony is handed to the browser to start a registration or login:
+// Options go to navigator.credentials and Token comes back with the result.
+type PasskeyCeremony struct {
+	Token   string      `json:"ceremony_token"`
+	Options interface{} `json:"options"`
+}
+
+// passkeyUser adapts a user and their passkeys to the webauthn library. The
+// user handle is the user's UUID, so it carries no personal data.
+type passkeyUser struct {
+	user        *models.User
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte                         { return u.user.ID[:] }
+func (u *passkeyUser) WebAuthnName() string                       { return u.user.Email }
+func (u *passkeyUser) WebAuthnDisplayName() string                { return u.user.Name }
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+// BeginRegistration starts adding a passkey to the signed-in user's account.
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID uuid.UUID) (*PasskeyCeremony, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	webAuthnUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(webAuthnUser.credentials))
+	for _, credential := range webAuthnUser.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(webAuthnUser, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.saveCeremony(ctx, models.WebAuthnCeremonyRegistration, &userID, session)
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyCeremony{Token: token, Options: creation}, nil
+}
+
+// FinishRegistration verifies the authenticator's attestation and stores the
+// new passkey, linked to the client's device when it sent one.
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID uuid.UUID, token, name string, response []byte, client ClientInfo) (*models.PasskeyCredential, error) {
+	session, err := s.consumeCeremony(ctx, token, models.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if session.userID == nil || *session.userID != userID {
+		return nil, errors.New("invalid or expired ceremony")
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, errors.New("invalid passkey response")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	webAuthnUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.webAuthn.CreateCredential(webAuthnUser, session.data, parsed)
+	if err != nil {
+		s.logger.Info("Passkey registration rejected", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, errors.New("passkey registration failed")
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(credential.ID)
+	existing, err := s.passkeyRepo.GetByCredentialID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("passkey already registered")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > maxPasskeyNameLength {
+		name = name[:maxPasskeyNameLength]
+	}
+
+	passkey := &models.PasskeyCredential{
+		ID:              uuid.New(),
+		UserID:          userID,
+		CredentialID:    credentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      make([]string, 0, len(credential.Transport)),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+		CreatedAt:       time.Now(),
+	}
+	for _, transport := range credential.Transport {
+		passkey.Transports = append(passkey.Transports, string(transport))
+	}
+	if aaguid, err := uuid.FromBytes(credential.Authenticator.AAGUID); err == nil && aaguid != uuid.Nil {
+		value := aaguid.String()
+		passkey.AAGUID = &value
+	}
+	if device, _ := s.authService.touchDevice(ctx, userID, client); device != nil {
+		passkey.DeviceID = &device.ID
+	}
+
+	if err := s.passkeyRepo.Create(ctx, passkey); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &userID, user.Role, "passkey_registered", strPtr("passkey"), strPtr(passkey.ID.String()), map[string]interface{}{
+		"name": passkey.Name,
+	})
+	return passkey, nil
+}
+
+// BeginLogin starts a discoverable login. The options carry no allow list,
+// so the response reveals nothing about which accounts exist.
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*PasskeyCeremony, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.saveCeremony(ctx, models.WebAuthnCeremonyLogin, nil, session)
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyCeremony{Token: token, Options: assertion}, nil
+}
+
+// FinishLogin verifies the assertion and starts a session for the passkey's
+// owner. A sign count that fails to advance means the credential may have
+// been cloned; the passkey is then disabled and the login refused.
+func (s *PasskeyService) FinishLogin(ctx context.Context, token string, response []byte, client ClientInfo) (*models.User, *models.Session, bool, error) {
+	session, err := s.consumeCeremony(ctx, token, models.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, nil, false, errors.New("invalid passkey response")
+	}
+
+	passkey, err := s.passkeyRepo.GetByCredentialID(ctx, base64.RawURLEncoding.EncodeToString(parsed.RawID))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if passkey == nil {
+		return nil, nil, false, errors.New("passkey verification failed")
+	}
+	if passkey.CloneDetectedAt != nil {
+		return nil, nil, false, errors.New("passkey has been disabled")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, passkey.UserID)
+	if err != nil {
+		return nil, nil, false, errors.New("passkey verification failed")
+	}
+	webAuthnUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	credential, err := s.webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		return webAuthnUser, nil
+	}, session.data, parsed)
+	if err != nil {
+		s.logger.Info("Passkey login rejected", zap.String("passkey_id", passkey.ID.String()), zap.Error(err))
+		s.authService.recordLoginFailure(ctx, user, user.Email, LoginMethodPasskey, "passkey_verification_failed", client)
+		return nil, nil, false, errors.New("passkey verification failed")
+	}
+
+	now := time.Now()
+	if credential.Authenticator.CloneWarning {
+		if err := s.passkeyRepo.MarkCloned(ctx, passkey.ID, now); err != nil {
+			return nil, nil, false, err
+		}
+		s.logger.Warn("Passkey sign count regressed; possible cloned authenticator",
+			zap.String("user_id", user.ID.String()), zap.String("passkey_id", passkey.ID.String()),
+			zap.Uint32("stored_count", passkey.SignCount), zap.Uint32("reported_count", parsed.Response.AuthenticatorData.Counter))
+		s.logService.Record(ctx, &user.ID, user.Role, "passkey_clone_detected", strPtr("passkey"), strPtr(passkey.ID.String()), map[string]interface{}{
+			"stored_count":   passkey.SignCount,
+			"reported_count": parsed.Response.AuthenticatorData.Counter,
+		})
+		s.authService.recordLoginFailure(ctx, user, user.Email, LoginMethodPasskey, "passkey_cloned", client)
+		return nil, nil, false, errors.New("passkey has been disabled")
+	}
+
+	if err := s.passkeyRepo.RecordUse(ctx, passkey.ID, credential.Authenticator.SignCount, credential.Flags.BackupState, now); err != nil {
+		return nil, nil, false, err
+	}
+
+	sess, isNewDevice, err := s.authService.completeLogin(ctx, user, client, LoginMethodPasskey)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	s.logger.Info("User logged in with passkey", zap.String("user_id", user.ID.String()))
+	return user, sess, isNewDevice, nil
+}
+
+func (s *PasskeyService) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]*models.PasskeyCredential, error) {
+	return s.passkeyRepo.ListByUser(ctx, userID)
+}
+
+// DeletePasskey removes a passkey unless it is the user's last way to sign in.
+func (s *PasskeyService) DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error {
+	passkey, err := s.passkeyRepo.GetByID(ctx, passkeyID)
+	if err != nil {
+		return err
+	}
+	if passkey == nil || passkey.UserID != userID {
+		return errors.New("passkey not found")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" {
+		passkeys, err := s.passkeyRepo.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		identities, err := s.identityRepo.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(passkeys) <= 1 && len(identities) == 0 {
+			return errors.New("cannot remove your last login method")
+		}
+	}
+
+	if err := s.passkeyRepo.Delete(ctx, passkeyID); err != nil {
+		return err
+	}
+	s.logService.Record(ctx, &userID, user.Role, "passkey_removed", strPtr("passkey"), strPtr(passkeyID.String()), map[string]interface{}{
+		"name": passkey.Name,
+	})
+	return nil
+}
+
+// loadUser returns the user with their usable passkeys. Passkeys disabled by
+// clone detection are left out so they can no longer sign in.
+func (s *PasskeyService) loadUser(ctx context.Context, user *models.User) (*passkeyUser, error) {
+	passkeys, err := s.passkeyRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	webAuthnUser := &passkeyUser{user: user}
+	for _, passkey := range passkeys {
+		if passkey.CloneDetectedAt != nil {
+			continue
+		}
+		id, err := base64.RawURLEncoding.DecodeString(passkey.CredentialID)
+		if err != nil {
+			continue
+		}
+		credential := webauthn.Credential{
+			ID:              id,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: passkey.BackupEligible,
+				BackupState:    passkey.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{SignCount: passkey.SignCount},
+		}
+		for _, transport := range passkey.Transports {
+			credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(transport))
+		}
+		webAuthnUser.credentials = append(webAuthnUser.credentials, credential)
+	}
+	return webAuthnUser, nil
+}
+
+type passkeySession struct {
+	userID *uuid.UUID
+	data   webauthn.SessionData
+}
+
+func (s *PasskeyService) saveCeremony(ctx context.Context, ceremony string, userID *uuid.UUID, session *webauthn.SessionData) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := s.ceremonyRepo.Create(ctx, &models.WebAuthnCeremony{
+		TokenHash:   hashToken(token),
+		Ceremony:    ceremony,
+		UserID:      userID,
+		SessionData: string(data),
+		ExpiresAt:   now.Add(s.challengeTTL),
+		CreatedAt:   now,
+	}); err != nil {
+		return "", err
+	}
+
+	if err := s.ceremonyRepo.DeleteExpired(ctx, now); err != nil {
+		s.logger.Warn("Failed to delete expired WebAuthn ceremonies", zap.Error(err))
+	}
+	return token, nil
+}
+
+func (s *PasskeyService) consumeCeremony(ctx context.Context, token, ceremony string) (*passkeySession, error) {
+	stored, err := s.ceremonyRepo.Consume(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Ceremony != ceremony || time.Now().After(stored.ExpiresAt) {
+		return nil, errors.New("invalid or expired ceremony")
+	}
+
+	session := &passkeySession{userID: stored.UserID}
+	if err := json.Unmarshal([]byte(stored.SessionData), &session.data); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
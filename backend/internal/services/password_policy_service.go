@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/auth"
+)
+
+// maxPasswordHistory caps both the password_history_count setting and the
+// number of old hashes kept per user.
+const maxPasswordHistory = 24
+
+// PasswordPolicyService applies the admin-configured password policy: the
+// character rules, the breached-password check, reuse history and expiry.
+type PasswordPolicyService struct {
+	settings    *SystemSettingsService
+	userRepo    repositories.UserRepository
+	historyRepo repositories.PasswordHistoryRepository
+	breached    *auth.BreachedPasswords
+	permissions *PermissionService
+	logService  *ActivityLogService
+	logger      *zap.Logger
+}
+
+// PasswordEvaluation is the outcome of checking a candidate password.
+type PasswordEvaluation struct {
+	auth.PasswordValidationResult
+	Breached bool
+}
+
+func NewPasswordPolicyService(
+	settings *SystemSettingsService,
+	userRepo repositories.UserRepository,
+	historyRepo repositories.PasswordHistoryRepository,
+	breached *auth.BreachedPasswords,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		settings:    settings,
+		userRepo:    userRepo,
+		historyRepo: historyRepo,
+		breached:    breached,
+		logService:  logService,
+		logger:      logger,
+	}
+}
+
+// Policy returns the character rules currently in force.
+func (s *PasswordPolicyService) Policy(ctx context.Context) auth.PasswordPolicy {
+	return auth.PasswordPolicy{
+		MinLength:        s.settings.Int(ctx, models.SettingPasswordMinLength),
+		RequireUppercase: s.settings.Bool(ctx, models.SettingPasswordRequireUppercase),
+		RequireLowercase: s.settings.Bool(ctx, models.SettingPasswordRequireLowercase),
+		RequireNumber:    s.settings.Bool(ctx, models.SettingPasswordRequireNumber),
+		RequireSpecial:   s.settings.Bool(ctx, models.SettingPasswordRequireSpecial),
+	}
+}
+
+// Evaluate checks a password against the policy and the breached-password
+// corpus. It does not look at any user's history, so it is safe to expose to
+// signed-out callers such as the signup form.
+func (s *PasswordPolicyService) Evaluate(ctx context.Context, password string) PasswordEvaluation {
+	evaluation := PasswordEvaluation{
+		PasswordValidationResult: auth.ValidatePasswordWithPolicy(password, s.Policy(ctx)),
+	}
+	if s.settings.Bool(ctx, models.SettingPasswordCheckBreached) {
+		breached, err := s.breached.Contains(password)
+		if err != nil {
+			s.logger.Warn("Failed to check breached password corpus", zap.Error(err))
+		}
+		if breached {
+			evaluation.Breached = true
+			evaluation.Valid = false
+			evaluation.Errors = append(evaluation.Errors, "Password has appeared in a data breach. Please choose a different password")
+			evaluation.Score = 0
+			evaluation.Strength = auth.PasswordWeak
+		}
+	}
+	return evaluation
+}
+
+// Validate checks a new password for user. user may be nil for an account
+// that does not exist yet, in which case reuse is not checked.
+func (s *PasswordPolicyService) Validate(ctx context.Context, user *models.User, password string) error {
+	evaluation := s.Evaluate(ctx, password)
+	if !evaluation.Valid {
+		return fmt.Errorf("password validation failed: %s", evaluation.Errors[0])
+	}
+	if user == nil {
+		return nil
+	}
+
+	historyCount := s.settings.Int(ctx, models.SettingPasswordHistoryCount)
+	if historyCount <= 0 {
+		return nil
+	}
+	if user.PasswordHash != "" && auth.CheckPasswordHash(password, user.PasswordHash) {
+		return fmt.Errorf("password validation failed: Password must not match any of your last %d passwords", historyCount)
+	}
+	// The current password counts towards the history
+	previous, err := s.historyRepo.ListRecent(ctx, user.ID, historyCount-1)
+	if err != nil {
+		return err
+	}
+	for _, entry := range previous {
+		if auth.CheckPasswordHash(password, entry.PasswordHash) {
+			return fmt.Errorf("password validation failed: Password must not match any of your last %d passwords", historyCount)
+		}
+	}
+	return nil
+}
+
+// SetPassword validates and stores a new password for user, keeping the old
+// hash in the history. mustChange asks the user to replace it at their next
+// sign-in.
+func (s *PasswordPolicyService) SetPassword(ctx context.Context, user *models.User, password string, mustChange bool) error {
+	if err := s.Validate(ctx, user, password); err != nil {
+		return err
+	}
+
+	hash, err := auth.GenerateHash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	if user.PasswordHash != "" {
+		if err := s.historyRepo.Create(ctx, &models.PasswordHistoryEntry{
+			ID:           uuid.New(),
+			UserID:       user.ID,
+			PasswordHash: user.PasswordHash,
+			CreatedAt:    now,
+		}); err != nil {
+			return err
+		}
+		if err := s.historyRepo.DeleteAllButRecent(ctx, user.ID, maxPasswordHistory); err != nil {
+			s.logger.Warn("Failed to prune password history", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hash, now, mustChange); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	user.PasswordHash = hash
+	user.PasswordChangedAt = now
+	user.MustChangePassword = mustChange
+	return nil
+}
+
+// SetPermissions refuses admin password resets of accounts holding
+// permissions the acting admin does not. Without it, any admin with
+// users.write may reset any password.
+func (s *PasswordPolicyService) SetPermissions(permissions *PermissionService) {
+	s.permissions = permissions
+}
+
+// AdminResetPassword sets a temporary password chosen by an admin. The user
+// must change it at their next sign-in.
+func (s *PasswordPolicyService) AdminResetPassword(ctx context.Context, adminID, userID uuid.UUID, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if s.permissions != nil {
+		if err := s.permissions.CheckCanManage(ctx, adminID, user.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.SetPassword(ctx, user, password, true); err != nil {
+		return err
+	}
+	s.logService.Record(ctx, &adminID, "admin", "user_password_reset", strPtr("user"), strPtr(userID.String()), nil)
+	return nil
+}
+
+// ChangeRequired reports whether user must choose a new password before
+// doing anything else: because an admin reset it, or because it is older than
+// password_max_age_days. Accounts without a password never expire.
+func (s *PasswordPolicyService) ChangeRequired(ctx context.Context, user *models.User) bool {
+	if user.MustChangePassword {
+		return true
+	}
+	if user.PasswordHash == "" {
+		return false
+	}
+	maxAgeDays := s.settings.Int(ctx, models.SettingPasswordMaxAgeDays)
+	if maxAgeDays <= 0 {
+		return false
+	}
+	return time.Since(user.PasswordChangedAt) > time.Duration(maxAgeDays)*24*time.Hour
+}
+
+// PasswordChangeRequired is ChangeRequired for a user ID. It satisfies
+// middleware.PasswordChangeChecker; a user that cannot be loaded is not held
+// back here, as AuthMiddleware has already accepted the request.
+func (s *PasswordPolicyService) PasswordChangeRequired(ctx context.Context, userID uuid.UUID) bool {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false
+	}
+	return s.ChangeRequired(ctx, user)
+}
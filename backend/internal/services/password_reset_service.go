@@ -13,24 +13,26 @@ import (
 
 	"base-app-service/internal/models"
 	"base-app-service/internal/repositories"
-	"base-app-service/pkg/auth"
 )
 
 type PasswordResetService struct {
-	userRepo           repositories.UserRepository
-	passwordResetRepo  repositories.PasswordResetRepository
-	logger             *zap.Logger
-	tokenExpiry        time.Duration
+	userRepo          repositories.UserRepository
+	passwordResetRepo repositories.PasswordResetRepository
+	passwords         *PasswordPolicyService
+	logger            *zap.Logger
+	tokenExpiry       time.Duration
 }
 
 func NewPasswordResetService(
 	userRepo repositories.UserRepository,
 	passwordResetRepo repositories.PasswordResetRepository,
+	passwords *PasswordPolicyService,
 	logger *zap.Logger,
 ) *PasswordResetService {
 	return &PasswordResetService{
 		userRepo:          userRepo,
 		passwordResetRepo: passwordResetRepo,
+		passwords:         passwords,
 		logger:            logger,
 		tokenExpiry:       1 * time.Hour, // 1 hour expiry
 	}
@@ -77,12 +79,6 @@ func (s *PasswordResetService) RequestPasswordReset(ctx context.Context, email s
 
 // ResetPassword validates token and resets password
 func (s *PasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
-	// Validate password strength using enhanced validation
-	validation := auth.ValidatePassword(newPassword)
-	if !validation.Valid {
-		return fmt.Errorf("password validation failed: %s", validation.Errors[0])
-	}
-
 	// Get reset token
 	resetToken, err := s.passwordResetRepo.GetByToken(ctx, token)
 	if err != nil {
@@ -99,17 +95,9 @@ func (s *PasswordResetService) ResetPassword(ctx context.Context, token, newPass
 		return errors.New("user not found")
 	}
 
-	// Hash new password
-	passwordHash, err := auth.HashPassword(newPassword)
-	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
-	}
-
-	// Update user password
-	user.PasswordHash = passwordHash
-	user.PasswordChangedAt = time.Now()
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
+	// Validate against the password policy and store the new password
+	if err := s.passwords.SetPassword(ctx, user, newPassword, false); err != nil {
+		return err
 	}
 
 	// Mark token as used
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+type PermissionService struct {
+	roleRepo       repositories.RoleRepository
+	permissionRepo repositories.PermissionRepository
+	userRepo       repositories.UserRepository
+	logService     *ActivityLogService
+	logger         *zap.Logger
+}
+
+func NewPermissionService(
+	roleRepo repositories.RoleRepository,
+	permissionRepo repositories.PermissionRepository,
+	userRepo repositories.UserRepository,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *PermissionService {
+	return &PermissionService{
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+		userRepo:       userRepo,
+		logService:     logService,
+		logger:         logger,
+	}
+}
+
+// EffectivePermissions returns the union of the user's role bundle and any
+// permissions granted to the user directly. The role is read from the user
+// record rather than the token so role changes apply immediately.
+func (s *PermissionService) EffectivePermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	set := make(map[string]bool)
+	role, err := s.roleRepo.GetByName(ctx, strings.ToLower(user.Role))
+	if err != nil {
+		return nil, err
+	}
+	if role != nil {
+		for _, p := range role.Permissions {
+			if p == models.PermissionWildcard {
+				for _, all := range models.AllPermissions {
+					set[all] = true
+				}
+				continue
+			}
+			set[p] = true
+		}
+	}
+
+	grants, err := s.permissionRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range grants {
+		set[g.PermissionName] = true
+	}
+
+	permissions := make([]string, 0, len(set))
+	for p := range set {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+type UserPermissions struct {
+	Role      string                    `json:"role"`
+	Grants    []*models.AdminPermission `json:"grants"`
+	Effective []string                  `json:"effective"`
+}
+
+// GetUserPermissions describes where a user's permissions come from.
+func (s *PermissionService) GetUserPermissions(ctx context.Context, userID uuid.UUID) (*UserPermissions, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	grants, err := s.permissionRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	effective, err := s.EffectivePermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &UserPermissions{Role: user.Role, Grants: grants, Effective: effective}, nil
+}
+
+// GrantPermission gives a user a permission outside of their role. Only
+// permissions the actor holds can be granted.
+func (s *PermissionService) GrantPermission(ctx context.Context, actorID, userID uuid.UUID, permission string) error {
+	if !models.IsKnownPermission(permission) {
+		return fmt.Errorf("unknown permission: %s", permission)
+	}
+	if err := s.checkHeld(ctx, actorID, []string{permission}); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.permissionRepo.Grant(ctx, &models.AdminPermission{
+		ID:             uuid.New(),
+		AdminID:        userID,
+		PermissionName: permission,
+		GrantedAt:      time.Now(),
+		GrantedBy:      &actorID,
+	}); err != nil {
+		return err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "permission_granted", strPtr("user"), strPtr(userID.String()), map[string]interface{}{
+		"permission": permission,
+	})
+	return nil
+}
+
+// RevokePermission removes a directly granted permission. Permissions that
+// come from the user's role are unaffected.
+func (s *PermissionService) RevokePermission(ctx context.Context, actorID, userID uuid.UUID, permission string) error {
+	if actorID == userID && permission == models.PermRolesManage {
+		return errors.New("cannot revoke your own roles.manage permission")
+	}
+	if err := s.permissionRepo.Revoke(ctx, userID, permission); err != nil {
+		return err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "permission_revoked", strPtr("user"), strPtr(userID.String()), map[string]interface{}{
+		"permission": permission,
+	})
+	return nil
+}
+
+// AssignRole changes a user's role. Admins cannot change their own role to
+// avoid locking themselves out, and can only move users out of and into roles
+// whose permissions they hold.
+func (s *PermissionService) AssignRole(ctx context.Context, actorID, userID uuid.UUID, roleName string) error {
+	roleName = strings.ToLower(strings.TrimSpace(roleName))
+	if actorID == userID {
+		return errors.New("cannot change your own role")
+	}
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	current, err := s.roleRepo.GetByName(ctx, strings.ToLower(user.Role))
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		if err := s.checkHeld(ctx, actorID, current.Permissions); err != nil {
+			return err
+		}
+	}
+	if err := s.checkHeld(ctx, actorID, role.Permissions); err != nil {
+		return err
+	}
+
+	previous := user.Role
+	user.Role = role.Name
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "role_assigned", strPtr("user"), strPtr(userID.String()), map[string]interface{}{
+		"from": previous,
+		"to":   role.Name,
+	})
+	return nil
+}
+
+func (s *PermissionService) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+type RoleRequest struct {
+	Name        string
+	Description *string
+	Permissions []string
+}
+
+func (s *PermissionService) CreateRole(ctx context.Context, actorID uuid.UUID, req RoleRequest) (*models.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("role name must be 2-32 lowercase letters, digits, '-' or '_'")
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkHeld(ctx, actorID, permissions); err != nil {
+		return nil, err
+	}
+	existing, err := s.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("role already exists")
+	}
+
+	now := time.Now()
+	role := &models.Role{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: req.Description,
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "role_created", strPtr("role"), strPtr(role.Name), map[string]interface{}{
+		"permissions": role.Permissions,
+	})
+	return role, nil
+}
+
+// UpdateRole changes a custom role's permissions and description. Admins
+// cannot change the role they hold, and can only give a role permissions they
+// hold.
+func (s *PermissionService) UpdateRole(ctx context.Context, actorID uuid.UUID, req RoleRequest) (*models.Role, error) {
+	role, err := s.roleRepo.GetByName(ctx, strings.ToLower(req.Name))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("role not found")
+	}
+	if role.Name == "admin" {
+		return nil, errors.New("the admin role cannot be modified")
+	}
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil || actor == nil {
+		return nil, errors.New("user not found")
+	}
+	if strings.EqualFold(actor.Role, role.Name) {
+		return nil, errors.New("cannot change your own role")
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkHeld(ctx, actorID, permissions); err != nil {
+		return nil, err
+	}
+
+	role.Permissions = permissions
+	if req.Description != nil {
+		role.Description = req.Description
+	}
+	role.UpdatedAt = time.Now()
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "role_updated", strPtr("role"), strPtr(role.Name), map[string]interface{}{
+		"permissions": role.Permissions,
+	})
+	return role, nil
+}
+
+// DeleteRole removes a custom role. Roles still assigned to users cannot be
+// deleted.
+func (s *PermissionService) DeleteRole(ctx context.Context, actorID uuid.UUID, name string) error {
+	role, err := s.roleRepo.GetByName(ctx, strings.ToLower(name))
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+	if role.IsSystem {
+		return errors.New("system roles cannot be deleted")
+	}
+
+	users, err := s.userRepo.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Role, role.Name) {
+			return errors.New("role is still assigned to users")
+		}
+	}
+
+	if err := s.roleRepo.Delete(ctx, role.Name); err != nil {
+		return err
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "role_deleted", strPtr("role"), strPtr(role.Name), nil)
+	return nil
+}
+
+// checkHeld refuses permissions the actor does not hold, so nobody can give
+// others, or through a role themselves, more than they have.
+func (s *PermissionService) checkHeld(ctx context.Context, actorID uuid.UUID, permissions []string) error {
+	held, err := s.EffectivePermissions(ctx, actorID)
+	if err != nil {
+		return err
+	}
+	has := make(map[string]bool, len(held))
+	for _, p := range held {
+		has[p] = true
+	}
+	for _, p := range permissions {
+		if p == models.PermissionWildcard {
+			if len(held) < len(models.AllPermissions) {
+				return errors.New("cannot grant permissions you do not hold")
+			}
+			continue
+		}
+		if !has[p] {
+			return fmt.Errorf("cannot grant permissions you do not hold: %s", p)
+		}
+	}
+	return nil
+}
+
+// CheckCanManage refuses when userID holds a permission actorID does not, so
+// an admin with limited permissions cannot take over or remove a more
+// privileged account.
+func (s *PermissionService) CheckCanManage(ctx context.Context, actorID, userID uuid.UUID) error {
+	held, err := s.EffectivePermissions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkHeld(ctx, actorID, held); err != nil {
+		if strings.HasPrefix(err.Error(), "cannot grant") {
+			return errors.New("cannot manage an account with permissions you do not hold")
+		}
+		return err
+	}
+	return nil
+}
+
+func normalizePermissions(permissions []string) ([]string, error) {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		p = strings.TrimSpace(p)
+		if !models.IsKnownPermission(p) {
+			return nil, fmt.Errorf("unknown permission: %s", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
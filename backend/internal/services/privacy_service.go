@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+)
+
+// PrivacyService applies users' privacy settings to what other users see of
+// them and whether they may message them. Users always see all of their own
+// profile, friends-only settings admit accepted friends, and users who
+// blocked each other do not see each other at all.
+type PrivacyService struct {
+	settingsRepo   repositories.SettingsRepository
+	userRepo       repositories.UserRepository
+	connectionRepo repositories.ConnectionRepository
+	logger         *zap.Logger
+}
+
+// relationship is how a viewer stands to the owner of a profile.
+type relationship struct {
+	self    bool
+	friend  bool
+	blocked bool
+}
+
+func NewPrivacyService(
+	settingsRepo repositories.SettingsRepository,
+	userRepo repositories.UserRepository,
+	connectionRepo repositories.ConnectionRepository,
+	logger *zap.Logger,
+) *PrivacyService {
+	return &PrivacyService{
+		settingsRepo:   settingsRepo,
+		userRepo:       userRepo,
+		connectionRepo: connectionRepo,
+		logger:         logger,
+	}
+}
+
+// Profile returns what viewerID may see of userID's profile.
+func (s *PrivacyService) Profile(ctx context.Context, viewerID, userID uuid.UUID) (*models.PublicProfile, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil || !visibleStatus(user.Status) {
+		return nil, errors.New("user not found")
+	}
+	settings, err := s.settings(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := s.relationship(ctx, viewerID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rel.blocked || (settings.AccountDeactivated && !rel.self) {
+		return nil, errors.New("user not found")
+	}
+	return profileFor(rel, user, settings), nil
+}
+
+// Profiles redacts users, such as search results, for viewerID.
+func (s *PrivacyService) Profiles(ctx context.Context, viewerID uuid.UUID, users []*models.User) ([]*models.PublicProfile, error) {
+	profiles := make([]*models.PublicProfile, 0, len(users))
+	for _, user := range users {
+		settings, err := s.settings(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := s.relationship(ctx, viewerID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if rel.blocked {
+			continue
+		}
+		profiles = append(profiles, profileFor(rel, user, settings))
+	}
+	return profiles, nil
+}
+
+// CanMessage returns an error when recipientID does not accept messages from
+// senderID.
+func (s *PrivacyService) CanMessage(ctx context.Context, senderID, recipientID uuid.UUID) error {
+	recipient, err := s.userRepo.GetByID(ctx, recipientID)
+	if err != nil || recipient == nil || !visibleStatus(recipient.Status) {
+		return errors.New("recipient not found")
+	}
+	settings, err := s.settings(ctx, recipient.ID)
+	if err != nil {
+		return err
+	}
+	if settings.AccountDeactivated {
+		return errors.New("recipient not found")
+	}
+	rel, err := s.relationship(ctx, senderID, recipient.ID)
+	if err != nil {
+		return err
+	}
+	if rel.blocked || !acceptsMessages(settings, rel) {
+		return errors.New("recipient does not accept messages")
+	}
+	return nil
+}
+
+func (s *PrivacyService) relationship(ctx context.Context, viewerID, ownerID uuid.UUID) (relationship, error) {
+	if viewerID == ownerID {
+		return relationship{self: true}, nil
+	}
+	blocked, err := s.connectionRepo.IsBlocked(ctx, viewerID, ownerID)
+	if err != nil {
+		return relationship{}, err
+	}
+	friend, err := s.connectionRepo.AreFriends(ctx, viewerID, ownerID)
+	if err != nil {
+		return relationship{}, err
+	}
+	return relationship{friend: friend, blocked: blocked}, nil
+}
+
+func profileFor(rel relationship, user *models.User, settings *models.ComprehensiveSettings) *models.PublicProfile {
+	profile := &models.PublicProfile{
+		ID:         user.ID,
+		Name:       user.Name,
+		IsFriend:   rel.friend,
+		CanMessage: acceptsMessages(settings, rel),
+	}
+	if !visibleTo(settings.ProfileVisibility, rel) {
+		profile.Restricted = true
+		return profile
+	}
+
+	profile.Username = settings.Username
+	profile.DisplayName = settings.DisplayName
+	profile.Bio = settings.Bio
+	profile.FirstName = user.FirstName
+	profile.LastName = user.LastName
+	profile.PhotoURL = user.PhotoURL
+	if visibleTo(settings.EmailVisibility, rel) {
+		profile.Email = &user.Email
+	}
+	if visibleTo(settings.PhoneVisibility, rel) && user.PhoneVerified {
+		profile.Phone = user.Phone
+	}
+	return profile
+}
+
+// settings returns the user's settings without creating them, falling back
+// to the defaults.
+func (s *PrivacyService) settings(ctx context.Context, userID uuid.UUID) (*models.ComprehensiveSettings, error) {
+	settings, err := s.settingsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = defaultSettings(userID)
+	}
+	return settings, nil
+}
+
+func visibleTo(visibility string, rel relationship) bool {
+	switch visibility {
+	case models.VisibilityPublic:
+		return true
+	case models.VisibilityFriends:
+		return rel.self || rel.friend
+	default:
+		return rel.self
+	}
+}
+
+func acceptsMessages(settings *models.ComprehensiveSettings, rel relationship) bool {
+	switch settings.AllowMessaging {
+	case models.MessagingEveryone:
+		return !rel.self
+	case models.MessagingFriends:
+		return rel.friend
+	default:
+		return false
+	}
+}
+
+func visibleStatus(status string) bool {
+	return status == "active" || status == "pending"
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/monitoring"
+	"base-app-service/internal/repositories"
+)
+
+// defaultRetentionRules apply to data classes admins have not set a rule for.
+// Credentials are cleaned up out of the box; records people may want to look
+// back on are kept until an admin decides otherwise.
+var defaultRetentionRules = map[string]models.RetentionRule{
+	models.RetentionSessions:            {Enabled: true, MaxAgeDays: intPtr(30)},
+	models.RetentionPasswordResetTokens: {Enabled: true, MaxAgeDays: intPtr(7)},
+	models.RetentionWebhookEvents:       {Enabled: true, MaxAgeDays: intPtr(30)},
+	models.RetentionSearchHistory:       {},
+	models.RetentionNotifications:       {},
+	models.RetentionActivityLogs:        {},
+}
+
+// RetentionService applies the retention rules admins set per data class. The
+// purger deletes matching rows in batches, optionally archiving them first as
+// gzipped JSON Lines, and records the runs that purged anything. Preview
+// reports what the rules would purge without deleting anything.
+type RetentionService struct {
+	cfg           config.RetentionConfig
+	retentionRepo repositories.RetentionRepository
+	metrics       *monitoring.Metrics
+	logService    *ActivityLogService
+	logger        *zap.Logger
+	running       sync.Mutex
+}
+
+func NewRetentionService(
+	cfg config.RetentionConfig,
+	retentionRepo repositories.RetentionRepository,
+	metrics *monitoring.Metrics,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *RetentionService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.ArchiveDir == "" {
+		cfg.ArchiveDir = "archives"
+	}
+	return &RetentionService{
+		cfg:           cfg,
+		retentionRepo: retentionRepo,
+		metrics:       metrics,
+		logService:    logService,
+		logger:        logger,
+	}
+}
+
+// Rules returns the rule in effect for every data class, in purge order.
+func (s *RetentionService) Rules(ctx context.Context) ([]*models.RetentionRule, error) {
+	stored, err := s.retentionRepo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byClass := make(map[string]*models.RetentionRule, len(stored))
+	for _, rule := range stored {
+		byClass[rule.DataClass] = rule
+	}
+
+	rules := make([]*models.RetentionRule, 0, len(models.RetentionDataClasses))
+	for _, dataClass := range models.RetentionDataClasses {
+		rule, ok := byClass[dataClass]
+		if !ok {
+			def := defaultRetentionRules[dataClass]
+			def.DataClass = dataClass
+			rule = &def
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// UpdateRule sets the rule for a data class. An enabled rule needs a maximum
+// age, a maximum row count or both.
+func (s *RetentionService) UpdateRule(ctx context.Context, adminID uuid.UUID, dataClass string, enabled bool, maxAgeDays, maxRows *int, archive bool) (*models.RetentionRule, error) {
+	if _, ok := defaultRetentionRules[dataClass]; !ok {
+		return nil, errors.New("unknown data class")
+	}
+	if maxAgeDays != nil && (*maxAgeDays < 1 || *maxAgeDays > 36500) {
+		return nil, errors.New("max_age_days must be between 1 and 36500")
+	}
+	if maxRows != nil && *maxRows < 1 {
+		return nil, errors.New("max_rows must be at least 1")
+	}
+	if enabled && maxAgeDays == nil && maxRows == nil {
+		return nil, errors.New("an enabled rule needs max_age_days or max_rows")
+	}
+
+	now := time.Now()
+	rule := &models.RetentionRule{
+		DataClass:  dataClass,
+		Enabled:    enabled,
+		MaxAgeDays: maxAgeDays,
+		MaxRows:    maxRows,
+		Archive:    archive,
+		UpdatedBy:  &adminID,
+		UpdatedAt:  &now,
+	}
+	if err := s.retentionRepo.SaveRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to save retention rule: %w", err)
+	}
+
+	s.logService.Record(ctx, &adminID, "admin", "retention_rule_updated", strPtr("retention_rule"), strPtr(dataClass), map[string]interface{}{
+		"enabled":      enabled,
+		"max_age_days": maxAgeDays,
+		"max_rows":     maxRows,
+		"archive":      archive,
+	})
+	return rule, nil
+}
+
+// Preview reports, for every data class, how many rows its rule would purge
+// if the purger ran now. Disabled rules are reported too, so admins can see
+// the effect of a rule before enabling it.
+func (s *RetentionService) Preview(ctx context.Context) ([]*models.RetentionReport, error) {
+	rules, err := s.Rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	reports := make([]*models.RetentionReport, 0, len(rules))
+	for _, rule := range rules {
+		total, matched, err := s.retentionRepo.Count(ctx, rule, now)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, &models.RetentionReport{
+			DataClass:   rule.DataClass,
+			Enabled:     rule.Enabled,
+			RowsTotal:   total,
+			RowsMatched: matched,
+		})
+	}
+	return reports, nil
+}
+
+// Run applies every enabled rule and returns a run per data class. Runs that
+// purged rows or failed are recorded. Only one run happens at a time.
+func (s *RetentionService) Run(ctx context.Context) ([]*models.RetentionRun, error) {
+	if !s.running.TryLock() {
+		return nil, errors.New("a purge is already running")
+	}
+	defer s.running.Unlock()
+
+	rules, err := s.Rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var runs []*models.RetentionRun
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		run := s.purge(ctx, rule)
+		runs = append(runs, run)
+		if run.RowsPurged == 0 && run.Error == nil {
+			continue
+		}
+		if err := s.retentionRepo.CreateRun(ctx, run); err != nil {
+			s.logger.Error("Failed to record retention run", zap.String("data_class", rule.DataClass), zap.Error(err))
+		}
+	}
+	return runs, nil
+}
+
+// Runs returns the latest purger runs, newest first.
+func (s *RetentionService) Runs(ctx context.Context, limit int) ([]*models.RetentionRun, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.retentionRepo.ListRuns(ctx, limit)
+}
+
+// purge deletes the rows rule matches, a batch at a time. Rows are aged
+// against the time the run started, so rows that age past the limit during
+// the run wait for the next one.
+func (s *RetentionService) purge(ctx context.Context, rule *models.RetentionRule) *models.RetentionRun {
+	now := time.Now()
+	run := &models.RetentionRun{ID: uuid.New(), DataClass: rule.DataClass, StartedAt: now}
+	fail := func(err error) *models.RetentionRun {
+		s.logger.Error("Retention purge failed", zap.String("data_class", rule.DataClass), zap.Error(err))
+		run.Error = strPtr(err.Error())
+		return run
+	}
+	defer func() {
+		finished := time.Now()
+		run.FinishedAt = &finished
+		if run.RowsPurged > 0 && s.metrics != nil {
+			s.metrics.RecordPurge(rule.DataClass, run.RowsPurged)
+		}
+	}()
+
+	var archivePath string
+	if rule.Archive {
+		archivePath = filepath.Join(s.cfg.ArchiveDir, fmt.Sprintf("%s-%s.jsonl.gz", rule.DataClass, now.UTC().Format("20060102T150405Z")))
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return fail(ctx.Err())
+		}
+		rows, err := s.retentionRepo.NextBatch(ctx, rule, now, s.cfg.BatchSize)
+		if err != nil {
+			return fail(err)
+		}
+		if len(rows) == 0 {
+			return run
+		}
+
+		// Rows reach the archive before they leave the database
+		if archivePath != "" {
+			if err := appendArchive(archivePath, rows); err != nil {
+				return fail(fmt.Errorf("failed to archive rows: %w", err))
+			}
+			run.ArchivePath = &archivePath
+		}
+		ids := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row["id"])
+		}
+		deleted, err := s.retentionRepo.DeleteRows(ctx, rule.DataClass, ids)
+		if err != nil {
+			return fail(err)
+		}
+		run.RowsPurged += deleted
+		run.Batches++
+
+		if len(rows) < s.cfg.BatchSize {
+			return run
+		}
+		if s.cfg.BatchPause > 0 {
+			select {
+			case <-ctx.Done():
+				return fail(ctx.Err())
+			case <-time.After(s.cfg.BatchPause):
+			}
+		}
+	}
+}
+
+// appendArchive appends rows to the archive at path as a gzip member of JSON
+// Lines and syncs it to disk. gzip readers read the members of a file one
+// after another, so a run that stops partway leaves a readable archive of
+// every batch it deleted.
+func appendArchive(path string, rows []map[string]interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,1149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/auth"
+)
+
+// SCIM resource and message schemas.
+const (
+	SCIMSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// SCIMTokenPrefix starts every SCIM bearer token.
+const SCIMTokenPrefix = "scim_"
+
+const (
+	scimDefaultCount = 100
+	scimMaxCount     = 200
+)
+
+var (
+	scimFilterPattern       = regexp.MustCompile(`^\s*([A-Za-z][\w.:]*)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+	scimMemberFilterPattern = regexp.MustCompile(`(?i)^members\[(.+)\]$`)
+)
+
+// SCIMService implements SCIM 2.0 provisioning for identity providers.
+// Users map onto accounts: userName is the email address, active is the
+// account status and roles is the account's role. Groups map onto roles, so a
+// user is a member of exactly one group; adding them to another group moves
+// them, and removing them puts them back in the user role. Roles that carry
+// permissions are only given from the dashboard, and the password and email
+// of accounts holding permissions cannot be changed, so a SCIM token cannot
+// take over admin access.
+type SCIMService struct {
+	cfg            config.SCIMConfig
+	tokenRepo      repositories.SCIMTokenRepository
+	userRepo       repositories.UserRepository
+	roleRepo       repositories.RoleRepository
+	permissionRepo repositories.PermissionRepository
+	sessionRepo    repositories.SessionRepository
+	passwords      *PasswordPolicyService
+	deletions      *AccountDeletionService
+	logService     *ActivityLogService
+	logger         *zap.Logger
+}
+
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMValue is an entry of a multi-valued attribute such as emails or members.
+type SCIMValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+// SCIMUser is the SCIM representation of an account. Password is accepted on
+// writes and never returned.
+type SCIMUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *SCIMName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []SCIMValue `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Password    string      `json:"password,omitempty"`
+	Roles       []SCIMValue `json:"roles,omitempty"`
+	Meta        *SCIMMeta   `json:"meta,omitempty"`
+}
+
+// SCIMGroup is the SCIM representation of a role.
+type SCIMGroup struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []SCIMValue `json:"members,omitempty"`
+	Meta        *SCIMMeta   `json:"meta,omitempty"`
+}
+
+type SCIMListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+func NewSCIMService(
+	cfg config.SCIMConfig,
+	tokenRepo repositories.SCIMTokenRepository,
+	userRepo repositories.UserRepository,
+	roleRepo repositories.RoleRepository,
+	permissionRepo repositories.PermissionRepository,
+	sessionRepo repositories.SessionRepository,
+	passwords *PasswordPolicyService,
+	deletions *AccountDeletionService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *SCIMService {
+	return &SCIMService{
+		cfg:            cfg,
+		tokenRepo:      tokenRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+		sessionRepo:    sessionRepo,
+		passwords:      passwords,
+		deletions:      deletions,
+		logService:     logService,
+		logger:         logger,
+	}
+}
+
+// IssueToken creates a bearer token for an identity provider and returns it
+// with the plaintext, which is not stored and cannot be retrieved again.
+func (s *SCIMService) IssueToken(ctx context.Context, actorID uuid.UUID, name string) (*models.SCIMToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("name is required")
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := SCIMTokenPrefix + secret
+
+	token := &models.SCIMToken{
+		ID:          uuid.New(),
+		Name:        name,
+		TokenPrefix: plaintext[:len(SCIMTokenPrefix)+8],
+		TokenHash:   hashToken(plaintext),
+		CreatedBy:   &actorID,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("failed to create scim token: %w", err)
+	}
+
+	s.logService.Record(ctx, &actorID, "admin", "scim_token_created", strPtr("scim_token"), strPtr(token.ID.String()), map[string]interface{}{
+		"name": token.Name,
+	})
+	return token, plaintext, nil
+}
+
+// ListTokens returns every SCIM token, including revoked ones.
+func (s *SCIMService) ListTokens(ctx context.Context) ([]*models.SCIMToken, error) {
+	return s.tokenRepo.List(ctx)
+}
+
+func (s *SCIMService) RevokeToken(ctx context.Context, actorID, id uuid.UUID) error {
+	revoked, err := s.tokenRepo.Revoke(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("scim token not found")
+	}
+	s.logService.Record(ctx, &actorID, "admin", "scim_token_revoked", strPtr("scim_token"), strPtr(id.String()), nil)
+	return nil
+}
+
+// AuthenticateSCIMToken resolves a bearer token to its unrevoked record.
+func (s *SCIMService) AuthenticateSCIMToken(ctx context.Context, plaintext string) (*models.SCIMToken, error) {
+	if !strings.HasPrefix(plaintext, SCIMTokenPrefix) {
+		return nil, errors.New("invalid scim token")
+	}
+	token, err := s.tokenRepo.GetByHash(ctx, hashToken(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.RevokedAt != nil {
+		return nil, errors.New("invalid scim token")
+	}
+	if err := s.tokenRepo.UpdateLastUsed(ctx, token.ID, time.Now()); err != nil {
+		s.logger.Warn("Failed to update scim token last used", zap.Error(err))
+	}
+	return token, nil
+}
+
+// ListUsers pages through accounts. Filters support userName, emails.value
+// and id with the eq operator, which is what identity providers send to
+// match existing accounts.
+func (s *SCIMService) ListUsers(ctx context.Context, filter string, startIndex, count int) (*SCIMListResponse, error) {
+	startIndex, count = scimPage(startIndex, count)
+
+	if strings.TrimSpace(filter) != "" {
+		attr, value, err := parseSCIMFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		var user *models.User
+		switch attr {
+		case "username", "emails", "emails.value":
+			user, _ = s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(value)))
+		case "id":
+			user, _ = s.findUser(ctx, value)
+		default:
+			return nil, errors.New("invalid filter: unsupported attribute " + attr)
+		}
+		var resources []interface{}
+		if user != nil && user.Status != "deleted" {
+			resources = append(resources, s.userResource(user))
+		}
+		return scimListPage(resources, startIndex, count), nil
+	}
+
+	users, total, err := s.userRepo.ListPage(ctx, startIndex-1, count)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, s.userResource(user))
+	}
+	return &SCIMListResponse{
+		Schemas:      []string{SCIMSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+func (s *SCIMService) GetUser(ctx context.Context, id string) (*SCIMUser, error) {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userResource(user), nil
+}
+
+// CreateUser provisions an account with a verified email. Without a password
+// the account signs in through single sign-on or a password reset.
+func (s *SCIMService) CreateUser(ctx context.Context, tokenID uuid.UUID, in SCIMUser) (*SCIMUser, error) {
+	email, err := scimEmail(in.UserName)
+	if err != nil {
+		return nil, err
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, email); existing != nil {
+		return nil, errors.New("user already exists")
+	}
+	role := "user"
+	if name := scimRole(in.Roles); name != "" {
+		if role, err = s.assignableRole(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	password := in.Password
+	if password != "" {
+		if err := s.passwords.Validate(ctx, nil, password); err != nil {
+			return nil, err
+		}
+	} else if password, err = randomToken(); err != nil {
+		return nil, err
+	}
+	passwordHash, err := auth.GenerateHash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	source := models.SignupSourceSCIM
+	status := "active"
+	if in.Active != nil && !*in.Active {
+		status = "disabled"
+	}
+	user := &models.User{
+		ID:                uuid.New(),
+		Email:             email,
+		PasswordHash:      passwordHash,
+		Status:            status,
+		Role:              role,
+		SignupSource:      &source,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	applySCIMNames(user, in)
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := s.userRepo.UpdateEmail(ctx, user.ID, user.Email, true); err != nil {
+		s.logger.Warn("Failed to mark provisioned email verified", zap.String("user_id", user.ID.String()), zap.Error(err))
+	} else {
+		user.EmailVerified = true
+	}
+
+	s.record(ctx, tokenID, "scim_user_provisioned", "user", user.ID.String(), map[string]interface{}{
+		"email": user.Email,
+		"role":  user.Role,
+	})
+	return s.userResource(user), nil
+}
+
+// ReplaceUser overwrites the account's attributes with in. Attributes the
+// account does not keep are ignored, and an absent active leaves the status
+// as it is.
+func (s *SCIMService) ReplaceUser(ctx context.Context, tokenID uuid.UUID, id string, in SCIMUser) (*SCIMUser, error) {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, tokenID, user, in)
+}
+
+// PatchUser applies SCIM patch operations to the account.
+func (s *SCIMService) PatchUser(ctx context.Context, tokenID uuid.UUID, id string, operations []SCIMPatchOperation) (*SCIMUser, error) {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patched := *s.userResource(user)
+	for _, operation := range operations {
+		if err := patchSCIMUser(&patched, operation); err != nil {
+			return nil, err
+		}
+	}
+	return s.updateUser(ctx, tokenID, user, patched)
+}
+
+// DeleteUser deprovisions the account: it is marked deleted, signed out
+// everywhere and purged after the usual grace period. Signing in does not
+// cancel it.
+func (s *SCIMService) DeleteUser(ctx context.Context, tokenID uuid.UUID, id string) error {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.guardLastAdmin(ctx, user); err != nil {
+		return err
+	}
+	if _, err := s.deletions.Request(ctx, user.ID, models.AccountDeletionBySCIM, nil); err != nil {
+		return err
+	}
+
+	s.record(ctx, tokenID, "scim_user_deleted", "user", user.ID.String(), map[string]interface{}{
+		"email": user.Email,
+	})
+	return nil
+}
+
+// ListGroups pages through roles. Filters support displayName and id with
+// the eq operator. withMembers false leaves out the member lists.
+func (s *SCIMService) ListGroups(ctx context.Context, filter string, startIndex, count int, withMembers bool) (*SCIMListResponse, error) {
+	startIndex, count = scimPage(startIndex, count)
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(filter) != "" {
+		attr, value, err := parseSCIMFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		var matched []*models.Role
+		for _, role := range roles {
+			switch attr {
+			case "displayname":
+				if strings.EqualFold(role.Name, strings.TrimSpace(value)) {
+					matched = append(matched, role)
+				}
+			case "id":
+				if role.ID.String() == value {
+					matched = append(matched, role)
+				}
+			default:
+				return nil, errors.New("invalid filter: unsupported attribute " + attr)
+			}
+		}
+		roles = matched
+	}
+
+	resources := make([]interface{}, 0, len(roles))
+	for _, role := range roles {
+		group, err := s.groupResource(ctx, role, withMembers)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, group)
+	}
+	return scimListPage(resources, startIndex, count), nil
+}
+
+func (s *SCIMService) GetGroup(ctx context.Context, id string, withMembers bool) (*SCIMGroup, error) {
+	role, err := s.findRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.groupResource(ctx, role, withMembers)
+}
+
+// CreateGroup adds a role without permissions; admins grant them from the
+// dashboard. Group names must be valid role names.
+func (s *SCIMService) CreateGroup(ctx context.Context, tokenID uuid.UUID, in SCIMGroup) (*SCIMGroup, error) {
+	name := strings.ToLower(strings.TrimSpace(in.DisplayName))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("invalid value: group displayName must be 2-32 lowercase letters, digits, '-' or '_'")
+	}
+	existing, err := s.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("group already exists")
+	}
+
+	now := time.Now()
+	role := &models.Role{
+		ID:          uuid.New(),
+		Name:        name,
+		Permissions: []string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+	if err := s.addMembers(ctx, role, scimValues(in.Members)); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, tokenID, "scim_group_created", "role", role.Name, map[string]interface{}{
+		"members": len(in.Members),
+	})
+	return s.groupResource(ctx, role, true)
+}
+
+// ReplaceGroup sets the group's members to in.Members. Groups cannot be
+// renamed, as the name is the role users hold.
+func (s *SCIMService) ReplaceGroup(ctx context.Context, tokenID uuid.UUID, id string, in SCIMGroup) (*SCIMGroup, error) {
+	role, err := s.findRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSCIMGroupName(role, in.DisplayName); err != nil {
+		return nil, err
+	}
+	if err := s.replaceMembers(ctx, role, scimValues(in.Members)); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, tokenID, "scim_group_updated", "role", role.Name, map[string]interface{}{
+		"members": len(in.Members),
+	})
+	return s.groupResource(ctx, role, true)
+}
+
+// PatchGroup applies member additions and removals.
+func (s *SCIMService) PatchGroup(ctx context.Context, tokenID uuid.UUID, id string, operations []SCIMPatchOperation) (*SCIMGroup, error) {
+	role, err := s.findRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, operation := range operations {
+		if err := s.patchGroup(ctx, role, operation); err != nil {
+			return nil, err
+		}
+	}
+
+	s.record(ctx, tokenID, "scim_group_updated", "role", role.Name, map[string]interface{}{
+		"operations": len(operations),
+	})
+	return s.groupResource(ctx, role, true)
+}
+
+// DeleteGroup removes a role that is not a system role. Its members go back
+// to the user role.
+func (s *SCIMService) DeleteGroup(ctx context.Context, tokenID uuid.UUID, id string) error {
+	role, err := s.findRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("mutability: system groups cannot be deleted")
+	}
+	if err := s.replaceMembers(ctx, role, nil); err != nil {
+		return err
+	}
+	if err := s.roleRepo.Delete(ctx, role.Name); err != nil {
+		return err
+	}
+
+	s.record(ctx, tokenID, "scim_group_deleted", "role", role.Name, nil)
+	return nil
+}
+
+// ServiceProviderConfig describes the supported SCIM features.
+func (s *SCIMService) ServiceProviderConfig() map[string]interface{} {
+	unsupported := map[string]interface{}{"supported": false}
+	return map[string]interface{}{
+		"schemas":        []string{SCIMSchemaServiceProviderConfig},
+		"patch":          map[string]interface{}{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": scimMaxCount},
+		"changePassword": map[string]interface{}{"supported": true},
+		"sort":           unsupported,
+		"etag":           unsupported,
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "A SCIM token issued by an admin",
+			"primary":     true,
+		}},
+		"meta": map[string]interface{}{
+			"resourceType": "ServiceProviderConfig",
+			"location":     s.cfg.BaseURL + "/ServiceProviderConfig",
+		},
+	}
+}
+
+func (s *SCIMService) updateUser(ctx context.Context, tokenID uuid.UUID, user *models.User, in SCIMUser) (*SCIMUser, error) {
+	email, err := scimEmail(in.UserName)
+	if err != nil {
+		return nil, err
+	}
+	if email != user.Email {
+		if existing, _ := s.userRepo.GetByEmail(ctx, email); existing != nil {
+			return nil, errors.New("user already exists")
+		}
+	}
+	role := user.Role
+	if name := scimRole(in.Roles); name != "" && !strings.EqualFold(name, user.Role) {
+		if role, err = s.assignableRole(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	if in.Password != "" || email != user.Email {
+		privileged, err := s.isPrivileged(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if privileged {
+			return nil, errors.New("mutability: the password and email of accounts with admin permissions cannot be changed over SCIM")
+		}
+	}
+	deactivate := in.Active != nil && !*in.Active && user.Status != "disabled"
+	if (role != user.Role || deactivate) && user.Role == "admin" {
+		if err := s.guardLastAdmin(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.Password != "" {
+		if err := s.passwords.SetPassword(ctx, user, in.Password, false); err != nil {
+			return nil, err
+		}
+	}
+	previousRole := user.Role
+	applySCIMNames(user, in)
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	if email != user.Email {
+		if err := s.userRepo.UpdateEmail(ctx, user.ID, email, true); err != nil {
+			return nil, err
+		}
+		user.Email = email
+		user.EmailVerified = true
+	}
+
+	switch {
+	case deactivate:
+		if err := s.userRepo.SetStatus(ctx, user.ID, "disabled"); err != nil {
+			return nil, err
+		}
+		user.Status = "disabled"
+		s.revokeSessions(ctx, user)
+		s.record(ctx, tokenID, "scim_user_deprovisioned", "user", user.ID.String(), nil)
+	case in.Active != nil && *in.Active && user.Status == "disabled":
+		if err := s.userRepo.SetStatus(ctx, user.ID, "active"); err != nil {
+			return nil, err
+		}
+		user.Status = "active"
+		s.record(ctx, tokenID, "scim_user_reactivated", "user", user.ID.String(), nil)
+	}
+
+	s.record(ctx, tokenID, "scim_user_updated", "user", user.ID.String(), map[string]interface{}{
+		"from_role": previousRole,
+		"role":      user.Role,
+	})
+	return s.userResource(user), nil
+}
+
+func (s *SCIMService) patchGroup(ctx context.Context, role *models.Role, operation SCIMPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	path := strings.TrimSpace(operation.Path)
+
+	if path == "" {
+		if op == "remove" {
+			return errors.New("invalid path: remove needs a path")
+		}
+		var value struct {
+			DisplayName string      `json:"displayName"`
+			Members     []SCIMValue `json:"members"`
+		}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return errors.New("invalid value: expected an object")
+		}
+		if err := checkSCIMGroupName(role, value.DisplayName); err != nil {
+			return err
+		}
+		if value.Members == nil {
+			return nil
+		}
+		if op == "replace" {
+			return s.replaceMembers(ctx, role, scimValues(value.Members))
+		}
+		return s.addMembers(ctx, role, scimValues(value.Members))
+	}
+
+	if strings.EqualFold(path, "displayName") {
+		if op == "remove" {
+			return errors.New("mutability: group displayName cannot be removed")
+		}
+		var name string
+		if err := json.Unmarshal(operation.Value, &name); err != nil {
+			return errors.New("invalid value: displayName must be a string")
+		}
+		return checkSCIMGroupName(role, name)
+	}
+
+	if match := scimMemberFilterPattern.FindStringSubmatch(path); match != nil {
+		if op != "remove" {
+			return errors.New("invalid path: member filters are only supported for remove")
+		}
+		attr, value, err := parseSCIMFilter(match[1])
+		if err != nil || attr != "value" {
+			return errors.New("invalid path: " + path)
+		}
+		return s.removeMembers(ctx, role, []string{value})
+	}
+
+	if !strings.EqualFold(path, "members") {
+		return errors.New("invalid path: " + path)
+	}
+	var members []SCIMValue
+	if len(operation.Value) > 0 {
+		if err := json.Unmarshal(operation.Value, &members); err != nil {
+			return errors.New("invalid value: members must be a list")
+		}
+	}
+	switch op {
+	case "add":
+		return s.addMembers(ctx, role, scimValues(members))
+	case "replace":
+		return s.replaceMembers(ctx, role, scimValues(members))
+	case "remove":
+		if members == nil {
+			return s.replaceMembers(ctx, role, nil)
+		}
+		return s.removeMembers(ctx, role, scimValues(members))
+	default:
+		return errors.New("invalid patch operation: " + operation.Op)
+	}
+}
+
+// addMembers moves the users into role, which must not carry permissions.
+func (s *SCIMService) addMembers(ctx context.Context, role *models.Role, ids []string) error {
+	for _, id := range ids {
+		user, err := s.findUser(ctx, id)
+		if err != nil {
+			return errors.New("invalid value: unknown member " + id)
+		}
+		if user.Role == role.Name {
+			continue
+		}
+		if isPrivilegedRole(role) {
+			return errPrivilegedRole
+		}
+		if err := s.setRole(ctx, user, role.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeMembers puts the users of role among ids back in the user role.
+func (s *SCIMService) removeMembers(ctx context.Context, role *models.Role, ids []string) error {
+	for _, id := range ids {
+		user, err := s.findUser(ctx, id)
+		if err != nil || user.Role != role.Name {
+			continue
+		}
+		if err := s.setRole(ctx, user, "user"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SCIMService) replaceMembers(ctx context.Context, role *models.Role, ids []string) error {
+	keep := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+	members, err := s.userRepo.ListByRole(ctx, role.Name)
+	if err != nil {
+		return err
+	}
+	var leaving []string
+	for _, member := range members {
+		if !keep[member.ID.String()] {
+			leaving = append(leaving, member.ID.String())
+		}
+	}
+	if err := s.removeMembers(ctx, role, leaving); err != nil {
+		return err
+	}
+	return s.addMembers(ctx, role, ids)
+}
+
+func (s *SCIMService) setRole(ctx context.Context, user *models.User, role string) error {
+	if user.Role == "admin" && role != "admin" {
+		if err := s.guardLastAdmin(ctx, user); err != nil {
+			return err
+		}
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	return s.userRepo.Update(ctx, user)
+}
+
+// guardLastAdmin keeps provisioning from locking every admin out.
+func (s *SCIMService) guardLastAdmin(ctx context.Context, user *models.User) error {
+	if user.Role != "admin" {
+		return nil
+	}
+	admins, err := s.userRepo.ListByRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	for _, admin := range admins {
+		if admin.ID != user.ID && admin.Status == "active" {
+			return nil
+		}
+	}
+	return errors.New("mutability: the last admin cannot be removed")
+}
+
+func (s *SCIMService) revokeSessions(ctx context.Context, user *models.User) {
+	if err := s.sessionRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+		s.logger.Error("Failed to revoke sessions of deprovisioned user", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
+
+func (s *SCIMService) findUser(ctx context.Context, id string) (*models.User, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil || user.Status == "deleted" {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *SCIMService) findRole(ctx context.Context, id string) (*models.Role, error) {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		if role.ID.String() == id {
+			return role, nil
+		}
+	}
+	return nil, errors.New("group not found")
+}
+
+var errPrivilegedRole = errors.New("mutability: roles with admin permissions cannot be assigned over SCIM")
+
+// assignableRole returns the name of the role to give a user, refusing roles
+// that carry permissions.
+func (s *SCIMService) assignableRole(ctx context.Context, name string) (string, error) {
+	role, err := s.roleRepo.GetByName(ctx, strings.ToLower(name))
+	if err != nil {
+		return "", err
+	}
+	if role == nil {
+		return "", errors.New("invalid value: role not found")
+	}
+	if isPrivilegedRole(role) {
+		return "", errPrivilegedRole
+	}
+	return role.Name, nil
+}
+
+// isPrivilegedRole reports whether role gives any admin permission. Every
+// permission is an admin permission.
+func isPrivilegedRole(role *models.Role) bool {
+	return role.Name == "admin" || len(role.Permissions) > 0
+}
+
+// isPrivileged reports whether user holds any admin permission, through
+// their role or granted directly.
+func (s *SCIMService) isPrivileged(ctx context.Context, user *models.User) (bool, error) {
+	role, err := s.roleRepo.GetByName(ctx, strings.ToLower(user.Role))
+	if err != nil {
+		return false, err
+	}
+	if role != nil && isPrivilegedRole(role) {
+		return true, nil
+	}
+	grants, err := s.permissionRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	return len(grants) > 0, nil
+}
+
+func (s *SCIMService) userResource(user *models.User) *SCIMUser {
+	active := user.Status == "active" || user.Status == "pending"
+	name := &SCIMName{Formatted: user.Name}
+	if user.FirstName != nil {
+		name.GivenName = *user.FirstName
+	}
+	if user.LastName != nil {
+		name.FamilyName = *user.LastName
+	}
+	return &SCIMUser{
+		Schemas:     []string{SCIMSchemaUser},
+		ID:          user.ID.String(),
+		UserName:    user.Email,
+		Name:        name,
+		DisplayName: user.Name,
+		Emails:      []SCIMValue{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Roles:       []SCIMValue{{Value: user.Role, Primary: true}},
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     s.cfg.BaseURL + "/Users/" + user.ID.String(),
+		},
+	}
+}
+
+func (s *SCIMService) groupResource(ctx context.Context, role *models.Role, withMembers bool) (*SCIMGroup, error) {
+	group := &SCIMGroup{
+		Schemas:     []string{SCIMSchemaGroup},
+		ID:          role.ID.String(),
+		DisplayName: role.Name,
+		Meta: &SCIMMeta{
+			ResourceType: "Group",
+			Created:      role.CreatedAt,
+			LastModified: role.UpdatedAt,
+			Location:     s.cfg.BaseURL + "/Groups/" + role.ID.String(),
+		},
+	}
+	if !withMembers {
+		return group, nil
+	}
+	members, err := s.userRepo.ListByRole(ctx, role.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		group.Members = append(group.Members, SCIMValue{
+			Value:   member.ID.String(),
+			Display: member.Email,
+			Ref:     s.cfg.BaseURL + "/Users/" + member.ID.String(),
+		})
+	}
+	return group, nil
+}
+
+func (s *SCIMService) record(ctx context.Context, tokenID uuid.UUID, action, targetType, targetID string, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["scim_token_id"] = tokenID.String()
+	s.logService.Record(ctx, nil, "scim", action, strPtr(targetType), strPtr(targetID), metadata)
+}
+
+// patchSCIMUser applies one patch operation to the user's representation.
+// Attributes the account does not keep, such as phone numbers or extension
+// schemas, are accepted and ignored.
+func patchSCIMUser(user *SCIMUser, operation SCIMPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return errors.New("invalid patch operation: " + operation.Op)
+	}
+	path := strings.TrimSpace(operation.Path)
+	if path != "" {
+		return setSCIMUserAttribute(user, op, path, operation.Value)
+	}
+	if op == "remove" {
+		return errors.New("invalid path: remove needs a path")
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(operation.Value, &values); err != nil {
+		return errors.New("invalid value: expected an object")
+	}
+	for attr, value := range values {
+		if err := setSCIMUserAttribute(user, op, attr, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setSCIMUserAttribute(user *SCIMUser, op, path string, value json.RawMessage) error {
+	path = strings.TrimPrefix(path, SCIMSchemaUser+":")
+	remove := op == "remove"
+	switch attr := strings.ToLower(path); attr {
+	case "active":
+		if remove {
+			return errors.New("invalid path: active cannot be removed")
+		}
+		active, err := scimBool(value)
+		if err != nil {
+			return err
+		}
+		user.Active = &active
+	case "username":
+		if remove {
+			return errors.New("invalid path: userName cannot be removed")
+		}
+		return scimString(value, &user.UserName)
+	case "password":
+		if remove {
+			return errors.New("invalid path: password cannot be removed")
+		}
+		return scimString(value, &user.Password)
+	case "displayname":
+		if remove {
+			user.DisplayName = ""
+			return nil
+		}
+		return scimString(value, &user.DisplayName)
+	case "name":
+		if remove {
+			user.Name = nil
+			return nil
+		}
+		var name SCIMName
+		if err := json.Unmarshal(value, &name); err != nil {
+			return errors.New("invalid value: name must be an object")
+		}
+		user.Name = &name
+	case "name.givenname", "name.familyname", "name.formatted":
+		if user.Name == nil {
+			user.Name = &SCIMName{}
+		}
+		field := map[string]*string{
+			"name.givenname":  &user.Name.GivenName,
+			"name.familyname": &user.Name.FamilyName,
+			"name.formatted":  &user.Name.Formatted,
+		}[attr]
+		if remove {
+			*field = ""
+			return nil
+		}
+		return scimString(value, field)
+	case "roles":
+		if remove {
+			user.Roles = nil
+			return nil
+		}
+		var roles []SCIMValue
+		if err := json.Unmarshal(value, &roles); err != nil {
+			return errors.New("invalid value: roles must be a list")
+		}
+		user.Roles = roles
+	}
+	return nil
+}
+
+func applySCIMNames(user *models.User, in SCIMUser) {
+	var given, family, formatted string
+	if in.Name != nil {
+		given = strings.TrimSpace(in.Name.GivenName)
+		family = strings.TrimSpace(in.Name.FamilyName)
+		formatted = strings.TrimSpace(in.Name.Formatted)
+	}
+	user.FirstName = optionalString(given)
+	user.LastName = optionalString(family)
+
+	display := strings.TrimSpace(in.DisplayName)
+	if display == "" {
+		display = formatted
+	}
+	if display == "" {
+		display = strings.TrimSpace(given + " " + family)
+	}
+	if display == "" {
+		display = user.Email
+	}
+	user.Name = display
+}
+
+func checkSCIMGroupName(role *models.Role, name string) error {
+	if name = strings.TrimSpace(name); name != "" && !strings.EqualFold(name, role.Name) {
+		return errors.New("mutability: groups cannot be renamed")
+	}
+	return nil
+}
+
+// scimEmail returns the account email for a userName.
+func scimEmail(userName string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(userName))
+	if email == "" {
+		return "", errors.New("invalid value: userName is required")
+	}
+	if !strings.Contains(email, "@") {
+		return "", errors.New("invalid value: userName must be an email address")
+	}
+	return email, nil
+}
+
+// scimRole returns the primary role's name, or the first one's.
+func scimRole(roles []SCIMValue) string {
+	for _, role := range roles {
+		if role.Primary {
+			return strings.ToLower(strings.TrimSpace(role.Value))
+		}
+	}
+	if len(roles) > 0 {
+		return strings.ToLower(strings.TrimSpace(roles[0].Value))
+	}
+	return ""
+}
+
+func scimValues(values []SCIMValue) []string {
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		ids = append(ids, value.Value)
+	}
+	return ids
+}
+
+// scimBool also accepts "True" and "False" as strings, which some identity
+// providers send.
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(str)); err == nil {
+			return b, nil
+		}
+	}
+	return false, errors.New("invalid value: expected a boolean")
+}
+
+func scimString(value json.RawMessage, dest *string) error {
+	if err := json.Unmarshal(value, dest); err != nil {
+		return errors.New("invalid value: expected a string")
+	}
+	return nil
+}
+
+// parseSCIMFilter parses the single `attribute eq "value"` filters identity
+// providers send, returning the attribute in lower case.
+func parseSCIMFilter(filter string) (string, string, error) {
+	match := scimFilterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", errors.New(`invalid filter: only attribute eq "value" is supported`)
+	}
+	value, err := strconv.Unquote(`"` + match[2] + `"`)
+	if err != nil {
+		return "", "", errors.New("invalid filter: malformed value")
+	}
+	attr := strings.ToLower(strings.TrimPrefix(match[1], SCIMSchemaUser+":"))
+	return attr, value, nil
+}
+
+// scimPage applies defaults and limits to 1-based paging parameters. A
+// negative count means none was given.
+func scimPage(startIndex, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = scimDefaultCount
+	}
+	if count > scimMaxCount {
+		count = scimMaxCount
+	}
+	return startIndex, count
+}
+
+func scimListPage(resources []interface{}, startIndex, count int) *SCIMListResponse {
+	total := len(resources)
+	from := startIndex - 1
+	if from > total {
+		from = total
+	}
+	to := from + count
+	if to > total {
+		to = total
+	}
+	page := resources[from:to]
+	if page == nil {
+		page = []interface{}{}
+	}
+	return &SCIMListResponse{
+		Schemas:      []string{SCIMSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
@@ -21,6 +21,7 @@ type SearchService struct {
 	notificationRepo  repositories.NotificationRepository
 	customCRUDRepo    repositories.CustomCRUDRepository
 	userRepo          repositories.UserRepository
+	privacy           *PrivacyService
 	logger            *zap.Logger
 }
 
@@ -31,6 +32,7 @@ func NewSearchService(
 	notificationRepo repositories.NotificationRepository,
 	customCRUDRepo repositories.CustomCRUDRepository,
 	userRepo repositories.UserRepository,
+	privacy *PrivacyService,
 	logger *zap.Logger,
 ) *SearchService {
 	return &SearchService{
@@ -40,6 +42,7 @@ func NewSearchService(
 		notificationRepo: notificationRepo,
 		customCRUDRepo:   customCRUDRepo,
 		userRepo:         userRepo,
+		privacy:          privacy,
 		logger:           logger,
 	}
 }
@@ -63,8 +66,11 @@ type SearchRequest struct {
 	Radius      *float64  `json:"radius"`      // Radius in kilometers for "Search Near Me"
 }
 
-// Search performs a comprehensive search across all searchable entities
-func (s *SearchService) Search(ctx context.Context, userID uuid.UUID, req SearchRequest) (*models.SearchResult, error) {
+// Search performs a comprehensive search across all searchable entities.
+// Dashboard items and custom CRUD data come from the tenant's records only.
+func (s *SearchService) Search(ctx context.Context, tenant *models.Tenant, req SearchRequest) (*models.SearchResult, error) {
+	userID := tenant.UserID
+
 	// Allow search with just coordinates (for "Search Near Me")
 	if req.Query == "" && req.Location == nil && req.Country == nil && req.City == nil && req.Latitude == nil && req.Longitude == nil {
 		return &models.SearchResult{
@@ -95,7 +101,7 @@ func (s *SearchService) Search(ctx context.Context, userID uuid.UUID, req Search
 
 	// Global search - search across all entities
 	if req.Type == "all" || req.Type == "dashboard_items" {
-		items, err := s.searchDashboardItems(ctx, userID, req)
+		items, err := s.searchDashboardItems(ctx, tenant, req)
 		if err == nil {
 			for _, item := range items {
 				results = append(results, models.SearchResult{
@@ -133,15 +139,11 @@ func (s *SearchService) Search(ctx context.Context, userID uuid.UUID, req Search
 	if req.Type == "all" || req.Type == "users" {
 		users, err := s.searchUsers(ctx, userID, req)
 		if err == nil {
-			for _, user := range users {
-				results = append(results, models.SearchResult{
-					Type:  "user",
-					ID:    user.ID.String(),
-					Title: user.Name,
-					Data:  user,
-				})
+			userResults, err := s.userResults(ctx, userID, users)
+			if err == nil {
+				results = append(results, userResults...)
+				totalCount += len(userResults)
 			}
-			totalCount += len(users)
 		}
 	}
 
@@ -163,7 +165,7 @@ func (s *SearchService) Search(ctx context.Context, userID uuid.UUID, req Search
 	}
 
 	if req.Type == "all" || req.Type == "cruds" || req.Type == "custom_cruds" {
-		crudData, err := s.searchCustomCRUDs(ctx, userID, req)
+		crudData, err := s.searchCustomCRUDs(ctx, tenant, req)
 		if err == nil {
 			for _, data := range crudData {
 				var dataMap map[string]interface{}
@@ -187,7 +189,7 @@ func (s *SearchService) Search(ctx context.Context, userID uuid.UUID, req Search
 	}
 
 	if req.Type == "all" || req.Type == "locations" {
-		locationResults, err := s.searchByLocation(ctx, userID, req)
+		locationResults, err := s.searchByLocation(ctx, tenant, req)
 		if err == nil {
 			results = append(results, locationResults...)
 			totalCount += len(locationResults)
@@ -299,13 +301,13 @@ func (s *SearchService) filterByRadius(results []models.SearchResult, centerLat,
 }
 
 // searchDashboardItems searches dashboard items with advanced filters
-func (s *SearchService) searchDashboardItems(ctx context.Context, userID uuid.UUID, req SearchRequest) ([]*models.DashboardItem, error) {
+func (s *SearchService) searchDashboardItems(ctx context.Context, tenant *models.Tenant, req SearchRequest) ([]*models.DashboardItem, error) {
 	if req.Query != "" {
-		return s.searchRepo.SearchDashboardItems(ctx, userID, req.Query, req.Limit)
+		return s.searchRepo.SearchDashboardItems(ctx, tenant, req.Query, req.Limit)
 	}
 	
 	// Search by filters (category, status, date range)
-	items, err := s.dashboardRepo.ListItems(ctx, userID, req.Limit, req.Offset)
+	items, err := s.dashboardRepo.ListItems(ctx, tenant, req.Limit, req.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -397,6 +399,25 @@ func (s *SearchService) searchUsers(ctx context.Context, userID uuid.UUID, req S
 	return []*models.User{}, nil
 }
 
+// userResults returns users as search results carrying only what the viewer
+// may see of their profiles.
+func (s *SearchService) userResults(ctx context.Context, viewerID uuid.UUID, users []*models.User) ([]models.SearchResult, error) {
+	profiles, err := s.privacy.Profiles(ctx, viewerID, users)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]models.SearchResult, 0, len(profiles))
+	for _, profile := range profiles {
+		results = append(results, models.SearchResult{
+			Type:  "user",
+			ID:    profile.ID.String(),
+			Title: profile.Name,
+			Data:  profile,
+		})
+	}
+	return results, nil
+}
+
 // searchUsersByLocation searches users by location
 func (s *SearchService) searchUsersByLocation(ctx context.Context, userID uuid.UUID, req SearchRequest) ([]*models.User, error) {
 	// Use repository method for location-based search
@@ -432,10 +453,10 @@ func (s *SearchService) searchNotifications(ctx context.Context, userID uuid.UUI
 }
 
 // searchCustomCRUDs searches custom CRUD data
-func (s *SearchService) searchCustomCRUDs(ctx context.Context, userID uuid.UUID, req SearchRequest) ([]*models.CustomCRUDData, error) {
+func (s *SearchService) searchCustomCRUDs(ctx context.Context, tenant *models.Tenant, req SearchRequest) ([]*models.CustomCRUDData, error) {
 	if req.EntityID != nil {
 		// Search in specific entity
-		data, err := s.customCRUDRepo.ListDataByEntity(ctx, *req.EntityID, req.Limit, req.Offset)
+		data, err := s.customCRUDRepo.ListDataByEntity(ctx, *req.EntityID, tenant, req.Limit, req.Offset)
 		if err != nil {
 			return nil, err
 		}
@@ -455,15 +476,15 @@ func (s *SearchService) searchCustomCRUDs(ctx context.Context, userID uuid.UUID,
 		return filtered, nil
 	}
 
-	// Search across all entities
-	entities, err := s.customCRUDRepo.ListEntities(ctx, nil, true)
+	// Search across all of the tenant's entities
+	entities, err := s.customCRUDRepo.ListEntities(ctx, tenant, true)
 	if err != nil {
 		return nil, err
 	}
 
 	var allData []*models.CustomCRUDData
 	for _, entity := range entities {
-		data, err := s.customCRUDRepo.ListDataByEntity(ctx, entity.ID, 50, 0)
+		data, err := s.customCRUDRepo.ListDataByEntity(ctx, entity.ID, tenant, 50, 0)
 		if err == nil {
 			allData = append(allData, data...)
 		}
@@ -492,27 +513,23 @@ func (s *SearchService) searchCustomCRUDs(ctx context.Context, userID uuid.UUID,
 }
 
 // searchByLocation searches across all entities by location
-func (s *SearchService) searchByLocation(ctx context.Context, userID uuid.UUID, req SearchRequest) ([]models.SearchResult, error) {
+func (s *SearchService) searchByLocation(ctx context.Context, tenant *models.Tenant, req SearchRequest) ([]models.SearchResult, error) {
 	var results []models.SearchResult
 
 	// Search users by location
 	if req.Country != nil || req.City != nil || req.Location != nil {
-		users, err := s.searchUsersByLocation(ctx, userID, req)
+		users, err := s.searchUsersByLocation(ctx, tenant.UserID, req)
 		if err == nil {
-			for _, user := range users {
-				results = append(results, models.SearchResult{
-					Type:  "user",
-					ID:    user.ID.String(),
-					Title: user.Name,
-					Data:  user,
-				})
+			userResults, err := s.userResults(ctx, tenant.UserID, users)
+			if err == nil {
+				results = append(results, userResults...)
 			}
 		}
 	}
 
 	// Search dashboard items by location (if metadata contains location)
 	if req.Location != nil {
-		items, err := s.dashboardRepo.ListItems(ctx, userID, 100, 0)
+		items, err := s.dashboardRepo.ListItems(ctx, tenant, 100, 0)
 		if err == nil {
 			locationLower := strings.ToLower(*req.Location)
 			for _, item := range items {
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"time"
 
@@ -17,6 +16,7 @@ type SettingsService struct {
 	settingsRepo repositories.SettingsRepository
 	userRepo     repositories.UserRepository
 	logger       *zap.Logger
+	consents     *ConsentService
 }
 
 func NewSettingsService(
@@ -40,32 +40,50 @@ func (s *SettingsService) GetSettings(ctx context.Context, userID uuid.UUID) (*m
 
 	// Create default settings if not exists
 	if settings == nil {
-		settings = &models.ComprehensiveSettings{
-			UserID:            userID,
-			ProfileVisibility: "public",
-			EmailVisibility:   "private",
-			PhoneVisibility:   "private",
-			AllowMessaging:    "everyone",
-			SearchVisibility:  true,
-			EmailNotifications: true,
-			PushNotifications:  true,
-			NotificationMessages: true,
-			NotificationAlerts:   true,
-			NotificationSecurity: true,
-			Language:          "en",
-			Timezone:          "UTC",
-			Theme:             "light",
-			FontSize:          "medium",
-			UpdatedAt:         time.Now(),
-		}
+		settings = defaultSettings(userID)
 		if err := s.settingsRepo.Create(ctx, settings); err != nil {
 			return nil, err
 		}
 	}
 
+	if s.consents != nil {
+		if settings.NotificationPromotions, err = s.consents.MarketingAllowed(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
 	return settings, nil
 }
 
+// SetConsents makes notification_promotions follow the marketing choice in
+// the consent ledger: it reads as that choice, and changing it is recorded
+// there. Without it, the setting is stored like the others.
+func (s *SettingsService) SetConsents(consents *ConsentService) {
+	s.consents = consents
+}
+
+// defaultSettings returns the settings of a user who never changed any.
+func defaultSettings(userID uuid.UUID) *models.ComprehensiveSettings {
+	return &models.ComprehensiveSettings{
+		UserID:               userID,
+		ProfileVisibility:    models.VisibilityPublic,
+		EmailVisibility:      models.VisibilityPrivate,
+		PhoneVisibility:      models.VisibilityPrivate,
+		AllowMessaging:       models.MessagingEveryone,
+		SearchVisibility:     true,
+		EmailNotifications:   true,
+		PushNotifications:    true,
+		NotificationMessages: true,
+		NotificationAlerts:   true,
+		NotificationSecurity: true,
+		Language:             "en",
+		Timezone:             "UTC",
+		Theme:                "light",
+		FontSize:             "medium",
+		UpdatedAt:            time.Now(),
+	}
+}
+
 // UpdateProfileSettings updates profile-related settings
 func (s *SettingsService) UpdateProfileSettings(ctx context.Context, userID uuid.UUID, updates map[string]interface{}) error {
 	settings, err := s.GetSettings(ctx, userID)
@@ -102,9 +120,7 @@ func (s *SettingsService) UpdateSecuritySettings(ctx context.Context, userID uui
 		return err
 	}
 
-	if twoFactorEnabled, ok := updates["two_factor_enabled"].(bool); ok {
-		settings.TwoFactorEnabled = twoFactorEnabled
-	}
+	// two_factor_enabled follows TwoFactorService and is not set here
 	if securityQuestions, ok := updates["security_questions"].(string); ok {
 		settings.SecurityQuestions = &securityQuestions
 	}
@@ -115,6 +131,17 @@ func (s *SettingsService) UpdateSecuritySettings(ctx context.Context, userID uui
 	return s.settingsRepo.Update(ctx, settings)
 }
 
+// SetTwoFactorEnabled keeps two_factor_enabled in line with the user's
+// second factor.
+func (s *SettingsService) SetTwoFactorEnabled(ctx context.Context, userID uuid.UUID, enabled bool) error {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	settings.TwoFactorEnabled = enabled
+	return s.settingsRepo.Update(ctx, settings)
+}
+
 // UpdatePrivacySettings updates privacy-related settings
 func (s *SettingsService) UpdatePrivacySettings(ctx context.Context, userID uuid.UUID, updates map[string]interface{}) error {
 	settings, err := s.GetSettings(ctx, userID)
@@ -123,15 +150,27 @@ func (s *SettingsService) UpdatePrivacySettings(ctx context.Context, userID uuid
 	}
 
 	if profileVisibility, ok := updates["profile_visibility"].(string); ok {
+		if !models.ValidVisibility(profileVisibility) {
+			return errors.New("invalid profile_visibility: use public, friends or private")
+		}
 		settings.ProfileVisibility = profileVisibility
 	}
 	if emailVisibility, ok := updates["email_visibility"].(string); ok {
+		if !models.ValidVisibility(emailVisibility) {
+			return errors.New("invalid email_visibility: use public, friends or private")
+		}
 		settings.EmailVisibility = emailVisibility
 	}
 	if phoneVisibility, ok := updates["phone_visibility"].(string); ok {
+		if !models.ValidVisibility(phoneVisibility) {
+			return errors.New("invalid phone_visibility: use public, friends or private")
+		}
 		settings.PhoneVisibility = phoneVisibility
 	}
 	if allowMessaging, ok := updates["allow_messaging"].(string); ok {
+		if allowMessaging != models.MessagingEveryone && allowMessaging != models.MessagingFriends && allowMessaging != models.MessagingNone {
+			return errors.New("invalid allow_messaging: use everyone, friends or none")
+		}
 		settings.AllowMessaging = allowMessaging
 	}
 	if searchVisibility, ok := updates["search_visibility"].(bool); ok {
@@ -144,8 +183,9 @@ func (s *SettingsService) UpdatePrivacySettings(ctx context.Context, userID uuid
 	return s.settingsRepo.Update(ctx, settings)
 }
 
-// UpdateNotificationSettings updates notification-related settings
-func (s *SettingsService) UpdateNotificationSettings(ctx context.Context, userID uuid.UUID, updates map[string]interface{}) error {
+// UpdateNotificationSettings updates notification-related settings. client
+// is where a change of notification_promotions is recorded as made from.
+func (s *SettingsService) UpdateNotificationSettings(ctx context.Context, userID uuid.UUID, updates map[string]interface{}, client ClientInfo) error {
 	settings, err := s.GetSettings(ctx, userID)
 	if err != nil {
 		return err
@@ -167,6 +207,11 @@ func (s *SettingsService) UpdateNotificationSettings(ctx context.Context, userID
 		settings.NotificationAlerts = notificationAlerts
 	}
 	if notificationPromotions, ok := updates["notification_promotions"].(bool); ok {
+		if s.consents != nil {
+			if _, err := s.consents.SetMarketing(ctx, userID, notificationPromotions, client); err != nil {
+				return err
+			}
+		}
 		settings.NotificationPromotions = notificationPromotions
 	}
 	if notificationSecurity, ok := updates["notification_security"].(bool); ok {
@@ -208,85 +253,6 @@ func (s *SettingsService) UpdateAccountPreferences(ctx context.Context, userID u
 	return s.settingsRepo.Update(ctx, settings)
 }
 
-// AddConnectedAccount adds a connected third-party account
-func (s *SettingsService) AddConnectedAccount(ctx context.Context, userID uuid.UUID, account models.ConnectedAccount) error {
-	settings, err := s.GetSettings(ctx, userID)
-	if err != nil {
-		return err
-	}
-
-	var accounts []models.ConnectedAccount
-	if settings.ConnectedAccounts != nil {
-		if err := json.Unmarshal([]byte(*settings.ConnectedAccounts), &accounts); err != nil {
-			accounts = []models.ConnectedAccount{}
-		}
-	}
-
-	// Check if account already exists
-	for i, acc := range accounts {
-		if acc.Provider == account.Provider {
-			accounts[i] = account // Update existing
-			accountsJSON, _ := json.Marshal(accounts)
-			accountsStr := string(accountsJSON)
-			settings.ConnectedAccounts = &accountsStr
-			return s.settingsRepo.Update(ctx, settings)
-		}
-	}
-
-	// Add new account
-	accounts = append(accounts, account)
-	accountsJSON, _ := json.Marshal(accounts)
-	accountsStr := string(accountsJSON)
-	settings.ConnectedAccounts = &accountsStr
-
-	return s.settingsRepo.Update(ctx, settings)
-}
-
-// RemoveConnectedAccount removes a connected account
-func (s *SettingsService) RemoveConnectedAccount(ctx context.Context, userID uuid.UUID, provider string) error {
-	settings, err := s.GetSettings(ctx, userID)
-	if err != nil {
-		return err
-	}
-
-	if settings.ConnectedAccounts == nil {
-		return nil
-	}
-
-	var accounts []models.ConnectedAccount
-	if err := json.Unmarshal([]byte(*settings.ConnectedAccounts), &accounts); err != nil {
-		return err
-	}
-
-	// Remove account
-	var filtered []models.ConnectedAccount
-	for _, acc := range accounts {
-		if acc.Provider != provider {
-			filtered = append(filtered, acc)
-		}
-	}
-
-	accountsJSON, _ := json.Marshal(filtered)
-	accountsStr := string(accountsJSON)
-	settings.ConnectedAccounts = &accountsStr
-
-	return s.settingsRepo.Update(ctx, settings)
-}
-
-// RequestAccountDeletion schedules account deletion
-func (s *SettingsService) RequestAccountDeletion(ctx context.Context, userID uuid.UUID, daysUntilDeletion int) error {
-	settings, err := s.GetSettings(ctx, userID)
-	if err != nil {
-		return err
-	}
-
-	settings.AccountDeletionRequested = true
-	scheduledAt := time.Now().AddDate(0, 0, daysUntilDeletion)
-	settings.AccountDeletionScheduledAt = &scheduledAt
-
-	return s.settingsRepo.Update(ctx, settings)
-}
-
 // DeactivateAccount temporarily deactivates account
 func (s *SettingsService) DeactivateAccount(ctx context.Context, userID uuid.UUID) error {
 	settings, err := s.GetSettings(ctx, userID)
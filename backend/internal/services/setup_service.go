@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/auth"
+)
+
+// SetupService creates the first admin of a fresh instance. While no admin
+// exists it holds a one-time setup token, taken from SETUP_TOKEN or generated
+// at start; whoever presents it chooses the admin's email and password. The
+// token stops working as soon as any admin exists.
+type SetupService struct {
+	cfg         config.SetupConfig
+	userRepo    repositories.UserRepository
+	authService *AuthService
+	passwords   *PasswordPolicyService
+	logService  *ActivityLogService
+	logger      *zap.Logger
+
+	mu        sync.Mutex
+	tokenHash string
+}
+
+// CompleteSetupRequest is what the setup form sends to create the first admin.
+type CompleteSetupRequest struct {
+	Token        string
+	Email        string
+	Name         string
+	Password     string
+	TermsVersion string
+	Client       ClientInfo
+}
+
+func NewSetupService(
+	cfg config.SetupConfig,
+	userRepo repositories.UserRepository,
+	authService *AuthService,
+	passwords *PasswordPolicyService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *SetupService {
+	return &SetupService{
+		cfg:         cfg,
+		userRepo:    userRepo,
+		authService: authService,
+		passwords:   passwords,
+		logService:  logService,
+		logger:      logger,
+	}
+}
+
+// Init arms setup when the instance has no admin. It returns the token when
+// it generated one, for the caller to show the operator; a configured token
+// is never echoed back.
+func (s *SetupService) Init(ctx context.Context) (string, error) {
+	admin, err := s.userRepo.FirstByRole(ctx, "admin")
+	if err != nil {
+		return "", err
+	}
+	if admin != nil {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token := strings.TrimSpace(s.cfg.Token); token != "" {
+		s.tokenHash = hashToken(token)
+		return "", nil
+	}
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	s.tokenHash = hashToken(token)
+	return token, nil
+}
+
+// Required reports whether the instance still waits for its first admin.
+func (s *SetupService) Required(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requiredLocked(ctx)
+}
+
+// Complete creates the first admin with the setup token and signs them in.
+func (s *SetupService) Complete(ctx context.Context, req CompleteSetupRequest) (*models.User, *models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	required, err := s.requiredLocked(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !required {
+		return nil, nil, errors.New("setup is already complete")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(strings.TrimSpace(req.Token))), []byte(s.tokenHash)) != 1 {
+		return nil, nil, errors.New("invalid setup token")
+	}
+	if err := s.authService.checkTerms(ctx, req.TermsVersion); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.CreateAdmin(ctx, CreateAdminRequest{Email: req.Email, Name: req.Name, Password: req.Password}, "setup")
+	if err != nil {
+		return nil, nil, err
+	}
+	s.tokenHash = ""
+	s.authService.recordSignupConsents(ctx, user, models.ConsentSourceSetup, req.TermsVersion, nil, req.Client)
+
+	session, _, err := s.authService.completeLogin(ctx, user, req.Client, LoginMethodPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.logger.Info("First admin created through setup", zap.String("user_id", user.ID.String()))
+	return user, session, nil
+}
+
+// CreateAdmin adds an active admin account outside the admin API, for setup
+// and the command line. source names where it came from in the activity log.
+func (s *SetupService) CreateAdmin(ctx context.Context, req CreateAdminRequest, source string) (*models.User, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	name := strings.TrimSpace(req.Name)
+	if email == "" {
+		return nil, errors.New("email is required")
+	}
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if existing, _ := s.userRepo.GetByEmail(ctx, email); existing != nil {
+		return nil, errors.New("user already exists")
+	}
+	if err := s.passwords.Validate(ctx, nil, req.Password); err != nil {
+		return nil, err
+	}
+	passwordHash, err := auth.GenerateHash(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	admin := &models.User{
+		ID:                uuid.New(),
+		Email:             email,
+		PasswordHash:      passwordHash,
+		Name:              name,
+		Status:            "active",
+		Role:              "admin",
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.userRepo.Create(ctx, admin); err != nil {
+		return nil, fmt.Errorf("failed to create admin: %w", err)
+	}
+
+	s.logService.Record(ctx, &admin.ID, "admin", "admin_created", strPtr("admin"), strPtr(admin.ID.String()), map[string]interface{}{
+		"source": source,
+	})
+	return admin, nil
+}
+
+// requiredLocked drops the token once an admin exists, however it was made.
+func (s *SetupService) requiredLocked(ctx context.Context) (bool, error) {
+	if s.tokenHash == "" {
+		return false, nil
+	}
+	admin, err := s.userRepo.FirstByRole(ctx, "admin")
+	if err != nil {
+		return false, err
+	}
+	if admin != nil {
+		s.tokenHash = ""
+		return false, nil
+	}
+	return true, nil
+}
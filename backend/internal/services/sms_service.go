@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/sms"
+)
+
+// smsSecurityMessageLength keeps security alerts to a few SMS segments.
+const smsSecurityMessageLength = 300
+
+// SMSService sends text messages: one-time codes to verify a phone number or
+// complete a sign-in, and security notifications for users who opted in.
+type SMSService struct {
+	cfg             config.SMSConfig
+	provider        sms.Provider
+	codeRepo        repositories.SMSCodeRepository
+	twoFactorRepo   repositories.TwoFactorRepository
+	userRepo        repositories.UserRepository
+	settingsService *SettingsService
+	logService      *ActivityLogService
+	logger          *zap.Logger
+}
+
+func NewSMSService(
+	cfg config.SMSConfig,
+	provider sms.Provider,
+	codeRepo repositories.SMSCodeRepository,
+	twoFactorRepo repositories.TwoFactorRepository,
+	userRepo repositories.UserRepository,
+	settingsService *SettingsService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *SMSService {
+	return &SMSService{
+		cfg:             cfg,
+		provider:        provider,
+		codeRepo:        codeRepo,
+		twoFactorRepo:   twoFactorRepo,
+		userRepo:        userRepo,
+		settingsService: settingsService,
+		logService:      logService,
+		logger:          logger,
+	}
+}
+
+// StartPhoneVerification texts a code to phone. The number only replaces the
+// user's current one once ConfirmPhone accepts the code. While SMS two-factor
+// authentication is on, the number cannot be changed.
+func (s *SMSService) StartPhoneVerification(ctx context.Context, userID uuid.UUID, phone string, ipAddress *string) (*models.SMSCode, error) {
+	phone, err := sms.Normalize(phone)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor != nil && twoFactor.Method == models.TwoFactorMethodSMS && (user.Phone == nil || *user.Phone != phone) {
+		return nil, errors.New("turn off two-factor authentication before changing your phone number")
+	}
+
+	code, err := s.sendCode(ctx, user, phone, models.SMSPurposePhoneVerification, nil, nil, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	s.logService.Record(ctx, &user.ID, user.Role, "phone_verification_requested", strPtr("user"), strPtr(user.ID.String()), nil)
+	return code, nil
+}
+
+// ConfirmPhone checks the code from StartPhoneVerification and, if it
+// matches, stores the number as the user's verified phone.
+func (s *SMSService) ConfirmPhone(ctx context.Context, userID uuid.UUID, guess string) (*models.User, error) {
+	code, err := s.codeRepo.GetPending(ctx, userID, models.SMSPurposePhoneVerification, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, errors.New("invalid or expired code")
+	}
+	if err := s.checkCode(ctx, code, guess); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdatePhone(ctx, userID, &code.Phone, true); err != nil {
+		return nil, fmt.Errorf("failed to update phone: %w", err)
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "phone_verified", strPtr("user"), strPtr(user.ID.String()), nil)
+	return user, nil
+}
+
+// NotifySecurity texts "security" notifications to users who turned on both
+// SMS and security notifications and have a verified phone. It is
+// registered with NotificationService.OnCreated.
+func (s *SMSService) NotifySecurity(ctx context.Context, notification *models.Notification) {
+	if notification.Type != "security" {
+		return
+	}
+	user, err := s.userRepo.GetByID(ctx, notification.UserID)
+	if err != nil || user.Phone == nil || !user.PhoneVerified {
+		return
+	}
+	settings, err := s.settingsService.GetSettings(ctx, user.ID)
+	if err != nil {
+		s.logger.Warn("Failed to load notification settings", zap.String("user_id", user.ID.String()), zap.Error(err))
+		return
+	}
+	if !settings.SMSNotifications || !settings.NotificationSecurity {
+		return
+	}
+
+	message := notification.Title + ": " + notification.Message
+	if runes := []rune(message); len(runes) > smsSecurityMessageLength {
+		message = string(runes[:smsSecurityMessageLength-1]) + "…"
+	}
+
+	// SMS delivery must not hold up whatever raised the notification
+	go func(ctx context.Context, phone string) {
+		if err := s.provider.Send(ctx, phone, "Base App: "+message); err != nil {
+			s.logger.Warn("Failed to send security SMS", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+	}(context.WithoutCancel(ctx), *user.Phone)
+}
+
+// sendCode stores and texts a new code for purpose, retiring the user's
+// earlier codes for it. Users get at most MaxPerHour codes of any kind.
+func (s *SMSService) sendCode(ctx context.Context, user *models.User, phone, purpose string, challengeHash, loginMethod, ipAddress *string) (*models.SMSCode, error) {
+	now := time.Now()
+	sent, err := s.codeRepo.CountSince(ctx, user.ID, now.Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if sent >= s.cfg.MaxPerHour {
+		s.logger.Warn("SMS code limit reached", zap.String("user_id", user.ID.String()))
+		return nil, errors.New("too many codes requested, try again later")
+	}
+
+	if err := s.codeRepo.InvalidateByUserID(ctx, user.ID, purpose, now); err != nil {
+		return nil, err
+	}
+
+	secret, err := randomCode()
+	if err != nil {
+		return nil, err
+	}
+	code := &models.SMSCode{
+		ID:            uuid.New(),
+		UserID:        user.ID,
+		Purpose:       purpose,
+		Phone:         phone,
+		CodeHash:      hashToken(secret),
+		ChallengeHash: challengeHash,
+		LoginMethod:   loginMethod,
+		IPAddress:     ipAddress,
+		ExpiresAt:     now.Add(s.cfg.CodeTTL),
+		CreatedAt:     now,
+	}
+	if err := s.codeRepo.Create(ctx, code); err != nil {
+		return nil, fmt.Errorf("failed to create code: %w", err)
+	}
+
+	// Expired codes still count towards the limit until the hour passes
+	if err := s.codeRepo.DeleteExpired(ctx, now.Add(-time.Hour)); err != nil {
+		s.logger.Warn("Failed to delete expired SMS codes", zap.Error(err))
+	}
+
+	message := fmt.Sprintf("Your Base App verification code is %s. It expires in %s.", secret, formatExpiry(s.cfg.CodeTTL))
+	if purpose == models.SMSPurposeLogin {
+		message = fmt.Sprintf("Your Base App sign-in code is %s. It expires in %s. Don't share it with anyone.", secret, formatExpiry(s.cfg.CodeTTL))
+	}
+	if err := s.provider.Send(ctx, phone, message); err != nil {
+		return nil, fmt.Errorf("failed to send text message: %w", err)
+	}
+	return code, nil
+}
+
+// checkCode consumes code if guess matches it. Each wrong guess counts, and
+// the code stops working after MaxAttempts of them, also when guesses arrive
+// in parallel: a match only consumes the code while the count is below the
+// limit.
+func (s *SMSService) checkCode(ctx context.Context, code *models.SMSCode, guess string) error {
+	now := time.Now()
+	if code.UsedAt != nil || now.After(code.ExpiresAt) || code.Attempts >= s.cfg.MaxAttempts {
+		return errors.New("invalid or expired code")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(guess)), []byte(code.CodeHash)) != 1 {
+		attempts, err := s.codeRepo.AddAttempt(ctx, code.ID)
+		if err != nil {
+			return err
+		}
+		if attempts >= s.cfg.MaxAttempts {
+			if _, err := s.codeRepo.MarkUsed(ctx, code.ID, now); err != nil {
+				return err
+			}
+		}
+		return errors.New("invalid or expired code")
+	}
+
+	used, err := s.codeRepo.Consume(ctx, code.ID, now, s.cfg.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !used {
+		// Used, or locked by wrong guesses, concurrently by other requests
+		return errors.New("invalid or expired code")
+	}
+	return nil
+}
+
+// randomCode returns a six-digit code.
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/auth"
+)
+
+// systemSettingDefaults lists the known system settings. The type of each
+// default is the type the setting accepts.
+var systemSettingDefaults = map[string]interface{}{
+	models.SettingMagicLinkEnabled: false,
+
+	models.SettingPasswordMinLength:        auth.DefaultPasswordPolicy.MinLength,
+	models.SettingPasswordRequireUppercase: auth.DefaultPasswordPolicy.RequireUppercase,
+	models.SettingPasswordRequireLowercase: auth.DefaultPasswordPolicy.RequireLowercase,
+	models.SettingPasswordRequireNumber:    auth.DefaultPasswordPolicy.RequireNumber,
+	models.SettingPasswordRequireSpecial:   auth.DefaultPasswordPolicy.RequireSpecial,
+	models.SettingPasswordMaxAgeDays:       0,
+	models.SettingPasswordHistoryCount:     5,
+	models.SettingPasswordCheckBreached:    true,
+}
+
+// systemSettingRanges bounds the integer settings, inclusive.
+var systemSettingRanges = map[string][2]int{
+	models.SettingPasswordMinLength:    {6, 128},
+	models.SettingPasswordMaxAgeDays:   {0, 3650},
+	models.SettingPasswordHistoryCount: {0, maxPasswordHistory},
+}
+
+// SystemSettingsService manages instance-wide settings such as which login
+// methods are offered. Unlike AdminSettingsService these are shared by all
+// admins.
+type SystemSettingsService struct {
+	settingsRepo repositories.SystemSettingsRepository
+	logService   *ActivityLogService
+	logger       *zap.Logger
+}
+
+func NewSystemSettingsService(
+	settingsRepo repositories.SystemSettingsRepository,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *SystemSettingsService {
+	return &SystemSettingsService{
+		settingsRepo: settingsRepo,
+		logService:   logService,
+		logger:       logger,
+	}
+}
+
+// GetSettings returns every known setting with its current or default value.
+func (s *SystemSettingsService) GetSettings(ctx context.Context) (map[string]interface{}, error) {
+	stored, err := s.settingsRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]interface{}, len(systemSettingDefaults))
+	for key, defaultValue := range systemSettingDefaults {
+		settings[key] = defaultValue
+	}
+	for _, setting := range stored {
+		defaultValue, ok := systemSettingDefaults[setting.Key]
+		if !ok {
+			continue
+		}
+		value, err := parseSystemSetting(setting.Value, defaultValue)
+		if err != nil {
+			s.logger.Warn("Ignoring invalid system setting", zap.String("key", setting.Key), zap.Error(err))
+			continue
+		}
+		settings[setting.Key] = value
+	}
+	return settings, nil
+}
+
+// Bool returns a boolean setting, falling back to its default when it cannot
+// be read.
+func (s *SystemSettingsService) Bool(ctx context.Context, key string) bool {
+	defaultValue, _ := systemSettingDefaults[key].(bool)
+	setting, err := s.settingsRepo.Get(ctx, key)
+	if err != nil {
+		s.logger.Warn("Failed to read system setting", zap.String("key", key), zap.Error(err))
+		return defaultValue
+	}
+	if setting == nil {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// Int returns an integer setting, falling back to its default when it cannot
+// be read.
+func (s *SystemSettingsService) Int(ctx context.Context, key string) int {
+	defaultValue, _ := systemSettingDefaults[key].(int)
+	setting, err := s.settingsRepo.Get(ctx, key)
+	if err != nil {
+		s.logger.Warn("Failed to read system setting", zap.String("key", key), zap.Error(err))
+		return defaultValue
+	}
+	if setting == nil {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(setting.Value)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// UpdateSettings validates and stores the given settings. Unknown keys and
+// values of the wrong type are rejected before anything is written.
+func (s *SystemSettingsService) UpdateSettings(ctx context.Context, adminID uuid.UUID, updates map[string]interface{}) (map[string]interface{}, error) {
+	if len(updates) == 0 {
+		return nil, errors.New("no settings to update")
+	}
+
+	keys := make([]string, 0, len(updates))
+	values := make(map[string]string, len(updates))
+	for key, value := range updates {
+		defaultValue, ok := systemSettingDefaults[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown setting: %s", key)
+		}
+		text, err := formatSystemSetting(value, defaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		if bounds, ok := systemSettingRanges[key]; ok {
+			if n, _ := strconv.Atoi(text); n < bounds[0] || n > bounds[1] {
+				return nil, fmt.Errorf("invalid value for %s: must be between %d and %d", key, bounds[0], bounds[1])
+			}
+		}
+		keys = append(keys, key)
+		values[key] = text
+	}
+	sort.Strings(keys)
+
+	now := time.Now()
+	for _, key := range keys {
+		if err := s.settingsRepo.Set(ctx, &models.SystemSetting{
+			Key:       key,
+			Value:     values[key],
+			UpdatedBy: &adminID,
+			UpdatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]interface{}, len(updates))
+	for key, value := range updates {
+		changes[key] = value
+	}
+	s.logService.Record(ctx, &adminID, "admin", "system_settings_updated", strPtr("system_settings"), nil, changes)
+
+	return s.GetSettings(ctx)
+}
+
+func parseSystemSetting(text string, defaultValue interface{}) (interface{}, error) {
+	switch defaultValue.(type) {
+	case bool:
+		return strconv.ParseBool(text)
+	case int:
+		return strconv.Atoi(text)
+	default:
+		return text, nil
+	}
+}
+
+func formatSystemSetting(value interface{}, defaultValue interface{}) (string, error) {
+	switch defaultValue.(type) {
+	case bool:
+		b, ok := value.(bool)
+		if !ok {
+			return "", errors.New("must be true or false")
+		}
+		return strconv.FormatBool(b), nil
+	case int:
+		// JSON numbers decode as float64
+		n, ok := value.(float64)
+		if !ok || n != float64(int(n)) {
+			return "", errors.New("must be a whole number")
+		}
+		return strconv.Itoa(int(n)), nil
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return "", errors.New("must be a string")
+		}
+		return text, nil
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/pkg/sms"
+)
+
+// TwoFactorService manages users' second factor and the sign-in step that
+// checks it. A code texted to the user's verified phone is the only method
+// so far.
+type TwoFactorService struct {
+	twoFactorRepo       repositories.TwoFactorRepository
+	codeRepo            repositories.SMSCodeRepository
+	userRepo            repositories.UserRepository
+	smsService          *SMSService
+	authService         *AuthService
+	settingsService     *SettingsService
+	notificationService *NotificationService
+	devices             *DeviceService
+	logService          *ActivityLogService
+	logger              *zap.Logger
+}
+
+// TwoFactorStatus describes a user's second factor for the settings page.
+type TwoFactorStatus struct {
+	Enabled       bool   `json:"enabled"`
+	Method        string `json:"method,omitempty"`
+	Phone         string `json:"phone,omitempty"`
+	PhoneVerified bool   `json:"phone_verified"`
+}
+
+func NewTwoFactorService(
+	twoFactorRepo repositories.TwoFactorRepository,
+	codeRepo repositories.SMSCodeRepository,
+	userRepo repositories.UserRepository,
+	smsService *SMSService,
+	authService *AuthService,
+	settingsService *SettingsService,
+	notificationService *NotificationService,
+	logService *ActivityLogService,
+	logger *zap.Logger,
+) *TwoFactorService {
+	return &TwoFactorService{
+		twoFactorRepo:       twoFactorRepo,
+		codeRepo:            codeRepo,
+		userRepo:            userRepo,
+		smsService:          smsService,
+		authService:         authService,
+		settingsService:     settingsService,
+		notificationService: notificationService,
+		logService:          logService,
+		logger:              logger,
+	}
+}
+
+// SetDevices lets devices the user trusts skip the second factor for the
+// trust period. Without it, every sign-in is challenged.
+func (s *TwoFactorService) SetDevices(devices *DeviceService) {
+	s.devices = devices
+}
+
+func (s *TwoFactorService) Status(ctx context.Context, userID uuid.UUID) (*TwoFactorStatus, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{PhoneVerified: user.PhoneVerified}
+	if user.Phone != nil {
+		status.Phone = sms.Mask(*user.Phone)
+	}
+	if twoFactor != nil {
+		status.Enabled = true
+		status.Method = twoFactor.Method
+	}
+	return status, nil
+}
+
+// EnableSMS turns on SMS codes at sign-in. It needs the current password and
+// a verified phone number.
+func (s *TwoFactorService) EnableSMS(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := s.checkPassword(ctx, userID, password)
+	if err != nil {
+		return err
+	}
+	if user.Phone == nil || !user.PhoneVerified {
+		return errors.New("verify a phone number first")
+	}
+
+	if err := s.twoFactorRepo.Set(ctx, &models.UserTwoFactor{
+		UserID:    user.ID,
+		Method:    models.TwoFactorMethodSMS,
+		EnabledAt: time.Now(),
+	}); err != nil {
+		return err
+	}
+	if err := s.settingsService.SetTwoFactorEnabled(ctx, user.ID, true); err != nil {
+		s.logger.Warn("Failed to update two-factor setting", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "two_factor_enabled", strPtr("user"), strPtr(user.ID.String()), map[string]interface{}{
+		"method": models.TwoFactorMethodSMS,
+	})
+	s.notify(ctx, user, "Two-factor authentication turned on",
+		"Signing in now needs a code texted to "+sms.Mask(*user.Phone)+".")
+	return nil
+}
+
+// Disable turns the second factor off. It needs the current password.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := s.checkPassword(ctx, userID, password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.settingsService.SetTwoFactorEnabled(ctx, user.ID, false); err != nil {
+		s.logger.Warn("Failed to update two-factor setting", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+	if err := s.codeRepo.InvalidateByUserID(ctx, user.ID, models.SMSPurposeLogin, time.Now()); err != nil {
+		s.logger.Warn("Failed to invalidate sign-in codes", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	s.logService.Record(ctx, &user.ID, user.Role, "two_factor_disabled", strPtr("user"), strPtr(user.ID.String()), nil)
+	s.notify(ctx, user, "Two-factor authentication turned off",
+		"Signing in no longer needs a code. If this wasn't you, change your password and turn it back on in Settings.")
+	return nil
+}
+
+// Required reports whether user must pass a second factor to sign in from
+// client. A device the user trusts skips it when the client presents the
+// device's trust token.
+func (s *TwoFactorService) Required(ctx context.Context, user *models.User, client ClientInfo) (bool, error) {
+	twoFactor, err := s.twoFactorRepo.Get(ctx, user.ID)
+	if err != nil || twoFactor == nil {
+		return false, err
+	}
+	if s.devices != nil && s.devices.trustedClient(ctx, user.ID, client) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Begin texts a sign-in code and returns the challenge the client completes
+// with Verify. method is how the user passed the first step.
+func (s *TwoFactorService) Begin(ctx context.Context, user *models.User, client ClientInfo, method string) (*TwoFactorRequiredError, error) {
+	if user.Phone == nil || !user.PhoneVerified {
+		// EnableSMS requires a verified phone and it cannot change while enabled
+		return nil, errors.New("no verified phone number for two-factor authentication")
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	challengeHash := hashToken(token)
+	code, err := s.smsService.sendCode(ctx, user, *user.Phone, models.SMSPurposeLogin, &challengeHash, &method, client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	s.authService.recordLoginEvent(ctx, &models.LoginEvent{
+		UserID:    &user.ID,
+		Email:     &user.Email,
+		EventType: models.LoginEventTwoFactorChallenge,
+		Method:    &method,
+	}, client)
+
+	return &TwoFactorRequiredError{
+		Token:       token,
+		Method:      models.TwoFactorMethodSMS,
+		Destination: sms.Mask(*user.Phone),
+		ExpiresAt:   code.ExpiresAt,
+	}, nil
+}
+
+// Resend replaces the code of a pending sign-in with a new one, under a new
+// challenge token.
+func (s *TwoFactorService) Resend(ctx context.Context, token string, client ClientInfo) (*TwoFactorRequiredError, error) {
+	code, user, err := s.pendingChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	method := LoginMethodPassword
+	if code.LoginMethod != nil {
+		method = *code.LoginMethod
+	}
+	return s.Begin(ctx, user, client, method)
+}
+
+// Verify completes a sign-in that Begin challenged, starting the session the
+// first step would have. With trustDevice, the device signed in from is
+// trusted and the returned token lets it skip the second factor for the
+// trust period.
+func (s *TwoFactorService) Verify(ctx context.Context, token, guess string, trustDevice bool, client ClientInfo) (*models.User, *models.Session, bool, string, error) {
+	code, user, err := s.pendingChallenge(ctx, token)
+	if err != nil {
+		return nil, nil, false, "", err
+	}
+	method := LoginMethodPassword
+	if code.LoginMethod != nil {
+		method = *code.LoginMethod
+	}
+
+	if err := s.smsService.checkCode(ctx, code, guess); err != nil {
+		s.authService.recordLoginFailure(ctx, user, user.Email, method, "invalid_two_factor_code", client)
+		return nil, nil, false, "", err
+	}
+
+	session, isNewDevice, err := s.authService.completeLogin(ctx, user, client, method)
+	if err != nil {
+		return nil, nil, false, "", err
+	}
+	var trustToken string
+	if trustDevice && s.devices != nil {
+		// The sign-in stands even if the device could not be trusted
+		if trustToken, err = s.devices.trust(ctx, user, client); err != nil {
+			s.logger.Warn("Failed to trust device", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+	}
+	s.logger.Info("User logged in with two-factor authentication", zap.String("user_id", user.ID.String()))
+	return user, session, isNewDevice, trustToken, nil
+}
+
+func (s *TwoFactorService) pendingChallenge(ctx context.Context, token string) (*models.SMSCode, *models.User, error) {
+	code, err := s.codeRepo.GetByChallengeHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if code == nil || code.UsedAt != nil || time.Now().After(code.ExpiresAt) {
+		return nil, nil, errors.New("invalid or expired code")
+	}
+	user, err := s.userRepo.GetByID(ctx, code.UserID)
+	if err != nil {
+		return nil, nil, errors.New("invalid or expired code")
+	}
+	return code, user, nil
+}
+
+func (s *TwoFactorService) checkPassword(ctx context.Context, userID uuid.UUID, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.PasswordHash == "" {
+		return nil, errors.New("set a password before changing two-factor authentication")
+	}
+	if !s.authService.verifyPassword(ctx, user, password) {
+		return nil, errors.New("invalid credentials")
+	}
+	return user, nil
+}
+
+func (s *TwoFactorService) notify(ctx context.Context, user *models.User, title, message string) {
+	if _, err := s.notificationService.CreateNotification(ctx, user.ID, "security", title, message, strPtr("/settings"), nil); err != nil {
+		s.logger.Warn("Failed to create two-factor notification", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
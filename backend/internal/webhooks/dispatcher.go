@@ -67,11 +67,16 @@ func (d *Dispatcher) deliverEvent(ctx context.Context, event *models.WebhookEven
 	event.ProcessedAt = &now
 	d.webhookRepo.UpdateEvent(ctx, event)
 
-	// Get subscription to retrieve webhook secret
-	subscription, err := d.webhookRepo.GetSubscriptionByURL(ctx, event.WebhookURL)
-	webhookSecret := d.secret // Default secret
-	if err == nil && subscription != nil && subscription.WebhookSecret != "" {
-		webhookSecret = subscription.WebhookSecret
+	// Sign with the secret of the subscription the event was created for. A
+	// URL can be subscribed by several organizations, so it only serves as a
+	// fallback for events stored without one.
+	webhookSecret := event.WebhookSecret
+	if webhookSecret == "" {
+		webhookSecret = d.secret // Default secret
+		subscription, err := d.webhookRepo.GetSubscriptionByURL(ctx, event.WebhookURL)
+		if err == nil && subscription != nil && subscription.WebhookSecret != "" {
+			webhookSecret = subscription.WebhookSecret
+		}
 	}
 
 	// Prepare payload
@@ -84,6 +89,9 @@ func (d *Dispatcher) deliverEvent(ctx context.Context, event *models.WebhookEven
 		"user_id":       event.UserID.String(),
 		"payload":       json.RawMessage(event.Payload),
 	}
+	if event.OrganizationID != nil {
+		payload["organization_id"] = event.OrganizationID.String()
+	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -37,12 +37,15 @@ func NewEmitter(webhookRepo repositories.WebhookRepository, secret string, logge
 	}
 }
 
+// Event is delivered to the subscriptions of its organization, or to the
+// platform's subscriptions when OrganizationID is nil.
 type Event struct {
-	EventType    string
-	EventVersion string
-	UserID       uuid.UUID
-	Payload      interface{}
-	Metadata     map[string]interface{}
+	EventType      string
+	EventVersion   string
+	UserID         uuid.UUID
+	OrganizationID *uuid.UUID
+	Payload        interface{}
+	Metadata       map[string]interface{}
 }
 
 func (e *Emitter) Emit(ctx context.Context, event Event) error {
@@ -55,21 +58,22 @@ func (e *Emitter) Emit(ctx context.Context, event Event) error {
 	payloadHash := e.hashPayload(payloadBytes)
 
 	webhookEvent := &models.WebhookEvent{
-		ID:           eventID,
-		EventType:    event.EventType,
-		EventVersion: event.EventVersion,
-		EventSource:  "base_app",
-		UserID:       event.UserID,
-		Payload:      payloadBytes,
-		PayloadHash:  payloadHash,
-		Status:       "pending",
-		ScheduledAt:  time.Now(),
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:             eventID,
+		EventType:      event.EventType,
+		EventVersion:   event.EventVersion,
+		EventSource:    "base_app",
+		UserID:         event.UserID,
+		OrganizationID: event.OrganizationID,
+		Payload:        payloadBytes,
+		PayloadHash:    payloadHash,
+		Status:         "pending",
+		ScheduledAt:    time.Now(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
-	// Get active subscriptions for this event type
-	subscriptions, err := e.webhookRepo.GetActiveSubscriptions(ctx, event.EventType)
+	// Get active subscriptions for this event type in the event's organization
+	subscriptions, err := e.webhookRepo.GetActiveSubscriptions(ctx, event.EventType, event.OrganizationID)
 	if err != nil {
 		return err
 	}
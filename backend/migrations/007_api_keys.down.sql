@@ -0,0 +1,6 @@
+-- Rollback API Keys Migration
+
+DROP INDEX IF EXISTS idx_api_keys_key_hash;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+
+DROP TABLE IF EXISTS api_keys;
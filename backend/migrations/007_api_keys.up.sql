@@ -0,0 +1,20 @@
+-- Personal Access Tokens / API Keys Migration
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    key_prefix TEXT NOT NULL, -- First characters of the token, shown in listings
+    key_hash TEXT UNIQUE NOT NULL, -- SHA-256 of the full token
+    scopes TEXT NOT NULL, -- JSON array, e.g. ["cruds:read","cruds:write"]
+    allowed_ips TEXT, -- JSON array of IPs/CIDRs, NULL = any
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    last_used_ip TEXT,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_roles_name;
+DROP TABLE IF EXISTS roles;
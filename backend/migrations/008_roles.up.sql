@@ -0,0 +1,18 @@
+-- Roles are named permission bundles. users.role references roles.name.
+CREATE TABLE IF NOT EXISTS roles (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    permissions TEXT NOT NULL DEFAULT '[]', -- JSON array of permission names, "*" grants all
+    is_system BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_roles_name ON roles(name);
+
+INSERT OR IGNORE INTO roles (id, name, description, permissions, is_system)
+VALUES ('00000000-0000-0000-0000-000000000001', 'admin', 'Full administrative access', '["*"]', 1);
+
+INSERT OR IGNORE INTO roles (id, name, description, permissions, is_system)
+VALUES ('00000000-0000-0000-0000-000000000002', 'user', 'Regular account', '[]', 1);
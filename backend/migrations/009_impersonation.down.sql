@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_account_switches_switched_to_user_id;
+ALTER TABLE account_switches DROP COLUMN ended_at;
+ALTER TABLE account_switches DROP COLUMN expires_at;
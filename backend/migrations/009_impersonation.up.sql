@@ -0,0 +1,5 @@
+-- Impersonation sessions are account switches with a deadline.
+ALTER TABLE account_switches ADD COLUMN expires_at DATETIME;
+ALTER TABLE account_switches ADD COLUMN ended_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_account_switches_switched_to_user_id ON account_switches(switched_to_user_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_identity_link_requests_user_id;
+DROP TABLE IF EXISTS identity_link_requests;
+DROP INDEX IF EXISTS idx_oidc_auth_states_expires_at;
+DROP TABLE IF EXISTS oidc_auth_states;
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS oauth_signing_keys;
+DROP INDEX IF EXISTS idx_oauth_refresh_tokens_session_id;
+DROP TABLE IF EXISTS oauth_refresh_tokens;
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,67 @@
+-- base-app as an OpenID Connect provider for other products
+
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id TEXT PRIMARY KEY,
+    client_id TEXT UNIQUE NOT NULL,
+    client_secret_hash TEXT, -- SHA-256 of the secret; NULL for public (PKCE-only) clients
+    name TEXT NOT NULL,
+    product_name TEXT, -- Matches X-Product-Name / product theme overrides
+    redirect_uris TEXT NOT NULL, -- JSON array of exact redirect URIs
+    post_logout_redirect_uris TEXT, -- JSON array
+    backchannel_logout_uri TEXT,
+    allowed_scopes TEXT NOT NULL, -- JSON array, e.g. ["openid","email","profile","offline_access"]
+    skip_consent BOOLEAN DEFAULT 0, -- First-party products
+    is_active BOOLEAN DEFAULT 1,
+    created_by TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT NOT NULL, -- JSON array
+    nonce TEXT,
+    code_challenge TEXT,
+    code_challenge_method TEXT,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS oauth_consents (
+    user_id TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    scopes TEXT NOT NULL, -- JSON array of granted scopes
+    granted_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, client_id),
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Refresh tokens issued to products live only as long as the base-app
+-- session they were issued under.
+CREATE TABLE IF NOT EXISTS oauth_refresh_tokens (
+    id TEXT PRIMARY KEY,
+    token_hash TEXT UNIQUE NOT NULL,
+    client_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    scopes TEXT NOT NULL, -- JSON array
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_refresh_tokens_session_id ON oauth_refresh_tokens(session_id);
+
+CREATE TABLE IF NOT EXISTS oauth_signing_keys (
+    kid TEXT PRIMARY KEY,
+    private_key TEXT NOT NULL, -- PEM encoded RSA key
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
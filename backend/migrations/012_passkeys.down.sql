@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_webauthn_ceremonies_expires_at;
+DROP TABLE IF EXISTS webauthn_ceremonies;
+DROP INDEX IF EXISTS idx_passkey_credentials_user_id;
+DROP TABLE IF EXISTS passkey_credentials;
@@ -0,0 +1,34 @@
+-- WebAuthn passkeys registered by users
+CREATE TABLE IF NOT EXISTS passkey_credentials (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    device_id TEXT, -- user_devices row the passkey was registered from
+    credential_id TEXT NOT NULL UNIQUE, -- base64url
+    public_key BLOB NOT NULL, -- COSE encoded
+    attestation_type TEXT,
+    aaguid TEXT,
+    sign_count INTEGER NOT NULL DEFAULT 0,
+    transports TEXT, -- JSON array
+    backup_eligible BOOLEAN DEFAULT 0,
+    backup_state BOOLEAN DEFAULT 0,
+    name TEXT NOT NULL,
+    clone_detected_at DATETIME, -- set when the sign count regressed; the passkey is no longer accepted
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(device_id) REFERENCES user_devices(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_passkey_credentials_user_id ON passkey_credentials(user_id);
+
+-- In-flight registration and login ceremonies
+CREATE TABLE IF NOT EXISTS webauthn_ceremonies (
+    token_hash TEXT PRIMARY KEY,
+    ceremony TEXT NOT NULL, -- registration, login
+    user_id TEXT, -- unset for discoverable logins
+    session_data TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_ceremonies_expires_at ON webauthn_ceremonies(expires_at);
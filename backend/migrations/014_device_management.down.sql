@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sessions_user_device;
+-- The per-user device uniqueness is kept; restoring a global constraint could
+-- fail on data written since.
@@ -0,0 +1,46 @@
+-- Device IDs come from the browser, so two accounts used on one browser share
+-- one. Make them unique per user instead of globally. SQLite cannot drop a
+-- column constraint, so the table is rebuilt; passkey links to devices are
+-- saved first because dropping the table nulls them when foreign keys are on.
+CREATE TEMP TABLE passkey_device_links AS
+    SELECT id, device_id FROM passkey_credentials WHERE device_id IS NOT NULL;
+
+CREATE TABLE user_devices_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    device_name TEXT,
+    device_type TEXT,
+    os TEXT,
+    browser TEXT,
+    ip_address TEXT,
+    location_country TEXT,
+    location_city TEXT,
+    is_trusted INTEGER DEFAULT 0,
+    trusted_at DATETIME,
+    last_used_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, device_id),
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO user_devices_new (id, user_id, device_id, device_name, device_type, os, browser, ip_address,
+    location_country, location_city, is_trusted, trusted_at, last_used_at, created_at)
+SELECT id, user_id, device_id, device_name, device_type, os, browser, ip_address,
+    location_country, location_city, is_trusted, trusted_at, last_used_at, created_at
+FROM user_devices;
+
+DROP TABLE user_devices;
+ALTER TABLE user_devices_new RENAME TO user_devices;
+
+CREATE INDEX IF NOT EXISTS idx_user_devices_user_id ON user_devices(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_devices_device_id ON user_devices(device_id);
+
+UPDATE passkey_credentials
+SET device_id = (SELECT l.device_id FROM passkey_device_links l WHERE l.id = passkey_credentials.id)
+WHERE id IN (SELECT id FROM passkey_device_links);
+
+DROP TABLE passkey_device_links;
+
+-- Sessions are matched to devices when a device is removed
+CREATE INDEX IF NOT EXISTS idx_sessions_user_device ON sessions(user_id, device_id);
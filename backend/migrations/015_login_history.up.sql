@@ -0,0 +1,24 @@
+-- Sign-in attempts and token refreshes, shown to users as their login history.
+-- user_id is NULL for failed attempts against an unknown email address.
+CREATE TABLE IF NOT EXISTS login_events (
+    id TEXT PRIMARY KEY,
+    user_id TEXT,
+    email TEXT,
+    event_type TEXT NOT NULL, -- login_success, login_failure, two_factor_challenge, token_refresh
+    method TEXT,              -- password, passkey, magic_link, oidc, refresh_token
+    failure_reason TEXT,
+    session_id TEXT,
+    ip_address TEXT,
+    user_agent TEXT,
+    device_id TEXT,
+    device_type TEXT,
+    os TEXT,
+    browser TEXT,
+    location_country TEXT,
+    location_city TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_events_user_id ON login_events(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_events_created_at ON login_events(created_at);
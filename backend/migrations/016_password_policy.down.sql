@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_password_history_user_id;
+DROP TABLE IF EXISTS password_history;
+ALTER TABLE users DROP COLUMN must_change_password;
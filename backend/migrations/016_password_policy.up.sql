@@ -0,0 +1,14 @@
+-- Set when an admin resets a password; the user must choose a new one
+-- before doing anything else.
+ALTER TABLE users ADD COLUMN must_change_password INTEGER DEFAULT 0;
+
+-- Previous password hashes, checked so users cannot reuse recent passwords.
+CREATE TABLE IF NOT EXISTS password_history (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history(user_id, created_at);
@@ -0,0 +1,21 @@
+-- Pending and completed email address changes. The confirmation link goes to
+-- the new address and the revert link to the old one; only SHA-256 hashes of
+-- the tokens are stored.
+CREATE TABLE IF NOT EXISTS email_change_requests (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    old_email TEXT NOT NULL,
+    new_email TEXT NOT NULL,
+    confirm_token_hash TEXT NOT NULL UNIQUE,
+    revert_token_hash TEXT NOT NULL UNIQUE,
+    session_id TEXT,          -- session that asked for the change; kept when the change is confirmed
+    ip_address TEXT,
+    expires_at DATETIME NOT NULL,        -- confirmation deadline
+    revert_expires_at DATETIME NOT NULL, -- the old address can undo the change until then
+    confirmed_at DATETIME,
+    reverted_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_change_requests_user_id ON email_change_requests(user_id, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_two_factor;
+DROP INDEX IF EXISTS idx_sms_codes_user_id;
+DROP TABLE IF EXISTS sms_codes;
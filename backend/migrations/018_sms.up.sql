@@ -0,0 +1,32 @@
+-- One-time codes sent by text message, to verify a phone number or as the
+-- second step of a sign-in. Only SHA-256 hashes of codes and challenge
+-- tokens are stored.
+CREATE TABLE IF NOT EXISTS sms_codes (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    purpose TEXT NOT NULL, -- phone_verification, login
+    phone TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    challenge_hash TEXT UNIQUE, -- login: identifies the sign-in waiting for the code
+    login_method TEXT,          -- login: how the first step was passed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    ip_address TEXT,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_codes_user_id ON sms_codes(user_id, created_at);
+
+-- The second factor a user has turned on, if any.
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id TEXT PRIMARY KEY,
+    method TEXT NOT NULL, -- sms
+    enabled_at DATETIME NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- two_factor_enabled was a preference nothing enforced; it now mirrors
+-- user_two_factor.
+UPDATE user_settings_comprehensive SET two_factor_enabled = 0;
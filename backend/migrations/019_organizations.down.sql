@@ -0,0 +1,11 @@
+-- Dropping the organizations deletes their records. The organization_id
+-- columns and per-owner name uniqueness are kept: SQLite cannot drop a column
+-- that references another table, and restoring global uniqueness could fail
+-- on data written since.
+DROP INDEX IF EXISTS idx_webhook_subscriptions_organization_id;
+DROP INDEX IF EXISTS idx_dashboard_items_organization_id;
+DROP INDEX IF EXISTS idx_organization_invitations_org;
+DROP TABLE IF EXISTS organization_invitations;
+DROP INDEX IF EXISTS idx_organization_members_user_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,123 @@
+-- Organizations let a team share dashboard items, custom CRUDs, templates and
+-- webhooks. Records with a NULL organization_id stay private to the user who
+-- created them, as before.
+CREATE TABLE IF NOT EXISTS organizations (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_by TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    role TEXT NOT NULL, -- owner, admin, member
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(organization_id, user_id),
+    FOREIGN KEY(organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members(user_id);
+
+-- Only a SHA-256 hash of the emailed token is stored
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by TEXT,
+    expires_at DATETIME NOT NULL,
+    accepted_at DATETIME,
+    accepted_by TEXT,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY(invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY(accepted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_org ON organization_invitations(organization_id, created_at);
+
+ALTER TABLE dashboard_items ADD COLUMN organization_id TEXT REFERENCES organizations(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_dashboard_items_organization_id ON dashboard_items(organization_id);
+
+ALTER TABLE webhook_subscriptions ADD COLUMN organization_id TEXT REFERENCES organizations(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_organization_id ON webhook_subscriptions(organization_id);
+
+ALTER TABLE webhook_events ADD COLUMN organization_id TEXT REFERENCES organizations(id) ON DELETE CASCADE;
+
+-- Entity and template names were globally unique. Make them unique per owner
+-- instead: per organization, or per user (entities) and platform-wide
+-- (templates) outside one. SQLite cannot drop a column constraint, so both
+-- tables are rebuilt; CRUD data is saved first because dropping the entities
+-- table deletes it when foreign keys are on.
+CREATE TEMP TABLE saved_custom_crud_data AS SELECT * FROM custom_crud_data;
+
+CREATE TABLE custom_crud_entities_new (
+    id TEXT PRIMARY KEY,
+    organization_id TEXT,
+    created_by TEXT NOT NULL,
+    entity_name TEXT NOT NULL, -- e.g., "products", "orders", "inventory"
+    display_name TEXT NOT NULL,
+    description TEXT,
+    schema TEXT NOT NULL, -- JSON schema defining fields
+    is_active INTEGER DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY(created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO custom_crud_entities_new (id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at)
+SELECT id, created_by, entity_name, display_name, description, schema, is_active, created_at, updated_at
+FROM custom_crud_entities;
+
+DROP TABLE custom_crud_entities;
+ALTER TABLE custom_crud_entities_new RENAME TO custom_crud_entities;
+
+CREATE INDEX IF NOT EXISTS idx_custom_crud_entities_created_by ON custom_crud_entities(created_by);
+CREATE INDEX IF NOT EXISTS idx_custom_crud_entities_active ON custom_crud_entities(is_active);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_crud_entities_org_name ON custom_crud_entities(organization_id, entity_name)
+    WHERE organization_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_crud_entities_user_name ON custom_crud_entities(created_by, entity_name)
+    WHERE organization_id IS NULL;
+
+INSERT INTO custom_crud_data SELECT * FROM saved_custom_crud_data;
+DROP TABLE saved_custom_crud_data;
+
+CREATE TABLE crud_templates_new (
+    id TEXT PRIMARY KEY,
+    organization_id TEXT,
+    name TEXT NOT NULL, -- e.g., "portfolio", "visa", "products"
+    display_name TEXT NOT NULL,
+    description TEXT,
+    schema TEXT NOT NULL, -- JSON schema defining fields
+    icon TEXT, -- Icon identifier
+    category TEXT, -- e.g., "business", "travel", "ecommerce"
+    created_by TEXT NOT NULL, -- User who created the template
+    is_active INTEGER DEFAULT 1,
+    is_system INTEGER DEFAULT 0, -- System templates cannot be deleted
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY(created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO crud_templates_new (id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at)
+SELECT id, name, display_name, description, schema, icon, category, created_by, is_active, is_system, created_at, updated_at
+FROM crud_templates;
+
+DROP TABLE crud_templates;
+ALTER TABLE crud_templates_new RENAME TO crud_templates;
+
+CREATE INDEX IF NOT EXISTS idx_crud_templates_category ON crud_templates(category);
+CREATE INDEX IF NOT EXISTS idx_crud_templates_active ON crud_templates(is_active);
+CREATE INDEX IF NOT EXISTS idx_crud_templates_created_by ON crud_templates(created_by);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_crud_templates_org_name ON crud_templates(organization_id, name)
+    WHERE organization_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_crud_templates_platform_name ON crud_templates(name)
+    WHERE organization_id IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_invitations_created_at;
+DROP INDEX IF EXISTS idx_user_invitations_email;
+DROP TABLE IF EXISTS user_invitations;
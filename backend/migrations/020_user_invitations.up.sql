@@ -0,0 +1,23 @@
+-- Invitations admins send to onboard users and admins. The invitee sets their
+-- own password when accepting; only a SHA-256 hash of the emailed token is
+-- stored, and resending replaces it.
+CREATE TABLE IF NOT EXISTS user_invitations (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    name TEXT,
+    role TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by TEXT,
+    expires_at DATETIME NOT NULL,
+    sent_at DATETIME NOT NULL,
+    send_count INTEGER NOT NULL DEFAULT 1,
+    accepted_at DATETIME,
+    accepted_by TEXT,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY(accepted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_invitations_email ON user_invitations(email);
+CREATE INDEX IF NOT EXISTS idx_user_invitations_created_at ON user_invitations(created_at);
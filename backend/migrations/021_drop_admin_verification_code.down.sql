@@ -0,0 +1,2 @@
+-- The old code is not restored; admins are created through setup or the CLI
+ALTER TABLE admin_settings ADD COLUMN admin_verification_code TEXT;
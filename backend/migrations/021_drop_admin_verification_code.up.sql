@@ -0,0 +1,2 @@
+-- The shared admin verification code is replaced by first-run setup
+ALTER TABLE admin_settings DROP COLUMN admin_verification_code;
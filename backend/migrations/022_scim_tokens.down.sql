@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_scim_tokens_created_at;
+DROP TABLE IF EXISTS scim_tokens;
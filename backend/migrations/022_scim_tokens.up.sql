@@ -0,0 +1,15 @@
+-- Bearer tokens admins issue to identity providers for SCIM provisioning.
+-- Only a SHA-256 hash of the token is stored; the plaintext is shown once.
+CREATE TABLE IF NOT EXISTS scim_tokens (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT,
+    last_used_at DATETIME,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_scim_tokens_created_at ON scim_tokens(created_at);
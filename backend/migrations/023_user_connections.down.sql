@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_user_blocks_blocked;
+DROP TABLE IF EXISTS user_blocks;
+DROP INDEX IF EXISTS idx_user_connections_addressee;
+DROP TABLE IF EXISTS user_connections;
@@ -0,0 +1,30 @@
+-- Friendships between users. A row is a pending request from requester_id to
+-- addressee_id until the addressee accepts it; declined, cancelled and
+-- removed friendships are deleted. There is at most one row per pair of
+-- users, whichever way round.
+CREATE TABLE IF NOT EXISTS user_connections (
+    id TEXT PRIMARY KEY,
+    requester_id TEXT NOT NULL,
+    addressee_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, accepted
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    accepted_at DATETIME,
+    UNIQUE(requester_id, addressee_id),
+    FOREIGN KEY(requester_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(addressee_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_connections_addressee ON user_connections(addressee_id, status);
+
+-- Users someone has blocked. Blocking works both ways: neither user can find,
+-- view, befriend or message the other.
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id TEXT NOT NULL,
+    blocked_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(blocker_id, blocked_id),
+    FOREIGN KEY(blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(blocked_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked ON user_blocks(blocked_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_data_exports_status;
+DROP INDEX IF EXISTS idx_data_exports_user_id;
+DROP TABLE IF EXISTS data_exports;
+DROP INDEX IF EXISTS idx_uploaded_files_user_id;
+DROP TABLE IF EXISTS uploaded_files;
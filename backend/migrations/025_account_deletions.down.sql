@@ -0,0 +1,4 @@
+-- created_by stays nullable on CRUD entities and data: records anonymized
+-- since could not be put back under NOT NULL.
+DROP INDEX IF EXISTS idx_account_deletions_purge_at;
+DROP TABLE IF EXISTS account_deletions;
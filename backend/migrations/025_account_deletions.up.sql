@@ -0,0 +1,81 @@
+-- Scheduled account deletions. An account is marked deleted when deletion is
+-- requested and purged at purge_at; until then a deletion the user requested
+-- themselves (requested_by = 'self') is cancelled by signing in, which puts
+-- back previous_status.
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id TEXT PRIMARY KEY,
+    requested_by TEXT NOT NULL, -- self, admin, scim
+    actor_id TEXT,
+    previous_status TEXT NOT NULL,
+    requested_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    purge_at DATETIME NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_deletions_purge_at ON account_deletions(purge_at);
+
+-- Accounts deleted before this migration keep the old five-day schedule.
+INSERT INTO account_deletions (user_id, requested_by, previous_status, requested_at, purge_at)
+SELECT id, 'self', 'active', COALESCE(status_changed_at, updated_at, CURRENT_TIMESTAMP),
+       datetime(COALESCE(status_changed_at, updated_at, CURRENT_TIMESTAMP), '+5 days')
+FROM users WHERE status = 'deleted';
+
+-- CRUD entities and records can outlive their creator when a purge
+-- anonymizes them, so created_by becomes nullable and is cleared instead of
+-- cascading. SQLite cannot change a column constraint, so both tables are
+-- rebuilt; CRUD data is saved first because dropping the entities table
+-- deletes it when foreign keys are on.
+CREATE TEMP TABLE saved_custom_crud_data AS SELECT * FROM custom_crud_data;
+
+CREATE TABLE custom_crud_entities_new (
+    id TEXT PRIMARY KEY,
+    organization_id TEXT,
+    created_by TEXT,
+    entity_name TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    description TEXT,
+    schema TEXT NOT NULL,
+    is_active INTEGER DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY(created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO custom_crud_entities_new SELECT id, organization_id, created_by, entity_name, display_name,
+    description, schema, is_active, created_at, updated_at
+FROM custom_crud_entities;
+
+DROP TABLE custom_crud_entities;
+ALTER TABLE custom_crud_entities_new RENAME TO custom_crud_entities;
+
+CREATE INDEX IF NOT EXISTS idx_custom_crud_entities_created_by ON custom_crud_entities(created_by);
+CREATE INDEX IF NOT EXISTS idx_custom_crud_entities_active ON custom_crud_entities(is_active);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_crud_entities_org_name ON custom_crud_entities(organization_id, entity_name)
+    WHERE organization_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_crud_entities_user_name ON custom_crud_entities(created_by, entity_name)
+    WHERE organization_id IS NULL;
+
+DROP TABLE custom_crud_data;
+
+CREATE TABLE custom_crud_data (
+    id TEXT PRIMARY KEY,
+    entity_id TEXT NOT NULL,
+    data TEXT NOT NULL, -- JSON data
+    created_by TEXT,
+    updated_by TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME,
+    FOREIGN KEY(entity_id) REFERENCES custom_crud_entities(id) ON DELETE CASCADE,
+    FOREIGN KEY(created_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY(updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_crud_data_entity_id ON custom_crud_data(entity_id);
+CREATE INDEX IF NOT EXISTS idx_custom_crud_data_created_by ON custom_crud_data(created_by);
+CREATE INDEX IF NOT EXISTS idx_custom_crud_data_deleted_at ON custom_crud_data(deleted_at);
+
+INSERT INTO custom_crud_data (id, entity_id, data, created_by, updated_by, created_at, updated_at, deleted_at)
+SELECT id, entity_id, data, created_by, updated_by, created_at, updated_at, deleted_at FROM saved_custom_crud_data;
+DROP TABLE saved_custom_crud_data;
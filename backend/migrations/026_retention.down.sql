@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_webhook_events_created_at;
+DROP INDEX IF EXISTS idx_sessions_expires_at;
+DROP INDEX IF EXISTS idx_retention_runs_started_at;
+DROP TABLE IF EXISTS retention_runs;
+DROP TABLE IF EXISTS retention_rules;
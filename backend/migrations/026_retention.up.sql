@@ -0,0 +1,32 @@
+-- Retention rules per data class, set by admins. A rule purges rows older
+-- than max_age_days, rows beyond the newest max_rows, or both; with archive
+-- set, rows are written to an archive file before they are deleted. Classes
+-- without a stored rule use the built-in defaults.
+CREATE TABLE IF NOT EXISTS retention_rules (
+    data_class TEXT PRIMARY KEY,
+    enabled INTEGER NOT NULL DEFAULT 0,
+    max_age_days INTEGER,
+    max_rows INTEGER,
+    archive INTEGER NOT NULL DEFAULT 0,
+    updated_by TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- One row per data class each time the purger deletes rows from it or fails.
+CREATE TABLE IF NOT EXISTS retention_runs (
+    id TEXT PRIMARY KEY,
+    data_class TEXT NOT NULL,
+    rows_purged INTEGER NOT NULL DEFAULT 0,
+    batches INTEGER NOT NULL DEFAULT 0,
+    archive_path TEXT,
+    error TEXT,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_runs_started_at ON retention_runs(started_at);
+
+-- The purger finds old rows by these
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_created_at ON webhook_events(created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_phone_index;
+ALTER TABLE users DROP COLUMN phone_index;
+DROP TABLE IF EXISTS encryption_keys;
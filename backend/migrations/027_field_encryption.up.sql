@@ -0,0 +1,19 @@
+-- Data keys sensitive columns are encrypted with, and the key blind indexes
+-- are computed with, each stored wrapped by the master key. The master key
+-- itself is never stored; master_key_id records which one wrapped the key.
+-- New values are sealed with the active data key; retired ones are kept
+-- until nothing is sealed with them any more.
+CREATE TABLE IF NOT EXISTS encryption_keys (
+    id TEXT PRIMARY KEY,
+    purpose TEXT NOT NULL, -- data, index
+    status TEXT NOT NULL,  -- active, retired
+    wrapped_key TEXT NOT NULL,
+    master_key_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    retired_at DATETIME
+);
+
+-- Blind index of the phone number, to find users by number once it is
+-- encrypted
+ALTER TABLE users ADD COLUMN phone_index TEXT;
+CREATE INDEX IF NOT EXISTS idx_users_phone_index ON users(phone_index);
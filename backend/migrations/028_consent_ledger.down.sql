@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_consent_records_document;
+DROP INDEX IF EXISTS idx_consent_records_user;
+DROP TABLE IF EXISTS consent_records;
+DROP INDEX IF EXISTS idx_legal_documents_kind;
+DROP TABLE IF EXISTS legal_documents;
@@ -0,0 +1,36 @@
+-- Versions of the terms of service and privacy policy published by admins.
+-- Users who have not accepted the latest required version of a document, or
+-- a version published after it, are asked to accept it again.
+CREATE TABLE IF NOT EXISTS legal_documents (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('terms', 'privacy')),
+    version TEXT NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT,
+    required INTEGER NOT NULL DEFAULT 1,
+    published_by TEXT,
+    published_at DATETIME NOT NULL,
+    UNIQUE(kind, version),
+    FOREIGN KEY(published_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_documents_kind ON legal_documents(kind, published_at);
+
+-- The consent ledger: one row each time a user accepts a document version or
+-- opts in or out of marketing. Rows are never updated; the latest row of a
+-- kind is the user's current choice.
+CREATE TABLE IF NOT EXISTS consent_records (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('terms', 'privacy', 'marketing')),
+    version TEXT,
+    granted INTEGER NOT NULL,
+    source TEXT NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_consent_records_user ON consent_records(user_id, kind, created_at);
+CREATE INDEX IF NOT EXISTS idx_consent_records_document ON consent_records(kind, version);
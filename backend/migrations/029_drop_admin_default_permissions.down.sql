@@ -0,0 +1 @@
+ALTER TABLE admin_settings ADD COLUMN default_permissions TEXT;
@@ -0,0 +1,3 @@
+-- Per-admin default permissions were stored but never applied; roles and
+-- direct grants are what give permissions
+ALTER TABLE admin_settings DROP COLUMN default_permissions;
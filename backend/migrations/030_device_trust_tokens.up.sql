@@ -0,0 +1,6 @@
+-- Trusting a device now hands it a secret after a passed second factor, and
+-- only requests presenting that secret skip the next one. Only a SHA-256
+-- hash of it is stored. Trust given before, which rested on the device ID
+-- alone, is withdrawn.
+ALTER TABLE user_devices ADD COLUMN trust_token_hash TEXT;
+UPDATE user_devices SET is_trusted = 0, trusted_at = NULL;
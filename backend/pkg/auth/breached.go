@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedPasswords looks passwords up in a local corpus of leaked password
+// hashes, so no password or hash prefix is ever sent to an outside service.
+//
+// The corpus is a text file of uppercase hex SHA-1 hashes, one per line and
+// sorted, each optionally followed by ":count" - the format of the "ordered
+// by hash" Pwned Passwords download. The file is binary searched in place,
+// so even the full multi-gigabyte corpus needs no memory or start-up time.
+type BreachedPasswords struct {
+	file *os.File
+	size int64
+}
+
+// OpenBreachedPasswords opens the corpus at path. An empty path returns nil,
+// which reports every password as not breached.
+func OpenBreachedPasswords(path string) (*BreachedPasswords, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+	return &BreachedPasswords{file: file, size: info.Size()}, nil
+}
+
+// Contains reports whether the password's SHA-1 is in the corpus.
+func (b *BreachedPasswords) Contains(password string) (bool, error) {
+	if b == nil {
+		return false, nil
+	}
+	sum := sha1.Sum([]byte(password))
+	target := []byte(strings.ToUpper(hex.EncodeToString(sum[:])))
+
+	// Every line starting in [lo, hi) is a candidate
+	lo, hi := int64(0), b.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := b.lineAtOrAfter(mid)
+		if err != nil {
+			return false, err
+		}
+		if line == nil || start >= hi {
+			hi = mid
+			continue
+		}
+
+		hash := line
+		if i := bytes.IndexByte(hash, ':'); i >= 0 {
+			hash = hash[:i]
+		}
+		switch cmp := bytes.Compare(bytes.ToUpper(bytes.TrimSpace(hash)), target); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineAtOrAfter returns the first line starting at or after offset, without
+// its newline, or a nil line at the end of the file.
+func (b *BreachedPasswords) lineAtOrAfter(offset int64) (int64, []byte, error) {
+	buf := make([]byte, 256)
+	start := offset
+	if offset > 0 {
+		// Skip the rest of the line offset falls in
+		n, err := b.file.ReadAt(buf, offset-1)
+		if err != nil && err != io.EOF {
+			return 0, nil, err
+		}
+		i := bytes.IndexByte(buf[:n], '\n')
+		if i < 0 {
+			return 0, nil, nil
+		}
+		start = offset + int64(i)
+	}
+	if start >= b.size {
+		return start, nil, nil
+	}
+
+	n, err := b.file.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	line := buf[:n]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return start, line, nil
+}
+
+// Close releases the corpus file.
+func (b *BreachedPasswords) Close() error {
+	if b == nil {
+		return nil
+	}
+	return b.file.Close()
+}
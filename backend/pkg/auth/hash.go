@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hash algorithms
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// HashParams selects the algorithm and cost used for new password hashes.
+// Hashes are self-describing: argon2id hashes use the PHC string format
+// ($argon2id$v=19$m=...,t=...,p=...$salt$key) and bcrypt its own $2a$ format,
+// so hashes made with older settings keep verifying after a change.
+type HashParams struct {
+	Algorithm  string
+	BcryptCost int
+	// Argon2id cost: memory in KiB, passes over it, and lanes
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// DefaultHashParams follows the OWASP recommendation for argon2id.
+var DefaultHashParams = HashParams{
+	Algorithm:         HashArgon2id,
+	BcryptCost:        12,
+	Argon2Memory:      64 * 1024,
+	Argon2Iterations:  3,
+	Argon2Parallelism: 2,
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var hashParams = DefaultHashParams
+
+// SetHashParams changes the parameters GenerateHash uses. It is meant to be
+// called once at start-up, before any password is hashed.
+func SetHashParams(params HashParams) error {
+	switch params.Algorithm {
+	case HashBcrypt:
+		if params.BcryptCost < bcrypt.MinCost || params.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case HashArgon2id:
+		if params.Argon2Memory < 8*uint32(params.Argon2Parallelism) || params.Argon2Iterations < 1 || params.Argon2Parallelism < 1 {
+			return errors.New("argon2id needs at least 1 iteration, 1 lane and 8 KiB of memory per lane")
+		}
+	default:
+		return fmt.Errorf("unknown password hash algorithm: %s", params.Algorithm)
+	}
+	hashParams = params
+	return nil
+}
+
+// GenerateHash hashes a password without validating it. Callers check it
+// against the configured password policy first.
+func GenerateHash(password string) (string, error) {
+	if hashParams.Algorithm == HashBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), hashParams.BcryptCost)
+		return string(bytes), err
+	}
+	return generateArgon2id(password, hashParams)
+}
+
+// CheckPasswordHash reports whether password matches hash, in any supported
+// format.
+func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, "$"+HashArgon2id+"$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(candidate, key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash reports whether hash was made with a different algorithm or a
+// lower cost than the current parameters, so it should be replaced the next
+// time the plain password is known. A hash stronger than the current
+// parameters is left alone.
+func NeedsRehash(hash string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$"+HashArgon2id+"$"):
+		if hashParams.Algorithm != HashArgon2id {
+			return true
+		}
+		params, _, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		return params.Argon2Memory < hashParams.Argon2Memory ||
+			params.Argon2Iterations < hashParams.Argon2Iterations ||
+			params.Argon2Parallelism < hashParams.Argon2Parallelism ||
+			len(key) < argon2KeyLength
+	default:
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false
+		}
+		return hashParams.Algorithm != HashBcrypt || cost < hashParams.BcryptCost
+	}
+}
+
+func generateArgon2id(password string, params HashParams) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, argon2KeyLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", HashArgon2id, argon2.Version,
+		params.Argon2Memory, params.Argon2Iterations, params.Argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func decodeArgon2id(hash string) (HashParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HashArgon2id {
+		return HashParams{}, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return HashParams{}, nil, nil, errors.New("unsupported argon2id version")
+	}
+
+	params := HashParams{Algorithm: HashArgon2id}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Iterations, &params.Argon2Parallelism); err != nil {
+		return HashParams{}, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	if params.Argon2Iterations < 1 || params.Argon2Parallelism < 1 {
+		return HashParams{}, nil, nil, errors.New("invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return HashParams{}, nil, nil, errors.New("invalid argon2id salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return HashParams{}, nil, nil, errors.New("invalid argon2id key")
+	}
+	return params, salt, key, nil
+}
+
+// TuneArgon2id finds the highest iteration count at the given memory and
+// parallelism whose hash takes no longer than target on this machine, by
+// timing real hashes. It returns at least one iteration, with the time that
+// setting took, so the caller can tell when even that exceeds the target and
+// memory should come down instead.
+func TuneArgon2id(target time.Duration, memory uint32, parallelism uint8) (HashParams, time.Duration) {
+	params := HashParams{
+		Algorithm:         HashArgon2id,
+		BcryptCost:        DefaultHashParams.BcryptCost,
+		Argon2Memory:      memory,
+		Argon2Iterations:  1,
+		Argon2Parallelism: parallelism,
+	}
+	elapsed := timeHash(params)
+	for iterations := uint32(2); iterations <= 64; iterations++ {
+		candidate := params
+		candidate.Argon2Iterations = iterations
+		took := timeHash(candidate)
+		if took > target {
+			break
+		}
+		params, elapsed = candidate, took
+	}
+	return params, elapsed
+}
+
+// TuneBcrypt finds the highest bcrypt cost whose hash takes no longer than
+// target on this machine, with the time it took.
+func TuneBcrypt(target time.Duration) (HashParams, time.Duration) {
+	params := DefaultHashParams
+	params.Algorithm = HashBcrypt
+	params.BcryptCost = bcrypt.MinCost
+	elapsed := timeHash(params)
+	for cost := bcrypt.MinCost + 1; cost <= bcrypt.MaxCost; cost++ {
+		candidate := params
+		candidate.BcryptCost = cost
+		took := timeHash(candidate)
+		if took > target {
+			break
+		}
+		params, elapsed = candidate, took
+	}
+	return params, elapsed
+}
+
+// timeHash returns the fastest of three hashes with params, to discount
+// scheduling noise.
+func timeHash(params HashParams) time.Duration {
+	const password = "correct horse battery staple"
+	var fastest time.Duration
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if params.Algorithm == HashBcrypt {
+			_, _ = bcrypt.GenerateFromPassword([]byte(password), params.BcryptCost)
+		} else {
+			_, _ = generateArgon2id(password, params)
+		}
+		if took := time.Since(start); i == 0 || took < fastest {
+			fastest = took
+		}
+	}
+	return fastest
+}
@@ -12,9 +12,17 @@ type Claims struct {
 	UserID    string `json:"user_id"`
 	SessionID string `json:"session_id"`
 	Role      string `json:"role"`
+	Actor     *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Actor identifies who is really acting when a token is used to impersonate
+// another user (the RFC 8693 "act" claim).
+type Actor struct {
+	Subject         string `json:"sub"`
+	ImpersonationID string `json:"impersonation_id"`
+}
+
 type TokenPair struct {
 	AccessToken  string
 	RefreshToken string
@@ -69,6 +77,31 @@ func GenerateTokenPair(userID, sessionID, role, secret string, accessExpiry, ref
 	}, nil
 }
 
+// GenerateImpersonationToken issues a short-lived access token for userID that
+// carries the impersonating actor. No refresh token is issued, so the session
+// ends when the token expires.
+func GenerateImpersonationToken(userID, role, actorID, impersonationID, secret string, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:    userID,
+		SessionID: uuid.Nil.String(),
+		Role:      role,
+		Actor: &Actor{
+			Subject:         actorID,
+			ImpersonationID: impersonationID,
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ID:        uuid.New().String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
 func ValidateToken(tokenString, secret string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
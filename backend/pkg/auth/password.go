@@ -2,14 +2,13 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"regexp"
+	"strings"
 	"unicode"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
-const bcryptCost = 12
-
 // Password requirements
 const (
 	MinPasswordLength = 8
@@ -31,94 +30,157 @@ const (
 	PasswordStrong
 )
 
+func (s PasswordStrength) String() string {
+	switch s {
+	case PasswordStrong:
+		return "strong"
+	case PasswordMedium:
+		return "medium"
+	default:
+		return "weak"
+	}
+}
+
+// PasswordPolicy is the set of rules a new password must satisfy.
+type PasswordPolicy struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireNumber    bool
+	RequireSpecial   bool
+}
+
+// DefaultPasswordPolicy is used when no other policy is configured.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:        MinPasswordLength,
+	RequireUppercase: true,
+	RequireLowercase: true,
+	RequireNumber:    true,
+	RequireSpecial:   true,
+}
+
 type PasswordValidationResult struct {
-	Valid   bool
+	Valid    bool
 	Strength PasswordStrength
-	Errors  []string
+	// Score estimates how hard the password is to guess, from 0 (trivial)
+	// to 4 (very hard).
+	Score  int
+	Errors []string
 }
 
+// ValidatePassword checks a password against DefaultPasswordPolicy.
 func ValidatePassword(password string) PasswordValidationResult {
+	return ValidatePasswordWithPolicy(password, DefaultPasswordPolicy)
+}
+
+// ValidatePasswordWithPolicy checks a password against policy and rejects
+// common passwords. The strength is estimated whether or not it is valid.
+func ValidatePasswordWithPolicy(password string, policy PasswordPolicy) PasswordValidationResult {
 	result := PasswordValidationResult{
 		Valid:  true,
 		Errors: []string{},
 	}
+	fail := func(message string) {
+		result.Valid = false
+		result.Errors = append(result.Errors, message)
+	}
 
 	// Length check
-	if len(password) < MinPasswordLength {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Password must be at least 8 characters long")
+	minLength := policy.MinLength
+	if minLength < 1 {
+		minLength = 1
+	}
+	if len(password) < minLength {
+		fail(fmt.Sprintf("Password must be at least %d characters long", minLength))
 	}
 	if len(password) > MaxPasswordLength {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Password must be at most 128 characters long")
+		fail(fmt.Sprintf("Password must be at most %d characters long", MaxPasswordLength))
 	}
 
 	// Complexity checks
-	hasUpperChar := hasUpper.MatchString(password)
-	hasLowerChar := hasLower.MatchString(password)
-	hasNumberChar := hasNumber.MatchString(password)
-	hasSpecialChar := hasSpecial.MatchString(password)
-
-	if !hasUpperChar {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Password must contain at least one uppercase letter")
+	if policy.RequireUppercase && !hasUpper.MatchString(password) {
+		fail("Password must contain at least one uppercase letter")
 	}
-	if !hasLowerChar {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Password must contain at least one lowercase letter")
+	if policy.RequireLowercase && !hasLower.MatchString(password) {
+		fail("Password must contain at least one lowercase letter")
 	}
-	if !hasNumberChar {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Password must contain at least one number")
+	if policy.RequireNumber && !hasNumber.MatchString(password) {
+		fail("Password must contain at least one number")
 	}
-	if !hasSpecialChar {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Password must contain at least one special character")
+	if policy.RequireSpecial && !hasSpecial.MatchString(password) {
+		fail("Password must contain at least one special character")
 	}
 
 	// Check for common weak passwords
 	if isCommonPassword(password) {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Password is too common. Please choose a stronger password")
+		fail("Password is too common. Please choose a stronger password")
 	}
 
-	// Determine strength
-	if result.Valid {
-		score := 0
-		if len(password) >= 12 {
-			score++
-		}
-		if hasUpperChar {
-			score++
-		}
-		if hasLowerChar {
-			score++
-		}
-		if hasNumberChar {
-			score++
-		}
-		if hasSpecialChar {
-			score++
-		}
-		// Check for mixed case and numbers
-		if hasUpperChar && hasLowerChar && hasNumberChar {
-			score++
-		}
-		// Check for special characters
-		if hasSpecialChar {
-			score++
+	result.Score = EstimatePasswordScore(password)
+	switch {
+	case result.Score >= 3:
+		result.Strength = PasswordStrong
+	case result.Score == 2:
+		result.Strength = PasswordMedium
+	default:
+		result.Strength = PasswordWeak
+	}
+
+	return result
+}
+
+// EstimatePasswordScore rates a password from 0 to 4 by the entropy of its
+// character classes and length, discounting repeats, sequences and common
+// passwords. It is a rough guide for strength meters, not a guarantee.
+func EstimatePasswordScore(password string) int {
+	if password == "" || isCommonPassword(password) {
+		return 0
+	}
+
+	pool := 0
+	if hasLower.MatchString(password) {
+		pool += 26
+	}
+	if hasUpper.MatchString(password) {
+		pool += 26
+	}
+	if hasNumber.MatchString(password) {
+		pool += 10
+	}
+	if hasSpecial.MatchString(password) {
+		pool += 33
+	}
+	for _, r := range password {
+		if r > unicode.MaxASCII {
+			pool += 100
+			break
 		}
+	}
 
-		if score >= 6 {
-			result.Strength = PasswordStrong
-		} else if score >= 4 {
-			result.Strength = PasswordMedium
-		} else {
-			result.Strength = PasswordWeak
+	// Characters repeating or continuing a run of the previous one add little
+	effective := 0.0
+	runes := []rune(password)
+	for i, r := range runes {
+		if i > 0 && (r == runes[i-1] || r == runes[i-1]+1 || r == runes[i-1]-1) {
+			effective += 0.25
+			continue
 		}
+		effective++
 	}
 
-	return result
+	bits := effective * math.Log2(float64(pool))
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 40:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 80:
+		return 3
+	default:
+		return 4
+	}
 }
 
 func isCommonPassword(password string) bool {
@@ -129,7 +191,7 @@ func isCommonPassword(password string) bool {
 		"princess", "football", "iloveyou", "welcome123",
 	}
 
-	lowerPassword := password
+	lowerPassword := strings.ToLower(password)
 	for _, common := range commonPasswords {
 		if lowerPassword == common {
 			return true
@@ -206,11 +268,5 @@ func HashPassword(password string) (string, error) {
 		return "", errors.New("password does not meet requirements: " + validation.Errors[0])
 	}
 
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
-	return string(bytes), err
-}
-
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	return GenerateHash(password)
 }
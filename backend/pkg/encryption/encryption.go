@@ -0,0 +1,288 @@
+// Package encryption implements envelope encryption for individual database
+// fields. Values are sealed with AES-256-GCM under data keys; the data keys are
+// stored wrapped by a master key that is kept out of the database, so changing
+// the master key only means rewrapping the data keys. Blind indexes let sealed
+// values be looked up by equality without decrypting them.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KeySize is the length of master, data and index keys: AES-256.
+const KeySize = 32
+
+// Sealed values look like "enc:v1:<key ID>:<base64 of nonce and ciphertext>".
+const sealedPrefix = "enc:v1:"
+
+// wrapAAD binds wrapped keys to their purpose, so a wrapped key cannot be
+// passed off as a sealed field or the other way round.
+var wrapAAD = []byte("encryption key")
+
+var (
+	// ErrUnknownKey is returned for values sealed with a key the keyring
+	// does not hold.
+	ErrUnknownKey = errors.New("encryption: value was sealed with an unknown key")
+	// ErrNoActiveKey is returned when sealing before a data key was added.
+	ErrNoActiveKey = errors.New("encryption: no active data key")
+)
+
+// Keyring holds the master key and the unwrapped data keys. The active data
+// key seals new values; the others are kept to open values sealed before a
+// rotation until they have been re-encrypted. It is safe for concurrent use.
+type Keyring struct {
+	master   cipher.AEAD
+	masterID string
+
+	mu     sync.RWMutex
+	active string
+	keys   map[string]cipher.AEAD
+	index  []byte
+}
+
+// NewKeyring returns a keyring with no data keys for the given master key.
+func NewKeyring(master []byte) (*Keyring, error) {
+	aead, err := newAEAD(master)
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{
+		master:   aead,
+		masterID: MasterKeyID(master),
+		keys:     make(map[string]cipher.AEAD),
+	}, nil
+}
+
+// MasterKeyID identifies the keyring's master key, to tell which master key
+// a stored data key was wrapped by.
+func (k *Keyring) MasterKeyID() string {
+	return k.masterID
+}
+
+// Wrap encrypts a data key under the master key for storage.
+func (k *Keyring) Wrap(key []byte) (string, error) {
+	return wrap(k.master, key)
+}
+
+// Unwrap decrypts a data key wrapped by Wrap.
+func (k *Keyring) Unwrap(wrapped string) ([]byte, error) {
+	return unwrap(k.master, wrapped)
+}
+
+// AddKey adds a data key. With active, it seals new values from now on.
+func (k *Keyring) AddKey(id string, key []byte, active bool) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = aead
+	if active {
+		k.active = id
+	}
+	return nil
+}
+
+// RemoveKey forgets a data key once nothing is sealed with it any more.
+func (k *Keyring) RemoveKey(id string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id != k.active {
+		delete(k.keys, id)
+	}
+}
+
+// ActiveKeyID returns the ID of the key new values are sealed with.
+func (k *Keyring) ActiveKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// SetIndexKey sets the key blind indexes are computed with.
+func (k *Keyring) SetIndexKey(key []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.index = append([]byte(nil), key...)
+}
+
+// Seal encrypts plaintext for field, the "table.column" it is stored in. The
+// field is authenticated with the value, so a sealed value copied into
+// another column does not open.
+func (k *Keyring) Seal(field, plaintext string) (string, error) {
+	k.mu.RLock()
+	id, aead := k.active, k.keys[k.active]
+	k.mu.RUnlock()
+	if aead == nil {
+		return "", ErrNoActiveKey
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+	return sealedPrefix + id + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value Seal returned for field. Values that were never
+// sealed, such as those stored before encryption was turned on, are returned
+// as they are.
+func (k *Keyring) Open(field, value string) (string, error) {
+	id, ok := KeyID(value)
+	if !ok {
+		return value, nil
+	}
+	k.mu.RLock()
+	aead := k.keys[id]
+	k.mu.RUnlock()
+	if aead == nil {
+		return "", ErrUnknownKey
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(value[len(sealedPrefix)+len(id)+1:])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("encryption: malformed sealed value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("encryption: cannot open %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of value for field. Equal values give
+// equal indexes, so a column of them can be searched for a value without
+// revealing it.
+func (k *Keyring) BlindIndex(field, value string) (string, error) {
+	k.mu.RLock()
+	key := k.index
+	k.mu.RUnlock()
+	if key == nil {
+		return "", errors.New("encryption: no index key")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// IsSealed reports whether value was returned by Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// KeyID returns the ID of the data key value was sealed with.
+func KeyID(value string) (string, bool) {
+	if !IsSealed(value) {
+		return "", false
+	}
+	rest := value[len(sealedPrefix):]
+	i := strings.IndexByte(rest, ':')
+	if i <= 0 {
+		return "", false
+	}
+	return rest[:i], true
+}
+
+// SealedPrefix returns the prefix of every value sealed with the key, for
+// finding them with LIKE.
+func SealedPrefix(keyID string) string {
+	return sealedPrefix + keyID + ":"
+}
+
+// GenerateKey returns a new random key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// NewKeyID returns a random ID for a new data key.
+func NewKeyID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// ParseKey reads a key written as base64 or hex.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(s); err == nil && len(key) == KeySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("encryption: a key must be %d bytes, written as base64 or hex", KeySize)
+}
+
+// EncodeKey writes a key the way ParseKey reads it.
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// MasterKeyID returns a short fingerprint of a master key.
+func MasterKeyID(master []byte) string {
+	sum := sha256.Sum256(master)
+	return hex.EncodeToString(sum[:8])
+}
+
+// UnwrapWith decrypts a data key wrapped under another master key than the
+// keyring's, e.g. the previous one after the master key was changed.
+func UnwrapWith(master []byte, wrapped string) ([]byte, error) {
+	aead, err := newAEAD(master)
+	if err != nil {
+		return nil, err
+	}
+	return unwrap(aead, wrapped)
+}
+
+func wrap(master cipher.AEAD, key []byte) (string, error) {
+	nonce := make([]byte, master.NonceSize(), master.NonceSize()+len(key)+master.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(master.Seal(nonce, nonce, key, wrapAAD)), nil
+}
+
+func unwrap(master cipher.AEAD, wrapped string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(sealed) < master.NonceSize() {
+		return nil, errors.New("encryption: malformed wrapped key")
+	}
+	key, err := master.Open(nil, sealed[:master.NonceSize()], sealed[master.NonceSize():], wrapAAD)
+	if err != nil {
+		return nil, errors.New("encryption: wrapped key does not open with this master key")
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption: keys must be %d bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -76,3 +77,23 @@ func getValidationMessage(fieldError validator.FieldError) string {
 		return fieldError.Field() + " is invalid"
 	}
 }
+
+// SCIMError is the error body SCIM 2.0 clients expect (RFC 7644 section 3.12).
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func RespondSCIMError(w http.ResponseWriter, status int, scimType, detail string) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(SCIMError{
+		Schemas:  []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,83 @@
+// Package geoip resolves IP addresses to a country and city from a local
+// MaxMind DB file (GeoLite2-City, GeoIP2-City or a compatible database), so
+// no request leaves the server.
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is the result of a lookup. Fields are empty when unknown.
+type Location struct {
+	Country string // ISO 3166-1 alpha-2 code, e.g. "DE"
+	City    string // English city name, e.g. "Berlin"
+}
+
+// String formats the location as "City, CC".
+func (l Location) String() string {
+	switch {
+	case l.City != "" && l.Country != "":
+		return l.City + ", " + l.Country
+	case l.City != "":
+		return l.City
+	default:
+		return l.Country
+	}
+}
+
+// Reader looks up locations in an MMDB file. A nil Reader is valid and
+// resolves nothing, so callers need not check whether GeoIP is configured.
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+// Open opens the database at path. An empty path returns a nil Reader.
+func Open(path string) (*Reader, error) {
+	if path == "" {
+		return nil, nil
+	}
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	return &Reader{db: db}, nil
+}
+
+// record holds the fields read from a City database record.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Lookup returns the location of ip. Private, invalid and unknown addresses
+// return an empty Location.
+func (r *Reader) Lookup(ip string) Location {
+	if r == nil {
+		return Location{}
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsUnspecified() {
+		return Location{}
+	}
+
+	var rec record
+	if err := r.db.Lookup(parsed, &rec); err != nil {
+		return Location{}
+	}
+	return Location{Country: rec.Country.ISOCode, City: rec.City.Names["en"]}
+}
+
+// Close releases the database file.
+func (r *Reader) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,145 @@
+// Package sms sends text messages through a pluggable provider: an HTTP
+// gateway in production, or a local log for development.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider delivers a text message to a phone number in E.164 form.
+type Provider interface {
+	Send(ctx context.Context, to, message string) error
+}
+
+// HTTPProvider posts each message as JSON ({"from", "to", "message"}) to a
+// gateway URL, with an optional bearer token. Most SMS vendors offer such an
+// endpoint directly or through a small relay, so no vendor SDK is needed.
+type HTTPProvider struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+func NewHTTPProvider(url, token, from string) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		token:  token,
+		from:   from,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *HTTPProvider) Send(ctx context.Context, to, message string) error {
+	body, err := json.Marshal(map[string]string{
+		"from":    p.from,
+		"to":      to,
+		"message": message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms gateway request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// LogProvider writes messages to a file, or to standard output, instead of
+// sending them. It is meant for development, where codes are read from the
+// log.
+type LogProvider struct {
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File
+}
+
+// NewLogProvider appends messages to the file at path, or writes them to
+// standard output when path is empty.
+func NewLogProvider(path string) (*LogProvider, error) {
+	if path == "" {
+		return &LogProvider{w: os.Stdout}, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SMS log: %w", err)
+	}
+	return &LogProvider{w: file, file: file}, nil
+}
+
+func (p *LogProvider) Send(ctx context.Context, to, message string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := fmt.Fprintf(p.w, "%s SMS to %s: %s\n", time.Now().UTC().Format(time.RFC3339), to, message)
+	return err
+}
+
+// Close releases the log file, if any.
+func (p *LogProvider) Close() error {
+	if p.file == nil {
+		return nil
+	}
+	return p.file.Close()
+}
+
+// Normalize returns phone in E.164 form ("+4915112345678"), dropping the
+// spaces, dots, dashes and brackets people write numbers with. Numbers must
+// include the country code.
+func Normalize(phone string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", errors.New("phone number contains invalid characters")
+		}
+	}
+
+	normalized := b.String()
+	if !strings.HasPrefix(normalized, "+") {
+		return "", errors.New("phone number must start with + and the country code")
+	}
+	if digits := len(normalized) - 1; digits < 8 || digits > 15 || normalized[1] == '0' {
+		return "", errors.New("phone number is not a valid international number")
+	}
+	return normalized, nil
+}
+
+// Mask hides all but the last four digits of a number, for showing which
+// phone a code was sent to.
+func Mask(phone string) string {
+	if len(phone) <= 4 {
+		return phone
+	}
+	return strings.Repeat("•", 4) + phone[len(phone)-4:]
+}
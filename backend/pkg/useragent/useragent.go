@@ -0,0 +1,109 @@
+// Package useragent extracts the browser, operating system and device type
+// from a User-Agent header. It recognises the common browsers and platforms
+// well enough to label sessions; it is not a full detection database.
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device types reported in Info.DeviceType.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// Info is the parsed form of a User-Agent. Fields are empty when unknown.
+type Info struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	DeviceType     string
+}
+
+// String describes the client as "Browser on OS", e.g. "Chrome on macOS".
+func (i Info) String() string {
+	switch {
+	case i.Browser != "" && i.OS != "":
+		return i.Browser + " on " + i.OS
+	case i.Browser != "":
+		return i.Browser
+	default:
+		return i.OS
+	}
+}
+
+type browserRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Order matters: most browsers also claim to be Safari and Chromium-based
+// ones also claim to be Chrome, so the more specific tokens come first.
+var browserRules = []browserRule{
+	{"Edge", regexp.MustCompile(`(?:Edg|EdgA|EdgiOS|Edge)/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|OPiOS|Opera)/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"Vivaldi", regexp.MustCompile(`Vivaldi/([\d.]+)`)},
+	{"Brave", regexp.MustCompile(`Brave/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+	{"curl", regexp.MustCompile(`^curl/([\d.]+)`)},
+}
+
+var botPattern = regexp.MustCompile(`(?i)bot|crawler|spider|slurp|facebookexternalhit|headless`)
+
+// Parse parses a User-Agent header.
+func Parse(ua string) Info {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return Info{}
+	}
+
+	info := Info{OS: parseOS(ua)}
+	for _, rule := range browserRules {
+		if match := rule.pattern.FindStringSubmatch(ua); match != nil {
+			info.Browser = rule.name
+			info.BrowserVersion = match[1]
+			break
+		}
+	}
+
+	switch {
+	case botPattern.MatchString(ua):
+		info.DeviceType = DeviceBot
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet") ||
+		(strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile")):
+		info.DeviceType = DeviceTablet
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod"):
+		info.DeviceType = DeviceMobile
+	case info.OS != "":
+		info.DeviceType = DeviceDesktop
+	}
+
+	return info
+}
+
+func parseOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad") || strings.Contains(ua, "iPod"):
+		return "iOS"
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "CrOS"):
+		return "ChromeOS"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "Macintosh") || strings.Contains(ua, "Mac OS X"):
+		return "macOS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	default:
+		return ""
+	}
+}
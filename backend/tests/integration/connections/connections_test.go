@@ -0,0 +1,131 @@
+package connections_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestConnections(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "connections.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	settingsRepo := repositories.NewSettingsRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	settingsService := services.NewSettingsService(settingsRepo, userRepo, logger)
+	privacy := services.NewPrivacyService(settingsRepo, userRepo, repositories.NewConnectionRepository(db), logger)
+	messaging := services.NewMessagingService(repositories.NewMessageRepository(db), userRepo, privacy, logger)
+	connections := services.NewConnectionService(repositories.NewConnectionRepository(db), userRepo, privacy,
+		services.NewNotificationService(notificationRepo, logger), logger)
+
+	signup := func(email, name string) *models.User {
+		user, _, err := authService.Signup(ctx, services.SignupRequest{Email: email, Password: "Str0ng!Passw0rd", Name: name})
+		require.NoError(t, err)
+		return user
+	}
+	alice := signup("alice@example.com", "Alice")
+	bob := signup("bob@example.com", "Bob")
+	carol := signup("carol@example.com", "Carol")
+
+	require.NoError(t, settingsService.UpdatePrivacySettings(ctx, bob.ID, map[string]interface{}{
+		"email_visibility": "friends",
+		"allow_messaging":  "friends",
+	}))
+
+	t.Run("friends-only settings admit friends", func(t *testing.T) {
+		profile, err := privacy.Profile(ctx, alice.ID, bob.ID)
+		require.NoError(t, err)
+		assert.Nil(t, profile.Email)
+		assert.False(t, profile.CanMessage)
+
+		request, err := connections.SendRequest(ctx, alice.ID, bob.ID)
+		require.NoError(t, err)
+		_, err = connections.SendRequest(ctx, alice.ID, bob.ID)
+		assert.EqualError(t, err, "friend request already sent")
+
+		incoming, err := connections.ListRequests(ctx, bob.ID, true)
+		require.NoError(t, err)
+		require.Len(t, incoming, 1)
+		assert.Equal(t, alice.ID, incoming[0].User.ID)
+
+		_, err = connections.AcceptRequest(ctx, alice.ID, request.ID)
+		assert.EqualError(t, err, "friend request not found", "only the addressee accepts")
+		_, err = connections.AcceptRequest(ctx, bob.ID, request.ID)
+		require.NoError(t, err)
+
+		profile, err = privacy.Profile(ctx, alice.ID, bob.ID)
+		require.NoError(t, err)
+		assert.True(t, profile.IsFriend)
+		require.NotNil(t, profile.Email)
+		assert.True(t, profile.CanMessage)
+		_, err = messaging.SendMessage(ctx, alice.ID, bob.ID, nil, "hi", nil)
+		assert.NoError(t, err)
+
+		friends, total, err := connections.ListFriends(ctx, bob.ID, 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, friends, 1)
+		assert.Equal(t, alice.ID, friends[0].ID)
+
+		notifications, err := notificationRepo.GetByUserID(ctx, alice.ID, false, 10)
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "Friend request accepted", notifications[0].Title)
+	})
+
+	t.Run("asking back accepts", func(t *testing.T) {
+		_, err := connections.SendRequest(ctx, carol.ID, alice.ID)
+		require.NoError(t, err)
+		connection, err := connections.SendRequest(ctx, alice.ID, carol.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ConnectionAccepted, connection.Status)
+
+		require.NoError(t, connections.RemoveFriend(ctx, alice.ID, carol.ID))
+		assert.EqualError(t, connections.RemoveFriend(ctx, alice.ID, carol.ID), "not friends")
+	})
+
+	t.Run("blocking hides users and ends friendships", func(t *testing.T) {
+		require.NoError(t, connections.Block(ctx, bob.ID, alice.ID))
+
+		_, total, err := connections.ListFriends(ctx, alice.ID, 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, total)
+		_, err = privacy.Profile(ctx, alice.ID, bob.ID)
+		assert.EqualError(t, err, "user not found")
+		_, err = messaging.SendMessage(ctx, alice.ID, bob.ID, nil, "hi", nil)
+		assert.EqualError(t, err, "recipient does not accept messages")
+		_, err = connections.SendRequest(ctx, alice.ID, bob.ID)
+		assert.EqualError(t, err, "cannot send a friend request to this user")
+
+		blocked, err := connections.ListBlocked(ctx, bob.ID)
+		require.NoError(t, err)
+		require.Len(t, blocked, 1)
+		assert.Equal(t, alice.ID, blocked[0].UserID)
+
+		require.NoError(t, connections.Unblock(ctx, bob.ID, alice.ID))
+		assert.EqualError(t, connections.Unblock(ctx, bob.ID, alice.ID), "user is not blocked")
+		_, err = privacy.Profile(ctx, alice.ID, bob.ID)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,154 @@
+package consent_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestConsentLedger(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "consent.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	consents := services.NewConsentService(repositories.NewConsentRepository(db), logService, logger)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	authService.SetConsents(consents)
+	notifications := services.NewNotificationService(repositories.NewNotificationRepository(db), logger)
+	notifications.SetConsents(consents)
+	settingsService := services.NewSettingsService(repositories.NewSettingsRepository(db), userRepo, logger)
+	settingsService.SetConsents(consents)
+
+	ip := "203.0.113.7"
+	signup := func(email, termsVersion string, marketing bool) (*models.User, error) {
+		user, _, err := authService.Signup(ctx, services.SignupRequest{
+			Email: email, Password: "Str0ng!Passw0rd", Name: email,
+			TermsAccepted: true, TermsVersion: termsVersion, MarketingConsent: marketing, IPAddress: &ip,
+		})
+		return user, err
+	}
+
+	admin, err := signup("admin@example.com", "draft", false)
+	require.NoError(t, err, "any terms version is taken before terms are published")
+
+	publish := func(kind, version string, required bool) {
+		_, err := consents.Publish(ctx, admin.ID, services.PublishDocumentRequest{
+			Kind: kind, Version: version, Title: "Terms " + version, Required: required,
+		})
+		require.NoError(t, err)
+	}
+	publish(models.LegalDocumentTerms, "1.0", true)
+
+	t.Run("publishing validates the document", func(t *testing.T) {
+		_, err := consents.Publish(ctx, admin.ID, services.PublishDocumentRequest{Kind: "cookies", Version: "1", Title: "Cookies"})
+		assert.EqualError(t, err, "unknown document kind")
+		_, err = consents.Publish(ctx, admin.ID, services.PublishDocumentRequest{Kind: models.LegalDocumentTerms, Version: "1.0", Title: "Again"})
+		assert.EqualError(t, err, "version already published")
+	})
+
+	user, err := signup("user@example.com", "1.0", true)
+	require.NoError(t, err)
+
+	t.Run("signup records the accepted terms and marketing choice", func(t *testing.T) {
+		_, err := signup("other@example.com", "0.9", false)
+		assert.EqualError(t, err, "unknown terms version")
+		_, _, err = authService.Signup(ctx, services.SignupRequest{Email: "nope@example.com", Password: "Str0ng!Passw0rd", Name: "Nope", TermsVersion: "1.0"})
+		assert.EqualError(t, err, "terms must be accepted")
+
+		status, err := consents.Status(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, status.Pending)
+		assert.True(t, status.Marketing)
+		require.Len(t, status.History, 2)
+		for _, record := range status.History {
+			assert.Equal(t, models.ConsentSourceSignup, record.Source)
+			assert.Equal(t, ip, *record.IPAddress)
+		}
+
+		// The admin accepted a version that was never published
+		assert.True(t, authService.ConsentRequired(ctx, admin))
+		assert.False(t, authService.ConsentRequired(ctx, user))
+	})
+
+	t.Run("a new required version asks users to accept it again", func(t *testing.T) {
+		publish(models.LegalDocumentTerms, "1.1", false)
+		assert.False(t, authService.ConsentRequired(ctx, user), "optional versions do not prompt")
+
+		publish(models.LegalDocumentTerms, "2.0", true)
+		pending, err := consents.Pending(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, "2.0", pending[0].Version)
+		assert.EqualError(t, consents.CheckTerms(ctx, "1.1"), "terms version is out of date")
+
+		_, err = consents.Accept(ctx, user.ID, models.LegalDocumentTerms, "1.1", services.ClientInfo{IPAddress: &ip})
+		assert.EqualError(t, err, "only the current version can be accepted")
+		_, err = consents.Accept(ctx, user.ID, models.LegalDocumentTerms, "2.0", services.ClientInfo{IPAddress: &ip})
+		require.NoError(t, err)
+		assert.False(t, authService.ConsentRequired(ctx, user))
+
+		docs, err := consents.Documents(ctx)
+		require.NoError(t, err)
+		require.Len(t, docs, 3)
+		assert.Equal(t, "2.0", docs[0].Version)
+		assert.Equal(t, int64(1), *docs[0].Acceptances)
+	})
+
+	t.Run("promotions need current marketing consent", func(t *testing.T) {
+		_, err := notifications.CreateNotification(ctx, user.ID, models.NotificationTypePromotion, "Sale", "Half off", nil, nil)
+		require.NoError(t, err)
+
+		record, err := consents.SetMarketing(ctx, user.ID, false, services.ClientInfo{IPAddress: &ip})
+		require.NoError(t, err)
+		again, err := consents.SetMarketing(ctx, user.ID, false, services.ClientInfo{})
+		require.NoError(t, err)
+		assert.Equal(t, record.ID, again.ID, "an unchanged choice is not recorded again")
+
+		_, err = notifications.CreateNotification(ctx, user.ID, models.NotificationTypePromotion, "Sale", "Half off", nil, nil)
+		assert.EqualError(t, err, "user has not opted in to marketing")
+		_, err = notifications.CreateNotification(ctx, user.ID, "system", "Maintenance", "Tonight", nil, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("the promotions setting follows the marketing choice", func(t *testing.T) {
+		settings, err := settingsService.GetSettings(ctx, user.ID)
+		require.NoError(t, err)
+		assert.False(t, settings.NotificationPromotions)
+
+		require.NoError(t, settingsService.UpdateNotificationSettings(ctx, user.ID,
+			map[string]interface{}{"notification_promotions": true}, services.ClientInfo{IPAddress: &ip}))
+		status, err := consents.Status(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, status.Marketing, "turning the setting on is recorded as opting in")
+		require.NotNil(t, status.History[0].IPAddress)
+		assert.Equal(t, ip, *status.History[0].IPAddress)
+		_, err = notifications.CreateNotification(ctx, user.ID, models.NotificationTypePromotion, "Sale", "Half off", nil, nil)
+		assert.NoError(t, err)
+
+		_, err = consents.SetMarketing(ctx, user.ID, false, services.ClientInfo{})
+		require.NoError(t, err)
+		settings, err = settingsService.GetSettings(ctx, user.ID)
+		require.NoError(t, err)
+		assert.False(t, settings.NotificationPromotions, "opting out turns the setting off")
+	})
+}
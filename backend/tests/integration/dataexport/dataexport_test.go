@@ -0,0 +1,160 @@
+package dataexport_test
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestDataExport(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := t.TempDir()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(dir, "export.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	settingsRepo := repositories.NewSettingsRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	exportRepo := repositories.NewDataExportRepository(db)
+	fileRepo := repositories.NewFileRepository(db)
+	notifications := services.NewNotificationService(notificationRepo, logger)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	privacy := services.NewPrivacyService(settingsRepo, userRepo, repositories.NewConnectionRepository(db), logger)
+	messaging := services.NewMessagingService(repositories.NewMessageRepository(db), userRepo, privacy, logger)
+	newExports := func(retention time.Duration) *services.DataExportService {
+		return services.NewDataExportService(config.DataExportConfig{
+			Dir:         filepath.Join(dir, "exports"),
+			Retention:   retention,
+			DownloadURL: "http://localhost:8080/v1/exports/download",
+		}, exportRepo, userRepo, fileRepo, notifications, logService, logger)
+	}
+	exports := newExports(time.Hour)
+
+	signup := func(email, name string) *models.User {
+		user, _, err := authService.Signup(ctx, services.SignupRequest{Email: email, Password: "Str0ng!Passw0rd", Name: name})
+		require.NoError(t, err)
+		return user
+	}
+	alice := signup("alice@example.com", "Alice")
+	bob := signup("bob@example.com", "Bob")
+
+	_, err = messaging.SendMessage(ctx, alice.ID, bob.ID, nil, "hello bob", nil)
+	require.NoError(t, err)
+	upload := filepath.Join(dir, "avatar.png")
+	require.NoError(t, os.WriteFile(upload, []byte("png bytes"), 0600))
+	require.NoError(t, fileRepo.Create(ctx, &models.UploadedFile{
+		ID: uuid.New(), UserID: alice.ID, OriginalName: "avatar.png", StoredName: "avatar.png",
+		Path: upload, Size: 9, CreatedAt: time.Now(),
+	}))
+
+	// waitReady waits for the background build and returns the download
+	// token from the notification sent when it finished
+	waitReady := func(userID uuid.UUID) string {
+		require.Eventually(t, func() bool {
+			list, err := exports.List(ctx, userID)
+			return err == nil && len(list) > 0 && list[0].Status != models.DataExportPending
+		}, 5*time.Second, 20*time.Millisecond)
+		list, err := exports.List(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, models.DataExportReady, list[0].Status)
+
+		sent, err := notificationRepo.GetByUserID(ctx, userID, false, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, sent)
+		require.NotNil(t, sent[0].Link)
+		link, err := url.Parse(*sent[0].Link)
+		require.NoError(t, err)
+		return link.Query().Get("token")
+	}
+
+	t.Run("archive holds records and files", func(t *testing.T) {
+		_, err := exports.Request(ctx, alice.ID)
+		require.NoError(t, err)
+		token := waitReady(alice.ID)
+
+		_, _, err = exports.Download(ctx, "wrong")
+		assert.EqualError(t, err, "invalid or expired download link")
+		export, path, err := exports.Download(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, export.UserID)
+
+		archive, err := zip.OpenReader(path)
+		require.NoError(t, err)
+		defer archive.Close()
+		contents := map[string]string{}
+		for _, f := range archive.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			b, err := io.ReadAll(rc)
+			rc.Close()
+			require.NoError(t, err)
+			contents[f.Name] = string(b)
+		}
+
+		var profile []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(contents["profile.json"]), &profile))
+		require.Len(t, profile, 1)
+		assert.Equal(t, "alice@example.com", profile[0]["email"])
+		assert.NotContains(t, profile[0], "password_hash")
+
+		var messages []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(contents["messages.json"]), &messages))
+		require.Len(t, messages, 1)
+		assert.Equal(t, "hello bob", messages[0]["content"])
+
+		assert.Contains(t, contents, "sessions.json")
+		assert.Contains(t, contents, "activity_logs.json")
+		found := false
+		for name, body := range contents {
+			if filepath.Dir(name) == "files" {
+				found = true
+				assert.Equal(t, "png bytes", body)
+			}
+		}
+		assert.True(t, found, "uploaded file is included")
+	})
+
+	t.Run("archives expire", func(t *testing.T) {
+		expiring := newExports(-time.Second)
+		_, err := expiring.Request(ctx, bob.ID)
+		require.NoError(t, err)
+		token := waitReady(bob.ID)
+
+		_, _, err = expiring.Download(ctx, token)
+		assert.EqualError(t, err, "invalid or expired download link")
+
+		list, err := expiring.List(ctx, bob.ID)
+		require.NoError(t, err)
+		purged, err := expiring.PurgeExpired(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		_, err = os.Stat(filepath.Join(dir, "exports", list[0].ID.String()+".zip"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
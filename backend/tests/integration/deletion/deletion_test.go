@@ -0,0 +1,245 @@
+package deletion_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+const password = "Str0ng!Passw0rd"
+
+func TestAccountDeletion(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := t.TempDir()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(dir, "deletion.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	orgRepo := repositories.NewOrganizationRepository(db)
+	fileRepo := repositories.NewFileRepository(db)
+	crudRepo := repositories.NewCustomCRUDRepository(db)
+	logRepo := repositories.NewActivityLogRepository(db)
+	logService := services.NewActivityLogService(logRepo, logger)
+	authService := services.NewAuthService(userRepo, sessionRepo, repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	privacy := services.NewPrivacyService(repositories.NewSettingsRepository(db), userRepo, repositories.NewConnectionRepository(db), logger)
+	messaging := services.NewMessagingService(repositories.NewMessageRepository(db), userRepo, privacy, logger)
+	notifications := services.NewNotificationService(repositories.NewNotificationRepository(db), logger)
+
+	deletions, err := services.NewAccountDeletionService(config.AccountDeletionConfig{GracePeriod: time.Hour},
+		repositories.NewAccountDeletionRepository(db), userRepo, sessionRepo, orgRepo, fileRepo,
+		repositories.NewDataExportRepository(db), logService, logger)
+	require.NoError(t, err)
+	authService.SetAccountDeletion(deletions)
+
+	signup := func(email string) *models.User {
+		user, _, err := authService.Signup(ctx, services.SignupRequest{Email: email, Password: password, Name: email})
+		require.NoError(t, err)
+		return user
+	}
+	count := func(query string, args ...interface{}) int {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, query, args...).Scan(&n))
+		return n
+	}
+
+	t.Run("signing in cancels a deletion the user asked for", func(t *testing.T) {
+		user := signup("cancel@example.com")
+		deletion, err := deletions.Request(ctx, user.ID, models.AccountDeletionBySelf, nil)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deletion.PurgeAt, time.Minute)
+
+		_, err = deletions.Request(ctx, user.ID, models.AccountDeletionBySelf, nil)
+		assert.EqualError(t, err, "account deletion already requested")
+		assert.Zero(t, count(`SELECT COUNT(*) FROM sessions WHERE user_id = ? AND revoked_at IS NULL`, user.ID.String()))
+
+		_, session, _, err := authService.Login(ctx, services.LoginRequest{Email: user.Email, Password: password})
+		require.NoError(t, err)
+		assert.NotNil(t, session)
+		got, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.Status, got.Status, "the status before the request is restored")
+		pending, err := deletions.Get(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, pending)
+	})
+
+	t.Run("admin deletions are not cancelled by signing in", func(t *testing.T) {
+		user := signup("removed@example.com")
+		adminID := uuid.New()
+		_, err := deletions.Request(ctx, user.ID, models.AccountDeletionByAdmin, &adminID)
+		require.NoError(t, err)
+
+		_, _, _, err = authService.Login(ctx, services.LoginRequest{Email: user.Email, Password: password})
+		assert.EqualError(t, err, "account is not active")
+	})
+
+	t.Run("admins cannot delete more privileged accounts or the last admin", func(t *testing.T) {
+		permissions := services.NewPermissionService(repositories.NewRoleRepository(db), repositories.NewPermissionRepository(db),
+			userRepo, logService, logger)
+		deletions.SetPermissions(permissions)
+		defer deletions.SetPermissions(nil)
+
+		withRole := func(email, role string) *models.User {
+			user := signup(email)
+			user.Role, user.Status = role, "active"
+			require.NoError(t, userRepo.Update(ctx, user))
+			return user
+		}
+		root := withRole("root@example.com", "admin")
+		_, err := permissions.CreateRole(ctx, root.ID, services.RoleRequest{
+			Name: "support", Permissions: []string{models.PermUsersRead, models.PermUsersWrite},
+		})
+		require.NoError(t, err)
+		_, err = permissions.CreateRole(ctx, root.ID, services.RoleRequest{
+			Name: "superuser", Permissions: models.AllPermissions,
+		})
+		require.NoError(t, err)
+		support := withRole("support@example.com", "support")
+		superuser := withRole("superuser@example.com", "superuser")
+
+		_, err = deletions.Request(ctx, root.ID, models.AccountDeletionByAdmin, &support.ID)
+		assert.EqualError(t, err, "cannot manage an account with permissions you do not hold")
+		_, err = deletions.Request(ctx, superuser.ID, models.AccountDeletionByAdmin, &support.ID)
+		assert.EqualError(t, err, "cannot manage an account with permissions you do not hold")
+
+		_, err = deletions.Request(ctx, root.ID, models.AccountDeletionByAdmin, &superuser.ID)
+		assert.EqualError(t, err, "cannot delete the last admin")
+		got, err := userRepo.GetByID(ctx, root.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "active", got.Status)
+
+		member := signup("member@example.com")
+		_, err = deletions.Request(ctx, member.ID, models.AccountDeletionByAdmin, &support.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("purge removes the account and its records", func(t *testing.T) {
+		purging, err := services.NewAccountDeletionService(config.AccountDeletionConfig{},
+			repositories.NewAccountDeletionRepository(db), userRepo, sessionRepo, orgRepo, fileRepo,
+			repositories.NewDataExportRepository(db), logService, logger)
+		require.NoError(t, err)
+
+		alice := signup("alice@example.com")
+		bob := signup("bob@example.com")
+		_, err = messaging.SendMessage(ctx, alice.ID, bob.ID, nil, "hello bob", nil)
+		require.NoError(t, err)
+		_, err = notifications.CreateNotification(ctx, alice.ID, "system", "Hi", "Welcome", nil, nil)
+		require.NoError(t, err)
+
+		upload := filepath.Join(dir, "avatar.png")
+		require.NoError(t, os.WriteFile(upload, []byte("png bytes"), 0600))
+		require.NoError(t, fileRepo.Create(ctx, &models.UploadedFile{
+			ID: uuid.New(), UserID: alice.ID, OriginalName: "avatar.png", StoredName: "avatar.png",
+			Path: upload, Size: 9, CreatedAt: time.Now(),
+		}))
+
+		// Alice owns an organization Bob is a member of, with a CRUD entity
+		// in it, and has a private entity of her own
+		now := time.Now()
+		org := &models.Organization{ID: uuid.New(), Name: "Acme", CreatedBy: &alice.ID, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, orgRepo.Create(ctx, org, &models.OrganizationMember{
+			OrganizationID: org.ID, UserID: alice.ID, Role: models.OrganizationRoleOwner, CreatedAt: now,
+		}))
+		require.NoError(t, orgRepo.AddMember(ctx, &models.OrganizationMember{
+			OrganizationID: org.ID, UserID: bob.ID, Role: models.OrganizationRoleMember, CreatedAt: now,
+		}))
+		entity := func(orgID *uuid.UUID, name string) *models.CustomCRUDData {
+			e := &models.CustomCRUDEntity{ID: uuid.New(), OrganizationID: orgID, CreatedBy: &alice.ID, EntityName: name,
+				DisplayName: name, Schema: `{}`, IsActive: true, CreatedAt: now, UpdatedAt: now}
+			require.NoError(t, crudRepo.CreateEntity(ctx, e))
+			d := &models.CustomCRUDData{ID: uuid.New(), EntityID: e.ID, Data: `{}`, CreatedBy: &alice.ID, CreatedAt: now, UpdatedAt: now}
+			require.NoError(t, crudRepo.CreateData(ctx, d))
+			return d
+		}
+		shared := entity(&org.ID, "orders")
+		private := entity(nil, "notes")
+
+		// She also added a dashboard item and a webhook to the organization,
+		// next to a dashboard item and a webhook of her own
+		dashboardRepo := repositories.NewDashboardRepository(db)
+		webhookRepo := repositories.NewWebhookRepository(db)
+		item := func(orgID *uuid.UUID, title string) *models.DashboardItem {
+			i := &models.DashboardItem{ID: uuid.New(), UserID: alice.ID, OrganizationID: orgID, Title: title,
+				Status: "active", CreatedAt: now, UpdatedAt: now}
+			require.NoError(t, dashboardRepo.Create(ctx, i))
+			return i
+		}
+		webhook := func(orgID *uuid.UUID, name string) *models.WebhookSubscription {
+			w := &models.WebhookSubscription{ID: uuid.New(), UserID: &alice.ID, OrganizationID: orgID, SubscriptionName: name,
+				WebhookURL: "https://hooks.example.com/" + name, WebhookSecret: "secret", IsActive: true, CreatedAt: now, UpdatedAt: now}
+			require.NoError(t, webhookRepo.CreateSubscription(ctx, w))
+			return w
+		}
+		sharedItem := item(&org.ID, "Roadmap")
+		privateItem := item(nil, "Groceries")
+		sharedWebhook := webhook(&org.ID, "team")
+		privateWebhook := webhook(nil, "mine")
+
+		_, err = purging.Request(ctx, alice.ID, models.AccountDeletionBySelf, nil)
+		require.NoError(t, err)
+		purged, err := purging.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		assert.Zero(t, count(`SELECT COUNT(*) FROM users WHERE id = ?`, alice.ID.String()))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM messages WHERE sender_id = ? OR recipient_id = ?`, alice.ID.String(), alice.ID.String()))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM notifications WHERE user_id = ?`, alice.ID.String()))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM sessions WHERE user_id = ?`, alice.ID.String()))
+		_, err = os.Stat(upload)
+		assert.True(t, os.IsNotExist(err), "uploaded file is removed")
+
+		// The organization keeps its records, without Alice's name on them
+		kept, err := crudRepo.GetDataByID(ctx, shared.ID, &models.Tenant{UserID: bob.ID, OrganizationID: &org.ID})
+		require.NoError(t, err)
+		assert.Nil(t, kept.CreatedBy)
+		assert.Zero(t, count(`SELECT COUNT(*) FROM custom_crud_data WHERE id = ?`, private.ID.String()))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM dashboard_items WHERE id = ? AND user_id = ?`, sharedItem.ID.String(), bob.ID.String()),
+			"the organization's dashboard item passes to its new owner")
+		assert.Zero(t, count(`SELECT COUNT(*) FROM dashboard_items WHERE id = ?`, privateItem.ID.String()))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM webhook_subscriptions WHERE id = ? AND user_id IS NULL`, sharedWebhook.ID.String()),
+			"the organization's webhook is kept without an owner")
+		assert.Zero(t, count(`SELECT COUNT(*) FROM webhook_subscriptions WHERE id = ?`, privateWebhook.ID.String()))
+		member, err := orgRepo.GetMember(ctx, org.ID, bob.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.OrganizationRoleOwner, member.Role)
+
+		logs, err := logRepo.List(ctx, 50)
+		require.NoError(t, err)
+		var receipt *models.ActivityLog
+		for _, log := range logs {
+			if log.Action == "account_purged" {
+				receipt = log
+			}
+		}
+		require.NotNil(t, receipt, "purge writes a receipt")
+		assert.Equal(t, alice.ID.String(), *receipt.TargetID)
+		var meta map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(*receipt.Metadata), &meta))
+		assert.NotContains(t, *receipt.Metadata, "alice@example.com")
+		assert.EqualValues(t, 1, meta["files_removed"])
+		assert.EqualValues(t, 1, meta["deleted"].(map[string]interface{})["messages"])
+	})
+}
@@ -0,0 +1,175 @@
+package devices_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestDeviceManagement(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "devices.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	deviceRepo := repositories.NewDeviceRepository(db)
+	authService := services.NewAuthService(userRepo, sessionRepo, deviceRepo, "test-secret", time.Minute, time.Hour, logger)
+	notificationService := services.NewNotificationService(repositories.NewNotificationRepository(db), logger)
+	deviceService := services.NewDeviceService(
+		config.DeviceConfig{TrustDuration: time.Hour}, deviceRepo, authService, notificationService,
+		services.NewEmailService(services.EmailConfig{}, logger),
+		services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger), logger,
+	)
+	authService.OnNewDevice(deviceService.NotifyNewDevice)
+
+	signup := func(email string) *models.User {
+		user, _, err := authService.Signup(ctx, services.SignupRequest{Email: email, Password: "Str0ng!Passw0rd", Name: "Member"})
+		require.NoError(t, err)
+		return user
+	}
+	login := func(email, deviceID string) (*models.Session, bool) {
+		ip := "203.0.113.7"
+		userAgent := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+		_, session, isNewDevice, err := authService.Login(ctx, services.LoginRequest{
+			Email: email, Password: "Str0ng!Passw0rd", DeviceID: &deviceID, IPAddress: &ip, UserAgent: &userAgent,
+		})
+		require.NoError(t, err)
+		return session, isNewDevice
+	}
+	securityAlerts := func(userID uuid.UUID) int {
+		notifications, err := notificationService.GetNotifications(ctx, userID, false, 50)
+		require.NoError(t, err)
+		count := 0
+		for _, n := range notifications {
+			if n.Type == "security" {
+				count++
+			}
+		}
+		return count
+	}
+
+	user := signup("member@example.com")
+	laptopSession, isNew := login(user.Email, "laptop")
+	assert.True(t, isNew)
+	phoneSession, _ := login(user.Email, "phone")
+	_, isNew = login(user.Email, "laptop")
+	assert.False(t, isNew)
+	assert.Equal(t, 2, securityAlerts(user.ID), "one alert per new device")
+
+	devices, err := deviceService.ListDevices(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	var laptop *models.Device
+	for _, d := range devices {
+		if d.DeviceID == "laptop" {
+			laptop = d
+		}
+	}
+	require.NotNil(t, laptop)
+	assert.Equal(t, "203.0.113.7", *laptop.IPAddress)
+	require.NotNil(t, laptop.Browser)
+	assert.Equal(t, "Chrome", *laptop.Browser)
+	assert.Equal(t, "macOS", *laptop.OS)
+	assert.Equal(t, "desktop", *laptop.DeviceType)
+	assert.Nil(t, laptop.LocationCountry, "no GeoIP database configured")
+
+	stored, err := repositories.NewSessionRepository(db).GetByID(ctx, laptopSession.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Browser)
+	assert.Equal(t, "Chrome", *stored.Browser)
+	assert.Equal(t, "macOS", *stored.OS)
+
+	t.Run("accounts sharing a browser each get the device", func(t *testing.T) {
+		other := signup("other@example.com")
+		_, isNew := login(other.Email, "laptop")
+		assert.True(t, isNew)
+		otherDevices, err := deviceService.ListDevices(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Len(t, otherDevices, 1)
+
+		_, err = deviceService.RenameDevice(ctx, other.ID, laptop.ID, "Mine now")
+		assert.EqualError(t, err, "device not found")
+		assert.EqualError(t, deviceService.RemoveDevice(ctx, other.ID, laptop.ID), "device not found")
+	})
+
+	// Devices are trusted by passing the second factor on them; see the
+	// twofactor tests. Here the trust is written directly.
+	trust := func(deviceID uuid.UUID, trustedAt time.Time) {
+		device, err := deviceRepo.GetByID(ctx, deviceID)
+		require.NoError(t, err)
+		hash := "trust-token-hash"
+		device.IsTrusted, device.TrustedAt, device.TrustTokenHash = true, &trustedAt, &hash
+		require.NoError(t, deviceRepo.Update(ctx, device))
+	}
+
+	t.Run("rename and untrust", func(t *testing.T) {
+		renamed, err := deviceService.RenameDevice(ctx, user.ID, laptop.ID, "  Work laptop ")
+		require.NoError(t, err)
+		assert.Equal(t, "Work laptop", *renamed.DeviceName)
+
+		trust(laptop.ID, time.Now())
+		devices, err := deviceService.ListDevices(ctx, user.ID)
+		require.NoError(t, err)
+		for _, d := range devices {
+			if d.ID == laptop.ID {
+				assert.True(t, deviceService.IsTrusted(d))
+				require.NotNil(t, d.TrustExpiresAt)
+				assert.WithinDuration(t, time.Now().Add(time.Hour), *d.TrustExpiresAt, time.Minute)
+			}
+		}
+
+		untrusted, err := deviceService.Untrust(ctx, user.ID, laptop.ID)
+		require.NoError(t, err)
+		assert.False(t, untrusted.IsTrusted)
+		stored, err := deviceRepo.GetByID(ctx, laptop.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored.TrustTokenHash, "the trust token is forgotten")
+	})
+
+	t.Run("trust lapses after the trust period", func(t *testing.T) {
+		trust(laptop.ID, time.Now().Add(-2*time.Hour))
+
+		devices, err := deviceService.ListDevices(ctx, user.ID)
+		require.NoError(t, err)
+		for _, d := range devices {
+			assert.False(t, d.IsTrusted)
+			assert.Nil(t, d.TrustExpiresAt)
+		}
+	})
+
+	t.Run("removing a device signs it out", func(t *testing.T) {
+		require.NoError(t, deviceService.RemoveDevice(ctx, user.ID, laptop.ID))
+
+		sessions, err := sessionRepo.GetByUserID(ctx, user.ID)
+		require.NoError(t, err)
+		var active []uuid.UUID
+		for _, s := range sessions {
+			active = append(active, s.ID)
+		}
+		assert.NotContains(t, active, laptopSession.ID)
+		assert.Contains(t, active, phoneSession.ID)
+
+		_, isNew := login(user.Email, "laptop")
+		assert.True(t, isNew, "a removed device is new again")
+	})
+}
@@ -0,0 +1,151 @@
+package emailchange_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestEmailChange(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "emailchange.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	changeRepo := repositories.NewEmailChangeRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	authService := services.NewAuthService(userRepo, sessionRepo, repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	emailChangeService := services.NewEmailChangeService(
+		config.EmailChangeConfig{LinkURL: "http://localhost:8080/", TokenTTL: time.Hour, RevertWindow: 24 * time.Hour},
+		changeRepo, userRepo, authService, services.NewEmailService(services.EmailConfig{}, logger), logService, logger,
+	)
+
+	user, current, err := authService.Signup(ctx, services.SignupRequest{
+		Email: "member@example.com", Password: "Str0ng!Passw0rd", Name: "Member",
+	})
+	require.NoError(t, err)
+	_, _, err = authService.Signup(ctx, services.SignupRequest{
+		Email: "taken@example.com", Password: "Str0ng!Passw0rd", Name: "Other",
+	})
+	require.NoError(t, err)
+	_, other, _, err := authService.Login(ctx, services.LoginRequest{Email: user.Email, Password: "Str0ng!Passw0rd"})
+	require.NoError(t, err)
+
+	active := func(sessionID uuid.UUID) bool {
+		sessions, err := sessionRepo.GetByUserID(ctx, user.ID)
+		require.NoError(t, err)
+		for _, session := range sessions {
+			if session.ID == sessionID {
+				return session.IsActive
+			}
+		}
+		return false
+	}
+
+	// issue stores a request with known tokens, standing in for the emails
+	issue := func(newEmail, confirmToken, revertToken string, expiresAt time.Time) {
+		stored, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NoError(t, changeRepo.Create(ctx, &models.EmailChangeRequest{
+			ID: uuid.New(), UserID: user.ID, OldEmail: stored.Email, NewEmail: newEmail,
+			ConfirmTokenHash: hash(confirmToken), RevertTokenHash: hash(revertToken), SessionID: &current.ID,
+			ExpiresAt: expiresAt, RevertExpiresAt: expiresAt.Add(24 * time.Hour), CreatedAt: time.Now(),
+		}))
+	}
+
+	t.Run("requests need the password and a free address", func(t *testing.T) {
+		_, err := emailChangeService.RequestChange(ctx, user.ID, current.ID, "new@example.com", "wrong", nil)
+		assert.EqualError(t, err, "invalid credentials")
+		_, err = emailChangeService.RequestChange(ctx, user.ID, current.ID, "Member@example.com", "Str0ng!Passw0rd", nil)
+		assert.EqualError(t, err, "new email is the same as the current one")
+		_, err = emailChangeService.RequestChange(ctx, user.ID, current.ID, "taken@example.com", "Str0ng!Passw0rd", nil)
+		assert.EqualError(t, err, "email already in use")
+
+		request, err := emailChangeService.RequestChange(ctx, user.ID, current.ID, "new@example.com", "Str0ng!Passw0rd", nil)
+		require.NoError(t, err)
+		pending, err := emailChangeService.Pending(ctx, user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, pending)
+		assert.Equal(t, request.ID, pending.ID)
+
+		stored, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "member@example.com", stored.Email, "the address only changes once confirmed")
+	})
+
+	t.Run("a new request replaces the pending one", func(t *testing.T) {
+		issue("first@example.com", "confirm-first", "revert-first", time.Now().Add(time.Hour))
+		issue("second@example.com", "confirm-second", "revert-second", time.Now().Add(time.Hour))
+		_, err := emailChangeService.RequestChange(ctx, user.ID, current.ID, "third@example.com", "Str0ng!Passw0rd", nil)
+		require.NoError(t, err)
+
+		_, err = emailChangeService.Confirm(ctx, "confirm-first")
+		assert.EqualError(t, err, "invalid or expired link")
+		require.NoError(t, emailChangeService.Cancel(ctx, user.ID))
+	})
+
+	t.Run("expired links are rejected", func(t *testing.T) {
+		issue("late@example.com", "confirm-expired", "revert-expired", time.Now().Add(-time.Minute))
+		_, err := emailChangeService.Confirm(ctx, "confirm-expired")
+		assert.EqualError(t, err, "invalid or expired link")
+	})
+
+	t.Run("confirming swaps the address and ends other sessions", func(t *testing.T) {
+		issue("changed@example.com", "confirm-ok", "revert-ok", time.Now().Add(time.Hour))
+
+		changed, err := emailChangeService.Confirm(ctx, "confirm-ok")
+		require.NoError(t, err)
+		assert.Equal(t, "changed@example.com", changed.Email)
+
+		stored, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "changed@example.com", stored.Email)
+		assert.True(t, stored.EmailVerified)
+
+		assert.True(t, active(current.ID), "the session that asked for the change stays signed in")
+		assert.False(t, active(other.ID))
+
+		_, err = emailChangeService.Confirm(ctx, "confirm-ok")
+		assert.EqualError(t, err, "invalid or expired link", "links work once")
+	})
+
+	t.Run("the old address can undo a confirmed change", func(t *testing.T) {
+		require.NoError(t, emailChangeService.Revert(ctx, "revert-ok"))
+
+		stored, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "member@example.com", stored.Email)
+
+		assert.False(t, active(current.ID), "reverting signs out every session")
+
+		assert.EqualError(t, emailChangeService.Revert(ctx, "revert-ok"), "invalid or expired link")
+	})
+}
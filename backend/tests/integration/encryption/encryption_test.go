@@ -0,0 +1,156 @@
+package encryption_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/encryption"
+)
+
+func TestFieldEncryption(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := t.TempDir()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(dir, "encryption.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	settingsRepo := repositories.NewSettingsRepository(db)
+	encryptionRepo := repositories.NewEncryptionRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+
+	newUser := func(email string, phone *string) uuid.UUID {
+		now := time.Now()
+		user := &models.User{ID: uuid.New(), Email: email, Name: email, Phone: phone, Role: "user", Status: "active", CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, userRepo.Create(ctx, user))
+		return user.ID
+	}
+	stored := func(query string, args ...interface{}) string {
+		var value string
+		require.NoError(t, db.QueryRowContext(ctx, query, args...).Scan(&value))
+		return value
+	}
+	phone := func(s string) *string { return &s }
+
+	// Stored before encryption was turned on
+	legacyID := newUser("legacy@example.com", phone("+15550000001"))
+
+	master, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	cfg := config.EncryptionConfig{MasterKey: encryption.EncodeKey(master), ReencryptBatchSize: 1}
+	keyring, err := services.LoadKeyring(ctx, cfg, false, encryptionRepo, logger)
+	require.NoError(t, err)
+	db.SetKeyring(keyring)
+	service := services.NewEncryptionService(cfg, keyring, encryptionRepo, logService, logger)
+
+	t.Run("values are sealed at rest and read transparently", func(t *testing.T) {
+		userID := newUser("sealed@example.com", phone("+15550000002"))
+		assert.True(t, encryption.IsSealed(stored(`SELECT phone FROM users WHERE id = ?`, userID.String())))
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "+15550000002", *user.Phone)
+
+		secret := "JBSWY3DPEHPK3PXP"
+		require.NoError(t, settingsRepo.Create(ctx, &models.ComprehensiveSettings{
+			UserID: userID, TwoFactorSecret: &secret, ProfileVisibility: "public", EmailVisibility: "private",
+			PhoneVisibility: "private", AllowMessaging: "everyone", Theme: "light", Language: "en", Timezone: "UTC",
+		}))
+		assert.True(t, encryption.IsSealed(stored(`SELECT two_factor_secret FROM user_settings_comprehensive WHERE user_id = ?`, userID.String())))
+		settings, err := settingsRepo.GetByUserID(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, secret, *settings.TwoFactorSecret)
+	})
+
+	t.Run("users are found by phone number through the blind index", func(t *testing.T) {
+		users, err := userRepo.List(ctx, "+1 555 000 0002")
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "sealed@example.com", users[0].Email)
+	})
+
+	t.Run("plaintext values are encrypted by re-encryption", func(t *testing.T) {
+		assert.Equal(t, "+15550000001", stored(`SELECT phone FROM users WHERE id = ?`, legacyID.String()))
+		require.NoError(t, service.Reencrypt(ctx))
+		assert.True(t, encryption.IsSealed(stored(`SELECT phone FROM users WHERE id = ?`, legacyID.String())))
+
+		users, err := userRepo.List(ctx, "+15550000001")
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, legacyID, users[0].ID)
+	})
+
+	t.Run("rotation re-encrypts values and destroys the old key", func(t *testing.T) {
+		oldKey := keyring.ActiveKeyID()
+		adminID := newUser("admin@example.com", nil)
+		status, err := service.Rotate(ctx, adminID)
+		require.NoError(t, err)
+		assert.NotEqual(t, oldKey, status.ActiveKeyID)
+
+		// Re-encryption runs in the background; the old key goes once
+		// nothing is sealed with it
+		require.Eventually(t, func() bool {
+			status, err = service.Status(ctx)
+			if err != nil || status.Reencrypting {
+				return false
+			}
+			for _, key := range status.Keys {
+				if key.ID == oldKey {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 20*time.Millisecond)
+
+		for _, field := range status.Fields {
+			assert.Zero(t, field.Plaintext, field.Field)
+			for id := range field.ByKey {
+				assert.Equal(t, status.ActiveKeyID, id, field.Field)
+			}
+		}
+	})
+
+	t.Run("a new master key rewraps the data keys", func(t *testing.T) {
+		newMaster, err := encryption.GenerateKey()
+		require.NoError(t, err)
+
+		_, err = services.LoadKeyring(ctx, config.EncryptionConfig{MasterKey: encryption.EncodeKey(newMaster)}, false, encryptionRepo, logger)
+		assert.Error(t, err, "keys wrapped by an unknown master key must not load")
+
+		rotated, err := services.LoadKeyring(ctx, config.EncryptionConfig{
+			MasterKey:          encryption.EncodeKey(newMaster),
+			PreviousMasterKeys: []string{encryption.EncodeKey(master)},
+		}, false, encryptionRepo, logger)
+		require.NoError(t, err)
+		db.SetKeyring(rotated)
+
+		keys, err := encryptionRepo.ListKeys(ctx)
+		require.NoError(t, err)
+		for _, key := range keys {
+			assert.Equal(t, encryption.MasterKeyID(newMaster), key.MasterKeyID)
+		}
+		user, err := userRepo.GetByID(ctx, legacyID)
+		require.NoError(t, err)
+		assert.Equal(t, "+15550000001", *user.Phone)
+		assert.False(t, strings.HasPrefix(*user.Phone, "enc:"))
+	})
+}
@@ -0,0 +1,193 @@
+package invitations_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInvitations(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "invitations.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	invitationRepo := repositories.NewUserInvitationRepository(db)
+	logRepo := repositories.NewActivityLogRepository(db)
+	logService := services.NewActivityLogService(logRepo, logger)
+	settings := services.NewSystemSettingsService(repositories.NewSystemSettingsRepository(db), logService, logger)
+	policy := services.NewPasswordPolicyService(settings, userRepo, repositories.NewPasswordHistoryRepository(db), nil, logService, logger)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	invitationService := services.NewInvitationService(
+		config.InvitationConfig{LinkURL: "http://localhost:8080/", TTL: time.Hour},
+		invitationRepo, userRepo, repositories.NewRoleRepository(db), authService, policy,
+		services.NewEmailService(services.EmailConfig{}, logger), logService, logger,
+	)
+
+	adminID := uuid.New()
+	require.NoError(t, userRepo.Create(ctx, &models.User{
+		ID: adminID, Email: "admin@example.com", Name: "Admin", Status: "active", Role: "admin",
+		PasswordChangedAt: time.Now(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}))
+
+	// issue stores an invitation with a known token, standing in for the email
+	issue := func(email, role, token string, expiresAt time.Time) *models.UserInvitation {
+		invitation := &models.UserInvitation{
+			ID: uuid.New(), Email: email, Role: role, TokenHash: hash(token), InvitedBy: &adminID,
+			ExpiresAt: expiresAt, SentAt: time.Now(), SendCount: 1, CreatedAt: time.Now(),
+		}
+		require.NoError(t, invitationRepo.Create(ctx, invitation))
+		return invitation
+	}
+	accept := func(token string) (*models.User, error) {
+		user, _, err := invitationService.Accept(ctx, services.AcceptInvitationRequest{
+			Token: token, Name: "Invitee", Password: "Str0ng!Passw0rd",
+		})
+		return user, err
+	}
+
+	t.Run("invite", func(t *testing.T) {
+		_, err := invitationService.Invite(ctx, adminID, "someone@example.com", "", "no-such-role")
+		assert.EqualError(t, err, "role not found")
+		_, err = invitationService.Invite(ctx, adminID, "ADMIN@example.com", "", "user")
+		assert.EqualError(t, err, "user already exists")
+
+		first, err := invitationService.Invite(ctx, adminID, "New@Example.com", "New", "user")
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", first.Email)
+		second, err := invitationService.Invite(ctx, adminID, "new@example.com", "New", "admin")
+		require.NoError(t, err)
+
+		// Inviting again replaces the open invitation
+		pending, err := invitationService.List(ctx, true, 50, 0)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, second.ID, pending[0].ID)
+
+		resent, err := invitationService.Resend(ctx, adminID, second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, resent.SendCount)
+		assert.NotEqual(t, second.TokenHash, resent.TokenHash)
+
+		require.NoError(t, invitationService.Revoke(ctx, adminID, second.ID))
+		assert.EqualError(t, invitationService.Revoke(ctx, adminID, second.ID), "invitation was already revoked")
+		_, err = invitationService.Resend(ctx, adminID, second.ID)
+		assert.EqualError(t, err, "invitation was already revoked")
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		invitation := issue("invitee@example.com", "admin", "good-token", time.Now().Add(time.Hour))
+
+		_, _, err := invitationService.Accept(ctx, services.AcceptInvitationRequest{Token: "good-token", Name: "Invitee", Password: "short"})
+		assert.Error(t, err)
+
+		user, err := accept("good-token")
+		require.NoError(t, err)
+		assert.Equal(t, "invitee@example.com", user.Email)
+		assert.Equal(t, "admin", user.Role)
+		assert.Equal(t, "active", user.Status)
+
+		stored, err := userRepo.GetByEmail(ctx, "invitee@example.com")
+		require.NoError(t, err)
+		assert.True(t, stored.EmailVerified)
+		require.NotNil(t, stored.SignupSource)
+		assert.Equal(t, models.SignupSourceInvitation, *stored.SignupSource)
+		_, _, _, err = authService.Login(ctx, services.LoginRequest{Email: "invitee@example.com", Password: "Str0ng!Passw0rd"})
+		assert.NoError(t, err)
+
+		// Single use
+		_, err = accept("good-token")
+		assert.EqualError(t, err, "invalid or expired invitation")
+
+		accepted, err := invitationRepo.GetByID(ctx, invitation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.InvitationStatusAccepted, accepted.Status(time.Now()))
+		require.NotNil(t, accepted.AcceptedBy)
+		assert.Equal(t, user.ID, *accepted.AcceptedBy)
+
+		logs, err := logRepo.List(ctx, 50)
+		require.NoError(t, err)
+		var recorded bool
+		for _, entry := range logs {
+			if entry.Action == "invitation_accepted" && entry.TargetID != nil && *entry.TargetID == invitation.ID.String() {
+				recorded = true
+			}
+		}
+		assert.True(t, recorded)
+	})
+
+	t.Run("invited roles cannot carry more than the inviter holds", func(t *testing.T) {
+		permissions := services.NewPermissionService(repositories.NewRoleRepository(db), repositories.NewPermissionRepository(db),
+			userRepo, logService, logger)
+		invitationService.SetPermissions(permissions)
+		defer invitationService.SetPermissions(nil)
+
+		_, err := permissions.CreateRole(ctx, adminID, services.RoleRequest{
+			Name: "recruiter", Permissions: []string{models.PermUsersWrite, models.PermRolesManage, models.PermAdminsManage},
+		})
+		require.NoError(t, err)
+		_, err = permissions.CreateRole(ctx, adminID, services.RoleRequest{
+			Name: "auditor", Permissions: []string{models.PermLogsRead},
+		})
+		require.NoError(t, err)
+		recruiterID := uuid.New()
+		require.NoError(t, userRepo.Create(ctx, &models.User{
+			ID: recruiterID, Email: "recruiter@example.com", Name: "Recruiter", Status: "active", Role: "recruiter",
+			PasswordChangedAt: time.Now(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}))
+
+		_, err = invitationService.Invite(ctx, recruiterID, "boss@example.com", "", "admin")
+		assert.EqualError(t, err, "cannot grant permissions you do not hold")
+		_, err = invitationService.Invite(ctx, recruiterID, "auditor@example.com", "", "auditor")
+		assert.EqualError(t, err, "cannot grant permissions you do not hold: logs.read")
+
+		// Nor can it resend an invitation someone else made
+		issued := issue("boss@example.com", "admin", "boss-token", time.Now().Add(time.Hour))
+		_, err = invitationService.Resend(ctx, recruiterID, issued.ID)
+		assert.EqualError(t, err, "cannot grant permissions you do not hold")
+
+		_, err = invitationService.Invite(ctx, recruiterID, "helper@example.com", "", "user")
+		require.NoError(t, err)
+	})
+
+	t.Run("expired and revoked", func(t *testing.T) {
+		issue("late@example.com", "user", "expired-token", time.Now().Add(-time.Minute))
+		_, err := accept("expired-token")
+		assert.EqualError(t, err, "invalid or expired invitation")
+
+		revoked := issue("revoked@example.com", "user", "revoked-token", time.Now().Add(time.Hour))
+		require.NoError(t, invitationService.Revoke(ctx, adminID, revoked.ID))
+		_, err = accept("revoked-token")
+		assert.EqualError(t, err, "invalid or expired invitation")
+
+		_, err = userRepo.GetByEmail(ctx, "revoked@example.com")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,117 @@
+package loginhistory_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestLoginHistoryAndSessionRevocation(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "loginhistory.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	sessionRepo := repositories.NewSessionRepository(db)
+	authService := services.NewAuthService(repositories.NewUserRepository(db), sessionRepo, repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	loginEventRepo := repositories.NewLoginEventRepository(db)
+	historyService := services.NewLoginHistoryService(config.LoginHistoryConfig{Retention: time.Hour}, loginEventRepo, logger)
+	authService.OnLoginEvent(historyService.Record)
+
+	var ended []uuid.UUID
+	authService.OnSessionsEnded(func(ctx context.Context, userID uuid.UUID, sessionIDs []uuid.UUID) {
+		ended = append(ended, sessionIDs...)
+	})
+
+	user, _, err := authService.Signup(ctx, services.SignupRequest{Email: "member@example.com", Password: "Str0ng!Passw0rd", Name: "Member"})
+	require.NoError(t, err)
+
+	ip := "203.0.113.7"
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0"
+	login := func(password string) (*models.Session, error) {
+		_, session, _, err := authService.Login(ctx, services.LoginRequest{
+			Email: user.Email, Password: password, IPAddress: &ip, UserAgent: &userAgent,
+		})
+		return session, err
+	}
+
+	_, err = login("wrong-password")
+	require.Error(t, err)
+	first, err := login("Str0ng!Passw0rd")
+	require.NoError(t, err)
+	second, err := login("Str0ng!Passw0rd")
+	require.NoError(t, err)
+	_, err = authService.RefreshToken(ctx, *second.RefreshToken, services.ClientInfo{IPAddress: &ip, UserAgent: &userAgent})
+	require.NoError(t, err)
+
+	t.Run("history records attempts with the client", func(t *testing.T) {
+		events, err := historyService.ListForUser(ctx, user.ID, 50, 0)
+		require.NoError(t, err)
+		require.Len(t, events, 4)
+
+		var types []string
+		for _, event := range events {
+			types = append(types, event.EventType)
+		}
+		assert.ElementsMatch(t, []string{
+			models.LoginEventFailure, models.LoginEventSuccess, models.LoginEventSuccess, models.LoginEventTokenRefresh,
+		}, types)
+
+		for _, event := range events {
+			assert.Equal(t, ip, *event.IPAddress)
+			require.NotNil(t, event.Browser)
+			assert.Equal(t, "Firefox", *event.Browser)
+			if event.EventType == models.LoginEventFailure {
+				assert.Equal(t, "invalid_password", *event.FailureReason)
+				assert.Nil(t, event.SessionID)
+			} else {
+				require.NotNil(t, event.SessionID)
+			}
+		}
+	})
+
+	t.Run("sessions can only be revoked by their owner", func(t *testing.T) {
+		assert.EqualError(t, authService.RevokeSession(ctx, uuid.New(), first.ID), "session not found")
+		require.NoError(t, authService.RevokeSession(ctx, user.ID, first.ID))
+		assert.Equal(t, []uuid.UUID{first.ID}, ended)
+
+		sessions, err := sessionRepo.GetByUserID(ctx, user.ID)
+		require.NoError(t, err)
+		for _, session := range sessions {
+			assert.NotEqual(t, first.ID, session.ID)
+		}
+		assert.EqualError(t, authService.RevokeSession(ctx, user.ID, first.ID), "session not found")
+	})
+
+	t.Run("events past the retention period are purged", func(t *testing.T) {
+		require.NoError(t, loginEventRepo.Create(ctx, &models.LoginEvent{
+			ID: uuid.New(), UserID: &user.ID, EventType: models.LoginEventSuccess, CreatedAt: time.Now().Add(-2 * time.Hour),
+		}))
+		purged, err := historyService.PurgeExpired(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		events, err := historyService.ListForUser(ctx, user.ID, 50, 0)
+		require.NoError(t, err)
+		assert.Len(t, events, 4)
+	})
+}
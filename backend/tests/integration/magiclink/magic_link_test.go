@@ -0,0 +1,130 @@
+package magiclink_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "magiclink.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	magicLinkRepo := repositories.NewMagicLinkRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	settingsService := services.NewSystemSettingsService(repositories.NewSystemSettingsRepository(db), logService, logger)
+	magicLinkService := services.NewMagicLinkService(
+		config.MagicLinkConfig{LoginURL: "http://localhost:8080/", TokenTTL: 15 * time.Minute, MaxPerEmail: 3, RequestWindow: 15 * time.Minute},
+		magicLinkRepo, userRepo, authService, settingsService,
+		services.NewEmailService(services.EmailConfig{}, logger), logService, logger,
+	)
+
+	user, _, err := authService.Signup(ctx, services.SignupRequest{
+		Email: "member@example.com", Password: "Str0ng!Passw0rd", Name: "Member",
+	})
+	require.NoError(t, err)
+
+	// issue stores a link with a known token, standing in for the email
+	issue := func(token string, expiresAt time.Time) {
+		require.NoError(t, magicLinkRepo.Create(ctx, &models.MagicLinkToken{
+			ID: uuid.New(), UserID: user.ID, TokenHash: hash(token), ExpiresAt: expiresAt, CreatedAt: time.Now(),
+		}))
+	}
+
+	deviceID := "phone-1"
+	client := services.ClientInfo{DeviceID: &deviceID}
+
+	t.Run("disabled until an admin turns it on", func(t *testing.T) {
+		assert.False(t, magicLinkService.Enabled(ctx))
+		assert.EqualError(t, magicLinkService.RequestLink(ctx, user.Email, nil), "magic-link login is disabled")
+
+		issue("before-enabled", time.Now().Add(time.Minute))
+		_, _, _, err := magicLinkService.Verify(ctx, "before-enabled", client)
+		assert.EqualError(t, err, "magic-link login is disabled")
+	})
+
+	t.Run("only known settings of the right type are accepted", func(t *testing.T) {
+		_, err := settingsService.UpdateSettings(ctx, user.ID, map[string]interface{}{"unknown": true})
+		assert.EqualError(t, err, "unknown setting: unknown")
+		_, err = settingsService.UpdateSettings(ctx, user.ID, map[string]interface{}{models.SettingMagicLinkEnabled: "yes"})
+		assert.Error(t, err)
+
+		settings, err := settingsService.UpdateSettings(ctx, user.ID, map[string]interface{}{models.SettingMagicLinkEnabled: true})
+		require.NoError(t, err)
+		assert.Equal(t, true, settings[models.SettingMagicLinkEnabled])
+		assert.True(t, magicLinkService.Enabled(ctx))
+	})
+
+	t.Run("requests are limited per address without revealing it", func(t *testing.T) {
+		require.NoError(t, magicLinkService.RequestLink(ctx, "nobody@example.com", nil))
+
+		before, err := magicLinkRepo.CountSince(ctx, user.ID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, magicLinkService.RequestLink(ctx, user.Email, nil))
+		}
+		after, err := magicLinkRepo.CountSince(ctx, user.ID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 3, after, "links stop at the per-address limit")
+		assert.Greater(t, after, before)
+	})
+
+	t.Run("a link signs in once and retires the others", func(t *testing.T) {
+		issue("first-link", time.Now().Add(time.Minute))
+		issue("second-link", time.Now().Add(time.Minute))
+
+		loggedIn, session, isNewDevice, err := magicLinkService.Verify(ctx, "first-link", client)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, loggedIn.ID)
+		assert.NotEmpty(t, session.Token)
+		assert.True(t, isNewDevice)
+
+		_, _, _, err = magicLinkService.Verify(ctx, "first-link", client)
+		assert.EqualError(t, err, "invalid or expired link")
+		_, _, _, err = magicLinkService.Verify(ctx, "second-link", client)
+		assert.EqualError(t, err, "invalid or expired link")
+	})
+
+	t.Run("expired links are refused", func(t *testing.T) {
+		issue("expired-link", time.Now().Add(-time.Second))
+		_, _, _, err := magicLinkService.Verify(ctx, "expired-link", client)
+		assert.EqualError(t, err, "invalid or expired link")
+	})
+
+	t.Run("the device is remembered across links", func(t *testing.T) {
+		issue("third-link", time.Now().Add(time.Minute))
+		_, _, isNewDevice, err := magicLinkService.Verify(ctx, "third-link", client)
+		require.NoError(t, err)
+		assert.False(t, isNewDevice)
+	})
+}
@@ -0,0 +1,201 @@
+package oauth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/handlers"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestOIDCProviderFlow(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "oauth.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	router := mux.NewRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	logoutTokens := make(chan string, 1)
+	product := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		logoutTokens <- r.PostForm.Get("logout_token")
+	}))
+	defer product.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	authService := services.NewAuthService(userRepo, sessionRepo, repositories.NewDeviceRepository(db), "test-secret", time.Minute, time.Hour, logger)
+	oauthService := services.NewOAuthServerService(
+		config.OAuthServerConfig{
+			Issuer:          server.URL + "/v1/oauth2",
+			ConsentURL:      "/consent",
+			CodeTTL:         time.Minute,
+			AccessTokenTTL:  time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+		repositories.NewOAuthClientRepository(db), repositories.NewOAuthCodeRepository(db),
+		repositories.NewOAuthConsentRepository(db), repositories.NewOAuthRefreshTokenRepository(db),
+		repositories.NewOAuthSigningKeyRepository(db), userRepo, sessionRepo, authService, logService, logger,
+	)
+	authService.OnSessionsEnded(oauthService.EndSessions)
+
+	h := handlers.NewOAuthServerHandler(oauthService, "/consent", logger)
+	router.HandleFunc("/v1/oauth2/.well-known/openid-configuration", h.Discovery)
+	router.HandleFunc("/v1/oauth2/jwks", h.JWKS)
+	router.HandleFunc("/v1/oauth2/token", h.Token)
+	router.HandleFunc("/v1/oauth2/userinfo", h.UserInfo)
+
+	user, session, err := authService.Signup(ctx, services.SignupRequest{
+		Email: "member@example.com", Password: "Str0ng!Passw0rd", Name: "Member",
+	})
+	require.NoError(t, err)
+
+	backchannel := product.URL + "/logout"
+	client, secret, err := oauthService.CreateClient(ctx, user.ID, services.OAuthClientRequest{
+		Name:                 "Product",
+		RedirectURIs:         []string{"http://localhost:3000/callback"},
+		BackchannelLogoutURI: &backchannel,
+		AllowedScopes:        []string{"openid", "email", "offline_access"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, secret, "public clients get no secret")
+
+	verifier := "a-long-random-verifier-for-the-test-0123456789"
+	sum := sha256.Sum256([]byte(verifier))
+	authorize := services.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         "http://localhost:3000/callback",
+		Scope:               "openid email offline_access",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+
+	issueCode := func() string {
+		redirectTo, err := oauthService.Decide(ctx, user.ID, session.ID, authorize, true)
+		require.NoError(t, err)
+		u, err := url.Parse(redirectTo)
+		require.NoError(t, err)
+		assert.Equal(t, "xyz", u.Query().Get("state"))
+		return u.Query().Get("code")
+	}
+
+	token := func(form url.Values) (int, map[string]interface{}) {
+		resp, err := http.PostForm(server.URL+"/v1/oauth2/token", form)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	userinfo := func(accessToken string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/oauth2/userinfo", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	t.Run("pkce is enforced", func(t *testing.T) {
+		status, body := token(url.Values{
+			"grant_type": {"authorization_code"}, "code": {issueCode()}, "client_id": {client.ClientID},
+			"redirect_uri": {authorize.RedirectURI}, "code_verifier": {"wrong"},
+		})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "invalid_grant", body["error"])
+	})
+
+	var refreshToken, accessToken string
+	t.Run("code exchange yields a verifiable id token", func(t *testing.T) {
+		status, body := token(url.Values{
+			"grant_type": {"authorization_code"}, "code": {issueCode()}, "client_id": {client.ClientID},
+			"redirect_uri": {authorize.RedirectURI}, "code_verifier": {verifier},
+		})
+		require.Equal(t, http.StatusOK, status, body)
+		accessToken, _ = body["access_token"].(string)
+		refreshToken, _ = body["refresh_token"].(string)
+		require.NotEmpty(t, refreshToken)
+
+		provider, err := oidc.NewProvider(ctx, server.URL+"/v1/oauth2")
+		require.NoError(t, err)
+		idToken, err := provider.Verifier(&oidc.Config{ClientID: client.ClientID}).Verify(ctx, body["id_token"].(string))
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.String(), idToken.Subject)
+		assert.Equal(t, "n-0S6", idToken.Nonce)
+
+		status, claims := userinfo(accessToken)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "member@example.com", claims["email"])
+	})
+
+	t.Run("refresh tokens rotate", func(t *testing.T) {
+		status, body := token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}, "client_id": {client.ClientID}})
+		require.Equal(t, http.StatusOK, status, body)
+
+		status, body = token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}, "client_id": {client.ClientID}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "invalid_grant", body["error"])
+	})
+
+	t.Run("logging out of base-app logs out of the product", func(t *testing.T) {
+		_, body := token(url.Values{
+			"grant_type": {"authorization_code"}, "code": {issueCode()}, "client_id": {client.ClientID},
+			"redirect_uri": {authorize.RedirectURI}, "code_verifier": {verifier},
+		})
+		liveRefresh := body["refresh_token"].(string)
+
+		require.NoError(t, authService.Logout(ctx, session.ID, false))
+
+		select {
+		case logoutToken := <-logoutTokens:
+			parts := strings.Split(logoutToken, ".")
+			require.Len(t, parts, 3)
+			payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+			require.NoError(t, err)
+			assert.Contains(t, string(payload), session.ID.String())
+		case <-time.After(5 * time.Second):
+			t.Fatal("no back-channel logout received")
+		}
+
+		status, body := token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {liveRefresh}, "client_id": {client.ClientID}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "invalid_grant", body["error"])
+
+		status, _ = userinfo(accessToken)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
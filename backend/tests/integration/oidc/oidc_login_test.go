@@ -0,0 +1,239 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+const clientID = "base-app"
+
+type pendingCode struct {
+	challenge string
+	nonce     string
+	subject   string
+	email     string
+}
+
+// mockProvider is a minimal OIDC provider: discovery, JWKS and a token
+// endpoint that enforces PKCE and signs RS256 id tokens.
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]pendingCode
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &mockProvider{key: key, codes: map[string]pendingCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		p.mu.Lock()
+		pending, ok := p.codes[r.Form.Get("code")]
+		delete(p.codes, r.Form.Get("code"))
+		p.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != pending.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            p.server.URL,
+			"aud":            clientID,
+			"sub":            pending.subject,
+			"email":          pending.email,
+			"email_verified": true,
+			"name":           "Oidc User",
+			"nonce":          pending.nonce,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize plays the user agent: it approves the request for the given
+// subject and returns the code and state the provider would redirect with.
+func (p *mockProvider) authorize(t *testing.T, authURL, subject, email string) (string, string) {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	q := u.Query()
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+
+	code := subject + "-" + q.Get("state")[:8]
+	p.mu.Lock()
+	p.codes[code] = pendingCode{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), subject: subject, email: email}
+	p.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func TestOIDCLoginAndLinking(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	provider := newMockProvider(t)
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "oidc.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	identityRepo := repositories.NewIdentityRepository(db)
+	authService := services.NewAuthService(
+		userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger,
+	)
+	oidcService := services.NewOIDCService(
+		config.OIDCConfig{
+			Providers: []config.OIDCProviderConfig{{
+				Name:        "mock",
+				DisplayName: "Mock",
+				IssuerURL:   provider.server.URL,
+				ClientID:    clientID,
+				RedirectURL: "http://localhost/callback",
+				Scopes:      []string{"openid", "email", "profile"},
+			}},
+			StateTTL: time.Minute,
+		},
+		identityRepo, repositories.NewOIDCStateRepository(db), repositories.NewIdentityLinkRepository(db), repositories.NewPasskeyRepository(db),
+		userRepo, authService,
+		services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger),
+		logger,
+	)
+
+	signIn := func(subject, email string) (*services.OIDCLoginResult, error) {
+		authURL, err := oidcService.BeginAuth(ctx, "mock", nil)
+		require.NoError(t, err)
+		code, state := provider.authorize(t, authURL, subject, email)
+		return oidcService.Complete(ctx, "mock", code, state, services.ClientInfo{})
+	}
+
+	t.Run("first sign-in creates a password-less user", func(t *testing.T) {
+		result, err := signIn("sub-new", "new@example.com")
+		require.NoError(t, err)
+		require.NotNil(t, result.Session)
+		assert.True(t, result.IsNewUser)
+		assert.True(t, result.User.EmailVerified)
+
+		again, err := signIn("sub-new", "new@example.com")
+		require.NoError(t, err)
+		assert.False(t, again.IsNewUser)
+		assert.Equal(t, result.User.ID, again.User.ID)
+	})
+
+	t.Run("state cannot be replayed", func(t *testing.T) {
+		authURL, err := oidcService.BeginAuth(ctx, "mock", nil)
+		require.NoError(t, err)
+		code, state := provider.authorize(t, authURL, "sub-replay", "replay@example.com")
+		_, err = oidcService.Complete(ctx, "mock", code, state, services.ClientInfo{})
+		require.NoError(t, err)
+		_, err = oidcService.Complete(ctx, "mock", code, state, services.ClientInfo{})
+		assert.EqualError(t, err, "invalid or expired state")
+	})
+
+	t.Run("matching email requires password confirmation", func(t *testing.T) {
+		existing, _, err := authService.Signup(ctx, services.SignupRequest{
+			Email: "owner@example.com", Password: "Str0ng!Passw0rd", Name: "Owner",
+		})
+		require.NoError(t, err)
+
+		result, err := signIn("sub-owner", "owner@example.com")
+		require.NoError(t, err)
+		require.NotEmpty(t, result.LinkToken)
+		assert.Nil(t, result.Session)
+
+		_, err = oidcService.ConfirmLink(ctx, result.LinkToken, "wrong-password", services.ClientInfo{})
+		assert.EqualError(t, err, "invalid credentials")
+
+		linked, err := oidcService.ConfirmLink(ctx, result.LinkToken, "Str0ng!Passw0rd", services.ClientInfo{})
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, linked.User.ID)
+
+		_, err = oidcService.ConfirmLink(ctx, result.LinkToken, "Str0ng!Passw0rd", services.ClientInfo{})
+		assert.EqualError(t, err, "invalid or expired link token")
+
+		login, err := signIn("sub-owner", "owner@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, login.User.ID)
+
+		// The account still has a password, so the identity can go.
+		require.NoError(t, oidcService.Unlink(ctx, existing.ID, linked.Identity.ID))
+	})
+
+	t.Run("last login method cannot be unlinked", func(t *testing.T) {
+		result, err := signIn("sub-only", "only@example.com")
+		require.NoError(t, err)
+		identities, err := oidcService.ListIdentities(ctx, result.User.ID)
+		require.NoError(t, err)
+		require.Len(t, identities, 1)
+
+		err = oidcService.Unlink(ctx, result.User.ID, identities[0].ID)
+		assert.EqualError(t, err, "cannot remove your last login method")
+	})
+}
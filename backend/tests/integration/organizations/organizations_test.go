@@ -0,0 +1,253 @@
+package organizations_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestOrganizations(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "organizations.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	dashboardRepo := repositories.NewDashboardRepository(db)
+	crudRepo := repositories.NewCustomCRUDRepository(db)
+	invitationRepo := repositories.NewOrganizationInvitationRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	organizationService := services.NewOrganizationService(
+		config.OrganizationConfig{InvitationURL: "http://localhost:8080/", InvitationTTL: time.Hour},
+		repositories.NewOrganizationRepository(db), invitationRepo, userRepo,
+		services.NewEmailService(services.EmailConfig{}, logger), logService, logger,
+	)
+	dashboardService := services.NewDashboardService(dashboardRepo, logger)
+	crudService := services.NewCustomCRUDService(crudRepo, logger)
+	templateService := services.NewCRUDTemplateService(repositories.NewCRUDTemplateRepository(db), logger)
+	searchService := services.NewSearchService(repositories.NewSearchRepository(db), dashboardRepo,
+		repositories.NewMessageRepository(db), repositories.NewNotificationRepository(db), crudRepo, userRepo,
+		services.NewPrivacyService(repositories.NewSettingsRepository(db), userRepo, repositories.NewConnectionRepository(db), logger), logger)
+
+	signup := func(email, name string) *models.User {
+		user, _, err := authService.Signup(ctx, services.SignupRequest{Email: email, Password: "Str0ng!Passw0rd", Name: name})
+		require.NoError(t, err)
+		return user
+	}
+	owner := signup("owner@example.com", "Owner")
+	member := signup("member@example.com", "Member")
+	outsider := signup("outsider@example.com", "Outsider")
+
+	// invite stores an invitation with a known token, standing in for the email
+	invite := func(orgID uuid.UUID, email, role, token string) {
+		require.NoError(t, invitationRepo.Create(ctx, &models.OrganizationInvitation{
+			ID: uuid.New(), OrganizationID: orgID, Email: email, Role: role, TokenHash: hash(token),
+			InvitedBy: &owner.ID, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now(),
+		}))
+	}
+	tenant := func(user *models.User, orgID *uuid.UUID) *models.Tenant {
+		scope := &models.Tenant{UserID: user.ID, OrganizationID: orgID}
+		if orgID != nil {
+			role, err := organizationService.MemberRole(ctx, *orgID, user.ID)
+			require.NoError(t, err)
+			scope.OrganizationRole = role
+		}
+		return scope
+	}
+
+	acme, err := organizationService.Create(ctx, owner.ID, "Acme")
+	require.NoError(t, err)
+	globex, err := organizationService.Create(ctx, outsider.ID, "Globex")
+	require.NoError(t, err)
+
+	t.Run("invitations", func(t *testing.T) {
+		invite(acme.ID, "member@example.com", models.OrganizationRoleMember, "member-token")
+
+		_, err := organizationService.AcceptInvitation(ctx, "member-token", outsider.ID)
+		assert.EqualError(t, err, "invitation was sent to a different email address")
+
+		joined, err := organizationService.AcceptInvitation(ctx, "member-token", member.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.ID, joined.ID)
+
+		_, err = organizationService.AcceptInvitation(ctx, "member-token", member.ID)
+		assert.EqualError(t, err, "invalid or expired invitation")
+
+		role, err := organizationService.MemberRole(ctx, acme.ID, member.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.OrganizationRoleMember, role)
+		role, err = organizationService.MemberRole(ctx, acme.ID, outsider.ID)
+		require.NoError(t, err)
+		assert.Empty(t, role)
+	})
+
+	t.Run("roles", func(t *testing.T) {
+		assert.EqualError(t, organizationService.RemoveMember(ctx, acme.ID, owner.ID, owner.ID), "an organization needs at least one owner")
+		assert.EqualError(t, organizationService.ChangeRole(ctx, acme.ID, owner.ID, owner.ID, models.OrganizationRoleAdmin), "an organization needs at least one owner")
+
+		require.NoError(t, organizationService.ChangeRole(ctx, acme.ID, owner.ID, member.ID, models.OrganizationRoleAdmin))
+		assert.EqualError(t, organizationService.ChangeRole(ctx, acme.ID, member.ID, member.ID, models.OrganizationRoleOwner),
+			"only owners can grant or remove the owner role")
+		_, err := organizationService.Invite(ctx, acme.ID, member.ID, "new@example.com", models.OrganizationRoleOwner)
+		assert.EqualError(t, err, "only owners can grant or remove the owner role")
+		assert.Error(t, organizationService.Delete(ctx, acme.ID, member.ID))
+
+		_, err = organizationService.ListMembers(ctx, acme.ID, outsider.ID)
+		assert.EqualError(t, err, "organization not found")
+
+		require.NoError(t, organizationService.ChangeRole(ctx, acme.ID, owner.ID, member.ID, models.OrganizationRoleMember))
+	})
+
+	t.Run("dashboard items", func(t *testing.T) {
+		shared, err := dashboardService.CreateItem(ctx, tenant(owner, &acme.ID), "Acme roadmap", nil, nil, nil)
+		require.NoError(t, err)
+		private, err := dashboardService.CreateItem(ctx, tenant(owner, nil), "Private roadmap", nil, nil, nil)
+		require.NoError(t, err)
+
+		// Members share the organization's items but not each other's own
+		_, err = dashboardService.GetItem(ctx, shared.ID, tenant(member, &acme.ID))
+		assert.NoError(t, err)
+		_, err = dashboardService.GetItem(ctx, private.ID, tenant(member, &acme.ID))
+		assert.Error(t, err)
+		_, err = dashboardService.GetItem(ctx, shared.ID, tenant(member, nil))
+		assert.Error(t, err)
+
+		_, err = dashboardService.GetItem(ctx, shared.ID, tenant(outsider, &globex.ID))
+		assert.Error(t, err)
+		assert.EqualError(t, dashboardService.DeleteItem(ctx, shared.ID, tenant(outsider, &globex.ID)), "item not found")
+
+		items, err := dashboardService.ListItems(ctx, tenant(outsider, &globex.ID), "")
+		require.NoError(t, err)
+		assert.Empty(t, items)
+		items, err = dashboardService.ListItems(ctx, tenant(member, &acme.ID), "")
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, shared.ID, items[0].ID)
+	})
+
+	t.Run("custom CRUDs", func(t *testing.T) {
+		schema := map[string]interface{}{"fields": []interface{}{}}
+		_, err := crudService.CreateEntity(ctx, tenant(member, &acme.ID), "products", "Products", nil, schema)
+		assert.EqualError(t, err, "organization admin role required")
+
+		entity, err := crudService.CreateEntity(ctx, tenant(owner, &acme.ID), "products", "Products", nil, schema)
+		require.NoError(t, err)
+		// The same name is free in another organization
+		_, err = crudService.CreateEntity(ctx, tenant(outsider, &globex.ID), "products", "Products", nil, schema)
+		require.NoError(t, err)
+
+		data, err := crudService.CreateData(ctx, entity.ID, tenant(member, &acme.ID), map[string]interface{}{"name": "Anvil"})
+		require.NoError(t, err)
+
+		_, err = crudService.GetEntity(ctx, entity.ID, tenant(outsider, &globex.ID))
+		assert.EqualError(t, err, "entity not found")
+		_, err = crudService.GetData(ctx, data.ID, tenant(outsider, &globex.ID))
+		assert.EqualError(t, err, "data not found")
+		_, err = crudService.GetData(ctx, data.ID, tenant(outsider, nil))
+		assert.EqualError(t, err, "data not found")
+		_, err = crudService.CreateData(ctx, entity.ID, tenant(outsider, &globex.ID), map[string]interface{}{"name": "Spy"})
+		assert.EqualError(t, err, "entity not found")
+		_, err = crudService.ListData(ctx, entity.ID, tenant(outsider, nil), 10, 0)
+		assert.EqualError(t, err, "entity not found")
+
+		entities, err := crudService.ListEntities(ctx, tenant(outsider, &globex.ID), false)
+		require.NoError(t, err)
+		require.Len(t, entities, 1)
+		assert.NotEqual(t, entity.ID, entities[0].ID)
+	})
+
+	t.Run("templates", func(t *testing.T) {
+		schema := map[string]interface{}{"fields": []interface{}{}}
+		_, err := templateService.CreateTemplate(ctx, tenant(owner, nil), "invoices", "Invoices", nil, schema, nil, nil)
+		assert.EqualError(t, err, "not allowed to manage these templates")
+		_, err = templateService.CreateTemplate(ctx, tenant(member, &acme.ID), "invoices", "Invoices", nil, schema, nil, nil)
+		assert.EqualError(t, err, "not allowed to manage these templates")
+
+		platform, err := templateService.CreateTemplate(ctx, &models.Tenant{UserID: owner.ID, Unrestricted: true}, "contacts", "Contacts", nil, schema, nil, nil)
+		require.NoError(t, err)
+		private, err := templateService.CreateTemplate(ctx, tenant(owner, &acme.ID), "invoices", "Invoices", nil, schema, nil, nil)
+		require.NoError(t, err)
+
+		found, err := templateService.GetTemplate(ctx, private.ID, tenant(outsider, &globex.ID))
+		require.NoError(t, err)
+		assert.Nil(t, found)
+		found, err = templateService.GetTemplateByName(ctx, "invoices", tenant(outsider, &globex.ID))
+		require.NoError(t, err)
+		assert.Nil(t, found)
+		assert.Error(t, templateService.DeleteTemplate(ctx, private.ID, tenant(outsider, &globex.ID)))
+
+		templates, err := templateService.ListTemplates(ctx, tenant(outsider, &globex.ID), nil, false)
+		require.NoError(t, err)
+		var ids []uuid.UUID
+		for _, template := range templates {
+			ids = append(ids, template.ID)
+		}
+		assert.Contains(t, ids, platform.ID)
+		assert.NotContains(t, ids, private.ID)
+	})
+
+	t.Run("webhooks", func(t *testing.T) {
+		sub := &models.WebhookSubscription{
+			ID: uuid.New(), UserID: &owner.ID, OrganizationID: &acme.ID, SubscriptionName: "Acme",
+			WebhookURL: "https://hooks.example.com/a", WebhookSecret: "secret", EventTypes: []string{"item.created"},
+			IsActive: true, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		require.NoError(t, webhookRepo.CreateSubscription(ctx, sub))
+
+		subscriptions, err := webhookRepo.GetActiveSubscriptions(ctx, "item.created", &globex.ID)
+		require.NoError(t, err)
+		assert.Empty(t, subscriptions)
+		subscriptions, err = webhookRepo.GetActiveSubscriptions(ctx, "item.created", nil)
+		require.NoError(t, err)
+		assert.Empty(t, subscriptions)
+		subscriptions, err = webhookRepo.GetActiveSubscriptions(ctx, "item.created", &acme.ID)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 1)
+		assert.Equal(t, sub.ID, subscriptions[0].ID)
+
+		found, err := webhookRepo.GetSubscriptionByID(ctx, sub.ID, &globex.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		results := func(tenant *models.Tenant) []models.SearchResult {
+			result, err := searchService.Search(ctx, tenant, services.SearchRequest{Query: "roadmap", Type: "dashboard_items"})
+			require.NoError(t, err)
+			found, _ := result.Data.(map[string]interface{})["results"].([]models.SearchResult)
+			return found
+		}
+		assert.Len(t, results(tenant(member, &acme.ID)), 1)
+		assert.Empty(t, results(tenant(outsider, &globex.ID)))
+		assert.Empty(t, results(tenant(outsider, nil)))
+		assert.Len(t, results(tenant(owner, nil)), 1)
+	})
+}
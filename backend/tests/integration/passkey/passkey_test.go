@@ -0,0 +1,236 @@
+package passkey_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+const (
+	rpID   = "localhost"
+	origin = "http://localhost:8080"
+)
+
+// softAuthenticator is a minimal ES256 platform authenticator with "none"
+// attestation. Its sign counter can be set to simulate a cloned key.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	counter      uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 32)
+	rand.Read(credentialID)
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func clientData(t *testing.T, ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": b64(challenge),
+		"origin":    origin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	return append(data, attested...)
+}
+
+func (a *softAuthenticator) create(t *testing.T, options *protocol.CredentialCreation) []byte {
+	a.userHandle = options.Response.User.ID.(protocol.URLEncodedBase64)
+
+	coseKey, err := webauthncbor.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	// UP | UV | AT
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x01|0x04|0x40, attested),
+	})
+	require.NoError(t, err)
+
+	response, err := json.Marshal(map[string]interface{}{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    b64(clientData(t, "webauthn.create", options.Response.Challenge)),
+			"attestationObject": b64(attestationObject),
+			"transports":        []string{"internal"},
+		},
+	})
+	require.NoError(t, err)
+	return response
+}
+
+func (a *softAuthenticator) get(t *testing.T, options *protocol.CredentialAssertion) []byte {
+	clientDataJSON := clientData(t, "webauthn.get", options.Response.Challenge)
+	authData := a.authData(0x01|0x04, nil)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	response, err := json.Marshal(map[string]interface{}{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    b64(clientDataJSON),
+			"authenticatorData": b64(authData),
+			"signature":         b64(signature),
+			"userHandle":        b64(a.userHandle),
+		},
+	})
+	require.NoError(t, err)
+	return response
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "passkey.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	passkeyService, err := services.NewPasskeyService(
+		config.WebAuthnConfig{RPID: rpID, RPDisplayName: "Base App", RPOrigins: []string{origin}, ChallengeTTL: time.Minute},
+		repositories.NewPasskeyRepository(db), repositories.NewWebAuthnCeremonyRepository(db),
+		repositories.NewIdentityRepository(db), userRepo, authService,
+		services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger), logger,
+	)
+	require.NoError(t, err)
+
+	user, _, err := authService.Signup(ctx, services.SignupRequest{
+		Email: "member@example.com", Password: "Str0ng!Passw0rd", Name: "Member",
+	})
+	require.NoError(t, err)
+
+	deviceID := "laptop-1"
+	client := services.ClientInfo{DeviceID: &deviceID}
+	authenticator := newSoftAuthenticator(t)
+
+	ceremony, err := passkeyService.BeginRegistration(ctx, user.ID)
+	require.NoError(t, err)
+	passkey, err := passkeyService.FinishRegistration(ctx, user.ID, ceremony.Token, "Laptop",
+		authenticator.create(t, ceremony.Options.(*protocol.CredentialCreation)), client)
+	require.NoError(t, err)
+	assert.Equal(t, "Laptop", passkey.Name)
+	assert.Equal(t, []string{"internal"}, passkey.Transports)
+	require.NotNil(t, passkey.DeviceID, "passkey is linked to the registering device")
+	assert.Equal(t, user.ID[:], authenticator.userHandle)
+
+	login := func() (*services.PasskeyCeremony, error) {
+		ceremony, err := passkeyService.BeginLogin(ctx)
+		require.NoError(t, err)
+		_, _, _, err = passkeyService.FinishLogin(ctx, ceremony.Token, authenticator.get(t, ceremony.Options.(*protocol.CredentialAssertion)), client)
+		return ceremony, err
+	}
+
+	t.Run("registering the same authenticator twice is refused", func(t *testing.T) {
+		ceremony, err := passkeyService.BeginRegistration(ctx, user.ID)
+		require.NoError(t, err)
+		options := ceremony.Options.(*protocol.CredentialCreation)
+		require.Len(t, options.Response.CredentialExcludeList, 1)
+		_, err = passkeyService.FinishRegistration(ctx, user.ID, ceremony.Token, "", authenticator.create(t, options), client)
+		assert.EqualError(t, err, "passkey already registered")
+	})
+
+	t.Run("login with an advancing counter", func(t *testing.T) {
+		authenticator.counter = 1
+		ceremony, err := passkeyService.BeginLogin(ctx)
+		require.NoError(t, err)
+		loggedIn, session, isNewDevice, err := passkeyService.FinishLogin(ctx, ceremony.Token,
+			authenticator.get(t, ceremony.Options.(*protocol.CredentialAssertion)), client)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, loggedIn.ID)
+		assert.NotEmpty(t, session.Token)
+		assert.False(t, isNewDevice)
+
+		authenticator.counter = 2
+		_, err = login()
+		require.NoError(t, err)
+	})
+
+	t.Run("a ceremony answers once", func(t *testing.T) {
+		authenticator.counter = 3
+		ceremony, err := login()
+		require.NoError(t, err)
+		authenticator.counter = 4
+		_, _, _, err = passkeyService.FinishLogin(ctx, ceremony.Token, authenticator.get(t, ceremony.Options.(*protocol.CredentialAssertion)), client)
+		assert.EqualError(t, err, "invalid or expired ceremony")
+	})
+
+	t.Run("a regressed counter disables the passkey", func(t *testing.T) {
+		authenticator.counter = 2
+		_, err := login()
+		assert.EqualError(t, err, "passkey has been disabled")
+
+		authenticator.counter = 10
+		_, err = login()
+		assert.EqualError(t, err, "passkey has been disabled")
+
+		passkeys, err := passkeyService.ListPasskeys(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, passkeys, 1)
+		assert.NotNil(t, passkeys[0].CloneDetectedAt)
+	})
+
+	t.Run("passkeys can only be removed by their owner", func(t *testing.T) {
+		assert.EqualError(t, passkeyService.DeletePasskey(ctx, uuid.New(), passkey.ID), "passkey not found")
+		require.NoError(t, passkeyService.DeletePasskey(ctx, user.ID, passkey.ID))
+	})
+}
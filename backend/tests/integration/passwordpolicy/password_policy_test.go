@@ -0,0 +1,169 @@
+package passwordpolicy_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+	"base-app-service/pkg/auth"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "passwordpolicy.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	// A one-entry breached corpus
+	sum := sha1.Sum([]byte("Leaked!Passw0rd"))
+	corpus := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(corpus, []byte(strings.ToUpper(hex.EncodeToString(sum[:]))+":42\n"), 0o600))
+	breached, err := auth.OpenBreachedPasswords(corpus)
+	require.NoError(t, err)
+	defer breached.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	settings := services.NewSystemSettingsService(repositories.NewSystemSettingsRepository(db), logService, logger)
+	policy := services.NewPasswordPolicyService(settings, userRepo, repositories.NewPasswordHistoryRepository(db), breached, logService, logger)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	authService.SetPasswordPolicy(policy)
+
+	adminID := uuid.New()
+	require.NoError(t, userRepo.Create(ctx, &models.User{
+		ID: adminID, Email: "admin@example.com", Name: "Admin", Status: "active", Role: "admin",
+		PasswordChangedAt: time.Now(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}))
+	_, err = settings.UpdateSettings(ctx, adminID, map[string]interface{}{
+		models.SettingPasswordMinLength:    float64(12),
+		models.SettingPasswordHistoryCount: float64(3),
+	})
+	require.NoError(t, err)
+
+	t.Run("signup enforces the configured policy and corpus", func(t *testing.T) {
+		_, _, err := authService.Signup(ctx, services.SignupRequest{Email: "short@example.com", Password: "Sh0rt!pw", Name: "Short"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "at least 12 characters")
+
+		_, _, err = authService.Signup(ctx, services.SignupRequest{Email: "leaked@example.com", Password: "Leaked!Passw0rd", Name: "Leaked"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "data breach")
+
+		evaluation := policy.Evaluate(ctx, "Leaked!Passw0rd")
+		assert.True(t, evaluation.Breached)
+		assert.False(t, evaluation.Valid)
+	})
+
+	t.Run("settings reject out-of-range values", func(t *testing.T) {
+		_, err := settings.UpdateSettings(ctx, adminID, map[string]interface{}{models.SettingPasswordHistoryCount: float64(100)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be between")
+	})
+
+	user, _, err := authService.Signup(ctx, services.SignupRequest{Email: "member@example.com", Password: "First!Passw0rd", Name: "Member"})
+	require.NoError(t, err)
+
+	t.Run("recent passwords cannot be reused", func(t *testing.T) {
+		require.NoError(t, policy.SetPassword(ctx, user, "Second!Passw0rd", false))
+		require.NoError(t, policy.SetPassword(ctx, user, "Third!Passw0rd1", false))
+
+		for _, reused := range []string{"Third!Passw0rd1", "Second!Passw0rd", "First!Passw0rd"} {
+			err := policy.SetPassword(ctx, user, reused, false)
+			require.Error(t, err, reused)
+			assert.Contains(t, err.Error(), "last 3 passwords")
+		}
+
+		// The first password falls out of a history of three
+		require.NoError(t, policy.SetPassword(ctx, user, "Fourth!Passw0rd", false))
+		require.NoError(t, policy.SetPassword(ctx, user, "First!Passw0rd", false))
+	})
+
+	t.Run("admin reset forces a change at next sign-in", func(t *testing.T) {
+		require.NoError(t, policy.AdminResetPassword(ctx, adminID, user.ID, "Temporary!Pass1"))
+		assert.True(t, policy.PasswordChangeRequired(ctx, user.ID))
+
+		stored, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NoError(t, policy.SetPassword(ctx, stored, "Chosen!Passw0rd9", false))
+		assert.False(t, policy.PasswordChangeRequired(ctx, user.ID))
+	})
+
+	t.Run("admins cannot reset the password of more privileged accounts", func(t *testing.T) {
+		permissions := services.NewPermissionService(repositories.NewRoleRepository(db), repositories.NewPermissionRepository(db),
+			userRepo, logService, logger)
+		policy.SetPermissions(permissions)
+		defer policy.SetPermissions(nil)
+
+		_, err := permissions.CreateRole(ctx, adminID, services.RoleRequest{
+			Name: "support", Permissions: []string{models.PermUsersRead, models.PermUsersWrite},
+		})
+		require.NoError(t, err)
+		supportID := uuid.New()
+		require.NoError(t, userRepo.Create(ctx, &models.User{
+			ID: supportID, Email: "support@example.com", Name: "Support", Status: "active", Role: "support",
+			PasswordChangedAt: time.Now(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}))
+
+		err = policy.AdminResetPassword(ctx, supportID, adminID, "Takeover!Passw0rd")
+		assert.EqualError(t, err, "cannot manage an account with permissions you do not hold")
+		assert.False(t, policy.PasswordChangeRequired(ctx, adminID))
+
+		memberID := uuid.New()
+		require.NoError(t, userRepo.Create(ctx, &models.User{
+			ID: memberID, Email: "reset@example.com", Name: "Reset", Status: "active", Role: "user",
+			PasswordChangedAt: time.Now(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}))
+		require.NoError(t, policy.AdminResetPassword(ctx, supportID, memberID, "Support!Passw0rd"))
+	})
+
+	t.Run("passwords expire after the maximum age", func(t *testing.T) {
+		_, err := settings.UpdateSettings(ctx, adminID, map[string]interface{}{models.SettingPasswordMaxAgeDays: float64(30)})
+		require.NoError(t, err)
+
+		stored, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.False(t, policy.ChangeRequired(ctx, stored))
+
+		stored.PasswordChangedAt = time.Now().Add(-31 * 24 * time.Hour)
+		assert.True(t, policy.ChangeRequired(ctx, stored))
+	})
+
+	t.Run("sign-in upgrades legacy bcrypt hashes", func(t *testing.T) {
+		legacy, err := bcrypt.GenerateFromPassword([]byte("Legacy!Passw0rd"), bcrypt.MinCost)
+		require.NoError(t, err)
+		stored, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NoError(t, userRepo.UpdatePasswordHash(ctx, user.ID, string(legacy)))
+
+		_, _, _, err = authService.Login(ctx, services.LoginRequest{Email: user.Email, Password: "Legacy!Passw0rd"})
+		require.NoError(t, err)
+
+		upgraded, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(upgraded.PasswordHash, "$argon2id$"), upgraded.PasswordHash)
+		assert.True(t, auth.CheckPasswordHash("Legacy!Passw0rd", upgraded.PasswordHash))
+		assert.WithinDuration(t, stored.PasswordChangedAt, upgraded.PasswordChangedAt, time.Second, "the password's age is unchanged")
+	})
+}
@@ -0,0 +1,109 @@
+package permissions_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestPermissionDelegation(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "permissions.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	permissions := services.NewPermissionService(repositories.NewRoleRepository(db), repositories.NewPermissionRepository(db),
+		userRepo, logService, logger)
+
+	createUser := func(email, role string) *models.User {
+		now := time.Now()
+		user := &models.User{
+			ID: uuid.New(), Email: email, PasswordHash: "x", Name: email, Status: "active", Role: role,
+			PasswordChangedAt: now, CreatedAt: now, UpdatedAt: now,
+		}
+		require.NoError(t, userRepo.Create(ctx, user))
+		return user
+	}
+
+	admin := createUser("admin@example.com", "admin")
+	_, err = permissions.CreateRole(ctx, admin.ID, services.RoleRequest{
+		Name: "manager", Permissions: []string{models.PermRolesManage, models.PermUsersRead},
+	})
+	require.NoError(t, err)
+	manager := createUser("manager@example.com", "manager")
+	member := createUser("member@example.com", "user")
+
+	t.Run("only held permissions can be granted", func(t *testing.T) {
+		err := permissions.GrantPermission(ctx, manager.ID, manager.ID, models.PermAdminsManage)
+		assert.EqualError(t, err, "cannot grant permissions you do not hold: admins.manage")
+		require.NoError(t, permissions.GrantPermission(ctx, manager.ID, member.ID, models.PermUsersRead))
+	})
+
+	t.Run("roles cannot carry more than the actor holds", func(t *testing.T) {
+		_, err := permissions.CreateRole(ctx, manager.ID, services.RoleRequest{
+			Name: "elevated", Permissions: []string{models.PermAdminsManage},
+		})
+		assert.EqualError(t, err, "cannot grant permissions you do not hold: admins.manage")
+
+		_, err = permissions.CreateRole(ctx, manager.ID, services.RoleRequest{
+			Name: "viewer", Permissions: []string{models.PermUsersRead},
+		})
+		require.NoError(t, err)
+		_, err = permissions.UpdateRole(ctx, manager.ID, services.RoleRequest{
+			Name: "viewer", Permissions: []string{models.PermUsersRead, models.PermUsersWrite},
+		})
+		assert.EqualError(t, err, "cannot grant permissions you do not hold: users.write")
+	})
+
+	t.Run("the actor's own role cannot be changed", func(t *testing.T) {
+		_, err := permissions.UpdateRole(ctx, manager.ID, services.RoleRequest{
+			Name: "manager", Permissions: []string{models.PermRolesManage, models.PermUsersRead},
+		})
+		assert.EqualError(t, err, "cannot change your own role")
+	})
+
+	t.Run("roles are only assigned within the actor's permissions", func(t *testing.T) {
+		assert.EqualError(t, permissions.AssignRole(ctx, manager.ID, member.ID, "admin"), "cannot grant permissions you do not hold")
+		assert.EqualError(t, permissions.AssignRole(ctx, manager.ID, admin.ID, "viewer"), "cannot grant permissions you do not hold",
+			"moving a user out of a role needs its permissions too")
+		require.NoError(t, permissions.AssignRole(ctx, manager.ID, member.ID, "viewer"))
+		require.NoError(t, permissions.AssignRole(ctx, admin.ID, member.ID, "manager"))
+	})
+
+	t.Run("users holding admin permissions cannot be impersonated", func(t *testing.T) {
+		switches := services.NewAccountSwitchService(repositories.NewAccountSwitchRepository(db), userRepo, logService,
+			services.NewNotificationService(repositories.NewNotificationRepository(db), logger), "test-secret", time.Hour, logger)
+		switches.SetPermissions(permissions)
+
+		_, err := switches.StartImpersonation(ctx, admin.ID, manager.ID, "support", 0, nil, nil)
+		assert.EqualError(t, err, "cannot impersonate an admin", "a custom role with admin permissions")
+
+		granted := createUser("granted@example.com", "user")
+		require.NoError(t, permissions.GrantPermission(ctx, admin.ID, granted.ID, models.PermUsersRead))
+		_, err = switches.StartImpersonation(ctx, admin.ID, granted.ID, "support", 0, nil, nil)
+		assert.EqualError(t, err, "cannot impersonate an admin", "a directly granted permission")
+
+		plain := createUser("plain@example.com", "user")
+		_, err = switches.StartImpersonation(ctx, admin.ID, plain.ID, "support", 0, nil, nil)
+		require.NoError(t, err)
+	})
+}
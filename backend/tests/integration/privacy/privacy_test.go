@@ -0,0 +1,135 @@
+package privacy_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestPrivacy(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "privacy.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	settingsRepo := repositories.NewSettingsRepository(db)
+	messageRepo := repositories.NewMessageRepository(db)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+	settingsService := services.NewSettingsService(settingsRepo, userRepo, logger)
+	privacy := services.NewPrivacyService(settingsRepo, userRepo, repositories.NewConnectionRepository(db), logger)
+	messaging := services.NewMessagingService(messageRepo, userRepo, privacy, logger)
+	search := services.NewSearchService(repositories.NewSearchRepository(db), repositories.NewDashboardRepository(db),
+		messageRepo, repositories.NewNotificationRepository(db), repositories.NewCustomCRUDRepository(db), userRepo, privacy, logger)
+
+	signup := func(email, name string) *models.User {
+		user, _, err := authService.Signup(ctx, services.SignupRequest{Email: email, Password: "Str0ng!Passw0rd", Name: name})
+		require.NoError(t, err)
+		return user
+	}
+	viewer := signup("viewer@example.com", "Viewer")
+	open := signup("open@example.com", "Searchable Open")
+	hidden := signup("hidden@example.com", "Searchable Hidden")
+
+	searchUsers := func(query string) []*models.PublicProfile {
+		result, err := search.Search(ctx, &models.Tenant{UserID: viewer.ID}, services.SearchRequest{Query: query, Type: "users"})
+		require.NoError(t, err)
+		var profiles []*models.PublicProfile
+		for _, r := range result.Data.(map[string]interface{})["results"].([]models.SearchResult) {
+			profiles = append(profiles, r.Data.(*models.PublicProfile))
+		}
+		return profiles
+	}
+
+	t.Run("defaults hide email and phone", func(t *testing.T) {
+		profiles := searchUsers("Searchable")
+		require.Len(t, profiles, 2)
+		for _, profile := range profiles {
+			assert.Nil(t, profile.Email)
+			assert.Nil(t, profile.Phone)
+			assert.True(t, profile.CanMessage)
+		}
+		assert.Empty(t, searchUsers("open@example.com"), "private emails are not searchable")
+	})
+
+	t.Run("settings are enforced", func(t *testing.T) {
+		require.NoError(t, settingsService.UpdatePrivacySettings(ctx, open.ID, map[string]interface{}{"email_visibility": "public"}))
+		require.NoError(t, settingsService.UpdatePrivacySettings(ctx, hidden.ID, map[string]interface{}{
+			"search_visibility":  false,
+			"profile_visibility": "private",
+			"allow_messaging":    "none",
+		}))
+		assert.Error(t, settingsService.UpdatePrivacySettings(ctx, open.ID, map[string]interface{}{"allow_messaging": "anyone"}))
+
+		profiles := searchUsers("Searchable")
+		require.Len(t, profiles, 1)
+		assert.Equal(t, open.ID, profiles[0].ID)
+		require.NotNil(t, profiles[0].Email)
+		assert.Equal(t, "open@example.com", *profiles[0].Email)
+		assert.Len(t, searchUsers("open@example.com"), 1)
+
+		profile, err := privacy.Profile(ctx, viewer.ID, hidden.ID)
+		require.NoError(t, err)
+		assert.True(t, profile.Restricted)
+		assert.False(t, profile.CanMessage)
+		assert.Nil(t, profile.Email)
+
+		own, err := privacy.Profile(ctx, hidden.ID, hidden.ID)
+		require.NoError(t, err)
+		assert.False(t, own.Restricted)
+		require.NotNil(t, own.Email)
+
+		_, err = messaging.SendMessage(ctx, viewer.ID, hidden.ID, nil, "hello", nil)
+		assert.EqualError(t, err, "recipient does not accept messages")
+		_, err = messaging.SendMessage(ctx, viewer.ID, open.ID, nil, "hello", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("location search only matches visible profiles", func(t *testing.T) {
+		connectionRepo := repositories.NewConnectionRepository(db)
+		nearby := map[string]*models.User{}
+		for _, visibility := range []string{"public", "friends", "private"} {
+			user := signup(visibility+"@berlin.example.com", "Berliner "+visibility)
+			require.NoError(t, settingsService.UpdatePrivacySettings(ctx, user.ID, map[string]interface{}{"profile_visibility": visibility}))
+			_, err := db.ExecContext(ctx, `UPDATE sessions SET location_country = 'DE', location_city = 'Berlin' WHERE user_id = ?`, user.ID)
+			require.NoError(t, err)
+			nearby[visibility] = user
+		}
+		city := "Berlin"
+		searchBerlin := func() []uuid.UUID {
+			result, err := search.Search(ctx, &models.Tenant{UserID: viewer.ID}, services.SearchRequest{City: &city, Type: "users"})
+			require.NoError(t, err)
+			var ids []uuid.UUID
+			for _, r := range result.Data.(map[string]interface{})["results"].([]models.SearchResult) {
+				ids = append(ids, r.Data.(*models.PublicProfile).ID)
+			}
+			return ids
+		}
+
+		assert.ElementsMatch(t, []uuid.UUID{nearby["public"].ID}, searchBerlin())
+
+		now := time.Now()
+		require.NoError(t, connectionRepo.Create(ctx, &models.Connection{ID: uuid.New(), RequesterID: viewer.ID,
+			AddresseeID: nearby["friends"].ID, Status: models.ConnectionAccepted, CreatedAt: now, AcceptedAt: &now}))
+		assert.ElementsMatch(t, []uuid.UUID{nearby["public"].ID, nearby["friends"].ID}, searchBerlin(),
+			"friends see friends-only profiles, nobody sees private ones")
+	})
+}
@@ -0,0 +1,185 @@
+package retention_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/monitoring"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := t.TempDir()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(dir, "retention.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	metrics := monitoring.NewMetrics(logger)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	retention := services.NewRetentionService(config.RetentionConfig{BatchSize: 2, ArchiveDir: filepath.Join(dir, "archives")},
+		repositories.NewRetentionRepository(db), metrics, logService, logger)
+	adminID := uuid.New()
+	userID := uuid.New()
+	now := time.Now()
+	userRepo := repositories.NewUserRepository(db)
+	for _, user := range []*models.User{
+		{ID: adminID, Email: "admin@example.com", Name: "Admin", Role: "admin", Status: "active", CreatedAt: now, UpdatedAt: now},
+		{ID: userID, Email: "user@example.com", Name: "User", Role: "user", Status: "active", CreatedAt: now, UpdatedAt: now},
+	} {
+		require.NoError(t, userRepo.Create(ctx, user))
+	}
+	notificationRepo := repositories.NewNotificationRepository(db)
+
+	count := func(query string, args ...interface{}) int {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, query, args...).Scan(&n))
+		return n
+	}
+	notify := func(title string, age time.Duration) {
+		require.NoError(t, notificationRepo.Create(ctx, &models.Notification{
+			ID: uuid.New(), UserID: userID, Type: "system", Title: title, Message: title, CreatedAt: time.Now().Add(-age),
+		}))
+	}
+	search := func(query string, age time.Duration) {
+		_, err := db.ExecContext(ctx, `INSERT INTO search_history (id, user_id, query, created_at) VALUES (?, ?, ?, ?)`,
+			uuid.New().String(), userID.String(), query, time.Now().Add(-age))
+		require.NoError(t, err)
+	}
+
+	t.Run("enabled rules need a limit", func(t *testing.T) {
+		_, err := retention.UpdateRule(ctx, adminID, models.RetentionNotifications, true, nil, nil, false)
+		assert.EqualError(t, err, "an enabled rule needs max_age_days or max_rows")
+		_, err = retention.UpdateRule(ctx, adminID, "users", true, intPtr(1), nil, false)
+		assert.EqualError(t, err, "unknown data class")
+	})
+
+	t.Run("age rules archive rows before purging them in batches", func(t *testing.T) {
+		for _, title := range []string{"old 1", "old 2", "old 3"} {
+			notify(title, 40*24*time.Hour)
+		}
+		notify("recent", time.Hour)
+
+		_, err := retention.UpdateRule(ctx, adminID, models.RetentionNotifications, true, intPtr(30), nil, true)
+		require.NoError(t, err)
+
+		reports, err := retention.Preview(ctx)
+		require.NoError(t, err)
+		report := findReport(reports, models.RetentionNotifications)
+		require.NotNil(t, report)
+		assert.EqualValues(t, 4, report.RowsTotal)
+		assert.EqualValues(t, 3, report.RowsMatched)
+		assert.Equal(t, 4, count(`SELECT COUNT(*) FROM notifications`), "previews delete nothing")
+
+		runs, err := retention.Run(ctx)
+		require.NoError(t, err)
+		run := findRun(runs, models.RetentionNotifications)
+		require.NotNil(t, run)
+		assert.Nil(t, run.Error)
+		assert.EqualValues(t, 3, run.RowsPurged)
+		assert.Equal(t, 2, run.Batches)
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM notifications WHERE title = 'recent'`))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM notifications`))
+
+		require.NotNil(t, run.ArchivePath)
+		titles := readArchive(t, *run.ArchivePath)
+		assert.ElementsMatch(t, []string{"old 1", "old 2", "old 3"}, titles)
+
+		purged := metrics.GetMetrics()["retention_rows_purged_total"].(map[string]int64)
+		assert.EqualValues(t, 3, purged[models.RetentionNotifications])
+	})
+
+	t.Run("count rules keep the newest rows", func(t *testing.T) {
+		for i, query := range []string{"oldest", "older", "old", "new", "newest"} {
+			search(query, time.Duration(5-i)*time.Hour)
+		}
+		_, err := retention.UpdateRule(ctx, adminID, models.RetentionSearchHistory, true, nil, intPtr(2), false)
+		require.NoError(t, err)
+
+		runs, err := retention.Run(ctx)
+		require.NoError(t, err)
+		run := findRun(runs, models.RetentionSearchHistory)
+		require.NotNil(t, run)
+		assert.EqualValues(t, 3, run.RowsPurged)
+		assert.Nil(t, run.ArchivePath)
+		assert.Equal(t, 2, count(`SELECT COUNT(*) FROM search_history WHERE query IN ('new', 'newest')`))
+		assert.Equal(t, 2, count(`SELECT COUNT(*) FROM search_history`))
+
+		history, err := retention.Runs(ctx, 10)
+		require.NoError(t, err)
+		assert.NotEmpty(t, history)
+	})
+
+	t.Run("disabled rules purge nothing", func(t *testing.T) {
+		_, err := retention.UpdateRule(ctx, adminID, models.RetentionNotifications, false, intPtr(1), nil, false)
+		require.NoError(t, err)
+		notify("stale", 10*24*time.Hour)
+
+		runs, err := retention.Run(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, findRun(runs, models.RetentionNotifications))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM notifications WHERE title = 'stale'`))
+	})
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func findReport(reports []*models.RetentionReport, dataClass string) *models.RetentionReport {
+	for _, report := range reports {
+		if report.DataClass == dataClass {
+			return report
+		}
+	}
+	return nil
+}
+
+func findRun(runs []*models.RetentionRun, dataClass string) *models.RetentionRun {
+	for _, run := range runs {
+		if run.DataClass == dataClass {
+			return run
+		}
+	}
+	return nil
+}
+
+// readArchive returns the titles of the notifications in an archive.
+func readArchive(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var titles []string
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		var row map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		titles = append(titles, row["title"].(string))
+	}
+	require.NoError(t, scanner.Err())
+	return titles
+}
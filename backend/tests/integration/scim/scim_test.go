@@ -0,0 +1,216 @@
+package scim_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+type fixture struct {
+	scim        *services.SCIMService
+	setup       *services.SetupService
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+}
+
+func newFixture(t *testing.T) fixture {
+	logger := zap.NewNop()
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "scim.db"),
+	}, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	settings := services.NewSystemSettingsService(repositories.NewSystemSettingsRepository(db), logService, logger)
+	policy := services.NewPasswordPolicyService(settings, userRepo, repositories.NewPasswordHistoryRepository(db), nil, logService, logger)
+	deletions, err := services.NewAccountDeletionService(config.AccountDeletionConfig{GracePeriod: time.Hour},
+		repositories.NewAccountDeletionRepository(db), userRepo, sessionRepo, repositories.NewOrganizationRepository(db),
+		repositories.NewFileRepository(db), repositories.NewDataExportRepository(db), logService, logger)
+	require.NoError(t, err)
+
+	return fixture{
+		scim: services.NewSCIMService(config.SCIMConfig{BaseURL: "https://app.example.com/scim/v2"},
+			repositories.NewSCIMTokenRepository(db), userRepo, repositories.NewRoleRepository(db), repositories.NewPermissionRepository(db), sessionRepo,
+			policy, deletions, logService, logger),
+		setup:       services.NewSetupService(config.SetupConfig{}, userRepo, nil, policy, logService, logger),
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+	}
+}
+
+func patch(op, path, value string) services.SCIMPatchOperation {
+	return services.SCIMPatchOperation{Op: op, Path: path, Value: json.RawMessage(value)}
+}
+
+func TestSCIM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("tokens authenticate until revoked", func(t *testing.T) {
+		f := newFixture(t)
+		admin, err := f.setup.CreateAdmin(ctx, services.CreateAdminRequest{Email: "root@example.com", Name: "Root", Password: "Str0ng!Passw0rd"}, "cli")
+		require.NoError(t, err)
+
+		token, plaintext, err := f.scim.IssueToken(ctx, admin.ID, "okta")
+		require.NoError(t, err)
+		assert.Equal(t, plaintext[:len(token.TokenPrefix)], token.TokenPrefix)
+
+		found, err := f.scim.AuthenticateSCIMToken(ctx, plaintext)
+		require.NoError(t, err)
+		assert.Equal(t, token.ID, found.ID)
+
+		require.NoError(t, f.scim.RevokeToken(ctx, admin.ID, token.ID))
+		_, err = f.scim.AuthenticateSCIMToken(ctx, plaintext)
+		assert.Error(t, err)
+	})
+
+	t.Run("users are provisioned, patched and deprovisioned", func(t *testing.T) {
+		f := newFixture(t)
+		tokenID := uuid.New()
+
+		created, err := f.scim.CreateUser(ctx, tokenID, services.SCIMUser{
+			UserName: "Jane@Example.com",
+			Name:     &services.SCIMName{GivenName: "Jane", FamilyName: "Doe"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", created.UserName)
+		assert.Equal(t, "Jane Doe", created.DisplayName)
+		assert.True(t, *created.Active)
+
+		_, err = f.scim.CreateUser(ctx, tokenID, services.SCIMUser{UserName: "jane@example.com"})
+		assert.EqualError(t, err, "user already exists")
+
+		list, err := f.scim.ListUsers(ctx, `userName eq "JANE@example.com"`, 1, -1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, list.TotalResults)
+		_, err = f.scim.ListUsers(ctx, `userName sw "j"`, 1, -1)
+		assert.ErrorContains(t, err, "invalid filter")
+
+		id := uuid.MustParse(created.ID)
+		require.NoError(t, f.sessionRepo.Create(ctx, &models.Session{
+			ID: uuid.New(), UserID: id, Token: "t", IsActive: true,
+			ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now(), LastUsedAt: time.Now(),
+		}))
+
+		patched, err := f.scim.PatchUser(ctx, tokenID, created.ID, []services.SCIMPatchOperation{
+			patch("Replace", "name.givenName", `"Janet"`),
+			patch("Replace", "active", `"False"`),
+		})
+		require.NoError(t, err)
+		assert.False(t, *patched.Active)
+		assert.Equal(t, "Janet", patched.Name.GivenName)
+
+		user, err := f.userRepo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "disabled", user.Status)
+		sessions, err := f.sessionRepo.GetByUserID(ctx, id)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+
+		require.NoError(t, f.scim.DeleteUser(ctx, tokenID, created.ID))
+		_, err = f.scim.GetUser(ctx, created.ID)
+		assert.EqualError(t, err, "user not found")
+	})
+
+	t.Run("groups map onto roles", func(t *testing.T) {
+		f := newFixture(t)
+		tokenID := uuid.New()
+		admin, err := f.setup.CreateAdmin(ctx, services.CreateAdminRequest{Email: "root@example.com", Name: "Root", Password: "Str0ng!Passw0rd"}, "cli")
+		require.NoError(t, err)
+		user, err := f.scim.CreateUser(ctx, tokenID, services.SCIMUser{UserName: "sam@example.com"})
+		require.NoError(t, err)
+
+		group, err := f.scim.CreateGroup(ctx, tokenID, services.SCIMGroup{
+			DisplayName: "Editors",
+			Members:     []services.SCIMValue{{Value: user.ID}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "editors", group.DisplayName)
+		require.Len(t, group.Members, 1)
+
+		got, err := f.scim.GetUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "editors", got.Roles[0].Value)
+
+		_, err = f.scim.PatchGroup(ctx, tokenID, group.ID, []services.SCIMPatchOperation{
+			patch("replace", "displayName", `"writers"`),
+		})
+		assert.ErrorContains(t, err, "mutability")
+
+		group, err = f.scim.PatchGroup(ctx, tokenID, group.ID, []services.SCIMPatchOperation{
+			patch("remove", `members[value eq "`+user.ID+`"]`, ``),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, group.Members)
+		got, err = f.scim.GetUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "user", got.Roles[0].Value)
+
+		require.NoError(t, f.scim.DeleteGroup(ctx, tokenID, group.ID))
+
+		admins, err := f.scim.ListGroups(ctx, `displayName eq "admin"`, 1, -1, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, admins.TotalResults)
+		adminGroup := admins.Resources[0].(*services.SCIMGroup)
+		_, err = f.scim.ReplaceGroup(ctx, tokenID, adminGroup.ID, services.SCIMGroup{DisplayName: "admin"})
+		assert.EqualError(t, err, "mutability: the last admin cannot be removed")
+		assert.EqualError(t, f.scim.DeleteUser(ctx, tokenID, admin.ID.String()), "mutability: the last admin cannot be removed")
+	})
+
+	t.Run("tokens cannot give or take over admin access", func(t *testing.T) {
+		f := newFixture(t)
+		tokenID := uuid.New()
+		admin, err := f.setup.CreateAdmin(ctx, services.CreateAdminRequest{Email: "root@example.com", Name: "Root", Password: "Str0ng!Passw0rd"}, "cli")
+		require.NoError(t, err)
+
+		_, err = f.scim.CreateUser(ctx, tokenID, services.SCIMUser{
+			UserName: "mallory@example.com",
+			Roles:    []services.SCIMValue{{Value: "admin"}},
+		})
+		assert.EqualError(t, err, "mutability: roles with admin permissions cannot be assigned over SCIM")
+
+		user, err := f.scim.CreateUser(ctx, tokenID, services.SCIMUser{UserName: "sam@example.com"})
+		require.NoError(t, err)
+		_, err = f.scim.PatchUser(ctx, tokenID, user.ID, []services.SCIMPatchOperation{
+			patch("replace", "roles", `[{"value": "admin"}]`),
+		})
+		assert.EqualError(t, err, "mutability: roles with admin permissions cannot be assigned over SCIM")
+
+		admins, err := f.scim.ListGroups(ctx, `displayName eq "admin"`, 1, -1, true)
+		require.NoError(t, err)
+		adminGroup := admins.Resources[0].(*services.SCIMGroup)
+		_, err = f.scim.PatchGroup(ctx, tokenID, adminGroup.ID, []services.SCIMPatchOperation{
+			patch("add", "members", `[{"value": "`+user.ID+`"}]`),
+		})
+		assert.EqualError(t, err, "mutability: roles with admin permissions cannot be assigned over SCIM")
+
+		for _, operation := range []services.SCIMPatchOperation{
+			patch("replace", "password", `"An0ther!Passw0rd"`),
+			patch("replace", "userName", `"mallory@example.com"`),
+		} {
+			_, err = f.scim.PatchUser(ctx, tokenID, admin.ID.String(), []services.SCIMPatchOperation{operation})
+			assert.EqualError(t, err, "mutability: the password and email of accounts with admin permissions cannot be changed over SCIM")
+		}
+		_, err = f.scim.PatchUser(ctx, tokenID, admin.ID.String(), []services.SCIMPatchOperation{
+			patch("replace", "name.givenName", `"Ruth"`),
+		})
+		require.NoError(t, err, "other attributes of admins can still be synced")
+	})
+}
@@ -0,0 +1,64 @@
+package sessions_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/database"
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+func TestRevokedSessions(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "sessions.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	authService := services.NewAuthService(repositories.NewUserRepository(db), repositories.NewSessionRepository(db),
+		repositories.NewDeviceRepository(db), "test-secret", time.Minute, time.Hour, logger)
+	protected := middleware.AuthMiddleware("test-secret", nil, authService, logger)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	call := func(token string) int {
+		req := httptest.NewRequest("GET", "/v1/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		protected.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	user, session, err := authService.Signup(ctx, services.SignupRequest{
+		Email: "member@example.com", Password: "Str0ng!Passw0rd", Name: "Member",
+	})
+	require.NoError(t, err)
+	_, other, _, err := authService.Login(ctx, services.LoginRequest{Email: user.Email, Password: "Str0ng!Passw0rd"})
+	require.NoError(t, err)
+
+	t.Run("access tokens stop working when their session is revoked", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, call(session.Token))
+		require.NoError(t, authService.RevokeSession(ctx, user.ID, session.ID))
+		assert.Equal(t, http.StatusUnauthorized, call(session.Token))
+		assert.Equal(t, http.StatusOK, call(other.Token), "other sessions are unaffected")
+	})
+
+	t.Run("signing out ends the session's access tokens", func(t *testing.T) {
+		require.NoError(t, authService.Logout(ctx, other.ID, false))
+		assert.Equal(t, http.StatusUnauthorized, call(other.Token))
+	})
+}
@@ -0,0 +1,126 @@
+package setup_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+type fixture struct {
+	userRepo repositories.UserRepository
+	newSetup func(cfg config.SetupConfig) *services.SetupService
+}
+
+func newFixture(t *testing.T) fixture {
+	logger := zap.NewNop()
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "setup.db"),
+	}, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	settings := services.NewSystemSettingsService(repositories.NewSystemSettingsRepository(db), logService, logger)
+	policy := services.NewPasswordPolicyService(settings, userRepo, repositories.NewPasswordHistoryRepository(db), nil, logService, logger)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), repositories.NewDeviceRepository(db),
+		"test-secret", time.Minute, time.Hour, logger)
+
+	return fixture{
+		userRepo: userRepo,
+		newSetup: func(cfg config.SetupConfig) *services.SetupService {
+			return services.NewSetupService(cfg, userRepo, authService, policy, logService, logger)
+		},
+	}
+}
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("generated token creates the first admin once", func(t *testing.T) {
+		f := newFixture(t)
+		setup := f.newSetup(config.SetupConfig{})
+		token, err := setup.Init(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+
+		required, err := setup.Required(ctx)
+		require.NoError(t, err)
+		assert.True(t, required)
+
+		request := services.CompleteSetupRequest{Token: "wrong", Email: "Root@Example.com", Name: "Root", Password: "Str0ng!Passw0rd"}
+		_, _, err = setup.Complete(ctx, request)
+		assert.EqualError(t, err, "invalid setup token")
+
+		request.Token = token
+		request.Password = "short"
+		_, _, err = setup.Complete(ctx, request)
+		assert.Error(t, err)
+
+		request.Password = "Str0ng!Passw0rd"
+		admin, session, err := setup.Complete(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, "root@example.com", admin.Email)
+		assert.Equal(t, "admin", admin.Role)
+		assert.NotEmpty(t, session.Token)
+
+		required, err = setup.Required(ctx)
+		require.NoError(t, err)
+		assert.False(t, required)
+		request.Email = "second@example.com"
+		_, _, err = setup.Complete(ctx, request)
+		assert.EqualError(t, err, "setup is already complete")
+
+		// A restart with an admin in place arms nothing
+		token, err = f.newSetup(config.SetupConfig{}).Init(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, token)
+	})
+
+	t.Run("configured token is not echoed", func(t *testing.T) {
+		f := newFixture(t)
+		setup := f.newSetup(config.SetupConfig{Token: "from-env"})
+		token, err := setup.Init(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, token)
+
+		_, _, err = setup.Complete(ctx, services.CompleteSetupRequest{
+			Token: "from-env", Email: "ops@example.com", Name: "Ops", Password: "Str0ng!Passw0rd",
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("admin created elsewhere ends setup", func(t *testing.T) {
+		f := newFixture(t)
+		setup := f.newSetup(config.SetupConfig{})
+		token, err := setup.Init(ctx)
+		require.NoError(t, err)
+
+		_, err = setup.CreateAdmin(ctx, services.CreateAdminRequest{Email: "cli@example.com", Name: "CLI", Password: "Str0ng!Passw0rd"}, "cli")
+		require.NoError(t, err)
+		_, err = setup.CreateAdmin(ctx, services.CreateAdminRequest{Email: "cli@example.com", Name: "CLI", Password: "Str0ng!Passw0rd"}, "cli")
+		assert.EqualError(t, err, "user already exists")
+
+		_, _, err = setup.Complete(ctx, services.CompleteSetupRequest{
+			Token: token, Email: "late@example.com", Name: "Late", Password: "Str0ng!Passw0rd",
+		})
+		assert.EqualError(t, err, "setup is already complete")
+
+		first, err := f.userRepo.FirstByRole(ctx, "admin")
+		require.NoError(t, err)
+		require.NotNil(t, first)
+		assert.Equal(t, "cli@example.com", first.Email)
+	})
+}
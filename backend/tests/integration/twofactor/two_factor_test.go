@@ -0,0 +1,274 @@
+package twofactor_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/config"
+	"base-app-service/internal/database"
+	"base-app-service/internal/models"
+	"base-app-service/internal/repositories"
+	"base-app-service/internal/services"
+)
+
+// fakeProvider records texts instead of sending them.
+type fakeProvider struct {
+	sent chan [2]string
+}
+
+func (p *fakeProvider) Send(ctx context.Context, to, message string) error {
+	p.sent <- [2]string{to, message}
+	return nil
+}
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// next returns the phone and code of the next text sent.
+func (p *fakeProvider) next(t *testing.T) (string, string) {
+	t.Helper()
+	select {
+	case msg := <-p.sent:
+		return msg[0], codePattern.FindString(msg[1])
+	case <-time.After(time.Second):
+		t.Fatal("no text message sent")
+		return "", ""
+	}
+}
+
+func TestTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	db, err := database.NewConnection(database.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "twofactor.db"),
+	}, logger)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.RunMigrations("../../../migrations"))
+
+	userRepo := repositories.NewUserRepository(db)
+	codeRepo := repositories.NewSMSCodeRepository(db)
+	twoFactorRepo := repositories.NewTwoFactorRepository(db)
+	logService := services.NewActivityLogService(repositories.NewActivityLogRepository(db), logger)
+	settingsService := services.NewSettingsService(repositories.NewSettingsRepository(db), userRepo, logger)
+	notificationService := services.NewNotificationService(repositories.NewNotificationRepository(db), logger)
+	deviceRepo := repositories.NewDeviceRepository(db)
+	authService := services.NewAuthService(userRepo, repositories.NewSessionRepository(db), deviceRepo,
+		"test-secret", time.Minute, time.Hour, logger)
+
+	provider := &fakeProvider{sent: make(chan [2]string, 10)}
+	smsService := services.NewSMSService(
+		config.SMSConfig{CodeTTL: 10 * time.Minute, MaxAttempts: 3, MaxPerHour: 5},
+		provider, codeRepo, twoFactorRepo, userRepo, settingsService, logService, logger,
+	)
+	notificationService.OnCreated(smsService.NotifySecurity)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, codeRepo, userRepo, smsService,
+		authService, settingsService, notificationService, logService, logger)
+	deviceService := services.NewDeviceService(config.DeviceConfig{TrustDuration: time.Hour}, deviceRepo,
+		authService, notificationService, nil, logService, logger)
+	twoFactorService.SetDevices(deviceService)
+	authService.SetTwoFactor(twoFactorService)
+
+	user, _, err := authService.Signup(ctx, services.SignupRequest{
+		Email: "member@example.com", Password: "Str0ng!Passw0rd", Name: "Member",
+	})
+	require.NoError(t, err)
+
+	t.Run("two-factor needs a verified phone", func(t *testing.T) {
+		assert.EqualError(t, twoFactorService.EnableSMS(ctx, user.ID, "Str0ng!Passw0rd"), "verify a phone number first")
+	})
+
+	t.Run("phone numbers are verified with a texted code", func(t *testing.T) {
+		_, err := smsService.StartPhoneVerification(ctx, user.ID, "12345", nil)
+		assert.EqualError(t, err, "phone number must start with + and the country code")
+
+		_, err = smsService.StartPhoneVerification(ctx, user.ID, "+49 151 1234 5678", nil)
+		require.NoError(t, err)
+		to, code := provider.next(t)
+		assert.Equal(t, "+4915112345678", to)
+
+		_, err = smsService.ConfirmPhone(ctx, user.ID, "000000")
+		assert.EqualError(t, err, "invalid or expired code")
+
+		verified, err := smsService.ConfirmPhone(ctx, user.ID, code)
+		require.NoError(t, err)
+		require.NotNil(t, verified.Phone)
+		assert.Equal(t, "+4915112345678", *verified.Phone)
+		assert.True(t, verified.PhoneVerified)
+	})
+
+	t.Run("codes stop working after too many wrong guesses", func(t *testing.T) {
+		_, err := smsService.StartPhoneVerification(ctx, user.ID, "+4915199999999", nil)
+		require.NoError(t, err)
+		_, code := provider.next(t)
+
+		for i := 0; i < 3; i++ {
+			_, err = smsService.ConfirmPhone(ctx, user.ID, "000000")
+			assert.EqualError(t, err, "invalid or expired code")
+		}
+		_, err = smsService.ConfirmPhone(ctx, user.ID, code)
+		assert.EqualError(t, err, "invalid or expired code")
+	})
+
+	t.Run("wrong guesses in parallel still lock the code", func(t *testing.T) {
+		racer, _, err := authService.Signup(ctx, services.SignupRequest{
+			Email: "racer@example.com", Password: "Str0ng!Passw0rd", Name: "Racer",
+		})
+		require.NoError(t, err)
+		_, err = smsService.StartPhoneVerification(ctx, racer.ID, "+4915188888888", nil)
+		require.NoError(t, err)
+		_, code := provider.next(t)
+		pending, err := codeRepo.GetPending(ctx, racer.ID, models.SMSPurposePhoneVerification, time.Now())
+		require.NoError(t, err)
+		require.NotNil(t, pending)
+
+		// Wrong guesses made alongside this one counted before the code was locked
+		_, err = db.ExecContext(ctx, `UPDATE sms_codes SET attempts = 3 WHERE id = ?`, pending.ID.String())
+		require.NoError(t, err)
+		used, err := codeRepo.Consume(ctx, pending.ID, time.Now(), 3)
+		require.NoError(t, err)
+		assert.False(t, used, "a request that loaded the code before the guesses cannot consume it")
+		_, err = smsService.ConfirmPhone(ctx, racer.ID, code)
+		assert.EqualError(t, err, "invalid or expired code")
+	})
+
+	t.Run("enabling needs the password", func(t *testing.T) {
+		assert.EqualError(t, twoFactorService.EnableSMS(ctx, user.ID, "wrong"), "invalid credentials")
+		require.NoError(t, twoFactorService.EnableSMS(ctx, user.ID, "Str0ng!Passw0rd"))
+
+		status, err := twoFactorService.Status(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.Equal(t, "••••5678", status.Phone)
+
+		settings, err := settingsService.GetSettings(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, settings.TwoFactorEnabled)
+	})
+
+	t.Run("the phone cannot change while two-factor is on", func(t *testing.T) {
+		_, err := smsService.StartPhoneVerification(ctx, user.ID, "+4915100000000", nil)
+		assert.EqualError(t, err, "turn off two-factor authentication before changing your phone number")
+	})
+
+	t.Run("signing in needs the texted code", func(t *testing.T) {
+		_, session, _, err := authService.Login(ctx, services.LoginRequest{Email: user.Email, Password: "Str0ng!Passw0rd"})
+		assert.Nil(t, session)
+		var challenge *services.TwoFactorRequiredError
+		require.True(t, errors.As(err, &challenge))
+		assert.Equal(t, "sms", challenge.Method)
+		assert.Equal(t, "••••5678", challenge.Destination)
+		_, code := provider.next(t)
+
+		_, _, _, _, err = twoFactorService.Verify(ctx, challenge.Token, "000000", false, services.ClientInfo{})
+		assert.EqualError(t, err, "invalid or expired code")
+
+		signedIn, session, _, _, err := twoFactorService.Verify(ctx, challenge.Token, code, false, services.ClientInfo{})
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, signedIn.ID)
+		assert.NotEmpty(t, session.Token)
+
+		_, _, _, _, err = twoFactorService.Verify(ctx, challenge.Token, code, false, services.ClientInfo{})
+		assert.EqualError(t, err, "invalid or expired code", "codes work once")
+	})
+
+	t.Run("trusted devices skip the texted code", func(t *testing.T) {
+		// A second user, as the first has few codes left this hour
+		other, _, err := authService.Signup(ctx, services.SignupRequest{
+			Email: "other@example.com", Password: "Str0ng!Passw0rd", Name: "Other",
+		})
+		require.NoError(t, err)
+		_, err = smsService.StartPhoneVerification(ctx, other.ID, "+4915122223333", nil)
+		require.NoError(t, err)
+		_, code := provider.next(t)
+		_, err = smsService.ConfirmPhone(ctx, other.ID, code)
+		require.NoError(t, err)
+		require.NoError(t, twoFactorService.EnableSMS(ctx, other.ID, "Str0ng!Passw0rd"))
+
+		deviceID := "laptop"
+		client := services.ClientInfo{DeviceID: &deviceID}
+		login := services.LoginRequest{Email: other.Email, Password: "Str0ng!Passw0rd", DeviceID: &deviceID}
+
+		_, _, _, err = authService.Login(ctx, login)
+		var challenge *services.TwoFactorRequiredError
+		require.True(t, errors.As(err, &challenge))
+		_, code = provider.next(t)
+		_, _, _, trustToken, err := twoFactorService.Verify(ctx, challenge.Token, code, true, client)
+		require.NoError(t, err)
+		require.NotEmpty(t, trustToken)
+
+		// The device ID alone is not enough: it is no secret
+		_, _, _, err = authService.Login(ctx, login)
+		require.True(t, errors.As(err, &challenge), "the device ID without its trust token is challenged")
+		provider.next(t)
+
+		login.DeviceTrustToken = &trustToken
+		_, session, _, err := authService.Login(ctx, login)
+		require.NoError(t, err)
+		assert.NotEmpty(t, session.Token)
+
+		device, err := deviceRepo.GetByDeviceID(ctx, other.ID, &deviceID)
+		require.NoError(t, err)
+		_, err = deviceService.Untrust(ctx, other.ID, device.ID)
+		require.NoError(t, err)
+		_, _, _, err = authService.Login(ctx, login)
+		require.True(t, errors.As(err, &challenge), "untrusted devices are challenged again")
+		provider.next(t)
+	})
+
+	t.Run("admins signing in are challenged too", func(t *testing.T) {
+		adminService := services.NewAdminService(userRepo, authService, logService,
+			repositories.NewAccessRequestRepository(db), nil, logger)
+		admin, err := userRepo.GetByEmail(ctx, "other@example.com")
+		require.NoError(t, err)
+		admin.Role = "admin"
+		require.NoError(t, userRepo.Update(ctx, admin))
+
+		_, session, err := adminService.Login(ctx, services.AdminLoginRequest{Email: admin.Email, Password: "Str0ng!Passw0rd"})
+		assert.Nil(t, session)
+		var challenge *services.TwoFactorRequiredError
+		require.True(t, errors.As(err, &challenge))
+		provider.next(t)
+	})
+
+	t.Run("security notifications are texted to users who opted in", func(t *testing.T) {
+		_, err := notificationService.CreateNotification(ctx, user.ID, "security", "Quiet", "Not texted", nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, settingsService.UpdateNotificationSettings(ctx, user.ID, map[string]interface{}{
+			"sms_notifications":     true,
+			"notification_security": true,
+		}, services.ClientInfo{}))
+		_, err = notificationService.CreateNotification(ctx, user.ID, "security", "New sign-in", "From a new device", nil, nil)
+		require.NoError(t, err)
+
+		select {
+		case msg := <-provider.sent:
+			assert.Equal(t, "+4915112345678", msg[0])
+			assert.Equal(t, "Base App: New sign-in: From a new device", msg[1])
+		case <-time.After(time.Second):
+			t.Fatal("security notification was not texted")
+		}
+	})
+
+	t.Run("codes are rate limited", func(t *testing.T) {
+		require.NoError(t, twoFactorService.Disable(ctx, user.ID, "Str0ng!Passw0rd"))
+		// Three codes were sent already this hour
+		for i := 0; i < 2; i++ {
+			_, err := smsService.StartPhoneVerification(ctx, user.ID, "+4915100000000", nil)
+			require.NoError(t, err)
+			provider.next(t)
+		}
+		_, err := smsService.StartPhoneVerification(ctx, user.ID, "+4915100000000", nil)
+		assert.EqualError(t, err, "too many codes requested, try again later")
+	})
+}
@@ -0,0 +1,75 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"base-app-service/pkg/auth"
+)
+
+// useHashParams switches the hash parameters for the rest of the test.
+func useHashParams(t *testing.T, params auth.HashParams) {
+	t.Helper()
+	require.NoError(t, auth.SetHashParams(params))
+	t.Cleanup(func() { require.NoError(t, auth.SetHashParams(auth.DefaultHashParams)) })
+}
+
+func TestArgon2idHash(t *testing.T) {
+	params := auth.DefaultHashParams
+	params.Argon2Memory = 8 * 1024
+	useHashParams(t, params)
+
+	hash, err := auth.GenerateHash("Str0ng!Passw0rd")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=3,p=2$"), hash)
+
+	assert.True(t, auth.CheckPasswordHash("Str0ng!Passw0rd", hash))
+	assert.False(t, auth.CheckPasswordHash("Str0ng!Passw0rd1", hash))
+	assert.False(t, auth.NeedsRehash(hash))
+
+	other, err := auth.GenerateHash("Str0ng!Passw0rd")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "each hash gets its own salt")
+
+	assert.False(t, auth.CheckPasswordHash("Str0ng!Passw0rd", "$argon2id$v=19$m=8192,t=3,p=2$bad"))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("Str0ng!Passw0rd"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	weak := auth.DefaultHashParams
+	weak.Argon2Memory = 8 * 1024
+	weak.Argon2Iterations = 1
+	useHashParams(t, weak)
+	weakHash, err := auth.GenerateHash("Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	strong := weak
+	strong.Argon2Iterations = 2
+	useHashParams(t, strong)
+	strongHash, err := auth.GenerateHash("Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	assert.True(t, auth.CheckPasswordHash("Str0ng!Passw0rd", string(legacy)), "bcrypt hashes keep verifying")
+	assert.True(t, auth.NeedsRehash(string(legacy)), "bcrypt is upgraded to argon2id")
+	assert.True(t, auth.NeedsRehash(weakHash), "lower argon2id cost is upgraded")
+
+	// Moving back to weaker settings does not downgrade existing hashes
+	useHashParams(t, weak)
+	assert.False(t, auth.NeedsRehash(strongHash))
+
+	useHashParams(t, auth.HashParams{Algorithm: auth.HashBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	assert.True(t, auth.NeedsRehash(string(legacy)), "lower bcrypt cost is upgraded")
+	assert.True(t, auth.NeedsRehash(weakHash), "argon2id moves to the configured bcrypt")
+}
+
+func TestSetHashParamsRejectsInvalidSettings(t *testing.T) {
+	assert.Error(t, auth.SetHashParams(auth.HashParams{Algorithm: "md5"}))
+	assert.Error(t, auth.SetHashParams(auth.HashParams{Algorithm: auth.HashBcrypt, BcryptCost: 2}))
+	assert.Error(t, auth.SetHashParams(auth.HashParams{Algorithm: auth.HashArgon2id, Argon2Memory: 64, Argon2Parallelism: 1}))
+}
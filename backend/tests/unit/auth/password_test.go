@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"base-app-service/pkg/auth"
+)
+
+func TestValidatePasswordWithPolicy(t *testing.T) {
+	relaxed := auth.PasswordPolicy{MinLength: 10}
+
+	result := auth.ValidatePasswordWithPolicy("correct horse battery", relaxed)
+	assert.True(t, result.Valid, result.Errors)
+
+	result = auth.ValidatePasswordWithPolicy("correct horse battery", auth.DefaultPasswordPolicy)
+	assert.False(t, result.Valid)
+	assert.Contains(t, result.Errors, "Password must contain at least one uppercase letter")
+
+	result = auth.ValidatePasswordWithPolicy("short", relaxed)
+	assert.Contains(t, result.Errors, "Password must be at least 10 characters long")
+
+	result = auth.ValidatePasswordWithPolicy("PASSWORD123", relaxed)
+	assert.False(t, result.Valid, "common passwords are refused in any case")
+}
+
+func TestEstimatePasswordScore(t *testing.T) {
+	assert.Equal(t, 0, auth.EstimatePasswordScore("password"))
+	assert.Equal(t, 0, auth.EstimatePasswordScore("abcdefgh"))
+	assert.Less(t, auth.EstimatePasswordScore("Summer2024"), auth.EstimatePasswordScore("k7#Vq!2mZp@9wL"))
+	assert.Equal(t, 4, auth.EstimatePasswordScore("k7#Vq!2mZp@9wL"))
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	var breached []string
+	for i := 0; i < 500; i++ {
+		breached = append(breached, fmt.Sprintf("leaked-%d", i))
+	}
+	lines := make([]string, len(breached))
+	for i, password := range breached {
+		lines[i] = fmt.Sprintf("%s:%d", sha1Hex(password), i+1)
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600))
+
+	corpus, err := auth.OpenBreachedPasswords(path)
+	require.NoError(t, err)
+	defer corpus.Close()
+
+	for _, password := range breached {
+		found, err := corpus.Contains(password)
+		require.NoError(t, err)
+		assert.True(t, found, password)
+	}
+	for i := 0; i < 500; i++ {
+		found, err := corpus.Contains(fmt.Sprintf("unique-%d", i))
+		require.NoError(t, err)
+		assert.False(t, found)
+	}
+
+	var none *auth.BreachedPasswords
+	found, err := none.Contains("leaked-1")
+	require.NoError(t, err)
+	assert.False(t, found, "no corpus configured")
+}
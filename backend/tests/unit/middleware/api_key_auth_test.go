@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+)
+
+type fakeAPIKeyAuthenticator struct {
+	token string
+	key   *models.APIKey
+	user  *models.User
+}
+
+func (f *fakeAPIKeyAuthenticator) AuthenticateAPIKey(ctx context.Context, token, ipAddress string) (*models.APIKey, *models.User, error) {
+	if token != f.token {
+		return nil, nil, errors.New("invalid api key")
+	}
+	return f.key, f.user, nil
+}
+
+func TestAPIKeyAuthAndScopes(t *testing.T) {
+	logger := zap.NewNop()
+	user := &models.User{ID: uuid.New(), Role: "user"}
+	authenticator := &fakeAPIKeyAuthenticator{
+		token: "pat_valid",
+		key:   &models.APIKey{ID: uuid.New(), UserID: user.ID, Scopes: []string{"cruds:read"}},
+		user:  user,
+	}
+	rules := []middleware.ScopeRule{
+		{PathPrefix: "/v1/users/me", Exact: true, ReadScope: "profile:read"},
+		{PathPrefix: "/v1/cruds/", ReadScope: "cruds:read", WriteScope: "cruds:write"},
+	}
+
+	var seenUser uuid.UUID
+	handler := middleware.AuthMiddleware("secret", authenticator, nil, logger)(
+		middleware.RequireAPIKeyScopes(rules, logger)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seenUser = middleware.GetUserIDFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}),
+		),
+	)
+
+	do := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("unknown key is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("GET", "/v1/cruds/entities", "pat_unknown"))
+	})
+
+	t.Run("granted read scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("GET", "/v1/cruds/entities", "pat_valid"))
+		assert.Equal(t, user.ID, seenUser)
+	})
+
+	t.Run("missing write scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do("POST", "/v1/cruds/entities", "pat_valid"))
+	})
+
+	t.Run("missing profile scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do("GET", "/v1/users/me", "pat_valid"))
+	})
+
+	t.Run("route without rule is closed", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do("GET", "/v1/notifications", "pat_valid"))
+	})
+
+	t.Run("exact rules do not cover paths below them", func(t *testing.T) {
+		authenticator.key.Scopes = []string{"profile:read"}
+		assert.Equal(t, http.StatusOK, do("GET", "/v1/users/me", "pat_valid"))
+		assert.Equal(t, http.StatusForbidden, do("GET", "/v1/users/me/settings", "pat_valid"))
+		assert.Equal(t, http.StatusForbidden, do("GET", "/v1/users/me/consents", "pat_valid"))
+	})
+}
@@ -0,0 +1,58 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"base-app-service/internal/middleware"
+)
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		expectedIP   string
+	}{
+		{"direct connection", "203.0.113.7:51234", "", "", "203.0.113.7"},
+		{"spoofed header from untrusted peer", "203.0.113.7:51234", "198.51.100.9", "", "203.0.113.7"},
+		{"forwarded by trusted proxy", "10.1.2.3:443", "198.51.100.9", "", "198.51.100.9"},
+		{"client-supplied hops are skipped", "10.1.2.3:443", "1.1.1.1, 198.51.100.9", "", "198.51.100.9"},
+		{"chain of trusted proxies", "10.1.2.3:443", "198.51.100.9, 192.0.2.1, 10.0.0.5", "", "198.51.100.9"},
+		{"malformed hop stops the walk", "10.1.2.3:443", "198.51.100.9, garbage", "", "10.1.2.3"},
+		{"real ip from trusted proxy", "192.0.2.1:443", "", "198.51.100.9", "198.51.100.9"},
+		{"ipv6 peer", "[2001:db8::1]:443", "198.51.100.9", "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := middleware.ClientIPMiddleware(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = middleware.GetIPAddress(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectedIP, got)
+		})
+	}
+
+	_, err = middleware.ParseTrustedProxies([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
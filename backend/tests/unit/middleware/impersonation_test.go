@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/pkg/auth"
+)
+
+type fakeImpersonationTracker struct {
+	active   map[uuid.UUID]bool
+	recorded []string
+}
+
+func (f *fakeImpersonationTracker) IsImpersonationActive(ctx context.Context, impersonationID uuid.UUID) bool {
+	return f.active[impersonationID]
+}
+
+func (f *fakeImpersonationTracker) RecordImpersonatedRequest(ctx context.Context, impersonationID, actorID, userID uuid.UUID, method, path string) {
+	f.recorded = append(f.recorded, actorID.String()+" as "+userID.String()+" "+method+" "+path)
+}
+
+func TestImpersonation(t *testing.T) {
+	logger := zap.NewNop()
+	secret := "test-secret"
+	actorID, userID, impersonationID := uuid.New(), uuid.New(), uuid.New()
+	tracker := &fakeImpersonationTracker{active: map[uuid.UUID]bool{impersonationID: true}}
+
+	token, err := auth.GenerateImpersonationToken(userID.String(), "user", actorID.String(), impersonationID.String(), secret, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, userID, middleware.GetUserIDFromContext(r.Context()))
+		assert.Equal(t, actorID, middleware.GetActorIDFromContext(r.Context()))
+		w.WriteHeader(http.StatusOK)
+	})
+	chain := func(h http.Handler) http.Handler {
+		return middleware.AuthMiddleware(secret, nil, nil, logger)(middleware.TrackImpersonation(tracker, logger)(h))
+	}
+
+	do := func(h http.Handler, path string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("requests carry both identities and are recorded", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(chain(ok), "/v1/users/me"))
+		assert.Len(t, tracker.recorded, 1)
+		assert.Contains(t, tracker.recorded[0], actorID.String()+" as "+userID.String())
+	})
+
+	t.Run("sensitive actions are blocked", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(chain(middleware.DenyImpersonation(logger)(ok)), "/v1/users/me/password"))
+	})
+
+	t.Run("stopped sessions are rejected", func(t *testing.T) {
+		tracker.active[impersonationID] = false
+		assert.Equal(t, http.StatusUnauthorized, do(chain(ok), "/v1/users/me"))
+	})
+}
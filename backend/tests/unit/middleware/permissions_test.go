@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"base-app-service/internal/middleware"
+	"base-app-service/internal/models"
+)
+
+type fakePermissionResolver map[uuid.UUID][]string
+
+func (f fakePermissionResolver) EffectivePermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return f[userID], nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	logger := zap.NewNop()
+	support := uuid.New()
+	viewer := uuid.New()
+	resolver := fakePermissionResolver{
+		support: {models.PermUsersRead, models.PermUsersWrite},
+		viewer:  {models.PermUsersRead},
+	}
+
+	handler := middleware.LoadPermissions(resolver, logger)(
+		middleware.RequirePermission(models.PermUsersWrite, logger)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}),
+		),
+	)
+
+	do := func(userID uuid.UUID) int {
+		req := httptest.NewRequest("POST", "/v1/admin/users", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do(support))
+	assert.Equal(t, http.StatusForbidden, do(viewer))
+	assert.Equal(t, http.StatusForbidden, do(uuid.Nil))
+}
@@ -0,0 +1,71 @@
+package sms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"base-app-service/pkg/sms"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		phone string
+		want  string
+		err   string
+	}{
+		{name: "already E.164", phone: "+4915112345678", want: "+4915112345678"},
+		{name: "spaces and dashes", phone: " +1 (234) 567-8900 ", want: "+12345678900"},
+		{name: "dots", phone: "+44.20.7946.0958", want: "+442079460958"},
+		{name: "no country code", phone: "0151 12345678", err: "phone number must start with + and the country code"},
+		{name: "letters", phone: "+1 800 FLOWERS", err: "phone number contains invalid characters"},
+		{name: "plus in the middle", phone: "+49+151", err: "phone number contains invalid characters"},
+		{name: "too short", phone: "+1234567", err: "phone number is not a valid international number"},
+		{name: "too long", phone: "+1234567890123456", err: "phone number is not a valid international number"},
+		{name: "country code starts with zero", phone: "+0151123456", err: "phone number is not a valid international number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sms.Normalize(tt.phone)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "••••5678", sms.Mask("+4915112345678"))
+	assert.Equal(t, "+12", sms.Mask("+12"))
+}
+
+func TestHTTPProvider(t *testing.T) {
+	var got map[string]string
+	var auth string
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("quota exceeded"))
+	}))
+	defer server.Close()
+
+	provider := sms.NewHTTPProvider(server.URL, "secret", "BaseApp")
+	require.NoError(t, provider.Send(context.Background(), "+4915112345678", "hello"))
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, map[string]string{"from": "BaseApp", "to": "+4915112345678", "message": "hello"}, got)
+
+	status = http.StatusTooManyRequests
+	assert.EqualError(t, provider.Send(context.Background(), "+4915112345678", "hello"), "sms gateway returned 429: quota exceeded")
+}
@@ -0,0 +1,82 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"base-app-service/pkg/useragent"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		ua         string
+		browser    string
+		os         string
+		deviceType string
+	}{
+		{
+			name:       "chrome on macOS",
+			ua:         "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			browser:    "Chrome",
+			os:         "macOS",
+			deviceType: useragent.DeviceDesktop,
+		},
+		{
+			name:       "edge on windows",
+			ua:         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			browser:    "Edge",
+			os:         "Windows",
+			deviceType: useragent.DeviceDesktop,
+		},
+		{
+			name:       "firefox on linux",
+			ua:         "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			browser:    "Firefox",
+			os:         "Linux",
+			deviceType: useragent.DeviceDesktop,
+		},
+		{
+			name:       "safari on iPhone",
+			ua:         "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			browser:    "Safari",
+			os:         "iOS",
+			deviceType: useragent.DeviceMobile,
+		},
+		{
+			name:       "chrome on iPad",
+			ua:         "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+			browser:    "Chrome",
+			os:         "iOS",
+			deviceType: useragent.DeviceTablet,
+		},
+		{
+			name:       "samsung internet on android",
+			ua:         "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36",
+			browser:    "Samsung Internet",
+			os:         "Android",
+			deviceType: useragent.DeviceMobile,
+		},
+		{
+			name:       "crawler",
+			ua:         "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			deviceType: useragent.DeviceBot,
+		},
+		{
+			name: "empty",
+			ua:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := useragent.Parse(tt.ua)
+			assert.Equal(t, tt.browser, info.Browser)
+			assert.Equal(t, tt.os, info.OS)
+			assert.Equal(t, tt.deviceType, info.DeviceType)
+		})
+	}
+
+	assert.Equal(t, "Chrome on macOS", useragent.Parse(tests[0].ua).String())
+}
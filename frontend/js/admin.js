@@ -39,7 +39,7 @@ window.addEventListener('DOMContentLoaded', async () => {
     
     // Load data with error handling - same approach as user dashboard
     try {
-        await Promise.all([loadUsers(), loadTemplates(), loadCRUDs(), loadAdminSettings()]);
+        await Promise.all([loadUsers(), loadInvitations(), loadTemplates(), loadCRUDs(), loadSystemSettings()]);
     } catch (error) {
         console.error('Failed to load admin dashboard data:', error);
         // If it's an auth error, redirect will happen in API client
@@ -132,6 +132,7 @@ function renderUsers() {
                         <button class="btn btn-warning btn-sm" onclick="toggleUserStatus('${id}', '${status}')" title="Toggle Status">
                             ${status === 'active' ? '⏸️ Disable' : '▶️ Enable'}
                         </button>
+                        <button class="btn btn-secondary btn-sm" onclick="resetUserPassword('${id}')" title="Reset Password">🔑 Reset Password</button>
                         <button class="btn btn-danger btn-sm" onclick="deleteUser('${id}')" title="Delete User">🗑️ Delete</button>
                     </div>
                 </div>
@@ -274,104 +275,78 @@ async function createUser(e) {
     }
 }
 
-// Template field management
-let templateFields = [];
+// Invitations
+async function loadInvitations() {
+    const grid = document.getElementById('invitations-grid');
+    if (!grid) return;
 
-function addTemplateField() {
-    const fieldsList = document.getElementById('template-fields-list');
-    if (!fieldsList) return;
-    
-    const fieldId = 'field_' + Date.now();
-    
-    const fieldHTML = `
-        <div class="template-field-item" data-field-id="${fieldId}" style="padding: 1rem; border: 1px solid var(--border); border-radius: 6px; margin-bottom: 0.5rem; background: var(--light);">
-            <div style="display: grid; grid-template-columns: 2fr 1fr 1fr auto; gap: 0.5rem; align-items: end;">
-                <div class="form-group" style="margin: 0;">
-                    <label style="font-size: 0.85rem;">Field Name *</label>
-                    <input type="text" class="field-name" placeholder="e.g., title, description" required>
+    try {
+        const response = await api.get('/admin/invitations?status=pending');
+        const invitations = response.data || [];
+        if (invitations.length === 0) {
+            grid.innerHTML = '<div class="empty-state"><p>No open invitations</p></div>';
+            return;
+        }
+        grid.innerHTML = invitations.map(invitation => `
+            <div class="card">
+                <div class="card-header">
+                    <h4 class="card-title">${escapeHtml(invitation.name || invitation.email)}</h4>
+                    <span class="card-badge badge-secondary">${escapeHtml(invitation.role)}</span>
                 </div>
-                <div class="form-group" style="margin: 0;">
-                    <label style="font-size: 0.85rem;">Type *</label>
-                    <select class="field-type" required>
-                        <option value="string">String</option>
-                        <option value="number">Number</option>
-                        <option value="boolean">Boolean</option>
-                        <option value="date">Date</option>
-                        <option value="email">Email</option>
-                        <option value="url">URL</option>
-                    </select>
+                <div class="card-body" style="padding: 1rem;">
+                    <p class="card-description" style="margin-bottom: 0.5rem;"><strong>Email:</strong> ${escapeHtml(invitation.email)}</p>
+                    <p class="card-description" style="font-size: 0.85rem; color: var(--text-light);">
+                        Sent ${escapeHtml(new Date(invitation.sent_at).toLocaleString())} · expires ${escapeHtml(new Date(invitation.expires_at).toLocaleString())}
+                    </p>
                 </div>
-                <div class="form-group" style="margin: 0;">
-                    <label style="font-size: 0.85rem;">Required</label>
-                    <input type="checkbox" class="field-required">
+                <div class="card-actions" style="display: flex; gap: 0.5rem;">
+                    <button class="btn btn-secondary btn-sm" onclick="resendInvitation('${escapeHtml(invitation.id)}')">✉️ Resend</button>
+                    <button class="btn btn-danger btn-sm" onclick="revokeInvitation('${escapeHtml(invitation.id)}')">Revoke</button>
                 </div>
-                <button type="button" class="btn btn-danger btn-sm" onclick="removeTemplateField('${fieldId}')">×</button>
             </div>
-            <div class="form-group" style="margin-top: 0.5rem;">
-                <input type="text" class="field-description" placeholder="Field description (optional)" style="width: 100%;">
-            </div>
-        </div>
-    `;
-    
-    fieldsList.insertAdjacentHTML('beforeend', fieldHTML);
-    templateFields.push(fieldId);
-    updateTemplateSchema();
+        `).join('');
+    } catch (error) {
+        console.error('Failed to load invitations:', error);
+        grid.innerHTML = '<div class="empty-state"><p>Failed to load invitations</p></div>';
+    }
 }
 
-function removeTemplateField(fieldId) {
-    const fieldEl = document.querySelector(`[data-field-id="${fieldId}"]`);
-    if (fieldEl) {
-        fieldEl.remove();
-        templateFields = templateFields.filter(id => id !== fieldId);
+async function inviteUser(e) {
+    e.preventDefault();
+    try {
+        await api.post('/admin/invitations', {
+            email: document.getElementById('invite-email').value,
+            name: document.getElementById('invite-name').value,
+            role: document.getElementById('invite-role').value
+        });
+        showMessage('Invitation sent', 'success');
+        closeModal('invite-user-modal');
+        document.getElementById('invite-email').value = '';
+        document.getElementById('invite-name').value = '';
+        await loadInvitations();
+    } catch (error) {
+        showMessage(error instanceof Error ? error.message : 'Failed to send invitation', 'error');
     }
-    updateTemplateSchema();
 }
 
-function updateTemplateSchema() {
-    const fields = {};
-    const required = [];
-    
-    document.querySelectorAll('.template-field-item').forEach(item => {
-        const name = item.querySelector('.field-name')?.value.trim();
-        const type = item.querySelector('.field-type')?.value;
-        const description = item.querySelector('.field-description')?.value.trim();
-        const isRequired = item.querySelector('.field-required')?.checked;
-        
-        if (name) {
-            const field = {
-                type: type,
-                description: description || name
-            };
-            
-            // Add format for specific types
-            if (type === 'email') {
-                field.format = 'email';
-                field.type = 'string';
-            } else if (type === 'url') {
-                field.format = 'uri';
-                field.type = 'string';
-            } else if (type === 'date') {
-                field.format = 'date';
-                field.type = 'string';
-            }
-            
-            fields[name] = field;
-            
-            if (isRequired) {
-                required.push(name);
-            }
-        }
-    });
-    
-    const schema = {
-        type: 'object',
-        properties: fields,
-        required: required
-    };
-    
-    const schemaInput = document.getElementById('template-schema');
-    if (schemaInput) {
-        schemaInput.value = JSON.stringify(schema, null, 2);
+async function resendInvitation(id) {
+    try {
+        await api.post(`/admin/invitations/${id}/resend`, {});
+        showMessage('Invitation sent again', 'success');
+        await loadInvitations();
+    } catch (error) {
+        showMessage(error instanceof Error ? error.message : 'Failed to resend invitation', 'error');
+    }
+}
+
+async function revokeInvitation(id) {
+    if (!confirm('Revoke this invitation? Its link will stop working.')) return;
+    try {
+        await api.delete(`/admin/invitations/${id}`);
+        showMessage('Invitation revoked', 'success');
+        await loadInvitations();
+    } catch (error) {
+        showMessage(error instanceof Error ? error.message : 'Failed to revoke invitation', 'error');
     }
 }
 
@@ -589,6 +564,19 @@ async function deleteUser(id) {
     }
 }
 
+// Sets a temporary password the user must change at their next sign-in
+async function resetUserPassword(id) {
+    const password = prompt('Temporary password for this user. They will have to change it when they next sign in.');
+    if (!password) return;
+
+    try {
+        await api.post(`/admin/users/${id}/password`, { password });
+        showMessage('Password reset. Share the temporary password with the user securely.', 'success');
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
 async function deleteTemplate(id) {
     if (!confirm('Are you sure you want to delete this template?')) return;
 
@@ -733,71 +721,37 @@ function editCRUD(id) {
     showMessage('Edit CRUD functionality coming soon', 'info');
 }
 
-// Admin Settings
-async function loadAdminSettings() {
+// System settings apply to the whole instance rather than to one admin
+async function loadSystemSettings() {
     try {
-        const response = await api.get('/admin/settings');
-        const settings = response.data || response || {};
-        
-        // Load current verification code
-        const currentCodeEl = document.getElementById('current-verification-code');
-        if (currentCodeEl) {
-            const code = settings.admin_verification_code || 'Kompasstech2025@';
-            currentCodeEl.value = code;
-        }
+        const response = await api.get('/admin/settings/system');
+        const settings = response.data || {};
+        document.querySelectorAll('[data-setting]').forEach(el => {
+            const value = settings[el.dataset.setting];
+            if (el.type === 'checkbox') {
+                el.checked = !!value;
+            } else if (value !== undefined) {
+                el.value = value;
+            }
+        });
     } catch (error) {
-        const errorMsg = error instanceof Error ? error.message : 'Failed to load admin settings';
-        // Don't show error if it's an auth error (redirect will happen)
-        if (!errorMsg.includes('UNAUTHORIZED') && !errorMsg.includes('401')) {
-            console.error('Failed to load admin settings:', error);
-        }
+        console.error('Failed to load system settings:', error);
     }
 }
 
-async function updateVerificationCode(e) {
-    e.preventDefault();
-    const newCode = document.getElementById('new-verification-code').value.trim();
-    const confirmCode = document.getElementById('confirm-verification-code').value.trim();
-    
-    if (!newCode) {
-        showMessage('Please enter a new verification code', 'error');
-        return;
-    }
-    
-    if (newCode !== confirmCode) {
-        showMessage('Verification codes do not match', 'error');
-        return;
-    }
-    
-    if (newCode.length < 6) {
-        showMessage('Verification code must be at least 6 characters long', 'error');
-        return;
-    }
-    
+async function updateSystemSetting(key, value) {
     try {
-        await api.put('/admin/settings', {
-            admin_verification_code: newCode
-        });
-        
-        showMessage('Verification code updated successfully', 'success');
-        
-        // Update current code display
-        const currentCodeEl = document.getElementById('current-verification-code');
-        if (currentCodeEl) {
-            currentCodeEl.value = newCode;
-        }
-        
-        // Clear form
-        document.getElementById('new-verification-code').value = '';
-        document.getElementById('confirm-verification-code').value = '';
+        await api.put('/admin/settings/system', { [key]: value });
+        showMessage('Setting updated', 'success');
     } catch (error) {
-        const errorMsg = error instanceof Error ? error.message : 'Failed to update verification code';
-        showMessage(errorMsg, 'error');
+        showMessage(getErrorMessage(error), 'error');
+        loadSystemSettings();
     }
 }
 
 // Export functions for retry buttons and onclick handlers
 window.loadUsers = loadUsers;
+window.updateSystemSetting = updateSystemSetting;
 window.loadTemplates = loadTemplates;
 window.loadCRUDs = loadCRUDs;
 
@@ -805,6 +759,7 @@ window.loadCRUDs = loadCRUDs;
 window.viewUser = viewUser;
 window.editUser = editUser;
 window.deleteUser = deleteUser;
+window.resetUserPassword = resetUserPassword;
 window.toggleUserStatus = toggleUserStatus;
 
 // Export template and CRUD action functions
@@ -823,5 +778,4 @@ window.openCreateTemplateModal = openCreateTemplateModal;
 window.openCreateCRUDModal = openCreateCRUDModal;
 window.addTemplateField = addTemplateField;
 window.removeTemplateField = removeTemplateField;
-window.updateVerificationCode = updateVerificationCode;
 
@@ -1,5 +1,17 @@
 const API_BASE_URL = 'http://localhost:8080/v1';
 
+// Identifies this browser to the server so sign-ins can be tied to a device.
+function getDeviceId() {
+    let deviceId = localStorage.getItem('device_id');
+    if (!deviceId) {
+        deviceId = window.crypto && crypto.randomUUID
+            ? crypto.randomUUID()
+            : Date.now().toString(36) + Math.random().toString(36).slice(2);
+        localStorage.setItem('device_id', deviceId);
+    }
+    return deviceId;
+}
+
 // API Client
 class API {
     constructor() {
@@ -9,12 +21,19 @@ class API {
     async request(endpoint, options = {}) {
         const url = `${this.baseURL}${endpoint}`;
         const token = localStorage.getItem('access_token');
+        // Given when this device was trusted at a two-factor sign-in
+        const trustToken = localStorage.getItem('device_trust_token');
+        // Organization routes name the organization in the path instead
+        const organizationId = endpoint.startsWith('/orgs') ? null : localStorage.getItem('organization_id');
 
         const config = {
             ...options,
             headers: {
                 'Content-Type': 'application/json',
+                'X-Device-ID': getDeviceId(),
+                ...(trustToken && { 'X-Device-Trust': trustToken }),
                 ...(token && { 'Authorization': `Bearer ${token}` }),
+                ...(organizationId && { 'X-Organization-ID': organizationId }),
                 ...options.headers,
             },
         };
@@ -64,6 +83,15 @@ class API {
                 data = {};
             }
 
+            // Send users whose password must be changed back to the change form
+            if (response.status === 403 && data && data.error && data.error.code === 'PASSWORD_CHANGE_REQUIRED') {
+                const path = window.location.pathname;
+                if (path !== '/' && path !== '/index.html') {
+                    window.location.href = '/?change_password=1';
+                    throw new Error('PASSWORD_CHANGE_REQUIRED');
+                }
+            }
+
             if (!response.ok) {
                 // Handle different error formats
                 let errorMessage = 'Request failed';
@@ -135,39 +163,7 @@ async function handleLogin(e) {
         }
 
         const response = await api.post('/auth/login', { email, password });
-        
-        // Extract data from nested response structure
-        // Backend returns: { success: true, data: { user: {...}, session: {...} } }
-        const data = response.data || response;
-        const user = data.user || {};
-        const session = data.session || {};
-        
-        // Store token (try multiple possible locations)
-        const token = session.token || data.token || response.access_token || response.token;
-        if (!token) {
-            throw new Error('No authentication token received from server');
-        }
-        localStorage.setItem('access_token', token);
-        
-        // Store user info
-        if (user && Object.keys(user).length > 0) {
-            localStorage.setItem('user', JSON.stringify(user));
-        } else {
-            throw new Error('No user data received from server');
-        }
-        
-        // Check if admin and redirect
-        const role = user.role || 'user';
-        showMessage('Login successful! Redirecting...', 'success');
-        
-        // Small delay to show success message
-        setTimeout(() => {
-            if (role === 'admin') {
-                window.location.href = '/admin-dashboard';
-            } else {
-                window.location.href = '/dashboard';
-            }
-        }, 500);
+        completeLogin(response);
     } catch (error) {
         // Restore button state
         const submitBtn = e.target.querySelector('button[type="submit"]');
@@ -182,6 +178,168 @@ async function handleLogin(e) {
     }
 }
 
+// Stores the session from a login response and leaves the login page.
+function completeLogin(response) {
+    // Extract data from nested response structure
+    // Backend returns: { success: true, data: { user: {...}, session: {...} } }
+    const data = response.data || response;
+
+    // The first step passed but a texted code is needed for a session
+    if (data.two_factor_required) {
+        showTwoFactorChallenge(data);
+        return;
+    }
+
+    const user = data.user || {};
+    const session = data.session || {};
+
+    // Store token (try multiple possible locations)
+    const token = session.token || data.token || response.access_token || response.token;
+    if (!token) {
+        throw new Error('No authentication token received from server');
+    }
+    localStorage.setItem('access_token', token);
+
+    // Store user info
+    if (user && Object.keys(user).length > 0) {
+        localStorage.setItem('user', JSON.stringify(user));
+    } else {
+        throw new Error('No user data received from server');
+    }
+
+    if (data.password_change_required) {
+        showMessage('Your password must be changed before you continue', 'error');
+        switchTab('change-password');
+        return;
+    }
+
+    showMessage('Login successful! Redirecting...', 'success');
+
+    // Small delay to show success message
+    setTimeout(redirectAfterLogin, 500);
+}
+
+function redirectAfterLogin() {
+    const user = JSON.parse(localStorage.getItem('user') || '{}');
+    const returnTo = takeReturnTo();
+    if (returnTo) {
+        window.location.href = returnTo;
+    } else if (user.role === 'admin') {
+        window.location.href = '/admin-dashboard';
+    } else {
+        window.location.href = '/dashboard';
+    }
+}
+
+async function handleRequiredPasswordChange(e) {
+    e.preventDefault();
+    const currentPassword = document.getElementById('change-current-password').value;
+    const newPassword = document.getElementById('change-new-password').value;
+    const confirmPassword = document.getElementById('change-confirm-password').value;
+
+    if (newPassword !== confirmPassword) {
+        showMessage('Passwords do not match', 'error');
+        return;
+    }
+
+    try {
+        await api.put('/users/me/password', {
+            current_password: currentPassword,
+            new_password: newPassword
+        });
+        showMessage('Password changed! Redirecting...', 'success');
+        setTimeout(redirectAfterLogin, 500);
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+// Rates a candidate password against the server's password policy as the
+// user types, without sending it more often than needed.
+let passwordStrengthTimer = null;
+function checkPasswordStrength(password, targetId) {
+    const target = document.getElementById(targetId);
+    if (!target) return;
+    clearTimeout(passwordStrengthTimer);
+    if (!password) {
+        target.textContent = '';
+        return;
+    }
+
+    passwordStrengthTimer = setTimeout(async () => {
+        try {
+            const response = await api.post('/auth/password-strength', { password });
+            const result = response.data || {};
+            const label = `Strength: ${result.strength} (${result.score}/4)`;
+            target.textContent = result.valid ? label : `${label} — ${result.errors[0]}`;
+            target.style.color = result.valid ? 'var(--success, green)' : 'var(--danger, #c00)';
+        } catch (error) {
+            target.textContent = '';
+        }
+    }, 300);
+}
+
+async function handlePasskeyLogin() {
+    if (!window.PublicKeyCredential) {
+        showMessage('This browser does not support passkeys', 'error');
+        return;
+    }
+
+    try {
+        const begin = await api.post('/auth/passkeys/login/begin');
+        const options = begin.data.options.publicKey;
+        const credential = await navigator.credentials.get({
+            publicKey: {
+                ...options,
+                challenge: base64urlToBuffer(options.challenge),
+                allowCredentials: (options.allowCredentials || []).map(c => ({ ...c, id: base64urlToBuffer(c.id) }))
+            }
+        });
+
+        const response = await api.post('/auth/passkeys/login/finish', {
+            ceremony_token: begin.data.ceremony_token,
+            credential: publicKeyCredentialToJSON(credential)
+        });
+        completeLogin(response);
+    } catch (error) {
+        if (error && error.name === 'NotAllowedError') return; // cancelled by the user
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+// WebAuthn helpers: the API exchanges binary fields as base64url strings.
+function base64urlToBuffer(value) {
+    const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+    const padded = base64 + '='.repeat((4 - base64.length % 4) % 4);
+    return Uint8Array.from(atob(padded), c => c.charCodeAt(0)).buffer;
+}
+
+function bufferToBase64url(buffer) {
+    const bytes = new Uint8Array(buffer);
+    let binary = '';
+    bytes.forEach(b => { binary += String.fromCharCode(b); });
+    return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+}
+
+function publicKeyCredentialToJSON(credential) {
+    const response = {};
+    ['clientDataJSON', 'attestationObject', 'authenticatorData', 'signature', 'userHandle'].forEach(field => {
+        if (credential.response[field]) {
+            response[field] = bufferToBase64url(credential.response[field]);
+        }
+    });
+    if (credential.response.getTransports) {
+        response.transports = credential.response.getTransports();
+    }
+    return {
+        id: credential.id,
+        rawId: bufferToBase64url(credential.rawId),
+        type: credential.type,
+        authenticatorAttachment: credential.authenticatorAttachment || undefined,
+        response
+    };
+}
+
 async function handleSignup(e) {
     e.preventDefault();
     const name = document.getElementById('signup-name').value;
@@ -214,22 +372,23 @@ async function handleAdminLogin(e) {
     e.preventDefault();
     const email = document.getElementById('admin-email').value.trim();
     const password = document.getElementById('admin-password').value;
-    const code = document.getElementById('admin-code').value.trim();
 
-    if (!email || !password || !code) {
+    if (!email || !password) {
         showMessage('Please fill in all fields', 'error');
         return;
     }
 
     try {
-        // First verify admin code (backend expects 'verification_code')
-        await api.post('/admin/verify-code', { verification_code: code });
-        
-        // Then login
         const response = await api.post('/admin/login', { email, password });
         
         // Extract data from nested response structure
         const data = response.data || response;
+
+        // The first step passed but a texted code is needed for a session
+        if (data.two_factor_required) {
+            showTwoFactorChallenge(data);
+            return;
+        }
         const admin = data.admin || data.user || {};
         const session = data.session || {};
         
@@ -251,69 +410,56 @@ async function handleAdminLogin(e) {
     }
 }
 
-async function handleAdminVerify(e) {
+// Creates the first admin with the setup token and signs them in
+async function handleSetup(e) {
     e.preventDefault();
-    const code = document.getElementById('verify-code').value.trim();
+    const token = document.getElementById('setup-token').value.trim();
+    const name = document.getElementById('setup-name').value.trim();
+    const email = document.getElementById('setup-email').value.trim();
+    const password = document.getElementById('setup-password').value;
 
-    if (!code) {
-        showMessage('Please enter a verification code', 'error');
-        return;
-    }
-
-    try {
-        // Backend expects 'verification_code' not 'code'
-        await api.post('/admin/verify-code', { verification_code: code });
-        showMessage('Verification code accepted! Please fill in your admin details.', 'success');
-        // Store verified code for signup
-        sessionStorage.setItem('admin_verified_code', code);
-        setTimeout(() => switchTab('admin-signup'), 1500);
-    } catch (error) {
-        showMessage(getErrorMessage(error), 'error');
-    }
-}
-
-async function handleAdminSignup(e) {
-    e.preventDefault();
-    const name = document.getElementById('admin-signup-name').value.trim();
-    const email = document.getElementById('admin-signup-email').value.trim();
-    const password = document.getElementById('admin-signup-password').value;
-    const termsAccepted = document.getElementById('admin-signup-terms').checked;
-    const verifiedCode = sessionStorage.getItem('admin_verified_code');
-    
-    if (!name || !email || !password) {
+    if (!token || !name || !email || !password) {
         showMessage('Please fill in all required fields', 'error');
         return;
     }
-
-    if (!termsAccepted) {
+    if (!document.getElementById('setup-terms').checked) {
         showMessage('Please accept the terms and conditions', 'error');
         return;
     }
 
-    if (!verifiedCode) {
-        showMessage('Please verify the admin code first', 'error');
-        switchTab('admin-verify');
-        return;
-    }
-
     try {
-        const response = await api.post('/admin/create', {
+        const response = await api.post('/setup', {
+            token,
             name,
             email,
             password,
-            verification_code: verifiedCode,
             terms_accepted: true,
             terms_version: '1.0'
         });
-        
-        showMessage('Admin account created successfully! Please login.', 'success');
-        sessionStorage.removeItem('admin_verified_code');
-        setTimeout(() => switchTab('admin-login'), 2000);
+        completeLogin(response);
     } catch (error) {
         showMessage(getErrorMessage(error), 'error');
     }
 }
 
+// Offers the setup form while the instance has no admin yet
+async function checkSetupRequired() {
+    const path = window.location.pathname;
+    if (path !== '/' && path !== '/index.html') {
+        return;
+    }
+    try {
+        const response = await api.get('/setup');
+        const required = response.data && response.data.required;
+        document.getElementById('setup-link').style.display = required ? '' : 'none';
+        if (required && !new URLSearchParams(window.location.search).toString()) {
+            switchTab('setup');
+        }
+    } catch (error) {
+        console.error('Failed to check setup status:', error);
+    }
+}
+
 // Helper function to extract error message
 function getErrorMessage(error) {
     if (error instanceof Error) {
@@ -337,6 +483,7 @@ function getErrorMessage(error) {
 function logout() {
     localStorage.removeItem('access_token');
     localStorage.removeItem('user');
+    localStorage.removeItem('organization_id');
     window.location.href = '/';
 }
 
@@ -353,14 +500,20 @@ function switchTab(tab) {
         document.querySelectorAll('.tab-btn')[1].classList.add('active');
     } else if (tab === 'admin-login') {
         document.getElementById('admin-login-form').classList.add('active');
-    } else if (tab === 'admin-verify') {
-        document.getElementById('admin-verify-form').classList.add('active');
-    } else if (tab === 'admin-signup') {
-        document.getElementById('admin-signup-form').classList.add('active');
+    } else if (tab === 'setup') {
+        document.getElementById('setup-form').classList.add('active');
     } else if (tab === 'forgot-password') {
         document.getElementById('forgot-password-form').classList.add('active');
     } else if (tab === 'reset-password') {
         document.getElementById('reset-password-form').classList.add('active');
+    } else if (tab === 'magic-link') {
+        document.getElementById('magic-link-form').classList.add('active');
+    } else if (tab === 'change-password') {
+        document.getElementById('change-password-form').classList.add('active');
+    } else if (tab === 'two-factor') {
+        document.getElementById('two-factor-form').classList.add('active');
+    } else if (tab === 'invitation') {
+        document.getElementById('invitation-form').classList.add('active');
     }
 }
 
@@ -378,6 +531,128 @@ async function handleForgotPassword(e) {
     }
 }
 
+// Magic Link
+async function handleMagicLinkRequest(e) {
+    e.preventDefault();
+    const email = document.getElementById('magic-link-email').value;
+
+    try {
+        await api.post('/auth/magic-link', { email });
+        showMessage('If an account exists with this email, a sign-in link has been sent. Please check your email.', 'success');
+        setTimeout(() => switchTab('login'), 3000);
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+async function verifyMagicLink(token) {
+    // Drop the token from the address bar and history before using it
+    window.history.replaceState(null, '', window.location.pathname);
+    try {
+        const response = await api.post('/auth/magic-link/verify', { token });
+        completeLogin(response);
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+// Follows an emailed email-change confirmation or revert link
+async function completeEmailChange(endpoint, token) {
+    window.history.replaceState(null, '', window.location.pathname);
+    try {
+        const response = await api.post(endpoint, { token });
+        showMessage(response.message || 'Done', 'success');
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+// Invitations
+async function openInvitation(token) {
+    window.history.replaceState(null, '', window.location.pathname);
+    try {
+        const response = await api.post('/auth/invitations/lookup', { token });
+        const invitation = response.data;
+        document.getElementById('invitation-token').value = token;
+        document.getElementById('invitation-email').textContent = invitation.email;
+        document.getElementById('invitation-role').textContent = invitation.role;
+        document.getElementById('invitation-name').value = invitation.name || '';
+        switchTab('invitation');
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+async function handleAcceptInvitation(e) {
+    e.preventDefault();
+    const password = document.getElementById('invitation-password').value;
+    if (password !== document.getElementById('invitation-confirm-password').value) {
+        showMessage('Passwords do not match', 'error');
+        return;
+    }
+
+    try {
+        const response = await api.post('/auth/invitations/accept', {
+            token: document.getElementById('invitation-token').value,
+            name: document.getElementById('invitation-name').value,
+            password,
+            terms_accepted: document.getElementById('invitation-terms').checked,
+            terms_version: '1.0'
+        });
+        completeLogin(response);
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+// Two-factor sign-in
+function showTwoFactorChallenge(challenge) {
+    sessionStorage.setItem('two_factor_challenge', challenge.challenge_token);
+    document.getElementById('two-factor-destination').textContent = challenge.destination || 'your phone';
+    document.getElementById('two-factor-code').value = '';
+    document.getElementById('two-factor-trust').checked = false;
+
+    const loginButton = document.querySelector('#login-form button[type="submit"]');
+    if (loginButton) {
+        loginButton.disabled = false;
+        loginButton.textContent = 'Login';
+    }
+    switchTab('two-factor');
+}
+
+async function handleTwoFactorVerify(e) {
+    e.preventDefault();
+    const challengeToken = sessionStorage.getItem('two_factor_challenge');
+    const code = document.getElementById('two-factor-code').value.trim();
+
+    try {
+        const response = await api.post('/auth/two-factor/verify', {
+            challenge_token: challengeToken,
+            code: code,
+            trust_device: document.getElementById('two-factor-trust').checked
+        });
+        sessionStorage.removeItem('two_factor_challenge');
+        const device = (response.data && response.data.device) || {};
+        if (device.trust_token) {
+            localStorage.setItem('device_trust_token', device.trust_token);
+        }
+        completeLogin(response);
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+async function resendTwoFactorCode() {
+    const challengeToken = sessionStorage.getItem('two_factor_challenge');
+    try {
+        const response = await api.post('/auth/two-factor/resend', { challenge_token: challengeToken });
+        showTwoFactorChallenge(response.data);
+        showMessage('A new code has been sent', 'success');
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
 // Reset Password
 async function handleResetPassword(e) {
     e.preventDefault();
@@ -410,6 +685,50 @@ window.addEventListener('DOMContentLoaded', () => {
         document.getElementById('reset-token').value = token;
         switchTab('reset-password');
     }
+
+    if (urlParams.get('change_password') && localStorage.getItem('access_token')) {
+        switchTab('change-password');
+    }
+
+    const magicToken = urlParams.get('magic_token');
+    if (magicToken) {
+        verifyMagicLink(magicToken);
+    }
+
+    const emailChangeToken = urlParams.get('email_change_token');
+    if (emailChangeToken) {
+        completeEmailChange('/auth/email-change/confirm', emailChangeToken);
+    }
+    const emailRevertToken = urlParams.get('email_revert_token');
+    if (emailRevertToken) {
+        completeEmailChange('/auth/email-change/revert', emailRevertToken);
+    }
+
+    const invitationToken = urlParams.get('invitation_token');
+    if (invitationToken) {
+        openInvitation(invitationToken);
+    }
+
+    // Organization invitations are accepted once signed in, on the next page
+    const orgInvitationToken = urlParams.get('org_invitation_token');
+    if (orgInvitationToken) {
+        sessionStorage.setItem('org_invitation_token', orgInvitationToken);
+        window.history.replaceState(null, '', window.location.pathname);
+        if (!localStorage.getItem('access_token')) {
+            showMessage('Sign in or create an account with the invited email to join the organization', 'info');
+        }
+    }
+
+    const magicLinkButton = document.getElementById('magic-link-button');
+    if (magicLinkButton) {
+        api.get('/auth/magic-link')
+            .then(response => {
+                if (response.data && response.data.enabled) {
+                    magicLinkButton.style.display = '';
+                }
+            })
+            .catch(() => {});
+    }
 });
 
 // Message Display
@@ -485,21 +804,60 @@ window.addEventListener('DOMContentLoaded', () => {
             window.location.href = '/dashboard';
         }
     } else if (path !== '/' && path !== '/index.html') {
-        // No valid auth, redirect to login
+        // No valid auth, redirect to login and come back afterwards
+        sessionStorage.setItem('return_to', path + window.location.search);
         window.location.href = '/';
     }
 });
 
+// Accepts an organization invitation saved from the sign-in page and
+// switches to the organization.
+async function acceptPendingOrgInvitation() {
+    const token = sessionStorage.getItem('org_invitation_token');
+    const path = window.location.pathname;
+    if (!token || !localStorage.getItem('access_token') || path === '/' || path === '/index.html') {
+        return;
+    }
+    sessionStorage.removeItem('org_invitation_token');
+    try {
+        const response = await api.post('/orgs/invitations/accept', { token });
+        const organization = response.data;
+        if (organization && organization.id) {
+            localStorage.setItem('organization_id', organization.id);
+        }
+        showMessage(response.message || 'You joined the organization', 'success');
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+window.addEventListener('DOMContentLoaded', acceptPendingOrgInvitation);
+window.addEventListener('DOMContentLoaded', checkSetupRequired);
+
+// Returns the same-origin path saved before a login redirect, once.
+function takeReturnTo() {
+    const returnTo = sessionStorage.getItem('return_to');
+    sessionStorage.removeItem('return_to');
+    if (returnTo && returnTo.startsWith('/') && !returnTo.startsWith('//')) {
+        return returnTo;
+    }
+    return null;
+}
+
 // Export API for other scripts
 window.api = api;
 window.showMessage = showMessage;
 window.openModal = openModal;
 window.closeModal = closeModal;
 window.logout = logout;
+window.getDeviceId = getDeviceId;
 window.getErrorMessage = getErrorMessage;
 window.switchTab = switchTab;
-window.handleAdminVerify = handleAdminVerify;
-window.handleAdminSignup = handleAdminSignup;
+window.handleSetup = handleSetup;
 window.handleForgotPassword = handleForgotPassword;
 window.handleResetPassword = handleResetPassword;
+window.handleMagicLinkRequest = handleMagicLinkRequest;
+window.handlePasskeyLogin = handlePasskeyLogin;
+window.base64urlToBuffer = base64urlToBuffer;
+window.publicKeyCredentialToJSON = publicKeyCredentialToJSON;
 
@@ -0,0 +1,88 @@
+// Consent screen for products signing in through base-app (OpenID Connect).
+// The authorization request arrives as the page query string.
+
+const SCOPE_DESCRIPTIONS = {
+    openid: 'Your account ID',
+    profile: 'Your name and profile picture',
+    email: 'Your email address',
+    offline_access: 'Stay signed in while you are away'
+};
+
+function authorizeParams() {
+    const params = new URLSearchParams(window.location.search);
+    const request = {};
+    ['response_type', 'client_id', 'redirect_uri', 'scope', 'state', 'nonce', 'code_challenge', 'code_challenge_method']
+        .forEach(key => { request[key] = params.get(key) || ''; });
+    return request;
+}
+
+// consentRequest calls the consent API directly so error responses keep the
+// redirect_to that returns the browser to the product.
+async function consentRequest(method, body) {
+    const query = method === 'GET' ? window.location.search : '';
+    const response = await fetch(`${API_BASE_URL}/oauth2/consent${query}`, {
+        method,
+        headers: {
+            'Content-Type': 'application/json',
+            'Authorization': `Bearer ${localStorage.getItem('access_token')}`
+        },
+        body: body ? JSON.stringify(body) : undefined
+    });
+    if (response.status === 401) {
+        sessionStorage.setItem('return_to', window.location.pathname + window.location.search);
+        localStorage.removeItem('access_token');
+        window.location.href = '/';
+        return null;
+    }
+    const data = await response.json();
+    if (data.data && data.data.redirect_to) {
+        window.location.href = data.data.redirect_to;
+        return null;
+    }
+    if (!response.ok) {
+        throw new Error((data.error && data.error.message) || 'Authorization failed');
+    }
+    return data.data;
+}
+
+async function loadConsent() {
+    try {
+        const prompt = await consentRequest('GET');
+        if (!prompt) return;
+        if (!prompt.consent_required) {
+            await decideConsent(true);
+            return;
+        }
+
+        document.getElementById('consent-client').textContent = prompt.client_name;
+        document.getElementById('consent-scopes').innerHTML = prompt.scopes
+            .map(scope => `<li>${escapeHtml(SCOPE_DESCRIPTIONS[scope] || scope)}</li>`)
+            .join('');
+        document.getElementById('consent-loading').style.display = 'none';
+        document.getElementById('consent-prompt').style.display = 'block';
+    } catch (error) {
+        document.getElementById('consent-loading').style.display = 'none';
+        showMessage(error.message, 'error');
+    }
+}
+
+async function decideConsent(approve) {
+    try {
+        await consentRequest('POST', { ...authorizeParams(), approve });
+    } catch (error) {
+        showMessage(error.message, 'error');
+    }
+}
+
+window.addEventListener('DOMContentLoaded', () => {
+    if (localStorage.getItem('access_token')) {
+        loadConsent();
+    }
+});
+
+function escapeHtml(text) {
+    if (!text) return '';
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
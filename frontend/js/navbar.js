@@ -6,6 +6,7 @@ let unreadMessages = 0;
 // Initialize navbar on page load
 window.addEventListener('DOMContentLoaded', async () => {
     await initializeNavbar();
+    await loadOrganizationSwitcher();
     await loadNotificationCount();
     await loadMessageCount();
     
@@ -48,6 +49,43 @@ async function initializeNavbar() {
     }
 }
 
+// Fills the workspace picker with the user's organizations. Requests made
+// while an organization is picked carry it in X-Organization-ID.
+async function loadOrganizationSwitcher() {
+    const select = document.getElementById('organization-switcher');
+    if (!select || !localStorage.getItem('access_token')) return;
+
+    try {
+        const response = await api.get('/orgs');
+        const organizations = response.data || [];
+        const current = localStorage.getItem('organization_id') || '';
+
+        select.innerHTML = '<option value="">Personal</option>' + organizations.map(org =>
+            `<option value="${escapeHtml(org.id)}">${escapeHtml(org.name)}</option>`
+        ).join('');
+
+        if (current && !organizations.some(org => org.id === current)) {
+            // No longer a member; fall back to personal records
+            localStorage.removeItem('organization_id');
+            window.location.reload();
+            return;
+        }
+        select.value = current;
+        select.style.display = organizations.length > 0 ? '' : 'none';
+    } catch (error) {
+        console.error('Failed to load organizations:', error);
+    }
+}
+
+function switchOrganization(organizationId) {
+    if (organizationId) {
+        localStorage.setItem('organization_id', organizationId);
+    } else {
+        localStorage.removeItem('organization_id');
+    }
+    window.location.reload();
+}
+
 function updateAvatar() {
     if (!currentUser) return;
     
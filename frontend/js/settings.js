@@ -49,7 +49,7 @@ async function loadAllSettings() {
         currentSettings = settingsResponse.data || settingsResponse || {};
         
         // Load privacy settings
-        loadPrivacySettings(currentSettings.privacy || {});
+        loadPrivacySettings(currentSettings);
         
         // Load notification settings
         loadNotificationSettings(currentSettings.notifications || {});
@@ -57,11 +57,29 @@ async function loadAllSettings() {
         // Load account preferences
         loadAccountPreferences(currentSettings.preferences || {});
         
+        // Load pending email change
+        await loadPendingEmailChange();
+
+        // Load phone and two-factor status
+        await loadTwoFactorStatus();
+
         // Load connected accounts
         await loadConnectedAccounts();
         
+        // Load passkeys
+        await loadPasskeys();
+
+        // Load devices
+        await loadDevices();
+
         // Load sessions
         await loadSessions();
+
+        // Load login history
+        await loadLoginHistory();
+
+        // Load data exports
+        await loadDataExports();
     } catch (error) {
         console.error('Failed to load settings:', error);
         const errorMsg = error instanceof Error ? error.message : 'Failed to load settings';
@@ -71,11 +89,11 @@ async function loadAllSettings() {
 
 function loadPrivacySettings(privacy) {
     document.getElementById('privacy-profile-visibility').value = privacy.profile_visibility || 'public';
-    document.getElementById('privacy-email-visible').checked = privacy.email_visible || false;
-    document.getElementById('privacy-phone-visible').checked = privacy.phone_visible || false;
-    document.getElementById('privacy-message-who').value = privacy.message_who || 'everyone';
-    document.getElementById('privacy-search-visible').checked = privacy.search_visible !== false;
-    document.getElementById('privacy-data-sharing').checked = privacy.data_sharing || false;
+    document.getElementById('privacy-email-visibility').value = privacy.email_visibility || 'private';
+    document.getElementById('privacy-phone-visibility').value = privacy.phone_visibility || 'private';
+    document.getElementById('privacy-message-who').value = privacy.allow_messaging || 'everyone';
+    document.getElementById('privacy-search-visible').checked = privacy.search_visibility !== false;
+    document.getElementById('privacy-data-sharing').checked = privacy.data_sharing_enabled || false;
 }
 
 function loadNotificationSettings(notifications) {
@@ -201,8 +219,6 @@ async function updateProfileSettings(e) {
     e.preventDefault();
     const updates = {
         name: document.getElementById('profile-name').value,
-        email: document.getElementById('profile-email').value,
-        phone: document.getElementById('profile-phone').value,
         username: document.getElementById('profile-username').value,
         bio: document.getElementById('profile-bio').value,
         date_of_birth: document.getElementById('profile-dob').value
@@ -216,9 +232,7 @@ async function updateProfileSettings(e) {
         
         // Update via profile endpoint
         await api.put('/users/me', {
-            name: updates.name,
-            email: updates.email,
-            phone: updates.phone
+            name: updates.name
         });
         
         // Update via settings endpoint for additional fields
@@ -283,16 +297,127 @@ async function changePassword(e) {
     }
 }
 
+// Email Change
+async function loadPendingEmailChange() {
+    const el = document.getElementById('pending-email-change');
+    if (!el) return;
+
+    try {
+        const response = await api.get('/users/me/email');
+        const pending = response.data;
+        if (!pending) {
+            el.style.display = 'none';
+            el.innerHTML = '';
+            return;
+        }
+        el.innerHTML = `
+            <p style="margin: 0;">Waiting for confirmation of <strong>${escapeHtml(pending.new_email)}</strong> until ${escapeHtml(formatDate(pending.expires_at))}.
+            <button class="btn btn-secondary btn-sm" onclick="cancelEmailChange()">Cancel</button></p>
+        `;
+        el.style.display = '';
+    } catch (error) {
+        console.error('Failed to load pending email change:', error);
+    }
+}
+
+async function requestEmailChange(e) {
+    e.preventDefault();
+    const newEmail = document.getElementById('new-email').value;
+    const password = document.getElementById('email-change-password').value;
+
+    try {
+        await api.post('/users/me/email', {
+            new_email: newEmail,
+            password: password
+        });
+        showMessage(`Confirmation link sent to ${newEmail}`, 'success');
+        document.getElementById('new-email').value = '';
+        document.getElementById('email-change-password').value = '';
+        await loadPendingEmailChange();
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to change email';
+        showMessage(errorMsg, 'error');
+    }
+}
+
+async function cancelEmailChange() {
+    try {
+        await api.delete('/users/me/email');
+        showMessage('Email change cancelled', 'success');
+        await loadPendingEmailChange();
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to cancel email change';
+        showMessage(errorMsg, 'error');
+    }
+}
+
+// Phone and two-factor authentication
+async function loadTwoFactorStatus() {
+    try {
+        const response = await api.get('/users/me/two-factor');
+        const status = response.data || {};
+        const phoneStatus = document.getElementById('phone-status');
+        if (phoneStatus) {
+            phoneStatus.textContent = status.phone
+                ? `${status.phone} · ${status.phone_verified ? 'Verified' : 'Not verified'}`
+                : 'No phone number added';
+        }
+        const checkbox = document.getElementById('2fa-enabled');
+        if (checkbox) {
+            checkbox.checked = !!status.enabled;
+        }
+    } catch (error) {
+        console.error('Failed to load two-factor status:', error);
+    }
+}
+
+async function startPhoneVerification(e) {
+    e.preventDefault();
+    const phone = document.getElementById('verify-phone').value;
+
+    try {
+        await api.post('/users/me/phone/verification', { phone });
+        showMessage('Verification code sent', 'success');
+        document.getElementById('phone-code-form').style.display = '';
+        document.getElementById('verify-phone-code').focus();
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to send code';
+        showMessage(errorMsg, 'error');
+    }
+}
+
+async function confirmPhoneVerification(e) {
+    e.preventDefault();
+    const code = document.getElementById('verify-phone-code').value.trim();
+
+    try {
+        const response = await api.post('/users/me/phone/verification/confirm', { code });
+        showMessage('Phone number verified', 'success');
+        document.getElementById('phone-code-form').style.display = 'none';
+        document.getElementById('verify-phone').value = '';
+        document.getElementById('verify-phone-code').value = '';
+        document.getElementById('profile-phone').value = (response.data && response.data.phone) || '';
+        await loadTwoFactorStatus();
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to verify phone';
+        showMessage(errorMsg, 'error');
+    }
+}
+
 // Update 2FA
 async function update2FA(e) {
     e.preventDefault();
     const enabled = document.getElementById('2fa-enabled').checked;
+    const password = document.getElementById('2fa-password').value;
 
     try {
-        await api.put('/users/me/settings/security', {
-            two_factor_enabled: enabled
+        await api.put('/users/me/two-factor', {
+            enabled: enabled,
+            password: password
         });
-        showMessage('2FA settings updated', 'success');
+        document.getElementById('2fa-password').value = '';
+        showMessage(enabled ? 'Two-factor authentication turned on' : 'Two-factor authentication turned off', 'success');
+        await loadTwoFactorStatus();
     } catch (error) {
         const errorMsg = error instanceof Error ? error.message : 'Failed to update 2FA';
         showMessage(errorMsg, 'error');
@@ -304,11 +429,11 @@ async function updatePrivacySettings(e) {
     e.preventDefault();
     const updates = {
         profile_visibility: document.getElementById('privacy-profile-visibility').value,
-        email_visible: document.getElementById('privacy-email-visible').checked,
-        phone_visible: document.getElementById('privacy-phone-visible').checked,
-        message_who: document.getElementById('privacy-message-who').value,
-        search_visible: document.getElementById('privacy-search-visible').checked,
-        data_sharing: document.getElementById('privacy-data-sharing').checked
+        email_visibility: document.getElementById('privacy-email-visibility').value,
+        phone_visibility: document.getElementById('privacy-phone-visibility').value,
+        allow_messaging: document.getElementById('privacy-message-who').value,
+        search_visibility: document.getElementById('privacy-search-visible').checked,
+        data_sharing_enabled: document.getElementById('privacy-data-sharing').checked
     };
 
     try {
@@ -399,26 +524,28 @@ async function loadConnectedAccounts() {
     const listEl = document.getElementById('connected-accounts-list');
     if (!listEl) return;
 
+    await completeIdentityLink();
+    await loadIdentityProviders();
+
     try {
-        const response = await api.get('/users/me/settings');
-        const settings = response.data || response || {};
-        const accounts = settings.connected_accounts || [];
+        const response = await api.get('/users/me/identities');
+        const identities = response.data || [];
         
-        if (accounts.length === 0) {
+        if (identities.length === 0) {
             listEl.innerHTML = '<div class="empty-state"><p>No connected accounts</p></div>';
             return;
         }
 
-        listEl.innerHTML = accounts.map(account => {
-            const provider = account.provider || 'unknown';
-            const email = account.email || '';
+        listEl.innerHTML = identities.map(identity => {
+            const provider = identity.provider || 'unknown';
+            const email = identity.email || '';
             return `
                 <div class="connected-account-item" style="padding: 1rem; border: 1px solid var(--border); border-radius: 6px; margin-bottom: 0.5rem; display: flex; justify-content: space-between; align-items: center;">
                     <div>
                         <strong>${escapeHtml(provider.charAt(0).toUpperCase() + provider.slice(1))}</strong>
                         ${email ? `<p style="margin: 0.25rem 0 0 0; color: var(--text-light); font-size: 0.85rem;">${escapeHtml(email)}</p>` : ''}
                     </div>
-                    <button class="btn btn-danger btn-sm" onclick="disconnectAccount('${provider}')">Disconnect</button>
+                    <button class="btn btn-danger btn-sm" onclick="disconnectAccount('${escapeHtml(identity.id)}', '${escapeHtml(provider)}')">Disconnect</button>
                 </div>
             `;
         }).join('');
@@ -427,18 +554,62 @@ async function loadConnectedAccounts() {
     }
 }
 
+async function loadIdentityProviders() {
+    const buttonsEl = document.getElementById('identity-provider-buttons');
+    if (!buttonsEl) return;
+
+    try {
+        const response = await api.get('/auth/oidc/providers');
+        const providers = response.data || [];
+        if (providers.length === 0) {
+            buttonsEl.innerHTML = '<p class="section-description">No sign-in providers are configured</p>';
+            return;
+        }
+        buttonsEl.innerHTML = providers.map(provider => `
+            <button class="btn btn-secondary" onclick="connectAccount('${escapeHtml(provider.name)}')">Connect ${escapeHtml(provider.display_name)}</button>
+        `).join('');
+    } catch (error) {
+        buttonsEl.innerHTML = '';
+    }
+}
+
 async function connectAccount(provider) {
-    showMessage(`Connecting ${provider} account... (Feature coming soon)`, 'info');
-    // Implementation would depend on OAuth flow
+    try {
+        const response = await api.post(`/users/me/identities/${encodeURIComponent(provider)}`);
+        sessionStorage.setItem('oidc_link_provider', provider);
+        window.location.href = response.data.authorization_url;
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to connect account';
+        showMessage(errorMsg, 'error');
+    }
 }
 
-async function disconnectAccount(provider) {
-    if (!confirm(`Are you sure you want to disconnect your ${provider} account?`)) return;
+// Finish a link started by connectAccount when the provider redirects back
+// to this page with a code and state.
+async function completeIdentityLink() {
+    const params = new URLSearchParams(window.location.search);
+    const provider = sessionStorage.getItem('oidc_link_provider');
+    if (!provider || !params.get('code') || !params.get('state')) return;
 
+    sessionStorage.removeItem('oidc_link_provider');
+    window.history.replaceState({}, '', window.location.pathname);
     try {
-        await api.delete('/users/me/settings/connected-accounts', {
-            body: { provider }
+        await api.post(`/auth/oidc/${encodeURIComponent(provider)}/callback`, {
+            code: params.get('code'),
+            state: params.get('state')
         });
+        showMessage(`${provider} account connected`, 'success');
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to connect account';
+        showMessage(errorMsg, 'error');
+    }
+}
+
+async function disconnectAccount(identityId, provider) {
+    if (!confirm(`Are you sure you want to disconnect your ${provider} account?`)) return;
+
+    try {
+        await api.delete(`/users/me/identities/${encodeURIComponent(identityId)}`);
         showMessage(`${provider} account disconnected`, 'success');
         await loadConnectedAccounts();
     } catch (error) {
@@ -447,6 +618,196 @@ async function disconnectAccount(provider) {
     }
 }
 
+// Load Passkeys
+async function loadPasskeys() {
+    const listEl = document.getElementById('passkeys-list');
+    if (!listEl) return;
+
+    try {
+        const response = await api.get('/users/me/passkeys');
+        const passkeys = response.data || [];
+
+        if (passkeys.length === 0) {
+            listEl.innerHTML = '<div class="empty-state"><p>No passkeys yet</p></div>';
+            return;
+        }
+
+        listEl.innerHTML = passkeys.map(passkey => {
+            const lastUsed = passkey.last_used_at ? new Date(passkey.last_used_at).toLocaleString() : 'Never';
+            const status = passkey.clone_detected_at
+                ? '<p style="margin: 0.25rem 0 0 0; color: var(--danger); font-size: 0.85rem;">Disabled: this passkey may have been copied. Remove it and add a new one.</p>'
+                : '';
+            return `
+                <div class="passkey-item" style="padding: 1rem; border: 1px solid var(--border); border-radius: 6px; margin-bottom: 0.5rem; display: flex; justify-content: space-between; align-items: center;">
+                    <div>
+                        <strong>${escapeHtml(passkey.name)}</strong>
+                        <p style="margin: 0.25rem 0 0 0; color: var(--text-light); font-size: 0.85rem;">Added ${new Date(passkey.created_at).toLocaleDateString()} · Last used ${escapeHtml(lastUsed)}</p>
+                        ${status}
+                    </div>
+                    <button class="btn btn-danger btn-sm" onclick="removePasskey('${escapeHtml(passkey.id)}', '${escapeHtml(passkey.name)}')">Remove</button>
+                </div>
+            `;
+        }).join('');
+    } catch (error) {
+        listEl.innerHTML = '<div class="empty-state"><p>Failed to load passkeys</p></div>';
+    }
+}
+
+async function addPasskey() {
+    if (!window.PublicKeyCredential) {
+        showMessage('This browser does not support passkeys', 'error');
+        return;
+    }
+
+    const name = prompt('Name this passkey (e.g. "Work laptop")', 'Passkey');
+    if (name === null) return;
+
+    try {
+        const begin = await api.post('/users/me/passkeys/registration/begin');
+        const options = begin.data.options.publicKey;
+        const credential = await navigator.credentials.create({
+            publicKey: {
+                ...options,
+                challenge: base64urlToBuffer(options.challenge),
+                user: { ...options.user, id: base64urlToBuffer(options.user.id) },
+                excludeCredentials: (options.excludeCredentials || []).map(c => ({ ...c, id: base64urlToBuffer(c.id) }))
+            }
+        });
+
+        await api.post('/users/me/passkeys/registration/finish', {
+            ceremony_token: begin.data.ceremony_token,
+            name: name,
+            credential: publicKeyCredentialToJSON(credential)
+        });
+        showMessage('Passkey added', 'success');
+        await loadPasskeys();
+    } catch (error) {
+        if (error && error.name === 'NotAllowedError') return; // cancelled by the user
+        if (error && error.name === 'InvalidStateError') {
+            showMessage('This device already has a passkey for your account', 'error');
+            return;
+        }
+        const errorMsg = error instanceof Error ? error.message : 'Failed to add passkey';
+        showMessage(errorMsg, 'error');
+    }
+}
+
+async function removePasskey(passkeyId, name) {
+    if (!confirm(`Remove the passkey "${name}"? You will no longer be able to sign in with it.`)) return;
+
+    try {
+        await api.delete(`/users/me/passkeys/${encodeURIComponent(passkeyId)}`);
+        showMessage('Passkey removed', 'success');
+        await loadPasskeys();
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to remove passkey';
+        showMessage(errorMsg, 'error');
+    }
+}
+
+// Devices
+// "Chrome on macOS" and "Berlin, DE" from the parsed client fields shared by
+// sessions and devices
+function describeClient(item) {
+    if (item.browser && item.os) return `${item.browser} on ${item.os}`;
+    return item.browser || item.os || '';
+}
+
+function describeLocation(item) {
+    return [item.location_city, item.location_country].filter(Boolean).join(', ');
+}
+
+async function loadDevices() {
+    const listEl = document.getElementById('devices-list');
+    if (!listEl) return;
+
+    try {
+        const response = await api.get('/users/me/devices');
+        const devices = response.data || [];
+
+        if (devices.length === 0) {
+            listEl.innerHTML = '<div class="empty-state"><p>No devices yet</p></div>';
+            return;
+        }
+
+        const currentDeviceId = getDeviceId();
+        listEl.innerHTML = devices.map(device => {
+            const name = device.device_name || describeClient(device) || 'Unnamed device';
+            const isCurrent = device.device_id === currentDeviceId;
+            const details = [`Last used ${new Date(device.last_used_at).toLocaleString()}`];
+            if (device.device_name && describeClient(device)) details.push(describeClient(device));
+            if (describeLocation(device)) details.push(describeLocation(device));
+            if (device.ip_address) details.push(device.ip_address);
+            const trust = device.is_trusted
+                ? `<p style="margin: 0.25rem 0 0 0; color: var(--success); font-size: 0.85rem;">Trusted until ${new Date(device.trust_expires_at).toLocaleDateString()}</p>`
+                : '';
+            return `
+                <div class="device-item" style="padding: 1rem; border: 1px solid var(--border); border-radius: 6px; margin-bottom: 0.5rem; display: flex; justify-content: space-between; align-items: center;">
+                    <div>
+                        <strong>${escapeHtml(name)}</strong>
+                        ${isCurrent ? '<span class="badge" style="margin-left: 0.5rem;">This device</span>' : ''}
+                        <p style="margin: 0.25rem 0 0 0; color: var(--text-light); font-size: 0.85rem;">${escapeHtml(details.join(' · '))}</p>
+                        ${trust}
+                    </div>
+                    <div style="display: flex; gap: 0.5rem;">
+                        <button class="btn btn-secondary btn-sm" onclick="renameDevice('${escapeHtml(device.id)}', '${escapeHtml(name)}')">Rename</button>
+                        ${device.is_trusted ? `<button class="btn btn-secondary btn-sm" onclick="untrustDevice('${escapeHtml(device.id)}', ${isCurrent})">Stop Trusting</button>` : ''}
+                        <button class="btn btn-danger btn-sm" onclick="removeDevice('${escapeHtml(device.id)}', '${escapeHtml(name)}', ${isCurrent})">Remove</button>
+                    </div>
+                </div>
+            `;
+        }).join('');
+    } catch (error) {
+        listEl.innerHTML = '<div class="empty-state"><p>Failed to load devices</p></div>';
+    }
+}
+
+async function renameDevice(deviceId, currentName) {
+    const name = prompt('Name this device', currentName);
+    if (!name || !name.trim()) return;
+
+    try {
+        await api.put(`/users/me/devices/${encodeURIComponent(deviceId)}`, { name: name.trim() });
+        await loadDevices();
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+// Devices are trusted by checking "Trust this device" at a two-factor sign-in
+async function untrustDevice(deviceId, isCurrent) {
+    try {
+        await api.delete(`/users/me/devices/${encodeURIComponent(deviceId)}/trust`);
+        if (isCurrent) {
+            localStorage.removeItem('device_trust_token');
+        }
+        showMessage('Device no longer trusted', 'success');
+        await loadDevices();
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
+async function removeDevice(deviceId, name, isCurrent) {
+    const warning = isCurrent
+        ? `Remove "${name}"? This is the device you are using, so you will be signed out.`
+        : `Remove "${name}"? It will be signed out.`;
+    if (!confirm(warning)) return;
+
+    try {
+        await api.delete(`/users/me/devices/${encodeURIComponent(deviceId)}`);
+        if (isCurrent) {
+            logout();
+            return;
+        }
+        showMessage('Device removed', 'success');
+        await loadDevices();
+        await loadSessions();
+    } catch (error) {
+        showMessage(getErrorMessage(error), 'error');
+    }
+}
+
 // Load Sessions
 async function loadSessions() {
     const listEl = document.getElementById('sessions-list');
@@ -472,8 +833,10 @@ function renderSessions(sessions) {
     }
 
     listEl.innerHTML = sessions.map(session => {
-        const device = session.device_name || session.browser || 'Unknown Device';
-        const location = session.location_city ? `${session.location_city}, ${session.location_country}` : (session.ip_address || 'Unknown Location');
+        const client = describeClient(session);
+        const place = describeLocation(session);
+        const device = [client || session.device_name || 'Unknown Device', place].filter(Boolean).join(' — ');
+        const location = session.ip_address || 'Unknown Location';
         const lastActive = session.last_used_at || session.last_active_at || session.created_at || '';
         const isCurrent = session.is_current || false;
 
@@ -487,12 +850,83 @@ function renderSessions(sessions) {
                             ${escapeHtml(location)} • Last active: ${formatDate(lastActive)}
                         </p>
                     </div>
+                    <button class="btn btn-secondary btn-sm" onclick="revokeSession('${escapeHtml(session.id)}', ${isCurrent})">Sign Out</button>
                 </div>
             </div>
         `;
     }).join('');
 }
 
+async function revokeSession(sessionId, isCurrent) {
+    const message = isCurrent
+        ? 'This is your current session. Sign out now?'
+        : 'Sign out this session?';
+    if (!confirm(message)) return;
+
+    try {
+        await api.delete(`/users/me/settings/sessions/${sessionId}`);
+        if (isCurrent) {
+            logout();
+            return;
+        }
+        showMessage('Session signed out', 'success');
+        await loadSessions();
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to sign out session';
+        showMessage(errorMsg, 'error');
+    }
+}
+
+const loginEventLabels = {
+    login_success: 'Signed in',
+    login_failure: 'Failed sign-in',
+    two_factor_challenge: 'Two-factor challenge',
+    token_refresh: 'Session refreshed'
+};
+
+const loginMethodLabels = {
+    password: 'password',
+    passkey: 'passkey',
+    magic_link: 'email link',
+    oidc: 'single sign-on',
+    refresh_token: 'refresh token'
+};
+
+async function loadLoginHistory() {
+    const listEl = document.getElementById('login-history-list');
+    if (!listEl) return;
+
+    try {
+        const response = await api.get('/users/me/settings/login-history?limit=50');
+        const events = response.data || [];
+
+        if (events.length === 0) {
+            listEl.innerHTML = '<div class="empty-state"><p>No login history yet</p></div>';
+            return;
+        }
+
+        listEl.innerHTML = events.map(event => {
+            let label = loginEventLabels[event.event_type] || event.event_type;
+            if (event.method && event.event_type !== 'token_refresh') {
+                label += ` with ${loginMethodLabels[event.method] || event.method}`;
+            }
+            const details = [formatDate(event.created_at)];
+            if (describeClient(event)) details.push(describeClient(event));
+            if (describeLocation(event)) details.push(describeLocation(event));
+            if (event.ip_address) details.push(event.ip_address);
+            const failed = event.event_type === 'login_failure';
+            return `
+                <div class="login-history-item" style="padding: 0.75rem 0; border-bottom: 1px solid var(--border);">
+                    <strong style="${failed ? 'color: var(--danger);' : ''}">${escapeHtml(label)}</strong>
+                    <p style="margin: 0.25rem 0 0 0; color: var(--text-light); font-size: 0.85rem;">${escapeHtml(details.join(' · '))}</p>
+                </div>
+            `;
+        }).join('');
+    } catch (error) {
+        listEl.innerHTML = '<div class="empty-state"><p>Failed to load login history</p></div>';
+    }
+}
+
 async function logoutAllDevices() {
     if (!confirm('Are you sure you want to logout from all devices?')) return;
 
@@ -510,21 +944,44 @@ async function logoutAllDevices() {
 
 async function exportData() {
     try {
-        const response = await api.get('/users/me/export');
-        const blob = new Blob([JSON.stringify(response, null, 2)], { type: 'application/json' });
-        const url = URL.createObjectURL(blob);
-        const a = document.createElement('a');
-        a.href = url;
-        a.download = `user-data-${new Date().toISOString()}.json`;
-        a.click();
-        URL.revokeObjectURL(url);
-        showMessage('Data exported successfully', 'success');
-    } catch (error) {
-        const errorMsg = error instanceof Error ? error.message : 'Failed to export data';
+        await api.post('/users/me/exports');
+        showMessage('Your export is being prepared. You will get a notification with a download link when it is ready.', 'success');
+        loadDataExports();
+    } catch (error) {
+        const errorMsg = error instanceof Error ? error.message : 'Failed to request data export';
         showMessage(errorMsg, 'error');
     }
 }
 
+const dataExportStatusLabels = {
+    pending: 'Being prepared',
+    ready: 'Ready (download link in your notifications)',
+    failed: 'Failed',
+    expired: 'Expired',
+};
+
+async function loadDataExports() {
+    const listEl = document.getElementById('data-exports-list');
+    if (!listEl) return;
+
+    try {
+        const response = await api.get('/users/me/exports');
+        const exports = response.data || [];
+        listEl.innerHTML = exports.slice(0, 5).map(exp => {
+            const details = [`Requested ${formatDate(exp.created_at)}`];
+            if (exp.status === 'ready' && exp.expires_at) details.push(`available until ${formatDate(exp.expires_at)}`);
+            return `
+                <div style="padding: 0.5rem 0; border-bottom: 1px solid var(--border);">
+                    <strong>${escapeHtml(dataExportStatusLabels[exp.status] || exp.status)}</strong>
+                    <p style="margin: 0.25rem 0 0 0; color: var(--text-light); font-size: 0.85rem;">${escapeHtml(details.join(', '))}</p>
+                </div>
+            `;
+        }).join('');
+    } catch (error) {
+        listEl.innerHTML = '';
+    }
+}
+
 async function deactivateAccount() {
     if (!confirm('Are you sure you want to deactivate your account? You can reactivate it later.')) return;
 
@@ -548,10 +1005,8 @@ async function deleteAccount() {
     }
 
     try {
-        await api.post('/users/me/settings/account/delete', {
-            days_until_deletion: 7
-        });
-        showMessage('Account deletion requested. You will receive a confirmation email.', 'success');
+        const response = await api.post('/users/me/delete', {});
+        showMessage(response.message || 'Account scheduled for deletion. Sign in again before it is purged to cancel.', 'success');
         setTimeout(() => logout(), 3000);
     } catch (error) {
         const errorMsg = error instanceof Error ? error.message : 'Failed to request account deletion';
@@ -598,7 +1053,11 @@ window.showSettingsSection = showSettingsSection;
 window.updateProfileSettings = updateProfileSettings;
 window.handleProfilePictureChange = handleProfilePictureChange;
 window.changePassword = changePassword;
+window.requestEmailChange = requestEmailChange;
+window.cancelEmailChange = cancelEmailChange;
 window.update2FA = update2FA;
+window.startPhoneVerification = startPhoneVerification;
+window.confirmPhoneVerification = confirmPhoneVerification;
 window.updatePrivacySettings = updatePrivacySettings;
 window.updateNotificationSettings = updateNotificationSettings;
 window.updateAccountPreferences = updateAccountPreferences;
@@ -611,3 +1070,6 @@ window.deleteAccount = deleteAccount;
 window.showHelp = showHelp;
 window.contactSupport = contactSupport;
 window.reportProblem = reportProblem;
+window.renameDevice = renameDevice;
+window.untrustDevice = untrustDevice;
+window.removeDevice = removeDevice;